@@ -0,0 +1,356 @@
+package cdp
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// chromeNames are the executables FindChrome looks for, in order
+var chromeNames = []string{
+	"google-chrome", "google-chrome-stable", "chromium", "chromium-browser", "chrome",
+	"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+}
+
+// FindChrome returns the path of an installed Chrome or Chromium
+func FindChrome() (string, error) {
+	for _, name := range chromeNames {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no Chrome or Chromium executable found in PATH")
+}
+
+// Browser is a connection to a browser, launched by Launch or reached by
+// Connect
+type Browser struct {
+	*Conn
+	cmd     *exec.Cmd // Nil when connected to a remote browser
+	dataDir string
+	once    sync.Once
+}
+
+// Launch starts a headless Chrome with a fresh profile and connects to it.
+// The context bounds the start only; the browser runs until Close.
+func Launch(ctx context.Context, chromePath string, extraArgs []string) (*Browser, error) {
+	dataDir, err := os.MkdirTemp("", "mcp-chrome-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the profile directory: %w", err)
+	}
+	args := []string{
+		"--headless=new",
+		"--remote-debugging-port=0",
+		"--user-data-dir=" + dataDir,
+		"--no-first-run",
+		"--no-default-browser-check",
+		"--disable-gpu",
+		"--disable-extensions",
+		"--disable-background-networking",
+		"--disable-sync",
+		"--mute-audio",
+		"--hide-scrollbars",
+		"--window-size=1280,800",
+	}
+	args = append(args, extraArgs...)
+	args = append(args, "about:blank")
+	cmd := exec.Command(chromePath, args...)
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		os.RemoveAll(dataDir)
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dataDir)
+		return nil, fmt.Errorf("failed to start %s: %w", chromePath, err)
+	}
+	b := &Browser{cmd: cmd, dataDir: dataDir}
+
+	// Chrome prints the URL of its DevTools endpoint on stderr once ready,
+	// or the reason it failed before exiting
+	type startup struct{ wsURL, output string }
+	started := make(chan startup, 1)
+	go func() {
+		var output []string
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if rest, ok := strings.CutPrefix(line, "DevTools listening on "); ok {
+				started <- startup{wsURL: strings.TrimSpace(rest)}
+				io.Copy(io.Discard, stderr)
+				return
+			}
+			if len(output) < 20 {
+				output = append(output, line)
+			}
+		}
+		started <- startup{output: strings.Join(output, "\n")}
+	}()
+
+	var wsURL string
+	select {
+	case s := <-started:
+		if s.wsURL == "" {
+			b.Close()
+			return nil, fmt.Errorf("browser exited on start: %s", s.output)
+		}
+		wsURL = s.wsURL
+	case <-ctx.Done():
+		b.Close()
+		return nil, fmt.Errorf("browser did not start: %w", ctx.Err())
+	}
+	if b.Conn, err = Dial(ctx, wsURL); err != nil {
+		b.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// Connect connects to a running browser, by its ws:// DevTools URL or the
+// http:// address of its remote debugging port
+func Connect(ctx context.Context, address string) (*Browser, error) {
+	if strings.HasPrefix(address, "http://") || strings.HasPrefix(address, "https://") {
+		wsURL, err := debuggerURL(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+		address = wsURL
+	}
+	conn, err := Dial(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	return &Browser{Conn: conn}, nil
+}
+
+// debuggerURL asks the remote debugging port for the browser DevTools URL
+func debuggerURL(ctx context.Context, address string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimSuffix(address, "/")+"/json/version", nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid browser address: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach the browser: %w", err)
+	}
+	defer resp.Body.Close()
+	var version struct {
+		WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&version); err != nil || version.WebSocketDebuggerURL == "" {
+		return "", fmt.Errorf("%s did not return a DevTools URL (status %d)", address, resp.StatusCode)
+	}
+	// The browser reports its own view of the host, which is wrong behind a
+	// port mapping
+	wsURL, err := url.Parse(version.WebSocketDebuggerURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid DevTools URL: %w", err)
+	}
+	if base, err := url.Parse(address); err == nil {
+		wsURL.Host = base.Host
+	}
+	return wsURL.String(), nil
+}
+
+// Close disconnects from the browser and, when launched, stops it and
+// removes its profile
+func (b *Browser) Close() error {
+	b.once.Do(func() {
+		if b.Conn != nil {
+			b.Conn.Close()
+		}
+		if b.cmd != nil {
+			b.cmd.Process.Kill()
+			b.cmd.Wait()
+			os.RemoveAll(b.dataDir)
+		}
+	})
+	return nil
+}
+
+// Page is a browser tab attached to the connection of its browser
+type Page struct {
+	conn      *Conn
+	TargetID  string
+	SessionID string
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewPage opens a blank tab
+func (b *Browser) NewPage(ctx context.Context) (*Page, error) {
+	var target struct {
+		TargetID string `json:"targetId"`
+	}
+	if err := b.Call(ctx, "", "Target.createTarget", map[string]interface{}{"url": "about:blank"}, &target); err != nil {
+		return nil, err
+	}
+	var session struct {
+		SessionID string `json:"sessionId"`
+	}
+	params := map[string]interface{}{"targetId": target.TargetID, "flatten": true}
+	if err := b.Call(ctx, "", "Target.attachToTarget", params, &session); err != nil {
+		b.Call(ctx, "", "Target.closeTarget", map[string]interface{}{"targetId": target.TargetID}, nil)
+		return nil, err
+	}
+	p := &Page{conn: b.Conn, TargetID: target.TargetID, SessionID: session.SessionID, done: make(chan struct{})}
+	if err := p.call(ctx, "Page.enable", nil, nil); err != nil {
+		p.Close(ctx)
+		return nil, err
+	}
+	return p, nil
+}
+
+// call invokes a method in the session of the page
+func (p *Page) call(ctx context.Context, method string, params, result interface{}) error {
+	return p.conn.Call(ctx, p.SessionID, method, params, result)
+}
+
+// FilterDocuments makes the page load a document, of the page or of a frame,
+// only when allow accepts its URL; other documents fail to load as blocked.
+// Subresources such as scripts and images are not filtered.
+func (p *Page) FilterDocuments(ctx context.Context, allow func(*url.URL) bool) error {
+	events, unsubscribe := p.conn.Subscribe(p.SessionID, "Fetch.requestPaused")
+	params := map[string]interface{}{
+		"patterns": []map[string]interface{}{{"urlPattern": "*", "resourceType": "Document", "requestStage": "Request"}},
+	}
+	if err := p.call(ctx, "Fetch.enable", params, nil); err != nil {
+		unsubscribe()
+		return err
+	}
+	go func() {
+		defer unsubscribe()
+		for {
+			select {
+			case raw := <-events:
+				var event struct {
+					RequestID string `json:"requestId"`
+					Request   struct {
+						URL string `json:"url"`
+					} `json:"request"`
+				}
+				if json.Unmarshal(raw, &event) != nil {
+					continue
+				}
+				// A paused request stalls its page until answered, so answer
+				// without the caller's context
+				answerCtx := context.Background()
+				if u, err := url.Parse(event.Request.URL); err == nil && allow(u) {
+					p.call(answerCtx, "Fetch.continueRequest", map[string]interface{}{"requestId": event.RequestID}, nil)
+				} else {
+					p.call(answerCtx, "Fetch.failRequest", map[string]interface{}{"requestId": event.RequestID, "errorReason": "BlockedByClient"}, nil)
+				}
+			case <-p.done:
+				return
+			case <-p.conn.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Navigate loads a URL and waits for its load event
+func (p *Page) Navigate(ctx context.Context, rawURL string) error {
+	loaded, unsubscribe := p.conn.Subscribe(p.SessionID, "Page.loadEventFired")
+	defer unsubscribe()
+	var result struct {
+		LoaderID  string `json:"loaderId"`
+		ErrorText string `json:"errorText"`
+	}
+	if err := p.call(ctx, "Page.navigate", map[string]interface{}{"url": rawURL}, &result); err != nil {
+		return err
+	}
+	if result.ErrorText != "" {
+		return fmt.Errorf("failed to load %s: %s", rawURL, result.ErrorText)
+	}
+	if result.LoaderID == "" {
+		return nil // A navigation within the document, such as to a fragment
+	}
+	select {
+	case <-loaded:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("page did not finish loading: %w", ctx.Err())
+	case <-p.conn.Done():
+		return ErrClosed
+	}
+}
+
+// Evaluate runs a JavaScript expression in the page, awaiting it when it
+// returns a promise, and decodes its JSON-serializable value into result
+func (p *Page) Evaluate(ctx context.Context, expression string, result interface{}) error {
+	var response struct {
+		Result struct {
+			Type  string          `json:"type"`
+			Value json.RawMessage `json:"value"`
+		} `json:"result"`
+		ExceptionDetails *struct {
+			Text      string `json:"text"`
+			Exception struct {
+				Description string `json:"description"`
+			} `json:"exception"`
+		} `json:"exceptionDetails"`
+	}
+	params := map[string]interface{}{"expression": expression, "returnByValue": true, "awaitPromise": true}
+	if err := p.call(ctx, "Runtime.evaluate", params, &response); err != nil {
+		return err
+	}
+	if e := response.ExceptionDetails; e != nil {
+		if e.Exception.Description != "" {
+			return fmt.Errorf("script failed: %s", e.Exception.Description)
+		}
+		return fmt.Errorf("script failed: %s", e.Text)
+	}
+	if result == nil || len(response.Result.Value) == 0 {
+		return nil
+	}
+	return json.Unmarshal(response.Result.Value, result)
+}
+
+// Screenshot captures the viewport, or the whole page when fullPage is set,
+// as a PNG image
+func (p *Page) Screenshot(ctx context.Context, fullPage bool) ([]byte, error) {
+	params := map[string]interface{}{"format": "png"}
+	if fullPage {
+		var metrics struct {
+			ContentSize    struct{ Width, Height float64 }  `json:"contentSize"`
+			CSSContentSize *struct{ Width, Height float64 } `json:"cssContentSize"`
+		}
+		if err := p.call(ctx, "Page.getLayoutMetrics", nil, &metrics); err != nil {
+			return nil, err
+		}
+		size := metrics.ContentSize
+		if metrics.CSSContentSize != nil {
+			size = *metrics.CSSContentSize
+		}
+		params["captureBeyondViewport"] = true
+		params["clip"] = map[string]interface{}{"x": 0, "y": 0, "width": size.Width, "height": size.Height, "scale": 1}
+	}
+	var result struct {
+		Data string `json:"data"`
+	}
+	if err := p.call(ctx, "Page.captureScreenshot", params, &result); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(result.Data)
+}
+
+// Close closes the tab
+func (p *Page) Close(ctx context.Context) error {
+	var err error
+	p.closeOnce.Do(func() {
+		close(p.done)
+		err = p.conn.Call(ctx, "", "Target.closeTarget", map[string]interface{}{"targetId": p.TargetID}, nil)
+	})
+	return err
+}
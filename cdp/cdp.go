@@ -0,0 +1,209 @@
+// Package cdp implements a small, dependency-free client of the Chrome
+// DevTools Protocol: enough to launch a headless Chrome, open pages, navigate
+// them, evaluate JavaScript and take screenshots.
+//
+// A Conn is one WebSocket connection to the browser. Pages are attached in
+// flat mode, so the commands and events of every page share the connection
+// and are told apart by their session ID.
+package cdp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrClosed is returned by calls on a closed or broken connection
+var ErrClosed = errors.New("cdp: connection closed")
+
+// Error is an error returned by a DevTools protocol method
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data"`
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.Data != "" {
+		return fmt.Sprintf("%s (%s)", e.Message, e.Data)
+	}
+	return e.Message
+}
+
+// message is a command, response or event of the protocol
+type message struct {
+	ID        int64           `json:"id,omitempty"`
+	SessionID string          `json:"sessionId,omitempty"`
+	Method    string          `json:"method,omitempty"`
+	Params    interface{}     `json:"params,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     *Error          `json:"error,omitempty"`
+}
+
+// incoming is a response or event as received, with raw params
+type incoming struct {
+	ID        int64           `json:"id"`
+	SessionID string          `json:"sessionId"`
+	Method    string          `json:"method"`
+	Params    json.RawMessage `json:"params"`
+	Result    json.RawMessage `json:"result"`
+	Error     *Error          `json:"error"`
+}
+
+// subscription receives the events of one method of one session
+type subscription struct {
+	sessionID string
+	method    string
+	events    chan json.RawMessage
+}
+
+// Conn is a connection to the DevTools endpoint of a browser
+type Conn struct {
+	ws *wsConn
+
+	mu            sync.Mutex
+	nextID        int64
+	pending       map[int64]chan incoming
+	subscriptions map[*subscription]bool
+	closed        chan struct{}
+}
+
+// Dial connects to the browser DevTools WebSocket URL, such as the
+// "ws://127.0.0.1:9222/devtools/browser/..." URL Chrome prints on start
+func Dial(ctx context.Context, url string) (*Conn, error) {
+	ws, err := dialWebSocket(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", url, err)
+	}
+	c := &Conn{
+		ws:            ws,
+		pending:       map[int64]chan incoming{},
+		subscriptions: map[*subscription]bool{},
+		closed:        make(chan struct{}),
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+// readLoop dispatches responses to their callers and events to their
+// subscribers until the connection fails
+func (c *Conn) readLoop() {
+	for {
+		data, err := c.ws.ReadMessage()
+		if err != nil {
+			c.Close()
+			return
+		}
+		var msg incoming
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		c.mu.Lock()
+		if msg.ID != 0 {
+			if ch, ok := c.pending[msg.ID]; ok {
+				delete(c.pending, msg.ID)
+				ch <- msg
+			}
+		} else if msg.Method != "" {
+			for sub := range c.subscriptions {
+				if sub.method == msg.Method && sub.sessionID == msg.SessionID {
+					// Slow subscribers miss events rather than stall the
+					// connection
+					select {
+					case sub.events <- msg.Params:
+					default:
+					}
+				}
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+// Done returns a channel closed when the connection closes
+func (c *Conn) Done() <-chan struct{} {
+	return c.closed
+}
+
+// Close closes the connection, failing the pending calls with ErrClosed
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.closed:
+		return nil
+	default:
+	}
+	close(c.closed)
+	return c.ws.Close()
+}
+
+// Call invokes a protocol method, in the session of a page or, with an empty
+// sessionID, of the browser, and decodes its result into result when not
+// nil
+func (c *Conn) Call(ctx context.Context, sessionID, method string, params, result interface{}) error {
+	ch := make(chan incoming, 1)
+	c.mu.Lock()
+	select {
+	case <-c.closed:
+		c.mu.Unlock()
+		return ErrClosed
+	default:
+	}
+	c.nextID++
+	id := c.nextID
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	if params == nil {
+		params = struct{}{}
+	}
+	data, err := json.Marshal(message{ID: id, SessionID: sessionID, Method: method, Params: params})
+	if err == nil {
+		err = c.ws.WriteMessage(data)
+	}
+	if err != nil {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return fmt.Errorf("%s: %w", method, err)
+	}
+
+	select {
+	case msg := <-ch:
+		if msg.Error != nil {
+			return fmt.Errorf("%s: %w", method, msg.Error)
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				return fmt.Errorf("%s: invalid result: %w", method, err)
+			}
+		}
+		return nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return ctx.Err()
+	case <-c.closed:
+		return ErrClosed
+	}
+}
+
+// Subscribe returns a channel receiving the params of the events of method
+// in a session, and a function ending the subscription. Events arriving
+// while the channel is full are dropped.
+func (c *Conn) Subscribe(sessionID, method string) (<-chan json.RawMessage, func()) {
+	sub := &subscription{sessionID: sessionID, method: method, events: make(chan json.RawMessage, 64)}
+	c.mu.Lock()
+	c.subscriptions[sub] = true
+	c.mu.Unlock()
+	return sub.events, func() {
+		c.mu.Lock()
+		delete(c.subscriptions, sub)
+		c.mu.Unlock()
+	}
+}
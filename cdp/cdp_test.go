@@ -0,0 +1,184 @@
+package cdp
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeBrowser serves the DevTools protocol over WebSocket, answering the
+// methods the page helpers use
+func fakeBrowser(t *testing.T, handle func(method string, params json.RawMessage) (interface{}, *Error, []incoming)) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			http.Error(w, "not a WebSocket request", http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + acceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		rw.Flush()
+		reader := bufio.NewReader(rw)
+		for {
+			_, opcode, payload, err := readFrame(reader)
+			if err != nil || opcode == opClose {
+				return
+			}
+			var msg incoming
+			json.Unmarshal(payload, &msg)
+			result, callErr, events := handle(msg.Method, msg.Params)
+			for _, event := range events {
+				event.SessionID = msg.SessionID
+				data, _ := json.Marshal(event)
+				writeFrame(conn, opText, data, false)
+			}
+			data, _ := json.Marshal(map[string]interface{}{"id": msg.ID, "sessionId": msg.SessionID, "result": result, "error": callErr})
+			writeFrame(conn, opText, data, false)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http") + "/devtools/browser/fake"
+}
+
+func TestPage(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n")
+	var clip map[string]interface{}
+	wsURL := fakeBrowser(t, func(method string, params json.RawMessage) (interface{}, *Error, []incoming) {
+		switch method {
+		case "Target.createTarget":
+			return map[string]string{"targetId": "T1"}, nil, nil
+		case "Target.attachToTarget":
+			return map[string]string{"sessionId": "S1"}, nil, nil
+		case "Page.enable", "Target.closeTarget":
+			return map[string]string{}, nil, nil
+		case "Page.navigate":
+			var p struct{ URL string }
+			json.Unmarshal(params, &p)
+			if strings.Contains(p.URL, "blocked") {
+				return map[string]string{"frameId": "F1", "errorText": "net::ERR_BLOCKED_BY_CLIENT"}, nil, nil
+			}
+			// The load event arrives before the response here, which the
+			// subscription taken before the call must not miss
+			return map[string]string{"frameId": "F1", "loaderId": "L1"}, nil, []incoming{{Method: "Page.loadEventFired", Params: json.RawMessage(`{"timestamp":1}`)}}
+		case "Runtime.evaluate":
+			var p struct{ Expression string }
+			json.Unmarshal(params, &p)
+			if p.Expression == "boom()" {
+				return map[string]interface{}{"result": map[string]string{"type": "object"},
+					"exceptionDetails": map[string]interface{}{"text": "Uncaught", "exception": map[string]string{"description": "ReferenceError: boom is not defined"}}}, nil, nil
+			}
+			return map[string]interface{}{"result": map[string]string{"type": "string", "value": "Example Domain"}}, nil, nil
+		case "Page.getLayoutMetrics":
+			return map[string]interface{}{"cssContentSize": map[string]float64{"x": 0, "y": 0, "width": 1280, "height": 4000}}, nil, nil
+		case "Page.captureScreenshot":
+			var p map[string]interface{}
+			json.Unmarshal(params, &p)
+			clip, _ = p["clip"].(map[string]interface{})
+			return map[string]string{"data": base64.StdEncoding.EncodeToString(png)}, nil, nil
+		}
+		return nil, &Error{Code: -32601, Message: "'" + method + "' wasn't found"}, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	browser, err := Connect(ctx, wsURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer browser.Close()
+	page, err := browser.NewPage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.TargetID != "T1" || page.SessionID != "S1" {
+		t.Errorf("Unexpected page %+v", page)
+	}
+
+	if err := page.Navigate(ctx, "https://example.com/"); err != nil {
+		t.Errorf("Navigate failed: %v", err)
+	}
+	if err := page.Navigate(ctx, "https://blocked.example/"); err == nil || !strings.Contains(err.Error(), "ERR_BLOCKED_BY_CLIENT") {
+		t.Errorf("Expected the navigation error, got %v", err)
+	}
+	var title string
+	if err := page.Evaluate(ctx, "document.title", &title); err != nil || title != "Example Domain" {
+		t.Errorf("Evaluate = %q, %v", title, err)
+	}
+	if err := page.Evaluate(ctx, "boom()", nil); err == nil || !strings.Contains(err.Error(), "ReferenceError") {
+		t.Errorf("Expected the script's exception, got %v", err)
+	}
+	data, err := page.Screenshot(ctx, true)
+	if err != nil || string(data) != string(png) {
+		t.Errorf("Screenshot = %q, %v", data, err)
+	}
+	if clip["height"] != 4000.0 {
+		t.Errorf("Expected the full page to be clipped, got %v", clip)
+	}
+	if err := browser.Call(ctx, "", "Browser.crash", nil, nil); err == nil || !strings.Contains(err.Error(), "wasn't found") {
+		t.Errorf("Expected the protocol error, got %v", err)
+	}
+	if err := page.Close(ctx); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+
+	browser.Close()
+	if err := browser.Call(ctx, "", "Target.getTargets", nil, nil); err != ErrClosed {
+		t.Errorf("Expected ErrClosed after Close, got %v", err)
+	}
+}
+
+func TestFilterDocuments(t *testing.T) {
+	answers := make(chan string, 2)
+	wsURL := fakeBrowser(t, func(method string, params json.RawMessage) (interface{}, *Error, []incoming) {
+		switch method {
+		case "Fetch.enable":
+			// Chrome pauses the requests of documents from then on
+			return map[string]string{}, nil, []incoming{
+				{Method: "Fetch.requestPaused", Params: json.RawMessage(`{"requestId":"R1","request":{"url":"https://docs.example.com/"}}`)},
+				{Method: "Fetch.requestPaused", Params: json.RawMessage(`{"requestId":"R2","request":{"url":"https://evil.example/"}}`)},
+			}
+		case "Fetch.continueRequest", "Fetch.failRequest":
+			var p struct{ RequestID string }
+			json.Unmarshal(params, &p)
+			answers <- method + " " + p.RequestID
+		}
+		return map[string]string{}, nil, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	browser, err := Connect(ctx, wsURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer browser.Close()
+	page := &Page{conn: browser.Conn, TargetID: "T1", SessionID: "S1", done: make(chan struct{})}
+	allow := func(u *url.URL) bool { return u.Hostname() == "docs.example.com" }
+	if err := page.FilterDocuments(ctx, allow); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case answer := <-answers:
+			got[answer] = true
+		case <-ctx.Done():
+			t.Fatalf("Paused requests were not answered, got %v", got)
+		}
+	}
+	if !got["Fetch.continueRequest R1"] || !got["Fetch.failRequest R2"] {
+		t.Errorf("Unexpected answers %v", got)
+	}
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultAuthRefresh is how long a token printed by an auth command is
+// reused when the server does not set authRefreshSeconds
+const defaultAuthRefresh = 5 * time.Minute
+
+// commandToken runs an external command, such as
+// "gcloud auth print-identity-token", whose output is a bearer token, and
+// caches the token for the refresh interval
+type commandToken struct {
+	args    []string
+	refresh time.Duration
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// newCommandToken creates a token source for args, refreshed every refresh
+// (defaultAuthRefresh when zero)
+func newCommandToken(args []string, refresh time.Duration) *commandToken {
+	if refresh <= 0 {
+		refresh = defaultAuthRefresh
+	}
+	return &commandToken{args: args, refresh: refresh}
+}
+
+// Authorization returns the Authorization header value, running the command
+// when the cached token is missing or expired
+func (c *commandToken) Authorization(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Before(c.expires) {
+		return "Bearer " + c.token, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.args[0], c.args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%v: %s", err, msg)
+		}
+		return "", fmt.Errorf("auth command %s failed: %w", c.args[0], err)
+	}
+
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return "", fmt.Errorf("auth command %s printed no token", c.args[0])
+	}
+	c.token = token
+	c.expires = time.Now().Add(c.refresh)
+	return "Bearer " + token, nil
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"mcp-go/config"
 	"mcp-go/transport"
+	"strings"
 	"sync"
+	"time"
 )
 
 // Client represents an MCP client that can connect to remote MCP servers
@@ -19,6 +21,18 @@ type Client interface {
 	// CallTool executes a tool with the given arguments
 	CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*transport.ToolResponse, error)
 
+	// ListResources returns all available resources
+	ListResources(ctx context.Context) ([]transport.Resource, error)
+
+	// ReadResource reads the contents of a resource
+	ReadResource(ctx context.Context, uri string) ([]transport.ResourceContent, error)
+
+	// ListPrompts returns all available prompts
+	ListPrompts(ctx context.Context) ([]transport.Prompt, error)
+
+	// GetPrompt renders a prompt with the given arguments
+	GetPrompt(ctx context.Context, name string, arguments map[string]string) (*transport.PromptResult, error)
+
 	// Close closes the client connection
 	Close() error
 
@@ -27,6 +41,16 @@ type Client interface {
 
 	// GetPrefix returns the tool name prefix
 	GetPrefix() string
+
+	// GetConfig returns the configuration the client was created from
+	GetConfig() config.MCPConfig
+
+	// IsInitialized reports whether the MCP server has been initialized
+	IsInitialized() bool
+
+	// Capabilities returns the capabilities the MCP server advertised when it
+	// was initialized, or nil before initialization
+	Capabilities() map[string]interface{}
 }
 
 // MCPClient implements the Client interface
@@ -41,16 +65,38 @@ type MCPClient struct {
 func NewClient(cfg config.MCPConfig) (Client, error) {
 	var t transport.Transport
 
-	switch cfg.Transport {
-	case "http", "":
-		t = transport.NewHTTPTransport(cfg.URL)
+	opts := transport.Options{
+		Timeout:          time.Duration(cfg.TimeoutSeconds) * time.Second,
+		Retries:          cfg.Retries,
+		MaxConcurrent:    cfg.MaxConcurrent,
+		MaxResponseBytes: cfg.MaxResponseBytes,
+	}
+	if cfg.TLS.Configured() {
+		tlsConfig, err := transport.NewTLSConfig(cfg.TLS.CAFile, cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.InsecureSkipVerify)
+		if err != nil {
+			return nil, err
+		}
+		opts.TLS = tlsConfig
+	}
+
+	switch cfg.TransportName() {
+	case "http":
+		httpTransport := transport.NewHTTPTransport(cfg.URL)
+		httpTransport.SetOptions(opts)
 		// Set auth headers if provided
-		if cfg.Auth != nil {
-			httpTransport := t.(*transport.HTTPTransport)
-			for key, value := range cfg.Auth {
-				httpTransport.SetHeader(key, value)
-			}
+		for key, value := range cfg.Auth {
+			httpTransport.SetHeader(key, value)
+		}
+		// A token printed by an external command takes the Authorization header
+		if len(cfg.AuthCommand) > 0 {
+			token := newCommandToken(cfg.AuthCommand, time.Duration(cfg.AuthRefreshSeconds)*time.Second)
+			httpTransport.SetHeaderFunc("Authorization", token.Authorization)
 		}
+		t = httpTransport
+	case "stdio":
+		stdioTransport := transport.NewStdioTransport(cfg.Command, cfg.Args, cfg.Env, cfg.Cwd)
+		stdioTransport.SetOptions(opts)
+		t = stdioTransport
 	default:
 		return nil, fmt.Errorf("unsupported transport: %s", cfg.Transport)
 	}
@@ -61,6 +107,15 @@ func NewClient(cfg config.MCPConfig) (Client, error) {
 	}, nil
 }
 
+// NewClientWithTransport creates a client that reaches the MCP server over t
+// instead of the transport cfg describes, such as an in-memory one in tests
+func NewClientWithTransport(cfg config.MCPConfig, t transport.Transport) Client {
+	return &MCPClient{
+		config:    cfg,
+		transport: t,
+	}
+}
+
 // Initialize connects and initializes the MCP server
 func (c *MCPClient) Initialize(ctx context.Context) error {
 	c.mu.Lock()
@@ -110,6 +165,15 @@ func (c *MCPClient) ListTools(ctx context.Context) ([]transport.Tool, error) {
 		return nil, fmt.Errorf("failed to list tools from %s: %w", c.config.Name, err)
 	}
 
+	// Drop the tools trimmed by the include/exclude patterns
+	allowed := tools[:0]
+	for _, tool := range tools {
+		if c.config.Tools.Allows(tool.Name) {
+			allowed = append(allowed, tool)
+		}
+	}
+	tools = allowed
+
 	// Apply prefix to tool names if configured
 	if c.config.Prefix != "" {
 		for i := range tools {
@@ -138,6 +202,11 @@ func (c *MCPClient) CallTool(ctx context.Context, name string, arguments map[str
 		}
 	}
 
+	// Tools trimmed from the catalog cannot be called either
+	if !c.config.Tools.Allows(actualName) {
+		return nil, fmt.Errorf("tool '%s' not found", name)
+	}
+
 	resp, err := c.transport.CallTool(ctx, actualName, arguments)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call tool %s on %s: %w", name, c.config.Name, err)
@@ -146,6 +215,106 @@ func (c *MCPClient) CallTool(ctx context.Context, name string, arguments map[str
 	return resp, nil
 }
 
+// ListResources returns all available resources
+func (c *MCPClient) ListResources(ctx context.Context) ([]transport.Resource, error) {
+	// Lazy initialization - initialize if not already done
+	if err := c.ensureInitialized(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	resources, err := c.transport.ListResources(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resources from %s: %w", c.config.Name, err)
+	}
+
+	// Apply prefix to resource URIs and names, like tool names
+	if c.config.Prefix != "" {
+		for i := range resources {
+			resources[i].URI = c.config.Prefix + resources[i].URI
+			resources[i].Name = c.config.Prefix + resources[i].Name
+		}
+	}
+
+	return resources, nil
+}
+
+// ReadResource reads the contents of a resource
+func (c *MCPClient) ReadResource(ctx context.Context, uri string) ([]transport.ResourceContent, error) {
+	// Lazy initialization - initialize if not already done
+	if err := c.ensureInitialized(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// Remove prefix if present
+	actualURI := strings.TrimPrefix(uri, c.config.Prefix)
+
+	contents, err := c.transport.ReadResource(ctx, actualURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource %s on %s: %w", uri, c.config.Name, err)
+	}
+
+	// Report URIs in the gateway's namespace
+	if c.config.Prefix != "" {
+		for i := range contents {
+			contents[i].URI = c.config.Prefix + contents[i].URI
+		}
+	}
+
+	return contents, nil
+}
+
+// ListPrompts returns all available prompts
+func (c *MCPClient) ListPrompts(ctx context.Context) ([]transport.Prompt, error) {
+	// Lazy initialization - initialize if not already done
+	if err := c.ensureInitialized(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	prompts, err := c.transport.ListPrompts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompts from %s: %w", c.config.Name, err)
+	}
+
+	// Apply prefix to prompt names, like tool names
+	if c.config.Prefix != "" {
+		for i := range prompts {
+			prompts[i].Name = c.config.Prefix + prompts[i].Name
+		}
+	}
+
+	return prompts, nil
+}
+
+// GetPrompt renders a prompt with the given arguments
+func (c *MCPClient) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*transport.PromptResult, error) {
+	// Lazy initialization - initialize if not already done
+	if err := c.ensureInitialized(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// Remove prefix if present
+	actualName := strings.TrimPrefix(name, c.config.Prefix)
+
+	result, err := c.transport.GetPrompt(ctx, actualName, arguments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt %s on %s: %w", name, c.config.Name, err)
+	}
+
+	return result, nil
+}
+
 // Close closes the client connection
 func (c *MCPClient) Close() error {
 	c.mu.Lock()
@@ -166,3 +335,26 @@ func (c *MCPClient) GetName() string {
 func (c *MCPClient) GetPrefix() string {
 	return c.config.Prefix
 }
+
+// GetConfig returns the configuration the client was created from
+func (c *MCPClient) GetConfig() config.MCPConfig {
+	return c.config
+}
+
+// IsInitialized reports whether the MCP server has been initialized
+func (c *MCPClient) IsInitialized() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.initialized
+}
+
+// Capabilities returns the capabilities the MCP server advertised when it
+// was initialized, or nil before initialization
+func (c *MCPClient) Capabilities() map[string]interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.initialized {
+		return nil
+	}
+	return c.transport.Capabilities()
+}
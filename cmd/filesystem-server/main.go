@@ -2,38 +2,128 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
-	"mcp-go/server"
+	"mcp-go/config"
 	"mcp-go/tools"
+	"mcp-go/transport"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 )
 
+// stringList is a repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
 func main() {
+	configPath := flag.String("config", "", "configuration file holding the filesystem section (default: first of "+strings.Join(config.DefaultConfigFiles, ", ")+" if present)")
+	addr := flag.String("addr", ":3335", "listen address")
+	var allowedPaths stringList
+	flag.Var(&allowedPaths, "allowed-path", "directory the tools may access (repeatable, added to filesystem.allowedPaths)")
+	readOnly := flag.Bool("read-only", false, "hide and reject the tools that change the filesystem (or filesystem.readOnly)")
+	maxReadBytes := flag.Int64("max-read-bytes", 0, "truncate reads beyond this many bytes (default: filesystem.maxReadBytes or 10 MiB)")
+	maxWriteBytes := flag.Int64("max-write-bytes", 0, "reject writes beyond this many bytes (default: filesystem.maxWriteBytes or 10 MiB)")
+	flag.Parse()
+
+	// The filesystem section of the gateway configuration applies here too
+	fsConfig, err := loadFilesystemConfig(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fsConfig.AllowedPaths = append(fsConfig.AllowedPaths, allowedPaths...)
+	if len(fsConfig.AllowedPaths) == 0 {
+		// Never expose the whole filesystem by default
+		wd, err := os.Getwd()
+		if err != nil {
+			log.Fatalf("No allowed paths configured and the working directory is unknown: %v", err)
+		}
+		log.Printf("Warning: no allowed paths configured; restricting the filesystem tools to the working directory (use --allowed-path %s to allow everything)", string(filepath.Separator))
+		fsConfig.AllowedPaths = []string{wd}
+	}
+	if err := tools.SetAllowedPaths(fsConfig.AllowedPaths); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Filesystem tools restricted to: %s", strings.Join(tools.GetAllowedPaths(), ", "))
+	if *maxReadBytes > 0 {
+		fsConfig.MaxReadBytes = *maxReadBytes
+	}
+	if *maxWriteBytes > 0 {
+		fsConfig.MaxWriteBytes = *maxWriteBytes
+	}
+	tools.SetSizeLimits(fsConfig.MaxReadBytes, fsConfig.MaxWriteBytes)
+	if *readOnly || fsConfig.ReadOnly {
+		tools.SetReadOnly(true)
+		log.Println("Read-only mode: tools that change the filesystem are disabled")
+	}
+
 	// Create a simple server for filesystem operations
 	srv := NewFileSystemServer()
+	tools.SetWatchNotifier(srv.notifyFileEvent)
 
 	http.HandleFunc("/initialize", handleInitialize)
 	http.HandleFunc("/tools/list", srv.handleToolsList)
 	http.HandleFunc("/tools/call", srv.handleToolsCall)
+	http.HandleFunc("/events", srv.handleEvents)
 
-	port := ":3335"
-	log.Printf("FileSystem MCP Server starting on port %s\n", port)
+	log.Printf("FileSystem MCP Server starting on %s\n", *addr)
 	log.Println("Endpoints available:")
 	log.Println("  GET  /initialize")
 	log.Println("  GET  /tools/list")
 	log.Println("  POST /tools/call")
+	log.Println("  GET  /events")
 
-	if err := http.ListenAndServe(port, nil); err != nil {
+	if err := http.ListenAndServe(*addr, nil); err != nil {
 		log.Fatalf("Server failed to start: %v\n", err)
 	}
 }
 
+// loadFilesystemConfig returns the filesystem section of the configuration
+// file at path. Without a path the default files are tried and a missing file
+// is not an error.
+func loadFilesystemConfig(path string) (config.FilesystemConfig, error) {
+	if path == "" {
+		path = config.FindConfigFile()
+		if _, err := os.Stat(path); err != nil {
+			return config.FilesystemConfig{}, nil
+		}
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		return config.FilesystemConfig{}, err
+	}
+	return cfg.Filesystem, nil
+}
+
 // FileSystemServer handles filesystem MCP operations
-type FileSystemServer struct{}
+type FileSystemServer struct {
+	registry *tools.Registry
+	// streams are the open /events streams
+	streams   map[chan []byte]bool
+	streamsMu sync.Mutex
+}
 
 func NewFileSystemServer() *FileSystemServer {
-	return &FileSystemServer{}
+	return &FileSystemServer{
+		registry: tools.FilesystemRegistry,
+		streams:  make(map[chan []byte]bool),
+	}
+}
+
+// toolCallRequest is the body of POST /tools/call
+type toolCallRequest struct {
+	Name      string                 `json:"name"`
+	Arguments map[string]interface{} `json:"arguments"`
 }
 
 // handleInitialize handles GET /initialize
@@ -43,12 +133,12 @@ func handleInitialize(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := server.InitializeResponse{
+	response := transport.InitializeResponse{
 		ProtocolVersion: "2024-11-05",
 		Capabilities: map[string]interface{}{
 			"tools": true,
 		},
-		ServerInfo: server.ServerInfo{
+		ServerInfo: transport.ServerInfo{
 			Name:    "filesystem-mcp",
 			Version: "0.1.0",
 		},
@@ -68,30 +158,19 @@ func (s *FileSystemServer) handleToolsList(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	var allTools []interface{}
-
-	// Add filesystem tools with "filesystem:" prefix
-	readFileTool := tools.GetReadFileTool()
-	readFileTool.Name = "filesystem:read_file"
-	allTools = append(allTools, readFileTool)
-
-	writeFileTool := tools.GetWriteFileTool()
-	writeFileTool.Name = "filesystem:write_file"
-	allTools = append(allTools, writeFileTool)
-
-	listDirTool := tools.GetListDirectoryTool()
-	listDirTool.Name = "filesystem:list_directory"
-	allTools = append(allTools, listDirTool)
-
-	createDirTool := tools.GetCreateDirectoryTool()
-	createDirTool.Name = "filesystem:create_directory"
-	allTools = append(allTools, createDirTool)
-
-	deleteFileTool := tools.GetDeleteFileTool()
-	deleteFileTool.Name = "filesystem:delete_file"
-	allTools = append(allTools, deleteFileTool)
+	// Tool names are unprefixed; the gateway adds the configured
+	// "filesystem:" prefix. Read-only mode hides the tools that change the
+	// filesystem.
+	allTools := []transport.Tool{}
+	for _, tool := range s.registry.Tools() {
+		definition := tool.Definition()
+		if tools.IsReadOnly() && tools.IsMutatingTool(definition.Name) {
+			continue
+		}
+		allTools = append(allTools, transport.Tool(definition))
+	}
 
-	response := server.ToolsListResponse{
+	response := transport.ToolsListResponse{
 		Tools: allTools,
 	}
 
@@ -109,44 +188,27 @@ func (s *FileSystemServer) handleToolsCall(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	var req server.ToolCallRequest
+	var req toolCallRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, fmt.Sprintf("Error decoding request: %v", err), http.StatusBadRequest)
 		return
 	}
 
-	var result string
-	var err error
-
-	// Handle filesystem tools
-	switch req.Name {
-	case "filesystem:read_file":
-		result, err = tools.CallReadFile(req.Arguments)
-	case "filesystem:write_file":
-		result, err = tools.CallWriteFile(req.Arguments)
-	case "filesystem:list_directory":
-		result, err = tools.CallListDirectory(req.Arguments)
-	case "filesystem:create_directory":
-		result, err = tools.CallCreateDirectory(req.Arguments)
-	case "filesystem:delete_file":
-		result, err = tools.CallDeleteFile(req.Arguments)
-	default:
+	// Handle filesystem tools, also under their former prefixed names
+	result, err := s.registry.Call(r.Context(), strings.TrimPrefix(req.Name, "filesystem:"), req.Arguments)
+	if errors.Is(err, tools.ErrToolNotFound) {
 		http.Error(w, "Tool not found", http.StatusNotFound)
 		return
 	}
-
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error calling tool: %v", err), http.StatusBadRequest)
 		return
 	}
 
-	response := server.ToolCallResponse{
-		Content: []server.ContentItem{
-			{
-				Type: "text",
-				Text: result,
-			},
-		},
+	response := transport.ToolResponse{
+		Content:           result.Content,
+		StructuredContent: result.StructuredContent,
+		IsError:           result.IsError,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -155,3 +217,73 @@ func (s *FileSystemServer) handleToolsCall(w http.ResponseWriter, r *http.Reques
 		return
 	}
 }
+
+// handleEvents handles GET /events, a server-sent event stream of
+// notifications/resources/updated notifications for watched paths
+func (s *FileSystemServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	stream := make(chan []byte, 64)
+	s.streamsMu.Lock()
+	s.streams[stream] = true
+	s.streamsMu.Unlock()
+	defer func() {
+		s.streamsMu.Lock()
+		delete(s.streams, stream)
+		s.streamsMu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data := <-stream:
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// notifyFileEvent sends a change to a watched path to every /events stream.
+// Streams that are not keeping up miss the event rather than blocking.
+func (s *FileSystemServer) notifyFileEvent(event tools.FileEvent) {
+	data, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "notifications/resources/updated",
+		"params": map[string]interface{}{
+			"uri":          "file://" + event.Path,
+			"subscription": event.Subscription,
+			"op":           event.Op,
+		},
+	})
+	if err != nil {
+		log.Printf("Error marshaling file event: %v", err)
+		return
+	}
+
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+	for stream := range s.streams {
+		select {
+		case stream <- data:
+		default:
+			log.Printf("Dropping file event for a slow stream")
+		}
+	}
+}
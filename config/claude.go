@@ -0,0 +1,107 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ClaudeServer is a server entry of the {"mcpServers": {...}} format used by
+// Claude Desktop and other MCP clients
+type ClaudeServer struct {
+	Command  string            `json:"command"`
+	Args     []string          `json:"args"`
+	Env      map[string]string `json:"env"`
+	Cwd      string            `json:"cwd"`
+	Type     string            `json:"type"` // "stdio", "http" or "streamable-http"; inferred when empty
+	URL      string            `json:"url"`
+	Headers  map[string]string `json:"headers"`
+	Disabled bool              `json:"disabled"`
+}
+
+// fromClaudeServers converts mcpServers entries, sorted by name, into server
+// configurations. Imported servers are enabled unless marked disabled.
+func fromClaudeServers(servers map[string]ClaudeServer) ([]MCPConfig, error) {
+	names := make([]string, 0, len(servers))
+	for name := range servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	configs := make([]MCPConfig, 0, len(names))
+	for _, name := range names {
+		s := servers[name]
+		cfg := MCPConfig{
+			Name:    name,
+			Command: s.Command,
+			Args:    s.Args,
+			Env:     s.Env,
+			Cwd:     s.Cwd,
+			URL:     s.URL,
+			Auth:    s.Headers,
+			Enabled: !s.Disabled,
+		}
+		switch s.Type {
+		case "":
+		case "stdio":
+			cfg.Transport = "stdio"
+		case "http", "streamable-http", "streamableHttp":
+			cfg.Transport = "http"
+		default:
+			problems = append(problems, fmt.Sprintf("mcpServers.%s.type: unsupported type %q (supported: stdio, http, streamable-http)", name, s.Type))
+		}
+		configs = append(configs, cfg)
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return configs, nil
+}
+
+// parseClaudeServers returns the servers of the "mcpServers" section of a
+// JSON document, if it has one
+func parseClaudeServers(data []byte) ([]MCPConfig, error) {
+	var doc struct {
+		MCPServers map[string]ClaudeServer `json:"mcpServers"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.MCPServers) == 0 {
+		return nil, nil
+	}
+	return fromClaudeServers(doc.MCPServers)
+}
+
+// ImportClaudeConfig adds the servers of a Claude Desktop style configuration
+// file ({"mcpServers": {...}}) to the configuration. The imported servers get
+// the defaults and secret resolution of LoadConfig, and the result is
+// validated.
+func (c *Config) ImportClaudeConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	servers, err := parseClaudeServers(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(servers) == 0 {
+		return fmt.Errorf("%s has no mcpServers entries", path)
+	}
+
+	imported := Config{Defaults: c.Defaults, SecretsFile: c.SecretsFile, Servers: servers}
+	for i := range imported.Servers {
+		imported.Servers[i].Source = path
+	}
+	imported.applyDefaults()
+	if err := imported.ResolveSecrets(); err != nil {
+		return err
+	}
+
+	c.Servers = append(c.Servers, imported.Servers...)
+	return c.Validate()
+}
@@ -1,19 +1,188 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 )
 
 // MCPConfig represents configuration for an MCP server connection
 type MCPConfig struct {
 	Name      string            `json:"name"`
 	URL       string            `json:"url"`
-	Transport string            `json:"transport"` // "http", "sse", "stdio"
-	Auth      map[string]string `json:"auth"`      // Auth headers/credentials
+	Transport string            `json:"transport"` // "http" or "stdio" (default: stdio when command is set, http otherwise)
+	Auth      map[string]string `json:"auth"`      // Auth headers/credentials; values may reference files ("file:///run/secrets/token")
+	AuthFiles map[string]string `json:"authFiles"` // Auth headers whose whole value is read from a file
 	Enabled   bool              `json:"enabled"`
-	Prefix    string            `json:"prefix"` // Tool name prefix (e.g., "cloudflare:")
+	Prefix    string            `json:"prefix"`   // Tool name prefix (e.g., "cloudflare:")
+	Replicas  []string          `json:"replicas"` // Failover URLs tried in order when the primary URL fails
+	// AuthCommand prints a bearer token for the Authorization header, e.g.
+	// ["gcloud", "auth", "print-identity-token"]; it is run again every
+	// AuthRefreshSeconds (default 300)
+	AuthCommand        []string `json:"authCommand"`
+	AuthRefreshSeconds int      `json:"authRefreshSeconds"`
+	// Command, Args, Env and Cwd start a stdio server as a child process,
+	// declared the same way as in Claude Desktop's configuration
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env"` // Added to the gateway's environment
+	Cwd     string            `json:"cwd"`
+	// TimeoutSeconds bounds each request to the server (default 60 for http)
+	TimeoutSeconds int `json:"timeoutSeconds"`
+	// Retries repeats HTTP requests that could not reach the server or got a
+	// 502/503/504 answer; see Retry for retrying failed tool calls
+	Retries int `json:"retries"`
+	// MaxConcurrent limits the requests in flight to the server
+	MaxConcurrent int `json:"maxConcurrent"`
+	// MaxResponseBytes rejects larger responses (0 = unlimited)
+	MaxResponseBytes int64 `json:"maxResponseBytes"`
+
+	// LoadBalancing selects how calls are spread over URL and Replicas:
+	// "failover" (default), "round_robin" or "least_pending"
+	LoadBalancing string `json:"loadBalancing"`
+	// Transforms are applied in order to the text of every tool response
+	Transforms []TransformConfig `json:"transforms"`
+	// Arguments are merged into every tool call forwarded to this server;
+	// ToolArguments does the same for individual tools (keyed by the tool
+	// name without prefix) and takes precedence
+	Arguments     ArgumentsConfig            `json:"arguments"`
+	ToolArguments map[string]ArgumentsConfig `json:"toolArguments"`
+	// MaxCallSeconds caps how long a single tool call may take (0 = no cap)
+	MaxCallSeconds int `json:"maxCallSeconds"`
+	// ValidateArguments checks the arguments of every call against the input
+	// schema the server lists for the tool, refusing mismatches before they
+	// are forwarded
+	ValidateArguments bool `json:"validateArguments"`
+	// Priority orders servers when resolving tool names that match no prefix;
+	// higher values are tried first and ties are broken by name
+	Priority int `json:"priority"`
+	// Initialize controls when the connection is set up: "lazy" (default,
+	// on first use), "eager" (blocks startup) or "background" (asynchronous,
+	// reported by /readyz)
+	Initialize string `json:"initialize"`
+	// InitializeTimeoutSeconds bounds a single initialization attempt
+	// (default 30)
+	InitializeTimeoutSeconds int `json:"initializeTimeoutSeconds"`
+	// Tags group servers so requests can be restricted to some of them
+	// (e.g. ["internal", "prod"])
+	Tags []string `json:"tags"`
+	// Retry re-issues failed calls to tools that are safe to repeat
+	Retry RetryConfig `json:"retry"`
+	// RateLimit caps the tool calls the gateway sends to this server
+	RateLimit RateLimitConfig `json:"rateLimit"`
+	// Tools trims the catalog of the server with glob patterns matched
+	// against the tool names without prefix
+	Tools ToolFilterConfig `json:"tools"`
+	// TLS configures https connections to internally-signed servers
+	TLS TLSConfig `json:"tls"`
+
+	// Source is the include file the server was declared in, if any
+	Source string `json:"-"`
+}
+
+// RateLimitConfig declares request rate and concurrency caps for a server
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `json:"requestsPerSecond"` // Sustained call rate (0 = unlimited)
+	Burst             int     `json:"burst"`             // Calls allowed at once above the rate (default 1)
+	MaxConcurrent     int     `json:"maxConcurrent"`     // Calls in flight at the same time (0 = unlimited)
+}
+
+// TLSConfig declares the certificates used to connect to a server over https
+type TLSConfig struct {
+	CAFile             string `json:"caFile"`             // PEM bundle of additional trusted authorities
+	CertFile           string `json:"certFile"`           // Client certificate for mutual TLS
+	KeyFile            string `json:"keyFile"`            // Private key of the client certificate
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"` // Do not verify the server certificate (testing only)
+}
+
+// Configured reports whether any TLS setting is present
+func (t TLSConfig) Configured() bool {
+	return t != TLSConfig{}
+}
+
+// ToolFilterConfig selects the upstream tools exposed by the gateway
+type ToolFilterConfig struct {
+	Include []string `json:"include"` // Glob patterns (e.g. "read_*") of the tools to expose (default: all)
+	Exclude []string `json:"exclude"` // Glob patterns of the tools to hide, applied after Include
+}
+
+// Allows reports whether a tool, named without prefix, passes the filter.
+// Patterns use path.Match syntax; invalid patterns are rejected by Validate
+// and match nothing here.
+func (f ToolFilterConfig) Allows(name string) bool {
+	if len(f.Include) > 0 && !matchAny(f.Include, name) {
+		return false
+	}
+	return !matchAny(f.Exclude, name)
+}
+
+// matchAny reports whether name matches one of the glob patterns
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validPattern reports whether pattern is valid path.Match syntax
+func validPattern(pattern string) bool {
+	_, err := path.Match(pattern, "")
+	return err == nil
+}
+
+// RetryConfig declares the gateway retry policy for read-only tools
+type RetryConfig struct {
+	MaxAttempts   int      `json:"maxAttempts"`   // Total attempts including the first (0 or 1 = no retries)
+	BackoffMs     int      `json:"backoffMs"`     // Delay before the first retry, doubled for each further one (default 100)
+	ReadOnlyTools []string `json:"readOnlyTools"` // Tool names without prefix that may be retried and coalesced; "*" marks every tool
+	BudgetPercent int      `json:"budgetPercent"` // Retries allowed as a percentage of calls (default 20)
+	// TrustAnnotations also treats tools the server annotates readOnlyHint as
+	// read-only, and retries tools it annotates idempotentHint
+	TrustAnnotations bool `json:"trustAnnotations"`
+}
+
+// ArgumentsConfig declares arguments injected into tool calls by the gateway
+type ArgumentsConfig struct {
+	Defaults map[string]interface{} `json:"defaults"` // Used only when the caller omits the argument
+	Forced   map[string]interface{} `json:"forced"`   // Always override the caller's value
+}
+
+// TransformConfig describes a transformation applied to upstream tool responses
+type TransformConfig struct {
+	Type       string `json:"type"`       // "truncate", "strip_ansi", "json_field" or "jq"
+	MaxBytes   int    `json:"maxBytes"`   // truncate: maximum size of each text item
+	Field      string `json:"field"`      // json_field: dotted path such as "result.items.0"
+	Expression string `json:"expression"` // jq: expression such as ".items[] | .name"
+}
+
+// TransportName returns the configured transport, defaulting to "stdio"
+// when a command is set and to "http" otherwise
+func (c MCPConfig) TransportName() string {
+	if c.Transport != "" {
+		return c.Transport
+	}
+	if c.Command != "" {
+		return "stdio"
+	}
+	return "http"
+}
+
+// Endpoints returns the primary URL followed by any replica URLs
+func (c MCPConfig) Endpoints() []string {
+	endpoints := make([]string, 0, 1+len(c.Replicas))
+	endpoints = append(endpoints, c.URL)
+	for _, replica := range c.Replicas {
+		if replica != "" && replica != c.URL {
+			endpoints = append(endpoints, replica)
+		}
+	}
+	return endpoints
 }
 
 // GooglePSEConfig represents Google PSE configuration
@@ -23,50 +192,750 @@ type GooglePSEConfig struct {
 	Enabled        bool   `json:"enabled"`
 }
 
+// BraveSearchConfig represents Brave Search configuration
+type BraveSearchConfig struct {
+	APIKey  string `json:"api_key"`
+	Enabled bool   `json:"enabled"`
+}
+
+// SearXNGConfig configures the searxng_search tool
+type SearXNGConfig struct {
+	// URL is the base URL of the SearXNG instance, which must have the json
+	// output format enabled
+	URL     string `json:"url"`
+	Enabled bool   `json:"enabled"`
+}
+
+// DuckDuckGoConfig configures the keyless duckduckgo_search tool
+type DuckDuckGoConfig struct {
+	// Enabled lists the tool; when unset it is listed only if no other
+	// search (Google PSE, Brave Search or SearXNG) is configured
+	Enabled *bool `json:"enabled"`
+}
+
+// HTTPRequestConfig configures the local http_request tool
+type HTTPRequestConfig struct {
+	Enabled bool `json:"enabled"`
+	// AllowedHosts are the hosts requests may go to: "api.example.com",
+	// "*.example.com" for its subdomains, "host:port" to pin a port, or "*"
+	// for any host (empty = none)
+	AllowedHosts []string `json:"allowed_hosts"`
+	// TimeoutSeconds bounds each request (0 = 30 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// MaxResponseBytes truncates larger response bodies (0 = 1 MiB)
+	MaxResponseBytes int64 `json:"max_response_bytes"`
+}
+
+// FetchPageConfig configures the local fetch_page tool
+type FetchPageConfig struct {
+	Enabled bool `json:"enabled"`
+	// TimeoutSeconds bounds each download (0 = 20 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// MaxLength caps the characters returned per call (0 = 20000)
+	MaxLength int `json:"max_length"`
+	// AllowPrivateNetworks permits fetching loopback, private and
+	// link-local addresses
+	AllowPrivateNetworks bool `json:"allow_private_networks"`
+}
+
+// RunCommandConfig configures the local run_command tool
+type RunCommandConfig struct {
+	Enabled bool `json:"enabled"`
+	// AllowedCommands are the programs that may run, by name ("go") or by
+	// exact path ("/usr/bin/make")
+	AllowedCommands []string `json:"allowed_commands"`
+	// Unsafe allows any command; only for trusted, isolated deployments
+	Unsafe bool `json:"unsafe"`
+	// AllowedEnv are the environment variables callers may set; PATH, LD_*
+	// and DYLD_* are never allowed
+	AllowedEnv []string `json:"allowed_env"`
+	// WorkingDir is the directory commands run in (empty = the gateway's
+	// working directory)
+	WorkingDir string `json:"working_dir"`
+	// TimeoutSeconds bounds each command (0 = 60 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// MaxOutputBytes truncates larger output (0 = 1 MiB)
+	MaxOutputBytes int64 `json:"max_output_bytes"`
+}
+
+// GitConfig configures the local git tools
+type GitConfig struct {
+	Enabled bool `json:"enabled"`
+	// Repositories are the directories the tools may work in, each a
+	// repository or a directory holding repositories
+	Repositories []string `json:"repositories"`
+	// TimeoutSeconds bounds each git command (0 = 30 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// MaxOutputBytes truncates larger output (0 = 1 MiB)
+	MaxOutputBytes int64 `json:"max_output_bytes"`
+}
+
+// SQLConnectionConfig is a named database connection of the sql_query tool
+type SQLConnectionConfig struct {
+	// Driver is "sqlite", "postgres", "mysql" or the name of another
+	// database/sql driver compiled into the gateway
+	Driver string `json:"driver"`
+	DSN    string `json:"dsn"`
+	// ReadOnly accepts only queries, run in a read-only transaction
+	ReadOnly bool `json:"read_only"`
+}
+
+// SQLConfig configures the local sql_query tool
+type SQLConfig struct {
+	Enabled     bool                           `json:"enabled"`
+	Connections map[string]SQLConnectionConfig `json:"connections"`
+	// TimeoutSeconds bounds each query (0 = 30 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// MaxRows caps the rows returned per query (0 = 1000)
+	MaxRows int `json:"max_rows"`
+}
+
+// WikipediaConfig configures the local wikipedia_search and
+// wikipedia_summary tools
+type WikipediaConfig struct {
+	Enabled bool `json:"enabled"`
+	// Language is the Wikipedia used when a call names none (default: "en")
+	Language string `json:"language"`
+}
+
+// EmbeddingConfig configures an OpenAI-compatible embeddings endpoint
+type EmbeddingConfig struct {
+	// URL is the API base URL, e.g. "https://api.openai.com/v1" or
+	// "http://localhost:11434/v1" for Ollama
+	URL    string `json:"url"`
+	APIKey string `json:"api_key"` // Optional; may reference a secret
+	Model  string `json:"model"`
+}
+
+// KnowledgeBaseConfig configures the local kb_add_document and kb_search
+// tools
+type KnowledgeBaseConfig struct {
+	Enabled   bool            `json:"enabled"`
+	Embedding EmbeddingConfig `json:"embedding"`
+	// IndexPath is the file the vector index is saved to, relative to the
+	// configuration file (empty = kept in memory and lost on restart)
+	IndexPath string `json:"index_path"`
+	// ChunkSize is the length of the indexed passages in characters
+	// (0 = 1000)
+	ChunkSize int `json:"chunk_size"`
+	// ChunkOverlap is how much consecutive passages overlap (0 = a tenth of
+	// the chunk size)
+	ChunkOverlap int `json:"chunk_overlap"`
+}
+
+// S3BucketConfig is a bucket of the S3 tools, keyed by the name the tools
+// use for it
+type S3BucketConfig struct {
+	// Bucket is the name of the bucket in the object store (empty = the key
+	// of the entry)
+	Bucket string `json:"bucket"`
+	// Endpoint is the base URL of an S3-compatible service such as MinIO
+	// (empty = AWS S3)
+	Endpoint string `json:"endpoint"`
+	Region   string `json:"region"` // Empty = us-east-1
+	// Credentials may reference secrets; when empty, AWS_ACCESS_KEY_ID,
+	// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN are used
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	SessionToken    string `json:"session_token"`
+	// PathStyle puts the bucket in the URL path, as MinIO expects
+	PathStyle bool `json:"path_style"`
+	// ReadOnly rejects writes and deletes
+	ReadOnly bool `json:"read_only"`
+}
+
+// S3Config configures the local S3 object storage tools
+type S3Config struct {
+	Enabled bool                      `json:"enabled"`
+	Buckets map[string]S3BucketConfig `json:"buckets"`
+	// TimeoutSeconds bounds each request (0 = 60 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// MaxObjectBytes caps the size of objects read or written (0 = 10 MiB)
+	MaxObjectBytes int64 `json:"max_object_bytes"`
+}
+
+// DockerConfig configures the local docker tools
+type DockerConfig struct {
+	Enabled bool `json:"enabled"`
+	// Host is the Docker daemon address, e.g. "unix:///var/run/docker.sock"
+	// or "tcp://127.0.0.1:2375" (empty = DOCKER_HOST, else the local socket)
+	Host string `json:"host"`
+	// AllowExec exposes docker_exec, which runs commands in containers
+	AllowExec bool `json:"allow_exec"`
+	// AllowRestart exposes docker_restart
+	AllowRestart bool `json:"allow_restart"`
+	// TimeoutSeconds bounds each call (0 = 30 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// MaxOutputBytes truncates longer logs and output (0 = 1 MiB)
+	MaxOutputBytes int64 `json:"max_output_bytes"`
+}
+
+// KubernetesConfig configures the local Kubernetes tools
+type KubernetesConfig struct {
+	Enabled bool `json:"enabled"`
+	// Kubeconfig is the kubeconfig file, relative to the configuration file
+	// (empty = the service account when running in a pod, else KUBECONFIG
+	// or ~/.kube/config)
+	Kubeconfig string `json:"kubeconfig"`
+	// Context of the kubeconfig to use (empty = its current context)
+	Context string `json:"context"`
+	// Namespaces the tools may use; empty allows every namespace and
+	// cluster-scoped resources
+	Namespaces []string `json:"namespaces"`
+	// DefaultNamespace is used when a call names none (empty = the
+	// namespace of the context or pod, else the first allowed namespace)
+	DefaultNamespace string `json:"default_namespace"`
+	// AllowApply exposes k8s_apply, which creates and updates objects
+	AllowApply bool `json:"allow_apply"`
+	// TimeoutSeconds bounds each call (0 = 30 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// MaxOutputBytes truncates longer output (0 = 1 MiB)
+	MaxOutputBytes int64 `json:"max_output_bytes"`
+}
+
+// EmailConfig configures the local send_email tool
+type EmailConfig struct {
+	Enabled bool `json:"enabled"`
+	// Host and Port of the SMTP server (port 0 = 587, or 465 with "tls")
+	Host string `json:"host"`
+	Port int    `json:"port"`
+	// Username and Password authenticate to the server (empty username = no
+	// authentication)
+	Username string `json:"username"`
+	Password string `json:"password"`
+	// From is the sender, e.g. "MCP Gateway <gateway@example.com>"
+	From string `json:"from"`
+	// TLS is "starttls", "tls" (implicit TLS) or "none" (empty = starttls)
+	TLS string `json:"tls"`
+	// AllowedRecipientDomains are the domains mail may be sent to;
+	// "*.example.com" also allows subdomains and "*" any domain
+	AllowedRecipientDomains []string `json:"allowed_recipient_domains"`
+	// MaxPerHour limits the emails sent in any hour (0 = 20)
+	MaxPerHour int `json:"max_per_hour"`
+	// MaxRecipients limits the recipients of one email (0 = 10)
+	MaxRecipients int `json:"max_recipients"`
+}
+
+// SlackConfig configures the local Slack tools
+type SlackConfig struct {
+	Enabled bool `json:"enabled"`
+	// BotToken is the "xoxb-" token of the Slack app (empty =
+	// SLACK_BOT_TOKEN)
+	BotToken string `json:"bot_token"`
+	// Channels the tools may use: channel IDs, such as "C0123456789",
+	// keyed by the name the tools use
+	Channels map[string]string `json:"channels"`
+	// TimeoutSeconds bounds each call (0 = 30 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
+// TelegramConfig configures the local Telegram tools
+type TelegramConfig struct {
+	Enabled bool `json:"enabled"`
+	// BotToken is the token BotFather issued for the bot (empty =
+	// TELEGRAM_BOT_TOKEN)
+	BotToken string `json:"bot_token"`
+	// ChatIDs are the chats the tools may send to and read from; group and
+	// channel IDs are negative
+	ChatIDs []int64 `json:"chat_ids"`
+	// TimeoutSeconds bounds each call (0 = 30 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
+// GitHubConfig configures the local GitHub tools
+type GitHubConfig struct {
+	Enabled bool `json:"enabled"`
+	// Token is a personal access or app token (empty = GITHUB_TOKEN, else
+	// unauthenticated access to public data)
+	Token string `json:"token"`
+	// APIURL is the REST API of GitHub Enterprise Server, e.g.
+	// "https://github.example.com/api/v3" (empty = api.github.com)
+	APIURL string `json:"api_url"`
+	// Repositories the tools may use, as "owner/name" or "owner/*"; empty
+	// allows every repository the token can access
+	Repositories []string `json:"repositories"`
+	// ReadOnly hides the tools that create issues and comments
+	ReadOnly bool `json:"read_only"`
+	// TimeoutSeconds bounds each call (0 = 30 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
+// JiraConfig configures the local Jira tools
+type JiraConfig struct {
+	Enabled bool `json:"enabled"`
+	// URL is the base URL of the instance, e.g. "https://acme.atlassian.net"
+	URL string `json:"url"`
+	// Email and APIToken authenticate to Jira Cloud; without email the token
+	// is used as a personal access token of Jira Server or Data Center
+	// (empty token = JIRA_API_TOKEN)
+	Email    string `json:"email"`
+	APIToken string `json:"api_token"`
+	// Projects the tools may use, by key; empty allows every project
+	Projects []string `json:"projects"`
+	// ReadOnly hides the tools that create issues and comments
+	ReadOnly bool `json:"read_only"`
+	// TimeoutSeconds bounds each call (0 = 30 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
+// OCRConfig configures the local ocr_image tool
+type OCRConfig struct {
+	Enabled bool `json:"enabled"`
+	// Engine is "tesseract" or "google_vision" (empty = tesseract)
+	Engine string `json:"engine"`
+	// TesseractPath is the tesseract program (empty = found in PATH)
+	TesseractPath string `json:"tesseract_path"`
+	// APIKey is the Google Cloud Vision API key of the google_vision engine
+	APIKey string `json:"api_key"`
+	// Language of the text, e.g. "eng+deu" for Tesseract or "en,de" for
+	// Google Cloud Vision (empty = the engine's default)
+	Language string `json:"language"`
+	// AllowedPaths are the directories images may be read from, relative to
+	// the configuration file (empty = only base64 content)
+	AllowedPaths []string `json:"allowed_paths"`
+	// MaxImageBytes rejects larger images (0 = 20 MiB)
+	MaxImageBytes int64 `json:"max_image_bytes"`
+	// TimeoutSeconds bounds each call (0 = 60 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
+// BrowserConfig configures the local browser tools, which drive a headless
+// Chrome
+type BrowserConfig struct {
+	Enabled bool `json:"enabled"`
+	// ChromePath is the Chrome or Chromium program (empty = found in PATH)
+	ChromePath string `json:"chrome_path"`
+	// ChromeArgs are extra command line flags, such as "--no-sandbox" when
+	// running as root in a container
+	ChromeArgs []string `json:"chrome_args"`
+	// RemoteURL connects to a running browser instead of launching one, by
+	// its ws:// DevTools URL or http:// remote debugging address
+	RemoteURL string `json:"remote_url"`
+	// AllowedDomains are the hosts pages may be loaded from: "example.com",
+	// "*.example.com", "host:port" or "*"
+	AllowedDomains []string `json:"allowed_domains"`
+	// MaxPages is the number of pages kept open (0 = 5)
+	MaxPages int `json:"max_pages"`
+	// IdleTimeoutSeconds closes pages unused for this long (0 = 600 seconds)
+	IdleTimeoutSeconds int `json:"idle_timeout_seconds"`
+	// MaxLength caps the characters browser_get_content returns (0 = 20000)
+	MaxLength int `json:"max_length"`
+	// TimeoutSeconds bounds each call (0 = 30 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
+// SpreadsheetConfig configures the local read_csv and read_xlsx tools
+type SpreadsheetConfig struct {
+	Enabled bool `json:"enabled"`
+	// AllowedPaths are the directories files may be read from, relative to
+	// the configuration file (empty = only content passed in the call)
+	AllowedPaths []string `json:"allowed_paths"`
+	// MaxRows caps the rows returned by a call (0 = 1000)
+	MaxRows int `json:"max_rows"`
+	// MaxFileBytes rejects larger files (0 = 20 MiB)
+	MaxFileBytes int64 `json:"max_file_bytes"`
+}
+
+// JSONQueryConfig configures the local json_query tool
+type JSONQueryConfig struct {
+	Enabled bool `json:"enabled"`
+	// AllowedPaths are the directories JSON files may be read from, relative
+	// to the configuration file (empty = only documents passed in the call)
+	AllowedPaths []string `json:"allowed_paths"`
+	// MaxInputBytes rejects larger documents (0 = 50 MiB)
+	MaxInputBytes int64 `json:"max_input_bytes"`
+	// MaxLength caps the characters of output returned (0 = 20000)
+	MaxLength int `json:"max_length"`
+}
+
+// DNSLookupConfig configures the local dns_lookup tool
+type DNSLookupConfig struct {
+	Enabled bool `json:"enabled"`
+	// Resolver is the IP address, with an optional port, of the DNS server
+	// asked when the call names none (empty = the system resolver)
+	Resolver string `json:"resolver"`
+	// TimeoutSeconds bounds each call (0 = 10 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
+// WhoisConfig configures the local whois tool
+type WhoisConfig struct {
+	Enabled bool `json:"enabled"`
+	// TimeoutSeconds bounds each call, referrals included (0 = 15 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// MaxLength caps the characters of the response returned (0 = 20000)
+	MaxLength int `json:"max_length"`
+}
+
+// CrawlSiteConfig configures the local crawl_site tool
+type CrawlSiteConfig struct {
+	Enabled bool `json:"enabled"`
+	// MaxPages caps the pages fetched by a crawl (0 = 50)
+	MaxPages int `json:"max_pages"`
+	// MaxDepth caps the links followed from the start page (0 = 3)
+	MaxDepth int `json:"max_depth"`
+	// MaxLength caps the characters of page text returned (0 = 50000)
+	MaxLength int `json:"max_length"`
+	// TimeoutSeconds bounds a whole crawl (0 = 120 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// AllowPrivateNetworks permits crawling loopback, private and link-local
+	// addresses
+	AllowPrivateNetworks bool `json:"allow_private_networks"`
+}
+
+// YouTubeTranscriptConfig configures the local youtube_transcript tool
+type YouTubeTranscriptConfig struct {
+	Enabled bool `json:"enabled"`
+	// Languages are the preferred caption languages when a call names none,
+	// such as ["en"] (empty = the first track of the video)
+	Languages []string `json:"languages"`
+	// MaxLength caps the characters returned per call (0 = 20000)
+	MaxLength int `json:"max_length"`
+	// TimeoutSeconds bounds each request to YouTube (0 = 20 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
+// PluginConfig declares a local tool implemented by an external program,
+// which receives the call's arguments as JSON on stdin and prints its
+// result. WASM modules run through a WASI runtime such as wasmtime.
+type PluginConfig struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// InputSchema is the JSON Schema of the arguments (default: any object)
+	InputSchema map[string]interface{} `json:"input_schema"`
+	// ReadOnly marks a tool that changes nothing, which clients may call
+	// without confirmation
+	ReadOnly bool `json:"read_only"`
+	// Command and Args start the program, e.g. "./plugins/weather" or
+	// "wasmtime" with ["run", "weather.wasm"]
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env"` // Added to the gateway's environment
+	// WorkingDir is the directory the program runs in (empty = the
+	// gateway's working directory)
+	WorkingDir string `json:"working_dir"`
+	// TimeoutSeconds bounds each call (0 = 60 seconds)
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// MaxOutputBytes rejects larger output (0 = 1 MiB)
+	MaxOutputBytes int64 `json:"max_output_bytes"`
+	// Disabled declares the tool without registering it at startup; the
+	// admin API registers it on demand
+	Disabled bool `json:"disabled"`
+}
+
+// ToolLimitsConfig bounds the resources of each call to a local tool
+type ToolLimitsConfig struct {
+	// TimeoutSeconds bounds the wall-clock time of a call (0 = no limit
+	// beyond the tool's own timeout)
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// MaxOutputBytes truncates larger results (0 = no limit)
+	MaxOutputBytes int `json:"max_output_bytes"`
+	// CPUSeconds and MemoryBytes limit the processes started by run_command,
+	// the git tools and ocr_image, on unix (0 = no limit)
+	CPUSeconds  int   `json:"cpu_seconds"`
+	MemoryBytes int64 `json:"memory_bytes"`
+}
+
+// FilesystemConfig configures the filesystem tools served by
+// cmd/filesystem-server
+type FilesystemConfig struct {
+	// AllowedPaths are the directories the tools may touch; every path
+	// argument must resolve inside one of them after symlink resolution
+	// (empty = the working directory of the filesystem server)
+	AllowedPaths []string `json:"allowedPaths"`
+	// ReadOnly hides and rejects every tool that changes the filesystem
+	ReadOnly bool `json:"readOnly"`
+	// MaxReadBytes truncates larger reads (0 = 10 MiB)
+	MaxReadBytes int64 `json:"maxReadBytes"`
+	// MaxWriteBytes rejects larger writes (0 = 10 MiB)
+	MaxWriteBytes int64 `json:"maxWriteBytes"`
+}
+
 // Config represents the application configuration
 type Config struct {
 	Port        string          `json:"port"`         // Server port (default: ":3333")
 	BearerToken string          `json:"bearer_token"` // Bearer token for authentication (optional)
 	GooglePSE   GooglePSEConfig `json:"google_pse"`   // Google PSE configuration
 	Servers     []MCPConfig     `json:"servers"`      // Remote MCP servers
+	// Defaults are inherited by every server entry unless overridden
+	Defaults DefaultsConfig `json:"defaults"`
+	// ToolRefreshSeconds re-lists upstream tools at this interval and notifies
+	// downstream clients of changes (0 = disabled)
+	ToolRefreshSeconds int `json:"tool_refresh_seconds"`
+	// CallHistoryFile persists the gateway call history as JSON lines
+	// (optional; the history is kept in memory either way)
+	CallHistoryFile string `json:"call_history_file"`
+	// ConfigRefreshSeconds polls a remote configuration source at this
+	// interval and applies its changes (0 = disabled)
+	ConfigRefreshSeconds int `json:"config_refresh_seconds"`
+	// ServersDir holds additional server definitions, one or more per file
+	// (default: servers.d next to the configuration file)
+	ServersDir string `json:"servers_dir"`
+	// SecretsFile is an encrypted file of secrets referenced as "secret:name",
+	// unlocked with the MCP_SECRETS_PASSPHRASE environment variable
+	SecretsFile string `json:"secrets_file"`
+	// Profiles override server settings per environment; the one named by
+	// Profile (or MCP_PROFILE) is applied when loading
+	Profiles map[string]ProfileConfig `json:"profiles"`
+	Profile  string                   `json:"profile"`
+	// Filesystem configures the filesystem tools
+	Filesystem FilesystemConfig `json:"filesystem"`
+	// BraveSearch configures the local brave_search tool
+	BraveSearch BraveSearchConfig `json:"brave_search"`
+	// SearXNG configures the local searxng_search tool
+	SearXNG SearXNGConfig `json:"searxng"`
+	// DuckDuckGo configures the local duckduckgo_search tool
+	DuckDuckGo DuckDuckGoConfig `json:"duckduckgo_search"`
+	// HTTPRequest configures the local http_request tool
+	HTTPRequest HTTPRequestConfig `json:"http_request"`
+	// FetchPage configures the local fetch_page tool
+	FetchPage FetchPageConfig `json:"fetch_page"`
+	// RunCommand configures the local run_command tool
+	RunCommand RunCommandConfig `json:"run_command"`
+	// Git configures the local git tools
+	Git GitConfig `json:"git"`
+	// SQL configures the local sql_query tool
+	SQL SQLConfig `json:"sql"`
+	// Wikipedia configures the local Wikipedia tools
+	Wikipedia WikipediaConfig `json:"wikipedia"`
+	// KnowledgeBase configures the local knowledge base tools
+	KnowledgeBase KnowledgeBaseConfig `json:"knowledge_base"`
+	// S3 configures the local S3 object storage tools
+	S3 S3Config `json:"s3"`
+	// Docker configures the local docker tools
+	Docker DockerConfig `json:"docker"`
+	// Kubernetes configures the local Kubernetes tools
+	Kubernetes KubernetesConfig `json:"kubernetes"`
+	// Email configures the local send_email tool
+	Email EmailConfig `json:"email"`
+	// Slack configures the local Slack tools
+	Slack SlackConfig `json:"slack"`
+	// Telegram configures the local Telegram tools
+	Telegram TelegramConfig `json:"telegram"`
+	// GitHub configures the local GitHub tools
+	GitHub GitHubConfig `json:"github"`
+	// Jira configures the local Jira tools
+	Jira JiraConfig `json:"jira"`
+	// OCR configures the local ocr_image tool
+	OCR OCRConfig `json:"ocr"`
+	// Browser configures the local browser tools
+	Browser BrowserConfig `json:"browser"`
+	// Spreadsheet configures the local read_csv and read_xlsx tools
+	Spreadsheet SpreadsheetConfig `json:"spreadsheet"`
+	// JSONQuery configures the local json_query tool
+	JSONQuery JSONQueryConfig `json:"json_query"`
+	// DNSLookup configures the local dns_lookup tool
+	DNSLookup DNSLookupConfig `json:"dns_lookup"`
+	// Whois configures the local whois tool
+	Whois WhoisConfig `json:"whois"`
+	// CrawlSite configures the local crawl_site tool
+	CrawlSite CrawlSiteConfig `json:"crawl_site"`
+	// YouTubeTranscript configures the local youtube_transcript tool
+	YouTubeTranscript YouTubeTranscriptConfig `json:"youtube_transcript"`
+	// DisabledTools are hidden from tools/list and cannot be called
+	DisabledTools []string `json:"disabled_tools"`
+	// ToolLimits bound the calls of local tools by tool name; the "*" entry
+	// applies to the tools without one
+	ToolLimits map[string]ToolLimitsConfig `json:"tool_limits"`
+	// Plugins are local tools implemented by external programs
+	Plugins []PluginConfig `json:"plugins"`
 }
 
-// LoadConfig loads configuration from a JSON file
+// DefaultConfigFiles are the configuration files looked for, in order, when
+// no path is given
+var DefaultConfigFiles = []string{"mcp-config.json", "mcp-config.yaml", "mcp-config.yml"}
+
+// FindConfigFile returns the first of DefaultConfigFiles that exists, or the
+// first one if none does
+func FindConfigFile() string {
+	for _, path := range DefaultConfigFiles {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return DefaultConfigFiles[0]
+}
+
+// LoadConfig loads configuration from a JSON or YAML file. Files ending in
+// .yaml or .yml are parsed as YAML; anything else as JSON. References to
+// environment variables such as "${API_URL}" are expanded in string values.
+// The profile named by MCP_PROFILE, or else by the file's "profile" field,
+// is applied.
 func LoadConfig(path string) (*Config, error) {
+	return LoadConfigProfile(path, os.Getenv(ProfileEnv))
+}
+
+// LoadConfigProfile is LoadConfig with the profile to apply given explicitly;
+// an empty profile falls back to the file's "profile" field. path may also be
+// a remote source (see RemoteSource).
+func LoadConfigProfile(path, profile string) (*Config, error) {
+	if IsRemoteSource(path) {
+		cfg, _, err := NewRemoteSource(path).Load(context.Background(), profile)
+		return cfg, err
+	}
+
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
+	ext := strings.ToLower(filepath.Ext(path))
+	return parseConfig(data, ext == ".yaml" || ext == ".yml", filepath.Dir(path), profile)
+}
+
+// parseConfig builds the configuration from the contents of a configuration
+// file. Relative paths in it are relative to baseDir; an empty baseDir (for
+// remote sources) leaves them relative to the working directory and skips
+// the default servers.d directory.
+func parseConfig(data []byte, yaml bool, baseDir, profile string) (*Config, error) {
+	var err error
+	if yaml {
+		data, err = yamlToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+	}
+
+	data, err = expandEnv(data)
+	if err != nil {
+		return nil, err
+	}
 
 	var config Config
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
+	// Servers may also be declared in the Claude Desktop "mcpServers" format
+	claudeServers, err := parseClaudeServers(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	config.Servers = append(config.Servers, claudeServers...)
+
+	// Merge servers dropped into the include directory
+	serversDir := config.ServersDir
+	if serversDir == "" && baseDir != "" {
+		serversDir = DefaultServersDir
+	}
+	if serversDir != "" {
+		if err := config.loadServersDir(resolveRelative(baseDir, serversDir), config.ServersDir != ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if profile == "" {
+		profile = config.Profile
+	}
+	if profile != "" {
+		if err := config.applyProfile(profile); err != nil {
+			return nil, err
+		}
+	}
+	config.applyDefaults()
+	if config.SecretsFile != "" {
+		config.SecretsFile = resolveRelative(baseDir, config.SecretsFile)
+	}
+	for i, allowed := range config.Filesystem.AllowedPaths {
+		if allowed != "" {
+			config.Filesystem.AllowedPaths[i] = resolveRelative(baseDir, allowed)
+		}
+	}
+	for i, repository := range config.Git.Repositories {
+		if repository != "" {
+			config.Git.Repositories[i] = resolveRelative(baseDir, repository)
+		}
+	}
+	for i, allowed := range config.OCR.AllowedPaths {
+		if allowed != "" {
+			config.OCR.AllowedPaths[i] = resolveRelative(baseDir, allowed)
+		}
+	}
+	for i, allowed := range config.Spreadsheet.AllowedPaths {
+		if allowed != "" {
+			config.Spreadsheet.AllowedPaths[i] = resolveRelative(baseDir, allowed)
+		}
+	}
+	for i, allowed := range config.JSONQuery.AllowedPaths {
+		if allowed != "" {
+			config.JSONQuery.AllowedPaths[i] = resolveRelative(baseDir, allowed)
+		}
+	}
+	if config.RunCommand.WorkingDir != "" {
+		config.RunCommand.WorkingDir = resolveRelative(baseDir, config.RunCommand.WorkingDir)
+	}
+	if config.Kubernetes.Kubeconfig != "" {
+		config.Kubernetes.Kubeconfig = resolveRelative(baseDir, config.Kubernetes.Kubeconfig)
+	}
+	if config.KnowledgeBase.IndexPath != "" {
+		config.KnowledgeBase.IndexPath = resolveRelative(baseDir, config.KnowledgeBase.IndexPath)
+	}
+	for i := range config.Plugins {
+		plugin := &config.Plugins[i]
+		// Paths such as "./plugins/weather" are relative to the file; bare
+		// names are looked up in PATH
+		if strings.ContainsRune(plugin.Command, '/') {
+			plugin.Command = resolveRelative(baseDir, plugin.Command)
+		}
+		if plugin.WorkingDir != "" {
+			plugin.WorkingDir = resolveRelative(baseDir, plugin.WorkingDir)
+		}
+	}
+
+	if err := config.ResolveSecrets(); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
 	return &config, nil
 }
 
+// resolveRelative joins a relative path to baseDir
+func resolveRelative(baseDir, path string) string {
+	if baseDir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
+
 // LoadConfigFromEnv loads configuration from environment variables
 // Format: MCP_SERVERS='[{"name":"cloudflare","url":"...","transport":"http"}]'
+// MCP_SERVERS_DIR names an include directory of server definitions.
 func LoadConfigFromEnv() (*Config, error) {
 	serversJSON := os.Getenv("MCP_SERVERS")
 	bearerToken := os.Getenv("MCP_BEARER_TOKEN")
+	serversDir := os.Getenv("MCP_SERVERS_DIR")
 
 	config := &Config{
 		BearerToken: bearerToken,
 		Servers:     []MCPConfig{},
+		ServersDir:  serversDir,
 	}
 
-	if serversJSON == "" {
+	if serversJSON == "" && serversDir == "" {
 		return config, nil
 	}
 
-	var servers []MCPConfig
-	if err := json.Unmarshal([]byte(serversJSON), &servers); err != nil {
-		return nil, fmt.Errorf("failed to parse MCP_SERVERS env var: %w", err)
+	if serversJSON != "" {
+		var servers []MCPConfig
+		if err := json.Unmarshal([]byte(serversJSON), &servers); err != nil {
+			return nil, fmt.Errorf("failed to parse MCP_SERVERS env var: %w", err)
+		}
+		config.Servers = servers
+	}
+	if serversDir != "" {
+		if err := config.loadServersDir(serversDir, true); err != nil {
+			return nil, err
+		}
 	}
 
-	config.Servers = servers
+	if err := config.ResolveSecrets(); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 	return config, nil
 }
 
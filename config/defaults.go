@@ -0,0 +1,86 @@
+package config
+
+import "strings"
+
+// DefaultsConfig holds settings inherited by every server entry that does not
+// set them itself. Zero values mean "not set".
+type DefaultsConfig struct {
+	TimeoutSeconds           int               `json:"timeoutSeconds"`
+	Retries                  int               `json:"retries"`
+	MaxConcurrent            int               `json:"maxConcurrent"`
+	MaxResponseBytes         int64             `json:"maxResponseBytes"`
+	MaxCallSeconds           int               `json:"maxCallSeconds"`
+	Initialize               string            `json:"initialize"`
+	InitializeTimeoutSeconds int               `json:"initializeTimeoutSeconds"`
+	LoadBalancing            string            `json:"loadBalancing"`
+	Auth                     map[string]string `json:"auth"` // Headers sent to every server; a server's own value for a header wins
+	Retry                    RetryConfig       `json:"retry"`
+	RateLimit                RateLimitConfig   `json:"rateLimit"`
+	TLS                      TLSConfig         `json:"tls"` // Used by http servers without a tls block of their own
+	// Prefix is used by servers without a prefix; "{name}" is replaced with
+	// the server name, so "{name}:" gives "github:" for the github server
+	Prefix string `json:"prefix"`
+}
+
+// applyDefaults fills the settings each server leaves unset from the
+// defaults section
+func (c *Config) applyDefaults() {
+	d := c.Defaults
+	for i := range c.Servers {
+		s := &c.Servers[i]
+
+		setInt(&s.TimeoutSeconds, d.TimeoutSeconds)
+		setInt(&s.Retries, d.Retries)
+		setInt(&s.MaxConcurrent, d.MaxConcurrent)
+		if s.MaxResponseBytes == 0 {
+			s.MaxResponseBytes = d.MaxResponseBytes
+		}
+		setInt(&s.MaxCallSeconds, d.MaxCallSeconds)
+		setString(&s.Initialize, d.Initialize)
+		setInt(&s.InitializeTimeoutSeconds, d.InitializeTimeoutSeconds)
+		setString(&s.LoadBalancing, d.LoadBalancing)
+
+		if len(d.Auth) > 0 {
+			auth := make(map[string]string, len(d.Auth)+len(s.Auth))
+			for header, value := range d.Auth {
+				auth[header] = value
+			}
+			for header, value := range s.Auth {
+				auth[header] = value
+			}
+			s.Auth = auth
+		}
+
+		setInt(&s.Retry.MaxAttempts, d.Retry.MaxAttempts)
+		setInt(&s.Retry.BackoffMs, d.Retry.BackoffMs)
+		setInt(&s.Retry.BudgetPercent, d.Retry.BudgetPercent)
+		if s.Retry.ReadOnlyTools == nil {
+			s.Retry.ReadOnlyTools = d.Retry.ReadOnlyTools
+		}
+		if s.RateLimit.RequestsPerSecond == 0 {
+			s.RateLimit.RequestsPerSecond = d.RateLimit.RequestsPerSecond
+		}
+		setInt(&s.RateLimit.Burst, d.RateLimit.Burst)
+		setInt(&s.RateLimit.MaxConcurrent, d.RateLimit.MaxConcurrent)
+
+		if !s.TLS.Configured() && s.TransportName() == "http" {
+			s.TLS = d.TLS
+		}
+
+		if s.Prefix == "" && d.Prefix != "" {
+			s.Prefix = strings.ReplaceAll(d.Prefix, "{name}", s.Name)
+		}
+	}
+}
+
+func setInt(value *int, def int) {
+	if *value == 0 {
+		*value = def
+	}
+}
+
+func setString(value *string, def string) {
+	if *value == "" {
+		*value = def
+	}
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// expandEnv replaces "${NAME}" and "${NAME:-default}" references in the
+// string values of a JSON document with environment variables. Other "$"
+// characters are kept as they are, so jq expressions and passwords need no
+// escaping. A reference to an unset variable without a default is a problem.
+func expandEnv(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte("${")) {
+		return data, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	var problems []string
+	doc = expandEnvValue("", doc, &problems)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return json.Marshal(doc)
+}
+
+// expandEnvValue expands the strings found in v; path locates v in the
+// document for problem reports
+func expandEnvValue(path string, v interface{}, problems *[]string) interface{} {
+	switch v := v.(type) {
+	case string:
+		expanded, missing := expandEnvString(v)
+		for _, name := range missing {
+			*problems = append(*problems, fmt.Sprintf("%s: environment variable %s is not set", path, name))
+		}
+		return expanded
+	case []interface{}:
+		for i, item := range v {
+			v[i] = expandEnvValue(fmt.Sprintf("%s[%d]", path, i), item, problems)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			field := key
+			if path != "" {
+				field = path + "." + key
+			}
+			v[key] = expandEnvValue(field, v[key], problems)
+		}
+	}
+	return v
+}
+
+// expandEnvString expands the references in s and returns the names of the
+// unset variables that had no default
+func expandEnvString(s string) (string, []string) {
+	var b strings.Builder
+	var missing []string
+	for {
+		start := strings.Index(s, "${")
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start:], '}')
+		if end < 0 {
+			break
+		}
+		b.WriteString(s[:start])
+
+		ref := s[start+2 : start+end]
+		name, def, hasDefault := strings.Cut(ref, ":-")
+		if value, ok := os.LookupEnv(name); ok && value != "" {
+			b.WriteString(value)
+		} else if hasDefault {
+			b.WriteString(def)
+		} else if !ok {
+			missing = append(missing, name)
+		}
+		s = s[start+end+1:]
+	}
+	b.WriteString(s)
+	return b.String(), missing
+}
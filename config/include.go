@@ -0,0 +1,107 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultServersDir is the include directory looked for next to the
+// configuration file when servers_dir is not set
+const DefaultServersDir = "servers.d"
+
+// loadServersDir appends the servers declared by the files of dir to the
+// configuration. Files are read in name order; only .json, .yaml and .yml
+// files are considered. A missing directory is not an error unless required.
+func (c *Config) loadServersDir(dir string, required bool) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read servers directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json", ".yaml", ".yml":
+			if !entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		servers, err := loadServersFile(path)
+		if err != nil {
+			return err
+		}
+		for i := range servers {
+			servers[i].Source = path
+		}
+		c.Servers = append(c.Servers, servers...)
+	}
+	return nil
+}
+
+// loadServersFile reads the servers declared by one include file, which holds
+// a single server, a list of servers, an object with a "servers" list or a
+// Claude Desktop style "mcpServers" object
+func loadServersFile(path string) ([]MCPConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		data, err = yamlToJSON(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	}
+
+	data, err = expandEnv(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var servers []MCPConfig
+		if err := json.Unmarshal(data, &servers); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return servers, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if _, ok := fields["mcpServers"]; ok {
+		servers, err := parseClaudeServers(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return servers, nil
+	}
+	if list, ok := fields["servers"]; ok {
+		var servers []MCPConfig
+		if err := json.Unmarshal(list, &servers); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return servers, nil
+	}
+
+	var server MCPConfig
+	if err := json.Unmarshal(data, &server); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return []MCPConfig{server}, nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keyringCommand returns the command printing the password stored in the OS
+// keyring for service: the macOS keychain through "security" and the Secret
+// Service (GNOME Keyring, KWallet) through "secret-tool" elsewhere
+func keyringCommand(service string) (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("security", "find-generic-password", "-s", service, "-w"), nil
+	case "windows":
+		return nil, fmt.Errorf("the OS keyring is not supported on windows; use secrets_file instead")
+	default:
+		return exec.Command("secret-tool", "lookup", "service", service), nil
+	}
+}
+
+// readKeyring returns the secret stored in the OS keyring for service
+func readKeyring(service string) (string, error) {
+	if service == "" {
+		return "", fmt.Errorf("keyring service name is empty")
+	}
+	cmd, err := keyringCommand(service)
+	if err != nil {
+		return "", err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%v: %s", err, msg)
+		}
+		return "", fmt.Errorf("failed to read %q from the keyring: %w", service, err)
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// KubeconfigContext is the cluster connection and credentials of a
+// kubeconfig context, with file references already read
+type KubeconfigContext struct {
+	Name                     string
+	Server                   string
+	CertificateAuthorityData []byte
+	InsecureSkipTLSVerify    bool
+	Namespace                string // Empty when the context sets none
+
+	Token                 string
+	ClientCertificateData []byte
+	ClientKeyData         []byte
+	// ExecCommand is a credential plugin, such as "aws" or
+	// "gke-gcloud-auth-plugin", printing an ExecCredential
+	ExecCommand string
+	ExecArgs    []string
+	ExecEnv     []string // "NAME=value" pairs added to the environment
+}
+
+// kubeconfigFile is the part of a kubeconfig file LoadKubeconfig uses
+type kubeconfigFile struct {
+	CurrentContext string `json:"current-context"`
+	Clusters       []struct {
+		Name    string `json:"name"`
+		Cluster struct {
+			Server                   string `json:"server"`
+			CertificateAuthority     string `json:"certificate-authority"`
+			CertificateAuthorityData string `json:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+		} `json:"cluster"`
+	} `json:"clusters"`
+	Contexts []struct {
+		Name    string `json:"name"`
+		Context struct {
+			Cluster   string `json:"cluster"`
+			User      string `json:"user"`
+			Namespace string `json:"namespace"`
+		} `json:"context"`
+	} `json:"contexts"`
+	Users []struct {
+		Name string `json:"name"`
+		User struct {
+			Token                 string `json:"token"`
+			TokenFile             string `json:"tokenFile"`
+			ClientCertificate     string `json:"client-certificate"`
+			ClientCertificateData string `json:"client-certificate-data"`
+			ClientKey             string `json:"client-key"`
+			ClientKeyData         string `json:"client-key-data"`
+			Exec                  *struct {
+				Command string   `json:"command"`
+				Args    []string `json:"args"`
+				Env     []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"env"`
+			} `json:"exec"`
+		} `json:"user"`
+	} `json:"users"`
+}
+
+// DefaultKubeconfigPath returns the kubeconfig kubectl would use: the first
+// file of KUBECONFIG, else ~/.kube/config
+func DefaultKubeconfigPath() string {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		return filepath.SplitList(env)[0]
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".kube", "config")
+}
+
+// LoadKubeconfig reads the named context of a kubeconfig file, or its
+// current context when contextName is empty. Relative file references are
+// resolved against the directory of the file.
+func LoadKubeconfig(path, contextName string) (*KubeconfigContext, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	if data, err = yamlToJSON(data); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig %s: %w", path, err)
+	}
+	var file kubeconfigFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig %s: %w", path, err)
+	}
+
+	if contextName == "" {
+		contextName = file.CurrentContext
+	}
+	if contextName == "" {
+		return nil, fmt.Errorf("kubeconfig %s has no current context; set one", path)
+	}
+	result := &KubeconfigContext{Name: contextName}
+	var clusterName, userName string
+	found := false
+	for _, c := range file.Contexts {
+		if c.Name == contextName {
+			clusterName, userName = c.Context.Cluster, c.Context.User
+			result.Namespace = c.Context.Namespace
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("kubeconfig %s has no context %q", path, contextName)
+	}
+
+	// readData returns inline base64 data, or the content of a file
+	baseDir := filepath.Dir(path)
+	readData := func(field, inline, file string) ([]byte, error) {
+		if inline != "" {
+			decoded, err := base64.StdEncoding.DecodeString(inline)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s-data in kubeconfig: %w", field, err)
+			}
+			return decoded, nil
+		}
+		if file == "" {
+			return nil, nil
+		}
+		content, err := os.ReadFile(resolveRelative(baseDir, file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s of kubeconfig: %w", field, err)
+		}
+		return content, nil
+	}
+
+	found = false
+	for _, c := range file.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		found = true
+		result.Server = c.Cluster.Server
+		result.InsecureSkipTLSVerify = c.Cluster.InsecureSkipTLSVerify
+		if result.CertificateAuthorityData, err = readData("certificate-authority", c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority); err != nil {
+			return nil, err
+		}
+		break
+	}
+	if !found || result.Server == "" {
+		return nil, fmt.Errorf("kubeconfig %s has no server for cluster %q", path, clusterName)
+	}
+
+	for _, u := range file.Users {
+		if u.Name != userName {
+			continue
+		}
+		result.Token = u.User.Token
+		if result.Token == "" && u.User.TokenFile != "" {
+			token, err := os.ReadFile(resolveRelative(baseDir, u.User.TokenFile))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read tokenFile of kubeconfig: %w", err)
+			}
+			result.Token = strings.TrimSpace(string(token))
+		}
+		if result.ClientCertificateData, err = readData("client-certificate", u.User.ClientCertificateData, u.User.ClientCertificate); err != nil {
+			return nil, err
+		}
+		if result.ClientKeyData, err = readData("client-key", u.User.ClientKeyData, u.User.ClientKey); err != nil {
+			return nil, err
+		}
+		if exec := u.User.Exec; exec != nil {
+			result.ExecCommand = exec.Command
+			result.ExecArgs = exec.Args
+			for _, env := range exec.Env {
+				result.ExecEnv = append(result.ExecEnv, env.Name+"="+env.Value)
+			}
+		}
+		break
+	}
+	return result, nil
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ProfileEnv names the environment variable selecting the profile applied by
+// LoadConfig
+const ProfileEnv = "MCP_PROFILE"
+
+// ProfileConfig overrides settings of the servers for one environment, such
+// as dev, staging or prod
+type ProfileConfig struct {
+	Servers map[string]ServerOverride `json:"servers"` // Keyed by server name
+}
+
+// ServerOverride replaces settings of a server in a profile. Unset fields
+// keep the value of the server entry; auth headers are merged.
+type ServerOverride struct {
+	URL      string            `json:"url"`
+	Replicas []string          `json:"replicas"`
+	Auth     map[string]string `json:"auth"`
+	Enabled  *bool             `json:"enabled"`
+}
+
+// applyProfile applies the overrides of the named profile
+func (c *Config) applyProfile(name string) error {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		available := make([]string, 0, len(c.Profiles))
+		for profile := range c.Profiles {
+			available = append(available, profile)
+		}
+		sort.Strings(available)
+		return fmt.Errorf("unknown profile %q (available: %v)", name, available)
+	}
+
+	var problems []string
+	for _, serverName := range sortedOverrideKeys(profile.Servers) {
+		override := profile.Servers[serverName]
+		found := false
+		for i := range c.Servers {
+			s := &c.Servers[i]
+			if s.Name != serverName {
+				continue
+			}
+			found = true
+			if override.URL != "" {
+				s.URL = override.URL
+			}
+			if override.Replicas != nil {
+				s.Replicas = override.Replicas
+			}
+			if len(override.Auth) > 0 {
+				auth := make(map[string]string, len(s.Auth)+len(override.Auth))
+				for header, value := range s.Auth {
+					auth[header] = value
+				}
+				for header, value := range override.Auth {
+					auth[header] = value
+				}
+				s.Auth = auth
+			}
+			if override.Enabled != nil {
+				s.Enabled = *override.Enabled
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("profiles.%s.servers.%s: no server named %q is configured", name, serverName, serverName))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	c.Profile = name
+	return nil
+}
+
+// sortedOverrideKeys returns the keys of m in order
+func sortedOverrideKeys(m map[string]ServerOverride) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,314 @@
+package config
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables read by remote configuration sources
+const (
+	// ConfigAuthEnv holds the Authorization header sent to http(s) sources
+	ConfigAuthEnv = "MCP_CONFIG_AUTH"
+	// gcsTokenEnv holds an OAuth access token for gs:// sources
+	gcsTokenEnv = "GOOGLE_OAUTH_ACCESS_TOKEN"
+)
+
+// s3UnsignedPayload is the payload hash used for signed S3 GET requests
+const s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+// IsRemoteSource reports whether path names a remote configuration source
+// rather than a local file
+func IsRemoteSource(path string) bool {
+	for _, scheme := range []string{"http://", "https://", "s3://", "gs://"} {
+		if strings.HasPrefix(path, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoteSource fetches a configuration file from an http(s) URL, an S3
+// object (s3://bucket/key) or a Google Cloud Storage object
+// (gs://bucket/object), so a fleet of gateways can share a centrally managed
+// catalog. Requests carry the ETag of the last fetch, so an unchanged file is
+// not downloaded or parsed again.
+//
+// http(s) sources send MCP_CONFIG_AUTH as the Authorization header. S3
+// requests are signed with AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and the
+// optional AWS_SESSION_TOKEN for AWS_REGION (default us-east-1);
+// AWS_ENDPOINT_URL_S3 selects an S3-compatible endpoint. GCS requests send
+// GOOGLE_OAUTH_ACCESS_TOKEN when it is set.
+type RemoteSource struct {
+	URL string
+
+	client *http.Client
+	mu     sync.Mutex
+	etag   string
+}
+
+// NewRemoteSource creates a source for a remote configuration URL
+func NewRemoteSource(url string) *RemoteSource {
+	return &RemoteSource{
+		URL:    url,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Load fetches and parses the configuration, applying the given profile. It
+// returns changed == false with a nil configuration when the file has not
+// changed since the previous Load.
+func (r *RemoteSource) Load(ctx context.Context, profile string) (*Config, bool, error) {
+	data, yaml, changed, err := r.fetch(ctx)
+	if err != nil || !changed {
+		return nil, changed, err
+	}
+	cfg, err := parseConfig(data, yaml, "", profile)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", r.URL, err)
+	}
+	return cfg, true, nil
+}
+
+// fetch downloads the file unless its ETag is unchanged, and reports whether
+// it is YAML
+func (r *RemoteSource) fetch(ctx context.Context) ([]byte, bool, bool, error) {
+	req, err := r.newRequest(ctx)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	r.mu.Lock()
+	etag := r.etag
+	r.mu.Unlock()
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if strings.HasPrefix(r.URL, "s3://") {
+		if err := signS3Request(req, time.Now()); err != nil {
+			return nil, false, false, err
+		}
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("failed to fetch config from %s: %w", r.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, false, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, false, false, fmt.Errorf("failed to fetch config from %s: status %d: %s", r.URL, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("failed to fetch config from %s: %w", r.URL, err)
+	}
+
+	r.mu.Lock()
+	r.etag = resp.Header.Get("ETag")
+	r.mu.Unlock()
+
+	ext := strings.ToLower(path.Ext(req.URL.Path))
+	yaml := ext == ".yaml" || ext == ".yml" || strings.Contains(resp.Header.Get("Content-Type"), "yaml")
+	return data, yaml, true, nil
+}
+
+// newRequest builds the GET request for the source
+func (r *RemoteSource) newRequest(ctx context.Context) (*http.Request, error) {
+	target := r.URL
+	authorization := ""
+
+	switch {
+	case strings.HasPrefix(r.URL, "s3://"):
+		bucket, key, ok := strings.Cut(strings.TrimPrefix(r.URL, "s3://"), "/")
+		if !ok || bucket == "" || key == "" {
+			return nil, fmt.Errorf("invalid S3 URI %q (expected s3://bucket/key)", r.URL)
+		}
+		if endpoint := os.Getenv("AWS_ENDPOINT_URL_S3"); endpoint != "" {
+			// Path-style addressing for S3-compatible stores
+			target = strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/" + awsURIEncode(key, false)
+		} else {
+			target = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, awsRegion(), awsURIEncode(key, false))
+		}
+	case strings.HasPrefix(r.URL, "gs://"):
+		bucket, object, ok := strings.Cut(strings.TrimPrefix(r.URL, "gs://"), "/")
+		if !ok || bucket == "" || object == "" {
+			return nil, fmt.Errorf("invalid GCS URI %q (expected gs://bucket/object)", r.URL)
+		}
+		target = "https://storage.googleapis.com/" + bucket + "/" + awsURIEncode(object, false)
+		if token := os.Getenv(gcsTokenEnv); token != "" {
+			authorization = "Bearer " + token
+		}
+	default:
+		authorization = os.Getenv(ConfigAuthEnv)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config source %q: %w", r.URL, err)
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return req, nil
+}
+
+// Watch polls the source every interval and calls fn with each changed
+// configuration until ctx is done. Errors are passed to onError and the
+// previous configuration stays in effect.
+func (r *RemoteSource) Watch(ctx context.Context, interval time.Duration, profile string, fn func(*Config), onError func(error)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			cfg, changed, err := r.Load(ctx, profile)
+			if err != nil {
+				onError(err)
+				continue
+			}
+			if changed {
+				fn(cfg)
+			}
+		}
+	}()
+}
+
+// awsRegion returns the configured AWS region
+func awsRegion() string {
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := os.Getenv(env); region != "" {
+			return region
+		}
+	}
+	return "us-east-1"
+}
+
+// signS3Request signs req with AWS Signature Version 4 using the credentials
+// of the environment. Without credentials the request is sent unsigned, which
+// works for public objects.
+func signS3Request(req *http.Request, now time.Time) error {
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return nil
+	}
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+	if req.Header.Get("X-Amz-Content-Sha256") == "" {
+		req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+	}
+	signAWSv4(req, accessKey, secretKey, awsRegion(), "s3", now)
+	return nil
+}
+
+// signAWSv4 adds the Signature Version 4 Authorization header to req,
+// signing the host, range and x-amz-* headers
+func signAWSv4(req *http.Request, accessKey, secretKey, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "range" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalPath := req.URL.EscapedPath()
+	if canonicalPath == "" {
+		canonicalPath = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query parameters sorted by name as SigV4 requires
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, awsURIEncode(name, true)+"="+awsURIEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsURIEncode percent-encodes everything but unreserved characters and,
+// unless encodeSlash is set, "/"
+func awsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.IndexByte("-._~", c) >= 0 || c == '/' && !encodeSlash {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
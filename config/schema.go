@@ -0,0 +1,66 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// schemaEnums lists the accepted values of enumerated fields, keyed by the
+// struct type and JSON name of the field
+var schemaEnums = map[string][]string{
+	"MCPConfig.transport":          {"http", "stdio"},
+	"MCPConfig.loadBalancing":      {"failover", "round_robin", "least_pending"},
+	"MCPConfig.initialize":         {"lazy", "eager", "background"},
+	"DefaultsConfig.loadBalancing": {"failover", "round_robin", "least_pending"},
+	"DefaultsConfig.initialize":    {"lazy", "eager", "background"},
+	"TransformConfig.type":         {"truncate", "strip_ansi", "json_field", "jq"},
+	"ClaudeServer.type":            {"stdio", "http", "streamable-http", "streamableHttp"},
+}
+
+// Schema returns a JSON Schema describing the configuration file, for editors
+// and CI checks. It is derived from the Config type so it cannot drift from
+// what LoadConfig accepts.
+func Schema() map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(Config{}))
+	schema["properties"].(map[string]interface{})["mcpServers"] = typeSchema(reflect.TypeOf(map[string]ClaudeServer{}))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "MCP gateway configuration"
+	return schema
+}
+
+// typeSchema returns the schema of a Go type
+func typeSchema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" || name == "-" || !field.IsExported() {
+				continue
+			}
+			property := typeSchema(field.Type)
+			if values, ok := schemaEnums[t.Name()+"."+name]; ok && field.Type.Kind() == reflect.String {
+				property["enum"] = values
+			}
+			properties[name] = property
+		}
+		return map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+	}
+	// interface{} values such as injected arguments accept anything
+	return map[string]interface{}{}
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// SecretsPassphraseEnv names the environment variable holding the passphrase
+// of the encrypted secrets file
+const SecretsPassphraseEnv = "MCP_SECRETS_PASSPHRASE"
+
+// Encrypted secrets files start with sealedMagic, followed by the key
+// derivation salt, the AES-GCM nonce and the sealed JSON object
+const (
+	sealedMagic      = "MCPSECRETS1"
+	sealedSaltSize   = 16
+	sealedIterations = 200000
+)
+
+// EncryptSecrets seals a name -> value map with a key derived from the
+// passphrase, producing the contents of a secrets_file
+func EncryptSecrets(secrets map[string]string, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase is empty")
+	}
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, sealedSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := sealedCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := append([]byte(sealedMagic), salt...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plain, []byte(sealedMagic)), nil
+}
+
+// DecryptSecrets opens data produced by EncryptSecrets
+func DecryptSecrets(data []byte, passphrase string) (map[string]string, error) {
+	if !bytes.HasPrefix(data, []byte(sealedMagic)) {
+		return nil, errors.New("not an encrypted secrets file")
+	}
+	data = data[len(sealedMagic):]
+	if len(data) < sealedSaltSize {
+		return nil, errors.New("encrypted secrets file is truncated")
+	}
+	salt, data := data[:sealedSaltSize], data[sealedSaltSize:]
+
+	aead, err := sealedCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("encrypted secrets file is truncated")
+	}
+	nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, sealed, []byte(sealedMagic))
+	if err != nil {
+		return nil, errors.New("failed to decrypt secrets file: wrong passphrase or corrupted file")
+	}
+
+	var secrets map[string]string
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse decrypted secrets: %w", err)
+	}
+	return secrets, nil
+}
+
+// loadSealedSecrets decrypts a secrets file with the passphrase taken from
+// the environment
+func loadSealedSecrets(path string) (map[string]string, error) {
+	passphrase := os.Getenv(SecretsPassphraseEnv)
+	if passphrase == "" {
+		return nil, fmt.Errorf("%s is not set", SecretsPassphraseEnv)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets file: %w", err)
+	}
+	return DecryptSecrets(data, passphrase)
+}
+
+// sealedCipher returns the AES-256-GCM cipher keyed by the passphrase
+func sealedCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2SHA256([]byte(passphrase), salt, sealedIterations, 32))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2SHA256 derives a key of keyLen bytes as specified by RFC 8018
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var key []byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		var counter [4]byte
+		binary.BigEndian.PutUint32(counter[:], block)
+		prf.Write(counter[:])
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
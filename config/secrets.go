@@ -0,0 +1,248 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// secretFilePrefix marks a value that is read from a file, e.g.
+// "file:///run/secrets/token"
+const secretFilePrefix = "file://"
+
+// secretKeyringPrefix marks a value read from the OS keyring, e.g.
+// "keyring:github-token"
+const secretKeyringPrefix = "keyring:"
+
+// secretSealedPrefix marks a value read from the encrypted secrets_file,
+// e.g. "secret:github_token"
+const secretSealedPrefix = "secret:"
+
+// ResolveSecrets replaces values that reference secrets with the secrets
+// themselves, so plaintext credentials need not live in the configuration.
+// A reference is either a file ("file:///run/secrets/token", for Docker or
+// Kubernetes secret mounts), an entry of the OS keyring ("keyring:service")
+// or an entry of the encrypted secrets_file ("secret:name", unlocked with
+// MCP_SECRETS_PASSPHRASE). It applies to auth header values, stdio env
+// values, the bearer token, search API keys and SQL connection DSNs.
+// A value may also keep a literal scheme in front of the reference, as in
+// "Bearer keyring:github".
+// Entries of a server's authFiles are read as files and set the whole header
+// value. Trailing newlines of files and keyring entries are removed.
+func (c *Config) ResolveSecrets() error {
+	var problems []string
+	resolver := &secretResolver{sealedFile: c.SecretsFile}
+	resolve := func(path string, value *string) {
+		resolved, err := resolver.resolve(*value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", path, err))
+			return
+		}
+		*value = resolved
+	}
+
+	resolve("bearer_token", &c.BearerToken)
+	resolve("google_pse.api_key", &c.GooglePSE.APIKey)
+	resolve("brave_search.api_key", &c.BraveSearch.APIKey)
+	resolve("knowledge_base.embedding.api_key", &c.KnowledgeBase.Embedding.APIKey)
+	resolve("email.password", &c.Email.Password)
+	resolve("slack.bot_token", &c.Slack.BotToken)
+	resolve("telegram.bot_token", &c.Telegram.BotToken)
+	resolve("github.token", &c.GitHub.Token)
+	resolve("jira.api_token", &c.Jira.APIToken)
+	resolve("ocr.api_key", &c.OCR.APIKey)
+
+	for _, name := range sortedKeys(c.SQL.Connections) {
+		connection := c.SQL.Connections[name]
+		resolve(fmt.Sprintf("sql.connections.%s.dsn", name), &connection.DSN)
+		c.SQL.Connections[name] = connection
+	}
+
+	for _, name := range sortedKeys(c.S3.Buckets) {
+		bucket := c.S3.Buckets[name]
+		resolve(fmt.Sprintf("s3.buckets.%s.access_key_id", name), &bucket.AccessKeyID)
+		resolve(fmt.Sprintf("s3.buckets.%s.secret_access_key", name), &bucket.SecretAccessKey)
+		resolve(fmt.Sprintf("s3.buckets.%s.session_token", name), &bucket.SessionToken)
+		c.S3.Buckets[name] = bucket
+	}
+
+	for i := range c.Servers {
+		s := &c.Servers[i]
+		path := fmt.Sprintf("servers[%d]", i)
+
+		for _, header := range sortedKeys(s.AuthFiles) {
+			value := secretFilePrefix + s.AuthFiles[header]
+			resolve(fmt.Sprintf("%s.authFiles.%s", path, header), &value)
+			if s.Auth == nil {
+				s.Auth = make(map[string]string)
+			}
+			s.Auth[header] = value
+		}
+		for _, header := range sortedKeys(s.Auth) {
+			value := s.Auth[header]
+			resolve(fmt.Sprintf("%s.auth.%s", path, header), &value)
+			s.Auth[header] = value
+		}
+		for _, name := range sortedKeys(s.Env) {
+			value := s.Env[name]
+			resolve(fmt.Sprintf("%s.env.%s", path, name), &value)
+			s.Env[name] = value
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// secretResolver reads the secrets referenced by configuration values. The
+// encrypted secrets file is decrypted once, when first referenced.
+type secretResolver struct {
+	sealedFile string
+	sealed     map[string]string
+	sealedErr  error
+	sealedRead bool
+}
+
+// resolve returns value with a trailing secret reference replaced by the
+// secret it points to
+func (r *secretResolver) resolve(value string) (string, error) {
+	for _, prefix := range []string{secretFilePrefix, secretKeyringPrefix, secretSealedPrefix} {
+		idx := strings.LastIndex(value, prefix)
+		if idx < 0 || (idx > 0 && value[idx-1] != ' ') {
+			continue
+		}
+		secret, err := r.read(prefix, value[idx+len(prefix):])
+		if err != nil {
+			return "", err
+		}
+		return value[:idx] + secret, nil
+	}
+	return value, nil
+}
+
+// read returns the secret named ref in the store selected by prefix
+func (r *secretResolver) read(prefix, ref string) (string, error) {
+	switch prefix {
+	case secretKeyringPrefix:
+		return readKeyring(ref)
+	case secretSealedPrefix:
+		if r.sealedFile == "" {
+			return "", fmt.Errorf("secret %q is referenced but no secrets_file is configured", ref)
+		}
+		if !r.sealedRead {
+			r.sealed, r.sealedErr = loadSealedSecrets(r.sealedFile)
+			r.sealedRead = true
+		}
+		if r.sealedErr != nil {
+			return "", r.sealedErr
+		}
+		secret, ok := r.sealed[ref]
+		if !ok {
+			return "", fmt.Errorf("secret %q is not in %s", ref, r.sealedFile)
+		}
+		return secret, nil
+	}
+
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// sortedKeys returns the keys of m in order, so problems are reported
+// deterministically
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// redactedValue replaces secrets in Redacted configurations
+const redactedValue = "REDACTED"
+
+// Redacted returns a copy of the configuration with the values that may hold
+// secrets replaced, so the effective configuration can be printed or logged
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	if redacted.BearerToken != "" {
+		redacted.BearerToken = redactedValue
+	}
+	if redacted.GooglePSE.APIKey != "" {
+		redacted.GooglePSE.APIKey = redactedValue
+	}
+	if redacted.BraveSearch.APIKey != "" {
+		redacted.BraveSearch.APIKey = redactedValue
+	}
+	if redacted.KnowledgeBase.Embedding.APIKey != "" {
+		redacted.KnowledgeBase.Embedding.APIKey = redactedValue
+	}
+	if redacted.Email.Password != "" {
+		redacted.Email.Password = redactedValue
+	}
+	if redacted.Slack.BotToken != "" {
+		redacted.Slack.BotToken = redactedValue
+	}
+	if redacted.Telegram.BotToken != "" {
+		redacted.Telegram.BotToken = redactedValue
+	}
+	if redacted.GitHub.Token != "" {
+		redacted.GitHub.Token = redactedValue
+	}
+	if redacted.Jira.APIToken != "" {
+		redacted.Jira.APIToken = redactedValue
+	}
+	if redacted.OCR.APIKey != "" {
+		redacted.OCR.APIKey = redactedValue
+	}
+
+	redacted.Defaults.Auth = redactValues(c.Defaults.Auth)
+
+	if c.SQL.Connections != nil {
+		redacted.SQL.Connections = make(map[string]SQLConnectionConfig, len(c.SQL.Connections))
+		for name, connection := range c.SQL.Connections {
+			connection.DSN = redactedValue
+			redacted.SQL.Connections[name] = connection
+		}
+	}
+
+	if c.S3.Buckets != nil {
+		redacted.S3.Buckets = make(map[string]S3BucketConfig, len(c.S3.Buckets))
+		for name, bucket := range c.S3.Buckets {
+			if bucket.SecretAccessKey != "" {
+				bucket.SecretAccessKey = redactedValue
+			}
+			if bucket.SessionToken != "" {
+				bucket.SessionToken = redactedValue
+			}
+			redacted.S3.Buckets[name] = bucket
+		}
+	}
+
+	redacted.Servers = make([]MCPConfig, len(c.Servers))
+	for i, s := range c.Servers {
+		s.Auth = redactValues(s.Auth)
+		s.AuthFiles = nil // Already merged into Auth
+		s.Env = redactValues(s.Env)
+		redacted.Servers[i] = s
+	}
+	return &redacted
+}
+
+// redactValues returns a copy of m with every value replaced
+func redactValues(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(m))
+	for key := range m {
+		redacted[key] = redactedValue
+	}
+	return redacted
+}
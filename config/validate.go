@@ -0,0 +1,505 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []string // Each problem is prefixed with the path of the field, e.g. "servers[1].url"
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// Validate checks the configuration for mistakes that would otherwise only
+// surface at runtime. All problems are reported at once.
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(path, format string, args ...interface{}) {
+		problems = append(problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if c.ToolRefreshSeconds < 0 {
+		add("tool_refresh_seconds", "must not be negative")
+	}
+	if c.ConfigRefreshSeconds < 0 {
+		add("config_refresh_seconds", "must not be negative")
+	}
+
+	for i, allowed := range c.Filesystem.AllowedPaths {
+		if allowed == "" {
+			add(fmt.Sprintf("filesystem.allowedPaths[%d]", i), "must not be empty")
+		}
+	}
+	if c.Filesystem.MaxReadBytes < 0 {
+		add("filesystem.maxReadBytes", "must not be negative")
+	}
+	if c.Filesystem.MaxWriteBytes < 0 {
+		add("filesystem.maxWriteBytes", "must not be negative")
+	}
+
+	if c.SearXNG.Enabled {
+		if u, err := url.Parse(c.SearXNG.URL); c.SearXNG.URL == "" || err != nil || u.Scheme == "" || u.Host == "" {
+			add("searxng.url", "must be the absolute URL of a SearXNG instance")
+		}
+	}
+	if c.HTTPRequest.TimeoutSeconds < 0 {
+		add("http_request.timeout_seconds", "must not be negative")
+	}
+	if c.HTTPRequest.MaxResponseBytes < 0 {
+		add("http_request.max_response_bytes", "must not be negative")
+	}
+	for i, host := range c.HTTPRequest.AllowedHosts {
+		if strings.TrimSpace(host) == "" {
+			add(fmt.Sprintf("http_request.allowed_hosts[%d]", i), "must not be empty")
+		}
+	}
+	if c.FetchPage.TimeoutSeconds < 0 {
+		add("fetch_page.timeout_seconds", "must not be negative")
+	}
+	if c.FetchPage.MaxLength < 0 {
+		add("fetch_page.max_length", "must not be negative")
+	}
+	if c.RunCommand.TimeoutSeconds < 0 {
+		add("run_command.timeout_seconds", "must not be negative")
+	}
+	if c.RunCommand.MaxOutputBytes < 0 {
+		add("run_command.max_output_bytes", "must not be negative")
+	}
+	for i, command := range c.RunCommand.AllowedCommands {
+		if command == "" {
+			add(fmt.Sprintf("run_command.allowed_commands[%d]", i), "must not be empty")
+		}
+	}
+	for i, name := range c.RunCommand.AllowedEnv {
+		upper := strings.ToUpper(name)
+		switch {
+		case name == "":
+			add(fmt.Sprintf("run_command.allowed_env[%d]", i), "must not be empty")
+		case upper == "PATH" || strings.HasPrefix(upper, "LD_") || strings.HasPrefix(upper, "DYLD_"):
+			add(fmt.Sprintf("run_command.allowed_env[%d]", i), "%s may not be set by callers", name)
+		}
+	}
+	if c.Git.Enabled && len(c.Git.Repositories) == 0 {
+		add("git.repositories", "is required when the git tools are enabled")
+	}
+	for i, repository := range c.Git.Repositories {
+		if repository == "" {
+			add(fmt.Sprintf("git.repositories[%d]", i), "must not be empty")
+		}
+	}
+	if c.Git.TimeoutSeconds < 0 {
+		add("git.timeout_seconds", "must not be negative")
+	}
+	if c.Git.MaxOutputBytes < 0 {
+		add("git.max_output_bytes", "must not be negative")
+	}
+	if c.SQL.Enabled && len(c.SQL.Connections) == 0 {
+		add("sql.connections", "is required when sql_query is enabled")
+	}
+	for _, name := range sortedKeys(c.SQL.Connections) {
+		connection := c.SQL.Connections[name]
+		if connection.Driver == "" {
+			add(fmt.Sprintf("sql.connections.%s.driver", name), "is required")
+		}
+		if connection.DSN == "" {
+			add(fmt.Sprintf("sql.connections.%s.dsn", name), "is required")
+		}
+	}
+	if c.SQL.TimeoutSeconds < 0 {
+		add("sql.timeout_seconds", "must not be negative")
+	}
+	if c.SQL.MaxRows < 0 {
+		add("sql.max_rows", "must not be negative")
+	}
+	if kb := c.KnowledgeBase; kb.Enabled {
+		if u, err := url.Parse(kb.Embedding.URL); kb.Embedding.URL == "" || err != nil || u.Scheme == "" || u.Host == "" {
+			add("knowledge_base.embedding.url", "must be the absolute URL of an OpenAI-compatible embeddings API")
+		}
+		if kb.Embedding.Model == "" {
+			add("knowledge_base.embedding.model", "is required when the knowledge base is enabled")
+		}
+	}
+	if c.S3.Enabled && len(c.S3.Buckets) == 0 {
+		add("s3.buckets", "is required when the S3 tools are enabled")
+	}
+	for _, name := range sortedKeys(c.S3.Buckets) {
+		if endpoint := c.S3.Buckets[name].Endpoint; endpoint != "" {
+			if u, err := url.Parse(endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+				add(fmt.Sprintf("s3.buckets.%s.endpoint", name), "must be an absolute URL")
+			}
+		}
+	}
+	if c.S3.TimeoutSeconds < 0 {
+		add("s3.timeout_seconds", "must not be negative")
+	}
+	if c.S3.MaxObjectBytes < 0 {
+		add("s3.max_object_bytes", "must not be negative")
+	}
+	if c.Docker.TimeoutSeconds < 0 {
+		add("docker.timeout_seconds", "must not be negative")
+	}
+	if c.Docker.MaxOutputBytes < 0 {
+		add("docker.max_output_bytes", "must not be negative")
+	}
+	for i, namespace := range c.Kubernetes.Namespaces {
+		if namespace == "" {
+			add(fmt.Sprintf("kubernetes.namespaces[%d]", i), "must not be empty")
+		}
+	}
+	if c.Kubernetes.TimeoutSeconds < 0 {
+		add("kubernetes.timeout_seconds", "must not be negative")
+	}
+	if c.Kubernetes.MaxOutputBytes < 0 {
+		add("kubernetes.max_output_bytes", "must not be negative")
+	}
+	if c.Email.Enabled {
+		if c.Email.Host == "" {
+			add("email.host", "is required when send_email is enabled")
+		}
+		if _, err := mail.ParseAddress(c.Email.From); err != nil {
+			add("email.from", "must be an email address")
+		}
+		if len(c.Email.AllowedRecipientDomains) == 0 {
+			add("email.allowed_recipient_domains", "is required when send_email is enabled; use \"*\" to allow any domain")
+		}
+	}
+	if port := c.Email.Port; port < 0 || port > 65535 {
+		add("email.port", "must be between 0 and 65535")
+	}
+	switch c.Email.TLS {
+	case "", "starttls", "tls", "none":
+	default:
+		add("email.tls", "must be starttls, tls or none")
+	}
+	if c.Email.MaxPerHour < 0 {
+		add("email.max_per_hour", "must not be negative")
+	}
+	if c.Email.MaxRecipients < 0 {
+		add("email.max_recipients", "must not be negative")
+	}
+	if c.Slack.Enabled && len(c.Slack.Channels) == 0 {
+		add("slack.channels", "is required when the Slack tools are enabled")
+	}
+	for _, name := range sortedKeys(c.Slack.Channels) {
+		if c.Slack.Channels[name] == "" {
+			add(fmt.Sprintf("slack.channels.%s", name), "must be a channel ID")
+		}
+	}
+	if c.Slack.TimeoutSeconds < 0 {
+		add("slack.timeout_seconds", "must not be negative")
+	}
+	if c.Telegram.Enabled && len(c.Telegram.ChatIDs) == 0 {
+		add("telegram.chat_ids", "is required when the Telegram tools are enabled")
+	}
+	if c.Telegram.TimeoutSeconds < 0 {
+		add("telegram.timeout_seconds", "must not be negative")
+	}
+	if apiURL := c.GitHub.APIURL; apiURL != "" {
+		if u, err := url.Parse(apiURL); err != nil || u.Scheme == "" || u.Host == "" {
+			add("github.api_url", "must be an absolute URL")
+		}
+	}
+	for i, repo := range c.GitHub.Repositories {
+		if owner, name, ok := strings.Cut(repo, "/"); !ok || owner == "" || name == "" {
+			add(fmt.Sprintf("github.repositories[%d]", i), "must be \"owner/name\" or \"owner/*\"")
+		}
+	}
+	if c.GitHub.TimeoutSeconds < 0 {
+		add("github.timeout_seconds", "must not be negative")
+	}
+	if c.Jira.Enabled || c.Jira.URL != "" {
+		if u, err := url.Parse(c.Jira.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			add("jira.url", "must be an absolute URL")
+		}
+	}
+	for i, project := range c.Jira.Projects {
+		if project == "" {
+			add(fmt.Sprintf("jira.projects[%d]", i), "must not be empty")
+		}
+	}
+	if c.Jira.TimeoutSeconds < 0 {
+		add("jira.timeout_seconds", "must not be negative")
+	}
+	switch c.OCR.Engine {
+	case "", "tesseract":
+	case "google_vision":
+		if c.OCR.Enabled && c.OCR.APIKey == "" {
+			add("ocr.api_key", "is required by the google_vision engine")
+		}
+	default:
+		add("ocr.engine", "must be tesseract or google_vision")
+	}
+	if c.OCR.MaxImageBytes < 0 {
+		add("ocr.max_image_bytes", "must not be negative")
+	}
+	if c.OCR.TimeoutSeconds < 0 {
+		add("ocr.timeout_seconds", "must not be negative")
+	}
+	if c.Browser.Enabled && len(c.Browser.AllowedDomains) == 0 {
+		add("browser.allowed_domains", "is required when the browser tools are enabled; use [\"*\"] to allow every domain")
+	}
+	for i, domain := range c.Browser.AllowedDomains {
+		if strings.TrimSpace(domain) == "" {
+			add(fmt.Sprintf("browser.allowed_domains[%d]", i), "must not be empty")
+		}
+	}
+	if remote := c.Browser.RemoteURL; remote != "" {
+		if u, err := url.Parse(remote); err != nil || (u.Scheme != "ws" && u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("browser.remote_url", "must be a ws:// DevTools URL or an http:// debugging address")
+		}
+	}
+	if c.Browser.MaxPages < 0 {
+		add("browser.max_pages", "must not be negative")
+	}
+	if c.Browser.IdleTimeoutSeconds < 0 {
+		add("browser.idle_timeout_seconds", "must not be negative")
+	}
+	if c.Browser.MaxLength < 0 {
+		add("browser.max_length", "must not be negative")
+	}
+	if c.Browser.TimeoutSeconds < 0 {
+		add("browser.timeout_seconds", "must not be negative")
+	}
+	if c.Spreadsheet.MaxRows < 0 {
+		add("spreadsheet.max_rows", "must not be negative")
+	}
+	if c.Spreadsheet.MaxFileBytes < 0 {
+		add("spreadsheet.max_file_bytes", "must not be negative")
+	}
+	if c.JSONQuery.MaxInputBytes < 0 {
+		add("json_query.max_input_bytes", "must not be negative")
+	}
+	if c.JSONQuery.MaxLength < 0 {
+		add("json_query.max_length", "must not be negative")
+	}
+	if c.DNSLookup.TimeoutSeconds < 0 {
+		add("dns_lookup.timeout_seconds", "must not be negative")
+	}
+	if c.Whois.TimeoutSeconds < 0 {
+		add("whois.timeout_seconds", "must not be negative")
+	}
+	if c.Whois.MaxLength < 0 {
+		add("whois.max_length", "must not be negative")
+	}
+	if c.CrawlSite.MaxPages < 0 {
+		add("crawl_site.max_pages", "must not be negative")
+	}
+	if c.CrawlSite.MaxDepth < 0 {
+		add("crawl_site.max_depth", "must not be negative")
+	}
+	if c.CrawlSite.MaxLength < 0 {
+		add("crawl_site.max_length", "must not be negative")
+	}
+	if c.CrawlSite.TimeoutSeconds < 0 {
+		add("crawl_site.timeout_seconds", "must not be negative")
+	}
+	if c.YouTubeTranscript.MaxLength < 0 {
+		add("youtube_transcript.max_length", "must not be negative")
+	}
+	if c.YouTubeTranscript.TimeoutSeconds < 0 {
+		add("youtube_transcript.timeout_seconds", "must not be negative")
+	}
+	for _, name := range sortedKeys(c.ToolLimits) {
+		limits := c.ToolLimits[name]
+		if limits.TimeoutSeconds < 0 || limits.MaxOutputBytes < 0 || limits.CPUSeconds < 0 || limits.MemoryBytes < 0 {
+			add("tool_limits."+name, "limits must not be negative")
+		}
+	}
+	pluginNames := map[string]int{}
+	for i, plugin := range c.Plugins {
+		path := fmt.Sprintf("plugins[%d]", i)
+		if plugin.Name == "" {
+			add(path+".name", "is required")
+		} else if j, ok := pluginNames[plugin.Name]; ok {
+			add(path+".name", "duplicate plugin name %q (also used by plugins[%d])", plugin.Name, j)
+		} else {
+			pluginNames[plugin.Name] = i
+		}
+		if plugin.Command == "" {
+			add(path+".command", "is required")
+		}
+		if plugin.TimeoutSeconds < 0 {
+			add(path+".timeout_seconds", "must not be negative")
+		}
+		if plugin.MaxOutputBytes < 0 {
+			add(path+".max_output_bytes", "must not be negative")
+		}
+	}
+	if c.KnowledgeBase.ChunkSize < 0 {
+		add("knowledge_base.chunk_size", "must not be negative")
+	}
+	if c.KnowledgeBase.ChunkOverlap < 0 {
+		add("knowledge_base.chunk_overlap", "must not be negative")
+	}
+	if size := c.KnowledgeBase.ChunkSize; size > 0 && c.KnowledgeBase.ChunkOverlap >= size {
+		add("knowledge_base.chunk_overlap", "must be smaller than chunk_size")
+	}
+
+	names := make(map[string]int)
+	prefixes := make(map[string]int)
+	for i, s := range c.Servers {
+		path := fmt.Sprintf("servers[%d]", i)
+		if s.Source != "" {
+			path = fmt.Sprintf("%s (%s)", path, s.Source)
+		}
+
+		if s.Name == "" {
+			add(path+".name", "is required")
+		} else if j, exists := names[s.Name]; exists {
+			add(path+".name", "duplicate server name %q (also used by servers[%d])", s.Name, j)
+		} else {
+			names[s.Name] = i
+		}
+
+		if !s.Enabled {
+			continue
+		}
+
+		if s.Prefix != "" {
+			if j, exists := prefixes[s.Prefix]; exists {
+				add(path+".prefix", "prefix %q is also used by servers[%d]; tool names would be ambiguous", s.Prefix, j)
+			} else {
+				prefixes[s.Prefix] = i
+			}
+		}
+
+		switch s.TransportName() {
+		case "http":
+			if s.URL == "" {
+				add(path+".url", "is required for the http transport")
+			} else if err := validateHTTPURL(s.URL); err != nil {
+				add(path+".url", "%v", err)
+			}
+			for j, replica := range s.Replicas {
+				if err := validateHTTPURL(replica); err != nil {
+					add(fmt.Sprintf("%s.replicas[%d]", path, j), "%v", err)
+				}
+			}
+		case "stdio":
+			if s.Command == "" {
+				add(path+".command", "is required for the stdio transport")
+			}
+			if s.TLS.Configured() {
+				add(path+".tls", "is only supported for the http transport")
+			}
+			if len(s.Replicas) > 0 {
+				add(path+".replicas", "are only supported for the http transport")
+			}
+		default:
+			add(path+".transport", "unknown transport %q (supported: http, stdio)", s.Transport)
+		}
+
+		if len(s.AuthCommand) > 0 {
+			if s.TransportName() != "http" {
+				add(path+".authCommand", "is only supported for the http transport")
+			} else if s.AuthCommand[0] == "" {
+				add(path+".authCommand", "command is empty")
+			}
+			if _, exists := s.Auth["Authorization"]; exists {
+				add(path+".authCommand", "conflicts with auth.Authorization")
+			}
+		}
+		if s.AuthRefreshSeconds < 0 {
+			add(path+".authRefreshSeconds", "must not be negative")
+		}
+
+		if (s.TLS.CertFile == "") != (s.TLS.KeyFile == "") {
+			add(path+".tls", "certFile and keyFile must be set together")
+		}
+
+		headers := make([]string, 0, len(s.Auth))
+		for header := range s.Auth {
+			headers = append(headers, header)
+		}
+		sort.Strings(headers)
+		for _, header := range headers {
+			value := s.Auth[header]
+			if !isHeaderName(header) {
+				add(path+".auth", "%q is not a valid HTTP header name", header)
+			}
+			if value == "" {
+				add(fmt.Sprintf("%s.auth.%s", path, header), "value is empty")
+			} else if strings.ContainsAny(value, "\r\n") {
+				add(fmt.Sprintf("%s.auth.%s", path, header), "value must not contain line breaks")
+			}
+		}
+
+		switch s.LoadBalancing {
+		case "", "failover", "round_robin", "least_pending":
+		default:
+			add(path+".loadBalancing", "unknown strategy %q (supported: failover, round_robin, least_pending)", s.LoadBalancing)
+		}
+		switch s.Initialize {
+		case "", "lazy", "eager", "background":
+		default:
+			add(path+".initialize", "unknown mode %q (supported: lazy, eager, background)", s.Initialize)
+		}
+
+		if s.TimeoutSeconds < 0 || s.Retries < 0 || s.MaxConcurrent < 0 || s.MaxResponseBytes < 0 {
+			add(path, "timeoutSeconds, retries, maxConcurrent and maxResponseBytes must not be negative")
+		}
+		if s.MaxCallSeconds < 0 {
+			add(path+".maxCallSeconds", "must not be negative")
+		}
+		if s.InitializeTimeoutSeconds < 0 {
+			add(path+".initializeTimeoutSeconds", "must not be negative")
+		}
+		if s.Retry.MaxAttempts < 0 || s.Retry.BackoffMs < 0 || s.Retry.BudgetPercent < 0 {
+			add(path+".retry", "values must not be negative")
+		}
+		for j, pattern := range s.Tools.Include {
+			if !validPattern(pattern) {
+				add(fmt.Sprintf("%s.tools.include[%d]", path, j), "invalid pattern %q", pattern)
+			}
+		}
+		for j, pattern := range s.Tools.Exclude {
+			if !validPattern(pattern) {
+				add(fmt.Sprintf("%s.tools.exclude[%d]", path, j), "invalid pattern %q", pattern)
+			}
+		}
+		if s.RateLimit.RequestsPerSecond < 0 || s.RateLimit.Burst < 0 || s.RateLimit.MaxConcurrent < 0 {
+			add(path+".rateLimit", "values must not be negative")
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validateHTTPURL checks that raw is an absolute http or https URL
+func validateHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %v", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("URL %q must start with http:// or https://", raw)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("URL %q has no host", raw)
+	}
+	return nil
+}
+
+// isHeaderName reports whether name is a valid HTTP header field name
+func isHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune("\"(),/:;<=>?@[\\]{}", r) {
+			return false
+		}
+	}
+	return true
+}
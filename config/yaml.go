@@ -0,0 +1,517 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlToJSON converts a YAML document to JSON so YAML configuration files can
+// be decoded with the same struct tags as JSON ones. It supports the subset
+// of YAML used for configuration: block mappings and sequences, flow
+// collections on a single line, quoted and plain scalars, literal (|) and
+// folded (>) block scalars, and comments. Anchors, tags and multi-document
+// files are not supported.
+func yamlToJSON(data []byte) ([]byte, error) {
+	p := newYAMLParser(string(data))
+	p.skipBlank()
+	if p.done() {
+		return []byte("{}"), nil
+	}
+
+	value, err := p.parseNode(p.lines[p.pos].indent)
+	if err != nil {
+		return nil, err
+	}
+	p.skipBlank()
+	if !p.done() {
+		return nil, p.errorf("unexpected content %q", p.lines[p.pos].text)
+	}
+
+	return json.Marshal(value)
+}
+
+// yamlLine is one line of the document
+type yamlLine struct {
+	num    int    // 1-based line number
+	indent int    // Leading spaces
+	text   string // Content without indentation and comment
+	raw    string // Original line, used by block scalars
+}
+
+// yamlParser parses a document line by line
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// newYAMLParser splits a document into lines
+func newYAMLParser(doc string) *yamlParser {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(strings.ReplaceAll(doc, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimLeft(raw, " ")
+		text := strings.TrimSpace(stripYAMLComment(trimmed))
+		if text == "---" || text == "..." {
+			text = ""
+		}
+		p.lines = append(p.lines, yamlLine{
+			num:    i + 1,
+			indent: len(raw) - len(trimmed),
+			text:   text,
+			raw:    raw,
+		})
+	}
+	return p
+}
+
+// done reports whether every line has been consumed
+func (p *yamlParser) done() bool {
+	return p.pos >= len(p.lines)
+}
+
+// skipBlank moves past empty and comment-only lines
+func (p *yamlParser) skipBlank() {
+	for !p.done() && p.lines[p.pos].text == "" {
+		p.pos++
+	}
+}
+
+// errorf returns an error pointing at the current line
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	line := len(p.lines)
+	if !p.done() {
+		line = p.lines[p.pos].num
+	}
+	return fmt.Errorf("yaml line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// parseNode parses the block node starting at the current line, which is
+// indented by indent spaces
+func (p *yamlParser) parseNode(indent int) (interface{}, error) {
+	p.skipBlank()
+	if p.done() || p.lines[p.pos].indent < indent {
+		return nil, nil
+	}
+
+	line := p.lines[p.pos]
+	if strings.HasPrefix(line.raw[line.indent:], "\t") {
+		return nil, p.errorf("tabs are not allowed for indentation")
+	}
+	if isYAMLSequenceItem(line.text) {
+		return p.parseSequence(line.indent)
+	}
+	if _, _, ok := splitYAMLKey(line.text); ok {
+		return p.parseMapping(line.indent)
+	}
+
+	p.pos++
+	return parseYAMLInline(line.text)
+}
+
+// parseSequence parses "- item" lines indented by indent spaces
+func (p *yamlParser) parseSequence(indent int) ([]interface{}, error) {
+	items := []interface{}{}
+	for {
+		p.skipBlank()
+		if p.done() {
+			return items, nil
+		}
+		line := p.lines[p.pos]
+		if line.indent > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+		// A sequence may sit at its parent key's indentation, so the next
+		// key ends it as well as a dedent does
+		if line.indent < indent || !isYAMLSequenceItem(line.text) {
+			return items, nil
+		}
+
+		after := line.text[1:]
+		rest := strings.TrimLeft(after, " ")
+		if rest == "" {
+			// The item is the block on the following, more indented lines
+			p.pos++
+			item, err := p.parseNode(indent + 1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+
+		// Parse the item as if its content started its own line, so that
+		// "- name: x" followed by "  url: y" forms one mapping
+		offset := line.indent + 1 + len(after) - len(rest)
+		p.lines[p.pos].indent = offset
+		p.lines[p.pos].text = rest
+		item, err := p.parseNode(offset)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+}
+
+// parseMapping parses "key: value" lines indented by indent spaces
+func (p *yamlParser) parseMapping(indent int) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	for {
+		p.skipBlank()
+		if p.done() {
+			return result, nil
+		}
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			return result, nil
+		}
+		if line.indent > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+		if isYAMLSequenceItem(line.text) {
+			return nil, p.errorf("expected a mapping key, found %q", line.text)
+		}
+
+		key, rest, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, p.errorf("expected a mapping key, found %q", line.text)
+		}
+		if _, exists := result[key]; exists {
+			return nil, p.errorf("duplicate key %q", key)
+		}
+		p.pos++
+
+		var value interface{}
+		var err error
+		switch {
+		case rest == "":
+			value, err = p.parseNested(indent)
+		case rest[0] == '|' || rest[0] == '>':
+			value, err = p.parseBlockScalar(indent, rest)
+		default:
+			value, err = parseYAMLInline(rest)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result[key] = value
+	}
+}
+
+// parseNested parses the value of a key written on the lines after it. A
+// sequence may start at the key's own indentation.
+func (p *yamlParser) parseNested(indent int) (interface{}, error) {
+	p.skipBlank()
+	if p.done() {
+		return nil, nil
+	}
+	line := p.lines[p.pos]
+	if line.indent > indent {
+		return p.parseNode(line.indent)
+	}
+	if line.indent == indent && isYAMLSequenceItem(line.text) {
+		return p.parseSequence(indent)
+	}
+	return nil, nil
+}
+
+// parseBlockScalar parses a literal (|) or folded (>) block scalar whose
+// lines are indented deeper than the key's indent
+func (p *yamlParser) parseBlockScalar(indent int, header string) (string, error) {
+	style, chomp := header[0], byte(0)
+	if len(header) > 1 {
+		chomp = header[1]
+		if (chomp != '-' && chomp != '+') || len(header) > 2 {
+			return "", p.errorf("unsupported block scalar header %q", header)
+		}
+	}
+
+	var lines []string
+	blockIndent := -1
+	for !p.done() {
+		line := p.lines[p.pos]
+		if strings.TrimSpace(line.raw) == "" {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		if line.indent <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = line.indent
+		}
+		if line.indent < blockIndent {
+			return "", p.errorf("inconsistent block scalar indentation")
+		}
+		lines = append(lines, line.raw[blockIndent:])
+		p.pos++
+	}
+
+	// Trailing blank lines belong to the chomping indicator, not the content
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var text string
+	if style == '|' {
+		text = strings.Join(lines, "\n")
+	} else {
+		var b strings.Builder
+		for i, line := range lines {
+			// Single line breaks fold into spaces; blank lines become breaks
+			switch {
+			case i == 0 || lines[i-1] == "":
+			case line == "":
+				b.WriteString("\n")
+			default:
+				b.WriteString(" ")
+			}
+			b.WriteString(line)
+		}
+		text = b.String()
+	}
+
+	switch {
+	case len(lines) == 0 || chomp == '-':
+	case chomp == '+':
+		text += strings.Repeat("\n", trailing+1)
+	default:
+		text += "\n"
+	}
+	return text, nil
+}
+
+// isYAMLSequenceItem reports whether text starts a block sequence item
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitYAMLKey splits "key: value" outside quotes and flow collections
+func splitYAMLKey(text string) (string, string, bool) {
+	if text == "" || text[0] == '[' || text[0] == '{' {
+		return "", "", false
+	}
+
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && i == 0:
+			quote = c
+		case c == ':' && (i+1 == len(text) || text[i+1] == ' '):
+			key, err := parseYAMLScalar(strings.TrimSpace(text[:i]))
+			if err != nil {
+				return "", "", false
+			}
+			return fmt.Sprint(key), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// stripYAMLComment removes a trailing "# comment" outside quotes
+func stripYAMLComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.ContainsRune(" \t[{,:", rune(text[i-1])) {
+				quote = c
+			}
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return text[:i]
+		}
+	}
+	return text
+}
+
+// parseYAMLInline parses a value written on a single line: a flow sequence,
+// a flow mapping or a scalar
+func parseYAMLInline(text string) (interface{}, error) {
+	if text != "" && (text[0] == '[' || text[0] == '{') {
+		f := &yamlFlow{text: text}
+		value, err := f.parse()
+		if err != nil {
+			return nil, err
+		}
+		f.skipSpace()
+		if f.pos != len(f.text) {
+			return nil, fmt.Errorf("yaml: unexpected %q after flow collection", f.text[f.pos:])
+		}
+		return value, nil
+	}
+	return parseYAMLScalar(text)
+}
+
+// parseYAMLScalar parses a quoted or plain scalar
+func parseYAMLScalar(text string) (interface{}, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	switch text[0] {
+	case '"':
+		s, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("yaml: invalid double-quoted string %s", text)
+		}
+		return s, nil
+	case '\'':
+		if len(text) < 2 || text[len(text)-1] != '\'' {
+			return nil, fmt.Errorf("yaml: invalid single-quoted string %s", text)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+
+	switch text {
+	case "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return n, nil
+	}
+	if strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "0o") {
+		if n, err := strconv.ParseInt(text, 0, 64); err == nil && !strings.Contains(text, "_") {
+			return n, nil
+		}
+	}
+	if strings.Trim(text, "0123456789+-.eE") == "" {
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			return f, nil
+		}
+	}
+	return text, nil
+}
+
+// yamlFlow parses a flow collection such as [a, b] or {a: 1, b: [2, 3]}
+type yamlFlow struct {
+	text string
+	pos  int
+}
+
+// skipSpace moves past spaces
+func (f *yamlFlow) skipSpace() {
+	for f.pos < len(f.text) && f.text[f.pos] == ' ' {
+		f.pos++
+	}
+}
+
+// parse parses the value at the current position
+func (f *yamlFlow) parse() (interface{}, error) {
+	f.skipSpace()
+	if f.pos >= len(f.text) {
+		return nil, fmt.Errorf("yaml: unterminated flow collection %s", f.text)
+	}
+
+	switch f.text[f.pos] {
+	case '[':
+		f.pos++
+		items := []interface{}{}
+		for {
+			f.skipSpace()
+			if f.pos < len(f.text) && f.text[f.pos] == ']' {
+				f.pos++
+				return items, nil
+			}
+			item, err := f.parse()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			if err := f.separator(']'); err != nil {
+				return nil, err
+			}
+		}
+	case '{':
+		f.pos++
+		result := map[string]interface{}{}
+		for {
+			f.skipSpace()
+			if f.pos < len(f.text) && f.text[f.pos] == '}' {
+				f.pos++
+				return result, nil
+			}
+			key, err := f.scalar(":,}")
+			if err != nil {
+				return nil, err
+			}
+			if f.pos >= len(f.text) || f.text[f.pos] != ':' {
+				return nil, fmt.Errorf("yaml: expected ':' after key %v in %s", key, f.text)
+			}
+			f.pos++
+			value, err := f.parse()
+			if err != nil {
+				return nil, err
+			}
+			result[fmt.Sprint(key)] = value
+			if err := f.separator('}'); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return f.scalar(",]}")
+}
+
+// separator consumes a comma, or leaves the closing bracket for the caller
+func (f *yamlFlow) separator(closing byte) error {
+	f.skipSpace()
+	if f.pos >= len(f.text) {
+		return fmt.Errorf("yaml: unterminated flow collection %s", f.text)
+	}
+	switch f.text[f.pos] {
+	case ',':
+		f.pos++
+		return nil
+	case closing:
+		return nil
+	}
+	return fmt.Errorf("yaml: unexpected %q in %s", f.text[f.pos], f.text)
+}
+
+// scalar parses a scalar ending before one of the stop characters
+func (f *yamlFlow) scalar(stop string) (interface{}, error) {
+	f.skipSpace()
+	start := f.pos
+	if f.pos < len(f.text) && (f.text[f.pos] == '"' || f.text[f.pos] == '\'') {
+		quote := f.text[f.pos]
+		f.pos++
+		for f.pos < len(f.text) && f.text[f.pos] != quote {
+			if f.text[f.pos] == '\\' && quote == '"' {
+				f.pos++
+			}
+			f.pos++
+		}
+		f.pos++
+		if f.pos > len(f.text) {
+			return nil, fmt.Errorf("yaml: unterminated string in %s", f.text)
+		}
+		value, err := parseYAMLScalar(f.text[start:f.pos])
+		f.skipSpace()
+		return value, err
+	}
+
+	for f.pos < len(f.text) && !strings.ContainsRune(stop, rune(f.text[f.pos])) {
+		f.pos++
+	}
+	return parseYAMLScalar(strings.TrimSpace(f.text[start:f.pos]))
+}
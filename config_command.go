@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mcp-go/config"
+	"os"
+)
+
+const configUsage = `usage:
+  mcp-go config validate [path]   load and validate a configuration file and print the effective configuration
+  mcp-go config schema            print the JSON Schema of the configuration file
+  mcp-go config encrypt-secrets <secrets.json> <output>
+                                  encrypt a JSON object of secrets for secrets_file with $MCP_SECRETS_PASSPHRASE`
+
+// runConfigCommand implements the "config" subcommand and returns the process
+// exit code
+func runConfigCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, configUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "validate":
+		if len(args) > 2 {
+			fmt.Fprintln(stderr, configUsage)
+			return 2
+		}
+		path := config.FindConfigFile()
+		if len(args) == 2 {
+			path = args[1]
+		}
+
+		// Loading applies the servers.d includes, environment references
+		// and secret files, then validates the result
+		cfg, err := config.LoadConfig(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", path, err)
+			return 1
+		}
+		if err := writeJSON(stdout, cfg.Redacted()); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		fmt.Fprintf(stderr, "%s: configuration is valid\n", path)
+		return 0
+	case "schema":
+		if err := writeJSON(stdout, config.Schema()); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		return 0
+	case "encrypt-secrets":
+		if len(args) != 3 {
+			fmt.Fprintln(stderr, configUsage)
+			return 2
+		}
+		if err := encryptSecretsFile(args[1], args[2]); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		fmt.Fprintf(stderr, "%s: encrypted %s\n", args[2], args[1])
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown config command %q\n%s\n", args[0], configUsage)
+		return 2
+	}
+}
+
+// encryptSecretsFile seals the JSON object of secrets in input into output
+func encryptSecretsFile(input, output string) error {
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	var secrets map[string]string
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return fmt.Errorf("%s must hold a JSON object of strings: %w", input, err)
+	}
+	sealed, err := config.EncryptSecrets(secrets, os.Getenv(config.SecretsPassphraseEnv))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt secrets (set %s): %w", config.SecretsPassphraseEnv, err)
+	}
+	return os.WriteFile(output, sealed, 0600)
+}
+
+// writeJSON writes v as indented JSON
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// configCommandRequested reports whether the process was started as
+// "mcp-go config ..."
+func configCommandRequested() bool {
+	return len(os.Args) > 1 && os.Args[1] == "config"
+}
@@ -11,7 +11,7 @@ PROJECT_ROOT="${SCRIPT_DIR}/.."
 # Build the Go binary into the deploy folder
 echo "🛠  Building Go binary..."
 cd "${PROJECT_ROOT}"
-go build -o deploy/mcp-server main.go
+go build -o deploy/mcp-server .
 
 # Build the Docker image using the deploy/docker-compose.yml
 echo "🐳  Building Docker image..."
@@ -0,0 +1,34 @@
+package gateway
+
+import (
+	"mcp-go/config"
+	"strings"
+)
+
+// injectArguments merges config-declared arguments into a tool call. The
+// precedence, from lowest to highest, is: server defaults, tool defaults,
+// caller arguments, server forced values, tool forced values. The caller's
+// map is never modified.
+func injectArguments(cfg config.MCPConfig, name string, arguments map[string]interface{}) map[string]interface{} {
+	toolName := strings.TrimPrefix(name, cfg.Prefix)
+	toolArgs := cfg.ToolArguments[toolName]
+
+	if len(cfg.Arguments.Defaults) == 0 && len(cfg.Arguments.Forced) == 0 &&
+		len(toolArgs.Defaults) == 0 && len(toolArgs.Forced) == 0 {
+		return arguments
+	}
+
+	merged := make(map[string]interface{}, len(arguments))
+	for _, layer := range []map[string]interface{}{
+		cfg.Arguments.Defaults,
+		toolArgs.Defaults,
+		arguments,
+		cfg.Arguments.Forced,
+		toolArgs.Forced,
+	} {
+		for k, v := range layer {
+			merged[k] = v
+		}
+	}
+	return merged
+}
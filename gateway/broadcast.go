@@ -0,0 +1,144 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"mcp-go/client"
+	"mcp-go/tools"
+	"mcp-go/transport"
+	"sort"
+	"sync"
+)
+
+// BroadcastToolName is the name of the built-in fan-out tool
+const BroadcastToolName = "gateway:broadcast"
+
+// BroadcastResult is the outcome of a broadcast call on one upstream server
+type BroadcastResult struct {
+	Server   string                  `json:"server"`
+	Tool     string                  `json:"tool"` // Tool name as exposed by the gateway (with prefix)
+	Response *transport.ToolResponse `json:"response,omitempty"`
+	Error    string                  `json:"error,omitempty"`
+}
+
+// GetBroadcastTool returns the definition of the built-in broadcast tool
+func GetBroadcastTool() transport.Tool {
+	return transport.Tool{
+		Name:        BroadcastToolName,
+		Description: "Call the same tool on every connected MCP server that exposes it and return the per-server results",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"tool": map[string]interface{}{
+					"type":        "string",
+					"description": "The tool name without any server prefix",
+				},
+				"arguments": map[string]interface{}{
+					"type":        "object",
+					"description": "Arguments passed unchanged to every server",
+				},
+			},
+			"required": []string{"tool"},
+		},
+	}
+}
+
+// Broadcast calls the named tool in parallel on every client exposing it.
+// The tool name is given without the client prefix. Results are sorted by
+// server name; a failure on one server is reported in its result rather than
+// failing the whole broadcast.
+func (g *Gateway) Broadcast(ctx context.Context, tool string, arguments map[string]interface{}) ([]BroadcastResult, error) {
+	clients := g.capableClients(ctx, capabilityTools)
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results []BroadcastResult
+	)
+	for _, c := range clients {
+		wg.Add(1)
+		go func(c client.Client) {
+			defer wg.Done()
+
+			tools, err := c.ListTools(ctx)
+			if err != nil {
+				return
+			}
+			fullName := c.GetPrefix() + tool
+			exposed := false
+			for _, t := range tools {
+				if t.Name == fullName {
+					exposed = true
+					break
+				}
+			}
+			if !exposed {
+				return
+			}
+
+			result := BroadcastResult{Server: c.GetName(), Tool: fullName}
+			resp, err := g.callClient(ctx, c, fullName, arguments)
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Response = resp
+			}
+
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("tool '%s' not found in any connected MCP server", tool)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Server < results[j].Server
+	})
+	return results, nil
+}
+
+// callBroadcastTool executes the built-in broadcast tool, labelling each
+// content item with the server that produced it
+func (g *Gateway) callBroadcastTool(ctx context.Context, arguments map[string]interface{}) (*transport.ToolResponse, error) {
+	var args struct {
+		Tool      string                 `arg:"tool,required"`
+		Arguments map[string]interface{} `arg:"arguments"`
+	}
+	if err := tools.DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	tool, toolArgs := args.Tool, args.Arguments
+	if g.ToolDisabled(tool) {
+		return nil, fmt.Errorf("tool '%s' not found", tool)
+	}
+	if toolArgs == nil {
+		toolArgs = make(map[string]interface{})
+	}
+
+	results, err := g.Broadcast(ctx, tool, toolArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &transport.ToolResponse{}
+	for _, r := range results {
+		if r.Error != "" {
+			resp.Content = append(resp.Content, transport.ContentItem{
+				Type: "text",
+				Text: fmt.Sprintf("[%s] error: %s", r.Server, r.Error),
+			})
+			continue
+		}
+		for _, item := range r.Response.Content {
+			if item.Type == "text" {
+				item.Text = fmt.Sprintf("[%s] %s", r.Server, item.Text)
+			}
+			resp.Content = append(resp.Content, item)
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,45 @@
+package gateway
+
+import (
+	"context"
+	"mcp-go/client"
+)
+
+// Capabilities aggregated by the gateway
+const (
+	capabilityTools     = "tools"
+	capabilityResources = "resources"
+	capabilityPrompts   = "prompts"
+)
+
+// supports reports whether a client may serve capability. A client that has
+// not been initialized yet is assumed to, since its capabilities are unknown;
+// once initialized it must advertise the capability with a value other than
+// false or null.
+func supports(c client.Client, capability string) bool {
+	caps := c.Capabilities()
+	if caps == nil {
+		return true
+	}
+	value, ok := caps[capability]
+	if !ok || value == nil {
+		return false
+	}
+	if enabled, isBool := value.(bool); isBool {
+		return enabled
+	}
+	return true
+}
+
+// capableClients returns the clients visible to ctx that may serve
+// capability, so aggregation skips requests that are bound to fail
+func (g *Gateway) capableClients(ctx context.Context, capability string) []client.Client {
+	clients := g.clientsFor(ctx)
+	capable := clients[:0]
+	for _, c := range clients {
+		if supports(c, capability) {
+			capable = append(capable, c)
+		}
+	}
+	return capable
+}
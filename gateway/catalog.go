@@ -0,0 +1,128 @@
+package gateway
+
+import (
+	"context"
+	"mcp-go/logging"
+	"mcp-go/transport"
+	"sort"
+	"time"
+)
+
+// CatalogChange describes how the aggregated tool list changed between two
+// refreshes
+type CatalogChange struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// Empty reports whether the change contains no tools
+func (c CatalogChange) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0
+}
+
+// OnToolsChanged registers fn to be called whenever a catalog refresh finds
+// added or removed tools
+func (g *Gateway) OnToolsChanged(fn func(CatalogChange)) {
+	g.catalogMu.Lock()
+	defer g.catalogMu.Unlock()
+	g.catalogWatchers = append(g.catalogWatchers, fn)
+}
+
+// RefreshCatalog re-lists the tools of every client and compares them with
+// the previous refresh. The first refresh only records the catalog. Tools of
+// a client whose listing fails are kept, so a transient upstream error is not
+// reported as the tools disappearing.
+func (g *Gateway) RefreshCatalog(ctx context.Context) CatalogChange {
+	tools, owners, failed := g.collectTools(ctx)
+
+	g.catalogMu.Lock()
+	previous := g.catalog
+	current := make(map[string]string, len(tools))
+	for _, tool := range tools {
+		current[tool.Name] = owners[tool.Name]
+	}
+	for name, owner := range previous {
+		if _, exists := current[name]; !exists && failed[owner] {
+			current[name] = owner
+		}
+	}
+	g.catalog = current
+	watchers := append([]func(CatalogChange){}, g.catalogWatchers...)
+	g.catalogMu.Unlock()
+
+	var change CatalogChange
+	if previous == nil {
+		return change
+	}
+	for name := range current {
+		if _, exists := previous[name]; !exists {
+			change.Added = append(change.Added, name)
+		}
+	}
+	for name := range previous {
+		if _, exists := current[name]; !exists {
+			change.Removed = append(change.Removed, name)
+		}
+	}
+	if change.Empty() {
+		return change
+	}
+
+	sort.Strings(change.Added)
+	sort.Strings(change.Removed)
+	logging.Infof("Tool catalog changed: %d added %v, %d removed %v",
+		len(change.Added), change.Added, len(change.Removed), change.Removed)
+	for _, fn := range watchers {
+		fn(change)
+	}
+	return change
+}
+
+// StartCatalogRefresh records the tool catalog in the background and then
+// refreshes it every interval until ctx is cancelled
+func (g *Gateway) StartCatalogRefresh(ctx context.Context, interval time.Duration) {
+	go func() {
+		g.RefreshCatalog(ctx)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				g.RefreshCatalog(ctx)
+			}
+		}
+	}()
+}
+
+// recordTools remembers the tools a client listed, for the validation of
+// calls and the annotations the retry policy may trust
+func (g *Gateway) recordTools(clientName string, tools []transport.Tool) {
+	byName := make(map[string]transport.Tool, len(tools))
+	for _, tool := range tools {
+		byName[tool.Name] = tool
+	}
+	g.listedMu.Lock()
+	defer g.listedMu.Unlock()
+	if g.listed == nil {
+		g.listed = make(map[string]map[string]transport.Tool)
+	}
+	g.listed[clientName] = byName
+}
+
+// listedTool returns the definition a client last listed for a tool
+func (g *Gateway) listedTool(clientName, name string) (transport.Tool, bool) {
+	g.listedMu.RLock()
+	defer g.listedMu.RUnlock()
+	tool, ok := g.listed[clientName][name]
+	return tool, ok
+}
+
+// annotations returns the annotations a client last listed for a tool, nil
+// when unknown
+func (g *Gateway) annotations(clientName, name string) *transport.ToolAnnotations {
+	tool, _ := g.listedTool(clientName, name)
+	return tool.Annotations
+}
@@ -0,0 +1,75 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"mcp-go/config"
+	"mcp-go/transport"
+)
+
+// flight is an upstream call shared by every identical caller that arrives
+// while it is in progress
+type flight struct {
+	done chan struct{}
+	resp *transport.ToolResponse
+	err  error
+	// ctxErr is the error of the first caller's context once the call
+	// returned, set when that caller gave up rather than the upstream
+	ctxErr error
+}
+
+// dedupKey identifies a call for coalescing. Only tools the retry policy
+// marks read-only are coalesced, since sharing one execution between callers
+// is only safe when the call has no side effects.
+func dedupKey(cfg config.MCPConfig, clientName, name string, arguments map[string]interface{}) (string, bool) {
+	if !isReadOnlyTool(cfg, name) {
+		return "", false
+	}
+	return callKey(clientName, name, arguments)
+}
+
+// callKey identifies a call by its client, tool and arguments
+func callKey(clientName, name string, arguments map[string]interface{}) (string, bool) {
+	// encoding/json sorts map keys, so equal arguments encode identically
+	encoded, err := json.Marshal(arguments)
+	if err != nil {
+		return "", false
+	}
+	return clientName + "\x00" + name + "\x00" + string(encoded), true
+}
+
+// callShared runs fn unless a call with the same key is already in flight,
+// in which case it waits for that call and returns its result. When the call
+// failed because the first caller's own context ended, waiters whose context
+// is still live call again, sharing that call among themselves. Failures of
+// the upstream, including its own MaxCallSeconds deadline, are shared as they
+// are so that a slow upstream is not called once per waiter.
+func (g *Gateway) callShared(ctx context.Context, key string, fn func(context.Context) (*transport.ToolResponse, error)) (*transport.ToolResponse, error) {
+	g.inflightMu.Lock()
+	if f, ok := g.inflight[key]; ok {
+		g.inflightMu.Unlock()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if f.err != nil && f.ctxErr != nil && ctx.Err() == nil {
+			return g.callShared(ctx, key, fn)
+		}
+		return f.resp, f.err
+	}
+
+	f := &flight{done: make(chan struct{})}
+	g.inflight[key] = f
+	g.inflightMu.Unlock()
+
+	f.resp, f.err = fn(ctx)
+	f.ctxErr = ctx.Err()
+
+	g.inflightMu.Lock()
+	delete(g.inflight, key)
+	g.inflightMu.Unlock()
+	close(f.done)
+
+	return f.resp, f.err
+}
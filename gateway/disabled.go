@@ -0,0 +1,36 @@
+package gateway
+
+import "mcp-go/transport"
+
+// DisableTools hides the named tools: they are left out of ListAllTools and
+// calling them fails as if they did not exist. Names are the ones exposed by
+// the gateway, prefix included.
+func (g *Gateway) DisableTools(names ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.disabledTools == nil {
+		g.disabledTools = make(map[string]bool)
+	}
+	for _, name := range names {
+		g.disabledTools[name] = true
+	}
+}
+
+// ToolDisabled reports whether the named tool was disabled with DisableTools
+func (g *Gateway) ToolDisabled(name string) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.disabledTools[name]
+}
+
+// withoutDisabledTools removes the disabled tools from a listing
+func (g *Gateway) withoutDisabledTools(tools []transport.Tool) []transport.Tool {
+	enabled := tools[:0]
+	for _, tool := range tools {
+		if !g.ToolDisabled(tool.Name) {
+			enabled = append(enabled, tool)
+		}
+	}
+	return enabled
+}
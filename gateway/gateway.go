@@ -3,24 +3,53 @@ package gateway
 import (
 	"context"
 	"fmt"
-	"log"
 	"mcp-go/client"
 	"mcp-go/config"
+	"mcp-go/logging"
 	"mcp-go/transport"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
 // Gateway manages multiple MCP client connections
 type Gateway struct {
-	clients map[string]client.Client
-	mu      sync.RWMutex
+	clients       map[string]client.Client
+	transforms    map[string][]transformFunc
+	retryBudgets  map[string]*retryBudget
+	limiters      map[string]*rateLimiter
+	inflight      map[string]*flight // Read-only calls in progress, keyed by dedupKey
+	inflightMu    sync.Mutex
+	middleware    []Middleware
+	disabledTools map[string]bool // Tools hidden with DisableTools
+	mu            sync.RWMutex
+
+	stats        map[string]*clientStats
+	initializing map[string]bool // Clients with a background initialization in progress
+	statsMu      sync.Mutex
+
+	history callHistory
+
+	catalog         map[string]string          // Last known tool name -> owning client
+	shadowed        map[string]map[string]bool // Kind -> shadowings last reported, see reportShadowed
+	catalogWatchers []func(CatalogChange)
+	catalogMu       sync.Mutex
+
+	listed   map[string]map[string]transport.Tool // Client -> tool name -> definition, as last listed
+	listedMu sync.RWMutex
 }
 
 // NewGateway creates a new gateway instance
 func NewGateway() *Gateway {
 	return &Gateway{
-		clients: make(map[string]client.Client),
+		clients:      make(map[string]client.Client),
+		transforms:   make(map[string][]transformFunc),
+		retryBudgets: make(map[string]*retryBudget),
+		limiters:     make(map[string]*rateLimiter),
+		inflight:     make(map[string]*flight),
+		stats:        make(map[string]*clientStats),
+		initializing: make(map[string]bool),
 	}
 }
 
@@ -34,26 +63,89 @@ func (g *Gateway) AddClient(c client.Client) error {
 		return fmt.Errorf("client %s already exists", name)
 	}
 
+	if err := validateInitializeMode(c.GetConfig().Initialize); err != nil {
+		return fmt.Errorf("invalid configuration for client %s: %w", name, err)
+	}
+
+	transforms, err := compileTransforms(c.GetConfig().Transforms)
+	if err != nil {
+		return fmt.Errorf("invalid transforms for client %s: %w", name, err)
+	}
+
 	g.clients[name] = c
+	g.transforms[name] = transforms
+	if c.GetConfig().Retry.MaxAttempts > 1 {
+		g.retryBudgets[name] = newRetryBudget(c.GetConfig().Retry)
+	}
+	if limiter := newRateLimiter(c.GetConfig().RateLimit); limiter != nil {
+		g.limiters[name] = limiter
+	}
 	return nil
 }
 
-// InitializeAll initializes all registered clients
+// InitializeAll initializes all registered clients concurrently, each within
+// its initialization timeout and all within ctx. The returned error lists the
+// clients that failed.
 func (g *Gateway) InitializeAll(ctx context.Context) error {
-	g.mu.RLock()
-	clients := make([]client.Client, 0, len(g.clients))
-	for _, c := range g.clients {
-		clients = append(clients, c)
+	return initializeError(g.InitializeEach(ctx))
+}
+
+// InitializeEach initializes all registered clients concurrently and reports
+// the outcome for each client, sorted by name
+func (g *Gateway) InitializeEach(ctx context.Context) []InitializeResult {
+	return g.initializeClients(ctx, g.clientList())
+}
+
+// initializeClients initializes the given clients concurrently
+func (g *Gateway) initializeClients(ctx context.Context, clients []client.Client) []InitializeResult {
+	results := make([]InitializeResult, len(clients))
+	var wg sync.WaitGroup
+	for i, c := range clients {
+		wg.Add(1)
+		go func(i int, c client.Client) {
+			defer wg.Done()
+			start := time.Now()
+			err := g.initializeClient(ctx, c)
+			results[i] = InitializeResult{Name: c.GetName(), Duration: time.Since(start)}
+			if err != nil {
+				g.statsFor(c.GetName()).recordError(err)
+				logging.Warnf("Failed to initialize client %s: %v", c.GetName(), err)
+				results[i].Error = err.Error()
+			} else {
+				logging.Infof("Successfully initialized MCP client: %s (%v)", c.GetName(), results[i].Duration.Round(time.Millisecond))
+			}
+		}(i, c)
 	}
-	g.mu.RUnlock()
+	wg.Wait()
 
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
+
+// initializeClient initializes a client within its initialization timeout
+func (g *Gateway) initializeClient(ctx context.Context, c client.Client) error {
+	timeout := defaultInitializeTimeout
+	if seconds := c.GetConfig().InitializeTimeoutSeconds; seconds > 0 {
+		timeout = time.Duration(seconds) * time.Second
+	}
+	initCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := c.Initialize(initCtx)
+	if err != nil && ctx.Err() == nil && initCtx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("initialization of %s exceeded %v: %w", c.GetName(), timeout, err)
+	}
+	return err
+}
+
+// initializeError summarizes the failed initializations, or returns nil
+func initializeError(results []InitializeResult) error {
 	var errors []string
-	for _, c := range clients {
-		if err := c.Initialize(ctx); err != nil {
-			log.Printf("Warning: Failed to initialize client %s: %v", c.GetName(), err)
-			errors = append(errors, fmt.Sprintf("%s: %v", c.GetName(), err))
-		} else {
-			log.Printf("Successfully initialized MCP client: %s", c.GetName())
+	for _, r := range results {
+		if r.Error != "" {
+			errors = append(errors, fmt.Sprintf("%s: %s", r.Name, r.Error))
 		}
 	}
 
@@ -67,12 +159,15 @@ func (g *Gateway) InitializeAll(ctx context.Context) error {
 // ListAllTools returns all tools from all connected clients
 // Tools are fetched in parallel for better performance
 func (g *Gateway) ListAllTools(ctx context.Context) ([]transport.Tool, error) {
-	g.mu.RLock()
-	clients := make([]client.Client, 0, len(g.clients))
-	for _, c := range g.clients {
-		clients = append(clients, c)
-	}
-	g.mu.RUnlock()
+	allTools, _, _ := g.collectTools(ctx)
+	return g.withoutDisabledTools(allTools), nil
+}
+
+// collectTools lists the tools of every client. Besides the merged listing it
+// returns the client each tool resolves to and the clients whose listing
+// failed.
+func (g *Gateway) collectTools(ctx context.Context) ([]transport.Tool, map[string]string, map[string]bool) {
+	clients := g.capableClients(ctx, capabilityTools)
 
 	// Use a channel to collect results from parallel goroutines
 	type result struct {
@@ -91,35 +186,77 @@ func (g *Gateway) ListAllTools(ctx context.Context) ([]transport.Tool, error) {
 	}
 
 	// Collect results
-	var allTools []transport.Tool
+	byClient := make(map[string][]transport.Tool, len(clients))
+	failed := make(map[string]bool)
 	for i := 0; i < len(clients); i++ {
 		res := <-results
 		if res.err != nil {
-			log.Printf("Warning: Failed to list tools from %s: %v", res.name, res.err)
+			g.statsFor(res.name).recordError(res.err)
+			logging.Warnf("Failed to list tools from %s: %v", res.name, res.err)
+			failed[res.name] = true
 			continue
 		}
-		allTools = append(allTools, res.tools...)
+		g.statsFor(res.name).recordToolCount(len(res.tools))
+		g.recordTools(res.name, res.tools)
+		byClient[res.name] = res.tools
+	}
+
+	// Merge in resolution order so the listing is stable and a tool exposed
+	// by several servers is listed once, for the server that will answer it
+	var allTools []transport.Tool
+	var shadowed []shadowing
+	owners := make(map[string]client.Client)
+	for _, c := range sortByPriority(clients) {
+		for _, tool := range byClient[c.GetName()] {
+			if owner, exists := owners[tool.Name]; exists {
+				shadowed = append(shadowed, shadowing{tool.Name, owner, c})
+				continue
+			}
+			owners[tool.Name] = c
+			allTools = append(allTools, tool)
+		}
 	}
+	g.reportShadowed(ctx, "tool", shadowed)
 
-	return allTools, nil
+	// Expose the built-in fan-out tool whenever there is something to fan out to
+	if len(clients) > 0 {
+		allTools = append(allTools, GetBroadcastTool())
+	}
+
+	ownerNames := make(map[string]string, len(owners))
+	for tool, owner := range owners {
+		ownerNames[tool] = owner.GetName()
+	}
+	return allTools, ownerNames, failed
 }
 
-// CallTool calls a tool, routing to the appropriate client
+// CallTool calls a tool, routing to the appropriate client through the
+// registered middleware
 func (g *Gateway) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*transport.ToolResponse, error) {
-	g.mu.RLock()
-	defer g.mu.RUnlock()
+	if g.ToolDisabled(name) {
+		return nil, fmt.Errorf("tool '%s' not found", name)
+	}
+	return g.chain(g.routeTool)(ctx, name, arguments)
+}
 
-	// Try to find the client that owns this tool
-	for _, c := range g.clients {
-		prefix := c.GetPrefix()
-		if prefix != "" && strings.HasPrefix(name, prefix) {
-			return c.CallTool(ctx, name, arguments)
-		}
+// routeTool finds the client that owns a tool and calls it
+func (g *Gateway) routeTool(ctx context.Context, name string, arguments map[string]interface{}) (*transport.ToolResponse, error) {
+	if name == BroadcastToolName {
+		return g.callBroadcastTool(ctx, arguments)
 	}
 
-	// If no prefix match, try all clients (for tools without prefix)
-	for _, c := range g.clients {
-		resp, err := c.CallTool(ctx, name, arguments)
+	// Snapshot the clients so no lock is held while calling upstream servers
+	clients := g.capableClients(ctx, capabilityTools)
+
+	// Try to find the client that owns this tool, preferring the longest
+	// matching prefix so "cloudflare-docs:" wins over "cloudflare-"
+	if owner := ownerByPrefix(clients, name); owner != nil {
+		return g.callClient(ctx, owner, name, arguments)
+	}
+
+	// If no prefix match, try all clients in priority order (for tools without prefix)
+	for _, c := range sortByPriority(clients) {
+		resp, err := g.callClient(ctx, c, name, arguments)
 		if err == nil {
 			return resp, nil
 		}
@@ -132,6 +269,79 @@ func (g *Gateway) CallTool(ctx context.Context, name string, arguments map[strin
 	return nil, fmt.Errorf("tool '%s' not found in any connected MCP server", name)
 }
 
+// callClient calls a tool on a specific client with its configured argument
+// injection, coalescing of identical read-only calls and response
+// transforms.
+func (g *Gateway) callClient(ctx context.Context, c client.Client, name string, arguments map[string]interface{}) (*transport.ToolResponse, error) {
+	cfg := c.GetConfig()
+	arguments = injectArguments(cfg, name, arguments)
+	if cfg.ValidateArguments {
+		if err := g.validateArguments(c.GetName(), name, arguments); err != nil {
+			return nil, err
+		}
+	}
+
+	var resp *transport.ToolResponse
+	var err error
+	key, ok := dedupKey(cfg, c.GetName(), name, arguments)
+	if !ok && cfg.Retry.TrustAnnotations && g.annotations(c.GetName(), name).ReadOnly() {
+		key, ok = callKey(c.GetName(), name, arguments)
+	}
+	if ok {
+		resp, err = g.callShared(ctx, key, func(ctx context.Context) (*transport.ToolResponse, error) {
+			return g.invokeClient(ctx, c, name, arguments)
+		})
+	} else {
+		resp, err = g.invokeClient(ctx, c, name, arguments)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.RLock()
+	transforms := g.transforms[c.GetName()]
+	g.mu.RUnlock()
+	return applyTransforms(resp, transforms), nil
+}
+
+// invokeClient calls a tool on a specific client under its retry policy,
+// rate limit and deadline, and records call metrics and history.
+// "not found" answers are not counted, since unprefixed tools are probed
+// against every client.
+func (g *Gateway) invokeClient(ctx context.Context, c client.Client, name string, arguments map[string]interface{}) (*transport.ToolResponse, error) {
+	cfg := c.GetConfig()
+
+	// Keep one slow upstream from consuming the caller's whole deadline
+	callCtx := ctx
+	if cfg.MaxCallSeconds > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(ctx, time.Duration(cfg.MaxCallSeconds)*time.Second)
+		defer cancel()
+	}
+
+	start := time.Now()
+	resp, err := g.callWithRetry(callCtx, c, name, arguments)
+	if err != nil && ctx.Err() == nil && callCtx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("tool call %s exceeded the %ds deadline of %s: %w", name, cfg.MaxCallSeconds, c.GetName(), err)
+	}
+	if !isNotFoundError(err) {
+		g.statsFor(c.GetName()).record(time.Since(start), err)
+		g.recordCall(c.GetName(), name, start, err)
+	}
+	return resp, err
+}
+
+// clientList returns a snapshot of the registered clients
+func (g *Gateway) clientList() []client.Client {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	clients := make([]client.Client, 0, len(g.clients))
+	for _, c := range g.clients {
+		clients = append(clients, c)
+	}
+	return clients
+}
+
 // GetClient returns a client by name
 func (g *Gateway) GetClient(name string) (client.Client, bool) {
 	g.mu.RLock()
@@ -151,6 +361,9 @@ func (g *Gateway) CloseAll() error {
 			errors = append(errors, fmt.Sprintf("%s: %v", name, err))
 		}
 	}
+	if err := g.history.close(); err != nil {
+		errors = append(errors, fmt.Sprintf("call history: %v", err))
+	}
 
 	if len(errors) > 0 {
 		return fmt.Errorf("errors closing clients: %s", strings.Join(errors, "; "))
@@ -161,13 +374,15 @@ func (g *Gateway) CloseAll() error {
 
 // LoadFromConfig loads clients from configuration
 func (g *Gateway) LoadFromConfig(cfg *config.Config) error {
+	g.DisableTools(cfg.DisabledTools...)
+
 	for _, serverCfg := range cfg.Servers {
 		if !serverCfg.Enabled {
-			log.Printf("Skipping disabled MCP server: %s", serverCfg.Name)
+			logging.Infof("Skipping disabled MCP server: %s", serverCfg.Name)
 			continue
 		}
 
-		c, err := client.NewClient(serverCfg)
+		c, err := newClient(serverCfg)
 		if err != nil {
 			return fmt.Errorf("failed to create client for %s: %w", serverCfg.Name, err)
 		}
@@ -179,3 +394,12 @@ func (g *Gateway) LoadFromConfig(cfg *config.Config) error {
 
 	return nil
 }
+
+// newClient creates the client for a server, spreading calls over its
+// replicas when it has any
+func newClient(cfg config.MCPConfig) (client.Client, error) {
+	if len(cfg.Replicas) > 0 {
+		return newReplicaSet(cfg)
+	}
+	return client.NewClient(cfg)
+}
@@ -0,0 +1,1512 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mcp-go/config"
+	"mcp-go/transport"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newUpstream starts a REST-style MCP server exposing a single "ping" tool
+// that answers with the given text.
+func newUpstream(t *testing.T, text string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(upstreamHandler(text))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// upstreamHandler serves the REST-style MCP endpoints used by newUpstream
+func upstreamHandler(text string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/initialize", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"protocolVersion": "2024-11-05",
+			"capabilities":    map[string]interface{}{"tools": true, "resources": true, "prompts": true},
+			"serverInfo":      map[string]string{"name": "upstream", "version": "test"},
+		})
+	})
+	mux.HandleFunc("/tools/list", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"tools": []map[string]interface{}{
+				{"name": "ping", "description": "ping", "inputSchema": map[string]interface{}{"type": "object"}},
+			},
+		})
+	})
+	mux.HandleFunc("/tools/call", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Name string `json:"name"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Name != "ping" {
+			http.Error(w, "Tool not found", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"content": []map[string]string{{"type": "text", "text": text}},
+		})
+	})
+	mux.HandleFunc("/resources/list", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"resources": []map[string]string{{"uri": "doc://readme", "name": "readme"}},
+		})
+	})
+	mux.HandleFunc("/resources/read", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			URI string `json:"uri"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.URI != "doc://readme" {
+			http.Error(w, "Resource not found", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"contents": []map[string]string{{"uri": req.URI, "text": text}},
+		})
+	})
+	mux.HandleFunc("/prompts/list", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"prompts": []map[string]interface{}{
+				{"name": "greet", "arguments": []map[string]interface{}{{"name": "who", "required": true}}},
+			},
+		})
+	})
+	mux.HandleFunc("/prompts/get", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Name      string            `json:"name"`
+			Arguments map[string]string `json:"arguments"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Name != "greet" {
+			http.Error(w, "Prompt not found", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"messages": []map[string]interface{}{
+				{"role": "user", "content": map[string]string{"type": "text", "text": text + " " + req.Arguments["who"]}},
+			},
+		})
+	})
+	return mux
+}
+
+func TestReplicaFailover(t *testing.T) {
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+	up := newUpstream(t, "from replica")
+
+	gw := NewGateway()
+	err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{{
+		Name:     "replicated",
+		URL:      down.URL,
+		Replicas: []string{up.URL},
+		Enabled:  true,
+		Prefix:   "rep:",
+	}}})
+	if err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	ctx := context.Background()
+	tools, err := gw.ListAllTools(ctx)
+	if err != nil {
+		t.Fatalf("ListAllTools failed: %v", err)
+	}
+	if len(tools) != 2 || tools[0].Name != "rep:ping" || tools[1].Name != BroadcastToolName {
+		t.Fatalf("Expected [rep:ping %s], got %v", BroadcastToolName, tools)
+	}
+
+	resp, err := gw.CallTool(ctx, "rep:ping", nil)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if len(resp.Content) != 1 || resp.Content[0].Text != "from replica" {
+		t.Errorf("Expected response from replica, got %v", resp.Content)
+	}
+
+	if _, err := gw.CallTool(ctx, "rep:missing", nil); !isNotFoundError(err) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func TestReplicaFailoverRunsCallsOnce(t *testing.T) {
+	var failedCalls, replicaCalls int32
+	handler := upstreamHandler("from replica")
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tools/call" {
+			atomic.AddInt32(&failedCalls, 1)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer failing.Close()
+	replica := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tools/call" {
+			atomic.AddInt32(&replicaCalls, 1)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer replica.Close()
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	load := func(retry config.RetryConfig, urls ...string) *Gateway {
+		gw := NewGateway()
+		if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{{
+			Name: "replicated", URL: urls[0], Replicas: urls[1:], Enabled: true, Retry: retry,
+		}}}); err != nil {
+			t.Fatalf("LoadFromConfig failed: %v", err)
+		}
+		return gw
+	}
+
+	// A call that failed after reaching the server is not repeated elsewhere
+	if _, err := load(config.RetryConfig{}, failing.URL, replica.URL).CallTool(context.Background(), "ping", nil); err == nil {
+		t.Fatal("Expected the failed call to be reported")
+	}
+	if failedCalls != 1 || replicaCalls != 0 {
+		t.Errorf("Expected the call to run exactly once, ran %d + %d times", failedCalls, replicaCalls)
+	}
+
+	// An unreachable endpoint cannot have run it, so the call moves on
+	resp, err := load(config.RetryConfig{}, unreachable.URL, replica.URL).CallTool(context.Background(), "ping", nil)
+	if err != nil || resp.Content[0].Text != "from replica" || replicaCalls != 1 {
+		t.Errorf("Expected the call to fail over, got %+v, %v", resp, err)
+	}
+
+	// Read-only tools fail over after any error
+	resp, err = load(config.RetryConfig{ReadOnlyTools: []string{"ping"}}, failing.URL, replica.URL).CallTool(context.Background(), "ping", nil)
+	if err != nil || resp.Content[0].Text != "from replica" || failedCalls != 2 || replicaCalls != 2 {
+		t.Errorf("Expected the read-only call to fail over, got %+v, %v", resp, err)
+	}
+}
+
+func TestReplicaRoundRobin(t *testing.T) {
+	first := newUpstream(t, "first")
+	second := newUpstream(t, "second")
+
+	gw := NewGateway()
+	err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{{
+		Name:          "balanced",
+		URL:           first.URL,
+		Replicas:      []string{second.URL},
+		LoadBalancing: LoadBalancingRoundRobin,
+		Enabled:       true,
+		Prefix:        "lb:",
+	}}})
+	if err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 4; i++ {
+		resp, err := gw.CallTool(context.Background(), "lb:ping", nil)
+		if err != nil {
+			t.Fatalf("CallTool failed: %v", err)
+		}
+		seen[resp.Content[0].Text] = true
+	}
+	if !seen["first"] || !seen["second"] {
+		t.Errorf("Expected calls to reach both replicas, got %v", seen)
+	}
+
+	health, ok := gw.EndpointHealth("balanced")
+	if !ok || len(health) != 2 {
+		t.Fatalf("Expected health for 2 endpoints, got %v", health)
+	}
+	for _, h := range health {
+		if !h.Healthy {
+			t.Errorf("Expected endpoint %s to be healthy", h.URL)
+		}
+	}
+}
+
+func TestReplicaUnsupportedStrategy(t *testing.T) {
+	gw := NewGateway()
+	err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{{
+		Name:          "bad",
+		URL:           "http://localhost:1",
+		Replicas:      []string{"http://localhost:2"},
+		LoadBalancing: "random",
+		Enabled:       true,
+	}}})
+	if err == nil {
+		t.Error("Expected error for unsupported load balancing strategy")
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	up := newUpstream(t, "pong")
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{{
+		Name: "up", URL: up.URL, Enabled: true, Prefix: "up:",
+	}}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	var order []string
+	trace := func(label string) Middleware {
+		return func(next CallFunc) CallFunc {
+			return func(ctx context.Context, name string, arguments map[string]interface{}) (*transport.ToolResponse, error) {
+				order = append(order, label)
+				return next(ctx, name, arguments)
+			}
+		}
+	}
+	gw.Use(trace("outer"), trace("inner"))
+	gw.Use(func(next CallFunc) CallFunc {
+		return func(ctx context.Context, name string, arguments map[string]interface{}) (*transport.ToolResponse, error) {
+			resp, err := next(ctx, name, arguments)
+			if err == nil {
+				resp.Content[0].Text += "!"
+			}
+			return resp, err
+		}
+	})
+
+	resp, err := gw.CallTool(context.Background(), "up:ping", nil)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	if resp.Content[0].Text != "pong!" {
+		t.Errorf("Expected middleware to modify response, got %q", resp.Content[0].Text)
+	}
+	if len(order) != 2 || order[0] != "outer" || order[1] != "inner" {
+		t.Errorf("Expected [outer inner], got %v", order)
+	}
+}
+
+func TestStats(t *testing.T) {
+	up := newUpstream(t, "pong")
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{{
+		Name: "up", URL: up.URL, Enabled: true, Prefix: "up:",
+	}}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if _, err := gw.CallTool(ctx, "up:ping", nil); err != nil {
+			t.Fatalf("CallTool failed: %v", err)
+		}
+	}
+	gw.CallTool(ctx, "up:missing", nil)
+
+	stats := gw.Stats()
+	if len(stats) != 1 {
+		t.Fatalf("Expected stats for 1 client, got %d", len(stats))
+	}
+	if stats[0].Name != "up" || stats[0].Calls != 3 || stats[0].Errors != 0 {
+		t.Errorf("Expected 3 successful calls for up, got %+v", stats[0])
+	}
+	last := stats[0].Buckets[len(stats[0].Buckets)-1]
+	if last.Count != 3 {
+		t.Errorf("Expected 3 calls in the largest bucket, got %d", last.Count)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	up := newUpstream(t, "pong")
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "up", URL: up.URL, Enabled: true, Prefix: "up:"},
+		{Name: "down", URL: "http://127.0.0.1:1", Enabled: true, Prefix: "down:"},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	ctx := context.Background()
+	gw.ListAllTools(ctx)
+	if _, err := gw.CallTool(ctx, "up:ping", nil); err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+
+	status := gw.Status()
+	if len(status) != 2 || status[0].Name != "down" || status[1].Name != "up" {
+		t.Fatalf("Expected status for [down up], got %+v", status)
+	}
+
+	down, up2 := status[0], status[1]
+	if down.Initialized || down.LastError == "" || down.ToolCount != nil {
+		t.Errorf("Expected uninitialized down client with an error, got %+v", down)
+	}
+	if !up2.Initialized || up2.ToolCount == nil || *up2.ToolCount != 1 || up2.LastSuccess == nil {
+		t.Errorf("Expected initialized up client with 1 tool, got %+v", up2)
+	}
+	if up2.Transport != "http" {
+		t.Errorf("Expected default transport 'http', got %q", up2.Transport)
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	a := newUpstream(t, "pong a")
+	b := newUpstream(t, "pong b")
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "a", URL: a.URL, Enabled: true, Prefix: "a:"},
+		{Name: "b", URL: b.URL, Enabled: true, Prefix: "b:"},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	ctx := context.Background()
+	results, err := gw.Broadcast(ctx, "ping", nil)
+	if err != nil {
+		t.Fatalf("Broadcast failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Server != "a" || results[1].Tool != "b:ping" {
+		t.Fatalf("Expected results from a and b, got %+v", results)
+	}
+
+	resp, err := gw.CallTool(ctx, BroadcastToolName, map[string]interface{}{"tool": "ping"})
+	if err != nil {
+		t.Fatalf("CallTool(%s) failed: %v", BroadcastToolName, err)
+	}
+	if len(resp.Content) != 2 || resp.Content[0].Text != "[a] pong a" || resp.Content[1].Text != "[b] pong b" {
+		t.Errorf("Unexpected broadcast content: %+v", resp.Content)
+	}
+
+	if _, err := gw.Broadcast(ctx, "missing", nil); !isNotFoundError(err) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func TestTransforms(t *testing.T) {
+	up := newUpstream(t, "\x1b[31m{\"result\":{\"items\":[\"first\",\"second\"]}}\x1b[0m")
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{{
+		Name: "up", URL: up.URL, Enabled: true, Prefix: "up:",
+		Transforms: []config.TransformConfig{
+			{Type: "strip_ansi"},
+			{Type: "jq", Expression: ".result.items | join(\",\")"},
+			{Type: "truncate", MaxBytes: 8},
+		},
+	}}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	resp, err := gw.CallTool(context.Background(), "up:ping", nil)
+	if err != nil {
+		t.Fatalf("CallTool failed: %v", err)
+	}
+	want := "first,se\n... [truncated 4 bytes]"
+	if resp.Content[0].Text != want {
+		t.Errorf("Expected %q, got %q", want, resp.Content[0].Text)
+	}
+
+	field := jsonFieldTransform("result.items.1")
+	if got := field(`{"result":{"items":["a","b"]}}`); got != "b" {
+		t.Errorf("Expected json_field to extract 'b', got %q", got)
+	}
+	if got := field("not json"); got != "not json" {
+		t.Errorf("Expected non-JSON text to be unchanged, got %q", got)
+	}
+
+	if _, err := compileTransforms([]config.TransformConfig{{Type: "uppercase"}}); err == nil {
+		t.Error("Expected error for unknown transform type")
+	}
+}
+
+func TestInjectArguments(t *testing.T) {
+	cfg := config.MCPConfig{
+		Prefix: "cf:",
+		Arguments: config.ArgumentsConfig{
+			Defaults: map[string]interface{}{"account_id": "default", "limit": 10.0},
+			Forced:   map[string]interface{}{"region": "eu"},
+		},
+		ToolArguments: map[string]config.ArgumentsConfig{
+			"list_zones": {
+				Defaults: map[string]interface{}{"limit": 50.0},
+				Forced:   map[string]interface{}{"account_id": "forced"},
+			},
+		},
+	}
+
+	caller := map[string]interface{}{"limit": 5.0, "region": "us"}
+	got := injectArguments(cfg, "cf:list_zones", caller)
+	want := map[string]interface{}{"account_id": "forced", "limit": 5.0, "region": "eu"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("Expected %s=%v, got %v", k, v, got[k])
+		}
+	}
+	if caller["region"] != "us" {
+		t.Error("Caller arguments must not be modified")
+	}
+
+	got = injectArguments(cfg, "cf:other", nil)
+	if got["account_id"] != "default" || got["limit"] != 10.0 || got["region"] != "eu" {
+		t.Errorf("Unexpected server-level injection: %v", got)
+	}
+
+	if got := injectArguments(config.MCPConfig{}, "x", caller); len(got) != len(caller) {
+		t.Errorf("Expected arguments unchanged without config, got %v", got)
+	}
+}
+
+func TestMaxCallSeconds(t *testing.T) {
+	upstream := upstreamHandler("")
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tools/call" {
+			io.Copy(io.Discard, r.Body)
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		upstream.ServeHTTP(w, r)
+	}))
+	defer slow.Close()
+
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{{
+		Name: "slow", URL: slow.URL, Enabled: true, Prefix: "slow:", MaxCallSeconds: 1,
+	}}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	start := time.Now()
+	_, err := gw.CallTool(context.Background(), "slow:ping", nil)
+	if err == nil {
+		t.Fatal("Expected deadline error")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Expected call to be cut off after ~1s, took %v", elapsed)
+	}
+	if stats := gw.Stats(); stats[0].Timeouts != 1 {
+		t.Errorf("Expected 1 timeout, got %+v", stats[0])
+	}
+}
+
+func TestPriorityResolution(t *testing.T) {
+	low := newUpstream(t, "low")
+	high := newUpstream(t, "high")
+	tie := newUpstream(t, "tie")
+
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "a-low", URL: low.URL, Enabled: true, Tags: []string{"low"}},
+		{Name: "z-high", URL: high.URL, Enabled: true, Priority: 10},
+		{Name: "b-tie", URL: tie.URL, Enabled: true},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		resp, err := gw.CallTool(ctx, "ping", nil)
+		if err != nil {
+			t.Fatalf("CallTool failed: %v", err)
+		}
+		if resp.Content[0].Text != "high" {
+			t.Fatalf("Expected highest priority server to answer, got %q", resp.Content[0].Text)
+		}
+	}
+
+	var logged strings.Builder
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+	tools, err := gw.ListAllTools(ctx)
+	if err != nil {
+		t.Fatalf("ListAllTools failed: %v", err)
+	}
+	pings := 0
+	for _, tool := range tools {
+		if tool.Name == "ping" {
+			pings++
+		}
+	}
+	if pings != 1 {
+		t.Errorf("Expected shadowed duplicates to be listed once, got %d", pings)
+	}
+
+	// The shadowed tools are reported once, not on every listing, even when
+	// listings restricted to tags, which see no shadowing, come in between
+	gw.ListAllTools(ctx)
+	gw.RefreshCatalog(ctx)
+	for i := 0; i < 2; i++ {
+		gw.ListAllTools(WithTags(ctx, "low"))
+		gw.ListAllTools(ctx)
+	}
+	if n := strings.Count(logged.String(), "is shadowed by z-high"); n != 2 {
+		t.Errorf("Expected the two shadowed tools to be reported once each, got:\n%s", logged.String())
+	}
+
+	ordered := sortByPriority(gw.clientList())
+	names := []string{ordered[0].GetName(), ordered[1].GetName(), ordered[2].GetName()}
+	if names[0] != "z-high" || names[1] != "a-low" || names[2] != "b-tie" {
+		t.Errorf("Unexpected resolution order: %v", names)
+	}
+}
+
+func TestInitializeModes(t *testing.T) {
+	up := newUpstream(t, "pong")
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "eager", URL: up.URL, Enabled: true, Prefix: "e:", Initialize: InitializeEager},
+		{Name: "lazy", URL: up.URL, Enabled: true, Prefix: "l:"},
+		{Name: "background", URL: up.URL, Enabled: true, Prefix: "b:", Initialize: InitializeBackground},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := gw.InitializeConfigured(ctx); err != nil {
+		t.Fatalf("InitializeConfigured failed: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	ready, states := gw.Ready()
+	for !ready && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		ready, states = gw.Ready()
+	}
+	if !ready {
+		t.Fatalf("Expected gateway to become ready, got %+v", states)
+	}
+
+	want := map[string]string{"background": ReadinessReady, "eager": ReadinessReady, "lazy": ReadinessLazy}
+	for _, s := range states {
+		if s.State != want[s.Name] {
+			t.Errorf("Expected %s to be %s, got %s", s.Name, want[s.Name], s.State)
+		}
+	}
+
+	err := NewGateway().LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "bad", URL: up.URL, Enabled: true, Initialize: "sometimes"},
+	}})
+	if err == nil {
+		t.Error("Expected error for unknown initialize mode")
+	}
+}
+
+func TestResources(t *testing.T) {
+	a := newUpstream(t, "readme a")
+	b := newUpstream(t, "readme b")
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "a", URL: a.URL, Enabled: true, Prefix: "a:"},
+		{Name: "b", URL: b.URL, Enabled: true, Prefix: "b:"},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	ctx := context.Background()
+	resources, err := gw.ListAllResources(ctx)
+	if err != nil {
+		t.Fatalf("ListAllResources failed: %v", err)
+	}
+	if len(resources) != 2 || resources[0].URI != "a:doc://readme" || resources[1].URI != "b:doc://readme" {
+		t.Fatalf("Expected prefixed resources from a and b, got %+v", resources)
+	}
+
+	contents, err := gw.ReadResource(ctx, "b:doc://readme")
+	if err != nil {
+		t.Fatalf("ReadResource failed: %v", err)
+	}
+	if len(contents) != 1 || contents[0].Text != "readme b" || contents[0].URI != "b:doc://readme" {
+		t.Errorf("Unexpected contents: %+v", contents)
+	}
+
+	if _, err := gw.ReadResource(ctx, "a:doc://missing"); !isNotFoundError(err) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func TestPrompts(t *testing.T) {
+	a := newUpstream(t, "hello from a")
+	b := newUpstream(t, "hello from b")
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "a", URL: a.URL, Enabled: true, Prefix: "a:"},
+		{Name: "b", URL: b.URL, Enabled: true, Priority: 1},
+		{Name: "c", URL: a.URL, Enabled: true},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	ctx := context.Background()
+	prompts, err := gw.ListAllPrompts(ctx)
+	if err != nil {
+		t.Fatalf("ListAllPrompts failed: %v", err)
+	}
+	// b and c both expose an unprefixed "greet"; b wins on priority
+	if len(prompts) != 2 || prompts[0].Name != "greet" || prompts[1].Name != "a:greet" {
+		t.Fatalf("Expected greet and a:greet, got %+v", prompts)
+	}
+
+	result, err := gw.GetPrompt(ctx, "greet", map[string]string{"who": "bob"})
+	if err != nil {
+		t.Fatalf("GetPrompt failed: %v", err)
+	}
+	if len(result.Messages) != 1 || result.Messages[0].Content.Text != "hello from b bob" {
+		t.Errorf("Unexpected prompt result: %+v", result)
+	}
+
+	if _, err := gw.GetPrompt(ctx, "a:missing", nil); !isNotFoundError(err) {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+func TestCatalogRefresh(t *testing.T) {
+	a := newUpstream(t, "a")
+	b := newUpstream(t, "b")
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "a", URL: a.URL, Enabled: true, Prefix: "a:"},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	var notified []CatalogChange
+	gw.OnToolsChanged(func(change CatalogChange) {
+		notified = append(notified, change)
+	})
+
+	ctx := context.Background()
+	if change := gw.RefreshCatalog(ctx); !change.Empty() {
+		t.Errorf("Expected the first refresh to only record the catalog, got %+v", change)
+	}
+
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "b", URL: b.URL, Enabled: true, Prefix: "b:"},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+	change := gw.RefreshCatalog(ctx)
+	if len(change.Added) != 1 || change.Added[0] != "b:ping" || len(change.Removed) != 0 {
+		t.Errorf("Expected b:ping to be added, got %+v", change)
+	}
+
+	// A failing upstream keeps its tools rather than reporting them removed
+	b.Close()
+	if change := gw.RefreshCatalog(ctx); !change.Empty() {
+		t.Errorf("Expected no change while b is unreachable, got %+v", change)
+	}
+
+	if len(notified) != 1 {
+		t.Errorf("Expected exactly one notification, got %+v", notified)
+	}
+}
+
+func TestTags(t *testing.T) {
+	internal := newUpstream(t, "internal")
+	public := newUpstream(t, "public")
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "internal", URL: internal.URL, Enabled: true, Prefix: "internal:", Tags: []string{"internal", "prod"}},
+		{Name: "public", URL: public.URL, Enabled: true, Prefix: "public:", Tags: []string{"prod"}},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	if names := gw.ClientsWithTags("prod"); len(names) != 2 {
+		t.Errorf("Expected both clients tagged prod, got %v", names)
+	}
+
+	ctx := WithTags(context.Background(), "internal")
+	tools, err := gw.ListAllTools(ctx)
+	if err != nil {
+		t.Fatalf("ListAllTools failed: %v", err)
+	}
+	if len(tools) != 2 || tools[0].Name != "internal:ping" {
+		t.Errorf("Expected only internal tools, got %+v", tools)
+	}
+
+	if _, err := gw.CallTool(ctx, "public:ping", nil); !isNotFoundError(err) {
+		t.Errorf("Expected public:ping to be hidden, got %v", err)
+	}
+	resp, err := gw.CallTool(ctx, "internal:ping", nil)
+	if err != nil || resp.Content[0].Text != "internal" {
+		t.Errorf("Unexpected response %+v, %v", resp, err)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	var failures int32 = 2
+	handler := upstreamHandler("eventually")
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tools/call" && atomic.AddInt32(&failures, -1) >= 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer flaky.Close()
+
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "flaky", URL: flaky.URL, Enabled: true, Retry: config.RetryConfig{
+			MaxAttempts: 3, BackoffMs: 1, ReadOnlyTools: []string{"ping"},
+		}},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	resp, err := gw.CallTool(context.Background(), "ping", nil)
+	if err != nil || resp.Content[0].Text != "eventually" {
+		t.Fatalf("Expected retries to succeed, got %+v, %v", resp, err)
+	}
+	stats := gw.Stats()[0]
+	if stats.Retries != 2 || stats.Calls != 1 || stats.Errors != 0 {
+		t.Errorf("Expected one successful call after 2 retries, got %+v", stats)
+	}
+
+	if !isReadOnlyTool(config.MCPConfig{Prefix: "f:", Retry: config.RetryConfig{ReadOnlyTools: []string{"ping"}}}, "f:ping") {
+		t.Error("Expected prefixed read-only tool to match")
+	}
+	if isReadOnlyTool(config.MCPConfig{Retry: config.RetryConfig{ReadOnlyTools: []string{"ping"}}}, "write") {
+		t.Error("Expected write not to be read-only")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	var inFlight, maxInFlight int32
+	handler := upstreamHandler("limited")
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tools/call" {
+			n := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			for {
+				max := atomic.LoadInt32(&maxInFlight)
+				if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer slow.Close()
+
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "slow", URL: slow.URL, Enabled: true, RateLimit: config.RateLimitConfig{
+			RequestsPerSecond: 50, Burst: 2, MaxConcurrent: 2,
+		}},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	start := time.Now()
+	errs := make(chan error, 6)
+	for i := 0; i < 6; i++ {
+		go func() {
+			_, err := gw.CallTool(context.Background(), "ping", nil)
+			errs <- err
+		}()
+	}
+	for i := 0; i < 6; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("CallTool failed: %v", err)
+		}
+	}
+
+	if max := atomic.LoadInt32(&maxInFlight); max > 2 {
+		t.Errorf("Expected at most 2 concurrent calls, saw %d", max)
+	}
+	// 2 calls use the burst, the other 4 wait 20ms each for a token
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("Expected the rate limit to spread calls over at least 80ms, took %v", elapsed)
+	}
+}
+
+func TestInflightDedup(t *testing.T) {
+	var calls int32
+	arrived := make(chan struct{}, 10)
+	release := make(chan struct{})
+	handler := upstreamHandler("shared")
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tools/call" {
+			atomic.AddInt32(&calls, 1)
+			arrived <- struct{}{}
+			<-release
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer upstream.Close()
+
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "up", URL: upstream.URL, Enabled: true, Retry: config.RetryConfig{ReadOnlyTools: []string{"ping"}}},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	results := make(chan *transport.ToolResponse, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			resp, err := gw.CallTool(context.Background(), "ping", map[string]interface{}{"q": "x"})
+			if err != nil {
+				t.Errorf("CallTool failed: %v", err)
+			}
+			results <- resp
+		}()
+	}
+
+	<-arrived
+	time.Sleep(50 * time.Millisecond) // let the other callers join the flight
+	close(release)
+	for i := 0; i < 3; i++ {
+		if resp := <-results; resp == nil || resp.Content[0].Text != "shared" {
+			t.Errorf("Unexpected response %+v", resp)
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected 1 upstream call, got %d", n)
+	}
+
+	if _, ok := dedupKey(config.MCPConfig{}, "up", "write", nil); ok {
+		t.Error("Expected tools not marked read-only to bypass deduplication")
+	}
+}
+
+// waitForFlight waits until a call with the key is in flight
+func waitForFlight(gw *Gateway, key string) {
+	for {
+		gw.inflightMu.Lock()
+		_, ok := gw.inflight[key]
+		gw.inflightMu.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestInflightDedupLeaderDeadline(t *testing.T) {
+	text := func(s string) *transport.ToolResponse {
+		return &transport.ToolResponse{Content: []transport.ContentItem{{Type: "text", Text: s}}}
+	}
+
+	// The upstream's own deadline, wrapped like invokeClient does, is
+	// shared: the waiters must not each call the slow upstream again
+	gw := NewGateway()
+	release := make(chan struct{})
+	go gw.callShared(context.Background(), "key", func(ctx context.Context) (*transport.ToolResponse, error) {
+		<-release
+		return nil, fmt.Errorf("tool call slow exceeded the 1s deadline of up: %w", context.DeadlineExceeded)
+	})
+	waitForFlight(gw, "key")
+	go func() {
+		time.Sleep(20 * time.Millisecond) // let the waiter join the flight
+		close(release)
+	}()
+	var ran int32
+	_, err := gw.callShared(context.Background(), "key", func(ctx context.Context) (*transport.ToolResponse, error) {
+		atomic.AddInt32(&ran, 1)
+		return text("own"), nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || atomic.LoadInt32(&ran) != 0 {
+		t.Errorf("Expected the upstream deadline to be shared, got %v after %d calls", err, ran)
+	}
+
+	// When the first caller's context ends, live waiters call again, and
+	// share that call among themselves
+	gw = NewGateway()
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	release = make(chan struct{})
+	leader := make(chan error, 1)
+	go func() {
+		_, err := gw.callShared(leaderCtx, "key", func(ctx context.Context) (*transport.ToolResponse, error) {
+			<-release
+			cancel()
+			return nil, ctx.Err()
+		})
+		leader <- err
+	}()
+	waitForFlight(gw, "key")
+	ran = 0
+	results := make(chan *transport.ToolResponse, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			resp, _ := gw.callShared(context.Background(), "key", func(ctx context.Context) (*transport.ToolResponse, error) {
+				atomic.AddInt32(&ran, 1)
+				time.Sleep(50 * time.Millisecond) // let the other waiter join
+				return text("rerun"), nil
+			})
+			results <- resp
+		}()
+	}
+	time.Sleep(20 * time.Millisecond) // let the waiters join the flight
+	close(release)
+	for i := 0; i < 2; i++ {
+		if resp := <-results; resp == nil || resp.Content[0].Text != "rerun" {
+			t.Errorf("Expected the waiter to get the rerun's response, got %+v", resp)
+		}
+	}
+	if n := atomic.LoadInt32(&ran); n != 1 {
+		t.Errorf("Expected the waiters to share 1 rerun, got %d", n)
+	}
+	if err := <-leader; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the leader to see its cancellation, got %v", err)
+	}
+}
+
+func TestCapabilityRouting(t *testing.T) {
+	var resourceCalls int32
+	handler := upstreamHandler("tools only")
+	toolsOnly := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/initialize":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"protocolVersion": "2024-11-05",
+				"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}, "prompts": false},
+				"serverInfo":      map[string]string{"name": "upstream", "version": "test"},
+			})
+		case "/resources/list", "/prompts/list":
+			atomic.AddInt32(&resourceCalls, 1)
+			handler.ServeHTTP(w, r)
+		default:
+			handler.ServeHTTP(w, r)
+		}
+	}))
+	defer toolsOnly.Close()
+	full := newUpstream(t, "full")
+
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "full", URL: full.URL, Enabled: true, Prefix: "full:"},
+		{Name: "tools", URL: toolsOnly.URL, Enabled: true, Prefix: "tools:"},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := gw.InitializeAll(ctx); err != nil {
+		t.Fatalf("InitializeAll failed: %v", err)
+	}
+
+	resources, err := gw.ListAllResources(ctx)
+	if err != nil || len(resources) != 1 || resources[0].URI != "full:doc://readme" {
+		t.Errorf("Expected only full's resource, got %+v, %v", resources, err)
+	}
+	prompts, err := gw.ListAllPrompts(ctx)
+	if err != nil || len(prompts) != 1 {
+		t.Errorf("Expected only full's prompt, got %+v, %v", prompts, err)
+	}
+	if n := atomic.LoadInt32(&resourceCalls); n != 0 {
+		t.Errorf("Expected no requests to a server without resources or prompts, got %d", n)
+	}
+
+	for _, status := range gw.Status() {
+		if status.Name == "tools" && status.Capabilities["tools"] == nil {
+			t.Errorf("Expected status to report advertised capabilities, got %+v", status.Capabilities)
+		}
+	}
+}
+
+func TestInitializeAllConcurrent(t *testing.T) {
+	release := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer hanging.Close()
+	defer close(release)
+	up := newUpstream(t, "up")
+
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "hang1", URL: hanging.URL, Enabled: true, InitializeTimeoutSeconds: 1},
+		{Name: "hang2", URL: hanging.URL, Enabled: true, InitializeTimeoutSeconds: 1},
+		{Name: "up", URL: up.URL, Enabled: true},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	start := time.Now()
+	results := gw.InitializeEach(context.Background())
+	if elapsed := time.Since(start); elapsed > 1900*time.Millisecond {
+		t.Errorf("Expected clients to be initialized concurrently, took %v", elapsed)
+	}
+	if len(results) != 3 || results[0].Name != "hang1" || results[2].Name != "up" {
+		t.Fatalf("Expected results sorted by name, got %+v", results)
+	}
+	if results[0].Error == "" || results[1].Error == "" || results[2].Error != "" {
+		t.Errorf("Expected only the hanging clients to fail, got %+v", results)
+	}
+}
+
+func TestCallHistory(t *testing.T) {
+	a := newUpstream(t, "a")
+	b := newUpstream(t, "b")
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "a", URL: a.URL, Enabled: true, Prefix: "a:"},
+		{Name: "b", URL: b.URL, Enabled: true, Prefix: "b:"},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+	path := t.TempDir() + "/calls.jsonl"
+	if err := gw.PersistCallHistory(path); err != nil {
+		t.Fatalf("PersistCallHistory failed: %v", err)
+	}
+
+	ctx := context.Background()
+	for _, name := range []string{"a:ping", "b:ping", "a:ping"} {
+		if _, err := gw.CallTool(ctx, name, nil); err != nil {
+			t.Fatalf("CallTool %s failed: %v", name, err)
+		}
+	}
+
+	if calls := gw.Calls(CallFilter{}); len(calls) != 3 || calls[0].Tool != "a:ping" || calls[1].Tool != "b:ping" {
+		t.Errorf("Expected 3 calls, most recent first, got %+v", calls)
+	}
+	if calls := gw.Calls(CallFilter{Server: "a", Limit: 1}); len(calls) != 1 || calls[0].Server != "a" {
+		t.Errorf("Expected 1 call to a, got %+v", calls)
+	}
+
+	// A new gateway picks up the persisted history
+	restarted := NewGateway()
+	if err := restarted.PersistCallHistory(path); err != nil {
+		t.Fatalf("PersistCallHistory failed: %v", err)
+	}
+	if calls := restarted.Calls(CallFilter{Tool: "b:ping"}); len(calls) != 1 {
+		t.Errorf("Expected the persisted b:ping call, got %+v", calls)
+	}
+}
+
+// TestStdioHelperProcess is not a real test: it is the stdio MCP server
+// started by TestStdioServer
+func TestStdioHelperProcess(t *testing.T) {
+	if os.Getenv("MCP_STDIO_HELPER") != "1" {
+		return
+	}
+	decoder := json.NewDecoder(os.Stdin)
+	encoder := json.NewEncoder(os.Stdout)
+	for {
+		var req struct {
+			ID     interface{}            `json:"id"`
+			Method string                 `json:"method"`
+			Params map[string]interface{} `json:"params"`
+		}
+		if err := decoder.Decode(&req); err != nil {
+			os.Exit(0)
+		}
+		var result interface{}
+		switch req.Method {
+		case "initialize":
+			result = map[string]interface{}{
+				"protocolVersion": "2024-11-05",
+				"capabilities":    map[string]interface{}{"tools": map[string]interface{}{}},
+				"serverInfo":      map[string]string{"name": "stdio", "version": "test"},
+			}
+		case "tools/list":
+			result = map[string]interface{}{
+				"tools": []map[string]interface{}{{"name": "greeting", "inputSchema": map[string]interface{}{"type": "object"}}},
+			}
+		case "tools/call":
+			result = map[string]interface{}{
+				"content": []map[string]string{{"type": "text", "text": os.Getenv("GREETING")}},
+			}
+		default:
+			continue // notifications
+		}
+		encoder.Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}
+}
+
+func TestStdioServer(t *testing.T) {
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{{
+		Name:    "stdio",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=TestStdioHelperProcess"},
+		Env:     map[string]string{"MCP_STDIO_HELPER": "1", "GREETING": "hello over stdio"},
+		Enabled: true,
+		Prefix:  "std:",
+	}}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+	defer gw.CloseAll()
+
+	ctx := context.Background()
+	tools, err := gw.ListAllTools(ctx)
+	if err != nil || len(tools) != 2 || tools[0].Name != "std:greeting" {
+		t.Fatalf("Expected std:greeting, got %+v, %v", tools, err)
+	}
+	resp, err := gw.CallTool(ctx, "std:greeting", nil)
+	if err != nil || resp.Content[0].Text != "hello over stdio" {
+		t.Errorf("Unexpected response %+v, %v", resp, err)
+	}
+	if status := gw.Status(); status[0].Transport != "stdio" {
+		t.Errorf("Expected stdio transport in status, got %+v", status[0])
+	}
+}
+
+func TestTransportOptions(t *testing.T) {
+	var failures int32 = 1
+	handler := upstreamHandler("a response that is far too long")
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tools/call" && atomic.AddInt32(&failures, -1) >= 0 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer flaky.Close()
+
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "retrying", URL: flaky.URL, Enabled: true, Prefix: "r:", Retries: 1},
+		{Name: "limited", URL: flaky.URL, Enabled: true, Prefix: "l:", MaxResponseBytes: 40},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	ctx := context.Background()
+	resp, err := gw.CallTool(ctx, "r:ping", nil)
+	if err != nil || resp.Content[0].Text != "a response that is far too long" {
+		t.Errorf("Expected the transport to retry the 503, got %+v, %v", resp, err)
+	}
+	if _, err := gw.CallTool(ctx, "l:ping", nil); err == nil || !strings.Contains(err.Error(), "exceeds the limit of 40 bytes") {
+		t.Errorf("Expected the response size limit to apply, got %v", err)
+	}
+}
+
+func TestDisableTools(t *testing.T) {
+	upstream := newUpstream(t, "a")
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{
+		Servers:       []config.MCPConfig{{Name: "a", URL: upstream.URL, Enabled: true, Prefix: "a:"}},
+		DisabledTools: []string{"a:ping"},
+	}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	tools, err := gw.ListAllTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListAllTools failed: %v", err)
+	}
+	for _, tool := range tools {
+		if tool.Name == "a:ping" {
+			t.Errorf("Expected a:ping to be hidden, got %+v", tools)
+		}
+	}
+	if _, err := gw.CallTool(context.Background(), "a:ping", nil); !isNotFoundError(err) {
+		t.Errorf("Expected disabled tool to be not found, got %v", err)
+	}
+	if _, err := gw.CallTool(context.Background(), BroadcastToolName, map[string]interface{}{"tool": "a:ping"}); !isNotFoundError(err) {
+		t.Errorf("Expected broadcast of disabled tool to fail, got %v", err)
+	}
+}
+
+func TestToolFilter(t *testing.T) {
+	kept := newUpstream(t, "kept")
+	trimmed := newUpstream(t, "trimmed")
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "kept", URL: kept.URL, Enabled: true, Prefix: "kept:", Tools: config.ToolFilterConfig{Include: []string{"p*"}}},
+		{Name: "trimmed", URL: trimmed.URL, Enabled: true, Prefix: "trimmed:", Tools: config.ToolFilterConfig{Exclude: []string{"ping"}}},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	tools, err := gw.ListAllTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListAllTools failed: %v", err)
+	}
+	if len(tools) != 2 || tools[0].Name != "kept:ping" || tools[1].Name != BroadcastToolName {
+		t.Errorf("Expected only kept:ping and the broadcast tool, got %+v", tools)
+	}
+	if _, err := gw.CallTool(context.Background(), "trimmed:ping", nil); !isNotFoundError(err) {
+		t.Errorf("Expected excluded tool to be not found, got %v", err)
+	}
+}
+
+func TestTLS(t *testing.T) {
+	upstream := httptest.NewTLSServer(upstreamHandler("secure"))
+	t.Cleanup(upstream.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: upstream.Certificate().Raw})
+	if err := os.WriteFile(caFile, ca, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		tls     config.TLSConfig
+		wantErr bool
+	}{
+		{name: "untrusted", wantErr: true},
+		{name: "ca", tls: config.TLSConfig{CAFile: caFile}},
+		{name: "insecure", tls: config.TLSConfig{InsecureSkipVerify: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			gw := NewGateway()
+			if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+				{Name: "secure", URL: upstream.URL, Enabled: true, TLS: tc.tls},
+			}}); err != nil {
+				t.Fatalf("LoadFromConfig failed: %v", err)
+			}
+			defer gw.CloseAll()
+
+			resp, err := gw.CallTool(context.Background(), "ping", nil)
+			if tc.wantErr {
+				if err == nil {
+					t.Error("Expected the untrusted certificate to be rejected")
+				}
+				return
+			}
+			if err != nil || resp.Content[0].Text != "secure" {
+				t.Errorf("Unexpected response %+v, %v", resp, err)
+			}
+		})
+	}
+
+	gw := NewGateway()
+	err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "secure", URL: upstream.URL, Enabled: true, TLS: config.TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")}},
+	}})
+	if err == nil {
+		t.Error("Expected a missing CA file to be reported")
+	}
+}
+
+func TestAuthCommand(t *testing.T) {
+	var seen atomic.Value
+	handler := upstreamHandler("ok")
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen.Store(r.Header.Get("Authorization"))
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(upstream.Close)
+
+	// The command counts its runs so caching can be checked
+	counter := filepath.Join(t.TempDir(), "runs")
+	script := `n=$(cat "$0" 2>/dev/null || echo 0); n=$((n+1)); echo $n > "$0"; echo "token-$n"`
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "a", URL: upstream.URL, Enabled: true, AuthCommand: []string{"sh", "-c", script, counter}, AuthRefreshSeconds: 3600},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := gw.CallTool(context.Background(), "ping", nil); err != nil {
+			t.Fatalf("CallTool failed: %v", err)
+		}
+	}
+	if got := seen.Load(); got != "Bearer token-1" {
+		t.Errorf("Expected the cached command token, got %v", got)
+	}
+
+	failing := NewGateway()
+	if err := failing.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "b", URL: upstream.URL, Enabled: true, AuthCommand: []string{"sh", "-c", "echo denied >&2; exit 1"}},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+	if _, err := failing.CallTool(context.Background(), "ping", nil); err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("Expected the command failure to be reported, got %v", err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	a := newUpstream(t, "a")
+	b := newUpstream(t, "b")
+	c := newUpstream(t, "c")
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "a", URL: a.URL, Enabled: true, Prefix: "a:"},
+		{Name: "b", URL: b.URL, Enabled: true, Prefix: "b:"},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+	kept, _ := gw.GetClient("a")
+
+	var changes []CatalogChange
+	gw.OnToolsChanged(func(change CatalogChange) { changes = append(changes, change) })
+	gw.RefreshCatalog(context.Background())
+
+	// b is removed, c is added and a is unchanged
+	if err := gw.Reload(context.Background(), &config.Config{
+		Servers: []config.MCPConfig{
+			{Name: "a", URL: a.URL, Enabled: true, Prefix: "a:"},
+			{Name: "b", URL: b.URL, Enabled: false, Prefix: "b:"},
+			{Name: "c", URL: c.URL, Enabled: true, Prefix: "c:"},
+		},
+		DisabledTools: []string{"a:ping"},
+	}); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	if current, _ := gw.GetClient("a"); current != kept {
+		t.Error("Expected the unchanged client to be kept")
+	}
+	if _, ok := gw.GetClient("b"); ok {
+		t.Error("Expected the disabled client to be removed")
+	}
+	resp, err := gw.CallTool(context.Background(), "c:ping", nil)
+	if err != nil || resp.Content[0].Text != "c" {
+		t.Errorf("Unexpected response from the added client: %+v, %v", resp, err)
+	}
+	if !gw.ToolDisabled("a:ping") {
+		t.Error("Expected the reloaded disabled tools to apply")
+	}
+	if len(changes) != 1 || len(changes[0].Added) != 1 || changes[0].Added[0] != "c:ping" {
+		t.Errorf("Expected the catalog change to be reported, got %+v", changes)
+	}
+}
+
+func TestValidateArguments(t *testing.T) {
+	var calls int32
+	upstream := upstreamHandler("pong")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tools/list":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"tools": []map[string]interface{}{{
+					"name": "ping",
+					"inputSchema": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"host":  map[string]interface{}{"type": "string"},
+							"count": map[string]interface{}{"type": "integer"},
+						},
+						"required": []string{"host", "count"},
+					},
+				}},
+			})
+			return
+		case "/tools/call":
+			atomic.AddInt32(&calls, 1)
+		}
+		upstream.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{{
+		Name: "v", URL: srv.URL, Enabled: true, Prefix: "v:", ValidateArguments: true,
+		// Injected arguments count towards the schema
+		Arguments: config.ArgumentsConfig{Defaults: map[string]interface{}{"count": 1}},
+	}}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	// The schema is unknown until the tools are listed
+	if _, err := gw.CallTool(context.Background(), "v:ping", nil); err != nil {
+		t.Fatalf("Expected the call to be forwarded, got %v", err)
+	}
+	if _, err := gw.ListAllTools(context.Background()); err != nil {
+		t.Fatalf("ListAllTools failed: %v", err)
+	}
+
+	_, err := gw.CallTool(context.Background(), "v:ping", map[string]interface{}{"count": "two"})
+	if err == nil || err.Error() != "invalid arguments: host: is required; count: must be an integer" {
+		t.Errorf("Expected a validation error, got %v", err)
+	}
+	if _, err := gw.CallTool(context.Background(), "v:ping", map[string]interface{}{"host": "example.com"}); err != nil {
+		t.Errorf("Expected a valid call to succeed, got %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("Expected the invalid call not to be forwarded, got %d calls", n)
+	}
+}
+
+func TestTrustAnnotations(t *testing.T) {
+	var failures int32 = 1
+	handler := upstreamHandler("annotated")
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tools/list":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"tools": []map[string]interface{}{{
+					"name":        "ping",
+					"inputSchema": map[string]interface{}{"type": "object"},
+					"annotations": map[string]interface{}{"title": "Ping", "readOnlyHint": true},
+				}},
+			})
+			return
+		case "/tools/call":
+			if atomic.AddInt32(&failures, -1) >= 0 {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer flaky.Close()
+
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "flaky", URL: flaky.URL, Enabled: true, Prefix: "f:", Retry: config.RetryConfig{
+			MaxAttempts: 2, BackoffMs: 1, TrustAnnotations: true,
+		}},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+
+	// Annotations are passed on to clients of the gateway
+	tools, err := gw.ListAllTools(context.Background())
+	if err != nil || len(tools) == 0 || !tools[0].Annotations.ReadOnly() || tools[0].Annotations.Title != "Ping" {
+		t.Fatalf("Expected the annotations of f:ping, got %+v, %v", tools, err)
+	}
+
+	resp, err := gw.CallTool(context.Background(), "f:ping", nil)
+	if err != nil || resp.Content[0].Text != "annotated" {
+		t.Fatalf("Expected the read-only tool to be retried, got %+v, %v", resp, err)
+	}
+	if stats := gw.Stats()[0]; stats.Retries != 1 {
+		t.Errorf("Expected 1 retry, got %+v", stats)
+	}
+}
+
+func TestMultipleContentItems(t *testing.T) {
+	handler := upstreamHandler("")
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tools/call" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"content": []map[string]interface{}{
+				{"type": "text", "text": "Screenshot taken"},
+				{"type": "image", "data": "cG5n", "mimeType": "image/png"},
+				{"type": "resource", "resource": map[string]string{"uri": "file:///page.html", "text": "<html>"}},
+			},
+			"isError": true,
+		})
+	}))
+	defer upstream.Close()
+
+	gw := NewGateway()
+	if err := gw.LoadFromConfig(&config.Config{Servers: []config.MCPConfig{
+		{Name: "browser", URL: upstream.URL, Enabled: true, Prefix: "b:"},
+	}}); err != nil {
+		t.Fatalf("LoadFromConfig failed: %v", err)
+	}
+	resp, err := gw.CallTool(context.Background(), "b:ping", nil)
+	if err != nil || len(resp.Content) != 3 || !resp.IsError {
+		t.Fatalf("Expected three content items flagged as an error, got %+v, %v", resp, err)
+	}
+	if image := resp.Content[1]; image.Data != "cG5n" || image.MimeType != "image/png" {
+		t.Errorf("Expected the image to be kept, got %+v", image)
+	}
+	if resource := resp.Content[2].Resource; resource == nil || resource.URI != "file:///page.html" || resource.Text != "<html>" {
+		t.Errorf("Expected the embedded resource to be kept, got %+v", resource)
+	}
+}
@@ -0,0 +1,152 @@
+package gateway
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"mcp-go/logging"
+	"os"
+	"sync"
+	"time"
+)
+
+// callHistorySize is the number of recent tool calls kept in memory
+const callHistorySize = 1000
+
+// CallRecord describes one tool call routed to an upstream server
+type CallRecord struct {
+	Time     time.Time `json:"time"`
+	Tool     string    `json:"tool"`
+	Server   string    `json:"server"`
+	Duration float64   `json:"durationSeconds"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// CallFilter selects records returned by Calls. Empty fields match anything.
+type CallFilter struct {
+	Tool   string
+	Server string
+	Limit  int // Maximum number of records (0 = all kept)
+}
+
+// callHistory is a ring buffer of recent calls, optionally appended to a
+// JSON lines file so the history survives restarts
+type callHistory struct {
+	mu      sync.Mutex
+	records []CallRecord
+	next    int
+	file    *os.File
+}
+
+// add appends a record, overwriting the oldest once the buffer is full
+func (h *callHistory) add(record CallRecord) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.records) < callHistorySize {
+		h.records = append(h.records, record)
+	} else {
+		h.records[h.next] = record
+		h.next = (h.next + 1) % callHistorySize
+	}
+
+	if h.file != nil {
+		data, err := json.Marshal(record)
+		if err == nil {
+			_, err = h.file.Write(append(data, '\n'))
+		}
+		if err != nil {
+			logging.Warnf("Failed to persist call history: %v", err)
+		}
+	}
+}
+
+// newest returns the matching records, most recent first
+func (h *callHistory) newest(filter CallFilter) []CallRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := []CallRecord{}
+	for i := 0; i < len(h.records); i++ {
+		// Walk backwards from the most recently written slot
+		idx := (h.next - 1 - i + 2*len(h.records)) % len(h.records)
+		r := h.records[idx]
+		if (filter.Tool != "" && r.Tool != filter.Tool) || (filter.Server != "" && r.Server != filter.Server) {
+			continue
+		}
+		result = append(result, r)
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+	}
+	return result
+}
+
+// close stops persisting the history
+func (h *callHistory) close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil {
+		return nil
+	}
+	err := h.file.Close()
+	h.file = nil
+	return err
+}
+
+// recordCall adds a tool call to the history
+func (g *Gateway) recordCall(server, tool string, start time.Time, err error) {
+	record := CallRecord{
+		Time:     start,
+		Tool:     tool,
+		Server:   server,
+		Duration: time.Since(start).Seconds(),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	g.history.add(record)
+}
+
+// Calls returns recent tool calls matching filter, most recent first
+func (g *Gateway) Calls(filter CallFilter) []CallRecord {
+	return g.history.newest(filter)
+}
+
+// PersistCallHistory loads the calls recorded in path by a previous run and
+// appends every new call to it. The file is created if it does not exist.
+func (g *Gateway) PersistCallHistory(path string) error {
+	var previous []CallRecord
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var record CallRecord
+			if json.Unmarshal(scanner.Bytes(), &record) == nil {
+				previous = append(previous, record)
+			}
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("failed to read call history %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to open call history %s: %w", path, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open call history %s: %w", path, err)
+	}
+
+	if len(previous) > callHistorySize {
+		previous = previous[len(previous)-callHistorySize:]
+	}
+	for _, record := range previous {
+		g.history.add(record)
+	}
+
+	g.history.mu.Lock()
+	g.history.file = file
+	g.history.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,167 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"mcp-go/client"
+	"mcp-go/logging"
+	"sort"
+	"time"
+)
+
+// Initialization modes for upstream servers
+const (
+	InitializeLazy       = "lazy"       // Initialize on first use (default)
+	InitializeEager      = "eager"      // Initialize before the server starts accepting requests
+	InitializeBackground = "background" // Initialize asynchronously after startup
+)
+
+// defaultInitializeTimeout bounds a single initialization attempt of a
+// client whose configuration sets no initializeTimeoutSeconds
+const defaultInitializeTimeout = 30 * time.Second
+
+// InitializeResult reports the outcome of initializing one client
+type InitializeResult struct {
+	Name     string        `json:"name"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// backgroundRetryInterval is how long a failed background initialization
+// waits before trying again
+const backgroundRetryInterval = 30 * time.Second
+
+// Readiness states reported for each client
+const (
+	ReadinessReady        = "ready"
+	ReadinessInitializing = "initializing"
+	ReadinessFailed       = "failed"
+	ReadinessLazy         = "lazy" // Not initialized yet, does not block readiness
+)
+
+// ClientReadiness describes whether one upstream server is ready to serve
+type ClientReadiness struct {
+	Name  string `json:"name"`
+	Mode  string `json:"mode"`
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+}
+
+// initializeMode returns the configured initialization mode of a client
+func initializeMode(c client.Client) string {
+	if mode := c.GetConfig().Initialize; mode != "" {
+		return mode
+	}
+	return InitializeLazy
+}
+
+// validateInitializeMode rejects unknown initialization modes
+func validateInitializeMode(mode string) error {
+	switch mode {
+	case "", InitializeLazy, InitializeEager, InitializeBackground:
+		return nil
+	}
+	return fmt.Errorf("unsupported initialize mode: %s", mode)
+}
+
+// InitializeConfigured initializes clients according to their configured
+// mode: eager clients are initialized before returning, background clients
+// are initialized asynchronously (retrying until ctx is done) and lazy
+// clients are left for their first use. The returned error lists eager
+// clients that failed.
+func (g *Gateway) InitializeConfigured(ctx context.Context) error {
+	return g.initializeConfigured(ctx, g.clientList())
+}
+
+// initializeConfigured initializes the given clients according to their mode
+func (g *Gateway) initializeConfigured(ctx context.Context, clients []client.Client) error {
+	var eager []client.Client
+	for _, c := range clients {
+		switch initializeMode(c) {
+		case InitializeEager:
+			eager = append(eager, c)
+		case InitializeBackground:
+			g.setInitializing(c.GetName(), true)
+			go g.initializeInBackground(ctx, c)
+		}
+	}
+
+	if len(eager) == 0 {
+		return nil
+	}
+	return initializeError(g.initializeClients(ctx, eager))
+}
+
+// initializeInBackground initializes a client, retrying on failure
+func (g *Gateway) initializeInBackground(ctx context.Context, c client.Client) {
+	defer g.setInitializing(c.GetName(), false)
+
+	for {
+		err := g.initializeClient(ctx, c)
+		if err == nil {
+			logging.Infof("Successfully initialized MCP client in background: %s", c.GetName())
+			return
+		}
+		g.statsFor(c.GetName()).recordError(err)
+		logging.Warnf("Background initialization of %s failed, retrying in %v: %v", c.GetName(), backgroundRetryInterval, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backgroundRetryInterval):
+		}
+	}
+}
+
+// setInitializing records whether a background initialization is running
+func (g *Gateway) setInitializing(name string, running bool) {
+	g.statsMu.Lock()
+	defer g.statsMu.Unlock()
+	if running {
+		g.initializing[name] = true
+	} else {
+		delete(g.initializing, name)
+	}
+}
+
+// Ready reports whether every eager and background client has been
+// initialized, along with the state of each client sorted by name
+func (g *Gateway) Ready() (bool, []ClientReadiness) {
+	clients := g.clientList()
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].GetName() < clients[j].GetName()
+	})
+
+	ready := true
+	result := make([]ClientReadiness, 0, len(clients))
+	for _, c := range clients {
+		name := c.GetName()
+		r := ClientReadiness{Name: name, Mode: initializeMode(c)}
+
+		g.statsMu.Lock()
+		running := g.initializing[name]
+		g.statsMu.Unlock()
+
+		switch {
+		case c.IsInitialized():
+			r.State = ReadinessReady
+		case r.Mode == InitializeLazy:
+			r.State = ReadinessLazy
+		case running:
+			r.State = ReadinessInitializing
+			ready = false
+		default:
+			r.State = ReadinessFailed
+			ready = false
+		}
+
+		if r.State != ReadinessReady {
+			s := g.statsFor(name)
+			s.mu.Lock()
+			r.Error = s.lastError
+			s.mu.Unlock()
+		}
+		result = append(result, r)
+	}
+	return ready, result
+}
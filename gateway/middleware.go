@@ -0,0 +1,35 @@
+package gateway
+
+import (
+	"context"
+	"mcp-go/transport"
+)
+
+// CallFunc executes a tool call routed through the gateway
+type CallFunc func(ctx context.Context, name string, arguments map[string]interface{}) (*transport.ToolResponse, error)
+
+// Middleware wraps a CallFunc with cross-cutting behavior such as argument
+// validation, redaction, billing or caching. A middleware may inspect or
+// modify the call, short-circuit it by not calling next, or post-process the
+// response.
+type Middleware func(next CallFunc) CallFunc
+
+// Use appends middleware to the chain applied around every routed tool call.
+// Middleware registered first is the outermost and sees the call first.
+func (g *Gateway) Use(middleware ...Middleware) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.middleware = append(g.middleware, middleware...)
+}
+
+// chain wraps call with the registered middleware
+func (g *Gateway) chain(call CallFunc) CallFunc {
+	g.mu.RLock()
+	middleware := g.middleware
+	g.mu.RUnlock()
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		call = middleware[i](call)
+	}
+	return call
+}
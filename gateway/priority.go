@@ -0,0 +1,69 @@
+package gateway
+
+import (
+	"context"
+	"mcp-go/client"
+	"mcp-go/logging"
+	"sort"
+)
+
+// sortByPriority returns the clients ordered for resolving unprefixed tool
+// names: highest configured priority first, ties broken by name
+func sortByPriority(clients []client.Client) []client.Client {
+	sorted := append([]client.Client(nil), clients...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, pj := sorted[i].GetConfig().Priority, sorted[j].GetConfig().Priority
+		if pi != pj {
+			return pi > pj
+		}
+		return sorted[i].GetName() < sorted[j].GetName()
+	})
+	return sorted
+}
+
+// shadowing is a tool or prompt name exposed by shadowed that is hidden
+// because owner, which comes first in resolution order, exposes it too
+type shadowing struct {
+	name            string
+	owner, shadowed client.Client
+}
+
+// reportShadowed logs the shadowings a listing of a kind ("tool" or
+// "prompt") found that the previous listing did not, so each is reported
+// once when the catalog changes rather than on every listing. Shadowings
+// that disappear are forgotten and reported again if they return. Listings
+// restricted with WithTags see only part of the catalog and are skipped:
+// whatever they shadow is shadowed in the full catalog too.
+func (g *Gateway) reportShadowed(ctx context.Context, kind string, found []shadowing) {
+	if len(TagsFromContext(ctx)) > 0 {
+		return
+	}
+	current := make(map[string]bool, len(found))
+	var added []shadowing
+	g.catalogMu.Lock()
+	if g.shadowed == nil {
+		g.shadowed = make(map[string]map[string]bool)
+	}
+	for _, s := range found {
+		key := s.name + "\x00" + s.owner.GetName() + "\x00" + s.shadowed.GetName()
+		current[key] = true
+		if !g.shadowed[kind][key] {
+			added = append(added, s)
+		}
+	}
+	g.shadowed[kind] = current
+	g.catalogMu.Unlock()
+
+	// Equal priorities are called out because the winner was then chosen
+	// only by name
+	for _, s := range added {
+		ownerPriority := s.owner.GetConfig().Priority
+		if ownerPriority == s.shadowed.GetConfig().Priority {
+			logging.Warnf("%s %s is exposed by both %s and %s with equal priority %d; resolving to %s by name (set priority to choose explicitly)",
+				kind, s.name, s.owner.GetName(), s.shadowed.GetName(), ownerPriority, s.owner.GetName())
+			continue
+		}
+		logging.Infof("The %s %s from %s is shadowed by %s (priority %d > %d)",
+			kind, s.name, s.shadowed.GetName(), s.owner.GetName(), ownerPriority, s.shadowed.GetConfig().Priority)
+	}
+}
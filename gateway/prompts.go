@@ -0,0 +1,79 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"mcp-go/client"
+	"mcp-go/logging"
+	"mcp-go/transport"
+)
+
+// ListAllPrompts returns the prompts of every connected client, with names
+// prefixed the same way as tool names. A prompt name exposed by several
+// clients is listed once, for the client that GetPrompt resolves it to.
+func (g *Gateway) ListAllPrompts(ctx context.Context) ([]transport.Prompt, error) {
+	clients := g.capableClients(ctx, capabilityPrompts)
+
+	type result struct {
+		prompts []transport.Prompt
+		err     error
+		name    string
+	}
+	results := make(chan result, len(clients))
+
+	for _, c := range clients {
+		go func(c client.Client) {
+			prompts, err := c.ListPrompts(ctx)
+			results <- result{prompts: prompts, err: err, name: c.GetName()}
+		}(c)
+	}
+
+	byClient := make(map[string][]transport.Prompt, len(clients))
+	for i := 0; i < len(clients); i++ {
+		res := <-results
+		if res.err != nil {
+			logging.Warnf("Failed to list prompts from %s: %v", res.name, res.err)
+			continue
+		}
+		byClient[res.name] = res.prompts
+	}
+
+	var allPrompts []transport.Prompt
+	var shadowed []shadowing
+	owners := make(map[string]client.Client)
+	for _, c := range sortByPriority(clients) {
+		for _, p := range byClient[c.GetName()] {
+			if owner, exists := owners[p.Name]; exists {
+				shadowed = append(shadowed, shadowing{p.Name, owner, c})
+				continue
+			}
+			owners[p.Name] = c
+			allPrompts = append(allPrompts, p)
+		}
+	}
+	g.reportShadowed(ctx, "prompt", shadowed)
+
+	return allPrompts, nil
+}
+
+// GetPrompt renders a prompt, routing by name prefix like CallTool
+func (g *Gateway) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*transport.PromptResult, error) {
+	clients := g.capableClients(ctx, capabilityPrompts)
+
+	if owner := ownerByPrefix(clients, name); owner != nil {
+		return owner.GetPrompt(ctx, name, arguments)
+	}
+
+	// If no prefix match, try all clients in priority order
+	for _, c := range sortByPriority(clients) {
+		result, err := c.GetPrompt(ctx, name, arguments)
+		if err == nil {
+			return result, nil
+		}
+		if !isNotFoundError(err) {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("prompt '%s' not found in any connected MCP server", name)
+}
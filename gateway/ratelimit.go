@@ -0,0 +1,103 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"mcp-go/config"
+	"sync"
+	"time"
+)
+
+// rateLimiter enforces a client's RateLimitConfig with a token bucket for
+// the call rate and a semaphore for concurrency
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	slots chan struct{} // nil when concurrency is unlimited
+}
+
+// newRateLimiter creates a limiter, or returns nil when cfg sets no limit
+func newRateLimiter(cfg config.RateLimitConfig) *rateLimiter {
+	if cfg.RequestsPerSecond <= 0 && cfg.MaxConcurrent <= 0 {
+		return nil
+	}
+
+	l := &rateLimiter{rate: cfg.RequestsPerSecond, burst: float64(cfg.Burst)}
+	if l.burst < 1 {
+		l.burst = 1
+	}
+	l.tokens = l.burst
+	l.last = time.Now()
+	if cfg.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, cfg.MaxConcurrent)
+	}
+	return l
+}
+
+// acquire waits until a call may start and returns the function that must be
+// called when it finishes
+func (l *rateLimiter) acquire(ctx context.Context) (func(), error) {
+	if err := l.waitToken(ctx); err != nil {
+		return nil, err
+	}
+	if l.slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case l.slots <- struct{}{}:
+		return func() { <-l.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// waitToken takes a token from the bucket, waiting for one to accumulate
+func (l *rateLimiter) waitToken(ctx context.Context) error {
+	if l.rate <= 0 {
+		return nil
+	}
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// throttle waits for the rate limiter of a client, if it has one
+func (g *Gateway) throttle(ctx context.Context, name string) (func(), error) {
+	g.mu.RLock()
+	limiter := g.limiters[name]
+	g.mu.RUnlock()
+	if limiter == nil {
+		return func() {}, nil
+	}
+
+	release, err := limiter.acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for rate limit of %s: %w", name, err)
+	}
+	return release, nil
+}
@@ -0,0 +1,79 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"mcp-go/client"
+	"mcp-go/config"
+	"mcp-go/logging"
+	"reflect"
+	"strings"
+)
+
+// Reload applies a changed configuration to a running gateway. Servers that
+// were removed or disabled are closed, new servers are added and servers
+// whose settings changed are replaced; unchanged servers keep their
+// connection. New clients are initialized according to their "initialize"
+// mode (background initialization runs until ctx is done) and the tool
+// catalog is refreshed so connected clients are notified of the changes.
+func (g *Gateway) Reload(ctx context.Context, cfg *config.Config) error {
+	desired := make(map[string]config.MCPConfig)
+	for _, serverCfg := range cfg.Servers {
+		if serverCfg.Enabled {
+			desired[serverCfg.Name] = serverCfg
+		}
+	}
+
+	// Detach the clients that are gone or changed
+	var removed []client.Client
+	g.mu.Lock()
+	for name, c := range g.clients {
+		if serverCfg, ok := desired[name]; ok && reflect.DeepEqual(c.GetConfig(), serverCfg) {
+			delete(desired, name)
+			continue
+		}
+		removed = append(removed, c)
+		delete(g.clients, name)
+		delete(g.transforms, name)
+		delete(g.retryBudgets, name)
+		delete(g.limiters, name)
+	}
+	g.disabledTools = make(map[string]bool)
+	g.mu.Unlock()
+	g.DisableTools(cfg.DisabledTools...)
+
+	var problems []string
+	for _, c := range removed {
+		if err := c.Close(); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", c.GetName(), err))
+		}
+	}
+
+	// Add the new and changed servers in configuration order
+	var added []client.Client
+	for _, serverCfg := range cfg.Servers {
+		if _, ok := desired[serverCfg.Name]; !ok || !serverCfg.Enabled {
+			continue
+		}
+		c, err := newClient(serverCfg)
+		if err == nil {
+			err = g.AddClient(c)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", serverCfg.Name, err))
+			continue
+		}
+		added = append(added, c)
+	}
+	logging.Infof("Configuration reloaded: %d servers removed or replaced, %d added", len(removed), len(added))
+
+	if err := g.initializeConfigured(ctx, added); err != nil {
+		problems = append(problems, err.Error())
+	}
+	g.RefreshCatalog(ctx)
+
+	if len(problems) > 0 {
+		return fmt.Errorf("errors reloading configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,405 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mcp-go/client"
+	"mcp-go/config"
+	"mcp-go/logging"
+	"mcp-go/transport"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Load balancing strategies for replicated upstream servers
+const (
+	LoadBalancingFailover     = "failover"      // Always prefer the primary, fall back in configuration order
+	LoadBalancingRoundRobin   = "round_robin"   // Rotate the starting endpoint on every call
+	LoadBalancingLeastPending = "least_pending" // Prefer the endpoint with the fewest in-flight calls
+)
+
+// unhealthyCooldown is how long a failed endpoint is skipped before it is
+// tried again
+const unhealthyCooldown = 30 * time.Second
+
+// endpoint is a single replica of a logical MCP server together with its
+// health and load counters
+type endpoint struct {
+	url     string
+	client  client.Client
+	pending int64 // In-flight calls, updated atomically
+
+	mu             sync.Mutex
+	unhealthyUntil time.Time
+	lastError      error
+}
+
+// healthy reports whether the endpoint may receive traffic
+func (e *endpoint) healthy(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return now.After(e.unhealthyUntil)
+}
+
+// markFailure takes the endpoint out of rotation for the cooldown period
+func (e *endpoint) markFailure(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.unhealthyUntil = time.Now().Add(unhealthyCooldown)
+	e.lastError = err
+}
+
+// markSuccess puts the endpoint back into rotation
+func (e *endpoint) markSuccess() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.unhealthyUntil = time.Time{}
+	e.lastError = nil
+}
+
+// replicaSet presents several endpoints of the same logical MCP server as a
+// single client. Calls are spread over healthy endpoints according to the
+// configured load balancing strategy and fail over to the remaining ones
+// when an endpoint returns an error. A tool call that may have run is only
+// repeated on another endpoint when the tool is safe to repeat.
+type replicaSet struct {
+	config    config.MCPConfig
+	name      string
+	prefix    string
+	strategy  string
+	endpoints []*endpoint
+	next      uint64 // Round-robin cursor, updated atomically
+
+	mu          sync.Mutex
+	annotations map[string]*transport.ToolAnnotations // From the last tool listing, by prefixed name
+}
+
+// newReplicaSet creates one client per endpoint listed in the configuration
+func newReplicaSet(cfg config.MCPConfig) (*replicaSet, error) {
+	strategy := cfg.LoadBalancing
+	if strategy == "" {
+		strategy = LoadBalancingFailover
+	}
+	switch strategy {
+	case LoadBalancingFailover, LoadBalancingRoundRobin, LoadBalancingLeastPending:
+	default:
+		return nil, fmt.Errorf("unsupported load balancing strategy for %s: %s", cfg.Name, strategy)
+	}
+
+	rs := &replicaSet{
+		config:   cfg,
+		name:     cfg.Name,
+		prefix:   cfg.Prefix,
+		strategy: strategy,
+	}
+
+	for _, url := range cfg.Endpoints() {
+		replicaCfg := cfg
+		replicaCfg.URL = url
+		replicaCfg.Replicas = nil
+
+		c, err := client.NewClient(replicaCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create client for %s (%s): %w", cfg.Name, url, err)
+		}
+		rs.endpoints = append(rs.endpoints, &endpoint{url: url, client: c})
+	}
+
+	return rs, nil
+}
+
+// isNotFoundError reports whether err means the tool does not exist upstream.
+// Such errors are answered identically by every replica, so they never
+// trigger a failover.
+func isNotFoundError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "not found")
+}
+
+// isConnectionError reports whether err means the endpoint could not be
+// reached, so a call cannot have run there
+func isConnectionError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// noFailover wraps an error after which try must not move on to the next
+// endpoint
+type noFailover struct {
+	err error
+}
+
+func (e *noFailover) Error() string { return e.err.Error() }
+func (e *noFailover) Unwrap() error { return e.err }
+
+// repeatable reports whether a call to the tool may run again on another
+// endpoint after failing, by the same rule as callWithRetry: the retry
+// policy lists it as read-only, or the server annotates it idempotent and
+// the policy trusts annotations
+func (rs *replicaSet) repeatable(name string) bool {
+	if isReadOnlyTool(rs.config, name) {
+		return true
+	}
+	if !rs.config.Retry.TrustAnnotations {
+		return false
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return rs.annotations[name].Idempotent()
+}
+
+// order returns the endpoints in the order they should be tried: healthy
+// endpoints arranged by the load balancing strategy, followed by unhealthy
+// ones as a last resort
+func (rs *replicaSet) order() []*endpoint {
+	now := time.Now()
+	var healthy, unhealthy []*endpoint
+	for _, e := range rs.endpoints {
+		if e.healthy(now) {
+			healthy = append(healthy, e)
+		} else {
+			unhealthy = append(unhealthy, e)
+		}
+	}
+
+	switch rs.strategy {
+	case LoadBalancingRoundRobin:
+		if len(healthy) > 1 {
+			start := int(atomic.AddUint64(&rs.next, 1)-1) % len(healthy)
+			healthy = append(healthy[start:], healthy[:start]...)
+		}
+	case LoadBalancingLeastPending:
+		for i := 1; i < len(healthy); i++ {
+			for j := i; j > 0 && atomic.LoadInt64(&healthy[j].pending) < atomic.LoadInt64(&healthy[j-1].pending); j-- {
+				healthy[j], healthy[j-1] = healthy[j-1], healthy[j]
+			}
+		}
+	}
+
+	return append(healthy, unhealthy...)
+}
+
+// try runs fn against each endpoint in turn until one succeeds, updating the
+// endpoint health as it goes. fn stops the failover by returning its error
+// wrapped in noFailover.
+func (rs *replicaSet) try(ctx context.Context, action string, fn func(c client.Client) error) error {
+	var failures []string
+	endpoints := rs.order()
+	for i, e := range endpoints {
+		atomic.AddInt64(&e.pending, 1)
+		err := fn(e.client)
+		atomic.AddInt64(&e.pending, -1)
+
+		if err == nil {
+			e.markSuccess()
+			return nil
+		}
+		var final *noFailover
+		stop := errors.As(err, &final)
+		if stop {
+			err = final.err
+		}
+		if isNotFoundError(err) || ctx.Err() != nil {
+			return err
+		}
+
+		e.markFailure(err)
+		if stop {
+			return err
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", e.url, err))
+		if i+1 < len(endpoints) {
+			logging.Warnf("%s endpoint %s failed, failing over to %s: %v", rs.name, e.url, endpoints[i+1].url, err)
+		}
+	}
+	return fmt.Errorf("all endpoints of %s failed to %s: %s", rs.name, action, strings.Join(failures, "; "))
+}
+
+// Initialize initializes the first endpoint that responds
+func (rs *replicaSet) Initialize(ctx context.Context) error {
+	return rs.try(ctx, "initialize", func(c client.Client) error {
+		return c.Initialize(ctx)
+	})
+}
+
+// ListTools lists tools from the first endpoint that responds
+func (rs *replicaSet) ListTools(ctx context.Context) ([]transport.Tool, error) {
+	var tools []transport.Tool
+	err := rs.try(ctx, "list tools", func(c client.Client) error {
+		var err error
+		tools, err = c.ListTools(ctx)
+		return err
+	})
+	if err == nil {
+		annotations := make(map[string]*transport.ToolAnnotations, len(tools))
+		for _, tool := range tools {
+			annotations[tool.Name] = tool.Annotations
+		}
+		rs.mu.Lock()
+		rs.annotations = annotations
+		rs.mu.Unlock()
+	}
+	return tools, err
+}
+
+// CallTool calls the tool on the first endpoint that responds. An endpoint
+// that cannot be reached or initialized is skipped, but once a call may
+// have run it is only repeated elsewhere for tools safe to repeat.
+func (rs *replicaSet) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*transport.ToolResponse, error) {
+	repeatable := rs.repeatable(name)
+	var resp *transport.ToolResponse
+	err := rs.try(ctx, "call tool "+name, func(c client.Client) error {
+		if err := c.Initialize(ctx); err != nil {
+			return err
+		}
+		var err error
+		resp, err = c.CallTool(ctx, name, arguments)
+		if err != nil && !repeatable && !isConnectionError(err) {
+			return &noFailover{err}
+		}
+		return err
+	})
+	return resp, err
+}
+
+// ListResources lists resources from the first endpoint that responds
+func (rs *replicaSet) ListResources(ctx context.Context) ([]transport.Resource, error) {
+	var resources []transport.Resource
+	err := rs.try(ctx, "list resources", func(c client.Client) error {
+		var err error
+		resources, err = c.ListResources(ctx)
+		return err
+	})
+	return resources, err
+}
+
+// ReadResource reads the resource from the first endpoint that responds
+func (rs *replicaSet) ReadResource(ctx context.Context, uri string) ([]transport.ResourceContent, error) {
+	var contents []transport.ResourceContent
+	err := rs.try(ctx, "read resource "+uri, func(c client.Client) error {
+		var err error
+		contents, err = c.ReadResource(ctx, uri)
+		return err
+	})
+	return contents, err
+}
+
+// ListPrompts lists prompts from the first endpoint that responds
+func (rs *replicaSet) ListPrompts(ctx context.Context) ([]transport.Prompt, error) {
+	var prompts []transport.Prompt
+	err := rs.try(ctx, "list prompts", func(c client.Client) error {
+		var err error
+		prompts, err = c.ListPrompts(ctx)
+		return err
+	})
+	return prompts, err
+}
+
+// GetPrompt renders the prompt on the first endpoint that responds
+func (rs *replicaSet) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*transport.PromptResult, error) {
+	var result *transport.PromptResult
+	err := rs.try(ctx, "get prompt "+name, func(c client.Client) error {
+		var err error
+		result, err = c.GetPrompt(ctx, name, arguments)
+		return err
+	})
+	return result, err
+}
+
+// Close closes every endpoint
+func (rs *replicaSet) Close() error {
+	var errors []string
+	for _, e := range rs.endpoints {
+		if err := e.client.Close(); err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", e.url, err))
+		}
+	}
+	if len(errors) > 0 {
+		return fmt.Errorf("errors closing %s: %s", rs.name, strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// GetName returns the name of the logical MCP server
+func (rs *replicaSet) GetName() string {
+	return rs.name
+}
+
+// GetPrefix returns the tool name prefix shared by all endpoints
+func (rs *replicaSet) GetPrefix() string {
+	return rs.prefix
+}
+
+// GetConfig returns the configuration of the logical MCP server
+func (rs *replicaSet) GetConfig() config.MCPConfig {
+	return rs.config
+}
+
+// IsInitialized reports whether any endpoint has been initialized
+func (rs *replicaSet) IsInitialized() bool {
+	for _, e := range rs.endpoints {
+		if e.client.IsInitialized() {
+			return true
+		}
+	}
+	return false
+}
+
+// Capabilities returns the capabilities of the first initialized endpoint.
+// Replicas are expected to run the same server.
+func (rs *replicaSet) Capabilities() map[string]interface{} {
+	for _, e := range rs.endpoints {
+		if caps := e.client.Capabilities(); caps != nil {
+			return caps
+		}
+	}
+	return nil
+}
+
+// EndpointHealth describes the health and load of a single replica endpoint
+type EndpointHealth struct {
+	URL       string `json:"url"`
+	Healthy   bool   `json:"healthy"`
+	Pending   int64  `json:"pending"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+// health returns a snapshot of every endpoint's health
+func (rs *replicaSet) health() []EndpointHealth {
+	now := time.Now()
+	result := make([]EndpointHealth, 0, len(rs.endpoints))
+	for _, e := range rs.endpoints {
+		h := EndpointHealth{
+			URL:     e.url,
+			Healthy: e.healthy(now),
+			Pending: atomic.LoadInt64(&e.pending),
+		}
+		e.mu.Lock()
+		if e.lastError != nil {
+			h.LastError = e.lastError.Error()
+		}
+		e.mu.Unlock()
+		result = append(result, h)
+	}
+	return result
+}
+
+// EndpointHealth returns the health of each endpoint of a replicated client.
+// The second return value is false if the client does not exist or has no
+// replicas.
+func (g *Gateway) EndpointHealth(name string) ([]EndpointHealth, bool) {
+	g.mu.RLock()
+	c, ok := g.clients[name]
+	g.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	rs, ok := c.(*replicaSet)
+	if !ok {
+		return nil, false
+	}
+	return rs.health(), true
+}
@@ -0,0 +1,93 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"mcp-go/client"
+	"mcp-go/logging"
+	"mcp-go/transport"
+	"strings"
+)
+
+// ListAllResources returns the resources of every connected client, with
+// URIs prefixed the same way as tool names. Resources are fetched in parallel.
+func (g *Gateway) ListAllResources(ctx context.Context) ([]transport.Resource, error) {
+	clients := g.capableClients(ctx, capabilityResources)
+
+	type result struct {
+		resources []transport.Resource
+		err       error
+		name      string
+	}
+	results := make(chan result, len(clients))
+
+	for _, c := range clients {
+		go func(c client.Client) {
+			resources, err := c.ListResources(ctx)
+			results <- result{resources: resources, err: err, name: c.GetName()}
+		}(c)
+	}
+
+	byClient := make(map[string][]transport.Resource, len(clients))
+	for i := 0; i < len(clients); i++ {
+		res := <-results
+		if res.err != nil {
+			logging.Warnf("Failed to list resources from %s: %v", res.name, res.err)
+			continue
+		}
+		byClient[res.name] = res.resources
+	}
+
+	// Merge in resolution order, listing each URI once
+	var allResources []transport.Resource
+	seen := make(map[string]bool)
+	for _, c := range sortByPriority(clients) {
+		for _, r := range byClient[c.GetName()] {
+			if seen[r.URI] {
+				continue
+			}
+			seen[r.URI] = true
+			allResources = append(allResources, r)
+		}
+	}
+
+	return allResources, nil
+}
+
+// ReadResource reads a resource, routing by URI prefix like CallTool
+func (g *Gateway) ReadResource(ctx context.Context, uri string) ([]transport.ResourceContent, error) {
+	clients := g.capableClients(ctx, capabilityResources)
+
+	if owner := ownerByPrefix(clients, uri); owner != nil {
+		return owner.ReadResource(ctx, uri)
+	}
+
+	// If no prefix match, try all clients in priority order
+	for _, c := range sortByPriority(clients) {
+		contents, err := c.ReadResource(ctx, uri)
+		if err == nil {
+			return contents, nil
+		}
+		if !isNotFoundError(err) {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("resource '%s' not found in any connected MCP server", uri)
+}
+
+// ownerByPrefix returns the client whose prefix is the longest match for
+// name, or nil if no prefix matches
+func ownerByPrefix(clients []client.Client, name string) client.Client {
+	var owner client.Client
+	for _, c := range clients {
+		prefix := c.GetPrefix()
+		if prefix != "" && strings.HasPrefix(name, prefix) {
+			if owner == nil || len(prefix) > len(owner.GetPrefix()) ||
+				(len(prefix) == len(owner.GetPrefix()) && c.GetName() < owner.GetName()) {
+				owner = c
+			}
+		}
+	}
+	return owner
+}
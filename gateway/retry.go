@@ -0,0 +1,128 @@
+package gateway
+
+import (
+	"context"
+	"mcp-go/client"
+	"mcp-go/config"
+	"mcp-go/logging"
+	"mcp-go/transport"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultRetryBackoff is the delay before the first retry when the
+	// policy does not set one
+	defaultRetryBackoff = 100 * time.Millisecond
+	// maxRetryBackoff caps the doubled delay between retries
+	maxRetryBackoff = 5 * time.Second
+	// defaultRetryBudgetPercent is the share of calls that may be retried
+	// when the policy does not set one
+	defaultRetryBudgetPercent = 20
+	// retryBudgetReserve is the number of retries available before any call
+	// has been made, and the most the budget can save up
+	retryBudgetReserve = 10
+)
+
+// retryBudget limits retries to a share of the calls made to a client, so a
+// failing upstream is not hit with MaxAttempts times its normal load
+type retryBudget struct {
+	mu     sync.Mutex
+	ratio  float64
+	tokens float64
+}
+
+// newRetryBudget creates the budget for a retry policy
+func newRetryBudget(policy config.RetryConfig) *retryBudget {
+	percent := policy.BudgetPercent
+	if percent <= 0 {
+		percent = defaultRetryBudgetPercent
+	}
+	return &retryBudget{ratio: float64(percent) / 100, tokens: retryBudgetReserve}
+}
+
+// deposit credits the budget for one call
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += b.ratio
+	if b.tokens > retryBudgetReserve {
+		b.tokens = retryBudgetReserve
+	}
+}
+
+// withdraw takes one retry from the budget, reporting false when exhausted
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// isReadOnlyTool reports whether the retry policy marks a tool as safe to
+// repeat. name may carry the client prefix.
+func isReadOnlyTool(cfg config.MCPConfig, name string) bool {
+	name = strings.TrimPrefix(name, cfg.Prefix)
+	for _, tool := range cfg.Retry.ReadOnlyTools {
+		if tool == "*" || tool == name {
+			return true
+		}
+	}
+	return false
+}
+
+// callWithRetry calls a tool, retrying failed calls to read-only tools, and
+// to tools annotated idempotent when the policy trusts annotations, with
+// exponential backoff while attempts and the client's retry budget last.
+// "not found" errors and cancellation are never retried. Every attempt
+// waits for the client's rate limit.
+func (g *Gateway) callWithRetry(ctx context.Context, c client.Client, name string, arguments map[string]interface{}) (*transport.ToolResponse, error) {
+	cfg := c.GetConfig()
+
+	g.mu.RLock()
+	budget := g.retryBudgets[c.GetName()]
+	g.mu.RUnlock()
+	if budget != nil {
+		budget.deposit()
+	}
+
+	safe := isReadOnlyTool(cfg, name) || (cfg.Retry.TrustAnnotations && g.annotations(c.GetName(), name).Idempotent())
+	retryable := budget != nil && cfg.Retry.MaxAttempts > 1 && safe
+	backoff := time.Duration(cfg.Retry.BackoffMs) * time.Millisecond
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		release, err := g.throttle(ctx, c.GetName())
+		if err != nil {
+			return nil, err
+		}
+		resp, err := c.CallTool(ctx, name, arguments)
+		release()
+		if err == nil || !retryable || attempt >= cfg.Retry.MaxAttempts || isNotFoundError(err) || ctx.Err() != nil {
+			return resp, err
+		}
+		if !budget.withdraw() {
+			logging.Warnf("Retry budget of %s exhausted, not retrying %s: %v", c.GetName(), name, err)
+			return resp, err
+		}
+
+		g.statsFor(c.GetName()).recordRetry()
+		logging.Infof("Retrying %s on %s in %v (attempt %d of %d): %v", name, c.GetName(), backoff, attempt+1, cfg.Retry.MaxAttempts, err)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the call latency
+// histogram kept for every client
+var latencyBuckets = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// latencySamples is the number of recent latencies kept per client for
+// percentile calculation
+const latencySamples = 1024
+
+// LatencyBucket is a cumulative histogram bucket
+type LatencyBucket struct {
+	UpperBound float64 `json:"le"` // Upper bound in seconds
+	Count      uint64  `json:"count"`
+}
+
+// ClientStats holds call counters and latency figures for one upstream client
+type ClientStats struct {
+	Name         string          `json:"name"`
+	Calls        uint64          `json:"calls"`
+	Errors       uint64          `json:"errors"`
+	Timeouts     uint64          `json:"timeouts"`
+	Retries      uint64          `json:"retries"`
+	LatencySum   float64         `json:"latencySumSeconds"`
+	LatencyP50   float64         `json:"latencyP50Seconds"`
+	LatencyP95   float64         `json:"latencyP95Seconds"`
+	Buckets      []LatencyBucket `json:"buckets"`
+	LastCallTime time.Time       `json:"lastCallTime,omitempty"`
+}
+
+// clientStats accumulates metrics for one client
+type clientStats struct {
+	mu       sync.Mutex
+	calls    uint64
+	errors   uint64
+	timeouts uint64
+	retries  uint64
+	sum      float64
+	buckets  []uint64
+	samples  []float64
+	next     int
+	lastCall time.Time
+
+	// Status information shared with the status API
+	toolCount     int
+	toolsListed   bool
+	lastError     string
+	lastErrorTime time.Time
+	lastSuccess   time.Time
+}
+
+// record adds the outcome of a single call
+func (s *clientStats) record(duration time.Duration, err error) {
+	seconds := duration.Seconds()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	s.lastCall = time.Now()
+	if err != nil {
+		s.errors++
+		if isTimeoutError(err) {
+			s.timeouts++
+		}
+		s.lastError = err.Error()
+		s.lastErrorTime = s.lastCall
+	} else {
+		s.lastSuccess = s.lastCall
+	}
+
+	s.sum += seconds
+	if s.buckets == nil {
+		s.buckets = make([]uint64, len(latencyBuckets))
+	}
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			s.buckets[i]++
+		}
+	}
+
+	if len(s.samples) < latencySamples {
+		s.samples = append(s.samples, seconds)
+	} else {
+		s.samples[s.next] = seconds
+		s.next = (s.next + 1) % latencySamples
+	}
+}
+
+// recordRetry counts a call attempt repeated by the retry policy
+func (s *clientStats) recordRetry() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.retries++
+}
+
+// recordError remembers an error that did not come from a tool call, such as
+// a failed initialization or tool listing
+func (s *clientStats) recordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastError = err.Error()
+	s.lastErrorTime = time.Now()
+}
+
+// recordToolCount remembers how many tools the client exposed when last listed
+func (s *clientStats) recordToolCount(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.toolCount = count
+	s.toolsListed = true
+}
+
+// snapshot returns the current metrics
+func (s *clientStats) snapshot(name string) ClientStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := ClientStats{
+		Name:         name,
+		Calls:        s.calls,
+		Errors:       s.errors,
+		Timeouts:     s.timeouts,
+		Retries:      s.retries,
+		LatencySum:   s.sum,
+		Buckets:      make([]LatencyBucket, len(latencyBuckets)),
+		LastCallTime: s.lastCall,
+	}
+	for i, bound := range latencyBuckets {
+		stats.Buckets[i].UpperBound = bound
+		if s.buckets != nil {
+			stats.Buckets[i].Count = s.buckets[i]
+		}
+	}
+
+	if len(s.samples) > 0 {
+		sorted := append([]float64(nil), s.samples...)
+		sort.Float64s(sorted)
+		stats.LatencyP50 = percentile(sorted, 0.50)
+		stats.LatencyP95 = percentile(sorted, 0.95)
+	}
+
+	return stats
+}
+
+// percentile returns the p-th percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	idx := int(float64(len(sorted))*p+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// isTimeoutError reports whether err was caused by a deadline or network timeout
+func isTimeoutError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// statsFor returns the metrics accumulator for a client, creating it if needed
+func (g *Gateway) statsFor(name string) *clientStats {
+	g.statsMu.Lock()
+	defer g.statsMu.Unlock()
+
+	s, ok := g.stats[name]
+	if !ok {
+		s = &clientStats{}
+		g.stats[name] = s
+	}
+	return s
+}
+
+// Stats returns call metrics for every configured client, sorted by name
+func (g *Gateway) Stats() []ClientStats {
+	g.mu.RLock()
+	names := make([]string, 0, len(g.clients))
+	for name := range g.clients {
+		names = append(names, name)
+	}
+	g.mu.RUnlock()
+	sort.Strings(names)
+
+	result := make([]ClientStats, 0, len(names))
+	for _, name := range names {
+		result = append(result, g.statsFor(name).snapshot(name))
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package gateway
+
+import (
+	"sort"
+	"time"
+)
+
+// ClientStatus describes the state of one upstream MCP server
+type ClientStatus struct {
+	Name          string                 `json:"name"`
+	Transport     string                 `json:"transport"`
+	URL           string                 `json:"url"`
+	Tags          []string               `json:"tags,omitempty"`
+	Capabilities  map[string]interface{} `json:"capabilities,omitempty"` // Advertised at initialization
+	Initialized   bool                   `json:"initialized"`
+	ToolCount     *int                   `json:"toolCount"` // nil until tools have been listed
+	LastError     string                 `json:"lastError,omitempty"`
+	LastErrorTime *time.Time             `json:"lastErrorTime,omitempty"`
+	LastSuccess   *time.Time             `json:"lastSuccessfulCall,omitempty"`
+	Endpoints     []EndpointHealth       `json:"endpoints,omitempty"` // Replica health for replicated servers
+}
+
+// Status returns the state of every configured client, sorted by name
+func (g *Gateway) Status() []ClientStatus {
+	g.mu.RLock()
+	names := make([]string, 0, len(g.clients))
+	for name := range g.clients {
+		names = append(names, name)
+	}
+	g.mu.RUnlock()
+	sort.Strings(names)
+
+	result := make([]ClientStatus, 0, len(names))
+	for _, name := range names {
+		c, ok := g.GetClient(name)
+		if !ok {
+			continue
+		}
+		cfg := c.GetConfig()
+		status := ClientStatus{
+			Name:         name,
+			Transport:    cfg.TransportName(),
+			URL:          cfg.URL,
+			Tags:         cfg.Tags,
+			Initialized:  c.IsInitialized(),
+			Capabilities: c.Capabilities(),
+		}
+		if rs, ok := c.(*replicaSet); ok {
+			status.Endpoints = rs.health()
+		}
+
+		s := g.statsFor(name)
+		s.mu.Lock()
+		if s.toolsListed {
+			count := s.toolCount
+			status.ToolCount = &count
+		}
+		status.LastError = s.lastError
+		if !s.lastErrorTime.IsZero() {
+			t := s.lastErrorTime
+			status.LastErrorTime = &t
+		}
+		if !s.lastSuccess.IsZero() {
+			t := s.lastSuccess
+			status.LastSuccess = &t
+		}
+		s.mu.Unlock()
+
+		result = append(result, status)
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package gateway
+
+import (
+	"context"
+	"mcp-go/client"
+	"sort"
+	"strings"
+)
+
+type tagsKey struct{}
+
+// WithTags restricts the gateway calls made with the returned context to
+// clients carrying at least one of the given tags. Without tags every client
+// is used.
+func WithTags(ctx context.Context, tags ...string) context.Context {
+	var cleaned []string
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			cleaned = append(cleaned, tag)
+		}
+	}
+	if len(cleaned) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, tagsKey{}, cleaned)
+}
+
+// TagsFromContext returns the tags set with WithTags
+func TagsFromContext(ctx context.Context) []string {
+	tags, _ := ctx.Value(tagsKey{}).([]string)
+	return tags
+}
+
+// ClientsWithTags returns the sorted names of the clients carrying at least
+// one of the given tags, or of every client when no tag is given
+func (g *Gateway) ClientsWithTags(tags ...string) []string {
+	var names []string
+	for _, c := range g.clientsFor(WithTags(context.Background(), tags...)) {
+		names = append(names, c.GetName())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// clientsFor returns a snapshot of the clients visible to ctx
+func (g *Gateway) clientsFor(ctx context.Context) []client.Client {
+	clients := g.clientList()
+	tags := TagsFromContext(ctx)
+	if len(tags) == 0 {
+		return clients
+	}
+
+	filtered := clients[:0]
+	for _, c := range clients {
+		if hasAnyTag(c.GetConfig().Tags, tags) {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// hasAnyTag reports whether have and want share a tag
+func hasAnyTag(have, want []string) bool {
+	for _, h := range have {
+		for _, w := range want {
+			if h == w {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"mcp-go/config"
+	"mcp-go/jq"
+	"mcp-go/transport"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// transformFunc rewrites the text of one content item
+type transformFunc func(text string) string
+
+// ansiPattern matches ANSI CSI and OSC escape sequences
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)`)
+
+// compileTransforms turns transform configuration into functions, rejecting
+// unknown types and invalid parameters up front
+func compileTransforms(cfgs []config.TransformConfig) ([]transformFunc, error) {
+	var transforms []transformFunc
+	for i, cfg := range cfgs {
+		switch cfg.Type {
+		case "truncate":
+			if cfg.MaxBytes <= 0 {
+				return nil, fmt.Errorf("transform %d: truncate requires a positive maxBytes", i)
+			}
+			transforms = append(transforms, truncateTransform(cfg.MaxBytes))
+		case "strip_ansi":
+			transforms = append(transforms, func(text string) string {
+				return ansiPattern.ReplaceAllString(text, "")
+			})
+		case "json_field":
+			if cfg.Field == "" {
+				return nil, fmt.Errorf("transform %d: json_field requires a field", i)
+			}
+			transforms = append(transforms, jsonFieldTransform(cfg.Field))
+		case "jq":
+			q, err := jq.Compile(cfg.Expression)
+			if err != nil {
+				return nil, fmt.Errorf("transform %d: %w", i, err)
+			}
+			transforms = append(transforms, jqTransform(q))
+		default:
+			return nil, fmt.Errorf("transform %d: unknown type %q", i, cfg.Type)
+		}
+	}
+	return transforms, nil
+}
+
+// truncateTransform cuts text to maxBytes, keeping UTF-8 intact, and notes how
+// much was removed
+func truncateTransform(maxBytes int) transformFunc {
+	return func(text string) string {
+		if len(text) <= maxBytes {
+			return text
+		}
+		cut := maxBytes
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		return fmt.Sprintf("%s\n... [truncated %d bytes]", text[:cut], len(text)-cut)
+	}
+}
+
+// jsonFieldTransform replaces a JSON document with the value at a dotted path.
+// Text that is not JSON, or lacks the field, is returned unchanged.
+func jsonFieldTransform(field string) transformFunc {
+	path := strings.Split(field, ".")
+	return func(text string) string {
+		var value interface{}
+		if err := json.Unmarshal([]byte(text), &value); err != nil {
+			return text
+		}
+		for _, key := range path {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				next, ok := v[key]
+				if !ok {
+					return text
+				}
+				value = next
+			case []interface{}:
+				i, err := strconv.Atoi(key)
+				if err != nil || i < 0 || i >= len(v) {
+					return text
+				}
+				value = v[i]
+			default:
+				return text
+			}
+		}
+		return jq.Format([]interface{}{value})
+	}
+}
+
+// jqTransform replaces a JSON document with the output of a jq expression.
+// Text that is not JSON, or fails to evaluate, is returned unchanged.
+func jqTransform(q *jq.Query) transformFunc {
+	return func(text string) string {
+		out, err := q.RunJSON([]byte(text))
+		if err != nil {
+			return text
+		}
+		return jq.Format(out)
+	}
+}
+
+// applyTransforms runs the transforms over every text content item. The
+// response is copied so cached or shared responses are never modified.
+func applyTransforms(resp *transport.ToolResponse, transforms []transformFunc) *transport.ToolResponse {
+	if resp == nil || len(transforms) == 0 {
+		return resp
+	}
+	out := *resp
+	out.Content = make([]transport.ContentItem, len(resp.Content))
+	for i, item := range resp.Content {
+		if item.Type == "text" {
+			for _, transform := range transforms {
+				item.Text = transform(item.Text)
+			}
+		}
+		out.Content[i] = item
+	}
+	return &out
+}
@@ -0,0 +1,19 @@
+package gateway
+
+import (
+	"mcp-go/jsonschema"
+)
+
+// validateArguments checks the arguments of a call against the input schema
+// of the tool. Tools whose schema is not known yet, because the client has
+// not been listed, are passed through for the server to judge.
+func (g *Gateway) validateArguments(clientName, name string, arguments map[string]interface{}) error {
+	tool, ok := g.listedTool(clientName, name)
+	if !ok || tool.InputSchema == nil {
+		return nil
+	}
+	if arguments == nil {
+		arguments = make(map[string]interface{})
+	}
+	return jsonschema.Validate(tool.InputSchema, arguments)
+}
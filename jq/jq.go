@@ -0,0 +1,1185 @@
+// Package jq implements a small, dependency-free subset of the jq query
+// language for slicing JSON documents.
+//
+// Supported syntax:
+//
+//	.                     identity
+//	.foo .foo.bar ."a b"  object field access
+//	.[0] .[-1] .[1:3]     array index and slice
+//	.[] .foo[]            iterate array elements or object values
+//	.foo?                 suppress errors
+//	a | b                 pipe
+//	a, b                  multiple outputs
+//	[ ... ]  { a: ., b }  array and object construction
+//	== != < <= > >=       comparison
+//	and or not            boolean logic
+//	"str" 1 true null     literals
+//
+// and the functions keys, values, length, type, first, last, add, reverse,
+// sort, tostring, tonumber, to_entries, not, map(f), select(f), has(k) and
+// join(sep).
+//
+// CompileJSONPath compiles JSONPath expressions into the same Query type.
+package jq
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Query is a compiled jq expression
+type Query struct {
+	expr string
+	root node
+}
+
+// Compile parses a jq expression
+func Compile(expr string) (*Query, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+
+	root, err := p.parsePipe()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected token %q in jq expression", p.peek().text)
+	}
+
+	return &Query{expr: expr, root: root}, nil
+}
+
+// String returns the source expression
+func (q *Query) String() string {
+	return q.expr
+}
+
+// Run evaluates the query against a decoded JSON value (as produced by
+// encoding/json) and returns every output value
+func (q *Query) Run(input interface{}) ([]interface{}, error) {
+	return q.root.eval(input)
+}
+
+// RunJSON decodes data and evaluates the query against it
+func (q *Query) RunJSON(data []byte) ([]interface{}, error) {
+	var input interface{}
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, fmt.Errorf("invalid JSON input: %w", err)
+	}
+	return q.Run(input)
+}
+
+// Format renders query outputs the way `jq -r` prints them: strings raw,
+// everything else as compact JSON, one value per line
+func Format(values []interface{}) string {
+	lines := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			lines = append(lines, s)
+			continue
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			lines = append(lines, fmt.Sprint(v))
+			continue
+		}
+		lines = append(lines, string(data))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ---- tokenizer ----
+
+type tokenKind int
+
+const (
+	tokPunct tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokField // .name or ."name"
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"':
+			s, n, err := readString(runes[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokString, text: s})
+			i += n
+		case r == '.' && i+1 < len(runes) && runes[i+1] == '"':
+			s, n, err := readString(runes[i+1:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokField, text: s})
+			i += n + 1
+		case r == '.' && i+1 < len(runes) && isIdentStart(runes[i+1]):
+			j := i + 1
+			for j < len(runes) && isIdentPart(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokField, text: string(runes[i+1 : j])})
+			i = j
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.' || runes[j] == 'e' || runes[j] == 'E') {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(runes[i:j])})
+			i = j
+		case isIdentStart(r):
+			j := i
+			for j < len(runes) && isIdentPart(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: string(runes[i:j])})
+			i = j
+		default:
+			if i+1 < len(runes) {
+				two := string(runes[i : i+2])
+				switch two {
+				case "==", "!=", "<=", ">=":
+					tokens = append(tokens, token{kind: tokPunct, text: two})
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune(".|,[]{}():?<>-", r) {
+				return nil, fmt.Errorf("unexpected character %q in jq expression", r)
+			}
+			tokens = append(tokens, token{kind: tokPunct, text: string(r)})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// readString reads a double-quoted JSON string starting at runes[0] and
+// returns its value and the number of runes consumed
+func readString(runes []rune) (string, int, error) {
+	for j := 1; j < len(runes); j++ {
+		if runes[j] == '\\' {
+			j++
+			continue
+		}
+		if runes[j] == '"' {
+			var s string
+			if err := json.Unmarshal([]byte(string(runes[:j+1])), &s); err != nil {
+				return "", 0, fmt.Errorf("invalid string literal: %w", err)
+			}
+			return s, j + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string literal")
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || unicode.IsDigit(r)
+}
+
+// ---- parser ----
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *parser) isPunct(text string) bool {
+	return !p.done() && p.tokens[p.pos].kind == tokPunct && p.tokens[p.pos].text == text
+}
+
+func (p *parser) isIdent(text string) bool {
+	return !p.done() && p.tokens[p.pos].kind == tokIdent && p.tokens[p.pos].text == text
+}
+
+func (p *parser) expect(text string) error {
+	if !p.isPunct(text) {
+		if p.done() {
+			return fmt.Errorf("expected %q at end of jq expression", text)
+		}
+		return fmt.Errorf("expected %q, got %q in jq expression", text, p.peek().text)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) parsePipe() (node, error) {
+	left, err := p.parseComma()
+	if err != nil {
+		return nil, err
+	}
+	for p.isPunct("|") {
+		p.pos++
+		right, err := p.parseComma()
+		if err != nil {
+			return nil, err
+		}
+		left = pipeNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseComma() (node, error) {
+	left, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	for p.isPunct(",") {
+		p.pos++
+		right, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		left = commaNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isIdent("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logicNode{op: "or", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.isIdent("and") {
+		p.pos++
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = logicNode{op: "and", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.isPunct(op) {
+			p.pos++
+			right, err := p.parsePostfix()
+			if err != nil {
+				return nil, err
+			}
+			return compareNode{op: op, left: left, right: right}, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	n, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for !p.done() {
+		tok := p.peek()
+		switch {
+		case tok.kind == tokField:
+			p.pos++
+			n = pipeNode{n, fieldNode{tok.text}}
+		case p.isPunct("."):
+			// ".[" continues a path, e.g. .foo.[0]
+			if p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text == "[" {
+				p.pos++
+				continue
+			}
+			return n, nil
+		case p.isPunct("["):
+			suffix, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			n = pipeNode{n, suffix}
+		case p.isPunct("?"):
+			p.pos++
+			n = tryNode{n}
+		default:
+			return n, nil
+		}
+	}
+	return n, nil
+}
+
+// parseBracket parses [], [n], [a:b] or ["key"] following a value
+func (p *parser) parseBracket() (node, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	if p.isPunct("]") {
+		p.pos++
+		return iterateNode{}, nil
+	}
+
+	var from, to node
+	var err error
+	if !p.isPunct(":") {
+		from, err = p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.isPunct(":") {
+		p.pos++
+		if !p.isPunct("]") {
+			to, err = p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return sliceNode{from: from, to: to}, nil
+	}
+	if err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return indexNode{from}, nil
+}
+
+func (p *parser) parseTerm() (node, error) {
+	if p.done() {
+		return nil, fmt.Errorf("unexpected end of jq expression")
+	}
+	tok := p.peek()
+
+	switch tok.kind {
+	case tokField:
+		p.pos++
+		return fieldNode{tok.text}, nil
+	case tokString:
+		p.pos++
+		return literalNode{tok.text}, nil
+	case tokNumber:
+		p.pos++
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", tok.text)
+		}
+		return literalNode{f}, nil
+	case tokIdent:
+		p.pos++
+		switch tok.text {
+		case "true":
+			return literalNode{true}, nil
+		case "false":
+			return literalNode{false}, nil
+		case "null":
+			return literalNode{nil}, nil
+		}
+		var arg node
+		if p.isPunct("(") {
+			p.pos++
+			var err error
+			arg, err = p.parsePipe()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+		}
+		return newFuncNode(tok.text, arg)
+	}
+
+	switch tok.text {
+	case ".":
+		p.pos++
+		if p.isPunct("[") {
+			return p.parseBracket()
+		}
+		return identityNode{}, nil
+	case "-":
+		p.pos++
+		if p.done() || p.peek().kind != tokNumber {
+			return nil, fmt.Errorf("expected number after '-'")
+		}
+		n, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		return literalNode{-n.(literalNode).value.(float64)}, nil
+	case "(":
+		p.pos++
+		n, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case "[":
+		p.pos++
+		if p.isPunct("]") {
+			p.pos++
+			return arrayNode{}, nil
+		}
+		n, err := p.parsePipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return arrayNode{n}, nil
+	case "{":
+		p.pos++
+		return p.parseObject()
+	}
+
+	return nil, fmt.Errorf("unexpected token %q in jq expression", tok.text)
+}
+
+// parseObject parses the body of an object construction after "{"
+func (p *parser) parseObject() (node, error) {
+	obj := objectNode{}
+	for !p.isPunct("}") {
+		if p.done() {
+			return nil, fmt.Errorf("unterminated object construction")
+		}
+		tok := p.peek()
+		if tok.kind != tokIdent && tok.kind != tokString {
+			return nil, fmt.Errorf("expected object key, got %q", tok.text)
+		}
+		p.pos++
+		key := tok.text
+
+		var value node = fieldNode{key}
+		if p.isPunct(":") {
+			p.pos++
+			var err error
+			value, err = p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+		}
+		obj.keys = append(obj.keys, key)
+		obj.values = append(obj.values, value)
+
+		if p.isPunct(",") {
+			p.pos++
+		} else if !p.isPunct("}") {
+			return nil, fmt.Errorf("expected ',' or '}' in object construction")
+		}
+	}
+	p.pos++
+	return obj, nil
+}
+
+// ---- evaluation ----
+
+type node interface {
+	eval(input interface{}) ([]interface{}, error)
+}
+
+type identityNode struct{}
+
+func (identityNode) eval(input interface{}) ([]interface{}, error) {
+	return []interface{}{input}, nil
+}
+
+type literalNode struct{ value interface{} }
+
+func (n literalNode) eval(interface{}) ([]interface{}, error) {
+	return []interface{}{n.value}, nil
+}
+
+type fieldNode struct{ name string }
+
+func (n fieldNode) eval(input interface{}) ([]interface{}, error) {
+	switch v := input.(type) {
+	case nil:
+		return []interface{}{nil}, nil
+	case map[string]interface{}:
+		return []interface{}{v[n.name]}, nil
+	}
+	return nil, fmt.Errorf("cannot index %s with %q", typeName(input), n.name)
+}
+
+type indexNode struct{ index node }
+
+func (n indexNode) eval(input interface{}) ([]interface{}, error) {
+	keys, err := n.index.eval(input)
+	if err != nil {
+		return nil, err
+	}
+	var out []interface{}
+	for _, key := range keys {
+		switch k := key.(type) {
+		case string:
+			vals, err := fieldNode{k}.eval(input)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, vals...)
+		case float64:
+			switch v := input.(type) {
+			case nil:
+				out = append(out, nil)
+			case []interface{}:
+				i := int(k)
+				if i < 0 {
+					i += len(v)
+				}
+				if i < 0 || i >= len(v) {
+					out = append(out, nil)
+				} else {
+					out = append(out, v[i])
+				}
+			default:
+				return nil, fmt.Errorf("cannot index %s with number", typeName(input))
+			}
+		default:
+			return nil, fmt.Errorf("cannot index with %s", typeName(key))
+		}
+	}
+	return out, nil
+}
+
+type sliceNode struct{ from, to node }
+
+func (n sliceNode) eval(input interface{}) ([]interface{}, error) {
+	var length int
+	switch v := input.(type) {
+	case nil:
+		return []interface{}{nil}, nil
+	case []interface{}:
+		length = len(v)
+	case string:
+		length = len([]rune(v))
+	default:
+		return nil, fmt.Errorf("cannot slice %s", typeName(input))
+	}
+
+	bound := func(b node, def int) (int, error) {
+		if b == nil {
+			return def, nil
+		}
+		vals, err := b.eval(input)
+		if err != nil {
+			return 0, err
+		}
+		if len(vals) != 1 {
+			return 0, fmt.Errorf("slice bound must produce a single value")
+		}
+		f, ok := vals[0].(float64)
+		if !ok {
+			return 0, fmt.Errorf("slice bound must be a number")
+		}
+		i := int(f)
+		if i < 0 {
+			i += length
+		}
+		if i < 0 {
+			i = 0
+		}
+		if i > length {
+			i = length
+		}
+		return i, nil
+	}
+
+	from, err := bound(n.from, 0)
+	if err != nil {
+		return nil, err
+	}
+	to, err := bound(n.to, length)
+	if err != nil {
+		return nil, err
+	}
+	if to < from {
+		to = from
+	}
+
+	if s, ok := input.(string); ok {
+		return []interface{}{string([]rune(s)[from:to])}, nil
+	}
+	return []interface{}{append([]interface{}(nil), input.([]interface{})[from:to]...)}, nil
+}
+
+type iterateNode struct{}
+
+func (iterateNode) eval(input interface{}) ([]interface{}, error) {
+	switch v := input.(type) {
+	case []interface{}:
+		return append([]interface{}(nil), v...), nil
+	case map[string]interface{}:
+		keys := sortedKeys(v)
+		out := make([]interface{}, 0, len(keys))
+		for _, k := range keys {
+			out = append(out, v[k])
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("cannot iterate over %s", typeName(input))
+}
+
+type tryNode struct{ inner node }
+
+func (n tryNode) eval(input interface{}) ([]interface{}, error) {
+	out, err := n.inner.eval(input)
+	if err != nil {
+		return nil, nil
+	}
+	return out, nil
+}
+
+type pipeNode struct{ left, right node }
+
+func (n pipeNode) eval(input interface{}) ([]interface{}, error) {
+	lefts, err := n.left.eval(input)
+	if err != nil {
+		return nil, err
+	}
+	var out []interface{}
+	for _, v := range lefts {
+		rights, err := n.right.eval(v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rights...)
+	}
+	return out, nil
+}
+
+type commaNode struct{ left, right node }
+
+func (n commaNode) eval(input interface{}) ([]interface{}, error) {
+	lefts, err := n.left.eval(input)
+	if err != nil {
+		return nil, err
+	}
+	rights, err := n.right.eval(input)
+	if err != nil {
+		return nil, err
+	}
+	return append(lefts, rights...), nil
+}
+
+type arrayNode struct{ inner node }
+
+func (n arrayNode) eval(input interface{}) ([]interface{}, error) {
+	if n.inner == nil {
+		return []interface{}{[]interface{}{}}, nil
+	}
+	items, err := n.inner.eval(input)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []interface{}{}
+	}
+	return []interface{}{items}, nil
+}
+
+type objectNode struct {
+	keys   []string
+	values []node
+}
+
+func (n objectNode) eval(input interface{}) ([]interface{}, error) {
+	obj := make(map[string]interface{}, len(n.keys))
+	for i, key := range n.keys {
+		vals, err := n.values[i].eval(input)
+		if err != nil {
+			return nil, err
+		}
+		if len(vals) == 0 {
+			return nil, nil
+		}
+		obj[key] = vals[0]
+	}
+	return []interface{}{obj}, nil
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n compareNode) eval(input interface{}) ([]interface{}, error) {
+	lefts, err := n.left.eval(input)
+	if err != nil {
+		return nil, err
+	}
+	rights, err := n.right.eval(input)
+	if err != nil {
+		return nil, err
+	}
+	var out []interface{}
+	for _, r := range rights {
+		for _, l := range lefts {
+			c := compare(l, r)
+			var result bool
+			switch n.op {
+			case "==":
+				result = c == 0
+			case "!=":
+				result = c != 0
+			case "<":
+				result = c < 0
+			case "<=":
+				result = c <= 0
+			case ">":
+				result = c > 0
+			case ">=":
+				result = c >= 0
+			}
+			out = append(out, result)
+		}
+	}
+	return out, nil
+}
+
+type logicNode struct {
+	op          string
+	left, right node
+}
+
+func (n logicNode) eval(input interface{}) ([]interface{}, error) {
+	lefts, err := n.left.eval(input)
+	if err != nil {
+		return nil, err
+	}
+	var out []interface{}
+	for _, l := range lefts {
+		if n.op == "and" && !truthy(l) {
+			out = append(out, false)
+			continue
+		}
+		if n.op == "or" && truthy(l) {
+			out = append(out, true)
+			continue
+		}
+		rights, err := n.right.eval(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rights {
+			out = append(out, truthy(r))
+		}
+	}
+	return out, nil
+}
+
+type funcNode struct {
+	name string
+	arg  node
+}
+
+// functionArity lists the supported functions and whether they take an argument
+var functionArity = map[string]bool{
+	"keys": false, "values": false, "length": false, "type": false,
+	"first": false, "last": false, "add": false, "reverse": false,
+	"sort": false, "tostring": false, "tonumber": false, "to_entries": false,
+	"not": false, "empty": false,
+	"map": true, "select": true, "has": true, "join": true,
+}
+
+func newFuncNode(name string, arg node) (node, error) {
+	takesArg, ok := functionArity[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown jq function %q", name)
+	}
+	if takesArg && arg == nil {
+		return nil, fmt.Errorf("jq function %s requires an argument", name)
+	}
+	if !takesArg && arg != nil {
+		return nil, fmt.Errorf("jq function %s takes no arguments", name)
+	}
+	return funcNode{name: name, arg: arg}, nil
+}
+
+func (n funcNode) eval(input interface{}) ([]interface{}, error) {
+	one := func(v interface{}) ([]interface{}, error) { return []interface{}{v}, nil }
+
+	switch n.name {
+	case "empty":
+		return nil, nil
+	case "not":
+		return one(!truthy(input))
+	case "type":
+		return one(typeName(input))
+	case "length":
+		switch v := input.(type) {
+		case nil:
+			return one(0.0)
+		case string:
+			return one(float64(len([]rune(v))))
+		case []interface{}:
+			return one(float64(len(v)))
+		case map[string]interface{}:
+			return one(float64(len(v)))
+		case float64:
+			if v < 0 {
+				v = -v
+			}
+			return one(v)
+		}
+	case "keys":
+		switch v := input.(type) {
+		case map[string]interface{}:
+			keys := sortedKeys(v)
+			out := make([]interface{}, len(keys))
+			for i, k := range keys {
+				out[i] = k
+			}
+			return one(out)
+		case []interface{}:
+			out := make([]interface{}, len(v))
+			for i := range v {
+				out[i] = float64(i)
+			}
+			return one(out)
+		}
+	case "values":
+		vals, err := iterateNode{}.eval(input)
+		if err != nil {
+			return nil, err
+		}
+		return one(vals)
+	case "first", "last":
+		arr, ok := input.([]interface{})
+		if !ok {
+			break
+		}
+		if len(arr) == 0 {
+			return one(nil)
+		}
+		if n.name == "first" {
+			return one(arr[0])
+		}
+		return one(arr[len(arr)-1])
+	case "reverse":
+		arr, ok := input.([]interface{})
+		if !ok {
+			break
+		}
+		out := make([]interface{}, len(arr))
+		for i, v := range arr {
+			out[len(arr)-1-i] = v
+		}
+		return one(out)
+	case "sort":
+		arr, ok := input.([]interface{})
+		if !ok {
+			break
+		}
+		out := append([]interface{}(nil), arr...)
+		sort.SliceStable(out, func(i, j int) bool { return compare(out[i], out[j]) < 0 })
+		return one(out)
+	case "add":
+		arr, ok := input.([]interface{})
+		if !ok {
+			break
+		}
+		return add(arr)
+	case "tostring":
+		if s, ok := input.(string); ok {
+			return one(s)
+		}
+		data, err := json.Marshal(input)
+		if err != nil {
+			return nil, err
+		}
+		return one(string(data))
+	case "tonumber":
+		switch v := input.(type) {
+		case float64:
+			return one(v)
+		case string:
+			f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse %q as number", v)
+			}
+			return one(f)
+		}
+	case "to_entries":
+		obj, ok := input.(map[string]interface{})
+		if !ok {
+			break
+		}
+		out := make([]interface{}, 0, len(obj))
+		for _, k := range sortedKeys(obj) {
+			out = append(out, map[string]interface{}{"key": k, "value": obj[k]})
+		}
+		return one(out)
+	case "map":
+		items, err := iterateNode{}.eval(input)
+		if err != nil {
+			return nil, err
+		}
+		out := []interface{}{}
+		for _, item := range items {
+			vals, err := n.arg.eval(item)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, vals...)
+		}
+		return one(out)
+	case "select":
+		conds, err := n.arg.eval(input)
+		if err != nil {
+			return nil, err
+		}
+		var out []interface{}
+		for _, c := range conds {
+			if truthy(c) {
+				out = append(out, input)
+			}
+		}
+		return out, nil
+	case "has":
+		keys, err := n.arg.eval(input)
+		if err != nil {
+			return nil, err
+		}
+		var out []interface{}
+		for _, key := range keys {
+			switch k := key.(type) {
+			case string:
+				obj, ok := input.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("cannot check whether %s has a string key", typeName(input))
+				}
+				_, exists := obj[k]
+				out = append(out, exists)
+			case float64:
+				arr, ok := input.([]interface{})
+				if !ok {
+					return nil, fmt.Errorf("cannot check whether %s has a number key", typeName(input))
+				}
+				out = append(out, k >= 0 && int(k) < len(arr))
+			default:
+				return nil, fmt.Errorf("has() key must be a string or number")
+			}
+		}
+		return out, nil
+	case "join":
+		arr, ok := input.([]interface{})
+		if !ok {
+			break
+		}
+		seps, err := n.arg.eval(input)
+		if err != nil {
+			return nil, err
+		}
+		var out []interface{}
+		for _, sep := range seps {
+			s, ok := sep.(string)
+			if !ok {
+				return nil, fmt.Errorf("join separator must be a string")
+			}
+			parts := make([]string, len(arr))
+			for i, v := range arr {
+				switch x := v.(type) {
+				case nil:
+				case string:
+					parts[i] = x
+				default:
+					parts[i] = Format([]interface{}{x})
+				}
+			}
+			out = append(out, strings.Join(parts, s))
+		}
+		return out, nil
+	}
+
+	return nil, fmt.Errorf("%s cannot be applied to %s", n.name, typeName(input))
+}
+
+// add sums numbers, concatenates strings and arrays, or merges objects
+func add(items []interface{}) ([]interface{}, error) {
+	var acc interface{}
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		if acc == nil {
+			acc = item
+			continue
+		}
+		switch a := acc.(type) {
+		case float64:
+			b, ok := item.(float64)
+			if !ok {
+				return nil, fmt.Errorf("cannot add %s to number", typeName(item))
+			}
+			acc = a + b
+		case string:
+			b, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("cannot add %s to string", typeName(item))
+			}
+			acc = a + b
+		case []interface{}:
+			b, ok := item.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot add %s to array", typeName(item))
+			}
+			acc = append(append([]interface{}(nil), a...), b...)
+		case map[string]interface{}:
+			b, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot add %s to object", typeName(item))
+			}
+			merged := make(map[string]interface{}, len(a)+len(b))
+			for k, v := range a {
+				merged[k] = v
+			}
+			for k, v := range b {
+				merged[k] = v
+			}
+			acc = merged
+		default:
+			return nil, fmt.Errorf("cannot add %s values", typeName(acc))
+		}
+	}
+	return []interface{}{acc}, nil
+}
+
+// typeName returns the jq type name of a decoded JSON value
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// truthy reports whether a value counts as true (everything but false and null)
+func truthy(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+	if b, ok := v.(bool); ok {
+		return b
+	}
+	return true
+}
+
+// typeOrder implements jq's ordering of values of different types
+var typeOrder = map[string]int{
+	"null": 0, "boolean": 1, "number": 2, "string": 3, "array": 4, "object": 5,
+}
+
+// compare orders two decoded JSON values
+func compare(a, b interface{}) int {
+	ta, tb := typeName(a), typeName(b)
+	if ta != tb {
+		return typeOrder[ta] - typeOrder[tb]
+	}
+	switch x := a.(type) {
+	case nil:
+		return 0
+	case bool:
+		y := b.(bool)
+		if x == y {
+			return 0
+		}
+		if !x {
+			return -1
+		}
+		return 1
+	case float64:
+		y := b.(float64)
+		if x < y {
+			return -1
+		}
+		if x > y {
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(x, b.(string))
+	case []interface{}:
+		y := b.([]interface{})
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if c := compare(x[i], y[i]); c != 0 {
+				return c
+			}
+		}
+		return len(x) - len(y)
+	}
+	if reflect.DeepEqual(a, b) {
+		return 0
+	}
+	return strings.Compare(Format([]interface{}{a}), Format([]interface{}{b}))
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,74 @@
+package jq
+
+import "testing"
+
+const doc = `{
+	"name": "gateway",
+	"tags": ["a", "b", "c"],
+	"servers": [
+		{"name": "fs", "tools": 5, "enabled": true},
+		{"name": "cf", "tools": 12, "enabled": false}
+	],
+	"nested": {"a b": {"c": 1}}
+}`
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{".", `{"name":"gateway","nested":{"a b":{"c":1}},"servers":[{"enabled":true,"name":"fs","tools":5},{"enabled":false,"name":"cf","tools":12}],"tags":["a","b","c"]}`},
+		{".name", "gateway"},
+		{`.nested."a b".c`, "1"},
+		{`.["name"]`, "gateway"},
+		{".tags[0]", "a"},
+		{".tags[-1]", "c"},
+		{".tags[1:]", `["b","c"]`},
+		{".tags[]", "a\nb\nc"},
+		{".servers[].name", "fs\ncf"},
+		{".servers[] | select(.tools > 10) | .name", "cf"},
+		{".servers | map(.tools) | add", "17"},
+		{"[.servers[] | select(.enabled) | .name]", `["fs"]`},
+		{".servers[0] | {name, count: .tools}", `{"count":5,"name":"fs"}`},
+		{".tags | length", "3"},
+		{".tags | join(\"-\")", "a-b-c"},
+		{"keys", `["name","nested","servers","tags"]`},
+		{".name, .tags[0]", "gateway\na"},
+		{".missing.deeper", "null"},
+		{".name.foo?", ""},
+		{".servers[0].enabled and .servers[1].enabled", "false"},
+		{".servers | first | has(\"name\")", "true"},
+	}
+
+	for _, tt := range tests {
+		q, err := Compile(tt.expr)
+		if err != nil {
+			t.Errorf("Compile(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		out, err := q.RunJSON([]byte(doc))
+		if err != nil {
+			t.Errorf("Run(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if got := Format(out); got != tt.want {
+			t.Errorf("Run(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	for _, expr := range []string{".[", "unknown_fn", ".foo |", "{a:", `"open`} {
+		if _, err := Compile(expr); err == nil {
+			t.Errorf("Expected Compile(%q) to fail", expr)
+		}
+	}
+
+	q, err := Compile(".name.foo")
+	if err != nil {
+		t.Fatalf("Compile failed: %v", err)
+	}
+	if _, err := q.RunJSON([]byte(doc)); err == nil {
+		t.Error("Expected error indexing a string")
+	}
+}
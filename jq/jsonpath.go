@@ -0,0 +1,439 @@
+package jq
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// CompileJSONPath parses a JSONPath expression into a Query. Supported
+// syntax:
+//
+//	$                          root
+//	.name ['name'] ["a b"]     member
+//	[0] [-1] [0,2] [1:5:2]     index, union and slice
+//	.* [*]                     every member or element
+//	..name ..* ..[0]           recursive descent
+//	[?(@.price < 10)]          filter, with a jq condition on @
+//
+// Filters take the comparisons and boolean logic of jq, with && and || as
+// aliases of and and or. Unlike jq, a JSONPath query yields nothing rather
+// than null or an error for members and indexes that do not exist.
+func CompileJSONPath(expr string) (*Query, error) {
+	p := &pathParser{src: []rune(strings.TrimSpace(expr))}
+	if !p.consume('$') {
+		return nil, fmt.Errorf("JSONPath expression must start with $")
+	}
+	var segments []pathSegment
+	for p.skipSpace(); !p.done(); p.skipSpace() {
+		segment, err := p.segment()
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+	return &Query{expr: expr, root: pathNode{segments}}, nil
+}
+
+// ---- JSONPath parser ----
+
+type pathParser struct {
+	src []rune
+	pos int
+}
+
+func (p *pathParser) done() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *pathParser) peek() rune {
+	if p.done() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *pathParser) consume(r rune) bool {
+	if p.peek() == r && !p.done() {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *pathParser) skipSpace() {
+	for !p.done() && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// segment parses .name, .*, [selectors] or their recursive .. forms
+func (p *pathParser) segment() (pathSegment, error) {
+	var segment pathSegment
+	switch {
+	case p.consume('['):
+		selectors, err := p.selectors()
+		segment.selectors = selectors
+		return segment, err
+	case p.consume('.'):
+		if p.consume('.') {
+			segment.recursive = true
+			if p.consume('[') {
+				selectors, err := p.selectors()
+				segment.selectors = selectors
+				return segment, err
+			}
+		}
+		if p.consume('*') {
+			segment.selectors = []pathSelector{wildcardSelector{}}
+			return segment, nil
+		}
+		start := p.pos
+		for !p.done() && (isIdentPart(p.peek()) || p.peek() == '-' || p.peek() > unicode.MaxASCII) {
+			p.pos++
+		}
+		if p.pos == start {
+			return segment, fmt.Errorf("expected a member name at offset %d of the JSONPath expression", start)
+		}
+		segment.selectors = []pathSelector{nameSelector{string(p.src[start:p.pos])}}
+		return segment, nil
+	}
+	return segment, fmt.Errorf("unexpected character %q in JSONPath expression", p.peek())
+}
+
+// selectors parses the comma-separated selectors of a bracket, after the
+// opening bracket
+func (p *pathParser) selectors() ([]pathSelector, error) {
+	var selectors []pathSelector
+	for {
+		p.skipSpace()
+		selector, err := p.selector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+		p.skipSpace()
+		if p.consume(']') {
+			return selectors, nil
+		}
+		if !p.consume(',') {
+			return nil, fmt.Errorf("expected , or ] at offset %d of the JSONPath expression", p.pos)
+		}
+	}
+}
+
+// selector parses a name, index, slice, wildcard or filter selector
+func (p *pathParser) selector() (pathSelector, error) {
+	switch r := p.peek(); {
+	case r == '*':
+		p.pos++
+		return wildcardSelector{}, nil
+	case r == '\'' || r == '"':
+		name, err := p.quoted()
+		return nameSelector{name}, err
+	case r == '?':
+		p.pos++
+		return p.filter()
+	case r == '-' || r == ':' || unicode.IsDigit(r):
+		return p.indexOrSlice()
+	}
+	return nil, fmt.Errorf("unexpected character %q in JSONPath selector", p.peek())
+}
+
+// quoted parses a single- or double-quoted string
+func (p *pathParser) quoted() (string, error) {
+	quote := p.src[p.pos]
+	var s strings.Builder
+	for p.pos++; !p.done(); p.pos++ {
+		r := p.src[p.pos]
+		switch {
+		case r == quote:
+			p.pos++
+			return s.String(), nil
+		case r == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			switch e := p.src[p.pos]; e {
+			case 'n':
+				s.WriteRune('\n')
+			case 't':
+				s.WriteRune('\t')
+			default:
+				s.WriteRune(e)
+			}
+		default:
+			s.WriteRune(r)
+		}
+	}
+	return "", fmt.Errorf("unterminated string in JSONPath expression")
+}
+
+// indexOrSlice parses an index such as -1 or a slice such as 1:5:2
+func (p *pathParser) indexOrSlice() (pathSelector, error) {
+	var parts [3]*int
+	count := 0
+	for ; count < 3; count++ {
+		p.skipSpace()
+		start := p.pos
+		if p.consume('-') || unicode.IsDigit(p.peek()) {
+			for unicode.IsDigit(p.peek()) {
+				p.pos++
+			}
+			n, err := strconv.Atoi(string(p.src[start:p.pos]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q in JSONPath expression", string(p.src[start:p.pos]))
+			}
+			parts[count] = &n
+		}
+		p.skipSpace()
+		if !p.consume(':') {
+			break
+		}
+	}
+	switch {
+	case count == 0 && parts[0] != nil:
+		return indexSelector{*parts[0]}, nil
+	case count == 0:
+		return nil, fmt.Errorf("expected an index at offset %d of the JSONPath expression", p.pos)
+	case count == 3:
+		return nil, fmt.Errorf("a slice takes at most start:end:step")
+	}
+	step := 1
+	if parts[2] != nil {
+		step = *parts[2]
+	}
+	return sliceSelector{start: parts[0], end: parts[1], step: step}, nil
+}
+
+// filter parses ?(condition) or ?condition, compiling the condition as jq
+func (p *pathParser) filter() (pathSelector, error) {
+	p.skipSpace()
+	start, depth := p.pos, 0
+	for ; !p.done(); p.pos++ {
+		r := p.src[p.pos]
+		if r == '\'' || r == '"' {
+			if _, err := p.quoted(); err != nil {
+				return nil, err
+			}
+			p.pos--
+			continue
+		}
+		if r == '(' || r == '[' {
+			depth++
+		} else if r == ')' || r == ']' {
+			if depth == 0 {
+				break
+			}
+			depth--
+		} else if r == ',' && depth == 0 {
+			break
+		}
+	}
+	condition := strings.TrimSpace(string(p.src[start:p.pos]))
+	translated, err := translateFilter(condition)
+	if err != nil {
+		return nil, err
+	}
+	query, err := Compile(translated)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSONPath filter %q: %w", condition, err)
+	}
+	return filterSelector{query.root}, nil
+}
+
+// translateFilter rewrites a JSONPath filter condition as jq: @ becomes .,
+// && and || become and and or, and single-quoted strings become JSON
+// strings
+func translateFilter(condition string) (string, error) {
+	src := []rune(condition)
+	var out strings.Builder
+	for i := 0; i < len(src); i++ {
+		r := src[i]
+		next := rune(0)
+		if i+1 < len(src) {
+			next = src[i+1]
+		}
+		switch {
+		case r == '\'' || r == '"':
+			p := &pathParser{src: src, pos: i}
+			s, err := p.quoted()
+			if err != nil {
+				return "", err
+			}
+			quoted, _ := json.Marshal(s)
+			out.Write(quoted)
+			i = p.pos - 1
+		case r == '@':
+			if next != '.' {
+				out.WriteRune('.')
+			}
+		case r == '$':
+			return "", fmt.Errorf("JSONPath filters cannot refer to the root $")
+		case r == '&' && next == '&':
+			out.WriteString(" and ")
+			i++
+		case r == '|' && next == '|':
+			out.WriteString(" or ")
+			i++
+		case r == '!' && next != '=':
+			return "", fmt.Errorf("JSONPath filters do not support !; compare with == false or use not")
+		default:
+			out.WriteRune(r)
+		}
+	}
+	if strings.TrimSpace(out.String()) == "" {
+		return "", fmt.Errorf("empty JSONPath filter")
+	}
+	return out.String(), nil
+}
+
+// ---- JSONPath evaluation ----
+
+// pathNode applies the segments of a JSONPath query in turn
+type pathNode struct{ segments []pathSegment }
+
+func (n pathNode) eval(input interface{}) ([]interface{}, error) {
+	values := []interface{}{input}
+	for _, segment := range n.segments {
+		var next []interface{}
+		for _, value := range values {
+			targets := []interface{}{value}
+			if segment.recursive {
+				targets = descendants(value, nil)
+			}
+			for _, target := range targets {
+				for _, selector := range segment.selectors {
+					next = selector.selectFrom(target, next)
+				}
+			}
+		}
+		values = next
+	}
+	if values == nil {
+		values = []interface{}{}
+	}
+	return values, nil
+}
+
+// pathSegment is one step of a JSONPath query
+type pathSegment struct {
+	recursive bool // Apply the selectors to the value and all its descendants
+	selectors []pathSelector
+}
+
+// descendants appends value and every value nested in it, depth first
+func descendants(value interface{}, out []interface{}) []interface{} {
+	out = append(out, value)
+	for _, child := range children(value) {
+		out = descendants(child, out)
+	}
+	return out
+}
+
+// children returns the elements of an array or the values of an object in
+// key order
+func children(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		keys := sortedKeys(v)
+		out := make([]interface{}, len(keys))
+		for i, k := range keys {
+			out[i] = v[k]
+		}
+		return out
+	}
+	return nil
+}
+
+// pathSelector appends the values it selects from a value to out
+type pathSelector interface {
+	selectFrom(value interface{}, out []interface{}) []interface{}
+}
+
+type nameSelector struct{ name string }
+
+func (s nameSelector) selectFrom(value interface{}, out []interface{}) []interface{} {
+	if m, ok := value.(map[string]interface{}); ok {
+		if v, ok := m[s.name]; ok {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+type wildcardSelector struct{}
+
+func (wildcardSelector) selectFrom(value interface{}, out []interface{}) []interface{} {
+	return append(out, children(value)...)
+}
+
+type indexSelector struct{ index int }
+
+func (s indexSelector) selectFrom(value interface{}, out []interface{}) []interface{} {
+	if a, ok := value.([]interface{}); ok {
+		i := s.index
+		if i < 0 {
+			i += len(a)
+		}
+		if i >= 0 && i < len(a) {
+			out = append(out, a[i])
+		}
+	}
+	return out
+}
+
+type sliceSelector struct {
+	start, end *int
+	step       int
+}
+
+func (s sliceSelector) selectFrom(value interface{}, out []interface{}) []interface{} {
+	a, ok := value.([]interface{})
+	if !ok || s.step == 0 {
+		return out
+	}
+	length := len(a)
+	bound := func(b *int, def int) int {
+		if b == nil {
+			return def
+		}
+		i := *b
+		if i < 0 {
+			i += length
+		}
+		return i
+	}
+	if s.step > 0 {
+		start, end := max(bound(s.start, 0), 0), min(bound(s.end, length), length)
+		for i := start; i < end; i += s.step {
+			out = append(out, a[i])
+		}
+	} else {
+		start, end := min(bound(s.start, length-1), length-1), max(bound(s.end, -length-1), -1)
+		for i := start; i > end; i += s.step {
+			out = append(out, a[i])
+		}
+	}
+	return out
+}
+
+type filterSelector struct{ condition node }
+
+func (s filterSelector) selectFrom(value interface{}, out []interface{}) []interface{} {
+	for _, child := range children(value) {
+		results, err := s.condition.eval(child)
+		if err != nil {
+			continue // A condition that does not apply does not match
+		}
+		for _, result := range results {
+			if truthy(result) {
+				out = append(out, child)
+				break
+			}
+		}
+	}
+	return out
+}
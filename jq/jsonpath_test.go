@@ -0,0 +1,55 @@
+package jq
+
+import "testing"
+
+func TestJSONPath(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"$", `{"name":"gateway","nested":{"a b":{"c":1}},"servers":[{"enabled":true,"name":"fs","tools":5},{"enabled":false,"name":"cf","tools":12}],"tags":["a","b","c"]}`},
+		{"$.name", "gateway"},
+		{`$.nested['a b'].c`, "1"},
+		{`$["nested"]["a b"]`, `{"c":1}`},
+		{"$.tags[0]", "a"},
+		{"$.tags[-1]", "c"},
+		{"$.tags[0,2]", "a\nc"},
+		{"$.tags[1:]", "b\nc"},
+		{"$.tags[::-1]", "c\nb\na"},
+		{"$.tags[*]", "a\nb\nc"},
+		{"$.servers[*].name", "fs\ncf"},
+		{"$..c", "1"},
+		{"$..name", "gateway\nfs\ncf"},
+		{"$.servers[?(@.tools > 10)].name", "cf"},
+		{"$.servers[?(@.enabled && @.tools < 10)].name", "fs"},
+		{"$.servers[?@.name == 'cf' || @.name == 'fs'].tools", "5\n12"},
+		{`$.servers[?(@.name == "x, y)")]`, ""},
+		{"$.tags[?(@ != 'b')]", "a\nc"},
+		{"$.missing.deeper", ""},
+		{"$.tags[7]", ""},
+	}
+
+	for _, tt := range tests {
+		q, err := CompileJSONPath(tt.expr)
+		if err != nil {
+			t.Errorf("CompileJSONPath(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		out, err := q.RunJSON([]byte(doc))
+		if err != nil {
+			t.Errorf("Run(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		if got := Format(out); got != tt.want {
+			t.Errorf("Run(%q) = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestJSONPathErrors(t *testing.T) {
+	for _, expr := range []string{".name", "$.", "$[", "$.tags[1:2:3:4]", "$['open", "$[?(!@.enabled)]", "$[?(@.a == $.b)]", "$[?()]", "$ name"} {
+		if _, err := CompileJSONPath(expr); err == nil {
+			t.Errorf("Expected CompileJSONPath(%q) to fail", expr)
+		}
+	}
+}
@@ -0,0 +1,396 @@
+// Package jsonschema validates decoded JSON values against the subset of
+// JSON Schema that tool input schemas use, without external dependencies.
+//
+// Supported keywords: type (a name or a list of names), enum, const,
+// properties, required, additionalProperties, items, minItems, maxItems,
+// minLength, maxLength, pattern, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, allOf, anyOf and oneOf. Other keywords, such as format
+// and default, are ignored. Schemas may be built in Go, so lists can be any
+// slice type and numbers any numeric type.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError is a violation of the schema by one value
+type FieldError struct {
+	// Field is the path of the value, such as "headers.Accept" or
+	// "command[0]", empty for the arguments themselves
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every violation found in a value
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid arguments: " + e.Details()
+}
+
+// Details lists the violations, such as "url: is required; count: must be
+// an integer"
+func (e *ValidationError) Details() string {
+	messages := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		if fe.Field == "" {
+			messages[i] = fe.Message
+		} else {
+			messages[i] = fe.Field + ": " + fe.Message
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Validate checks a decoded JSON value against a schema and returns a
+// *ValidationError listing the violations, or nil. Members of an object
+// that are null are treated as absent, as the tools do.
+func Validate(schema map[string]interface{}, value interface{}) error {
+	v := &validator{}
+	v.validate(schema, value, "")
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: v.errors}
+}
+
+type validator struct {
+	errors []FieldError
+}
+
+func (v *validator) fail(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validate(schema map[string]interface{}, value interface{}, path string) {
+	if schema == nil {
+		return
+	}
+	if types := stringList(schema["type"]); len(types) > 0 {
+		matched := false
+		for _, t := range types {
+			if hasType(value, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "must be %s", article(types))
+			return // The other keywords would only repeat the mistake
+		}
+	}
+
+	if enum, ok := schema["enum"]; ok {
+		values := list(enum)
+		found := false
+		for _, allowed := range values {
+			if equal(value, allowed) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			names := make([]string, len(values))
+			for i, allowed := range values {
+				names[i] = display(allowed)
+			}
+			v.fail(path, "must be one of %s", strings.Join(names, ", "))
+		}
+	}
+	if constant, ok := schema["const"]; ok && !equal(value, constant) {
+		v.fail(path, "must be %s", display(constant))
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		v.validateObject(schema, value, path)
+	case []interface{}:
+		v.validateArray(schema, value, path)
+	case string:
+		v.validateString(schema, value, path)
+	default:
+		if n, ok := number(value); ok {
+			v.validateNumber(schema, n, path)
+		}
+	}
+
+	for _, sub := range schemaList(schema["allOf"]) {
+		v.validate(sub, value, path)
+	}
+	if subs := schemaList(schema["anyOf"]); len(subs) > 0 {
+		if matches(subs, value) == 0 {
+			v.fail(path, "does not match any of the allowed forms")
+		}
+	}
+	if subs := schemaList(schema["oneOf"]); len(subs) > 0 {
+		if n := matches(subs, value); n != 1 {
+			v.fail(path, "must match exactly one of the allowed forms, matches %d", n)
+		}
+	}
+}
+
+func (v *validator) validateObject(schema map[string]interface{}, object map[string]interface{}, path string) {
+	for _, name := range stringList(schema["required"]) {
+		if object[name] == nil {
+			v.fail(join(path, name), "is required")
+		}
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names) // Report in a stable order
+	for _, name := range names {
+		member := object[name]
+		if member == nil {
+			continue
+		}
+		if property, ok := properties[name]; ok {
+			sub, _ := property.(map[string]interface{})
+			v.validate(sub, member, join(path, name))
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.fail(join(path, name), "is not a known argument")
+			}
+		case map[string]interface{}:
+			v.validate(additional, member, join(path, name))
+		}
+	}
+}
+
+func (v *validator) validateArray(schema map[string]interface{}, array []interface{}, path string) {
+	if min, ok := integer(schema["minItems"]); ok && len(array) < min {
+		v.fail(path, "must have at least %d %s", min, plural(min, "item"))
+	}
+	if max, ok := integer(schema["maxItems"]); ok && len(array) > max {
+		v.fail(path, "must have at most %d %s", max, plural(max, "item"))
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		for i, item := range array {
+			v.validate(items, item, fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+func (v *validator) validateString(schema map[string]interface{}, s string, path string) {
+	length := utf8.RuneCountInString(s)
+	if min, ok := integer(schema["minLength"]); ok && length < min {
+		v.fail(path, "must be at least %d %s long", min, plural(min, "character"))
+	}
+	if max, ok := integer(schema["maxLength"]); ok && length > max {
+		v.fail(path, "must be at most %d %s long", max, plural(max, "character"))
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := compile(pattern)
+		if err == nil && !re.MatchString(s) {
+			v.fail(path, "must match the pattern %s", pattern)
+		}
+	}
+}
+
+func (v *validator) validateNumber(schema map[string]interface{}, n float64, path string) {
+	if min, ok := number(schema["minimum"]); ok && n < min {
+		v.fail(path, "must be at least %s", formatNumber(min))
+	}
+	if max, ok := number(schema["maximum"]); ok && n > max {
+		v.fail(path, "must be at most %s", formatNumber(max))
+	}
+	if min, ok := number(schema["exclusiveMinimum"]); ok && n <= min {
+		v.fail(path, "must be greater than %s", formatNumber(min))
+	}
+	if max, ok := number(schema["exclusiveMaximum"]); ok && n >= max {
+		v.fail(path, "must be less than %s", formatNumber(max))
+	}
+}
+
+// matches counts the schemas a value satisfies
+func matches(schemas []map[string]interface{}, value interface{}) int {
+	n := 0
+	for _, sub := range schemas {
+		if Validate(sub, value) == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// hasType reports whether a value is of a JSON Schema type
+func hasType(value interface{}, t string) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := number(value)
+		return ok
+	case "integer":
+		n, ok := number(value)
+		return ok && n == math.Trunc(n) && !math.IsInf(n, 0)
+	}
+	return true // Unknown types are not enforced
+}
+
+// number returns the value of any Go number or json.Number
+func number(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// integer returns a schema number as an int
+func integer(value interface{}) (int, bool) {
+	n, ok := number(value)
+	return int(n), ok
+}
+
+// equal compares a value with a schema constant, numbers by value
+func equal(a, b interface{}) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// list returns the elements of any slice
+func list(value interface{}) []interface{} {
+	if values, ok := value.([]interface{}); ok {
+		return values
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice {
+		return nil
+	}
+	values := make([]interface{}, rv.Len())
+	for i := range values {
+		values[i] = rv.Index(i).Interface()
+	}
+	return values
+}
+
+// stringList returns a string or the strings of a slice
+func stringList(value interface{}) []string {
+	if s, ok := value.(string); ok {
+		return []string{s}
+	}
+	var out []string
+	for _, item := range list(value) {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// schemaList returns the schemas of an allOf, anyOf or oneOf
+func schemaList(value interface{}) []map[string]interface{} {
+	var out []map[string]interface{}
+	for _, item := range list(value) {
+		if schema, ok := item.(map[string]interface{}); ok {
+			out = append(out, schema)
+		}
+	}
+	return out
+}
+
+// article names the expected types, such as "a string" or "an integer or
+// null"
+func article(types []string) string {
+	names := make([]string, len(types))
+	for i, t := range types {
+		switch t {
+		case "null":
+			names[i] = "null"
+		case "array", "object", "integer":
+			names[i] = "an " + t
+		default:
+			names[i] = "a " + t
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+// display formats an enum or const value for a message
+func display(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+func formatNumber(n float64) string {
+	return fmt.Sprintf("%g", n)
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// patterns caches compiled patterns, since schemas are validated on every
+// call
+var patterns sync.Map
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
@@ -0,0 +1,96 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// schema mirrors the Go-built input schemas of the tools
+var schema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"url":    map[string]interface{}{"type": "string", "pattern": "^https?://"},
+		"format": map[string]interface{}{"type": "string", "enum": []string{"markdown", "text"}},
+		"count":  map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 10},
+		"ratio":  map[string]interface{}{"type": []string{"number", "null"}, "exclusiveMaximum": 1.0},
+		"tags": map[string]interface{}{
+			"type":     "array",
+			"items":    map[string]interface{}{"type": "string", "minLength": 2},
+			"maxItems": 2,
+		},
+		"headers": map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": map[string]interface{}{"type": "string"},
+		},
+	},
+	"required":             []string{"url"},
+	"additionalProperties": false,
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		args string
+		want []FieldError
+	}{
+		{`{"url": "https://example.com", "format": "text", "count": 3, "tags": ["go", "mcp"], "headers": {"Accept": "text/html"}}`, nil},
+		// Null members count as absent
+		{`{"url": "https://example.com", "format": null, "ratio": null}`, nil},
+		{`{"format": "pdf"}`, []FieldError{
+			{"url", "is required"},
+			{"format", "must be one of markdown, text"},
+		}},
+		{`{"url": 42, "count": 2.5, "ratio": 1}`, []FieldError{
+			{"count", "must be an integer"},
+			{"ratio", "must be less than 1"},
+			{"url", "must be a string"},
+		}},
+		{`{"url": "ftp://example.com", "count": 11, "tags": ["a", "bc", "de"], "headers": {"Accept": 1}, "extra": true}`, []FieldError{
+			{"count", "must be at most 10"},
+			{"extra", "is not a known argument"},
+			{"headers.Accept", "must be a string"},
+			{"tags", "must have at most 2 items"},
+			{"tags[0]", "must be at least 2 characters long"},
+			{"url", "must match the pattern ^https?://"},
+		}},
+	}
+	for _, tt := range tests {
+		var args interface{}
+		if err := json.Unmarshal([]byte(tt.args), &args); err != nil {
+			t.Fatal(err)
+		}
+		err := Validate(schema, args)
+		var verr *ValidationError
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("Validate(%s) = %v, expected no error", tt.args, err)
+			}
+			continue
+		}
+		if !errors.As(err, &verr) || !reflect.DeepEqual(verr.Errors, tt.want) {
+			t.Errorf("Validate(%s) = %v, expected %v", tt.args, err, tt.want)
+		}
+	}
+}
+
+func TestValidateCombinators(t *testing.T) {
+	oneOf := map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": "number"},
+			map[string]interface{}{"type": "integer"},
+		},
+	}
+	if err := Validate(oneOf, "x"); err != nil {
+		t.Errorf("Expected a string to match one form, got %v", err)
+	}
+	// 3 is both a number and an integer
+	if err := Validate(oneOf, 3.0); err == nil || err.Error() != "invalid arguments: must match exactly one of the allowed forms, matches 2" {
+		t.Errorf("Expected an ambiguous match error, got %v", err)
+	}
+	anyOf := map[string]interface{}{"anyOf": []map[string]interface{}{{"type": "string"}, {"type": "boolean"}}}
+	if err := Validate(anyOf, 1.0); err == nil {
+		t.Error("Expected a number to match no form")
+	}
+}
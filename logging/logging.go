@@ -0,0 +1,76 @@
+// Package logging writes leveled messages through the standard logger, so
+// the configured output and flags still apply. Messages below the minimum
+// level set with SetLevel are dropped; fatal errors keep using log.Fatal.
+package logging
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+)
+
+// Level is the severity of a message
+type Level int32
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+var levelNames = map[string]Level{"debug": Debug, "info": Info, "warn": Warn, "error": Error}
+
+// ParseLevel returns the level named debug, info, warn or error
+func ParseLevel(name string) (Level, error) {
+	level, ok := levelNames[name]
+	if !ok {
+		return Info, fmt.Errorf("invalid log level %q (supported: debug, info, warn, error)", name)
+	}
+	return level, nil
+}
+
+var minimum atomic.Int32
+
+func init() {
+	minimum.Store(int32(Info))
+}
+
+// SetLevel drops messages below level from now on
+func SetLevel(level Level) {
+	minimum.Store(int32(level))
+}
+
+// Enabled reports whether messages of the level are written
+func Enabled(level Level) bool {
+	return int32(level) >= minimum.Load()
+}
+
+// Debugf logs detail that is only useful when diagnosing a problem, such as
+// every request handled
+func Debugf(format string, args ...interface{}) {
+	output(Debug, "", format, args...)
+}
+
+// Infof logs normal operation: startup, configuration and tool calls
+func Infof(format string, args ...interface{}) {
+	output(Info, "", format, args...)
+}
+
+// Warnf logs a problem the server works around, prefixed with "Warning: "
+func Warnf(format string, args ...interface{}) {
+	output(Warn, "Warning: ", format, args...)
+}
+
+// Errorf logs a failure that lost a response or a request
+func Errorf(format string, args ...interface{}) {
+	output(Error, "", format, args...)
+}
+
+func output(level Level, prefix, format string, args ...interface{}) {
+	if !Enabled(level) {
+		return
+	}
+	// Skip output and the helper so that log.Lshortfile names the caller
+	log.Output(3, prefix+fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+		SetLevel(Info)
+	}()
+
+	logAll := func() string {
+		out.Reset()
+		Debugf("debug %d", 1)
+		Infof("info %d", 2)
+		Warnf("warn %d", 3)
+		Errorf("error %d", 4)
+		return out.String()
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"debug", "debug 1\ninfo 2\nWarning: warn 3\nerror 4\n"},
+		{"info", "info 2\nWarning: warn 3\nerror 4\n"},
+		{"warn", "Warning: warn 3\nerror 4\n"},
+		{"error", "error 4\n"},
+	}
+	for _, tt := range tests {
+		level, err := ParseLevel(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		SetLevel(level)
+		if got := logAll(); got != tt.want {
+			t.Errorf("At level %s expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+
+	// The wording does not decide the level
+	SetLevel(Warn)
+	out.Reset()
+	Infof("Tool x failed with an invalid argument")
+	if out.Len() != 0 {
+		t.Errorf("Expected an info message to be dropped at warn level, got %q", out.String())
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil || !strings.Contains(err.Error(), "supported: debug, info, warn, error") {
+		t.Errorf("Expected an unknown level to be rejected, got %v", err)
+	}
+}
+
+func TestShortfileNamesCaller(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	log.SetFlags(log.Lshortfile)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.LstdFlags)
+	}()
+
+	Infof("hello")
+	if !strings.HasPrefix(out.String(), "logging_test.go:") {
+		t.Errorf("Expected the caller's file, got %q", out.String())
+	}
+}
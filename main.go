@@ -1,27 +1,195 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"mcp-go/config"
 	"mcp-go/gateway"
+	"mcp-go/logging"
 	"mcp-go/server"
 	"mcp-go/tools"
 	"os"
+	"slices"
+	"strings"
+	"time"
 )
 
+// stringList is a repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// setLogLevel configures the standard logger for the given --log-level. At
+// debug level log lines also name the file and line that wrote them.
+func setLogLevel(name string) error {
+	level, err := logging.ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	if level == logging.Debug {
+		log.SetFlags(log.LstdFlags | log.Lshortfile)
+	}
+	logging.SetLevel(level)
+	return nil
+}
+
+// loadConfig loads the file given with --config, which must succeed, or else
+// the first default configuration file found, falling back to environment
+// variables and then to the defaults. An invalid configuration is always an
+// error rather than silently ignored. profile selects the profile of the
+// file to apply (empty = MCP_PROFILE or the file's default); remote, when
+// set, is the source of a remote path.
+func loadConfig(path, profile string, remote *config.RemoteSource) (*config.Config, error) {
+	if profile == "" {
+		profile = os.Getenv(config.ProfileEnv)
+	}
+	if remote != nil {
+		cfg, _, err := remote.Load(context.Background(), profile)
+		return cfg, err
+	}
+	if path != "" {
+		return config.LoadConfigProfile(path, profile)
+	}
+
+	var invalid *config.ValidationError
+	cfg, err := config.LoadConfigProfile(config.FindConfigFile(), profile)
+	if err == nil || errors.As(err, &invalid) {
+		return cfg, err
+	}
+
+	// Try environment variables
+	cfg, err = config.LoadConfigFromEnv()
+	if err == nil || errors.As(err, &invalid) {
+		return cfg, err
+	}
+	logging.Infof("No configuration found, running without remote MCP servers: %v", err)
+	return config.DefaultConfig(), nil
+}
+
+// configureKubernetes enables the Kubernetes tools with the credentials of
+// the configured kubeconfig, or of the pod's service account when the gateway
+// runs in a cluster without one
+func configureKubernetes(k8s config.KubernetesConfig) error {
+	var cluster *tools.KubernetesCluster
+	var contextNamespace string
+	if k8s.Kubeconfig == "" && os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		inCluster, namespace, err := tools.InClusterKubernetes()
+		if err != nil {
+			return err
+		}
+		cluster, contextNamespace = inCluster, namespace
+		logging.Infof("Kubernetes tools use the in-cluster service account")
+	} else {
+		path := k8s.Kubeconfig
+		if path == "" {
+			path = config.DefaultKubeconfigPath()
+		}
+		kube, err := config.LoadKubeconfig(path, k8s.Context)
+		if err != nil {
+			return err
+		}
+		cluster = &tools.KubernetesCluster{
+			Server:         kube.Server,
+			CAData:         kube.CertificateAuthorityData,
+			Insecure:       kube.InsecureSkipTLSVerify,
+			Token:          kube.Token,
+			ClientCertData: kube.ClientCertificateData,
+			ClientKeyData:  kube.ClientKeyData,
+			ExecCommand:    kube.ExecCommand,
+			ExecArgs:       kube.ExecArgs,
+			ExecEnv:        kube.ExecEnv,
+		}
+		contextNamespace = kube.Namespace
+		logging.Infof("Kubernetes tools use context %s of %s", kube.Name, path)
+	}
+
+	// The namespace of the context is only a default when it is allowed
+	namespace := k8s.DefaultNamespace
+	if namespace == "" && contextNamespace != "" {
+		namespace = contextNamespace
+		if len(k8s.Namespaces) > 0 && !slices.Contains(k8s.Namespaces, namespace) {
+			namespace = ""
+		}
+	}
+	return tools.SetKubernetesConfig(*cluster, k8s.Namespaces, namespace, k8s.AllowApply, time.Duration(k8s.TimeoutSeconds)*time.Second, k8s.MaxOutputBytes)
+}
+
 func main() {
+	if configCommandRequested() {
+		os.Exit(runConfigCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	// Command line flags take precedence over the configuration file, which
+	// takes precedence over environment variables
+	configPath := flag.String("config", "", "configuration file (default: first of "+strings.Join(config.DefaultConfigFiles, ", ")+")")
+	addr := flag.String("addr", "", "listen address, overrides the configured port (e.g. :8080 or 127.0.0.1:8080)")
+	profile := flag.String("profile", "", "configuration profile to apply, e.g. dev or prod (default: $MCP_PROFILE)")
+	logLevel := flag.String("log-level", "info", "log verbosity: debug, info, warn or error")
+	importClaude := flag.String("import-claude-config", "", "add the servers of a Claude Desktop style configuration file ({\"mcpServers\": {...}})")
+	var disabledTools, enabledServers stringList
+	flag.Var(&disabledTools, "disable-tool", "hide a tool from clients (repeatable)")
+	flag.Var(&enabledServers, "enable-server", "enable a configured server that is disabled in the configuration (repeatable)")
+	flag.Parse()
+
+	if err := setLogLevel(*logLevel); err != nil {
+		log.Fatal(err)
+	}
+
 	// Create gateway
 	gw := gateway.NewGateway()
 
-	// Try to load configuration from file or environment
-	cfg, err := config.LoadConfig("mcp-config.json")
+	// Load configuration from file, URL or environment. A remote
+	// configuration (http(s), s3:// or gs://) can be polled for changes.
+	var remote *config.RemoteSource
+	if config.IsRemoteSource(*configPath) {
+		remote = config.NewRemoteSource(*configPath)
+	}
+	cfg, err := loadConfig(*configPath, *profile, remote)
 	if err != nil {
-		// Try environment variables
-		cfg, err = config.LoadConfigFromEnv()
-		if err != nil {
-			log.Printf("No configuration found, running without remote MCP servers: %v", err)
-			cfg = config.DefaultConfig()
+		log.Fatal(err)
+	}
+	if cfg.Profile != "" {
+		logging.Infof("Using configuration profile %q", cfg.Profile)
+	}
+
+	// Apply flag overrides, again for every reloaded configuration
+	applyFlags := func(cfg *config.Config) error {
+		if *importClaude != "" {
+			if err := cfg.ImportClaudeConfig(*importClaude); err != nil {
+				return err
+			}
+		}
+		for _, name := range enabledServers {
+			found := false
+			for i := range cfg.Servers {
+				if cfg.Servers[i].Name == name {
+					cfg.Servers[i].Enabled = true
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("invalid --enable-server: no server named %q is configured", name)
+			}
 		}
+		if len(enabledServers) > 0 {
+			// Enabling servers can introduce conflicts such as duplicate prefixes
+			if err := cfg.Validate(); err != nil {
+				return err
+			}
+		}
+		cfg.DisabledTools = append(cfg.DisabledTools, disabledTools...)
+		return nil
+	}
+	if err := applyFlags(cfg); err != nil {
+		log.Fatal(err)
 	}
 
 	// Load clients from configuration
@@ -29,9 +197,42 @@ func main() {
 		log.Fatalf("Failed to load MCP clients: %v", err)
 	}
 
-	// Note: Clients will be initialized lazily when first used (tools/list or tools/call)
-	// This allows the server to start immediately without waiting for remote servers
-	log.Println("MCP clients loaded. They will be initialized on first use.")
+	// Initialize clients according to their "initialize" mode. Eager clients
+	// block here, background clients connect asynchronously (see /readyz) and
+	// lazy clients (the default) are initialized when first used
+	if err := gw.InitializeConfigured(context.Background()); err != nil {
+		logging.Warnf("%v", err)
+	}
+	logging.Infof("MCP clients loaded. Lazy clients will be initialized on first use.")
+
+	// Keep the call history served by /gateway/calls across restarts
+	if cfg.CallHistoryFile != "" {
+		if err := gw.PersistCallHistory(cfg.CallHistoryFile); err != nil {
+			logging.Warnf("%v", err)
+		}
+	}
+
+	// Poll a remote configuration and apply its changes without a restart
+	if remote != nil && cfg.ConfigRefreshSeconds > 0 {
+		remote.Watch(context.Background(), time.Duration(cfg.ConfigRefreshSeconds)*time.Second, *profile, func(changed *config.Config) {
+			if err := applyFlags(changed); err != nil {
+				logging.Warnf("ignoring changed configuration: %v", err)
+				return
+			}
+			if err := gw.Reload(context.Background(), changed); err != nil {
+				logging.Warnf("%v", err)
+			}
+		}, func(err error) {
+			logging.Warnf("failed to refresh configuration: %v", err)
+		})
+		logging.Infof("Configuration refresh from %s enabled every %ds", *configPath, cfg.ConfigRefreshSeconds)
+	}
+
+	// Periodically re-list upstream tools so catalog drift reaches clients
+	if cfg.ToolRefreshSeconds > 0 {
+		gw.StartCatalogRefresh(context.Background(), time.Duration(cfg.ToolRefreshSeconds)*time.Second)
+		logging.Infof("Tool catalog refresh enabled every %ds", cfg.ToolRefreshSeconds)
+	}
 
 	// Configure Google PSE from config file or environment variables
 	googlePSE := cfg.GetGooglePSEConfig()
@@ -43,22 +244,404 @@ func main() {
 		apiKey = googlePSE.APIKey
 		searchEngineID = googlePSE.SearchEngineID
 		googlePSEEnabled = true
-		log.Println("Google PSE configured from config file")
+		logging.Infof("Google PSE configured from config file")
 	} else {
 		// Try environment variables
 		apiKey = os.Getenv("GOOGLE_PSE_API_KEY")
 		searchEngineID = os.Getenv("GOOGLE_PSE_SEARCH_ENGINE_ID")
 		if apiKey != "" && searchEngineID != "" {
 			googlePSEEnabled = true
-			log.Println("Google PSE configured from environment variables")
+			logging.Infof("Google PSE configured from environment variables")
 		}
 	}
 
 	if googlePSEEnabled {
 		tools.SetGooglePSEConfig(apiKey, searchEngineID)
-		log.Println("Google PSE enabled successfully")
+		logging.Infof("Google PSE enabled successfully")
 	} else {
-		log.Println("Google PSE not configured (set enabled:true in config file or GOOGLE_PSE_API_KEY and GOOGLE_PSE_SEARCH_ENGINE_ID env vars)")
+		logging.Infof("Google PSE not configured (set enabled:true in config file or GOOGLE_PSE_API_KEY and GOOGLE_PSE_SEARCH_ENGINE_ID env vars)")
+	}
+
+	// Configure Brave Search from config file or environment variable
+	braveAPIKey := os.Getenv("BRAVE_SEARCH_API_KEY")
+	if braveSearch := cfg.BraveSearch; braveSearch.Enabled && braveSearch.APIKey != "" {
+		braveAPIKey = braveSearch.APIKey
+	}
+	if braveAPIKey != "" {
+		tools.SetBraveSearchConfig(braveAPIKey)
+		logging.Infof("Brave Search enabled successfully")
+	}
+
+	// Configure SearXNG from config file or environment variable
+	searXNGURL := os.Getenv("SEARXNG_URL")
+	if searXNG := cfg.SearXNG; searXNG.Enabled && searXNG.URL != "" {
+		searXNGURL = searXNG.URL
+	}
+	if searXNGURL != "" {
+		tools.SetSearXNGConfig(searXNGURL)
+		logging.Infof("SearXNG search enabled with %s", searXNGURL)
+	}
+
+	// DuckDuckGo needs no key, so it is the fallback when no other search is
+	// configured
+	duckDuckGo := !googlePSEEnabled && braveAPIKey == "" && searXNGURL == ""
+	if cfg.DuckDuckGo.Enabled != nil {
+		duckDuckGo = *cfg.DuckDuckGo.Enabled
+	}
+	tools.SetDuckDuckGoEnabled(duckDuckGo)
+	if duckDuckGo {
+		logging.Infof("DuckDuckGo search enabled")
+	}
+
+	// Configure the http_request tool; it stays hidden unless enabled
+	if httpRequest := cfg.HTTPRequest; httpRequest.Enabled {
+		tools.SetHTTPRequestConfig(httpRequest.AllowedHosts, time.Duration(httpRequest.TimeoutSeconds)*time.Second, httpRequest.MaxResponseBytes)
+		if len(httpRequest.AllowedHosts) == 0 {
+			logging.Warnf("http_request is enabled without allowed_hosts, so every request will be rejected")
+		} else {
+			logging.Infof("http_request enabled for hosts: %s", strings.Join(httpRequest.AllowedHosts, ", "))
+		}
+	}
+
+	// Configure the fetch_page tool; it stays hidden unless enabled
+	if fetchPage := cfg.FetchPage; fetchPage.Enabled {
+		tools.SetFetchPageConfig(time.Duration(fetchPage.TimeoutSeconds)*time.Second, fetchPage.MaxLength, fetchPage.AllowPrivateNetworks)
+		logging.Infof("fetch_page enabled")
+	}
+
+	// Configure the run_command tool; it stays hidden unless enabled
+	if runCommand := cfg.RunCommand; runCommand.Enabled {
+		tools.SetRunCommandConfig(tools.RunCommandConfig{
+			AllowedCommands: runCommand.AllowedCommands,
+			Unsafe:          runCommand.Unsafe,
+			AllowedEnv:      runCommand.AllowedEnv,
+			WorkingDir:      runCommand.WorkingDir,
+			Timeout:         time.Duration(runCommand.TimeoutSeconds) * time.Second,
+			MaxOutputBytes:  runCommand.MaxOutputBytes,
+		})
+		if runCommand.Unsafe {
+			logging.Warnf("run_command is enabled in unsafe mode and will run any command")
+		} else {
+			logging.Infof("run_command enabled for commands: %s", strings.Join(runCommand.AllowedCommands, ", "))
+		}
+	}
+
+	// Configure the git tools; they stay hidden unless enabled
+	if git := cfg.Git; git.Enabled {
+		if err := tools.SetGitConfig(git.Repositories, time.Duration(git.TimeoutSeconds)*time.Second, git.MaxOutputBytes); err != nil {
+			log.Fatalf("Failed to configure the git tools: %v", err)
+		}
+		logging.Infof("git tools enabled for repositories: %s", strings.Join(git.Repositories, ", "))
+	}
+
+	// Configure the sql_query tool; it stays hidden unless enabled
+	if sqlConfig := cfg.SQL; sqlConfig.Enabled {
+		connections := make(map[string]tools.SQLConnection, len(sqlConfig.Connections))
+		for name, connection := range sqlConfig.Connections {
+			connections[name] = tools.SQLConnection{Driver: connection.Driver, DSN: connection.DSN, ReadOnly: connection.ReadOnly}
+		}
+		tools.SetSQLQueryConfig(connections, time.Duration(sqlConfig.TimeoutSeconds)*time.Second, sqlConfig.MaxRows)
+		logging.Infof("sql_query enabled with %d connections", len(connections))
+	}
+
+	// Configure the Wikipedia tools; they stay hidden unless enabled
+	if wikipedia := cfg.Wikipedia; wikipedia.Enabled {
+		language := wikipedia.Language
+		if language == "" {
+			language = "en"
+		}
+		if err := tools.SetWikipediaConfig(language); err != nil {
+			log.Fatalf("Failed to configure the Wikipedia tools: %v", err)
+		}
+		logging.Infof("Wikipedia tools enabled (default language: %s)", language)
+	}
+
+	// Configure the knowledge base tools; they stay hidden unless enabled
+	if kb := cfg.KnowledgeBase; kb.Enabled {
+		embedder := &tools.OpenAIEmbedder{URL: kb.Embedding.URL, APIKey: kb.Embedding.APIKey, Model: kb.Embedding.Model}
+		if err := tools.SetKnowledgeBaseConfig(embedder, kb.IndexPath, kb.ChunkSize, kb.ChunkOverlap); err != nil {
+			log.Fatalf("Failed to configure the knowledge base: %v", err)
+		}
+		if kb.IndexPath == "" {
+			logging.Infof("Knowledge base enabled with %s embeddings; the index is kept in memory only", kb.Embedding.Model)
+		} else {
+			logging.Infof("Knowledge base enabled with %s embeddings, index at %s", kb.Embedding.Model, kb.IndexPath)
+		}
+	}
+
+	// Configure the S3 tools; they stay hidden unless enabled
+	if s3 := cfg.S3; s3.Enabled {
+		buckets := make(map[string]tools.S3Bucket, len(s3.Buckets))
+		for name, bucket := range s3.Buckets {
+			if bucket.Bucket == "" {
+				bucket.Bucket = name
+			}
+			if bucket.AccessKeyID == "" && bucket.SecretAccessKey == "" {
+				bucket.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+				bucket.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+				bucket.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+			}
+			buckets[name] = tools.S3Bucket{
+				Bucket:          bucket.Bucket,
+				Endpoint:        bucket.Endpoint,
+				Region:          bucket.Region,
+				AccessKeyID:     bucket.AccessKeyID,
+				SecretAccessKey: bucket.SecretAccessKey,
+				SessionToken:    bucket.SessionToken,
+				PathStyle:       bucket.PathStyle,
+				ReadOnly:        bucket.ReadOnly,
+			}
+		}
+		tools.SetS3Config(buckets, time.Duration(s3.TimeoutSeconds)*time.Second, s3.MaxObjectBytes)
+		logging.Infof("S3 tools enabled with %d buckets", len(buckets))
+	}
+
+	// Configure the docker tools; they stay hidden unless enabled
+	if docker := cfg.Docker; docker.Enabled {
+		host := docker.Host
+		if host == "" {
+			host = os.Getenv("DOCKER_HOST")
+		}
+		if err := tools.SetDockerConfig(host, docker.AllowExec, docker.AllowRestart, time.Duration(docker.TimeoutSeconds)*time.Second, docker.MaxOutputBytes); err != nil {
+			log.Fatalf("Failed to configure the docker tools: %v", err)
+		}
+		logging.Infof("docker tools enabled for %s (exec: %v, restart: %v)", tools.GetDockerConfig().Host, docker.AllowExec, docker.AllowRestart)
+	}
+
+	// Configure the Kubernetes tools; they stay hidden unless enabled
+	if k8s := cfg.Kubernetes; k8s.Enabled {
+		if err := configureKubernetes(k8s); err != nil {
+			log.Fatalf("Failed to configure the Kubernetes tools: %v", err)
+		}
+		if len(k8s.Namespaces) > 0 {
+			logging.Infof("Kubernetes tools enabled for namespaces: %s", strings.Join(k8s.Namespaces, ", "))
+		} else {
+			logging.Infof("Kubernetes tools enabled for every namespace")
+		}
+	}
+
+	// Configure the send_email tool; it stays hidden unless enabled
+	if email := cfg.Email; email.Enabled {
+		err := tools.SetEmailConfig(&tools.EmailConfig{
+			Host:                    email.Host,
+			Port:                    email.Port,
+			Username:                email.Username,
+			Password:                email.Password,
+			From:                    email.From,
+			TLS:                     email.TLS,
+			AllowedRecipientDomains: email.AllowedRecipientDomains,
+			MaxPerHour:              email.MaxPerHour,
+			MaxRecipients:           email.MaxRecipients,
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure the send_email tool: %v", err)
+		}
+		logging.Infof("send_email tool enabled via %s for domains: %s", email.Host, strings.Join(email.AllowedRecipientDomains, ", "))
+	}
+
+	// Configure the Slack tools; they stay hidden unless enabled
+	if slack := cfg.Slack; slack.Enabled {
+		token := slack.BotToken
+		if token == "" {
+			token = os.Getenv("SLACK_BOT_TOKEN")
+		}
+		if token == "" {
+			log.Fatalf("Failed to configure the Slack tools: set slack.bot_token or SLACK_BOT_TOKEN")
+		}
+		tools.SetSlackConfig(token, slack.Channels, time.Duration(slack.TimeoutSeconds)*time.Second)
+		logging.Infof("Slack tools enabled with %d channels", len(slack.Channels))
+	}
+
+	// Configure the Telegram tools; they stay hidden unless enabled
+	if telegram := cfg.Telegram; telegram.Enabled {
+		token := telegram.BotToken
+		if token == "" {
+			token = os.Getenv("TELEGRAM_BOT_TOKEN")
+		}
+		if token == "" {
+			log.Fatalf("Failed to configure the Telegram tools: set telegram.bot_token or TELEGRAM_BOT_TOKEN")
+		}
+		tools.SetTelegramConfig(token, telegram.ChatIDs, time.Duration(telegram.TimeoutSeconds)*time.Second)
+		logging.Infof("Telegram tools enabled with %d chats", len(telegram.ChatIDs))
+	}
+
+	// Configure the GitHub tools; they stay hidden unless enabled
+	if github := cfg.GitHub; github.Enabled {
+		token := github.Token
+		if token == "" {
+			token = os.Getenv("GITHUB_TOKEN")
+		}
+		if token == "" {
+			logging.Warnf("GitHub tools have no token and can only read public data")
+		}
+		tools.SetGitHubConfig(github.APIURL, token, github.Repositories, github.ReadOnly, time.Duration(github.TimeoutSeconds)*time.Second)
+		if len(github.Repositories) > 0 {
+			logging.Infof("GitHub tools enabled for repositories: %s (read-only: %v)", strings.Join(github.Repositories, ", "), github.ReadOnly)
+		} else {
+			logging.Infof("GitHub tools enabled for every repository (read-only: %v)", github.ReadOnly)
+		}
+	}
+
+	// Configure the Jira tools; they stay hidden unless enabled
+	if jira := cfg.Jira; jira.Enabled {
+		token := jira.APIToken
+		if token == "" {
+			token = os.Getenv("JIRA_API_TOKEN")
+		}
+		if token == "" {
+			log.Fatalf("Failed to configure the Jira tools: set jira.api_token or JIRA_API_TOKEN")
+		}
+		tools.SetJiraConfig(jira.URL, jira.Email, token, jira.Projects, jira.ReadOnly, time.Duration(jira.TimeoutSeconds)*time.Second)
+		if len(jira.Projects) > 0 {
+			logging.Infof("Jira tools enabled for %s, projects: %s (read-only: %v)", jira.URL, strings.Join(jira.Projects, ", "), jira.ReadOnly)
+		} else {
+			logging.Infof("Jira tools enabled for %s, every project (read-only: %v)", jira.URL, jira.ReadOnly)
+		}
+	}
+
+	// Configure the ocr_image tool; it stays hidden unless enabled
+	if ocr := cfg.OCR; ocr.Enabled {
+		err := tools.SetOCRConfig(tools.OCRConfig{
+			Engine:        ocr.Engine,
+			TesseractPath: ocr.TesseractPath,
+			VisionAPIKey:  ocr.APIKey,
+			Language:      ocr.Language,
+			AllowedPaths:  ocr.AllowedPaths,
+			MaxImageBytes: ocr.MaxImageBytes,
+			Timeout:       time.Duration(ocr.TimeoutSeconds) * time.Second,
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure the ocr_image tool: %v", err)
+		}
+		logging.Infof("ocr_image tool enabled with %s", tools.GetOCRConfig().Engine)
+	}
+
+	// Configure the browser tools; they stay hidden unless enabled
+	if browser := cfg.Browser; browser.Enabled {
+		err := tools.SetBrowserConfig(tools.BrowserConfig{
+			ChromePath:     browser.ChromePath,
+			ChromeArgs:     browser.ChromeArgs,
+			RemoteURL:      browser.RemoteURL,
+			AllowedDomains: browser.AllowedDomains,
+			MaxPages:       browser.MaxPages,
+			IdleTimeout:    time.Duration(browser.IdleTimeoutSeconds) * time.Second,
+			MaxLength:      browser.MaxLength,
+			Timeout:        time.Duration(browser.TimeoutSeconds) * time.Second,
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure the browser tools: %v", err)
+		}
+		if browser.RemoteURL != "" {
+			logging.Infof("Browser tools enabled with %s, allowed domains: %s", browser.RemoteURL, strings.Join(browser.AllowedDomains, ", "))
+		} else {
+			logging.Infof("Browser tools enabled with %s, allowed domains: %s", tools.GetBrowserConfig().ChromePath, strings.Join(browser.AllowedDomains, ", "))
+		}
+	}
+
+	// Configure the read_csv and read_xlsx tools; they stay hidden unless
+	// enabled
+	if spreadsheet := cfg.Spreadsheet; spreadsheet.Enabled {
+		err := tools.SetSpreadsheetConfig(tools.SpreadsheetConfig{
+			AllowedPaths: spreadsheet.AllowedPaths,
+			MaxRows:      spreadsheet.MaxRows,
+			MaxFileBytes: spreadsheet.MaxFileBytes,
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure the spreadsheet tools: %v", err)
+		}
+		logging.Infof("Spreadsheet tools enabled, allowed paths: %v", tools.GetSpreadsheetConfig().AllowedPaths)
+	}
+
+	// Configure the json_query tool; it stays hidden unless enabled
+	if jsonQuery := cfg.JSONQuery; jsonQuery.Enabled {
+		err := tools.SetJSONQueryConfig(tools.JSONQueryConfig{
+			AllowedPaths:  jsonQuery.AllowedPaths,
+			MaxInputBytes: jsonQuery.MaxInputBytes,
+			MaxLength:     jsonQuery.MaxLength,
+		})
+		if err != nil {
+			log.Fatalf("Failed to configure the json_query tool: %v", err)
+		}
+		logging.Infof("json_query tool enabled, allowed paths: %v", tools.GetJSONQueryConfig().AllowedPaths)
+	}
+
+	// Configure the dns_lookup tool; it stays hidden unless enabled
+	if dnsLookup := cfg.DNSLookup; dnsLookup.Enabled {
+		err := tools.SetDNSLookupConfig(dnsLookup.Resolver, time.Duration(dnsLookup.TimeoutSeconds)*time.Second)
+		if err != nil {
+			log.Fatalf("Failed to configure the dns_lookup tool: %v", err)
+		}
+		logging.Infof("dns_lookup enabled")
+	}
+
+	// Configure the whois tool; it stays hidden unless enabled
+	if whois := cfg.Whois; whois.Enabled {
+		tools.SetWhoisConfig(time.Duration(whois.TimeoutSeconds)*time.Second, whois.MaxLength)
+		logging.Infof("whois enabled")
+	}
+
+	// Configure the crawl_site tool; it stays hidden unless enabled
+	if crawlSite := cfg.CrawlSite; crawlSite.Enabled {
+		tools.SetCrawlSiteConfig(tools.CrawlSiteConfig{
+			MaxPages:             crawlSite.MaxPages,
+			MaxDepth:             crawlSite.MaxDepth,
+			MaxLength:            crawlSite.MaxLength,
+			Timeout:              time.Duration(crawlSite.TimeoutSeconds) * time.Second,
+			AllowPrivateNetworks: crawlSite.AllowPrivateNetworks,
+		})
+		logging.Infof("crawl_site enabled")
+	}
+
+	// Configure the youtube_transcript tool; it stays hidden unless enabled
+	if youtube := cfg.YouTubeTranscript; youtube.Enabled {
+		tools.SetYouTubeTranscriptConfig(tools.YouTubeTranscriptConfig{
+			Timeout:   time.Duration(youtube.TimeoutSeconds) * time.Second,
+			MaxLength: youtube.MaxLength,
+			Languages: youtube.Languages,
+		})
+		logging.Infof("youtube_transcript enabled")
+	}
+
+	// Load the tools implemented by external programs; the server registers
+	// them, and the admin API adds and removes them later
+	var plugins []*tools.Plugin
+	for _, plugin := range cfg.Plugins {
+		var env []string
+		for name, value := range plugin.Env {
+			env = append(env, name+"="+value)
+		}
+		tool, err := tools.NewPlugin(tools.PluginConfig{
+			Name:           plugin.Name,
+			Description:    plugin.Description,
+			InputSchema:    plugin.InputSchema,
+			ReadOnly:       plugin.ReadOnly,
+			Command:        plugin.Command,
+			Args:           plugin.Args,
+			Env:            env,
+			WorkingDir:     plugin.WorkingDir,
+			Timeout:        time.Duration(plugin.TimeoutSeconds) * time.Second,
+			MaxOutputBytes: plugin.MaxOutputBytes,
+		})
+		if err != nil {
+			log.Fatalf("Failed to load plugin: %v", err)
+		}
+		plugins = append(plugins, tool)
+		logging.Infof("Plugin %s loaded", plugin.Name)
+	}
+
+	// Bound the resources of local tool calls
+	if len(cfg.ToolLimits) > 0 {
+		limits := make(map[string]tools.Limits, len(cfg.ToolLimits))
+		for name, l := range cfg.ToolLimits {
+			limits[name] = tools.Limits{
+				Timeout:        time.Duration(l.TimeoutSeconds) * time.Second,
+				MaxOutputBytes: l.MaxOutputBytes,
+				CPUSeconds:     l.CPUSeconds,
+				MemoryBytes:    l.MemoryBytes,
+			}
+		}
+		tools.DefaultRegistry.Use(tools.LimitTools(limits))
+		logging.Infof("Resource limits set for %d local tool entries", len(limits))
 	}
 
 	// Get bearer token from config or environment
@@ -69,5 +652,21 @@ func main() {
 
 	// Start server with gateway, configured port, and bearer token
 	port := cfg.GetPort()
-	server.StartWithGatewayAndPortAndAuth(gw, port, bearerToken)
+	if *addr != "" {
+		port = *addr
+	}
+	srv := server.NewServerWithAuth(gw, bearerToken)
+	if bearerToken != "" {
+		logging.Infof("Bearer token authentication enabled")
+	} else {
+		logging.Infof("Bearer token authentication disabled (no token configured)")
+	}
+	for i, plugin := range plugins {
+		if err := srv.DeclarePlugin(plugin, !cfg.Plugins[i].Disabled); err != nil {
+			log.Fatalf("Failed to register plugin: %v", err)
+		}
+	}
+	if err := srv.ListenAndServe(port); err != nil {
+		log.Fatalf("Server failed to start: %v\n", err)
+	}
 }
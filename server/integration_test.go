@@ -1,129 +1,115 @@
-package server
+package server_test
 
 import (
-	"bytes"
-	"encoding/json"
-	"net/http"
-	"net/http/httptest"
+	"context"
+	"mcp-go/gateway"
+	"mcp-go/server"
+	"mcp-go/tools"
+	"mcp-go/tools/toolstest"
+	"mcp-go/transport"
+	"strings"
 	"testing"
 )
 
-// TestFullWorkflow tests the complete MCP workflow:
+// TestFullWorkflow tests the complete MCP workflow against a server with
+// local tools and an upstream server behind the gateway:
 // 1. Initialize
-// 2. List tools
-// 3. Call echo tool
+// 2. List and call tools
+// 3. List and read resources
+// 4. List and get prompts
 func TestFullWorkflow(t *testing.T) {
-	// Step 1: Initialize
-	initReq := httptest.NewRequest(http.MethodGet, "/initialize", nil)
-	initW := httptest.NewRecorder()
-	handleInitialize(initW, initReq)
-
-	if initW.Code != http.StatusOK {
-		t.Fatalf("Initialize failed with status %d", initW.Code)
-	}
-
-	var initResponse InitializeResponse
-	if err := json.Unmarshal(initW.Body.Bytes(), &initResponse); err != nil {
-		t.Fatalf("Failed to unmarshal initialize response: %v", err)
+	upstream := &toolstest.FakeTransport{
+		Tools:     []transport.Tool{{Name: "ping", InputSchema: map[string]interface{}{"type": "object"}}},
+		Responses: map[string]*transport.ToolResponse{"ping": toolstest.Text("pong")},
+		Resources: []transport.Resource{{URI: "doc://readme", Name: "readme"}},
+		Contents: map[string][]transport.ResourceContent{
+			"doc://readme": {{URI: "doc://readme", Text: "Read me"}},
+		},
+		Prompts: []transport.Prompt{{Name: "greet", Arguments: []transport.PromptArgument{{Name: "who", Required: true}}}},
+		PromptResults: map[string]*transport.PromptResult{
+			"greet": {Messages: []transport.PromptMessage{{Role: "user", Content: transport.ContentItem{Type: "text", Text: "Hello"}}}},
+		},
 	}
-
-	if initResponse.ProtocolVersion != "2024-11-05" {
-		t.Errorf("Invalid protocol version: %s", initResponse.ProtocolVersion)
+	gw := gateway.NewGateway()
+	if err := gw.AddClient(toolstest.NewFakeClient("upstream", "up:", upstream)); err != nil {
+		t.Fatal(err)
 	}
+	registry := tools.NewRegistry()
+	registry.MustRegister(tools.NewTool(tools.Definition{
+		Name:        "echo",
+		InputSchema: map[string]interface{}{"type": "object"},
+	}, func(ctx context.Context, arguments map[string]interface{}) (*tools.ToolResult, error) {
+		return tools.TextResult(arguments["message"].(string)), nil
+	}))
+	client := toolstest.Connect(server.NewServerWithRegistry(gw, registry))
+	ctx := context.Background()
 
-	// Step 2: List tools
-	srv := NewServer(nil)
-	listReq := httptest.NewRequest(http.MethodGet, "/tools/list", nil)
-	listW := httptest.NewRecorder()
-	srv.handleToolsList(listW, listReq)
-
-	if listW.Code != http.StatusOK {
-		t.Fatalf("List tools failed with status %d", listW.Code)
+	// Step 1: Initialize
+	if err := client.Initialize(ctx, nil); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
 	}
 
-	var listResponse ToolsListResponse
-	if err := json.Unmarshal(listW.Body.Bytes(), &listResponse); err != nil {
-		t.Fatalf("Failed to unmarshal list response: %v", err)
+	// Step 2: List and call local and upstream tools
+	listed, err := client.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
 	}
-
-	if len(listResponse.Tools) == 0 {
-		t.Fatal("No tools returned")
+	var names []string
+	for _, tool := range listed {
+		names = append(names, tool.Name)
 	}
-
-	// Step 3: Call echo tool
-	callBody := ToolCallRequest{
-		Name: "echo",
-		Arguments: map[string]interface{}{
-			"message": "Integration test message",
-		},
+	if got := strings.Join(names, ","); !strings.HasPrefix(got, "echo,up:ping") {
+		t.Errorf("Expected the local and upstream tools, got %s", got)
 	}
+	toolstest.Run(t, client, []toolstest.Case{
+		{Name: "local", Tool: "echo", Arguments: map[string]interface{}{"message": "Integration test message"}, Want: "Integration test message"},
+		{Name: "upstream", Tool: "up:ping", Want: "pong"},
+		{Name: "unknown", Tool: "up:missing", WantError: "not found", WantCode: -32000},
+	})
 
-	bodyBytes, _ := json.Marshal(callBody)
-	callReq := httptest.NewRequest(http.MethodPost, "/tools/call", bytes.NewBuffer(bodyBytes))
-	callReq.Header.Set("Content-Type", "application/json")
-	callW := httptest.NewRecorder()
-	srv.handleToolsCall(callW, callReq)
-
-	if callW.Code != http.StatusOK {
-		t.Fatalf("Call tool failed with status %d", callW.Code)
+	// Step 3: Resources are listed with the upstream prefix and read by it
+	resources, err := client.ListResources(ctx)
+	if err != nil || len(resources) != 1 || resources[0].URI != "up:doc://readme" {
+		t.Fatalf("Expected the upstream resource, got %+v, %v", resources, err)
 	}
-
-	var callResponse ToolCallResponse
-	if err := json.Unmarshal(callW.Body.Bytes(), &callResponse); err != nil {
-		t.Fatalf("Failed to unmarshal call response: %v", err)
+	contents, err := client.ReadResource(ctx, "up:doc://readme")
+	if err != nil || len(contents) != 1 || contents[0].Text != "Read me" {
+		t.Errorf("Expected the resource contents, got %+v, %v", contents, err)
 	}
-
-	if len(callResponse.Content) != 1 {
-		t.Fatalf("Expected 1 content item, got %d", len(callResponse.Content))
+	if _, err := client.ReadResource(ctx, "up:doc://missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected a missing resource not to be found, got %v", err)
 	}
 
-	if callResponse.Content[0].Text != "Integration test message" {
-		t.Errorf("Expected 'Integration test message', got '%s'", callResponse.Content[0].Text)
+	// Step 4: Prompts likewise
+	prompts, err := client.ListPrompts(ctx)
+	if err != nil || len(prompts) != 1 || prompts[0].Name != "up:greet" || !prompts[0].Arguments[0].Required {
+		t.Fatalf("Expected the upstream prompt, got %+v, %v", prompts, err)
 	}
-}
-
-// TestMCPResponseFormat verifies that tool call responses follow MCP format
-func TestMCPResponseFormat(t *testing.T) {
-	callBody := ToolCallRequest{
-		Name: "echo",
-		Arguments: map[string]interface{}{
-			"message": "Format test",
-		},
+	prompt, err := client.GetPrompt(ctx, "up:greet", map[string]string{"who": "Ann"})
+	if err != nil || len(prompt.Messages) != 1 || prompt.Messages[0].Content.Text != "Hello" {
+		t.Errorf("Expected the rendered prompt, got %+v, %v", prompt, err)
 	}
-
-	srv := NewServer(nil)
-	bodyBytes, _ := json.Marshal(callBody)
-	req := httptest.NewRequest(http.MethodPost, "/tools/call", bytes.NewBuffer(bodyBytes))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-
-	srv.handleToolsCall(w, req)
-
-	if w.Code != http.StatusOK {
-		t.Fatalf("Expected status 200, got %d", w.Code)
+	if _, err := client.GetPrompt(ctx, "up:missing", nil); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected a missing prompt not to be found, got %v", err)
 	}
+}
 
-	var response ToolCallResponse
-	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
-		t.Fatalf("Failed to unmarshal response: %v", err)
-	}
+// TestWithoutGateway checks that resources and prompts are empty, rather
+// than failing, on a server without upstream servers
+func TestWithoutGateway(t *testing.T) {
+	client := toolstest.NewClient(tools.NewRegistry())
+	ctx := context.Background()
 
-	// Verify MCP format: content must be an array
-	if response.Content == nil {
-		t.Fatal("Content must not be nil")
+	if resources, err := client.ListResources(ctx); err != nil || resources == nil || len(resources) != 0 {
+		t.Errorf("Expected an empty resource list, got %#v, %v", resources, err)
 	}
-
-	if len(response.Content) == 0 {
-		t.Fatal("Content array must not be empty")
+	if prompts, err := client.ListPrompts(ctx); err != nil || prompts == nil || len(prompts) != 0 {
+		t.Errorf("Expected an empty prompt list, got %#v, %v", prompts, err)
 	}
-
-	// Verify content item structure
-	item := response.Content[0]
-	if item.Type != "text" {
-		t.Errorf("Content type must be 'text', got '%s'", item.Type)
+	if _, err := client.ReadResource(ctx, "doc://readme"); err == nil || !strings.Contains(err.Error(), "resource 'doc://readme' not found") {
+		t.Errorf("Expected the resource not to be found, got %v", err)
 	}
-
-	if item.Text == "" {
-		t.Error("Content text must not be empty")
+	if _, err := client.GetPrompt(ctx, "greet", nil); err == nil || !strings.Contains(err.Error(), "prompt 'greet' not found") {
+		t.Errorf("Expected the prompt not to be found, got %v", err)
 	}
 }
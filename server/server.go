@@ -3,11 +3,17 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"mcp-go/gateway"
+	"mcp-go/jsonschema"
+	"mcp-go/logging"
 	"mcp-go/tools"
+	"mcp-go/transport"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -56,15 +62,31 @@ type ToolsListResult struct {
 
 // ToolCallResult represents the result of tools/call method
 type ToolCallResult struct {
-	Content []ContentItem `json:"content"`
+	Content           []ContentItem `json:"content"`
+	StructuredContent interface{}   `json:"structuredContent,omitempty"`
+	IsError           bool          `json:"isError,omitempty"`
 }
 
-// ContentItem represents a content item in the tool call response
-type ContentItem struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+// ResourcesListResult represents the result of resources/list method
+type ResourcesListResult struct {
+	Resources []transport.Resource `json:"resources"`
 }
 
+// ResourceReadResult represents the result of resources/read method
+type ResourceReadResult struct {
+	Contents []transport.ResourceContent `json:"contents"`
+}
+
+// PromptsListResult represents the result of prompts/list method
+type PromptsListResult struct {
+	Prompts []transport.Prompt `json:"prompts"`
+}
+
+// ContentItem represents a content item in the tool call response, local
+// or proxied: text, an image with its base64 data and MIME type, or an
+// embedded resource
+type ContentItem = transport.ContentItem
+
 // Session represents a client session
 type Session struct {
 	ID        string
@@ -74,17 +96,27 @@ type Session struct {
 // Server holds the server state including gateway and sessions
 type Server struct {
 	gateway     *gateway.Gateway
+	registry    *tools.Registry // Local tools
 	sessions    map[string]*Session
 	bearerToken string // Bearer token for authentication (empty means no auth required)
 	mu          sync.RWMutex
+
+	streams   map[chan []byte]bool // Open GET SSE streams that receive notifications
+	streamsMu sync.Mutex
+
+	plugins   map[string]*tools.Plugin // Script tools declared in the configuration, by name
+	pluginsMu sync.Mutex
 }
 
 // NewServer creates a new server instance
 func NewServer(gw *gateway.Gateway) *Server {
 	return &Server{
 		gateway:     gw,
+		registry:    tools.DefaultRegistry,
 		sessions:    make(map[string]*Session),
 		bearerToken: "",
+		streams:     make(map[chan []byte]bool),
+		plugins:     make(map[string]*tools.Plugin),
 	}
 }
 
@@ -92,16 +124,24 @@ func NewServer(gw *gateway.Gateway) *Server {
 func NewServerWithAuth(gw *gateway.Gateway, bearerToken string) *Server {
 	return &Server{
 		gateway:     gw,
+		registry:    tools.DefaultRegistry,
 		sessions:    make(map[string]*Session),
 		bearerToken: bearerToken,
+		streams:     make(map[chan []byte]bool),
+		plugins:     make(map[string]*tools.Plugin),
 	}
 }
 
-// generateSessionID generates a unique session ID
-func (s *Server) generateSessionID() string {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// NewServerWithRegistry creates a server that serves the local tools of
+// registry instead of tools.DefaultRegistry, such as a test's own tools
+func NewServerWithRegistry(gw *gateway.Gateway, registry *tools.Registry) *Server {
+	srv := NewServer(gw)
+	srv.registry = registry
+	return srv
+}
 
+// generateSessionID generates a unique session ID. The caller holds s.mu.
+func (s *Server) generateSessionID() string {
 	timestamp := time.Now().UnixNano()
 	return fmt.Sprintf("session-%d", timestamp)
 }
@@ -127,6 +167,117 @@ func (s *Server) getOrCreateSession(sessionID string) *Session {
 	return session
 }
 
+// addStream registers an SSE stream for server-initiated notifications
+func (s *Server) addStream() chan []byte {
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+	stream := make(chan []byte, 16)
+	s.streams[stream] = true
+	return stream
+}
+
+// removeStream unregisters an SSE stream
+func (s *Server) removeStream(stream chan []byte) {
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+	delete(s.streams, stream)
+}
+
+// notify sends a JSON-RPC notification to every open SSE stream. Streams
+// that are not keeping up miss the notification rather than blocking.
+func (s *Server) notify(method string) {
+	data, err := json.Marshal(map[string]string{"jsonrpc": "2.0", "method": method})
+	if err != nil {
+		logging.Errorf("Error marshaling notification %s: %v", method, err)
+		return
+	}
+
+	s.streamsMu.Lock()
+	defer s.streamsMu.Unlock()
+	for stream := range s.streams {
+		select {
+		case stream <- data:
+		default:
+			logging.Warnf("Dropping notification %s for a slow SSE stream", method)
+		}
+	}
+}
+
+// notifyToolsChanged tells connected clients to re-fetch tools/list
+func (s *Server) notifyToolsChanged(change gateway.CatalogChange) {
+	s.notify("notifications/tools/list_changed")
+}
+
+// RegisterTool adds a local tool while the server runs and tells connected
+// clients to re-fetch tools/list. The name must not be taken by another
+// local tool.
+func (s *Server) RegisterTool(definition tools.Definition, handler tools.Handler) error {
+	return s.registerTool(tools.NewTool(definition, handler))
+}
+
+// registerTool adds a local tool and notifies connected clients
+func (s *Server) registerTool(tool tools.Tool) error {
+	if err := s.registry.Register(tool); err != nil {
+		return err
+	}
+	s.notify("notifications/tools/list_changed")
+	return nil
+}
+
+// UnregisterTool removes a local tool and tells connected clients to
+// re-fetch tools/list. It reports whether the tool was registered.
+func (s *Server) UnregisterTool(name string) bool {
+	if !s.registry.Unregister(name) {
+		return false
+	}
+	s.notify("notifications/tools/list_changed")
+	return true
+}
+
+// DeclarePlugin makes a script tool declared in the configuration available
+// to the admin API, which registers and unregisters it by name while the
+// server runs. The tool is registered right away when register is set.
+func (s *Server) DeclarePlugin(plugin *tools.Plugin, register bool) error {
+	name := plugin.Definition().Name
+	s.pluginsMu.Lock()
+	defer s.pluginsMu.Unlock()
+	if _, taken := s.plugins[name]; taken {
+		return fmt.Errorf("plugin %q is already declared", name)
+	}
+	if register {
+		if err := s.registerTool(plugin); err != nil {
+			return err
+		}
+	}
+	s.plugins[name] = plugin
+	return nil
+}
+
+// PluginStatus describes a declared script tool for the admin API
+type PluginStatus struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Registered  bool   `json:"registered"`
+}
+
+// pluginStatus reports whether the declared plugin is registered. The
+// caller holds s.pluginsMu.
+func (s *Server) pluginStatus(plugin *tools.Plugin) PluginStatus {
+	definition := plugin.Definition()
+	tool, ok := s.registry.Lookup(definition.Name)
+	return PluginStatus{
+		Name:        definition.Name,
+		Description: definition.Description,
+		Registered:  ok && tool == tools.Tool(plugin),
+	}
+}
+
+// ServeHTTP serves the MCP endpoint, so the server can be mounted on any
+// mux or called in-process
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handleMCP(w, r)
+}
+
 // setCORSHeaders sets CORS headers for all responses
 func setCORSHeaders(w http.ResponseWriter) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -197,20 +348,20 @@ func (s *Server) authenticate(r *http.Request) bool {
 	// Extract bearer token from Authorization header
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
-		log.Printf("Authentication failed: No Authorization header. Expected token: %s", s.bearerToken)
+		logging.Warnf("Authentication failed: No Authorization header. Expected token: %s", s.bearerToken)
 		return false
 	}
 
 	// Check if it starts with "Bearer "
 	if len(authHeader) < 7 || authHeader[:7] != "Bearer " {
-		log.Printf("Authentication failed: Invalid Authorization header format. Received: %s, Expected token: %s", authHeader, s.bearerToken)
+		logging.Warnf("Authentication failed: Invalid Authorization header format. Received: %s, Expected token: %s", authHeader, s.bearerToken)
 		return false
 	}
 
 	// Extract token
 	token := authHeader[7:]
 	if token != s.bearerToken {
-		log.Printf("Authentication failed: Token mismatch. Received: %s, Expected: %s", token, s.bearerToken)
+		logging.Warnf("Authentication failed: Token mismatch. Received: %s, Expected: %s", token, s.bearerToken)
 		return false
 	}
 
@@ -220,13 +371,13 @@ func (s *Server) authenticate(r *http.Request) bool {
 // handleMCP handles the main MCP endpoint (POST /mcp or GET /mcp for SSE)
 func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
 	// Log incoming requests for debugging
-	log.Printf("Incoming request: %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+	logging.Debugf("Incoming request: %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
 
 	// Handle CORS preflight requests
 	if r.Method == http.MethodOptions {
 		setCORSHeaders(w)
 		w.WriteHeader(http.StatusOK)
-		log.Printf("CORS preflight request handled successfully")
+		logging.Debugf("CORS preflight request handled successfully")
 		return
 	}
 
@@ -235,7 +386,7 @@ func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
 		setCORSHeaders(w)
 		w.Header().Set("WWW-Authenticate", "Bearer")
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		log.Printf("Authentication failed for request from %s", r.RemoteAddr)
+		logging.Warnf("Authentication failed for request from %s", r.RemoteAddr)
 		return
 	}
 
@@ -255,12 +406,17 @@ func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Cache-Control", "no-cache")
 			w.Header().Set("Connection", "keep-alive")
 			w.Header().Set("Mcp-Session-Id", session.ID)
-			log.Printf("SSE connection established for session %s", session.ID)
+			logging.Debugf("SSE connection established for session %s", session.ID)
+
+			// Server-initiated notifications are delivered on this stream,
+			// from before the client learns it is connected
+			notifications := s.addStream()
+			defer s.removeStream(notifications)
 
 			// Send initial connection confirmation
 			_, err := fmt.Fprintf(w, ": connected\n\n")
 			if err != nil {
-				log.Printf("Error writing SSE connection message: %v", err)
+				logging.Errorf("Error writing SSE connection message: %v", err)
 				return
 			}
 
@@ -282,13 +438,21 @@ func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
 				select {
 				case <-ctx.Done():
 					// Client disconnected
-					log.Printf("SSE connection closed for session %s", session.ID)
+					logging.Debugf("SSE connection closed for session %s", session.ID)
 					return
+				case data := <-notifications:
+					if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+						logging.Errorf("Error writing SSE notification: %v", err)
+						return
+					}
+					if flusher, ok := w.(http.Flusher); ok {
+						flusher.Flush()
+					}
 				case <-ticker.C:
 					// Send keep-alive comment
 					_, err := fmt.Fprintf(w, ": keep-alive\n\n")
 					if err != nil {
-						log.Printf("Error writing SSE keep-alive: %v", err)
+						logging.Errorf("Error writing SSE keep-alive: %v", err)
 						return
 					}
 					if flusher, ok := w.(http.Flusher); ok {
@@ -301,7 +465,7 @@ func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
 
 	if r.Method != http.MethodPost {
 		setCORSHeaders(w)
-		log.Printf("Received %s request to %s, expected POST or GET", r.Method, r.URL.Path)
+		logging.Warnf("Received %s request to %s, expected POST or GET", r.Method, r.URL.Path)
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
@@ -352,6 +516,13 @@ func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
 		acceptHeader == "*/*" ||
 		(acceptHeader != "" && !strings.Contains(acceptHeader, "application/json"))
 
+	// ?tags=internal,prod restricts the request to upstream servers carrying
+	// one of the tags
+	ctx := r.Context()
+	if tags := r.URL.Query().Get("tags"); tags != "" {
+		ctx = gateway.WithTags(ctx, strings.Split(tags, ",")...)
+	}
+
 	// Route to appropriate handler
 	var response JSONRPCResponse
 	var err error
@@ -361,12 +532,20 @@ func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
 		response, err = s.handleInitialize(req)
 	case "tools/list":
 		// tools/list doesn't require params, but accept empty params
-		log.Printf("Handling tools/list request (ID: %v)", req.ID)
-		response, err = s.handleToolsList(r.Context(), req)
+		logging.Debugf("Handling tools/list request (ID: %v)", req.ID)
+		response, err = s.handleToolsList(ctx, req)
 	case "tools/call":
-		response, err = s.handleToolsCall(r.Context(), req)
+		response, err = s.handleToolsCall(ctx, req)
+	case "resources/list":
+		response, err = s.handleResourcesList(ctx, req)
+	case "resources/read":
+		response, err = s.handleResourcesRead(ctx, req)
+	case "prompts/list":
+		response, err = s.handlePromptsList(ctx, req)
+	case "prompts/get":
+		response, err = s.handlePromptsGet(ctx, req)
 	default:
-		log.Printf("Unknown method requested: %s", req.Method)
+		logging.Warnf("Unknown method requested: %s", req.Method)
 		response = JSONRPCResponse{
 			JSONRPC: "2.0",
 			Error: &RPCError{
@@ -377,8 +556,22 @@ func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if err != nil {
-		log.Printf("Error handling %s request: %v", req.Method, err)
+	var invalid *jsonschema.ValidationError
+	if errors.As(err, &invalid) {
+		// Arguments that do not match the input schema of a tool are the
+		// client's mistake, reported with the fields at fault
+		logging.Warnf("Invalid params for %s request: %v", req.Method, err)
+		response = JSONRPCResponse{
+			JSONRPC: "2.0",
+			Error: &RPCError{
+				Code:    -32602,
+				Message: err.Error(),
+				Data:    invalid,
+			},
+			ID: req.ID,
+		}
+	} else if err != nil {
+		logging.Errorf("Error handling %s request: %v", req.Method, err)
 		response = JSONRPCResponse{
 			JSONRPC: "2.0",
 			Error: &RPCError{
@@ -399,19 +592,19 @@ func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
 
 	// Log response for debugging
 	if response.Error != nil {
-		log.Printf("Returning error response for %s: %d - %s", req.Method, response.Error.Code, response.Error.Message)
+		logging.Debugf("Returning error response for %s: %d - %s", req.Method, response.Error.Code, response.Error.Message)
 	} else {
-		log.Printf("Returning success response for %s (ID: %v)", req.Method, req.ID)
+		logging.Debugf("Returning success response for %s (ID: %v)", req.Method, req.ID)
 	}
 
 	// Write response
 	if useSSE {
 		if err := writeSSEResponse(w, response); err != nil {
-			log.Printf("Error writing SSE response: %v", err)
+			logging.Errorf("Error writing SSE response: %v", err)
 		}
 	} else {
 		if err := writeJSONResponse(w, response); err != nil {
-			log.Printf("Error writing JSON response: %v", err)
+			logging.Errorf("Error writing JSON response: %v", err)
 		}
 	}
 }
@@ -421,7 +614,9 @@ func (s *Server) handleInitialize(req JSONRPCRequest) (JSONRPCResponse, error) {
 	result := InitializeResult{
 		ProtocolVersion: "2024-11-05",
 		Capabilities: map[string]interface{}{
-			"tools": true,
+			"tools":     map[string]interface{}{"listChanged": true},
+			"resources": true,
+			"prompts":   true,
 		},
 		ServerInfo: ServerInfo{
 			Name:    "mcp-go",
@@ -440,25 +635,22 @@ func (s *Server) handleInitialize(req JSONRPCRequest) (JSONRPCResponse, error) {
 func (s *Server) handleToolsList(ctx context.Context, req JSONRPCRequest) (JSONRPCResponse, error) {
 	var allTools []interface{}
 
-	// Add local echo tool
-	echoTool := tools.GetEchoTool()
-	allTools = append(allTools, echoTool)
-	log.Printf("Added local tool: %s", echoTool.Name)
-
-	// Add local Google PSE tool (only if enabled)
-	googlePSETool := tools.GetGooglePSETool()
-	if tools.GetGooglePSEConfig() != nil {
-		allTools = append(allTools, googlePSETool)
-		log.Printf("Added local tool: %s", googlePSETool.Name)
+	// Add local tools, as the registry serves them
+	for _, tool := range s.registry.Tools() {
+		definition := tool.Definition()
+		if !s.toolDisabled(definition.Name) {
+			allTools = append(allTools, definition)
+			logging.Debugf("Added local tool: %s", definition.Name)
+		}
 	}
 
 	// Add tools from gateway (remote MCP servers)
 	if s.gateway != nil {
 		remoteTools, err := s.gateway.ListAllTools(ctx)
 		if err != nil {
-			log.Printf("Warning: Failed to list remote tools: %v", err)
+			logging.Warnf("Failed to list remote tools: %v", err)
 		} else {
-			log.Printf("Successfully fetched %d remote tools", len(remoteTools))
+			logging.Debugf("Successfully fetched %d remote tools", len(remoteTools))
 			// Convert transport.Tool to interface{} for JSON encoding
 			for _, tool := range remoteTools {
 				allTools = append(allTools, tool)
@@ -466,7 +658,7 @@ func (s *Server) handleToolsList(ctx context.Context, req JSONRPCRequest) (JSONR
 		}
 	}
 
-	log.Printf("Total tools to return: %d", len(allTools))
+	logging.Debugf("Total tools to return: %d", len(allTools))
 
 	result := ToolsListResult{
 		Tools: allTools,
@@ -479,6 +671,34 @@ func (s *Server) handleToolsList(ctx context.Context, req JSONRPCRequest) (JSONR
 	}, nil
 }
 
+// toolResultResponse wraps the result of a local tool in a response
+func toolResultResponse(id interface{}, result *tools.ToolResult) JSONRPCResponse {
+	callResult := ToolCallResult{
+		Content:           contentItems(result.Content),
+		StructuredContent: result.StructuredContent,
+		IsError:           result.IsError,
+	}
+	return JSONRPCResponse{
+		JSONRPC: "2.0",
+		Result:  callResult,
+		ID:      id,
+	}
+}
+
+// contentItems returns the content of a result, an empty list rather than
+// null when there is none
+func contentItems(items []ContentItem) []ContentItem {
+	if items == nil {
+		return []ContentItem{}
+	}
+	return items
+}
+
+// toolDisabled reports whether a tool was disabled in the gateway configuration
+func (s *Server) toolDisabled(name string) bool {
+	return s.gateway != nil && s.gateway.ToolDisabled(name)
+}
+
 // handleToolsCall handles the tools/call method
 func (s *Server) handleToolsCall(ctx context.Context, req JSONRPCRequest) (JSONRPCResponse, error) {
 	// Extract name and arguments from params
@@ -497,65 +717,30 @@ func (s *Server) handleToolsCall(ctx context.Context, req JSONRPCRequest) (JSONR
 		arguments = make(map[string]interface{})
 	}
 
-	// Handle local echo tool
-	if name == "echo" {
-		message, err := tools.CallEcho(arguments)
-		if err != nil {
-			return JSONRPCResponse{}, err
-		}
-
-		result := ToolCallResult{
-			Content: []ContentItem{
-				{
-					Type: "text",
-					Text: message,
-				},
-			},
-		}
-
-		return JSONRPCResponse{
-			JSONRPC: "2.0",
-			Result:  result,
-			ID:      req.ID,
-		}, nil
+	// Disabled tools are reported like unknown ones
+	if s.toolDisabled(name) {
+		return JSONRPCResponse{}, fmt.Errorf("tool '%s' not found", name)
 	}
 
-	// Handle local Google PSE tool
-	if name == "google_pse_search" {
-		result, err := tools.CallGooglePSE(arguments)
-		if err != nil {
-			return JSONRPCResponse{}, err
-		}
-
-		toolResult := ToolCallResult{
-			Content: []ContentItem{
-				{
-					Type: "text",
-					Text: result,
-				},
-			},
-		}
-
-		return JSONRPCResponse{
-			JSONRPC: "2.0",
-			Result:  toolResult,
-			ID:      req.ID,
-		}, nil
+	// Handle local tools
+	result, err := s.registry.Call(ctx, name, arguments)
+	if err == nil {
+		return toolResultResponse(req.ID, result), nil
+	}
+	if !errors.Is(err, tools.ErrToolNotFound) {
+		return JSONRPCResponse{}, err
 	}
 
 	// Try to handle via gateway (remote MCP servers)
 	if s.gateway != nil {
 		remoteResp, err := s.gateway.CallTool(ctx, name, arguments)
 		if err == nil {
-			// Convert transport.ToolResponse to ToolCallResult
+			// Convert transport.ToolResponse to ToolCallResult, keeping
+			// every content item
 			result := ToolCallResult{
-				Content: make([]ContentItem, len(remoteResp.Content)),
-			}
-			for i, item := range remoteResp.Content {
-				result.Content[i] = ContentItem{
-					Type: item.Type,
-					Text: item.Text,
-				}
+				Content:           contentItems(remoteResp.Content),
+				StructuredContent: remoteResp.StructuredContent,
+				IsError:           remoteResp.IsError,
 			}
 
 			return JSONRPCResponse{
@@ -574,6 +759,106 @@ func (s *Server) handleToolsCall(ctx context.Context, req JSONRPCRequest) (JSONR
 	return JSONRPCResponse{}, fmt.Errorf("tool '%s' not found", name)
 }
 
+// handleResourcesList handles the resources/list method
+func (s *Server) handleResourcesList(ctx context.Context, req JSONRPCRequest) (JSONRPCResponse, error) {
+	resources := []transport.Resource{}
+
+	// Resources come from the gateway (remote MCP servers)
+	if s.gateway != nil {
+		remoteResources, err := s.gateway.ListAllResources(ctx)
+		if err != nil {
+			logging.Warnf("Failed to list remote resources: %v", err)
+		} else {
+			resources = append(resources, remoteResources...)
+		}
+	}
+
+	return JSONRPCResponse{
+		JSONRPC: "2.0",
+		Result:  ResourcesListResult{Resources: resources},
+		ID:      req.ID,
+	}, nil
+}
+
+// handleResourcesRead handles the resources/read method
+func (s *Server) handleResourcesRead(ctx context.Context, req JSONRPCRequest) (JSONRPCResponse, error) {
+	uri, ok := req.Params["uri"].(string)
+	if !ok || uri == "" {
+		return JSONRPCResponse{}, fmt.Errorf("missing or invalid 'uri' in params")
+	}
+
+	if s.gateway == nil {
+		return JSONRPCResponse{}, fmt.Errorf("resource '%s' not found", uri)
+	}
+
+	contents, err := s.gateway.ReadResource(ctx, uri)
+	if err != nil {
+		return JSONRPCResponse{}, err
+	}
+
+	return JSONRPCResponse{
+		JSONRPC: "2.0",
+		Result:  ResourceReadResult{Contents: contents},
+		ID:      req.ID,
+	}, nil
+}
+
+// handlePromptsList handles the prompts/list method
+func (s *Server) handlePromptsList(ctx context.Context, req JSONRPCRequest) (JSONRPCResponse, error) {
+	prompts := []transport.Prompt{}
+
+	// Prompts come from the gateway (remote MCP servers)
+	if s.gateway != nil {
+		remotePrompts, err := s.gateway.ListAllPrompts(ctx)
+		if err != nil {
+			logging.Warnf("Failed to list remote prompts: %v", err)
+		} else {
+			prompts = append(prompts, remotePrompts...)
+		}
+	}
+
+	return JSONRPCResponse{
+		JSONRPC: "2.0",
+		Result:  PromptsListResult{Prompts: prompts},
+		ID:      req.ID,
+	}, nil
+}
+
+// handlePromptsGet handles the prompts/get method
+func (s *Server) handlePromptsGet(ctx context.Context, req JSONRPCRequest) (JSONRPCResponse, error) {
+	name, ok := req.Params["name"].(string)
+	if !ok || name == "" {
+		return JSONRPCResponse{}, fmt.Errorf("missing or invalid 'name' in params")
+	}
+
+	// Prompt arguments are string-valued per the MCP spec
+	arguments := make(map[string]string)
+	if args, ok := req.Params["arguments"].(map[string]interface{}); ok {
+		for key, value := range args {
+			if str, ok := value.(string); ok {
+				arguments[key] = str
+			} else {
+				arguments[key] = fmt.Sprint(value)
+			}
+		}
+	}
+
+	if s.gateway == nil {
+		return JSONRPCResponse{}, fmt.Errorf("prompt '%s' not found", name)
+	}
+
+	result, err := s.gateway.GetPrompt(ctx, name, arguments)
+	if err != nil {
+		return JSONRPCResponse{}, err
+	}
+
+	return JSONRPCResponse{
+		JSONRPC: "2.0",
+		Result:  result,
+		ID:      req.ID,
+	}, nil
+}
+
 // isNotFoundError checks if error is a "not found" error
 func isNotFoundError(err error) bool {
 	if err == nil {
@@ -625,28 +910,268 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleReady reports whether every eager and background upstream server has
+// been initialized (responds 503 until they have)
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+	w.Header().Set("Content-Type", "application/json")
+
+	ready := true
+	servers := []gateway.ClientReadiness{}
+	if s.gateway != nil {
+		ready, servers = s.gateway.Ready()
+	}
+
+	status := "ready"
+	if ready {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		status = "not ready"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  status,
+		"servers": servers,
+	})
+}
+
+// handleMetrics exposes per-upstream gateway call metrics in the Prometheus
+// text exposition format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if !s.authenticate(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.WriteHeader(http.StatusOK)
+
+	if s.gateway == nil {
+		return
+	}
+	stats := s.gateway.Stats()
+
+	fmt.Fprintln(w, "# HELP mcp_gateway_calls_total Tool calls routed to each upstream MCP server.")
+	fmt.Fprintln(w, "# TYPE mcp_gateway_calls_total counter")
+	for _, st := range stats {
+		fmt.Fprintf(w, "mcp_gateway_calls_total{server=%q} %d\n", st.Name, st.Calls)
+	}
+	fmt.Fprintln(w, "# HELP mcp_gateway_errors_total Tool calls that returned an error.")
+	fmt.Fprintln(w, "# TYPE mcp_gateway_errors_total counter")
+	for _, st := range stats {
+		fmt.Fprintf(w, "mcp_gateway_errors_total{server=%q} %d\n", st.Name, st.Errors)
+	}
+	fmt.Fprintln(w, "# HELP mcp_gateway_timeouts_total Tool calls that timed out.")
+	fmt.Fprintln(w, "# TYPE mcp_gateway_timeouts_total counter")
+	for _, st := range stats {
+		fmt.Fprintf(w, "mcp_gateway_timeouts_total{server=%q} %d\n", st.Name, st.Timeouts)
+	}
+	fmt.Fprintln(w, "# HELP mcp_gateway_retries_total Tool call attempts repeated by the retry policy.")
+	fmt.Fprintln(w, "# TYPE mcp_gateway_retries_total counter")
+	for _, st := range stats {
+		fmt.Fprintf(w, "mcp_gateway_retries_total{server=%q} %d\n", st.Name, st.Retries)
+	}
+	fmt.Fprintln(w, "# HELP mcp_gateway_call_duration_seconds Tool call latency per upstream MCP server.")
+	fmt.Fprintln(w, "# TYPE mcp_gateway_call_duration_seconds histogram")
+	for _, st := range stats {
+		for _, b := range st.Buckets {
+			fmt.Fprintf(w, "mcp_gateway_call_duration_seconds_bucket{server=%q,le=\"%g\"} %d\n", st.Name, b.UpperBound, b.Count)
+		}
+		fmt.Fprintf(w, "mcp_gateway_call_duration_seconds_bucket{server=%q,le=\"+Inf\"} %d\n", st.Name, st.Calls)
+		fmt.Fprintf(w, "mcp_gateway_call_duration_seconds_sum{server=%q} %g\n", st.Name, st.LatencySum)
+		fmt.Fprintf(w, "mcp_gateway_call_duration_seconds_count{server=%q} %d\n", st.Name, st.Calls)
+	}
+	fmt.Fprintln(w, "# HELP mcp_gateway_call_duration_p95_seconds 95th percentile of recent tool call latency.")
+	fmt.Fprintln(w, "# TYPE mcp_gateway_call_duration_p95_seconds gauge")
+	for _, st := range stats {
+		fmt.Fprintf(w, "mcp_gateway_call_duration_p95_seconds{server=%q} %g\n", st.Name, st.LatencyP95)
+	}
+}
+
+// handleGatewayStatus reports the state of every upstream MCP server
+func (s *Server) handleGatewayStatus(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authenticate(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	servers := []gateway.ClientStatus{}
+	if s.gateway != nil {
+		servers = s.gateway.Status()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"servers": servers,
+	})
+}
+
+// handleGatewayCalls returns recent tool calls routed by the gateway,
+// optionally filtered with ?tool=, ?server= and ?limit=
+func (s *Server) handleGatewayCalls(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authenticate(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := gateway.CallFilter{
+		Tool:   query.Get("tool"),
+		Server: query.Get("server"),
+		Limit:  100,
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = n
+	}
+
+	calls := []gateway.CallRecord{}
+	if s.gateway != nil {
+		calls = s.gateway.Calls(filter)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"calls": calls,
+	})
+}
+
+// handleGatewayTools is the admin API of the script tools declared in the
+// configuration: GET /gateway/tools lists them and whether each is
+// registered, POST /gateway/tools/{name} registers one and DELETE
+// /gateway/tools/{name} unregisters it. Clients are notified of the change.
+func (s *Server) handleGatewayTools(w http.ResponseWriter, r *http.Request) {
+	setCORSHeaders(w)
+	if !s.authenticate(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/gateway/tools"), "/")
+	s.pluginsMu.Lock()
+	defer s.pluginsMu.Unlock()
+
+	if name == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		statuses := []PluginStatus{}
+		for _, plugin := range s.plugins {
+			statuses = append(statuses, s.pluginStatus(plugin))
+		}
+		sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"tools": statuses,
+		})
+		return
+	}
+
+	plugin, ok := s.plugins[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("No script tool %q is declared in the configuration", name), http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		if err := s.registerTool(plugin); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		logging.Infof("Script tool %s registered through the admin API", name)
+	case http.MethodDelete:
+		if !s.pluginStatus(plugin).Registered {
+			http.Error(w, fmt.Sprintf("Script tool %q is not registered", name), http.StatusConflict)
+			return
+		}
+		s.UnregisterTool(name)
+		logging.Infof("Script tool %s unregistered through the admin API", name)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(s.pluginStatus(plugin))
+}
+
 // StartWithGatewayAndPortAndAuth starts the HTTP server with a gateway, custom port, and bearer token
 func StartWithGatewayAndPortAndAuth(gw *gateway.Gateway, port string, bearerToken string) {
 	var srv *Server
 	if bearerToken != "" {
 		srv = NewServerWithAuth(gw, bearerToken)
-		log.Println("Bearer token authentication enabled")
+		logging.Infof("Bearer token authentication enabled")
 	} else {
 		srv = NewServer(gw)
-		log.Println("Bearer token authentication disabled (no token configured)")
+		logging.Infof("Bearer token authentication disabled (no token configured)")
+	}
+
+	if err := srv.ListenAndServe(port); err != nil {
+		log.Fatalf("Server failed to start: %v\n", err)
+	}
+}
+
+// ListenAndServe serves the MCP endpoint and the health, metrics and
+// gateway endpoints on a port until the HTTP server fails. Embedders use it
+// instead of the Start functions to keep hold of the server, for example to
+// register tools at runtime.
+func (s *Server) ListenAndServe(port string) error {
+	srv, gw := s, s.gateway
+
+	// Push tool catalog changes found by the gateway to connected clients
+	if gw != nil {
+		gw.OnToolsChanged(srv.notifyToolsChanged)
 	}
 
 	// Health check endpoint (responds immediately, no auth required)
 	http.HandleFunc("/health", srv.handleHealth)
 
+	// Readiness endpoint (503 until eager/background upstreams are initialized)
+	http.HandleFunc("/readyz", srv.handleReady)
+
+	// Gateway call metrics (Prometheus text format)
+	http.HandleFunc("/metrics", srv.handleMetrics)
+
+	// Upstream MCP server status
+	http.HandleFunc("/gateway/status", srv.handleGatewayStatus)
+
+	// Recent tool calls routed by the gateway
+	http.HandleFunc("/gateway/calls", srv.handleGatewayCalls)
+
+	// Admin API of the script tools declared in the configuration
+	http.HandleFunc("/gateway/tools", srv.handleGatewayTools)
+	http.HandleFunc("/gateway/tools/", srv.handleGatewayTools)
+
 	// Single MCP endpoint
 	http.HandleFunc("/mcp", srv.handleMCP)
 
 	// Also support root path for compatibility
 	http.HandleFunc("/", srv.handleMCP)
 
-	// Ensure port starts with ":"
-	if port[0] != ':' {
+	// Ensure a bare port number starts with ":"
+	if !strings.Contains(port, ":") {
 		port = ":" + port
 	}
 
@@ -661,16 +1186,19 @@ func StartWithGatewayAndPortAndAuth(gw *gateway.Gateway, port string, bearerToke
 		IdleTimeout:       300 * time.Second, // Timeout for idle connections (5 minutes)
 	}
 
-	log.Printf("MCP Server starting on port %s\n", port)
-	log.Println("Endpoints available:")
-	log.Println("  GET  /health (Health check - responds immediately)")
-	log.Println("  POST /mcp (JSON-RPC 2.0 over SSE)")
-	log.Println("  POST / (JSON-RPC 2.0 over SSE)")
+	logging.Infof("MCP Server starting on port %s", port)
+	logging.Infof("Endpoints available:")
+	logging.Infof("  GET  /health (Health check - responds immediately)")
+	logging.Infof("  GET  /readyz (Readiness - waits for eager/background upstreams)")
+	logging.Infof("  GET  /metrics (Gateway call metrics)")
+	logging.Infof("  GET  /gateway/status (Upstream MCP server status)")
+	logging.Infof("  GET  /gateway/calls (Recent tool calls, filter with ?tool=&server=&limit=)")
+	logging.Infof("  GET  /gateway/tools, POST|DELETE /gateway/tools/{name} (Register declared script tools)")
+	logging.Infof("  POST /mcp (JSON-RPC 2.0 over SSE)")
+	logging.Infof("  POST / (JSON-RPC 2.0 over SSE)")
 	if gw != nil {
-		log.Println("Gateway enabled: Remote MCP servers will be accessible")
+		logging.Infof("Gateway enabled: Remote MCP servers will be accessible")
 	}
 
-	if err := server.ListenAndServe(); err != nil {
-		log.Fatalf("Server failed to start: %v\n", err)
-	}
+	return server.ListenAndServe()
 }
@@ -1,213 +1,330 @@
 package server
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"mcp-go/tools"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
-func TestHandleInitialize(t *testing.T) {
-	req := httptest.NewRequest(http.MethodGet, "/initialize", nil)
-	w := httptest.NewRecorder()
-
-	handleInitialize(w, req)
-
-	if w.Code != http.StatusOK {
-		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+// rpc sends a JSON-RPC request to srv and decodes the response. The body is
+// sent as it is when it is a string, so malformed requests can be tested.
+func rpc(t *testing.T, srv http.Handler, body interface{}) (*httptest.ResponseRecorder, JSONRPCResponse) {
+	t.Helper()
+	data, ok := body.(string)
+	if !ok {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = string(encoded)
 	}
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
 
-	var response InitializeResponse
+	var response JSONRPCResponse
 	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
-		t.Fatalf("Failed to unmarshal response: %v", err)
+		t.Fatalf("Failed to unmarshal response %q: %v", w.Body.String(), err)
 	}
+	return w, response
+}
 
-	if response.ProtocolVersion != "2024-11-05" {
-		t.Errorf("Expected protocol version '2024-11-05', got '%s'", response.ProtocolVersion)
-	}
+// request returns a JSON-RPC request of method
+func request(method string, params map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params}
+}
 
-	if tools, ok := response.Capabilities["tools"].(bool); !ok || !tools {
-		t.Errorf("Expected tools capability to be true, got %v", response.Capabilities["tools"])
+// decodeResult decodes the result of a successful response into result
+func decodeResult(t *testing.T, response JSONRPCResponse, result interface{}) {
+	t.Helper()
+	if response.Error != nil {
+		t.Fatalf("Unexpected error: %+v", response.Error)
 	}
+	data, err := json.Marshal(response.Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, result); err != nil {
+		t.Fatalf("Failed to decode result %s: %v", data, err)
+	}
+}
 
-	if response.ServerInfo.Name != "mcp-go" {
-		t.Errorf("Expected server name 'mcp-go', got '%s'", response.ServerInfo.Name)
+func TestHandleInitialize(t *testing.T) {
+	w, response := rpc(t, NewServer(nil), request("initialize", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if w.Header().Get("Mcp-Session-Id") == "" {
+		t.Error("Expected a session ID")
 	}
 
-	if response.ServerInfo.Version != "0.1.0" {
-		t.Errorf("Expected server version '0.1.0', got '%s'", response.ServerInfo.Version)
+	var result InitializeResult
+	decodeResult(t, response, &result)
+	if result.ProtocolVersion != "2024-11-05" {
+		t.Errorf("Expected protocol version '2024-11-05', got '%s'", result.ProtocolVersion)
+	}
+	if tools, ok := result.Capabilities["tools"].(map[string]interface{}); !ok || tools["listChanged"] != true {
+		t.Errorf("Expected the tools capability to announce listChanged, got %v", result.Capabilities["tools"])
+	}
+	if result.ServerInfo.Name != "mcp-go" || result.ServerInfo.Version != "0.1.0" {
+		t.Errorf("Unexpected server info %+v", result.ServerInfo)
 	}
 }
 
-func TestHandleInitializeMethodNotAllowed(t *testing.T) {
-	req := httptest.NewRequest(http.MethodPost, "/initialize", nil)
-	w := httptest.NewRecorder()
-
-	handleInitialize(w, req)
+func TestHandleMCPErrors(t *testing.T) {
+	srv := NewServer(nil)
 
+	req := httptest.NewRequest(http.MethodPut, "/mcp", nil)
+	w := httptest.NewRecorder()
+	srv.ServeHTTP(w, req)
 	if w.Code != http.StatusMethodNotAllowed {
 		t.Errorf("Expected status code %d, got %d", http.StatusMethodNotAllowed, w.Code)
 	}
+
+	for _, tc := range []struct {
+		name string
+		body interface{}
+		code int
+	}{
+		{"parse error", "invalid json", -32700},
+		{"invalid request", map[string]interface{}{"jsonrpc": "1.0", "id": 1, "method": "tools/list"}, -32600},
+		{"unknown method", request("tools/destroy", nil), -32601},
+		{"missing name", request("tools/call", map[string]interface{}{}), -32000},
+		{"missing uri", request("resources/read", map[string]interface{}{}), -32000},
+	} {
+		if _, response := rpc(t, srv, tc.body); response.Error == nil || response.Error.Code != tc.code {
+			t.Errorf("%s: expected error code %d, got %+v", tc.name, tc.code, response.Error)
+		}
+	}
 }
 
-func TestHandleToolsList(t *testing.T) {
+func TestHandleToolsListAndCallEcho(t *testing.T) {
 	srv := NewServer(nil) // No gateway for this test
-	req := httptest.NewRequest(http.MethodGet, "/tools/list", nil)
-	w := httptest.NewRecorder()
 
-	srv.handleToolsList(w, req)
-
-	if w.Code != http.StatusOK {
-		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	_, response := rpc(t, srv, request("tools/list", nil))
+	var list struct {
+		Tools []tools.Definition `json:"tools"`
 	}
-
-	var response ToolsListResponse
-	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
-		t.Fatalf("Failed to unmarshal response: %v", err)
-	}
-
-	if len(response.Tools) < 1 {
-		t.Fatalf("Expected at least 1 tool, got %d", len(response.Tools))
-	}
-
-	// Find echo tool in the list
-	foundEcho := false
-	var echoToolMap map[string]interface{}
-	for _, toolInterface := range response.Tools {
-		toolMap, ok := toolInterface.(map[string]interface{})
-		if !ok {
-			// Try to unmarshal as EchoTool
-			toolJSON, _ := json.Marshal(toolInterface)
-			var echoTool tools.EchoTool
-			if err := json.Unmarshal(toolJSON, &echoTool); err == nil {
-				if echoTool.Name == "echo" {
-					foundEcho = true
-					// Convert back to map for validation
-					echoJSON, _ := json.Marshal(echoTool)
-					json.Unmarshal(echoJSON, &echoToolMap)
-					break
-				}
-				continue
-			}
-			continue
-		}
-
-		if name, ok := toolMap["name"].(string); ok && name == "echo" {
-			foundEcho = true
-			echoToolMap = toolMap
-			break
+	decodeResult(t, response, &list)
+	var echo *tools.Definition
+	for i := range list.Tools {
+		if list.Tools[i].Name == "echo" {
+			echo = &list.Tools[i]
 		}
 	}
-
-	if !foundEcho {
+	if echo == nil {
 		t.Fatal("Expected to find 'echo' tool in the tools list")
 	}
+	if echo.Description != "Echo back the provided message" || echo.InputSchema["type"] != "object" {
+		t.Errorf("Unexpected echo definition %+v", echo)
+	}
 
-	if echoToolMap["description"] != "Echo back the provided message" {
-		t.Errorf("Expected description 'Echo back the provided message', got '%v'", echoToolMap["description"])
+	_, response = rpc(t, srv, request("tools/call", map[string]interface{}{
+		"name":      "echo",
+		"arguments": map[string]interface{}{"message": "Hello, World!"},
+	}))
+	var result ToolCallResult
+	decodeResult(t, response, &result)
+	if len(result.Content) != 1 || result.Content[0].Type != "text" || result.Content[0].Text != "Hello, World!" {
+		t.Errorf("Unexpected result %+v", result)
 	}
 
-	// Verify input schema
-	if inputSchema, ok := echoToolMap["inputSchema"].(map[string]interface{}); ok {
-		if schemaType, ok := inputSchema["type"].(string); !ok || schemaType != "object" {
-			t.Errorf("Expected input schema type 'object', got %v", inputSchema["type"])
-		}
+	w, response := rpc(t, srv, request("tools/call", map[string]interface{}{"name": "unknown-tool"}))
+	if w.Code != http.StatusInternalServerError || response.Error == nil || response.Error.Message != "tool 'unknown-tool' not found" {
+		t.Errorf("Expected unknown-tool not to be found, got %d %+v", w.Code, response.Error)
 	}
 }
 
-func TestHandleToolsListMethodNotAllowed(t *testing.T) {
-	srv := NewServer(nil)
-	req := httptest.NewRequest(http.MethodPost, "/tools/list", nil)
+func TestSSEResponse(t *testing.T) {
+	body, _ := json.Marshal(request("initialize", nil))
+	req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(body))
 	w := httptest.NewRecorder()
+	NewServer(nil).ServeHTTP(w, req)
 
-	srv.handleToolsList(w, req)
-
-	if w.Code != http.StatusMethodNotAllowed {
-		t.Errorf("Expected status code %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	if w.Header().Get("Content-Type") != "text/event-stream" || !strings.HasPrefix(w.Body.String(), "data: {") {
+		t.Errorf("Expected an SSE response, got %q: %q", w.Header().Get("Content-Type"), w.Body.String())
 	}
 }
 
-func TestHandleToolsCallEcho(t *testing.T) {
-	srv := NewServer(nil)
-	requestBody := ToolCallRequest{
-		Name: "echo",
-		Arguments: map[string]interface{}{
-			"message": "Hello, World!",
-		},
+func TestAuthentication(t *testing.T) {
+	srv := NewServerWithAuth(nil, "secret")
+	for _, tc := range []struct {
+		header string
+		code   int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"Bearer secret", http.StatusOK},
+	} {
+		body, _ := json.Marshal(request("initialize", nil))
+		req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(body))
+		req.Header.Set("Accept", "application/json")
+		if tc.header != "" {
+			req.Header.Set("Authorization", tc.header)
+		}
+		w := httptest.NewRecorder()
+		srv.ServeHTTP(w, req)
+		if w.Code != tc.code {
+			t.Errorf("%q: expected status code %d, got %d", tc.header, tc.code, w.Code)
+		}
 	}
+}
 
-	body, _ := json.Marshal(requestBody)
-	req := httptest.NewRequest(http.MethodPost, "/tools/call", bytes.NewBuffer(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
+// greetDefinition is a tool registered at runtime by the tests
+var greetDefinition = tools.Definition{
+	Name:        "greet",
+	Description: "Greet someone",
+	InputSchema: map[string]interface{}{"type": "object"},
+}
 
-	srv.handleToolsCall(w, req)
+func greet(ctx context.Context, arguments map[string]interface{}) (*tools.ToolResult, error) {
+	return tools.TextResult("Hello"), nil
+}
 
-	if w.Code != http.StatusOK {
-		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
-	}
+func TestRegisterTool(t *testing.T) {
+	srv := NewServerWithRegistry(nil, tools.NewRegistry())
 
-	var response ToolCallResponse
-	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
-		t.Fatalf("Failed to unmarshal response: %v", err)
+	if err := srv.RegisterTool(greetDefinition, greet); err != nil {
+		t.Fatalf("RegisterTool failed: %v", err)
 	}
-
-	if len(response.Content) != 1 {
-		t.Fatalf("Expected 1 content item, got %d", len(response.Content))
+	if err := srv.RegisterTool(greetDefinition, greet); err == nil {
+		t.Error("Expected registering greet twice to fail")
 	}
 
-	content := response.Content[0]
-	if content.Type != "text" {
-		t.Errorf("Expected content type 'text', got '%s'", content.Type)
+	_, response := rpc(t, srv, request("tools/list", nil))
+	var list struct {
+		Tools []tools.Definition `json:"tools"`
+	}
+	decodeResult(t, response, &list)
+	if len(list.Tools) != 1 || list.Tools[0].Name != "greet" {
+		t.Errorf("Expected greet to be listed, got %+v", list.Tools)
+	}
+	_, response = rpc(t, srv, request("tools/call", map[string]interface{}{"name": "greet"}))
+	var result ToolCallResult
+	decodeResult(t, response, &result)
+	if len(result.Content) != 1 || result.Content[0].Text != "Hello" {
+		t.Errorf("Unexpected result %+v", result)
 	}
 
-	if content.Text != "Hello, World!" {
-		t.Errorf("Expected text 'Hello, World!', got '%s'", content.Text)
+	if !srv.UnregisterTool("greet") || srv.UnregisterTool("greet") {
+		t.Error("Expected greet to be unregistered exactly once")
+	}
+	if _, response := rpc(t, srv, request("tools/call", map[string]interface{}{"name": "greet"})); response.Error == nil {
+		t.Error("Expected the unregistered tool not to be found")
 	}
 }
 
-func TestHandleToolsCallUnknownTool(t *testing.T) {
-	srv := NewServer(nil)
-	requestBody := ToolCallRequest{
-		Name:      "unknown-tool",
-		Arguments: map[string]interface{}{},
-	}
+func TestToolsListChangedNotification(t *testing.T) {
+	srv := NewServerWithRegistry(nil, tools.NewRegistry())
+	httpServer := httptest.NewServer(srv)
+	defer httpServer.Close()
 
-	body, _ := json.Marshal(requestBody)
-	req := httptest.NewRequest(http.MethodPost, "/tools/call", bytes.NewBuffer(body))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, httpServer.URL+"/mcp", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open the SSE stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" || resp.Header.Get("Mcp-Session-Id") == "" {
+		t.Fatalf("Unexpected SSE headers %v", resp.Header)
+	}
 
-	srv.handleToolsCall(w, req)
+	lines := bufio.NewScanner(resp.Body)
+	if !lines.Scan() || lines.Text() != ": connected" {
+		t.Fatalf("Expected the connection comment, got %q", lines.Text())
+	}
 
-	if w.Code != http.StatusNotFound {
-		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	// Registering and unregistering each notify the open stream
+	if err := srv.RegisterTool(greetDefinition, greet); err != nil {
+		t.Fatal(err)
+	}
+	srv.UnregisterTool("greet")
+	for i := 0; i < 2; i++ {
+		var data string
+		for data == "" && lines.Scan() {
+			data = strings.TrimPrefix(lines.Text(), "data: ")
+		}
+		var notification JSONRPCRequest
+		if err := json.Unmarshal([]byte(data), &notification); err != nil || notification.Method != "notifications/tools/list_changed" {
+			t.Fatalf("Expected a list_changed notification, got %q (%v)", data, err)
+		}
 	}
 }
 
-func TestHandleToolsCallInvalidJSON(t *testing.T) {
-	srv := NewServer(nil)
-	req := httptest.NewRequest(http.MethodPost, "/tools/call", bytes.NewBufferString("invalid json"))
-	req.Header.Set("Content-Type", "application/json")
-	w := httptest.NewRecorder()
-
-	srv.handleToolsCall(w, req)
+func TestGatewayToolsAdminAPI(t *testing.T) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plugin, err := tools.NewPlugin(tools.PluginConfig{Name: "weather", Description: "Weather", Command: executable})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServerWithRegistry(nil, tools.NewRegistry())
+	srv.bearerToken = "secret"
+	if err := srv.DeclarePlugin(plugin, false); err != nil {
+		t.Fatalf("DeclarePlugin failed: %v", err)
+	}
+	if err := srv.DeclarePlugin(plugin, false); err == nil {
+		t.Error("Expected declaring weather twice to fail")
+	}
 
-	if w.Code != http.StatusBadRequest {
-		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, w.Code)
+	admin := func(method, path, token string) (int, string) {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		srv.handleGatewayTools(w, req)
+		return w.Code, strings.TrimSpace(w.Body.String())
+	}
+	registered := func() bool {
+		_, ok := srv.registry.Lookup("weather")
+		return ok
 	}
-}
 
-func TestHandleToolsCallMethodNotAllowed(t *testing.T) {
-	srv := NewServer(nil)
-	req := httptest.NewRequest(http.MethodGet, "/tools/call", nil)
-	w := httptest.NewRecorder()
+	if code, _ := admin(http.MethodGet, "/gateway/tools", ""); code != http.StatusUnauthorized {
+		t.Errorf("Expected the admin API to require the token, got %d", code)
+	}
+	if code, body := admin(http.MethodGet, "/gateway/tools", "secret"); code != http.StatusOK || body != `{"tools":[{"name":"weather","description":"Weather","registered":false}]}` {
+		t.Errorf("Unexpected listing %d %s", code, body)
+	}
 
-	srv.handleToolsCall(w, req)
+	if code, body := admin(http.MethodPost, "/gateway/tools/weather", "secret"); code != http.StatusOK || !strings.Contains(body, `"registered":true`) || !registered() {
+		t.Errorf("Expected weather to be registered, got %d %s", code, body)
+	}
+	if code, _ := admin(http.MethodPost, "/gateway/tools/weather", "secret"); code != http.StatusConflict {
+		t.Errorf("Expected registering weather twice to conflict, got %d", code)
+	}
+	if code, body := admin(http.MethodDelete, "/gateway/tools/weather", "secret"); code != http.StatusOK || !strings.Contains(body, `"registered":false`) || registered() {
+		t.Errorf("Expected weather to be unregistered, got %d %s", code, body)
+	}
+	if code, _ := admin(http.MethodDelete, "/gateway/tools/weather", "secret"); code != http.StatusConflict {
+		t.Errorf("Expected unregistering weather twice to conflict, got %d", code)
+	}
 
-	if w.Code != http.StatusMethodNotAllowed {
-		t.Errorf("Expected status code %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	// Only declared script tools can be managed
+	if code, _ := admin(http.MethodDelete, "/gateway/tools/echo", "secret"); code != http.StatusNotFound {
+		t.Errorf("Expected an undeclared tool to be unknown, got %d", code)
+	}
+	if code, _ := admin(http.MethodPut, "/gateway/tools/weather", "secret"); code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected PUT to be rejected, got %d", code)
 	}
 }
@@ -0,0 +1,148 @@
+package tools
+
+// toolStruct is the layout shared by the definitions the Get*Tool functions
+// return
+type toolStruct interface {
+	~struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description"`
+		InputSchema map[string]interface{} `json:"inputSchema"`
+	}
+}
+
+// describe returns the definition of a built-in tool with its output schema
+// and annotations
+func describe[T toolStruct](tool T) Definition {
+	fields := struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description"`
+		InputSchema map[string]interface{} `json:"inputSchema"`
+	}(tool)
+	return Definition{
+		Name:         fields.Name,
+		Description:  fields.Description,
+		InputSchema:  fields.InputSchema,
+		OutputSchema: builtinOutputSchemas[fields.Name],
+		Annotations:  builtinAnnotations[fields.Name],
+	}
+}
+
+// inspects annotates a tool that does not change its environment
+func inspects(title string, openWorld bool) *Annotations {
+	return &Annotations{Title: title, ReadOnlyHint: hint(true), OpenWorldHint: hint(openWorld)}
+}
+
+// changes annotates a tool that changes its environment
+func changes(title string, destructive, idempotent, openWorld bool) *Annotations {
+	return &Annotations{
+		Title:           title,
+		ReadOnlyHint:    hint(false),
+		DestructiveHint: hint(destructive),
+		IdempotentHint:  hint(idempotent),
+		OpenWorldHint:   hint(openWorld),
+	}
+}
+
+func hint(value bool) *bool {
+	return &value
+}
+
+// builtinAnnotations are the annotations of the built-in and filesystem
+// tools. Tools reaching the web or public services are open world; tools
+// working on configured repositories, clusters, buckets or files are not.
+var builtinAnnotations = map[string]*Annotations{
+	"echo":              inspects("Echo", false),
+	"google_pse_search": inspects("Google Search", true),
+	"brave_search":      inspects("Brave Search", true),
+	"searxng_search":    inspects("SearXNG Search", true),
+	"duckduckgo_search": inspects("DuckDuckGo Search", true),
+	// Any method may be sent
+	"http_request": changes("HTTP Request", true, false, true),
+	"fetch_page":   inspects("Fetch Page", true),
+	"run_command":  changes("Run Command", true, false, true),
+
+	"git_status": inspects("Git Status", false),
+	"git_log":    inspects("Git Log", false),
+	"git_diff":   inspects("Git Diff", false),
+	"git_show":   inspects("Git Show", false),
+	"git_commit": changes("Git Commit", false, false, false),
+	"git_branch": changes("Git Branch", false, false, false),
+
+	// Statements may write unless the connection is read-only
+	"sql_query":         changes("SQL Query", true, false, false),
+	"wikipedia_search":  inspects("Wikipedia Search", true),
+	"wikipedia_summary": inspects("Wikipedia Summary", true),
+	// A document added again under its id replaces the first
+	"kb_add_document": changes("Add Knowledge Base Document", true, true, false),
+	"kb_search":       inspects("Search Knowledge Base", false),
+
+	"s3_list":          inspects("List S3 Objects", false),
+	"s3_get_object":    inspects("Get S3 Object", false),
+	"s3_put_object":    changes("Put S3 Object", true, true, false),
+	"s3_delete_object": changes("Delete S3 Object", true, true, false),
+
+	"docker_ps":      inspects("List Docker Containers", false),
+	"docker_logs":    inspects("Docker Logs", false),
+	"docker_inspect": inspects("Inspect Docker Container", false),
+	"docker_exec":    changes("Docker Exec", true, false, false),
+	"docker_restart": changes("Restart Docker Container", false, false, false),
+
+	"k8s_list":     inspects("List Kubernetes Resources", false),
+	"k8s_get":      inspects("Get Kubernetes Resource", false),
+	"k8s_describe": inspects("Describe Kubernetes Resource", false),
+	"k8s_logs":     inspects("Kubernetes Logs", false),
+	"k8s_apply":    changes("Apply Kubernetes Manifest", true, true, false),
+
+	"send_email":            changes("Send Email", false, false, true),
+	"slack_post_message":    changes("Post Slack Message", false, false, true),
+	"slack_read_channel":    inspects("Read Slack Channel", true),
+	"telegram_send_message": changes("Send Telegram Message", false, false, true),
+	"telegram_get_updates":  inspects("Get Telegram Updates", true),
+
+	"github_search_repositories": inspects("Search GitHub Repositories", true),
+	"github_search_issues":       inspects("Search GitHub Issues", true),
+	"github_get_file":            inspects("Get GitHub File", true),
+	"github_list_pull_requests":  inspects("List GitHub Pull Requests", true),
+	"github_create_issue":        changes("Create GitHub Issue", false, false, true),
+	"github_create_comment":      changes("Comment on GitHub", false, false, true),
+	"jira_search":                inspects("Search Jira", false),
+	"jira_get_issue":             inspects("Get Jira Issue", false),
+	"jira_create_issue":          changes("Create Jira Issue", false, false, false),
+	"jira_add_comment":           changes("Comment on Jira Issue", false, false, false),
+
+	"ocr_image":           inspects("OCR Image", false),
+	"browser_navigate":    inspects("Browser Navigate", true),
+	"browser_get_content": inspects("Browser Page Content", true),
+	"browser_screenshot":  inspects("Browser Screenshot", true),
+	"read_csv":            inspects("Read CSV", false),
+	"read_xlsx":           inspects("Read XLSX", false),
+	"json_query":          inspects("JSON Query", false),
+	"dns_lookup":          inspects("DNS Lookup", true),
+	"whois":               inspects("WHOIS", true),
+	"crawl_site":          inspects("Crawl Site", true),
+	"youtube_transcript":  inspects("YouTube Transcript", true),
+
+	"read_file":           inspects("Read File", false),
+	"read_multiple_files": inspects("Read Multiple Files", false),
+	"write_file":          changes("Write File", true, true, false),
+	"list_directory":      inspects("List Directory", false),
+	"create_directory":    changes("Create Directory", false, true, false),
+	"delete_file":         changes("Delete File", true, true, false),
+	"move_file":           changes("Move File", true, false, false),
+	"copy_file":           changes("Copy File", true, true, false),
+	"search_files":        inspects("Search Files", false),
+	"find_files":          inspects("Find Files", false),
+	"get_file_info":       inspects("File Info", false),
+	"edit_file":           changes("Edit File", true, false, false),
+	"append_file":         changes("Append to File", false, false, false),
+	"directory_tree":      inspects("Directory Tree", false),
+	"hash_file":           inspects("Hash File", false),
+	"create_archive":      changes("Create Archive", true, true, false),
+	"extract_archive":     changes("Extract Archive", true, true, false),
+	"diff_files":          inspects("Diff Files", false),
+	"set_permissions":     changes("Set Permissions", false, true, false),
+	// Watches only change the state of the server
+	"watch_path":      inspects("Watch Path", false),
+	"unwatch_path":    inspects("Unwatch Path", false),
+	"get_file_events": inspects("Get File Events", false),
+}
@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// braveSearchURL is the Brave Search web search endpoint
+var braveSearchURL = "https://api.search.brave.com/res/v1/web/search"
+
+// BraveSearchTool represents the Brave Search tool definition
+type BraveSearchTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// GetBraveSearchTool returns the Brave Search tool definition
+func GetBraveSearchTool() BraveSearchTool {
+	return BraveSearchTool{
+		Name:        "brave_search",
+		Description: "Search the web using the Brave Search API",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "The search query",
+				},
+				"count": map[string]interface{}{
+					"type":        "integer",
+					"description": "Number of results to return (1-20, default: 10)",
+					"default":     10,
+					"minimum":     1,
+					"maximum":     20,
+				},
+				"offset": map[string]interface{}{
+					"type":        "integer",
+					"description": "Page of results to return, starting at 0 (0-9, default: 0)",
+					"default":     0,
+					"minimum":     0,
+					"maximum":     9,
+				},
+			},
+			"required": []string{"query"},
+		},
+	}
+}
+
+// braveSearchResponse is the part of the Brave Search API response the tool
+// uses
+type braveSearchResponse struct {
+	Web struct {
+		Results []struct {
+			Title       string `json:"title"`
+			URL         string `json:"url"`
+			Description string `json:"description"`
+		} `json:"results"`
+	} `json:"web"`
+}
+
+var braveSearchAPIKey string
+
+// SetBraveSearchConfig enables the Brave Search tool with an API key
+func SetBraveSearchConfig(apiKey string) {
+	braveSearchAPIKey = apiKey
+}
+
+// BraveSearchConfigured reports whether the Brave Search tool has an API key
+func BraveSearchConfigured() bool {
+	return braveSearchAPIKey != ""
+}
+
+// CallBraveSearch executes a Brave web search
+func CallBraveSearch(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+	if braveSearchAPIKey == "" {
+		return nil, fmt.Errorf("Brave Search not configured. Please set an API key")
+	}
+
+	args := struct {
+		Query  string `arg:"query,required"`
+		Count  int    `arg:"count"`
+		Offset int    `arg:"offset"`
+	}{Count: 10}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	count := args.Count
+	if count < 1 || count > 20 {
+		count = 10
+	}
+	offset := min(max(args.Offset, 0), 9)
+
+	params := url.Values{}
+	params.Set("q", args.Query)
+	params.Set("count", strconv.Itoa(count))
+	params.Set("offset", strconv.Itoa(offset))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", braveSearchURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Subscription-Token", braveSearchAPIKey)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute search: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Brave Search API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var apiResp braveSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	// Brave highlights matches in titles and snippets with <strong>
+	results := make([]SearchResult, len(apiResp.Web.Results))
+	for i, item := range apiResp.Web.Results {
+		results[i] = SearchResult{
+			Title:   stripHTMLTags(item.Title),
+			Link:    item.URL,
+			Snippet: stripHTMLTags(item.Description),
+		}
+	}
+	return TextResult(formatSearchResults("", results)), nil
+}
@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCallBraveSearch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Subscription-Token") != "test-key" || r.URL.Query().Get("q") != "golang" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"web":{"results":[{"title":"The <strong>Go</strong> Programming Language","url":"https://go.dev/","description":"Build &amp; ship <strong>fast</strong>"}]}}`))
+	}))
+	defer server.Close()
+
+	defer func(previous string) { braveSearchURL = previous }(braveSearchURL)
+	braveSearchURL = server.URL
+	SetBraveSearchConfig("test-key")
+	defer SetBraveSearchConfig("")
+
+	result, err := resultText(CallBraveSearch(context.Background(), map[string]interface{}{"query": "golang", "count": float64(5)}))
+	if err != nil {
+		t.Fatalf("CallBraveSearch failed: %v", err)
+	}
+	want := "Found 1 results:\n\n1. The Go Programming Language\n   URL: https://go.dev/\n   Build & ship fast\n\n"
+	if result != want {
+		t.Errorf("Unexpected result:\n%q\nwant:\n%q", result, want)
+	}
+}
+
+func TestCallBraveSearchMissingConfig(t *testing.T) {
+	SetBraveSearchConfig("")
+	if _, err := CallBraveSearch(context.Background(), map[string]interface{}{"query": "golang"}); err == nil {
+		t.Error("Expected an error without an API key")
+	}
+}
@@ -0,0 +1,552 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-go/cdp"
+)
+
+// Defaults for the browser tools when the configuration leaves them unset
+const (
+	DefaultBrowserTimeout     = 30 * time.Second
+	DefaultBrowserMaxPages    = 5
+	DefaultBrowserIdleTimeout = 10 * time.Minute
+	DefaultBrowserMaxLength   = 20000
+)
+
+// BrowserTool represents a browser tool definition
+type BrowserTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+var (
+	browserPageIDProperty = map[string]interface{}{
+		"type":        "string",
+		"description": "ID of a page opened by browser_navigate",
+	}
+	browserURLProperty = map[string]interface{}{
+		"type":        "string",
+		"description": "The http or https URL to open in a new page, instead of page_id",
+	}
+)
+
+// GetBrowserTools returns the browser tool definitions
+func GetBrowserTools() []BrowserTool {
+	return []BrowserTool{
+		{
+			Name:        "browser_navigate",
+			Description: "Load a URL in a headless browser, running its JavaScript, and return the page ID, title and final URL. Use it for pages that fetch_page cannot render; pass the page ID to the other browser tools.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "The http or https URL to load",
+					},
+					"page_id": map[string]interface{}{
+						"type":        "string",
+						"description": "ID of a page to navigate instead of opening a new one",
+					},
+					"wait_for": map[string]interface{}{
+						"type":        "string",
+						"description": "CSS selector of an element to wait for after the page loads, for content rendered late",
+					},
+				},
+				"required": []string{"url"},
+			},
+		},
+		{
+			Name:        "browser_get_content",
+			Description: "Return the rendered content of a browser page, or of one element of it, as markdown, plain text or HTML",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"page_id": browserPageIDProperty,
+					"url":     browserURLProperty,
+					"selector": map[string]interface{}{
+						"type":        "string",
+						"description": "CSS selector of the element to return (default: the main content of the page)",
+					},
+					"format": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"markdown", "text", "html"},
+						"description": "Output format (default: markdown)",
+						"default":     "markdown",
+					},
+					"max_length": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of characters to return, at most the configured limit",
+						"minimum":     1,
+					},
+					"start_index": map[string]interface{}{
+						"type":        "integer",
+						"description": "Character offset to start from, to continue a truncated page (default: 0)",
+						"default":     0,
+						"minimum":     0,
+					},
+				},
+			},
+		},
+		{
+			Name:        "browser_screenshot",
+			Description: "Take a PNG screenshot of a browser page",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"page_id": browserPageIDProperty,
+					"url":     browserURLProperty,
+					"full_page": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Capture the whole page instead of the 1280x800 viewport (default: false)",
+						"default":     false,
+					},
+				},
+			},
+		},
+	}
+}
+
+// IsBrowserTool reports whether name is one of the browser tools
+func IsBrowserTool(name string) bool {
+	for _, tool := range GetBrowserTools() {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// BrowserConfig holds the configuration for the browser tools
+type BrowserConfig struct {
+	ChromePath string   // Empty = the first Chrome or Chromium found in PATH
+	ChromeArgs []string // Extra command line flags, such as --no-sandbox
+	// RemoteURL connects to a running browser, by its ws:// DevTools URL or
+	// http:// debugging address, instead of launching one
+	RemoteURL string
+	// AllowedDomains are the hosts pages and frames may be loaded from, with
+	// the patterns of http_request's allowed hosts
+	AllowedDomains []string
+	MaxPages       int // Pages kept open; the least recently used is closed beyond it
+	IdleTimeout    time.Duration
+	Timeout        time.Duration
+	MaxLength      int
+}
+
+var browserConfig *BrowserConfig
+
+// browserPage is an open page with the time it was last used
+type browserPage struct {
+	id       string
+	page     *cdp.Page
+	lastUsed time.Time
+	mu       sync.Mutex // Serializes the tools on the page
+}
+
+// browserPool is the browser shared by the tools and its open pages. The
+// browser starts on first use and stops once no page is left.
+var browserPool struct {
+	mu      sync.Mutex
+	browser *cdp.Browser
+	pages   map[string]*browserPage
+	nextID  int
+}
+
+// SetBrowserConfig enables the browser tools. Zero limits select the
+// defaults.
+func SetBrowserConfig(config BrowserConfig) error {
+	if len(config.AllowedDomains) == 0 {
+		return fmt.Errorf("the browser tools require allowed domains; use \"*\" to allow every domain")
+	}
+	if config.RemoteURL == "" && config.ChromePath == "" {
+		path, err := cdp.FindChrome()
+		if err != nil {
+			return err
+		}
+		config.ChromePath = path
+	}
+	if config.MaxPages <= 0 {
+		config.MaxPages = DefaultBrowserMaxPages
+	}
+	if config.IdleTimeout <= 0 {
+		config.IdleTimeout = DefaultBrowserIdleTimeout
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultBrowserTimeout
+	}
+	if config.MaxLength <= 0 {
+		config.MaxLength = DefaultBrowserMaxLength
+	}
+	CloseBrowser()
+	browserConfig = &config
+	return nil
+}
+
+// GetBrowserConfig returns the current configuration, nil when the tools
+// are disabled
+func GetBrowserConfig() *BrowserConfig {
+	return browserConfig
+}
+
+// CloseBrowser closes the open pages and stops the browser
+func CloseBrowser() {
+	browserPool.mu.Lock()
+	defer browserPool.mu.Unlock()
+	if browserPool.browser != nil {
+		browserPool.browser.Close()
+	}
+	browserPool.browser = nil
+	browserPool.pages = nil
+}
+
+// CallBrowserTool runs the named browser tool. Screenshots are returned as
+// PNG images.
+func CallBrowserTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+	config := browserConfig
+	if config == nil {
+		return nil, fmt.Errorf("browser tools not configured. Set browser.enabled in the config file")
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+	switch name {
+	case "browser_navigate":
+		return textResult(config.navigate(ctx, arguments))
+	case "browser_get_content":
+		return textResult(config.getContent(ctx, arguments))
+	case "browser_screenshot":
+		return config.screenshot(ctx, arguments)
+	}
+	return nil, fmt.Errorf("unknown browser tool: %s", name)
+}
+
+// screenshot runs browser_screenshot, returning the PNG image followed by a
+// caption naming the page
+func (c *BrowserConfig) screenshot(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+	var args struct {
+		FullPage bool `arg:"full_page"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	page, err := c.targetPage(ctx, arguments)
+	if err != nil {
+		return nil, err
+	}
+	defer page.mu.Unlock()
+	image, err := page.page.Screenshot(ctx, args.FullPage)
+	if err != nil {
+		return nil, fmt.Errorf("screenshot failed: %w", err)
+	}
+	var location string
+	page.page.Evaluate(ctx, "location.href", &location)
+	result := ImageResult(image, "image/png")
+	result.Content = append(result.Content, TextContent(fmt.Sprintf("Screenshot of page %s (%s)", page.id, location)))
+	return result, nil
+}
+
+// checkURL rejects URLs that are not http(s) or whose host is not allowed
+func (c *BrowserConfig) checkURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL scheme %q, only http and https are allowed", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("URL must include a host")
+	}
+	if !hostMatches(c.AllowedDomains, u) {
+		return fmt.Errorf("host %s is not in the allowed domains", u.Host)
+	}
+	return nil
+}
+
+// navigate loads a URL in a new or an existing page
+func (c *BrowserConfig) navigate(ctx context.Context, arguments map[string]interface{}) (string, error) {
+	var args struct {
+		URL     string `arg:"url,required"`
+		PageID  string `arg:"page_id"`
+		WaitFor string `arg:"wait_for"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return "", err
+	}
+	if err := c.checkURL(args.URL); err != nil {
+		return "", err
+	}
+	var page *browserPage
+	var err error
+	if args.PageID != "" {
+		page, err = c.lookupPage(args.PageID)
+	} else {
+		page, err = c.openPage(ctx)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer page.mu.Unlock()
+	if err := page.page.Navigate(ctx, args.URL); err != nil {
+		return "", err
+	}
+	if args.WaitFor != "" {
+		if err := waitForSelector(ctx, page.page, args.WaitFor); err != nil {
+			return "", err
+		}
+	}
+
+	var info struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+	}
+	if err := page.page.Evaluate(ctx, "({title: document.title, url: location.href})", &info); err != nil {
+		return "", err
+	}
+	var result strings.Builder
+	fmt.Fprintf(&result, "Page ID: %s\n", page.id)
+	if info.Title != "" {
+		fmt.Fprintf(&result, "Title: %s\n", info.Title)
+	}
+	fmt.Fprintf(&result, "URL: %s", info.URL)
+	return result.String(), nil
+}
+
+// waitForSelector waits until an element matches selector or ctx ends
+func waitForSelector(ctx context.Context, page *cdp.Page, selector string) error {
+	wait := time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		wait = time.Until(deadline) - 500*time.Millisecond
+	}
+	quoted, _ := json.Marshal(selector)
+	script := fmt.Sprintf(`new Promise((resolve) => {
+	const deadline = Date.now() + %d;
+	const check = () => {
+		if (document.querySelector(%s)) resolve(true);
+		else if (Date.now() > deadline) resolve(false);
+		else setTimeout(check, 100);
+	};
+	check();
+})`, wait.Milliseconds(), quoted)
+	var found bool
+	if err := page.Evaluate(ctx, script, &found); err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("no element matching %s appeared on the page", selector)
+	}
+	return nil
+}
+
+// getContent returns the rendered content of a page
+func (c *BrowserConfig) getContent(ctx context.Context, arguments map[string]interface{}) (string, error) {
+	args := struct {
+		Format     string `arg:"format"`
+		MaxLength  int    `arg:"max_length"`
+		StartIndex int    `arg:"start_index"`
+		Selector   string `arg:"selector"`
+	}{MaxLength: c.MaxLength}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return "", err
+	}
+	format := "markdown"
+	if f := args.Format; f != "" {
+		if f != "markdown" && f != "text" && f != "html" {
+			return "", fmt.Errorf("format must be markdown, text or html")
+		}
+		format = f
+	}
+	maxLength := args.MaxLength
+	if maxLength <= 0 || maxLength > c.MaxLength {
+		maxLength = c.MaxLength
+	}
+	startIndex := max(args.StartIndex, 0)
+	selector := args.Selector
+
+	page, err := c.targetPage(ctx, arguments)
+	if err != nil {
+		return "", err
+	}
+	defer page.mu.Unlock()
+	quoted, _ := json.Marshal(selector)
+	script := fmt.Sprintf(`(() => {
+	const selector = %s;
+	const root = selector ? document.querySelector(selector) : document.documentElement;
+	return root && {title: document.title, url: location.href, html: root.outerHTML};
+})()`, quoted)
+	var doc *struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+		HTML  string `json:"html"`
+	}
+	if err := page.page.Evaluate(ctx, script, &doc); err != nil {
+		return "", err
+	}
+	if doc == nil {
+		return "", fmt.Errorf("no element matches %s", selector)
+	}
+
+	content := doc.HTML
+	if format != "html" {
+		tokens := tokenizeHTML(doc.HTML)
+		if selector == "" {
+			tokens = mainContent(tokens)
+		}
+		base, _ := url.Parse(doc.URL)
+		content = htmlToText(tokens, base, format == "text")
+	}
+	var result strings.Builder
+	fmt.Fprintf(&result, "Page ID: %s\n", page.id)
+	if doc.Title != "" {
+		fmt.Fprintf(&result, "Title: %s\n", doc.Title)
+	}
+	fmt.Fprintf(&result, "URL: %s\n\n", doc.URL)
+	writeContentWindow(&result, content, startIndex, maxLength)
+	return result.String(), nil
+}
+
+// targetPage returns the page named by the page_id argument, or a new page
+// loaded with the url argument, locked for the caller
+func (c *BrowserConfig) targetPage(ctx context.Context, arguments map[string]interface{}) (*browserPage, error) {
+	var args struct {
+		PageID string `arg:"page_id"`
+		URL    string `arg:"url"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	id, rawURL := args.PageID, args.URL
+	switch {
+	case id != "" && rawURL != "":
+		return nil, fmt.Errorf("pass either page_id or url, not both")
+	case id != "":
+		return c.lookupPage(id)
+	case rawURL != "":
+		if err := c.checkURL(rawURL); err != nil {
+			return nil, err
+		}
+		page, err := c.openPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := page.page.Navigate(ctx, rawURL); err != nil {
+			page.mu.Unlock()
+			return nil, err
+		}
+		return page, nil
+	}
+	return nil, fmt.Errorf("page_id or url argument is required")
+}
+
+// lookupPage returns an open page, locked for the caller
+func (c *BrowserConfig) lookupPage(id string) (*browserPage, error) {
+	browserPool.mu.Lock()
+	c.closeIdlePages()
+	page := browserPool.pages[id]
+	if page != nil {
+		page.lastUsed = time.Now()
+	}
+	browserPool.mu.Unlock()
+	if page == nil {
+		return nil, fmt.Errorf("unknown page_id %q: pages close after %v unused or when more than %d are open; call browser_navigate again", id, c.IdleTimeout, c.MaxPages)
+	}
+	page.mu.Lock()
+	return page, nil
+}
+
+// openPage opens a page, starting the browser when needed and closing the
+// least recently used page beyond the limit. The page is locked for the
+// caller.
+func (c *BrowserConfig) openPage(ctx context.Context) (*browserPage, error) {
+	browserPool.mu.Lock()
+	defer browserPool.mu.Unlock()
+	c.closeIdlePages()
+	browser := browserPool.browser
+	if browser != nil {
+		select {
+		case <-browser.Done():
+			// The browser crashed or the remote one went away
+			browser.Close()
+			browser, browserPool.pages = nil, nil
+		default:
+		}
+	}
+	if browser == nil {
+		var err error
+		if c.RemoteURL != "" {
+			browser, err = cdp.Connect(ctx, c.RemoteURL)
+		} else {
+			browser, err = cdp.Launch(ctx, c.ChromePath, c.ChromeArgs)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to start the browser: %w", err)
+		}
+		browserPool.browser = browser
+		browserPool.pages = map[string]*browserPage{}
+	}
+
+	for len(browserPool.pages) >= c.MaxPages {
+		c.closePage(leastRecentlyUsed(browserPool.pages))
+	}
+	page, err := browser.NewPage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a page: %w", err)
+	}
+	allow := func(u *url.URL) bool {
+		// Pages may embed blank and inline frames
+		return u.Scheme == "about" || u.Scheme == "data" || (u.Scheme == "http" || u.Scheme == "https") && hostMatches(c.AllowedDomains, u)
+	}
+	if err := page.FilterDocuments(ctx, allow); err != nil {
+		page.Close(context.Background())
+		return nil, fmt.Errorf("failed to open a page: %w", err)
+	}
+	browserPool.nextID++
+	p := &browserPage{id: fmt.Sprintf("page-%d", browserPool.nextID), page: page, lastUsed: time.Now()}
+	p.mu.Lock()
+	browserPool.pages[p.id] = p
+	return p, nil
+}
+
+// closeIdlePages closes the pages unused for the idle timeout, and the
+// browser with the last one. The pool must be locked.
+func (c *BrowserConfig) closeIdlePages() {
+	for _, page := range browserPool.pages {
+		if time.Since(page.lastUsed) > c.IdleTimeout {
+			c.closePage(page)
+		}
+	}
+	if browserPool.browser != nil && len(browserPool.pages) == 0 {
+		browserPool.browser.Close()
+		browserPool.browser = nil
+	}
+}
+
+// closePage closes a page in the background, once its current user is
+// done. The pool must be locked.
+func (c *BrowserConfig) closePage(page *browserPage) {
+	delete(browserPool.pages, page.id)
+	go func() {
+		page.mu.Lock()
+		defer page.mu.Unlock()
+		ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+		defer cancel()
+		page.page.Close(ctx)
+	}()
+}
+
+// leastRecentlyUsed returns the page unused for the longest time
+func leastRecentlyUsed(pages map[string]*browserPage) *browserPage {
+	var oldest *browserPage
+	for _, page := range pages {
+		if oldest == nil || page.lastUsed.Before(oldest.lastUsed) {
+			oldest = page
+		}
+	}
+	return oldest
+}
@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mcp-go/cdp"
+)
+
+func TestCallBrowserToolRejects(t *testing.T) {
+	if err := SetBrowserConfig(BrowserConfig{RemoteURL: "ws://127.0.0.1:1/devtools/browser/none", AllowedDomains: []string{"*.example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { browserConfig = nil }()
+	ctx := context.Background()
+
+	// Every argument below is rejected before the browser is reached
+	for _, tt := range []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"browser_navigate", map[string]interface{}{}, "url argument is required"},
+		{"browser_navigate", map[string]interface{}{"url": "file:///etc/passwd"}, "unsupported URL scheme"},
+		{"browser_navigate", map[string]interface{}{"url": "https://example.org/"}, "not in the allowed domains"},
+		{"browser_navigate", map[string]interface{}{"url": "https://docs.example.com/", "page_id": "page-9"}, "unknown page_id"},
+		{"browser_get_content", map[string]interface{}{}, "page_id or url argument is required"},
+		{"browser_get_content", map[string]interface{}{"page_id": "page-1", "url": "https://docs.example.com/"}, "not both"},
+		{"browser_get_content", map[string]interface{}{"url": "https://docs.example.com/", "format": "pdf"}, "format must be"},
+	} {
+		if _, err := CallBrowserTool(ctx, tt.name, tt.args); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s %v: expected an error containing %q, got %v", tt.name, tt.args, tt.want, err)
+		}
+	}
+	if _, err := CallBrowserTool(ctx, "browser_screenshot", map[string]interface{}{"url": "http://localhost/"}); err == nil || !strings.Contains(err.Error(), "not in the allowed domains") {
+		t.Errorf("Expected the screenshot of a disallowed host to be rejected, got %v", err)
+	}
+	if err := SetBrowserConfig(BrowserConfig{RemoteURL: "ws://127.0.0.1:1/"}); err == nil {
+		t.Error("Expected a configuration without allowed domains to be rejected")
+	}
+}
+
+func TestCallBrowserToolChrome(t *testing.T) {
+	chrome, err := cdp.FindChrome()
+	if err != nil {
+		t.Skip(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/away" {
+			http.Redirect(w, r, "http://localhost.invalid/", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Rendered</title></head><body><main id="app"></main>
+<script>setTimeout(() => { document.getElementById("app").innerHTML = "<h1>Hello</h1><p id=late>from JavaScript</p>"; }, 200);</script></body></html>`)
+	}))
+	defer server.Close()
+
+	config := BrowserConfig{ChromePath: chrome, AllowedDomains: []string{"127.0.0.1"}, MaxPages: 1}
+	if strings.Contains(chrome, "chromium") {
+		config.ChromeArgs = []string{"--no-sandbox"}
+	}
+	if err := SetBrowserConfig(config); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { CloseBrowser(); browserConfig = nil }()
+	ctx := context.Background()
+
+	result, err := resultText(CallBrowserTool(ctx, "browser_navigate", map[string]interface{}{"url": server.URL, "wait_for": "#late"}))
+	if err != nil || !strings.Contains(result, "Page ID: page-1\nTitle: Rendered") {
+		t.Fatalf("browser_navigate = %q, %v", result, err)
+	}
+	result, err = resultText(CallBrowserTool(ctx, "browser_get_content", map[string]interface{}{"page_id": "page-1", "format": "text"}))
+	if err != nil || !strings.Contains(result, "Hello") || !strings.Contains(result, "from JavaScript") {
+		t.Errorf("browser_get_content = %q, %v", result, err)
+	}
+	screenshot, err := CallBrowserTool(ctx, "browser_screenshot", map[string]interface{}{"page_id": "page-1"})
+	if err != nil {
+		t.Fatalf("browser_screenshot failed: %v", err)
+	}
+	image, _ := base64.StdEncoding.DecodeString(screenshot.Content[0].Data)
+	if screenshot.Content[0].MimeType != "image/png" || !bytes.HasPrefix(image, []byte("\x89PNG")) || !strings.HasPrefix(screenshot.Content[1].Text, "Screenshot of page page-1") {
+		t.Errorf("browser_screenshot returned %d bytes and %+v", len(image), screenshot.Content[1])
+	}
+	if _, err := CallBrowserTool(ctx, "browser_navigate", map[string]interface{}{"url": server.URL + "/away"}); err == nil {
+		t.Error("Expected a redirect to a disallowed domain to be blocked")
+	}
+	// The second page closed the first beyond max_pages
+	if _, err := CallBrowserTool(ctx, "browser_get_content", map[string]interface{}{"page_id": "page-1"}); err == nil || !strings.Contains(err.Error(), "unknown page_id") {
+		t.Errorf("Expected page-1 to be closed, got %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package tools
+
+import "context"
+
+// newBuiltinRegistry returns a registry holding the built-in tools of the
+// gateway server, in the order tools/list shows them. Each is served once
+// its configuration is set.
+func newBuiltinRegistry() *Registry {
+	r := NewRegistry()
+
+	r.MustRegister(&builtinTool{definition: describe(GetEchoTool()), execute: contextFree(CallEcho)})
+	r.MustRegister(&builtinTool{
+		definition: describe(GetGooglePSETool()),
+		enabled:    func() bool { return GetGooglePSEConfig() != nil },
+		execute:    CallGooglePSE,
+	})
+	r.MustRegister(&builtinTool{
+		definition: describe(GetBraveSearchTool()),
+		enabled:    BraveSearchConfigured,
+		execute:    CallBraveSearch,
+	})
+	r.MustRegister(&builtinTool{
+		definition: describe(GetSearXNGTool()),
+		enabled:    SearXNGConfigured,
+		execute:    CallSearXNG,
+	})
+	r.MustRegister(&builtinTool{
+		definition: describe(GetDuckDuckGoTool()),
+		enabled:    DuckDuckGoEnabled,
+		execute:    CallDuckDuckGo,
+	})
+	r.MustRegister(&builtinTool{
+		definition: describe(GetHTTPRequestTool()),
+		enabled:    func() bool { return GetHTTPRequestConfig() != nil },
+		execute:    CallHTTPRequest,
+	})
+	r.MustRegister(&builtinTool{
+		definition: describe(GetFetchPageTool()),
+		enabled:    func() bool { return GetFetchPageConfig() != nil },
+		execute:    CallFetchPage,
+	})
+	// The command is killed if the client goes away
+	r.MustRegister(&builtinTool{
+		definition: describe(GetRunCommandTool()),
+		enabled:    func() bool { return GetRunCommandConfig() != nil },
+		execute:    CallRunCommand,
+	})
+	for _, tool := range GetGitTools() {
+		r.MustRegister(&builtinTool{
+			definition: describe(tool),
+			enabled:    func() bool { return GetGitConfig() != nil },
+			execute:    namedTool(tool.Name, CallGitTool),
+		})
+	}
+	r.MustRegister(&builtinTool{
+		definition: describe(GetSQLQueryTool()),
+		enabled:    func() bool { return GetSQLQueryConfig() != nil },
+		execute:    CallSQLQuery,
+	})
+	for _, tool := range GetWikipediaTools() {
+		r.MustRegister(&builtinTool{
+			definition: describe(tool),
+			enabled:    WikipediaEnabled,
+			execute:    namedTool(tool.Name, CallWikipediaTool),
+		})
+	}
+	for _, tool := range GetKnowledgeBaseTools() {
+		r.MustRegister(&builtinTool{
+			definition: describe(tool),
+			enabled:    func() bool { return GetKnowledgeBaseConfig() != nil },
+			execute:    namedTool(tool.Name, CallKnowledgeBaseTool),
+		})
+	}
+	for _, tool := range GetS3Tools() {
+		r.MustRegister(&builtinTool{
+			definition: describe(tool),
+			enabled:    func() bool { return GetS3Config() != nil },
+			execute:    namedTool(tool.Name, CallS3Tool),
+		})
+	}
+	for _, tool := range GetDockerTools() {
+		name := tool.Name
+		r.MustRegister(&builtinTool{
+			definition: describe(tool),
+			enabled:    func() bool { return DockerToolEnabled(name) },
+			execute:    namedTool(name, CallDockerTool),
+		})
+	}
+	for _, tool := range GetKubernetesTools() {
+		name := tool.Name
+		r.MustRegister(&builtinTool{
+			definition: describe(tool),
+			enabled:    func() bool { return KubernetesToolEnabled(name) },
+			execute:    namedTool(name, CallKubernetesTool),
+		})
+	}
+	r.MustRegister(&builtinTool{
+		definition: describe(GetSendEmailTool()),
+		enabled:    func() bool { return GetEmailConfig() != nil },
+		execute:    contextFree(CallSendEmail),
+	})
+	for _, tool := range GetSlackTools() {
+		r.MustRegister(&builtinTool{
+			definition: describe(tool),
+			enabled:    func() bool { return GetSlackConfig() != nil },
+			execute:    namedTool(tool.Name, CallSlackTool),
+		})
+	}
+	for _, tool := range GetTelegramTools() {
+		r.MustRegister(&builtinTool{
+			definition: describe(tool),
+			enabled:    func() bool { return GetTelegramConfig() != nil },
+			execute:    namedTool(tool.Name, CallTelegramTool),
+		})
+	}
+	for _, tool := range GetGitHubTools() {
+		name := tool.Name
+		r.MustRegister(&builtinTool{
+			definition: describe(tool),
+			enabled:    func() bool { return GitHubToolEnabled(name) },
+			execute:    namedTool(name, CallGitHubTool),
+		})
+	}
+	for _, tool := range GetJiraTools() {
+		name := tool.Name
+		r.MustRegister(&builtinTool{
+			definition: describe(tool),
+			enabled:    func() bool { return JiraToolEnabled(name) },
+			execute:    namedTool(name, CallJiraTool),
+		})
+	}
+	r.MustRegister(&builtinTool{
+		definition: describe(GetOCRTool()),
+		enabled:    func() bool { return GetOCRConfig() != nil },
+		execute:    CallOCRImage,
+	})
+	for _, tool := range GetBrowserTools() {
+		r.MustRegister(&builtinTool{
+			definition: describe(tool),
+			enabled:    func() bool { return GetBrowserConfig() != nil },
+			execute:    namedTool(tool.Name, CallBrowserTool),
+		})
+	}
+	for _, tool := range GetSpreadsheetTools() {
+		name := tool.Name
+		r.MustRegister(&builtinTool{
+			definition: describe(tool),
+			enabled:    func() bool { return GetSpreadsheetConfig() != nil },
+			execute: func(_ context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+				return CallSpreadsheetTool(name, arguments)
+			},
+		})
+	}
+	r.MustRegister(&builtinTool{
+		definition: describe(GetJSONQueryTool()),
+		enabled:    func() bool { return GetJSONQueryConfig() != nil },
+		execute:    contextFree(CallJSONQuery),
+	})
+	r.MustRegister(&builtinTool{
+		definition: describe(GetDNSLookupTool()),
+		enabled:    func() bool { return GetDNSLookupConfig() != nil },
+		execute:    CallDNSLookup,
+	})
+	r.MustRegister(&builtinTool{
+		definition: describe(GetWhoisTool()),
+		enabled:    func() bool { return GetWhoisConfig() != nil },
+		execute:    CallWhois,
+	})
+	r.MustRegister(&builtinTool{
+		definition: describe(GetCrawlSiteTool()),
+		enabled:    func() bool { return GetCrawlSiteConfig() != nil },
+		execute:    CallCrawlSite,
+	})
+	r.MustRegister(&builtinTool{
+		definition: describe(GetYouTubeTranscriptTool()),
+		enabled:    func() bool { return GetYouTubeTranscriptConfig() != nil },
+		execute:    CallYouTubeTranscript,
+	})
+	return r
+}
+
+// FilesystemRegistry holds the filesystem tools served by
+// cmd/filesystem-server, under their unprefixed names
+var FilesystemRegistry = newFilesystemRegistry()
+
+// newFilesystemRegistry returns a registry holding the filesystem tools in
+// the order tools/list shows them
+func newFilesystemRegistry() *Registry {
+	r := NewRegistry()
+	add := func(definition FileSystemTool, call func(map[string]interface{}) (*ToolResult, error)) {
+		r.MustRegister(&builtinTool{definition: describe(definition), execute: contextFree(call)})
+	}
+	add(GetReadFileTool(), CallReadFile)
+	add(GetReadMultipleFilesTool(), CallReadMultipleFiles)
+	add(GetWriteFileTool(), CallWriteFile)
+	add(GetListDirectoryTool(), CallListDirectory)
+	add(GetCreateDirectoryTool(), CallCreateDirectory)
+	add(GetDeleteFileTool(), CallDeleteFile)
+	add(GetMoveFileTool(), CallMoveFile)
+	add(GetCopyFileTool(), CallCopyFile)
+	add(GetSearchFilesTool(), CallSearchFiles)
+	add(GetFindFilesTool(), CallFindFiles)
+	add(GetFileInfoTool(), CallGetFileInfo)
+	add(GetEditFileTool(), CallEditFile)
+	add(GetAppendFileTool(), CallAppendFile)
+	add(GetDirectoryTreeTool(), CallDirectoryTree)
+	add(GetHashFileTool(), CallHashFile)
+	add(GetCreateArchiveTool(), CallCreateArchive)
+	add(GetExtractArchiveTool(), CallExtractArchive)
+	add(GetDiffFilesTool(), CallDiffFiles)
+	add(GetSetPermissionsTool(), CallSetPermissions)
+	add(GetWatchPathTool(), CallWatchPath)
+	add(GetUnwatchPathTool(), CallUnwatchPath)
+	add(GetFileEventsTool(), CallGetFileEvents)
+	return r
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package tools
+
+import "os/exec"
+
+// setProcessGroup is not available on this platform
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// setResourceLimits is not available on this platform
+func setResourceLimits(cmd *exec.Cmd, cpuSeconds int, memoryBytes int64) {}
+
+// killProcessGroup kills the process of a started cmd; its children are
+// left running on this platform
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
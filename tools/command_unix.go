@@ -0,0 +1,42 @@
+//go:build unix
+
+package tools
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts cmd in its own process group, so the children it
+// spawns can be killed with it
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills the process group of a started cmd
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
+
+// setResourceLimits runs cmd through sh, which sets the limits with ulimit
+// before replacing itself with the command, since Go cannot set the rlimits
+// of a child alone. Limits apply to each process the command starts.
+func setResourceLimits(cmd *exec.Cmd, cpuSeconds int, memoryBytes int64) {
+	if cmd.Err != nil {
+		return // Run reports that the command was not found
+	}
+	script := ""
+	if cpuSeconds > 0 {
+		script += fmt.Sprintf("ulimit -t %d && ", cpuSeconds)
+	}
+	if memoryBytes > 0 {
+		script += fmt.Sprintf("ulimit -v %d && ", max(memoryBytes/1024, 1))
+	}
+	script += `exec "$@"`
+	cmd.Args = append([]string{"sh", "-c", script, "sh", cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = "/bin/sh"
+}
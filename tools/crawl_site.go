@@ -0,0 +1,529 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Defaults for the crawl_site tool when the configuration leaves them unset
+const (
+	DefaultCrawlSiteTimeout    = 2 * time.Minute
+	DefaultCrawlSiteMaxPages   = 50
+	DefaultCrawlSiteMaxDepth   = 3
+	DefaultCrawlSiteMaxLength  = 50000
+	DefaultCrawlSitePageLength = 3000
+	// crawlSiteMaxSitemaps bounds the sitemaps read through sitemap indexes
+	crawlSiteMaxSitemaps = 10
+)
+
+// crawlSiteSkippedExtensions are links to files that are not pages
+var crawlSiteSkippedExtensions = map[string]bool{
+	".pdf": true, ".zip": true, ".gz": true, ".tar": true, ".png": true,
+	".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".webp": true,
+	".ico": true, ".mp3": true, ".mp4": true, ".webm": true, ".css": true,
+	".js": true, ".woff": true, ".woff2": true, ".exe": true, ".dmg": true,
+}
+
+// CrawlSiteTool represents the crawl_site tool definition
+type CrawlSiteTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// GetCrawlSiteTool returns the crawl_site tool definition
+func GetCrawlSiteTool() CrawlSiteTool {
+	return CrawlSiteTool{
+		Name:        "crawl_site",
+		Description: "Crawl a website from a start page, following links within the same host or reading its sitemap, and return the URL, title and main text of each page. Honors robots.txt.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"url": map[string]interface{}{
+					"type":        "string",
+					"description": "The http or https URL of the start page, or of a sitemap",
+				},
+				"mode": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"links", "sitemap"},
+					"description": "links follows links from the start page; sitemap reads the pages listed in the sitemap of the URL, of robots.txt or at /sitemap.xml (default: sitemap when the URL ends in .xml, links otherwise)",
+				},
+				"max_pages": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of pages to fetch, at most the configured limit",
+					"minimum":     1,
+				},
+				"max_depth": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of links to follow from the start page, at most the configured limit (0 fetches only the start page)",
+					"minimum":     0,
+				},
+				"path_prefix": map[string]interface{}{
+					"type":        "string",
+					"description": "Only visit pages whose path starts with this prefix, such as /docs/",
+				},
+				"page_length": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of characters of text per page (default: 3000)",
+					"minimum":     1,
+				},
+				"format": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"markdown", "text"},
+					"description": "Output format of the page text (default: markdown)",
+				},
+			},
+			"required": []string{"url"},
+		},
+	}
+}
+
+// CrawlSiteConfig holds the configuration for the crawl_site tool
+type CrawlSiteConfig struct {
+	MaxPages  int
+	MaxDepth  int
+	MaxLength int           // Characters of page text returned by a crawl
+	Timeout   time.Duration // Of the whole crawl
+	// AllowPrivateNetworks permits crawling loopback, private and link-local
+	// addresses, which are refused by default
+	AllowPrivateNetworks bool
+}
+
+var crawlSiteConfig *CrawlSiteConfig
+
+// SetCrawlSiteConfig enables the crawl_site tool. Zero values select the
+// defaults.
+func SetCrawlSiteConfig(config CrawlSiteConfig) {
+	if config.MaxPages <= 0 {
+		config.MaxPages = DefaultCrawlSiteMaxPages
+	}
+	if config.MaxDepth <= 0 {
+		config.MaxDepth = DefaultCrawlSiteMaxDepth
+	}
+	if config.MaxLength <= 0 {
+		config.MaxLength = DefaultCrawlSiteMaxLength
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultCrawlSiteTimeout
+	}
+	crawlSiteConfig = &config
+}
+
+// GetCrawlSiteConfig returns the current configuration, nil when the tool
+// is disabled
+func GetCrawlSiteConfig() *CrawlSiteConfig {
+	return crawlSiteConfig
+}
+
+// CrawledPage is a page fetched by crawl_site
+type CrawledPage struct {
+	URL       string `json:"url"`
+	Title     string `json:"title,omitempty"`
+	Depth     int    `json:"depth"`
+	Text      string `json:"text"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// CrawlResult is the structured result of crawl_site
+type CrawlResult struct {
+	Site  string        `json:"site"`
+	Pages []CrawledPage `json:"pages"`
+	// NotVisited counts the URLs found but left out of the page budget
+	NotVisited int      `json:"not_visited"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// CallCrawlSite crawls a site and returns its pages as markdown, with the
+// same pages as structured content
+func CallCrawlSite(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+	config := crawlSiteConfig
+	if config == nil {
+		return nil, fmt.Errorf("crawl_site not configured. Set crawl_site.enabled in the config file")
+	}
+	args := struct {
+		URL        string `arg:"url,required"`
+		Mode       string `arg:"mode"`
+		MaxPages   int    `arg:"max_pages"`
+		MaxDepth   int    `arg:"max_depth"`
+		PageLength int    `arg:"page_length"`
+		Format     string `arg:"format"`
+		PathPrefix string `arg:"path_prefix"`
+	}{MaxPages: config.MaxPages, MaxDepth: config.MaxDepth, PageLength: DefaultCrawlSitePageLength}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	start, err := url.Parse(args.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %v", err)
+	}
+	if start.Scheme != "http" && start.Scheme != "https" || start.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http or https URL")
+	}
+	start.Fragment = ""
+
+	mode := args.Mode
+	if mode == "" {
+		mode = "links"
+		if strings.HasSuffix(strings.ToLower(start.Path), ".xml") {
+			mode = "sitemap"
+		}
+	}
+	if mode != "links" && mode != "sitemap" {
+		return nil, fmt.Errorf("mode must be links or sitemap")
+	}
+	maxPages, maxDepth, pageLength := args.MaxPages, args.MaxDepth, args.PageLength
+	if maxPages < 1 || maxDepth < 0 || pageLength < 1 {
+		return nil, fmt.Errorf("max_pages and page_length must be positive and max_depth not negative")
+	}
+	maxPages, maxDepth = min(maxPages, config.MaxPages), min(maxDepth, config.MaxDepth)
+	plain := false
+	switch args.Format {
+	case "", "markdown":
+	case "text":
+		plain = true
+	default:
+		return nil, fmt.Errorf("format must be markdown or text")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+	c := &crawler{
+		client: webClient(DefaultFetchPageTimeout, config.AllowPrivateNetworks),
+		site:   &url.URL{Scheme: start.Scheme, Host: start.Host},
+		plain:  plain,
+		prefix: args.PathPrefix,
+	}
+	c.robots = c.fetchRobots(ctx)
+
+	type queued struct {
+		url   *url.URL
+		depth int
+	}
+	var queue []queued
+	seen := map[string]bool{}
+	enqueue := func(u *url.URL, depth int) {
+		if key := crawlKey(u); !seen[key] && c.allowed(u) {
+			seen[key] = true
+			queue = append(queue, queued{u, depth})
+		}
+	}
+	result := &CrawlResult{Site: c.site.String(), Pages: []CrawledPage{}}
+	if mode == "sitemap" {
+		sitemaps := c.robots.sitemaps
+		if strings.HasSuffix(strings.ToLower(start.Path), ".xml") {
+			sitemaps = []string{start.String()}
+		} else if len(sitemaps) == 0 {
+			sitemaps = []string{c.site.String() + "/sitemap.xml"}
+		}
+		pages, err := c.readSitemaps(ctx, sitemaps)
+		if err != nil {
+			return nil, err
+		}
+		for _, page := range pages {
+			enqueue(page, 0)
+		}
+		if len(queue) == 0 {
+			return nil, fmt.Errorf("the sitemap lists no pages of %s that may be crawled", c.site.Host)
+		}
+	} else {
+		if !c.allowed(start) {
+			return nil, fmt.Errorf("%s is outside path_prefix or disallowed by robots.txt", start)
+		}
+		enqueue(start, 0)
+	}
+
+	remaining := config.MaxLength
+	for len(queue) > 0 && len(result.Pages) < maxPages && remaining > 0 && ctx.Err() == nil {
+		next := queue[0]
+		queue = queue[1:]
+		page, links, err := c.fetch(ctx, next.url)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", next.url, err))
+			continue
+		}
+		page.Depth = next.depth
+		length := min(pageLength, remaining)
+		if runes := []rune(page.Text); len(runes) > length {
+			page.Text, page.Truncated = string(runes[:length]), true
+		}
+		remaining -= utf8.RuneCountInString(page.Text)
+		result.Pages = append(result.Pages, page)
+		if mode == "links" && next.depth < maxDepth {
+			for _, link := range links {
+				enqueue(link, next.depth+1)
+			}
+		}
+	}
+	result.NotVisited = len(queue)
+	if ctx.Err() != nil {
+		result.Errors = append(result.Errors, fmt.Sprintf("crawl stopped after %s", config.Timeout))
+	}
+	if len(result.Pages) == 0 && len(result.Errors) > 0 {
+		return nil, fmt.Errorf("no page could be fetched: %s", strings.Join(result.Errors, "; "))
+	}
+	return StructuredResult(formatCrawlResult(result, remaining <= 0), result), nil
+}
+
+// formatCrawlResult renders the pages of a crawl as markdown sections
+func formatCrawlResult(result *CrawlResult, outOfLength bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Crawled %d pages of %s", len(result.Pages), result.Site)
+	if result.NotVisited > 0 {
+		fmt.Fprintf(&b, " (%d more found but not visited)", result.NotVisited)
+	}
+	b.WriteString("\n")
+	for _, page := range result.Pages {
+		title := page.Title
+		if title == "" {
+			title = page.URL
+		}
+		fmt.Fprintf(&b, "\n## %s\nURL: %s\n\n%s", title, page.URL, page.Text)
+		if page.Truncated {
+			b.WriteString(" [...]")
+		}
+		b.WriteString("\n")
+	}
+	if outOfLength {
+		b.WriteString("\n[Output limit reached; crawl a narrower path_prefix or use fetch_page for single pages]\n")
+	}
+	if len(result.Errors) > 0 {
+		b.WriteString("\nErrors:\n")
+		for _, e := range result.Errors {
+			fmt.Fprintf(&b, "- %s\n", e)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// crawler fetches the pages of one site
+type crawler struct {
+	client *http.Client
+	site   *url.URL // Scheme and host of the site
+	prefix string
+	robots *robotsRules
+	plain  bool
+}
+
+// allowed reports whether a URL is on the site, under the path prefix and
+// allowed by robots.txt
+func (c *crawler) allowed(u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" || !strings.EqualFold(u.Host, c.site.Host) {
+		return false
+	}
+	p := u.EscapedPath()
+	if p == "" {
+		p = "/"
+	}
+	if crawlSiteSkippedExtensions[strings.ToLower(path.Ext(u.Path))] {
+		return false
+	}
+	return strings.HasPrefix(p, c.prefix) && c.robots.allows(u.RequestURI())
+}
+
+// get requests a URL of the site, returning the response when it succeeded
+func (c *crawler) get(ctx context.Context, target string, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "mcp-go crawl_site")
+	req.Header.Set("Accept", accept)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if !strings.EqualFold(resp.Request.URL.Host, c.site.Host) {
+		resp.Body.Close()
+		return nil, fmt.Errorf("redirected off the site to %s", resp.Request.URL)
+	}
+	return resp, nil
+}
+
+// fetch downloads a page and returns its text with the links it holds
+func (c *crawler) fetch(ctx context.Context, u *url.URL) (CrawledPage, []*url.URL, error) {
+	page := CrawledPage{URL: u.String()}
+	resp, err := c.get(ctx, u.String(), "text/html,application/xhtml+xml,text/plain;q=0.9")
+	if err != nil {
+		return page, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, fetchPageMaxDownload))
+	if err != nil {
+		return page, nil, fmt.Errorf("failed to read page: %w", err)
+	}
+	page.URL = resp.Request.URL.String()
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml" || mediaType == "" && isHTML(body):
+	case mediaType == "text/plain" || mediaType == "text/markdown":
+		page.Text = strings.TrimSpace(string(body))
+		return page, nil, nil
+	default:
+		return page, nil, fmt.Errorf("unsupported content type %s", mediaType)
+	}
+
+	tokens := tokenizeHTML(string(body))
+	page.Title = htmlTitle(tokens)
+	page.Text = strings.TrimSpace(htmlToText(mainContent(tokens), resp.Request.URL, c.plain))
+	var links []*url.URL
+	for _, token := range tokens {
+		href, ok := token.attrs["href"]
+		if token.tag != "a" || token.end || !ok || strings.Contains(strings.ToLower(token.attrs["rel"]), "nofollow") {
+			continue
+		}
+		link, err := resp.Request.URL.Parse(strings.TrimSpace(href))
+		if err != nil {
+			continue
+		}
+		link.Fragment = ""
+		links = append(links, link)
+	}
+	return page, links, nil
+}
+
+// readSitemaps returns the page URLs listed in sitemaps, following sitemap
+// indexes
+func (c *crawler) readSitemaps(ctx context.Context, sitemaps []string) ([]*url.URL, error) {
+	var pages []*url.URL
+	read := 0
+	for len(sitemaps) > 0 && read < crawlSiteMaxSitemaps {
+		target := sitemaps[0]
+		sitemaps = sitemaps[1:]
+		read++
+		resp, err := c.get(ctx, target, "application/xml,text/xml")
+		if err != nil {
+			if len(pages) == 0 && len(sitemaps) == 0 {
+				return nil, fmt.Errorf("failed to read sitemap %s: %w", target, err)
+			}
+			continue
+		}
+		var doc struct {
+			Pages    []string `xml:"url>loc"`
+			Sitemaps []string `xml:"sitemap>loc"`
+		}
+		err = xml.NewDecoder(io.LimitReader(resp.Body, fetchPageMaxDownload)).Decode(&doc)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid sitemap %s: %v", target, err)
+		}
+		for _, loc := range doc.Pages {
+			if u, err := url.Parse(strings.TrimSpace(loc)); err == nil {
+				pages = append(pages, u)
+			}
+		}
+		for _, loc := range doc.Sitemaps {
+			sitemaps = append(sitemaps, strings.TrimSpace(loc))
+		}
+	}
+	return pages, nil
+}
+
+// fetchRobots reads the robots.txt of the site; a missing or unreadable
+// file allows everything
+func (c *crawler) fetchRobots(ctx context.Context) *robotsRules {
+	resp, err := c.get(ctx, c.site.String()+"/robots.txt", "text/plain")
+	if err != nil {
+		return &robotsRules{}
+	}
+	defer resp.Body.Close()
+	return parseRobots(io.LimitReader(resp.Body, 512<<10))
+}
+
+// crawlKey identifies a page regardless of fragment and trailing slash
+func crawlKey(u *url.URL) string {
+	p := strings.TrimSuffix(u.EscapedPath(), "/")
+	key := strings.ToLower(u.Host) + p
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
+
+// robotsRules are the rules of a robots.txt for all user agents
+type robotsRules struct {
+	rules    []robotsRule
+	sitemaps []string
+}
+
+// robotsRule is an Allow or Disallow line
+type robotsRule struct {
+	allow   bool
+	length  int // Of the pattern, the longest match wins
+	pattern *regexp.Regexp
+}
+
+// parseRobots reads the rules of the groups for user agent * and the
+// sitemaps of a robots.txt, with * and $ wildcards in paths
+func parseRobots(r io.Reader) *robotsRules {
+	rules := &robotsRules{}
+	scanner := bufio.NewScanner(r)
+	applies, inAgents := false, false
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			// Consecutive User-agent lines share a group
+			if !inAgents {
+				applies = false
+			}
+			inAgents = true
+			applies = applies || value == "*"
+		case "allow", "disallow":
+			inAgents = false
+			if !applies || value == "" {
+				continue
+			}
+			pattern := regexp.QuoteMeta(value)
+			pattern = strings.ReplaceAll(pattern, `\*`, ".*")
+			if strings.HasSuffix(pattern, `\$`) {
+				pattern = strings.TrimSuffix(pattern, `\$`) + "$"
+			}
+			rules.rules = append(rules.rules, robotsRule{
+				allow:   key == "allow",
+				length:  len(value),
+				pattern: regexp.MustCompile("^" + pattern),
+			})
+		case "sitemap":
+			rules.sitemaps = append(rules.sitemaps, value)
+		default:
+			inAgents = false
+		}
+	}
+	return rules
+}
+
+// allows reports whether a path and query may be crawled: the longest
+// matching rule decides, Allow winning ties
+func (r *robotsRules) allows(requestURI string) bool {
+	allowed, longest := true, -1
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(requestURI) {
+			continue
+		}
+		if rule.length > longest || rule.length == longest && rule.allow {
+			allowed, longest = rule.allow, rule.length
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCallCrawlSite(t *testing.T) {
+	pages := map[string]string{
+		"/":            `<html><head><title>Home</title></head><body><nav><a href="/nav">Nav</a></nav><main><p>Welcome</p><a href="/docs/a#top">A</a> <a href="/private/x">X</a> <a href="https://other.example/">Other</a> <a href="/logo.png">Logo</a></main></body></html>`,
+		"/docs/a":      `<html><head><title>A</title></head><body><p>Page A</p><a href="/docs/b">B</a> <a href="/">Home</a></body></html>`,
+		"/docs/b":      `<html><head><title>B</title></head><body><p>Page B</p><a href="/docs/c">C</a></body></html>`,
+		"/docs/c":      `<html><head><title>C</title></head><body><p>Page C</p></body></html>`,
+		"/nav":         `<html><head><title>Nav</title></head><body><p>Navigation</p></body></html>`,
+		"/private/x":   `<html><body>Secret</body></html>`,
+		"/notes.txt":   "Plain notes",
+		"/robots.txt":  "User-agent: other\nDisallow: /\n\nUser-agent: *\nDisallow: /private/\nSitemap: SITE/sitemap.xml\n",
+		"/sitemap.xml": `<?xml version="1.0"?><sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><sitemap><loc>SITE/pages.xml</loc></sitemap></sitemapindex>`,
+		"/pages.xml":   `<?xml version="1.0"?><urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>SITE/docs/c</loc></url><url><loc>SITE/notes.txt</loc></url><url><loc>SITE/private/x</loc></url></urlset>`,
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		switch {
+		case strings.HasSuffix(r.URL.Path, ".xml"):
+			w.Header().Set("Content-Type", "application/xml")
+		case strings.HasSuffix(r.URL.Path, ".txt"):
+			w.Header().Set("Content-Type", "text/plain")
+		default:
+			w.Header().Set("Content-Type", "text/html")
+		}
+		w.Write([]byte(strings.ReplaceAll(body, "SITE", server.URL)))
+	}))
+	defer server.Close()
+	defer func() { crawlSiteConfig = nil }()
+
+	// Loopback addresses are refused unless private networks are allowed
+	SetCrawlSiteConfig(CrawlSiteConfig{})
+	if _, _, err := crawl(map[string]interface{}{"url": server.URL}); err == nil || !strings.Contains(err.Error(), "private network") {
+		t.Errorf("Expected a private network error, got %v", err)
+	}
+
+	SetCrawlSiteConfig(CrawlSiteConfig{MaxDepth: 2, AllowPrivateNetworks: true})
+	text, result, err := crawl(map[string]interface{}{"url": server.URL + "/"})
+	if err != nil {
+		t.Fatalf("CallCrawlSite failed: %v", err)
+	}
+	var urls []string
+	for _, page := range result.Pages {
+		urls = append(urls, strings.TrimPrefix(page.URL, server.URL))
+	}
+	// /private/ is disallowed, other hosts and images are skipped and /docs/c
+	// is beyond the depth limit
+	if got := strings.Join(urls, " "); got != "/ /nav /docs/a /docs/b" {
+		t.Errorf("Unexpected pages: %s", got)
+	}
+	if result.Pages[2].Title != "A" || !strings.HasPrefix(result.Pages[2].Text, "Page A") || result.Pages[3].Depth != 2 {
+		t.Errorf("Unexpected page: %+v", result.Pages[2])
+	}
+	if !strings.HasPrefix(text, "Crawled 4 pages of "+server.URL+"\n\n## Home\nURL: "+server.URL+"/\n\nWelcome") {
+		t.Errorf("Unexpected text:\n%s", text)
+	}
+
+	// The page budget and path prefix narrow the crawl
+	_, result, err = crawl(map[string]interface{}{
+		"url": server.URL + "/docs/a", "path_prefix": "/docs/", "max_pages": float64(1), "page_length": float64(4),
+	})
+	if err != nil || len(result.Pages) != 1 || result.Pages[0].Text != "Page" || !result.Pages[0].Truncated || result.NotVisited != 1 {
+		t.Errorf("Expected a single truncated page with one left, got %+v, %v", result, err)
+	}
+
+	// The sitemap of robots.txt is followed through its index
+	_, result, err = crawl(map[string]interface{}{"url": server.URL, "mode": "sitemap"})
+	if err != nil || len(result.Pages) != 2 || result.Pages[0].Title != "C" || result.Pages[1].Text != "Plain notes" {
+		t.Errorf("Expected the pages of the sitemap, got %+v, %v", result, err)
+	}
+
+	if _, _, err := crawl(map[string]interface{}{"url": server.URL + "/private/x"}); err == nil {
+		t.Error("Expected a start page disallowed by robots.txt to be refused")
+	}
+}
+
+// crawl runs crawl_site, returning the text and the structured pages
+func crawl(arguments map[string]interface{}) (string, *CrawlResult, error) {
+	result, err := CallCrawlSite(context.Background(), arguments)
+	if err != nil {
+		return "", nil, err
+	}
+	return result.Content[0].Text, result.StructuredContent.(*CrawlResult), nil
+}
+
+func TestRobotsRules(t *testing.T) {
+	rules := parseRobots(strings.NewReader("User-agent: bot\nUser-agent: *\nDisallow: /search\nDisallow: /*.pdf$\nAllow: /search/about\n\nUser-agent: other\nDisallow: /\n"))
+	tests := map[string]bool{
+		"/":                  true,
+		"/search?q=go":       false,
+		"/search/about":      true,
+		"/files/report.pdf":  false,
+		"/files/report.pdfx": true,
+	}
+	for uri, expected := range tests {
+		if got := rules.allows(uri); got != expected {
+			t.Errorf("allows(%q) = %v, expected %v", uri, got, expected)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DecodeArgs maps tool arguments onto the fields of the struct target points
+// to, replacing type assertions on the arguments map. Fields are bound with
+// an arg tag naming the argument, optionally followed by ",required":
+//
+//	var args struct {
+//		URL       string   `arg:"url,required"`
+//		Languages []string `arg:"languages"`
+//		MaxLength int      `arg:"max_length"`
+//	}
+//	args.MaxLength = 5000 // Default
+//	if err := DecodeArgs(arguments, &args); err != nil {
+//		return "", err
+//	}
+//
+// Absent and null arguments leave their field unchanged, so defaults are
+// set beforehand. Required arguments must be present, and required strings,
+// lists and maps must not be empty. Values are coerced where the intent is
+// clear: whole numbers to integers, numeric and boolean strings to numbers
+// and booleans, and a single value to a one-element list. Supported fields
+// are strings, booleans, numbers, slices and string-keyed maps of them,
+// nested structs, pointers to them and interface{}.
+func DecodeArgs(arguments map[string]interface{}, target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("DecodeArgs needs a pointer to a struct, got %T", target)
+	}
+	return decodeStruct(arguments, v.Elem(), "")
+}
+
+// decodeStruct decodes an object into the tagged fields of a struct; prefix
+// names the enclosing argument in errors
+func decodeStruct(object map[string]interface{}, v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("arg")
+		if !ok || tag == "-" || !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		required := options == "required"
+		path := prefix + name
+
+		value := object[name]
+		if value == nil {
+			if required {
+				return fmt.Errorf("%s argument is required and must be %s", path, describeKind(field.Type, true))
+			}
+			continue
+		}
+		if err := decodeValue(value, v.Field(i), path); err != nil {
+			return err
+		}
+		if required && emptyValue(v.Field(i)) {
+			return fmt.Errorf("%s argument is required and must be %s", path, describeKind(field.Type, true))
+		}
+	}
+	return nil
+}
+
+// decodeValue stores a decoded JSON value in a field
+func decodeValue(value interface{}, v reflect.Value, path string) error {
+	invalid := func() error {
+		return fmt.Errorf("%s argument must be %s", path, describeKind(v.Type(), false))
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		element := reflect.New(v.Type().Elem())
+		if err := decodeValue(value, element.Elem(), path); err != nil {
+			return err
+		}
+		v.Set(element)
+		return nil
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return invalid()
+		}
+		v.Set(reflect.ValueOf(value))
+		return nil
+
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return invalid()
+		}
+		v.SetString(s)
+		return nil
+	case reflect.Bool:
+		switch b := value.(type) {
+		case bool:
+			v.SetBool(b)
+			return nil
+		case string:
+			parsed, err := strconv.ParseBool(strings.TrimSpace(b))
+			if err != nil {
+				return invalid()
+			}
+			v.SetBool(parsed)
+			return nil
+		}
+		return invalid()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := argumentNumber(value)
+		if !ok || n != math.Trunc(n) || v.OverflowInt(int64(n)) {
+			return invalid()
+		}
+		v.SetInt(int64(n))
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := argumentNumber(value)
+		if !ok || n != math.Trunc(n) || n < 0 || v.OverflowUint(uint64(n)) {
+			return invalid()
+		}
+		v.SetUint(uint64(n))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		n, ok := argumentNumber(value)
+		if !ok {
+			return invalid()
+		}
+		v.SetFloat(n)
+		return nil
+
+	case reflect.Slice:
+		items, ok := value.([]interface{})
+		if !ok {
+			if list, isStrings := value.([]string); isStrings {
+				for _, s := range list {
+					items = append(items, s)
+				}
+			} else {
+				// A single value stands for a list of one
+				items = []interface{}{value}
+			}
+		}
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decodeValue(item, slice.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				if _, single := value.([]interface{}); !single && len(items) == 1 {
+					return invalid()
+				}
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return invalid()
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(object))
+		for key, item := range object {
+			element := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeValue(item, element, path+"."+key); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), element)
+		}
+		v.Set(m)
+		return nil
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return invalid()
+		}
+		return decodeStruct(object, v, path+".")
+	}
+	return fmt.Errorf("%s argument cannot be decoded into a %s field", path, v.Type())
+}
+
+// argumentNumber returns a number passed as a JSON number, a Go number or a
+// numeric string
+func argumentNumber(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	}
+	return 0, false
+}
+
+// emptyValue reports whether a decoded required argument is empty: a blank
+// string or an empty list or map
+func emptyValue(v reflect.Value) bool {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return false
+}
+
+// describeKind names the type of a field for error messages, such as "an
+// integer" or "a non-empty list of strings"
+func describeKind(t reflect.Type, nonEmpty bool) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	qualifier := ""
+	if nonEmpty {
+		qualifier = "non-empty "
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a " + qualifier + "string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "an integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a non-negative integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice:
+		return "a " + qualifier + "list of " + strings.TrimPrefix(strings.TrimPrefix(plural(describeKind(t.Elem(), false)), "a "), "an ")
+	case reflect.Map, reflect.Struct:
+		if nonEmpty {
+			return "a non-empty object"
+		}
+		return "an object"
+	}
+	return "a value"
+}
+
+// plural turns the description of a type into that of several values
+func plural(description string) string {
+	switch {
+	case strings.HasSuffix(description, "string"), strings.HasSuffix(description, "boolean"),
+		strings.HasSuffix(description, "integer"), strings.HasSuffix(description, "number"),
+		strings.HasSuffix(description, "object"), strings.HasSuffix(description, "value"):
+		return description + "s"
+	case strings.Contains(description, "list of"):
+		return strings.Replace(description, "list of", "lists of", 1)
+	}
+	return description
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecodeArgs(t *testing.T) {
+	type filter struct {
+		Field string `arg:"field,required"`
+		Value string `arg:"value"`
+	}
+	type args struct {
+		URL       string            `arg:"url,required"`
+		Tags      []string          `arg:"tags"`
+		Limit     int               `arg:"limit"`
+		Ratio     float64           `arg:"ratio"`
+		Verbose   bool              `arg:"verbose"`
+		Offset    *int              `arg:"offset"`
+		Headers   map[string]string `arg:"headers"`
+		Filter    filter            `arg:"filter"`
+		Raw       interface{}       `arg:"raw"`
+		Untagged  string
+		Unexposed string `arg:"-"`
+	}
+
+	var got args
+	got.Limit = 10 // Default kept when absent
+	err := DecodeArgs(map[string]interface{}{
+		"url":      "https://example.com",
+		"tags":     "go", // A single value stands for a list
+		"ratio":    "0.5",
+		"verbose":  "true",
+		"offset":   float64(3),
+		"headers":  map[string]interface{}{"Accept": "text/html"},
+		"filter":   map[string]interface{}{"field": "lang"},
+		"raw":      []interface{}{1.0, "x"},
+		"Untagged": "ignored",
+	}, &got)
+	if err != nil {
+		t.Fatalf("DecodeArgs failed: %v", err)
+	}
+	offset := 3
+	want := args{
+		URL: "https://example.com", Tags: []string{"go"}, Limit: 10, Ratio: 0.5, Verbose: true,
+		Offset: &offset, Headers: map[string]string{"Accept": "text/html"}, Filter: filter{Field: "lang"},
+		Raw: []interface{}{1.0, "x"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %+v, expected %+v", got, want)
+	}
+
+	failures := []struct {
+		arguments map[string]interface{}
+		want      string
+	}{
+		{map[string]interface{}{}, "url argument is required and must be a non-empty string"},
+		{map[string]interface{}{"url": "  "}, "url argument is required and must be a non-empty string"},
+		{map[string]interface{}{"url": "u", "limit": 2.5}, "limit argument must be an integer"},
+		{map[string]interface{}{"url": "u", "tags": []interface{}{"a", 1.0}}, "tags[1] argument must be a string"},
+		{map[string]interface{}{"url": "u", "tags": true}, "tags argument must be a list of strings"},
+		{map[string]interface{}{"url": "u", "verbose": "maybe"}, "verbose argument must be a boolean"},
+		{map[string]interface{}{"url": "u", "filter": map[string]interface{}{}}, "filter.field argument is required and must be a non-empty string"},
+	}
+	for _, tt := range failures {
+		var got args
+		if err := DecodeArgs(tt.arguments, &got); err == nil || err.Error() != tt.want {
+			t.Errorf("DecodeArgs(%v) = %v, expected %q", tt.arguments, err, tt.want)
+		}
+	}
+
+	if err := DecodeArgs(nil, args{}); err == nil {
+		t.Error("Expected a non-pointer target to be refused")
+	}
+}
@@ -0,0 +1,322 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// diffContextLines is the number of unchanged lines shown around changes
+const diffContextLines = 3
+
+// maxDiffCells bounds the line-matching table of unifiedDiff; larger changed
+// regions are shown as a single replacement
+const maxDiffCells = 4 << 20
+
+// diffOp is one line of a line diff: ' ' kept, '-' removed or '+' added
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// splitLines splits text into lines without their line feeds. The second
+// result reports whether text ended with a line feed.
+func splitLines(text string) ([]string, bool) {
+	if text == "" {
+		return nil, false
+	}
+	trailing := strings.HasSuffix(text, "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n"), trailing
+}
+
+// joinLines is the inverse of splitLines
+func joinLines(lines []string, trailing bool) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	text := strings.Join(lines, "\n")
+	if trailing {
+		text += "\n"
+	}
+	return text
+}
+
+// unifiedDiff returns the unified diff turning oldText into newText, or ""
+// when their lines are equal
+func unifiedDiff(oldName, newName, oldText, newText string) string {
+	a, _ := splitLines(oldText)
+	b, _ := splitLines(newText)
+	ops := diffLines(a, b)
+
+	changed := false
+	for _, op := range ops {
+		if op.kind != ' ' {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	// Group the changes, with their context, into hunks
+	for start := 0; start < len(ops); {
+		// Find the next change
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		hunkStart := first - diffContextLines
+		if hunkStart < start {
+			hunkStart = start
+		}
+
+		// Extend the hunk while changes are close enough to share context
+		end := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i + 1
+				continue
+			}
+			if i-end >= 2*diffContextLines {
+				break
+			}
+		}
+		hunkEnd := end + diffContextLines
+		if hunkEnd > len(ops) {
+			hunkEnd = len(ops)
+		}
+
+		// Line numbers of the hunk in both texts
+		oldLine, newLine := 1, 1
+		for _, op := range ops[:hunkStart] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, op := range ops[hunkStart:hunkEnd] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		if oldCount == 0 {
+			oldLine--
+		}
+		if newCount == 0 {
+			newLine--
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, op := range ops[hunkStart:hunkEnd] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.text)
+			out.WriteByte('\n')
+		}
+		start = hunkEnd
+	}
+	return out.String()
+}
+
+// hunkRange formats the start,count pair of a hunk header
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// diffLines returns the operations turning a into b. Common leading and
+// trailing lines are matched directly and the rest by longest common
+// subsequence.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// diffMiddle diffs the differing middle of two texts
+func diffMiddle(a, b []string) []diffOp {
+	var ops []diffOp
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:]
+	// and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// hunkHeader matches "@@ -start[,count] +start[,count] @@"
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// applyPatch applies the hunks of a unified diff to text. Each hunk is
+// located by its context and removed lines, preferring the position closest
+// to the one in its header, so patches still apply after nearby edits.
+func applyPatch(text, patch string) (string, error) {
+	lines, trailing := splitLines(text)
+	patchLines, _ := splitLines(patch)
+
+	offset := 0 // shift of line numbers caused by earlier hunks
+	for i := 0; i < len(patchLines); {
+		m := hunkHeader.FindStringSubmatch(patchLines[i])
+		if m == nil {
+			// File headers and other preamble
+			i++
+			continue
+		}
+		hunkNumber := patchLines[i]
+		oldStart, _ := strconv.Atoi(m[1])
+		i++
+
+		var oldLines, newLines []string
+		for ; i < len(patchLines) && !strings.HasPrefix(patchLines[i], "@@"); i++ {
+			line := patchLines[i]
+			switch {
+			case strings.HasPrefix(line, "\\"):
+				// "\ No newline at end of file"
+			case strings.HasPrefix(line, "---") || strings.HasPrefix(line, "+++"):
+				// Headers of a following file in a multi-file patch
+			case line == "":
+				// Editors often strip the space of empty context lines
+				oldLines = append(oldLines, "")
+				newLines = append(newLines, "")
+			case line[0] == ' ':
+				oldLines = append(oldLines, line[1:])
+				newLines = append(newLines, line[1:])
+			case line[0] == '-':
+				oldLines = append(oldLines, line[1:])
+			case line[0] == '+':
+				newLines = append(newLines, line[1:])
+			default:
+				return "", fmt.Errorf("invalid line in hunk %s: %q", hunkNumber, line)
+			}
+		}
+
+		want := oldStart - 1 + offset
+		if len(oldLines) == 0 {
+			// Pure insertion after line oldStart
+			want = oldStart + offset
+		}
+		at := findBlock(lines, oldLines, want)
+		if at < 0 {
+			return "", fmt.Errorf("hunk %s does not apply: its context was not found", hunkNumber)
+		}
+
+		replaced := make([]string, 0, len(lines)-len(oldLines)+len(newLines))
+		replaced = append(replaced, lines[:at]...)
+		replaced = append(replaced, newLines...)
+		replaced = append(replaced, lines[at+len(oldLines):]...)
+		lines = replaced
+		offset += len(newLines) - len(oldLines)
+	}
+	if len(lines) > 0 && !trailing && text == "" {
+		trailing = true
+	}
+	return joinLines(lines, trailing), nil
+}
+
+// findBlock returns the index of the occurrence of block in lines closest to
+// want, or -1
+func findBlock(lines, block []string, want int) int {
+	if want < 0 {
+		want = 0
+	}
+	if want > len(lines) {
+		want = len(lines)
+	}
+	if len(block) == 0 {
+		return want
+	}
+	for distance := 0; distance <= len(lines); distance++ {
+		for _, at := range []int{want - distance, want + distance} {
+			if at < 0 || at+len(block) > len(lines) {
+				continue
+			}
+			if blockAt(lines, block, at) {
+				return at
+			}
+		}
+	}
+	return -1
+}
+
+func blockAt(lines, block []string, at int) bool {
+	for k, line := range block {
+		if lines[at+k] != line {
+			return false
+		}
+	}
+	return true
+}
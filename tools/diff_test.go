@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	newText := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+
+	want := "--- old\n+++ new\n" +
+		"@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n" +
+		"@@ -11,3 +11,4 @@\n k\n l\n m\n+n\n"
+	if got := unifiedDiff("old", "new", oldText, newText); got != want {
+		t.Errorf("unifiedDiff =\n%s\nwant\n%s", got, want)
+	}
+	if got := unifiedDiff("old", "new", oldText, oldText); got != "" {
+		t.Errorf("Expected no diff for equal texts, got %q", got)
+	}
+	if got := unifiedDiff("old", "new", "", "x\n"); !strings.Contains(got, "@@ -0,0 +1 @@\n+x\n") {
+		t.Errorf("Unexpected diff against an empty text: %q", got)
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	oldText := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	newText := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+
+	// A patch produced by unifiedDiff round-trips
+	patch := unifiedDiff("old", "new", oldText, newText)
+	if got, err := applyPatch(oldText, patch); err != nil || got != newText {
+		t.Errorf("applyPatch = %q, %v, want %q", got, err, newText)
+	}
+
+	// Hunks still apply when the lines moved
+	shifted := "x\ny\n" + oldText
+	if got, err := applyPatch(shifted, patch); err != nil || got != "x\ny\n"+newText {
+		t.Errorf("Expected the patch to apply at an offset, got %q, %v", got, err)
+	}
+
+	// A hunk whose context is missing is rejected
+	if _, err := applyPatch("unrelated\n", patch); err == nil || !strings.Contains(err.Error(), "does not apply") {
+		t.Errorf("Expected a does not apply error, got %v", err)
+	}
+}
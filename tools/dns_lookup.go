@@ -0,0 +1,223 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// DefaultDNSLookupTimeout bounds a dns_lookup call when the configuration
+// leaves it unset
+const DefaultDNSLookupTimeout = 10 * time.Second
+
+// dnsRecordTypes are the record types dns_lookup resolves, in output order
+var dnsRecordTypes = []string{"A", "AAAA", "CNAME", "MX", "TXT"}
+
+// DNSLookupTool represents the dns_lookup tool definition
+type DNSLookupTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// GetDNSLookupTool returns the dns_lookup tool definition
+func GetDNSLookupTool() DNSLookupTool {
+	return DNSLookupTool{
+		Name:        "dns_lookup",
+		Description: "Resolve the DNS records of a domain name, optionally through a specific resolver",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name": map[string]interface{}{
+					"type":        "string",
+					"description": "The domain name, such as example.com",
+				},
+				"types": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string", "enum": dnsRecordTypes},
+					"description": "Record types to resolve (default: all of A, AAAA, CNAME, MX and TXT)",
+				},
+				"resolver": map[string]interface{}{
+					"type":        "string",
+					"description": "IP address of the DNS server to ask, with an optional port, such as 1.1.1.1 or [2606:4700::1111]:53 (default: the configured resolver)",
+				},
+			},
+			"required": []string{"name"},
+		},
+	}
+}
+
+// DNSLookupConfig holds the configuration for the dns_lookup tool
+type DNSLookupConfig struct {
+	Resolver string // Empty = the system resolver
+	Timeout  time.Duration
+}
+
+var dnsLookupConfig *DNSLookupConfig
+
+// SetDNSLookupConfig enables the dns_lookup tool. A zero timeout selects
+// the default.
+func SetDNSLookupConfig(resolver string, timeout time.Duration) error {
+	if resolver != "" {
+		address, err := resolverAddress(resolver)
+		if err != nil {
+			return err
+		}
+		resolver = address
+	}
+	if timeout <= 0 {
+		timeout = DefaultDNSLookupTimeout
+	}
+	dnsLookupConfig = &DNSLookupConfig{Resolver: resolver, Timeout: timeout}
+	return nil
+}
+
+// GetDNSLookupConfig returns the current configuration, nil when the tool
+// is disabled
+func GetDNSLookupConfig() *DNSLookupConfig {
+	return dnsLookupConfig
+}
+
+// resolverAddress returns the host:port of a resolver given as an IP
+// address with an optional port. Host names are refused so that the
+// resolver itself needs no lookup.
+func resolverAddress(resolver string) (string, error) {
+	host, port, err := net.SplitHostPort(resolver)
+	if err != nil {
+		host, port = strings.Trim(resolver, "[]"), "53"
+	}
+	if net.ParseIP(host) == nil {
+		return "", fmt.Errorf("resolver must be an IP address with an optional port, got %q", resolver)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// CallDNSLookup resolves the records of a name
+func CallDNSLookup(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+	config := dnsLookupConfig
+	if config == nil {
+		return nil, fmt.Errorf("dns_lookup not configured. Set dns_lookup.enabled in the config file")
+	}
+	var args struct {
+		Name     string   `arg:"name,required"`
+		Types    []string `arg:"types"`
+		Resolver string   `arg:"resolver"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	name := strings.TrimSuffix(strings.TrimSpace(args.Name), ".")
+	if name == "" {
+		return nil, fmt.Errorf("name argument is required and must be a non-empty string")
+	}
+	types := args.Types
+	if len(types) == 0 {
+		types = dnsRecordTypes
+	}
+	supported := map[string]bool{}
+	for _, t := range dnsRecordTypes {
+		supported[t] = true
+	}
+	wanted := map[string]bool{}
+	for _, t := range types {
+		t = strings.ToUpper(t)
+		if !supported[t] {
+			return nil, fmt.Errorf("unsupported record type %q, use %s", t, strings.Join(dnsRecordTypes, ", "))
+		}
+		wanted[t] = true
+	}
+
+	server := config.Resolver
+	if args.Resolver != "" {
+		var err error
+		if server, err = resolverAddress(args.Resolver); err != nil {
+			return nil, err
+		}
+	}
+	resolver := net.DefaultResolver
+	if server != "" {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, server)
+			},
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "DNS records of %s", name)
+	if server != "" {
+		fmt.Fprintf(&result, " from %s", server)
+	}
+	result.WriteString("\n")
+	for _, t := range dnsRecordTypes {
+		if !wanted[t] {
+			continue
+		}
+		records, err := lookupRecords(ctx, resolver, name, t)
+		fmt.Fprintf(&result, "\n%s:\n", t)
+		var dnsErr *net.DNSError
+		switch {
+		case errors.As(err, &dnsErr) && dnsErr.IsNotFound, err == nil && len(records) == 0:
+			result.WriteString("  (none)\n")
+		case err != nil:
+			fmt.Fprintf(&result, "  error: %v\n", err)
+		default:
+			for _, record := range records {
+				fmt.Fprintf(&result, "  %s\n", record)
+			}
+		}
+	}
+	return TextResult(strings.TrimSuffix(result.String(), "\n")), nil
+}
+
+// lookupRecords returns the records of one type as text
+func lookupRecords(ctx context.Context, resolver *net.Resolver, name, recordType string) ([]string, error) {
+	var records []string
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			records = append(records, ip.String())
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		// The canonical name of a name without CNAME is the name itself
+		if !strings.EqualFold(strings.TrimSuffix(cname, "."), name) {
+			records = append(records, cname)
+		}
+	case "MX":
+		mxs, err := resolver.LookupMX(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			records = append(records, fmt.Sprintf("%d %s", mx.Pref, mx.Host))
+		}
+	case "TXT":
+		txts, err := resolver.LookupTXT(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, txt := range txts {
+			records = append(records, fmt.Sprintf("%q", txt))
+		}
+	}
+	return records, nil
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+)
+
+// serveDNS answers the A and TXT queries of example.test on a local UDP
+// port and returns its address
+func serveDNS(t *testing.T) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			query := buf[:n]
+			// The question follows the 12-byte header: labels, then type and class
+			end := 12
+			var labels []string
+			for end < n && query[end] != 0 {
+				labels = append(labels, string(query[end+1:end+1+int(query[end])]))
+				end += 1 + int(query[end])
+			}
+			end += 5
+			qtype := binary.BigEndian.Uint16(query[end-4:])
+
+			var answers [][]byte
+			if strings.EqualFold(strings.Join(labels, "."), "example.test") {
+				switch qtype {
+				case 1: // A
+					answers = append(answers, dnsAnswer(1, []byte{192, 0, 2, 1}))
+				case 16: // TXT
+					answers = append(answers, dnsAnswer(16, append([]byte{11}, "v=spf1 -all"...)))
+				}
+			}
+			response := append([]byte{}, query[:2]...)
+			response = append(response, 0x81, 0x80, 0, 1, 0, byte(len(answers)), 0, 0, 0, 0)
+			response = append(response, query[12:end]...)
+			for _, answer := range answers {
+				response = append(response, answer...)
+			}
+			conn.WriteTo(response, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// dnsAnswer builds a resource record for the name of the question
+func dnsAnswer(rtype uint16, data []byte) []byte {
+	record := []byte{0xc0, 12, 0, byte(rtype), 0, 1, 0, 0, 0, 60, 0, byte(len(data))}
+	return append(record, data...)
+}
+
+func TestCallDNSLookup(t *testing.T) {
+	resolver := serveDNS(t)
+	defer func() { dnsLookupConfig = nil }()
+
+	if err := SetDNSLookupConfig("dns.example", 0); err == nil {
+		t.Error("Expected a resolver given by name to be refused")
+	}
+	if err := SetDNSLookupConfig(resolver, 0); err != nil {
+		t.Fatalf("SetDNSLookupConfig failed: %v", err)
+	}
+
+	result, err := resultText(CallDNSLookup(context.Background(), map[string]interface{}{
+		"name":  "example.test.",
+		"types": []interface{}{"a", "TXT", "MX"},
+	}))
+	if err != nil {
+		t.Fatalf("CallDNSLookup failed: %v", err)
+	}
+	expected := "DNS records of example.test from " + resolver + "\n\nA:\n  192.0.2.1\n\nMX:\n  (none)\n\nTXT:\n  \"v=spf1 -all\""
+	if result != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, result)
+	}
+
+	if _, err := CallDNSLookup(context.Background(), map[string]interface{}{"name": "example.test", "types": []interface{}{"SOA"}}); err == nil {
+		t.Error("Expected an unsupported record type to be refused")
+	}
+	if _, err := CallDNSLookup(context.Background(), map[string]interface{}{"name": "example.test", "resolver": "localhost"}); err == nil {
+		t.Error("Expected a resolver given by name to be refused")
+	}
+}
@@ -0,0 +1,528 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults for the docker tools when the configuration leaves them unset
+const (
+	DefaultDockerHost      = "unix:///var/run/docker.sock"
+	DefaultDockerTimeout   = 30 * time.Second
+	DefaultDockerMaxOutput = 1 << 20
+)
+
+// DockerTool represents a docker tool definition
+type DockerTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// containerProperty is the schema of the container argument of the docker
+// tools
+var containerProperty = map[string]interface{}{
+	"type":        "string",
+	"description": "Name or ID of the container",
+}
+
+// GetDockerTools returns the definitions of the docker tools.
+// docker_exec and docker_restart are only enabled by their configuration
+// flags; see DockerToolEnabled.
+func GetDockerTools() []DockerTool {
+	return []DockerTool{
+		{
+			Name:        "docker_ps",
+			Description: "List Docker containers with their image, state and status",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"all": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Include stopped containers (default: false)",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list containers whose name contains this text",
+					},
+				},
+			},
+		},
+		{
+			Name:        "docker_logs",
+			Description: "Show the logs of a Docker container",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"container": containerProperty,
+					"tail": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of lines from the end of the logs (default: 100)",
+						"default":     100,
+						"minimum":     1,
+					},
+					"since": map[string]interface{}{
+						"type":        "string",
+						"description": "Only show logs since a duration ago (e.g. \"15m\") or an RFC 3339 time",
+					},
+					"timestamps": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Prefix every line with its timestamp (default: false)",
+					},
+				},
+				"required": []string{"container"},
+			},
+		},
+		{
+			Name:        "docker_inspect",
+			Description: "Show the low-level details of a Docker container as JSON: configuration, state, mounts and network settings",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"container": containerProperty,
+				},
+				"required": []string{"container"},
+			},
+		},
+		{
+			Name:        "docker_exec",
+			Description: "Run a command in a running Docker container and return its output and exit code",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"container": containerProperty,
+					"command": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "The command and its arguments, e.g. [\"ls\", \"-la\", \"/app\"]",
+					},
+					"workdir": map[string]interface{}{
+						"type":        "string",
+						"description": "Working directory inside the container",
+					},
+					"user": map[string]interface{}{
+						"type":        "string",
+						"description": "User to run the command as",
+					},
+				},
+				"required": []string{"container", "command"},
+			},
+		},
+		{
+			Name:        "docker_restart",
+			Description: "Restart a Docker container",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"container": containerProperty,
+					"timeout": map[string]interface{}{
+						"type":        "integer",
+						"description": "Seconds to wait for the container to stop before killing it (default: 10)",
+						"minimum":     0,
+					},
+				},
+				"required": []string{"container"},
+			},
+		},
+	}
+}
+
+// IsDockerTool reports whether name is one of the docker tools
+func IsDockerTool(name string) bool {
+	for _, tool := range GetDockerTools() {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DockerConfig holds the configuration for the docker tools
+type DockerConfig struct {
+	// Host is the Docker daemon address: "unix:///path/to/socket",
+	// "tcp://host:port" or an http URL
+	Host           string
+	AllowExec      bool
+	AllowRestart   bool
+	Timeout        time.Duration
+	MaxOutputBytes int64
+
+	client  *http.Client
+	baseURL string
+}
+
+var dockerConfig *DockerConfig
+
+// SetDockerConfig enables the docker tools. An empty host selects the local
+// socket, and a zero timeout or output limit the default.
+func SetDockerConfig(host string, allowExec, allowRestart bool, timeout time.Duration, maxOutputBytes int64) error {
+	if host == "" {
+		host = DefaultDockerHost
+	}
+	if timeout <= 0 {
+		timeout = DefaultDockerTimeout
+	}
+	if maxOutputBytes <= 0 {
+		maxOutputBytes = DefaultDockerMaxOutput
+	}
+
+	config := &DockerConfig{Host: host, AllowExec: allowExec, AllowRestart: allowRestart, Timeout: timeout, MaxOutputBytes: maxOutputBytes}
+	switch {
+	case strings.HasPrefix(host, "unix://"):
+		socket := strings.TrimPrefix(host, "unix://")
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}
+		config.client = &http.Client{Transport: transport}
+		config.baseURL = "http://docker"
+	case strings.HasPrefix(host, "tcp://"):
+		config.client = &http.Client{}
+		config.baseURL = "http://" + strings.TrimPrefix(host, "tcp://")
+	case strings.HasPrefix(host, "http://"), strings.HasPrefix(host, "https://"):
+		config.client = &http.Client{}
+		config.baseURL = strings.TrimRight(host, "/")
+	default:
+		return fmt.Errorf("unsupported Docker host %q, use unix://, tcp:// or http(s)://", host)
+	}
+	dockerConfig = config
+	return nil
+}
+
+// GetDockerConfig returns the current configuration, nil when the tools are
+// disabled
+func GetDockerConfig() *DockerConfig {
+	return dockerConfig
+}
+
+// DockerToolEnabled reports whether the named docker tool is enabled:
+// docker_exec and docker_restart need their configuration flags
+func DockerToolEnabled(name string) bool {
+	config := dockerConfig
+	if config == nil || !IsDockerTool(name) {
+		return false
+	}
+	switch name {
+	case "docker_exec":
+		return config.AllowExec
+	case "docker_restart":
+		return config.AllowRestart
+	}
+	return true
+}
+
+// CallDockerTool executes the named docker tool
+func CallDockerTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+	config := dockerConfig
+	if config == nil {
+		return nil, fmt.Errorf("docker tools not configured. Set docker.enabled in the config file")
+	}
+	if !DockerToolEnabled(name) {
+		return nil, fmt.Errorf("%s is disabled; enable it with docker.allow_%s", name, strings.TrimPrefix(name, "docker_"))
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	if name == "docker_ps" {
+		return textResult(config.ps(ctx, arguments))
+	}
+
+	args := struct {
+		Container string `arg:"container,required"`
+		Timeout   int    `arg:"timeout"`
+	}{Timeout: -1}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	container := args.Container
+	switch name {
+	case "docker_logs":
+		return textResult(config.logs(ctx, container, arguments))
+	case "docker_inspect":
+		info, err := config.get(ctx, "/containers/"+url.PathEscape(container)+"/json", nil)
+		if err != nil {
+			return nil, err
+		}
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, info, "", "  "); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		return TextResult(config.limit(indented.Bytes())), nil
+	case "docker_exec":
+		return textResult(config.exec(ctx, container, arguments))
+	case "docker_restart":
+		query := url.Values{}
+		if args.Timeout >= 0 {
+			query.Set("t", strconv.Itoa(args.Timeout))
+		}
+		if _, err := config.post(ctx, "/containers/"+url.PathEscape(container)+"/restart", query, nil); err != nil {
+			return nil, err
+		}
+		return TextResult(fmt.Sprintf("Restarted container %s", container)), nil
+	default:
+		return nil, fmt.Errorf("unknown docker tool: %s", name)
+	}
+}
+
+// ps lists the containers
+func (c *DockerConfig) ps(ctx context.Context, arguments map[string]interface{}) (string, error) {
+	var args struct {
+		All  bool   `arg:"all"`
+		Name string `arg:"name"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	if args.All {
+		query.Set("all", "1")
+	}
+	if args.Name != "" {
+		filters, _ := json.Marshal(map[string][]string{"name": {args.Name}})
+		query.Set("filters", string(filters))
+	}
+
+	body, err := c.get(ctx, "/containers/json", query)
+	if err != nil {
+		return "", err
+	}
+	var containers []struct {
+		ID     string   `json:"Id"`
+		Names  []string `json:"Names"`
+		Image  string   `json:"Image"`
+		State  string   `json:"State"`
+		Status string   `json:"Status"`
+		Ports  []struct {
+			IP          string `json:"IP"`
+			PrivatePort int    `json:"PrivatePort"`
+			PublicPort  int    `json:"PublicPort"`
+			Type        string `json:"Type"`
+		} `json:"Ports"`
+	}
+	if err := json.Unmarshal(body, &containers); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(containers) == 0 {
+		return "No containers found.", nil
+	}
+
+	var b strings.Builder
+	b.WriteString("CONTAINER ID\tNAMES\tIMAGE\tSTATE\tSTATUS\tPORTS\n")
+	for _, container := range containers {
+		names := make([]string, len(container.Names))
+		for i, name := range container.Names {
+			names[i] = strings.TrimPrefix(name, "/")
+		}
+		var ports []string
+		for _, port := range container.Ports {
+			if port.PublicPort != 0 {
+				ports = append(ports, fmt.Sprintf("%s:%d->%d/%s", port.IP, port.PublicPort, port.PrivatePort, port.Type))
+			} else {
+				ports = append(ports, fmt.Sprintf("%d/%s", port.PrivatePort, port.Type))
+			}
+		}
+		id := container.ID
+		if len(id) > 12 {
+			id = id[:12]
+		}
+		fmt.Fprintf(&b, "%s\t%s\t%s\t%s\t%s\t%s\n", id, strings.Join(names, ","), container.Image, container.State, container.Status, strings.Join(ports, ", "))
+	}
+	return b.String(), nil
+}
+
+// logs returns the logs of a container
+func (c *DockerConfig) logs(ctx context.Context, container string, arguments map[string]interface{}) (string, error) {
+	args := struct {
+		Tail       int    `arg:"tail"`
+		Timestamps bool   `arg:"timestamps"`
+		Since      string `arg:"since"`
+	}{Tail: 100}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("stdout", "1")
+	query.Set("stderr", "1")
+	if args.Tail > 0 {
+		query.Set("tail", strconv.Itoa(args.Tail))
+	}
+	if args.Timestamps {
+		query.Set("timestamps", "1")
+	}
+	if since := args.Since; since != "" {
+		if d, err := time.ParseDuration(since); err == nil {
+			query.Set("since", strconv.FormatInt(time.Now().Add(-d).Unix(), 10))
+		} else if t, err := time.Parse(time.RFC3339, since); err == nil {
+			query.Set("since", strconv.FormatInt(t.Unix(), 10))
+		} else {
+			return "", fmt.Errorf("since must be a duration such as \"15m\" or an RFC 3339 time")
+		}
+	}
+
+	// Logs of containers without a TTY are multiplexed, see demuxDockerStream
+	info, err := c.get(ctx, "/containers/"+url.PathEscape(container)+"/json", nil)
+	if err != nil {
+		return "", err
+	}
+	var details struct {
+		Config struct {
+			Tty bool `json:"Tty"`
+		} `json:"Config"`
+	}
+	if err := json.Unmarshal(info, &details); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	body, err := c.get(ctx, "/containers/"+url.PathEscape(container)+"/logs", query)
+	if err != nil {
+		return "", err
+	}
+	if !details.Config.Tty {
+		body = demuxDockerStream(body)
+	}
+	if len(body) == 0 {
+		return "No logs.", nil
+	}
+	return c.limit(body), nil
+}
+
+// exec runs a command in a container
+func (c *DockerConfig) exec(ctx context.Context, container string, arguments map[string]interface{}) (string, error) {
+	var args struct {
+		Command []string `arg:"command,required"`
+		Workdir string   `arg:"workdir"`
+		User    string   `arg:"user"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return "", err
+	}
+	spec := map[string]interface{}{"Cmd": args.Command, "AttachStdout": true, "AttachStderr": true}
+	if args.Workdir != "" {
+		spec["WorkingDir"] = args.Workdir
+	}
+	if args.User != "" {
+		spec["User"] = args.User
+	}
+
+	created, err := c.post(ctx, "/containers/"+url.PathEscape(container)+"/exec", nil, spec)
+	if err != nil {
+		return "", err
+	}
+	var execInstance struct {
+		ID string `json:"Id"`
+	}
+	if err := json.Unmarshal(created, &execInstance); err != nil || execInstance.ID == "" {
+		return "", fmt.Errorf("failed to create exec instance: %s", created)
+	}
+
+	output, err := c.post(ctx, "/exec/"+execInstance.ID+"/start", nil, map[string]interface{}{"Detach": false, "Tty": false})
+	if err != nil {
+		return "", err
+	}
+	info, err := c.get(ctx, "/exec/"+execInstance.ID+"/json", nil)
+	if err != nil {
+		return "", err
+	}
+	var state struct {
+		ExitCode int `json:"ExitCode"`
+	}
+	if err := json.Unmarshal(info, &state); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	return fmt.Sprintf("Exit code: %d\n\n%s", state.ExitCode, c.limit(demuxDockerStream(output))), nil
+}
+
+// get sends a GET request to the Docker API and returns the response body
+func (c *DockerConfig) get(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	return c.do(ctx, "GET", path, query, nil)
+}
+
+// post sends a POST request with an optional JSON body to the Docker API
+func (c *DockerConfig) post(ctx context.Context, path string, query url.Values, body interface{}) ([]byte, error) {
+	return c.do(ctx, "POST", path, query, body)
+}
+
+func (c *DockerConfig) do(ctx context.Context, method, path string, query url.Values, body interface{}) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the Docker daemon at %s: %w", c.Host, err)
+	}
+	defer resp.Body.Close()
+
+	// Read one byte past the limit so limit can tell output was cut
+	data, err := io.ReadAll(io.LimitReader(resp.Body, c.MaxOutputBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("Docker: %s", apiErr.Message)
+		}
+		return nil, fmt.Errorf("Docker returned status %d", resp.StatusCode)
+	}
+	return data, nil
+}
+
+// limit returns output as a string, truncated to the configured size
+func (c *DockerConfig) limit(output []byte) string {
+	if int64(len(output)) > c.MaxOutputBytes {
+		return string(output[:c.MaxOutputBytes]) + fmt.Sprintf("\n[Output truncated after %d bytes]", c.MaxOutputBytes)
+	}
+	return string(output)
+}
+
+// demuxDockerStream joins the frames of a multiplexed Docker stream. Each
+// frame has an 8 byte header: the stream (1 stdout, 2 stderr), three zero
+// bytes and the big-endian length of the payload.
+func demuxDockerStream(stream []byte) []byte {
+	var out []byte
+	for len(stream) >= 8 {
+		size := int(binary.BigEndian.Uint32(stream[4:8]))
+		if stream[0] > 2 || stream[1] != 0 || stream[2] != 0 || stream[3] != 0 {
+			// Not a multiplexed stream after all
+			return append(out, stream...)
+		}
+		stream = stream[8:]
+		if size > len(stream) {
+			size = len(stream) // Truncated by the output limit
+		}
+		out = append(out, stream[:size]...)
+		stream = stream[size:]
+	}
+	return out
+}
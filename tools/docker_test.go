@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// dockerFrame encodes a frame of a multiplexed Docker stream
+func dockerFrame(stream byte, payload string) string {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return string(header) + payload
+}
+
+func TestCallDockerTool(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /containers/json":
+			w.Write([]byte(`[{"Id":"0123456789abcdef","Names":["/web"],"Image":"nginx","State":"running","Status":"Up 2 hours",` +
+				`"Ports":[{"IP":"0.0.0.0","PrivatePort":80,"PublicPort":8080,"Type":"tcp"}]}]`))
+		case "GET /containers/web/json":
+			w.Write([]byte(`{"Id":"0123456789abcdef","Config":{"Tty":false}}`))
+		case "GET /containers/web/logs":
+			if r.URL.Query().Get("tail") != "2" {
+				http.Error(w, "bad tail", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(dockerFrame(1, "started\n") + dockerFrame(2, "warning: slow\n")))
+		case "GET /containers/missing/json":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"No such container: missing"}`))
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	if err := SetDockerConfig("tcp://"+strings.TrimPrefix(server.URL, "http://"), false, false, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { dockerConfig = nil }()
+	ctx := context.Background()
+
+	result, err := resultText(CallDockerTool(ctx, "docker_ps", map[string]interface{}{}))
+	if err != nil || !strings.Contains(result, "0123456789ab\tweb\tnginx\trunning\tUp 2 hours\t0.0.0.0:8080->80/tcp") {
+		t.Errorf("docker_ps = %q, %v", result, err)
+	}
+	result, err = resultText(CallDockerTool(ctx, "docker_logs", map[string]interface{}{"container": "web", "tail": float64(2)}))
+	if err != nil || result != "started\nwarning: slow\n" {
+		t.Errorf("docker_logs = %q, %v", result, err)
+	}
+	if _, err := CallDockerTool(ctx, "docker_inspect", map[string]interface{}{"container": "missing"}); err == nil || !strings.Contains(err.Error(), "No such container") {
+		t.Errorf("Expected the daemon's error, got %v", err)
+	}
+	if DockerToolEnabled("docker_exec") {
+		t.Error("docker_exec should need allow_exec")
+	}
+	if _, err := CallDockerTool(ctx, "docker_restart", map[string]interface{}{"container": "web"}); err == nil || !strings.Contains(err.Error(), "docker.allow_restart") {
+		t.Errorf("Expected docker_restart to be disabled, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// duckDuckGoURL is the DuckDuckGo HTML endpoint, which needs no API key
+var duckDuckGoURL = "https://html.duckduckgo.com/html/"
+
+// DuckDuckGoTool represents the DuckDuckGo search tool definition
+type DuckDuckGoTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// GetDuckDuckGoTool returns the DuckDuckGo search tool definition
+func GetDuckDuckGoTool() DuckDuckGoTool {
+	return DuckDuckGoTool{
+		Name:        "duckduckgo_search",
+		Description: "Search the web using DuckDuckGo",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "The search query",
+				},
+				"max_results": map[string]interface{}{
+					"type":        "integer",
+					"description": "Number of results to return (1-20, default: 10)",
+					"default":     10,
+					"minimum":     1,
+					"maximum":     20,
+				},
+				"region": map[string]interface{}{
+					"type":        "string",
+					"description": "Region of the results, e.g. \"us-en\" or \"de-de\" (default: any)",
+				},
+			},
+			"required": []string{"query"},
+		},
+	}
+}
+
+var duckDuckGoEnabled bool
+
+// SetDuckDuckGoEnabled lists or hides the DuckDuckGo search tool
+func SetDuckDuckGoEnabled(enabled bool) {
+	duckDuckGoEnabled = enabled
+}
+
+// DuckDuckGoEnabled reports whether the DuckDuckGo search tool is listed
+func DuckDuckGoEnabled() bool {
+	return duckDuckGoEnabled
+}
+
+// CallDuckDuckGo executes a DuckDuckGo search by reading its HTML results
+// page
+func CallDuckDuckGo(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+	args := struct {
+		Query      string `arg:"query,required"`
+		MaxResults int    `arg:"max_results"`
+		Region     string `arg:"region"`
+	}{MaxResults: 10}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	maxResults := args.MaxResults
+	if maxResults < 1 || maxResults > 20 {
+		maxResults = 10
+	}
+
+	form := url.Values{}
+	form.Set("q", args.Query)
+	if args.Region != "" {
+		form.Set("kl", args.Region)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", duckDuckGoURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; mcp-go)")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute search: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DuckDuckGo returned status %d", resp.StatusCode)
+	}
+
+	results := parseDuckDuckGoResults(string(body))
+	if len(results) > maxResults {
+		results = results[:maxResults]
+	}
+	return TextResult(formatSearchResults("", results)), nil
+}
+
+// parseDuckDuckGoResults extracts the organic results of a DuckDuckGo HTML
+// results page: each has a "result__a" title link and a "result__snippet"
+func parseDuckDuckGoResults(page string) []SearchResult {
+	var results []SearchResult
+	var open string  // The class of the link whose text is being collected
+	skipped := false // The last result was an ad
+	var text strings.Builder
+
+	for _, token := range tokenizeHTML(page) {
+		switch {
+		case token.tag == "" && open != "":
+			text.WriteString(token.text)
+		case token.tag == "a" && !token.end:
+			classes := " " + token.attrs["class"] + " "
+			switch {
+			case strings.Contains(classes, " result__a "):
+				open = ""
+				link := duckDuckGoTarget(token.attrs["href"])
+				if skipped = link == ""; !skipped {
+					results = append(results, SearchResult{Link: link})
+					open = "result__a"
+				}
+			case strings.Contains(classes, " result__snippet ") && len(results) > 0 && !skipped:
+				open = "result__snippet"
+			}
+			text.Reset()
+		case token.tag == "a" && token.end && open != "":
+			value := strings.Join(strings.Fields(text.String()), " ")
+			if open == "result__a" {
+				results[len(results)-1].Title = value
+			} else {
+				results[len(results)-1].Snippet = value
+			}
+			open = ""
+		}
+	}
+	return results
+}
+
+// duckDuckGoTarget returns the destination of a result link, which usually
+// goes through a DuckDuckGo redirect. Ads, which go through the ad click
+// endpoint, return "".
+func duckDuckGoTarget(href string) string {
+	if strings.HasPrefix(href, "//") {
+		href = "https:" + href
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	if strings.HasSuffix(u.Hostname(), "duckduckgo.com") {
+		if u.Path == "/y.js" {
+			return ""
+		}
+		if target := u.Query().Get("uddg"); target != "" {
+			return target
+		}
+	}
+	return href
+}
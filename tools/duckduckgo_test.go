@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const duckDuckGoPage = `<html><body>
+<div class="result results_links">
+  <h2 class="result__title"><a rel="nofollow" class="result__a" href="//duckduckgo.com/l/?uddg=https%3A%2F%2Fgo.dev%2F&amp;rut=abc">The <b>Go</b> Programming Language</a></h2>
+  <a class="result__snippet" href="//duckduckgo.com/l/?uddg=https%3A%2F%2Fgo.dev%2F">Go is an open source   programming language.</a>
+</div>
+<div class="result results_links result--ad">
+  <a class="result__a" href="https://duckduckgo.com/y.js?ad_provider=x&u3=ad">Buy now</a>
+  <a class="result__snippet" href="https://duckduckgo.com/y.js?ad_provider=x">Sponsored</a>
+</div>
+<div class="result results_links">
+  <a class="result__a" href="https://pkg.go.dev/">Go Packages</a>
+</div>
+</body></html>`
+
+func TestParseDuckDuckGoResults(t *testing.T) {
+	results := parseDuckDuckGoResults(duckDuckGoPage)
+	want := []SearchResult{
+		{Title: "The Go Programming Language", Link: "https://go.dev/", Snippet: "Go is an open source programming language."},
+		{Title: "Go Packages", Link: "https://pkg.go.dev/"},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("Expected %d results, got %+v", len(want), results)
+	}
+	for i := range want {
+		if results[i] != want[i] {
+			t.Errorf("Result %d: got %+v, want %+v", i, results[i], want[i])
+		}
+	}
+}
+
+func TestCallDuckDuckGo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("q") != "golang" || r.FormValue("kl") != "us-en" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(duckDuckGoPage))
+	}))
+	defer server.Close()
+	defer func(previous string) { duckDuckGoURL = previous }(duckDuckGoURL)
+	duckDuckGoURL = server.URL
+
+	result, err := resultText(CallDuckDuckGo(context.Background(), map[string]interface{}{"query": "golang", "region": "us-en", "max_results": float64(1)}))
+	if err != nil {
+		t.Fatalf("CallDuckDuckGo failed: %v", err)
+	}
+	if !strings.HasPrefix(result, "Found 1 results:\n\n1. The Go Programming Language\n   URL: https://go.dev/\n") {
+		t.Errorf("Unexpected result:\n%s", result)
+	}
+}
@@ -28,10 +28,15 @@ func GetEchoTool() EchoTool {
 }
 
 // CallEcho executes the echo tool with the given arguments
-func CallEcho(arguments map[string]interface{}) (string, error) {
-	message, ok := arguments["message"].(string)
-	if !ok {
-		return "", fmt.Errorf("message argument is required and must be a string")
+func CallEcho(arguments map[string]interface{}) (*ToolResult, error) {
+	var args struct {
+		Message *string `arg:"message"` // May be empty
 	}
-	return message, nil
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Message == nil {
+		return nil, fmt.Errorf("message argument is required and must be a string")
+	}
+	return TextResult(*args.Message), nil
 }
@@ -55,7 +55,7 @@ func TestCallEcho(t *testing.T) {
 		"message": "Test message",
 	}
 
-	result, err := CallEcho(arguments)
+	result, err := resultText(CallEcho(arguments))
 	if err != nil {
 		t.Fatalf("CallEcho returned error: %v", err)
 	}
@@ -70,7 +70,7 @@ func TestCallEchoEmptyMessage(t *testing.T) {
 		"message": "",
 	}
 
-	result, err := CallEcho(arguments)
+	result, err := resultText(CallEcho(arguments))
 	if err != nil {
 		t.Fatalf("CallEcho returned error: %v", err)
 	}
@@ -110,7 +110,7 @@ func TestCallEchoLongMessage(t *testing.T) {
 		"message": longMessage,
 	}
 
-	result, err := CallEcho(arguments)
+	result, err := resultText(CallEcho(arguments))
 	if err != nil {
 		t.Fatalf("CallEcho returned error: %v", err)
 	}
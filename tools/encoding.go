@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Text encodings read_file can decode
+const (
+	encodingUTF8    = "utf-8"
+	encodingUTF16LE = "utf-16le"
+	encodingUTF16BE = "utf-16be"
+	encodingLatin1  = "latin-1"
+)
+
+// textEncodings are the values of the read_file encoding argument that
+// decode text; "text" detects the encoding
+var textEncodings = []string{"text", encodingUTF8, encodingUTF16LE, encodingUTF16BE, encodingLatin1}
+
+// detectEncoding guesses the encoding of data: a byte order mark decides,
+// then UTF-16 is recognised by the zero bytes of ASCII text, then valid
+// UTF-8 is assumed, and anything else is taken as Latin-1
+func detectEncoding(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xef, 0xbb, 0xbf}):
+		return encodingUTF8
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		return encodingUTF16LE
+	case bytes.HasPrefix(data, []byte{0xfe, 0xff}):
+		return encodingUTF16BE
+	}
+
+	if len(data) >= 4 {
+		sample := data
+		if len(sample) > 4096 {
+			sample = sample[:4096]
+		}
+		var evenZeros, oddZeros int
+		for i, b := range sample {
+			if b != 0 {
+				continue
+			}
+			if i%2 == 0 {
+				evenZeros++
+			} else {
+				oddZeros++
+			}
+		}
+		half := len(sample) / 2
+		switch {
+		case oddZeros > half*2/5 && evenZeros <= half/10:
+			return encodingUTF16LE
+		case evenZeros > half*2/5 && oddZeros <= half/10:
+			return encodingUTF16BE
+		}
+	}
+
+	if utf8.Valid(data) {
+		return encodingUTF8
+	}
+	return encodingLatin1
+}
+
+// decodeText converts data in the given encoding to UTF-8, dropping a byte
+// order mark
+func decodeText(data []byte, encoding string) string {
+	switch encoding {
+	case encodingUTF16LE, encodingUTF16BE:
+		data = bytes.TrimPrefix(data, []byte{0xff, 0xfe})
+		data = bytes.TrimPrefix(data, []byte{0xfe, 0xff})
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			if encoding == encodingUTF16LE {
+				units[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+			} else {
+				units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+			}
+		}
+		return string(utf16.Decode(units))
+	case encodingLatin1:
+		var b strings.Builder
+		b.Grow(len(data) * 2)
+		for _, c := range data {
+			b.WriteRune(rune(c))
+		}
+		return b.String()
+	default:
+		return string(bytes.TrimPrefix(data, []byte{0xef, 0xbb, 0xbf}))
+	}
+}
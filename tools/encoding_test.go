@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+		text string
+	}{
+		{"utf-8", []byte("héllo"), encodingUTF8, "héllo"},
+		{"utf-8 bom", []byte("\xef\xbb\xbfhi"), encodingUTF8, "hi"},
+		{"utf-16le bom", []byte("\xff\xfeh\x00\xe9\x00"), encodingUTF16LE, "hé"},
+		{"utf-16be bom", []byte("\xfe\xff\x00h\x00\xe9"), encodingUTF16BE, "hé"},
+		{"utf-16le without bom", []byte("h\x00e\x00l\x00l\x00o\x00"), encodingUTF16LE, "hello"},
+		{"latin-1", []byte("caf\xe9"), encodingLatin1, "café"},
+	}
+	for _, tt := range tests {
+		got := detectEncoding(tt.data)
+		if got != tt.want {
+			t.Errorf("%s: detectEncoding = %s, want %s", tt.name, got, tt.want)
+			continue
+		}
+		if text := decodeText(tt.data, got); text != tt.text {
+			t.Errorf("%s: decodeText = %q, want %q", tt.name, text, tt.text)
+		}
+	}
+}
+
+func TestReadFileEncodings(t *testing.T) {
+	root := sandbox(t)
+	file := filepath.Join(root, "windows.txt")
+	if err := os.WriteFile(file, []byte("\xff\xfeo\x00k\x00\r\x00\n\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := resultText(CallReadFile(map[string]interface{}{"path": file}))
+	if err != nil || result != "ok\r\n\n[Converted from utf-16le to UTF-8]" {
+		t.Errorf("Expected the UTF-16 file to be converted, got %q, %v", result, err)
+	}
+	if result, err := resultText(CallReadFile(map[string]interface{}{"path": file, "encoding": "UTF-16LE"})); err != nil || result != "ok\r\n" {
+		t.Errorf("Expected an explicit encoding to convert silently, got %q, %v", result, err)
+	}
+	if _, err := CallReadFile(map[string]interface{}{"path": file, "encoding": "ebcdic"}); err == nil || !strings.Contains(err.Error(), "latin-1") {
+		t.Errorf("Expected an unknown encoding to list the supported ones, got %v", err)
+	}
+}
@@ -0,0 +1,261 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+// Defaults for the fetch_page tool when the configuration leaves them unset
+const (
+	DefaultFetchPageTimeout   = 20 * time.Second
+	DefaultFetchPageMaxLength = 20000
+	// fetchPageMaxDownload bounds the bytes downloaded before conversion
+	fetchPageMaxDownload = 5 << 20
+)
+
+// FetchPageTool represents the fetch_page tool definition
+type FetchPageTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// GetFetchPageTool returns the fetch_page tool definition
+func GetFetchPageTool() FetchPageTool {
+	return FetchPageTool{
+		Name:        "fetch_page",
+		Description: "Download a web page and return its main content as markdown or plain text, without scripts, navigation and other boilerplate",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"url": map[string]interface{}{
+					"type":        "string",
+					"description": "The http or https URL of the page",
+				},
+				"format": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"markdown", "text"},
+					"description": "Output format (default: markdown)",
+					"default":     "markdown",
+				},
+				"max_length": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of characters to return, at most the configured limit",
+					"minimum":     1,
+				},
+				"start_index": map[string]interface{}{
+					"type":        "integer",
+					"description": "Character offset to start from, to continue a truncated page (default: 0)",
+					"default":     0,
+					"minimum":     0,
+				},
+			},
+			"required": []string{"url"},
+		},
+	}
+}
+
+// FetchPageConfig holds the configuration for the fetch_page tool
+type FetchPageConfig struct {
+	Timeout   time.Duration
+	MaxLength int
+	// AllowPrivateNetworks permits fetching loopback, private and link-local
+	// addresses, which are refused by default
+	AllowPrivateNetworks bool
+}
+
+var fetchPageConfig *FetchPageConfig
+
+// SetFetchPageConfig enables the fetch_page tool. A zero timeout or length
+// selects the default.
+func SetFetchPageConfig(timeout time.Duration, maxLength int, allowPrivateNetworks bool) {
+	if timeout <= 0 {
+		timeout = DefaultFetchPageTimeout
+	}
+	if maxLength <= 0 {
+		maxLength = DefaultFetchPageMaxLength
+	}
+	fetchPageConfig = &FetchPageConfig{
+		Timeout:              timeout,
+		MaxLength:            maxLength,
+		AllowPrivateNetworks: allowPrivateNetworks,
+	}
+}
+
+// GetFetchPageConfig returns the current configuration, nil when the tool
+// is disabled
+func GetFetchPageConfig() *FetchPageConfig {
+	return fetchPageConfig
+}
+
+// isPrivateIP reports whether ip is an address fetch_page refuses by default
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast()
+}
+
+// client returns the HTTP client of the fetch_page tool
+func (c *FetchPageConfig) client() *http.Client {
+	return webClient(c.Timeout, c.AllowPrivateNetworks)
+}
+
+// webClient returns an HTTP client for fetching web pages. Unless private
+// networks are allowed, every connection, including those of redirects, is
+// checked after name resolution, so DNS cannot point it at internal hosts.
+func webClient(timeout time.Duration, allowPrivateNetworks bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivateNetworks {
+		dialer := &net.Dialer{
+			Timeout: timeout,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || isPrivateIP(ip) {
+					return fmt.Errorf("access to private network address %s is not allowed", host)
+				}
+				return nil
+			},
+		}
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, network, address string) (net.Conn, error) {
+			return dialer.DialContext(ctx, network, address)
+		}
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("refusing redirect to %s", req.URL)
+			}
+			return nil
+		},
+	}
+}
+
+// CallFetchPage downloads a page and converts it to markdown or plain text.
+// Text and JSON responses are returned as they are. The download is aborted
+// when ctx ends.
+func CallFetchPage(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+	if fetchPageConfig == nil {
+		return nil, fmt.Errorf("fetch_page not configured. Set fetch_page.enabled in the config file")
+	}
+	config := fetchPageConfig
+
+	var args struct {
+		URL        string `arg:"url,required"`
+		Format     string `arg:"format"`
+		MaxLength  int    `arg:"max_length"`
+		StartIndex int    `arg:"start_index"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	target, err := url.Parse(args.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %v", err)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme %q, only http and https are allowed", target.Scheme)
+	}
+
+	plain := false
+	if args.Format != "" {
+		switch args.Format {
+		case "markdown":
+		case "text":
+			plain = true
+		default:
+			return nil, fmt.Errorf("format must be markdown or text")
+		}
+	}
+	maxLength := config.MaxLength
+	if args.MaxLength > 0 && args.MaxLength < maxLength {
+		maxLength = args.MaxLength
+	}
+	startIndex := max(args.StartIndex, 0)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", target.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "mcp-go fetch_page")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.5")
+
+	resp, err := config.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("fetching %s returned status %d", target, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, fetchPageMaxDownload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page: %w", err)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	var title, content string
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml" || mediaType == "" && isHTML(body):
+		tokens := tokenizeHTML(string(body))
+		title = htmlTitle(tokens)
+		content = htmlToText(mainContent(tokens), resp.Request.URL, plain)
+	case strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "json") || strings.HasSuffix(mediaType, "xml"):
+		content = string(body)
+	default:
+		return nil, fmt.Errorf("unsupported content type %s", mediaType)
+	}
+
+	var result strings.Builder
+	if title != "" {
+		fmt.Fprintf(&result, "Title: %s\n", title)
+	}
+	fmt.Fprintf(&result, "URL: %s\n\n", resp.Request.URL)
+
+	writeContentWindow(&result, content, startIndex, maxLength)
+	return TextResult(result.String()), nil
+}
+
+// writeContentWindow writes maxLength characters of a page's content from
+// startIndex, noting how to continue when truncated
+func writeContentWindow(result *strings.Builder, content string, startIndex, maxLength int) {
+	total := utf8.RuneCountInString(content)
+	if startIndex >= total {
+		if total > 0 {
+			fmt.Fprintf(result, "[No more content: the page has %d characters]", total)
+		} else {
+			result.WriteString("[The page has no text content]")
+		}
+		return
+	}
+	runes := []rune(content)[startIndex:]
+	if len(runes) > maxLength {
+		result.WriteString(string(runes[:maxLength]))
+		fmt.Fprintf(result, "\n\n[Content truncated at %d of %d characters; call again with start_index %d to continue]", startIndex+maxLength, total, startIndex+maxLength)
+	} else {
+		result.WriteString(string(runes))
+	}
+}
+
+// isHTML guesses whether an untyped response body is an HTML document
+func isHTML(body []byte) bool {
+	head := strings.ToLower(strings.TrimSpace(string(body[:min(len(body), 512)])))
+	return strings.HasPrefix(head, "<!doctype html") || strings.HasPrefix(head, "<html")
+}
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCallFetchPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><head><title>Test</title></head><body><p>" + strings.Repeat("a", 30) + "</p></body></html>"))
+	}))
+	defer server.Close()
+	defer func() { fetchPageConfig = nil }()
+
+	// Loopback addresses are refused unless private networks are allowed
+	SetFetchPageConfig(time.Second, 20, false)
+	if _, err := CallFetchPage(context.Background(), map[string]interface{}{"url": server.URL}); err == nil || !strings.Contains(err.Error(), "private network") {
+		t.Errorf("Expected a private network error, got %v", err)
+	}
+
+	SetFetchPageConfig(time.Second, 20, true)
+	result, err := resultText(CallFetchPage(context.Background(), map[string]interface{}{"url": server.URL}))
+	if err != nil {
+		t.Fatalf("CallFetchPage failed: %v", err)
+	}
+	if !strings.HasPrefix(result, "Title: Test\nURL: "+server.URL) || !strings.Contains(result, strings.Repeat("a", 20)+"\n\n[Content truncated at 20 of 30 characters; call again with start_index 20") {
+		t.Errorf("Unexpected result:\n%s", result)
+	}
+
+	result, err = resultText(CallFetchPage(context.Background(), map[string]interface{}{"url": server.URL, "start_index": float64(20)}))
+	if err != nil || !strings.HasSuffix(result, "\n\n"+strings.Repeat("a", 10)) {
+		t.Errorf("Expected the rest of the page, got %q, %v", result, err)
+	}
+}
+
+func TestCallFetchPageCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	SetFetchPageConfig(time.Minute, 0, true)
+	defer func() { fetchPageConfig = nil }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := CallFetchPage(ctx, map[string]interface{}{"url": server.URL}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the download to be cancelled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the download to stop with its context, took %v", elapsed)
+	}
+}
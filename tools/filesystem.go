@@ -1,9 +1,15 @@
 package tools
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 )
 
 // FileSystemTool represents a filesystem tool definition
@@ -17,7 +23,7 @@ type FileSystemTool struct {
 func GetReadFileTool() FileSystemTool {
 	return FileSystemTool{
 		Name:        "read_file",
-		Description: "Read the contents of a file",
+		Description: "Read the contents of a file, or a range of its lines",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -25,6 +31,24 @@ func GetReadFileTool() FileSystemTool {
 					"type":        "string",
 					"description": "The path to the file to read",
 				},
+				"offset": map[string]interface{}{
+					"type":        "integer",
+					"description": "Number of lines to skip from the start of the file (default: 0)",
+				},
+				"limit": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of lines to return (default: all)",
+				},
+				"tail_lines": map[string]interface{}{
+					"type":        "integer",
+					"description": "Return only the last N lines of the file; cannot be combined with offset or limit",
+				},
+				"encoding": map[string]interface{}{
+					"type":        "string",
+					"enum":        append(append([]string(nil), textEncodings...), "base64"),
+					"description": "Decode the content from this encoding, \"text\" (default) detecting UTF-8, UTF-16 and Latin-1 and converting to UTF-8; or \"base64\" for binary files such as images",
+				},
+				"follow_symlinks": followSymlinksProperty("Read through a symlink (default: true); when false a symlink is refused"),
 			},
 			"required": []string{"path"},
 		},
@@ -35,7 +59,7 @@ func GetReadFileTool() FileSystemTool {
 func GetWriteFileTool() FileSystemTool {
 	return FileSystemTool{
 		Name:        "write_file",
-		Description: "Write content to a file",
+		Description: "Write content to a file. The file is replaced atomically, so readers never see a partial write",
 		InputSchema: map[string]interface{}{
 			"type": "object",
 			"properties": map[string]interface{}{
@@ -47,6 +71,41 @@ func GetWriteFileTool() FileSystemTool {
 					"type":        "string",
 					"description": "The content to write to the file",
 				},
+				"encoding": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"text", "base64"},
+					"description": "How content is encoded: text (default) or base64, for binary files",
+				},
+				"backup": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Keep the previous content of an existing file as <path>.bak (default false)",
+				},
+			},
+			"required": []string{"path", "content"},
+		},
+	}
+}
+
+// GetAppendFileTool returns the append_file tool definition
+func GetAppendFileTool() FileSystemTool {
+	return FileSystemTool{
+		Name:        "append_file",
+		Description: "Append content to the end of a file, creating it if it does not exist",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "The path to the file to append to",
+				},
+				"content": map[string]interface{}{
+					"type":        "string",
+					"description": "The content to append",
+				},
+				"ensure_newline": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Start the content on a new line and end it with a line feed (default: false)",
+				},
 			},
 			"required": []string{"path", "content"},
 		},
@@ -65,6 +124,7 @@ func GetListDirectoryTool() FileSystemTool {
 					"type":        "string",
 					"description": "The path to the directory to list",
 				},
+				"follow_symlinks": followSymlinksProperty("Describe what symlinks point to instead of the links themselves (default: false); links leaving the allowed paths are never followed"),
 			},
 			"required": []string{"path"},
 		},
@@ -107,140 +167,714 @@ func GetDeleteFileTool() FileSystemTool {
 	}
 }
 
+// GetMoveFileTool returns the move_file tool definition
+func GetMoveFileTool() FileSystemTool {
+	return FileSystemTool{
+		Name:        "move_file",
+		Description: "Move or rename a file or directory",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"source": map[string]interface{}{
+					"type":        "string",
+					"description": "The path of the file or directory to move",
+				},
+				"destination": map[string]interface{}{
+					"type":        "string",
+					"description": "The new path of the file or directory",
+				},
+				"overwrite": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Replace the destination if it already exists (default: false)",
+				},
+			},
+			"required": []string{"source", "destination"},
+		},
+	}
+}
+
+// GetCopyFileTool returns the copy_file tool definition
+func GetCopyFileTool() FileSystemTool {
+	return FileSystemTool{
+		Name:        "copy_file",
+		Description: "Copy a file, or a directory with all of its contents, preserving permissions",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"source": map[string]interface{}{
+					"type":        "string",
+					"description": "The path of the file or directory to copy",
+				},
+				"destination": map[string]interface{}{
+					"type":        "string",
+					"description": "The path of the copy",
+				},
+				"overwrite": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Replace the destination if it already exists (default: false)",
+				},
+			},
+			"required": []string{"source", "destination"},
+		},
+	}
+}
+
 // CallReadFile reads a file and returns its contents
-func CallReadFile(arguments map[string]interface{}) (string, error) {
-	path, ok := arguments["path"].(string)
-	if !ok {
-		return "", fmt.Errorf("path argument is required and must be a string")
+func CallReadFile(arguments map[string]interface{}) (*ToolResult, error) {
+	args := struct {
+		Path           string `arg:"path,required"`
+		Offset         int    `arg:"offset"`
+		Limit          int    `arg:"limit"`
+		TailLines      int    `arg:"tail_lines"`
+		Encoding       string `arg:"encoding"`
+		FollowSymlinks bool   `arg:"follow_symlinks"`
+	}{FollowSymlinks: true}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	path, offset, limit, tail := args.Path, args.Offset, args.Limit, args.TailLines
+	if offset < 0 || limit < 0 || tail < 0 {
+		return nil, fmt.Errorf("offset, limit and tail_lines must not be negative")
+	}
+	if tail > 0 && (offset > 0 || limit > 0) {
+		return nil, fmt.Errorf("tail_lines cannot be combined with offset or limit")
+	}
+	encoding, err := checkEncoding(args.Encoding, append(textEncodings, "base64"))
+	if err != nil {
+		return nil, err
 	}
 
-	// Resolve absolute path
-	absPath, err := filepath.Abs(path)
+	// Resolve absolute path within the allowed paths
+	if !args.FollowSymlinks {
+		entryPath, err := resolveEntryPath(path)
+		if err != nil {
+			return nil, err
+		}
+		if info, err := os.Lstat(entryPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return nil, fmt.Errorf("%s is a symlink and follow_symlinks is false", path)
+		}
+	}
+	absPath, err := resolvePath(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to resolve path: %v", err)
+		return nil, err
 	}
 
-	content, err := os.ReadFile(absPath)
+	// Ranges are read without loading the whole file, and no more than the
+	// read limit (plus one byte, to detect truncation) is kept
+	maxRead, _ := sizeLimits()
+	var content []byte
+	switch {
+	case tail > 0:
+		content, err = readTailLines(absPath, tail, maxRead+1)
+	case offset > 0 || limit > 0:
+		content, err = readLineRange(absPath, offset, limit, maxRead+1)
+	default:
+		content, err = readHead(absPath, maxRead+1)
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to read file: %v", err)
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	truncated := int64(len(content)) > maxRead
+	if truncated && encoding == "base64" {
+		return nil, fmt.Errorf("%s is larger than the %d byte read limit; read it in parts with offset and limit", path, maxRead)
+	}
+	if encoding == "base64" {
+		return TextResult(base64.StdEncoding.EncodeToString(content)), nil
 	}
 
-	return string(content), nil
+	var notice string
+	if truncated {
+		if tail > 0 {
+			content = content[int64(len(content))-maxRead:]
+			notice = fmt.Sprintf("\n[Truncated: showing the last %d bytes, the read limit]", maxRead)
+		} else {
+			content = content[:maxRead]
+			notice = fmt.Sprintf("\n[Truncated: showing the first %d bytes, the read limit; read the rest with offset and limit]", maxRead)
+		}
+	}
+
+	// Detected non-UTF-8 text is converted, and the conversion reported
+	if encoding == "text" {
+		encoding = detectEncoding(content)
+		if encoding != encodingUTF8 {
+			notice += fmt.Sprintf("\n[Converted from %s to UTF-8]", encoding)
+		}
+	}
+	return TextResult(decodeText(content, encoding) + notice), nil
 }
 
 // CallWriteFile writes content to a file
-func CallWriteFile(arguments map[string]interface{}) (string, error) {
-	path, ok := arguments["path"].(string)
-	if !ok {
-		return "", fmt.Errorf("path argument is required and must be a string")
+func CallWriteFile(arguments map[string]interface{}) (*ToolResult, error) {
+	if err := checkWritable("write_file"); err != nil {
+		return nil, err
 	}
 
-	content, ok := arguments["content"].(string)
-	if !ok {
-		return "", fmt.Errorf("content argument is required and must be a string")
+	var args struct {
+		Path     string  `arg:"path,required"`
+		Content  *string `arg:"content"` // May be empty
+		Encoding string  `arg:"encoding"`
+		Backup   bool    `arg:"backup"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
 	}
+	if args.Content == nil {
+		return nil, fmt.Errorf("content argument is required and must be a string")
+	}
+	path, content, backup := args.Path, *args.Content, args.Backup
 
-	// Resolve absolute path
-	absPath, err := filepath.Abs(path)
+	encoding, err := checkEncoding(args.Encoding, []string{"text", "base64"})
 	if err != nil {
-		return "", fmt.Errorf("failed to resolve path: %v", err)
+		return nil, err
+	}
+	data := []byte(content)
+	if encoding == "base64" {
+		if data, err = base64.StdEncoding.DecodeString(content); err != nil {
+			return nil, fmt.Errorf("content is not valid base64: %v", err)
+		}
+	}
+
+	if err := checkWriteSize(len(data)); err != nil {
+		return nil, err
+	}
+
+	// Resolve absolute path within the allowed paths
+	absPath, err := resolvePath(path)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create parent directories if they don't exist
 	dir := filepath.Dir(absPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create parent directories: %v", err)
+		return nil, fmt.Errorf("failed to create parent directories: %v", err)
+	}
+
+	var note string
+	if backup {
+		backedUp, err := backupFile(absPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to back up file: %v", err)
+		}
+		if backedUp {
+			note = fmt.Sprintf(" (previous content kept in %s.bak)", absPath)
+		}
+	}
+
+	if err := writeFileAtomic(absPath, data); err != nil {
+		return nil, fmt.Errorf("failed to write file: %v", err)
+	}
+
+	return TextResult(fmt.Sprintf("Successfully wrote %d bytes to %s%s", len(data), absPath, note)), nil
+}
+
+// writeFileAtomic replaces the file at path with data. The data is written
+// to a temporary file in the same directory and renamed into place, so the
+// file is never left partially written. An existing file keeps its mode.
+func writeFileAtomic(path string, data []byte) error {
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", path)
+		}
+		perm = info.Mode().Perm()
 	}
+	return replaceFile(path, perm, func(f *os.File) error {
+		_, err := f.Write(data)
+		return err
+	})
+}
 
-	if err := os.WriteFile(absPath, []byte(content), 0644); err != nil {
-		return "", fmt.Errorf("failed to write file: %v", err)
+// backupFile copies an existing file at path to path.bak, replacing an older
+// backup. It reports false when there is no file to back up.
+func backupFile(path string) (bool, error) {
+	in, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return false, nil
 	}
+	if err != nil {
+		return false, err
+	}
+	defer in.Close()
 
-	return fmt.Sprintf("Successfully wrote %d bytes to %s", len(content), absPath), nil
+	info, err := in.Stat()
+	if err != nil {
+		return false, err
+	}
+	if !info.Mode().IsRegular() {
+		return false, fmt.Errorf("%s is not a regular file", path)
+	}
+	err = replaceFile(path+".bak", info.Mode().Perm(), func(f *os.File) error {
+		_, err := io.Copy(f, in)
+		return err
+	})
+	return err == nil, err
 }
 
-// CallListDirectory lists files and directories in a directory
-func CallListDirectory(arguments map[string]interface{}) (string, error) {
-	path, ok := arguments["path"].(string)
-	if !ok {
-		return "", fmt.Errorf("path argument is required and must be a string")
+// replaceFile fills a temporary file next to path with write, syncs it and
+// renames it over path. The temporary file is removed on failure.
+func replaceFile(path string, perm os.FileMode, write func(*os.File) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
 	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
 
-	// Resolve absolute path
-	absPath, err := filepath.Abs(path)
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// CallAppendFile appends content to a file. The file is opened in append
+// mode, so concurrent appends do not overwrite each other.
+func CallAppendFile(arguments map[string]interface{}) (*ToolResult, error) {
+	if err := checkWritable("append_file"); err != nil {
+		return nil, err
+	}
+
+	var args struct {
+		Path          string  `arg:"path,required"`
+		Content       *string `arg:"content"` // May be empty
+		EnsureNewline bool    `arg:"ensure_newline"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Content == nil {
+		return nil, fmt.Errorf("content argument is required and must be a string")
+	}
+	path, content, ensureNewline := args.Path, *args.Content, args.EnsureNewline
+
+	if err := checkWriteSize(len(content)); err != nil {
+		return nil, err
+	}
+
+	// Resolve absolute path within the allowed paths
+	absPath, err := resolvePath(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to resolve path: %v", err)
+		return nil, err
+	}
+
+	// Create parent directories if they don't exist
+	if err := os.MkdirAll(filepath.Dir(absPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create parent directories: %v", err)
+	}
+
+	f, err := os.OpenFile(absPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE|sandboxOpenFlags(), 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	if ensureNewline {
+		if !endsWithNewline(absPath) {
+			content = "\n" + content
+		}
+		if content != "" && content[len(content)-1] != '\n' {
+			content += "\n"
+		}
+	}
+
+	if _, err := f.WriteString(content); err != nil {
+		return nil, fmt.Errorf("failed to append to file: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to append to file: %v", err)
+	}
+
+	return TextResult(fmt.Sprintf("Successfully appended %d bytes to %s", len(content), absPath)), nil
+}
+
+// endsWithNewline reports whether the file at path is empty or ends with a
+// line feed
+func endsWithNewline(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return true
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return true
+	}
+	return last[0] == '\n'
+}
+
+// DirectoryEntry is one entry of a list_directory result
+type DirectoryEntry struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Size    int64  `json:"size"`
+	Mode    string `json:"mode"`
+	ModTime string `json:"mtime"`
+	// Target is the destination of a symlink
+	Target string `json:"target,omitempty"`
+}
+
+// DirectoryListing is the structured result of list_directory
+type DirectoryListing struct {
+	Path    string           `json:"path"`
+	Entries []DirectoryEntry `json:"entries"`
+}
+
+// CallListDirectory lists files and directories in a directory, with the
+// entries as structured content
+func CallListDirectory(arguments map[string]interface{}) (*ToolResult, error) {
+	var args struct {
+		Path           string `arg:"path,required"`
+		FollowSymlinks bool   `arg:"follow_symlinks"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	path, follow := args.Path, args.FollowSymlinks
+
+	// Resolve absolute path within the allowed paths
+	absPath, err := resolvePath(path)
+	if err != nil {
+		return nil, err
 	}
 
 	entries, err := os.ReadDir(absPath)
 	if err != nil {
-		return "", fmt.Errorf("failed to read directory: %v", err)
+		return nil, fmt.Errorf("failed to read directory: %v", err)
 	}
 
+	listing := &DirectoryListing{Path: absPath, Entries: []DirectoryEntry{}}
 	result := fmt.Sprintf("Contents of %s:\n", absPath)
 	for _, entry := range entries {
 		info, err := entry.Info()
 		if err != nil {
 			continue
 		}
-		entryType := "file"
+		item := DirectoryEntry{Name: entry.Name(), Type: "file"}
 		if entry.IsDir() {
-			entryType = "directory"
+			item.Type = "directory"
 		}
-		result += fmt.Sprintf("  %s [%s] %d bytes\n", entry.Name(), entryType, info.Size())
+
+		label := item.Type
+		if info.Mode()&os.ModeSymlink != 0 {
+			entryPath := filepath.Join(absPath, entry.Name())
+			item.Type = "symlink"
+			item.Target, _ = os.Readlink(entryPath)
+			label = "symlink -> " + item.Target
+			switch {
+			case symlinkEscapes(entryPath):
+				label += ", outside the allowed paths or missing"
+			case follow:
+				// Describe the target, which stays within the allowed paths
+				if targetInfo, err := os.Stat(entryPath); err == nil {
+					info = targetInfo
+					item.Type = "file"
+					if info.IsDir() {
+						item.Type = "directory"
+					}
+					label = item.Type + " via symlink -> " + item.Target
+				}
+			}
+		}
+
+		item.Size = info.Size()
+		item.Mode = info.Mode().Perm().String()
+		item.ModTime = info.ModTime().UTC().Format(time.RFC3339)
+		result += fmt.Sprintf("  %s [%s] %d bytes %s\n", item.Name, label, item.Size, item.Mode)
+		listing.Entries = append(listing.Entries, item)
 	}
 
-	return result, nil
+	return StructuredResult(result, listing), nil
 }
 
 // CallCreateDirectory creates a new directory
-func CallCreateDirectory(arguments map[string]interface{}) (string, error) {
-	path, ok := arguments["path"].(string)
-	if !ok {
-		return "", fmt.Errorf("path argument is required and must be a string")
+func CallCreateDirectory(arguments map[string]interface{}) (*ToolResult, error) {
+	if err := checkWritable("create_directory"); err != nil {
+		return nil, err
 	}
 
-	// Resolve absolute path
-	absPath, err := filepath.Abs(path)
+	var args struct {
+		Path string `arg:"path,required"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	path := args.Path
+
+	// Resolve absolute path within the allowed paths
+	absPath, err := resolvePath(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to resolve path: %v", err)
+		return nil, err
 	}
 
 	if err := os.MkdirAll(absPath, 0755); err != nil {
-		return "", fmt.Errorf("failed to create directory: %v", err)
+		return nil, fmt.Errorf("failed to create directory: %v", err)
 	}
 
-	return fmt.Sprintf("Successfully created directory: %s", absPath), nil
+	return TextResult(fmt.Sprintf("Successfully created directory: %s", absPath)), nil
 }
 
 // CallDeleteFile deletes a file or directory
-func CallDeleteFile(arguments map[string]interface{}) (string, error) {
-	path, ok := arguments["path"].(string)
-	if !ok {
-		return "", fmt.Errorf("path argument is required and must be a string")
+func CallDeleteFile(arguments map[string]interface{}) (*ToolResult, error) {
+	if err := checkWritable("delete_file"); err != nil {
+		return nil, err
+	}
+
+	var args struct {
+		Path string `arg:"path,required"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
 	}
+	path := args.Path
 
-	// Resolve absolute path
-	absPath, err := filepath.Abs(path)
+	// Resolve absolute path within the allowed paths
+	absPath, err := resolveEntryPath(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to resolve path: %v", err)
+		return nil, err
+	}
+
+	if isProtectedPath(absPath) {
+		return nil, fmt.Errorf("access denied: %s is a protected root and cannot be deleted", path)
 	}
 
-	info, err := os.Stat(absPath)
+	info, err := os.Lstat(absPath)
 	if err != nil {
-		return "", fmt.Errorf("file or directory does not exist: %v", err)
+		return nil, fmt.Errorf("file or directory does not exist: %v", err)
 	}
 
 	if info.IsDir() {
 		if err := os.RemoveAll(absPath); err != nil {
-			return "", fmt.Errorf("failed to delete directory: %v", err)
+			return nil, fmt.Errorf("failed to delete directory: %v", err)
 		}
-		return fmt.Sprintf("Successfully deleted directory: %s", absPath), nil
+		return TextResult(fmt.Sprintf("Successfully deleted directory: %s", absPath)), nil
 	}
 
 	if err := os.Remove(absPath); err != nil {
-		return "", fmt.Errorf("failed to delete file: %v", err)
+		return nil, fmt.Errorf("failed to delete file: %v", err)
+	}
+
+	return TextResult(fmt.Sprintf("Successfully deleted file: %s", absPath)), nil
+}
+
+// CallMoveFile moves or renames a file or directory, copying and deleting it
+// when source and destination are on different devices
+func CallMoveFile(arguments map[string]interface{}) (*ToolResult, error) {
+	if err := checkWritable("move_file"); err != nil {
+		return nil, err
+	}
+
+	var args struct {
+		Source      string `arg:"source,required"`
+		Destination string `arg:"destination,required"`
+		Overwrite   bool   `arg:"overwrite"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	source, destination, overwrite := args.Source, args.Destination, args.Overwrite
+
+	// Both ends act on the entries themselves, not on symlink targets
+	srcPath, err := resolveEntryPath(source)
+	if err != nil {
+		return nil, err
+	}
+	dstPath, err := resolveEntryPath(destination)
+	if err != nil {
+		return nil, err
+	}
+
+	if isProtectedPath(srcPath) {
+		return nil, fmt.Errorf("access denied: %s is a protected root and cannot be moved", source)
+	}
+	info, err := os.Lstat(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("source does not exist: %v", err)
+	}
+	if info.IsDir() && withinRoot(dstPath, srcPath) {
+		return nil, fmt.Errorf("cannot move %s into itself", source)
+	}
+	if err := prepareDestination(dstPath, destination, overwrite); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create parent directories: %v", err)
+	}
+
+	if err := os.Rename(srcPath, dstPath); err != nil {
+		if !errors.Is(err, syscall.EXDEV) {
+			return nil, fmt.Errorf("failed to move: %v", err)
+		}
+		// Rename cannot cross devices: copy, then remove the source
+		if err := copyPath(srcPath, dstPath); err != nil {
+			os.RemoveAll(dstPath)
+			return nil, fmt.Errorf("failed to move: %v", err)
+		}
+		if err := os.RemoveAll(srcPath); err != nil {
+			return nil, fmt.Errorf("copied to %s but failed to remove the source: %v", dstPath, err)
+		}
+	}
+
+	return TextResult(fmt.Sprintf("Successfully moved %s to %s", srcPath, dstPath)), nil
+}
+
+// CallCopyFile copies a file or, recursively, a directory
+func CallCopyFile(arguments map[string]interface{}) (*ToolResult, error) {
+	if err := checkWritable("copy_file"); err != nil {
+		return nil, err
+	}
+
+	var args struct {
+		Source      string `arg:"source,required"`
+		Destination string `arg:"destination,required"`
+		Overwrite   bool   `arg:"overwrite"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	source, destination, overwrite := args.Source, args.Destination, args.Overwrite
+
+	// Symlinks are copied as links, so none is followed out of the sandbox
+	srcPath, err := resolveEntryPath(source)
+	if err != nil {
+		return nil, err
+	}
+	dstPath, err := resolveEntryPath(destination)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Lstat(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("source does not exist: %v", err)
+	}
+	if info.IsDir() && withinRoot(dstPath, srcPath) {
+		return nil, fmt.Errorf("cannot copy %s into itself", source)
+	}
+	if err := prepareDestination(dstPath, destination, overwrite); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create parent directories: %v", err)
+	}
+
+	if err := copyPath(srcPath, dstPath); err != nil {
+		os.RemoveAll(dstPath)
+		return nil, fmt.Errorf("failed to copy: %v", err)
+	}
+
+	return TextResult(fmt.Sprintf("Successfully copied %s to %s", srcPath, dstPath)), nil
+}
+
+// checkEncoding returns the encoding argument in lower case if it is one of
+// allowed, and "text" when it is unset
+func checkEncoding(encoding string, allowed []string) (string, error) {
+	if encoding == "" {
+		return "text", nil
 	}
+	encoding = strings.ToLower(encoding)
+	for _, name := range allowed {
+		if encoding == name {
+			return encoding, nil
+		}
+	}
+	return "", fmt.Errorf("encoding argument must be one of %s", strings.Join(allowed, ", "))
+}
 
-	return fmt.Sprintf("Successfully deleted file: %s", absPath), nil
+// prepareDestination makes way for a new entry at dstPath: an existing entry
+// is an error unless overwrite is set, in which case it is removed
+func prepareDestination(dstPath, destination string, overwrite bool) error {
+	if _, err := os.Lstat(dstPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to inspect destination: %v", err)
+	}
+	if !overwrite {
+		return fmt.Errorf("destination %s already exists (set overwrite to replace it)", destination)
+	}
+	if isProtectedPath(dstPath) {
+		return fmt.Errorf("access denied: %s is a protected root and cannot be replaced", destination)
+	}
+	if err := os.RemoveAll(dstPath); err != nil {
+		return fmt.Errorf("failed to replace destination: %v", err)
+	}
+	return nil
+}
+
+// copyPath copies the file, directory tree or symlink at src to dst,
+// preserving permissions
+func copyPath(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	case info.IsDir():
+		if err := os.Mkdir(dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := copyPath(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+				return err
+			}
+		}
+		// Mkdir is subject to the umask
+		return os.Chmod(dst, info.Mode().Perm())
+	case info.Mode().IsRegular():
+		return copyFile(src, dst, info.Mode().Perm())
+	default:
+		return fmt.Errorf("cannot copy %s: not a regular file, directory or symlink", src)
+	}
+}
+
+// copyFile copies the contents of the regular file src to a new file dst
+// with the given permissions
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL|openNoFollow, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(dst, perm)
 }
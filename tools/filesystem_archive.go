@@ -0,0 +1,485 @@
+package tools
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Archive formats supported by create_archive and extract_archive
+const (
+	archiveZip   = "zip"
+	archiveTarGz = "tar.gz"
+)
+
+// GetCreateArchiveTool returns the create_archive tool definition
+func GetCreateArchiveTool() FileSystemTool {
+	return FileSystemTool{
+		Name:        "create_archive",
+		Description: "Create a zip or tar.gz archive of a file or directory",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"source": map[string]interface{}{
+					"type":        "string",
+					"description": "The file or directory to archive; it is stored under its own name",
+				},
+				"destination": map[string]interface{}{
+					"type":        "string",
+					"description": "The path of the archive to create",
+				},
+				"format": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{archiveZip, archiveTarGz},
+					"description": "The archive format (default: from the destination extension)",
+				},
+				"overwrite": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Replace the destination if it already exists (default: false)",
+				},
+			},
+			"required": []string{"source", "destination"},
+		},
+	}
+}
+
+// GetExtractArchiveTool returns the extract_archive tool definition
+func GetExtractArchiveTool() FileSystemTool {
+	return FileSystemTool{
+		Name:        "extract_archive",
+		Description: "Extract a zip or tar.gz archive into a directory",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"source": map[string]interface{}{
+					"type":        "string",
+					"description": "The archive to extract",
+				},
+				"destination": map[string]interface{}{
+					"type":        "string",
+					"description": "The directory to extract into; it is created if needed",
+				},
+				"format": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{archiveZip, archiveTarGz},
+					"description": "The archive format (default: from the source extension)",
+				},
+				"overwrite": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Replace existing files (default: false)",
+				},
+			},
+			"required": []string{"source", "destination"},
+		},
+	}
+}
+
+// CallCreateArchive archives a file or directory
+func CallCreateArchive(arguments map[string]interface{}) (*ToolResult, error) {
+	if err := checkWritable("create_archive"); err != nil {
+		return nil, err
+	}
+
+	source, destination, format, overwrite, err := archiveArguments(arguments, "destination")
+	if err != nil {
+		return nil, err
+	}
+
+	srcPath, err := resolvePath(source)
+	if err != nil {
+		return nil, err
+	}
+	dstPath, err := resolvePath(destination)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(srcPath); err != nil {
+		return nil, fmt.Errorf("source does not exist: %v", err)
+	}
+	if withinRoot(dstPath, srcPath) {
+		return nil, fmt.Errorf("the archive cannot be created inside %s", source)
+	}
+	if err := prepareDestination(dstPath, destination, overwrite); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create parent directories: %v", err)
+	}
+
+	out, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL|openNoFollow, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %v", err)
+	}
+	var count int
+	if format == archiveZip {
+		count, err = writeZip(out, srcPath)
+	} else {
+		count, err = writeTarGz(out, srcPath)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dstPath)
+		return nil, fmt.Errorf("failed to create archive: %v", err)
+	}
+
+	return TextResult(fmt.Sprintf("Successfully archived %d entries of %s to %s", count, srcPath, dstPath)), nil
+}
+
+// CallExtractArchive extracts an archive into a directory. Entries that
+// would land outside the destination (zip slip) are rejected.
+func CallExtractArchive(arguments map[string]interface{}) (*ToolResult, error) {
+	if err := checkWritable("extract_archive"); err != nil {
+		return nil, err
+	}
+
+	source, destination, format, overwrite, err := archiveArguments(arguments, "source")
+	if err != nil {
+		return nil, err
+	}
+
+	srcPath, err := resolvePath(source)
+	if err != nil {
+		return nil, err
+	}
+	dstPath, err := resolvePath(destination)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dstPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create destination: %v", err)
+	}
+
+	x := &extractor{root: dstPath, overwrite: overwrite}
+	if format == archiveZip {
+		err = x.extractZip(srcPath)
+	} else {
+		err = x.extractTarGz(srcPath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract archive: %v", err)
+	}
+
+	return TextResult(fmt.Sprintf("Successfully extracted %d entries of %s to %s", x.count, srcPath, dstPath)), nil
+}
+
+// archiveArguments returns the common arguments of the archive tools. The
+// format defaults to the extension of the argument named archive.
+func archiveArguments(arguments map[string]interface{}, archive string) (source, destination, format string, overwrite bool, err error) {
+	var args struct {
+		Source      string  `arg:"source,required"`
+		Destination string  `arg:"destination,required"`
+		Format      *string `arg:"format"`
+		Overwrite   bool    `arg:"overwrite"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return "", "", "", false, err
+	}
+	source, destination, overwrite = args.Source, args.Destination, args.Overwrite
+
+	if args.Format != nil {
+		format = *args.Format
+	} else {
+		name := source
+		if archive == "destination" {
+			name = destination
+		}
+		switch lower := strings.ToLower(name); {
+		case strings.HasSuffix(lower, ".zip"):
+			format = archiveZip
+		case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+			format = archiveTarGz
+		default:
+			return "", "", "", false, fmt.Errorf("cannot infer the archive format from %s; set format to zip or tar.gz", name)
+		}
+	}
+	if format != archiveZip && format != archiveTarGz {
+		return "", "", "", false, fmt.Errorf("format argument must be zip or tar.gz")
+	}
+	return source, destination, format, overwrite, nil
+}
+
+// walkArchive calls fn for src and, when it is a directory, everything
+// below it, with the slash-separated archive name of each entry
+func walkArchive(src string, fn func(file, name string, info os.FileInfo) error) error {
+	base := filepath.Dir(src)
+	return filepath.Walk(src, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(base, file)
+		if err != nil {
+			return err
+		}
+		return fn(file, filepath.ToSlash(rel), info)
+	})
+}
+
+// writeZip writes a zip archive of src to w and returns the number of entries
+func writeZip(w io.Writer, src string) (int, error) {
+	zw := zip.NewWriter(w)
+	count := 0
+	err := walkArchive(src, func(file, name string, info os.FileInfo) error {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		} else {
+			header.Method = zip.Deflate
+		}
+		entry, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		count++
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(file)
+			if err != nil {
+				return err
+			}
+			_, err = io.WriteString(entry, target)
+			return err
+		case info.Mode().IsRegular():
+			return copyFileTo(entry, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, zw.Close()
+}
+
+// writeTarGz writes a gzip-compressed tar archive of src to w and returns the
+// number of entries
+func writeTarGz(w io.Writer, src string) (int, error) {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	count := 0
+	err := walkArchive(src, func(file, name string, info os.FileInfo) error {
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(file)
+			if err != nil {
+				return err
+			}
+			link = target
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		count++
+		if info.Mode().IsRegular() {
+			return copyFileTo(tw, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	return count, gw.Close()
+}
+
+// copyFileTo copies the contents of the file at path to w
+func copyFileTo(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// extractor writes archive entries below root
+type extractor struct {
+	root      string
+	overwrite bool
+	count     int
+}
+
+// target returns the path of the archive entry name below the root,
+// rejecting names that escape it directly or through a symlink extracted
+// earlier
+func (x *extractor) target(name string) (string, error) {
+	slashed := strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(slashed, "/") || strings.Contains("/"+slashed+"/", "/../") {
+		return "", fmt.Errorf("entry %q escapes the destination", name)
+	}
+	clean := path.Clean("/" + slashed)
+	if clean == "/" {
+		return x.root, nil
+	}
+	target := filepath.Join(x.root, filepath.FromSlash(clean))
+	resolved, err := evalSymlinksAllowMissing(filepath.Dir(target))
+	if err != nil {
+		return "", err
+	}
+	if !withinRoot(resolved, x.root) {
+		return "", fmt.Errorf("entry %q escapes the destination", name)
+	}
+	if _, err := resolvePath(target); err != nil {
+		return "", err
+	}
+	return filepath.Join(resolved, filepath.Base(target)), nil
+}
+
+// create makes way for the entry at target
+func (x *extractor) create(target string) error {
+	if _, err := os.Lstat(target); err == nil {
+		if !x.overwrite {
+			return fmt.Errorf("%s already exists (set overwrite to replace it)", target)
+		}
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+	}
+	x.count++
+	return os.MkdirAll(filepath.Dir(target), 0755)
+}
+
+// writeEntry extracts one entry of the given mode from r
+func (x *extractor) writeEntry(name string, mode fs.FileMode, r io.Reader) error {
+	target, err := x.target(name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case mode.IsDir():
+		if target == x.root {
+			return nil
+		}
+		if info, err := os.Lstat(target); err == nil && info.IsDir() {
+			return nil
+		}
+		if err := x.create(target); err != nil {
+			return err
+		}
+		return os.Mkdir(target, mode.Perm()|0700)
+	case mode&fs.ModeSymlink != 0:
+		data, err := io.ReadAll(io.LimitReader(r, 4096))
+		if err != nil {
+			return err
+		}
+		link := string(data)
+		// The link must point inside the destination, also when it leads
+		// through links extracted earlier
+		resolved, err := resolveLink(filepath.Dir(target), link)
+		if err != nil {
+			return err
+		}
+		if !withinRoot(resolved, x.root) {
+			return fmt.Errorf("symlink %q points outside the destination", name)
+		}
+		if err := x.create(target); err != nil {
+			return err
+		}
+		return os.Symlink(link, target)
+	case mode.IsRegular():
+		if err := x.create(target); err != nil {
+			return err
+		}
+		out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL|openNoFollow, mode.Perm())
+		if err != nil {
+			return err
+		}
+		// Entries are bounded by the write limit, against decompression bombs
+		_, maxWrite := sizeLimits()
+		n, err := io.Copy(out, io.LimitReader(r, maxWrite+1))
+		if err == nil && n > maxWrite {
+			err = fmt.Errorf("entry %q is larger than the %d byte write limit", name, maxWrite)
+		}
+		if err != nil {
+			out.Close()
+			os.Remove(target)
+			return err
+		}
+		return out.Close()
+	default:
+		return fmt.Errorf("entry %q has an unsupported type", name)
+	}
+}
+
+// extractZip extracts the zip archive at path
+func (x *extractor) extractZip(path string) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = x.writeEntry(f.Name, f.Mode(), rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// extractTarGz extracts the gzip-compressed tar archive at path
+func (x *extractor) extractTarGz(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		mode := header.FileInfo().Mode()
+		var r io.Reader = tr
+		if header.Typeflag == tar.TypeSymlink {
+			r = strings.NewReader(header.Linkname)
+		}
+		if header.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+		if err := x.writeEntry(header.Name, mode, r); err != nil {
+			return err
+		}
+	}
+}
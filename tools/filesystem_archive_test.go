@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	root := sandbox(t)
+	writeTree(t, root, map[string]string{
+		"project/main.go":     "package main\n",
+		"project/pkg/util.go": "package pkg\n",
+	})
+	if err := os.Chmod(filepath.Join(root, "project", "main.go"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("main.go", filepath.Join(root, "project", "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"out.zip", "out.tar.gz"} {
+		archive := filepath.Join(root, name)
+		if _, err := CallCreateArchive(map[string]interface{}{"source": filepath.Join(root, "project"), "destination": archive}); err != nil {
+			t.Fatalf("%s: CallCreateArchive failed: %v", name, err)
+		}
+
+		dest := filepath.Join(root, "extracted-"+name)
+		if _, err := CallExtractArchive(map[string]interface{}{"source": archive, "destination": dest}); err != nil {
+			t.Fatalf("%s: CallExtractArchive failed: %v", name, err)
+		}
+		if content, err := os.ReadFile(filepath.Join(dest, "project", "pkg", "util.go")); err != nil || string(content) != "package pkg\n" {
+			t.Errorf("%s: unexpected extracted file: %q, %v", name, content, err)
+		}
+		if info, err := os.Stat(filepath.Join(dest, "project", "main.go")); err != nil || info.Mode().Perm() != 0755 {
+			t.Errorf("%s: expected permissions to be kept, got %v, %v", name, info, err)
+		}
+		if target, err := os.Readlink(filepath.Join(dest, "project", "link")); err != nil || target != "main.go" {
+			t.Errorf("%s: expected the symlink to be kept, got %q, %v", name, target, err)
+		}
+
+		// Existing files are only replaced with overwrite
+		if _, err := CallExtractArchive(map[string]interface{}{"source": archive, "destination": dest}); err == nil {
+			t.Errorf("%s: expected extracting over existing files to fail", name)
+		}
+		if _, err := CallExtractArchive(map[string]interface{}{"source": archive, "destination": dest, "overwrite": true}); err != nil {
+			t.Errorf("%s: CallExtractArchive with overwrite failed: %v", name, err)
+		}
+	}
+
+	if _, err := CallCreateArchive(map[string]interface{}{"source": root, "destination": filepath.Join(root, "x.rar")}); err == nil {
+		t.Error("Expected an unknown format to fail")
+	}
+}
+
+func TestExtractArchiveZipSlip(t *testing.T) {
+	root := sandbox(t)
+	for _, entry := range []struct {
+		name string
+		mode os.FileMode
+		body string
+	}{
+		{"../evil.txt", 0644, "evil"},
+		{"a/../../evil.txt", 0644, "evil"},
+		{"/etc/evil.txt", 0644, "evil"},
+		{"link", os.ModeSymlink | 0777, "../../outside"},
+	} {
+		archive := filepath.Join(root, "evil.zip")
+		f, err := os.Create(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zw := zip.NewWriter(f)
+		header := &zip.FileHeader{Name: entry.name}
+		header.SetMode(entry.mode)
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(entry.body))
+		zw.Close()
+		f.Close()
+
+		_, err = CallExtractArchive(map[string]interface{}{"source": archive, "destination": filepath.Join(root, "dest")})
+		if err == nil || !strings.Contains(err.Error(), "outside the destination") && !strings.Contains(err.Error(), "escapes the destination") {
+			t.Errorf("%s: expected the entry to be rejected, got %v", entry.name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(root), "evil.txt")); err == nil {
+		t.Error("Expected nothing to be written outside the destination")
+	}
+}
+
+func TestExtractArchiveChainedSymlinks(t *testing.T) {
+	root := sandbox(t)
+	archive := filepath.Join(root, "chained.zip")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, entry := range []struct {
+		name string
+		mode os.FileMode
+		body string
+	}{
+		{"d1/d2/", os.ModeDir | 0755, ""},
+		// Points at the destination itself, which is allowed
+		{"d1/d2/u", os.ModeSymlink | 0777, "../.."},
+		{"up", os.ModeSymlink | 0777, "d1/d2/u/d1"},
+		// Cleaned as text this stays inside, but u leads to the destination
+		// so the two ".." leave it
+		{"evil", os.ModeSymlink | 0777, "d1/d2/u/../../outside.txt"},
+	} {
+		header := &zip.FileHeader{Name: entry.name}
+		header.SetMode(entry.mode)
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(entry.body))
+	}
+	zw.Close()
+	f.Close()
+
+	dest := filepath.Join(root, "dest")
+	_, err = CallExtractArchive(map[string]interface{}{"source": archive, "destination": dest})
+	if err == nil || !strings.Contains(err.Error(), `symlink "evil" points outside the destination`) {
+		t.Fatalf("Expected the chained symlink to be rejected, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(dest, "evil")); err == nil {
+		t.Error("Expected the escaping symlink not to be created")
+	}
+	if target, err := os.Readlink(filepath.Join(dest, "up")); err != nil || target != "d1/d2/u/d1" {
+		t.Errorf("Expected the link staying inside to be extracted, got %q, %v", target, err)
+	}
+}
@@ -0,0 +1,235 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// GetEditFileTool returns the edit_file tool definition
+func GetEditFileTool() FileSystemTool {
+	return FileSystemTool{
+		Name:        "edit_file",
+		Description: "Make targeted changes to an existing file, by find/replace edits or by applying unified diff hunks, and return a diff of the changes",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "The path to the file to edit",
+				},
+				"edits": map[string]interface{}{
+					"type":        "array",
+					"description": "Edits applied in order; each old_text must occur exactly once unless replace_all is set",
+					"items": map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"old_text": map[string]interface{}{
+								"type":        "string",
+								"description": "The exact text to replace",
+							},
+							"new_text": map[string]interface{}{
+								"type":        "string",
+								"description": "The replacement text",
+							},
+							"replace_all": map[string]interface{}{
+								"type":        "boolean",
+								"description": "Replace every occurrence of old_text (default: false)",
+							},
+						},
+						"required": []string{"old_text", "new_text"},
+					},
+				},
+				"patch": map[string]interface{}{
+					"type":        "string",
+					"description": "A unified diff to apply instead of edits; hunks are located by their context lines",
+				},
+				"dry_run": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Return the diff without changing the file (default: false)",
+				},
+			},
+			"required": []string{"path"},
+		},
+	}
+}
+
+// GetDiffFilesTool returns the diff_files tool definition
+func GetDiffFilesTool() FileSystemTool {
+	return FileSystemTool{
+		Name:        "diff_files",
+		Description: "Show the unified diff between two files, or between a file and proposed new content",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "The original file; a missing file compares as empty",
+				},
+				"other_path": map[string]interface{}{
+					"type":        "string",
+					"description": "The file to compare it with",
+				},
+				"content": map[string]interface{}{
+					"type":        "string",
+					"description": "Content to compare the file with, instead of other_path",
+				},
+			},
+			"required": []string{"path"},
+		},
+	}
+}
+
+// CallDiffFiles returns the unified diff from a file to another file or to
+// the given content
+func CallDiffFiles(arguments map[string]interface{}) (*ToolResult, error) {
+	var args struct {
+		Path      string  `arg:"path,required"`
+		OtherPath *string `arg:"other_path"`
+		Content   *string `arg:"content"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	if (args.OtherPath == nil) == (args.Content == nil) {
+		return nil, fmt.Errorf("exactly one of the other_path and content arguments is required")
+	}
+
+	oldName, oldText, err := readForDiff(args.Path)
+	if err != nil {
+		return nil, err
+	}
+	var newName, newText string
+	if args.Content != nil {
+		newName, newText = oldName+" (proposed)", *args.Content
+	} else if newName, newText, err = readForDiff(*args.OtherPath); err != nil {
+		return nil, err
+	}
+
+	diff := unifiedDiff(oldName, newName, oldText, newText)
+	if diff == "" {
+		return TextResult("No differences"), nil
+	}
+	return TextResult(diff), nil
+}
+
+// readForDiff resolves path and reads it, naming a missing file /dev/null
+// and treating it as empty
+func readForDiff(path string) (name, text string, err error) {
+	absPath, err := resolvePath(path)
+	if err != nil {
+		return "", "", err
+	}
+	maxRead, _ := sizeLimits()
+	data, err := readHead(absPath, maxRead+1)
+	if os.IsNotExist(err) {
+		return "/dev/null", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read file: %v", err)
+	}
+	if int64(len(data)) > maxRead {
+		return "", "", fmt.Errorf("%s is larger than the %d byte read limit", path, maxRead)
+	}
+	if isBinary(data) {
+		return "", "", fmt.Errorf("%s is a binary file", path)
+	}
+	return absPath, string(data), nil
+}
+
+// CallEditFile applies edits or a patch to a file and returns the diff
+func CallEditFile(arguments map[string]interface{}) (*ToolResult, error) {
+	var args struct {
+		Path   string     `arg:"path,required"`
+		Edits  []fileEdit `arg:"edits"`
+		Patch  *string    `arg:"patch"`
+		DryRun bool       `arg:"dry_run"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	if (args.Edits == nil) == (args.Patch == nil) {
+		return nil, fmt.Errorf("exactly one of the edits and patch arguments is required")
+	}
+	path, dryRun := args.Path, args.DryRun
+	if !dryRun {
+		if err := checkWritable("edit_file"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Resolve absolute path within the allowed paths
+	absPath, err := resolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	maxRead, _ := sizeLimits()
+	data, err := readHead(absPath, maxRead+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+	if int64(len(data)) > maxRead {
+		return nil, fmt.Errorf("%s is larger than the %d byte read limit and cannot be edited", path, maxRead)
+	}
+	original := string(data)
+
+	var updated string
+	if args.Patch != nil {
+		updated, err = applyPatch(original, *args.Patch)
+	} else {
+		updated, err = applyEdits(original, args.Edits)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if updated == original {
+		return TextResult(fmt.Sprintf("No changes to %s", absPath)), nil
+	}
+	if err := checkWriteSize(len(updated)); err != nil {
+		return nil, err
+	}
+	diff := unifiedDiff(absPath, absPath, original, updated)
+	if dryRun {
+		return TextResult(fmt.Sprintf("Dry run, %s not changed:\n%s", absPath, diff)), nil
+	}
+
+	if err := writeFileAtomic(absPath, []byte(updated)); err != nil {
+		return nil, fmt.Errorf("failed to write file: %v", err)
+	}
+
+	return TextResult(fmt.Sprintf("Successfully edited %s:\n%s", absPath, diff)), nil
+}
+
+// fileEdit is one find/replace edit of edit_file
+type fileEdit struct {
+	OldText    string  `arg:"old_text"` // May be only whitespace
+	NewText    *string `arg:"new_text"` // May be empty
+	ReplaceAll bool    `arg:"replace_all"`
+}
+
+// applyEdits applies find/replace edits to text in order
+func applyEdits(text string, edits []fileEdit) (string, error) {
+	if len(edits) == 0 {
+		return "", fmt.Errorf("edits argument must not be empty")
+	}
+	for i, edit := range edits {
+		if edit.OldText == "" {
+			return "", fmt.Errorf("edits[%d].old_text is required and must be a non-empty string", i)
+		}
+		if edit.NewText == nil {
+			return "", fmt.Errorf("edits[%d].new_text is required and must be a string", i)
+		}
+		oldText, newText, replaceAll := edit.OldText, *edit.NewText, edit.ReplaceAll
+
+		switch count := strings.Count(text, oldText); {
+		case count == 0:
+			return "", fmt.Errorf("edits[%d].old_text was not found in the file", i)
+		case count > 1 && !replaceAll:
+			return "", fmt.Errorf("edits[%d].old_text occurs %d times; add surrounding lines to make it unique or set replace_all", i, count)
+		}
+		text = strings.ReplaceAll(text, oldText, newText)
+	}
+	return text, nil
+}
@@ -0,0 +1,181 @@
+package tools
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// GetFileInfoTool returns the get_file_info tool definition
+func GetFileInfoTool() FileSystemTool {
+	return FileSystemTool{
+		Name:        "get_file_info",
+		Description: "Get the metadata of a file or directory: type, size, permissions, modification time, owner and MIME type",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "The path to the file or directory",
+				},
+				"follow_symlinks": followSymlinksProperty("Describe the target of a symlink instead of the link itself (default: false)"),
+			},
+			"required": []string{"path"},
+		},
+	}
+}
+
+// CallGetFileInfo returns the metadata of a file or directory
+func CallGetFileInfo(arguments map[string]interface{}) (*ToolResult, error) {
+	var args struct {
+		Path           string `arg:"path,required"`
+		FollowSymlinks bool   `arg:"follow_symlinks"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	path, follow := args.Path, args.FollowSymlinks
+
+	// A final symlink is described rather than followed unless asked;
+	// following is refused for links leaving the allowed paths
+	absPath, err := resolveFollow(path, follow)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("file or directory does not exist: %v", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "path: %s\n", absPath)
+	fmt.Fprintf(&b, "type: %s\n", entryType(info))
+	fmt.Fprintf(&b, "size: %d\n", info.Size())
+	fmt.Fprintf(&b, "mode: %s (%04o)\n", info.Mode(), info.Mode().Perm())
+	fmt.Fprintf(&b, "modified: %s\n", info.ModTime().UTC().Format(time.RFC3339))
+	if owner := fileOwner(info); owner != "" {
+		fmt.Fprintf(&b, "owner: %s\n", owner)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		if target, err := os.Readlink(absPath); err == nil {
+			fmt.Fprintf(&b, "target: %s\n", target)
+		}
+		if symlinkEscapes(absPath) {
+			fmt.Fprintf(&b, "target_status: outside the allowed paths or missing\n")
+		}
+	}
+	if info.Mode().IsRegular() {
+		fmt.Fprintf(&b, "mime_type: %s\n", detectMimeType(absPath))
+	}
+	return TextResult(b.String()), nil
+}
+
+// hashAlgorithms are the algorithms supported by hash_file
+var hashAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// GetHashFileTool returns the hash_file tool definition
+func GetHashFileTool() FileSystemTool {
+	return FileSystemTool{
+		Name:        "hash_file",
+		Description: "Compute the checksum of a file",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "The path to the file",
+				},
+				"algorithm": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"md5", "sha1", "sha256", "sha512"},
+					"description": "The hash algorithm (default: sha256)",
+				},
+			},
+			"required": []string{"path"},
+		},
+	}
+}
+
+// CallHashFile computes the checksum of a file, in the format of sha256sum
+func CallHashFile(arguments map[string]interface{}) (*ToolResult, error) {
+	args := struct {
+		Path      string `arg:"path,required"`
+		Algorithm string `arg:"algorithm"`
+	}{Algorithm: "sha256"}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	path := args.Path
+
+	algorithm := strings.ToLower(strings.ReplaceAll(args.Algorithm, "-", ""))
+	newHash, ok := hashAlgorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported algorithm %q (use md5, sha1, sha256 or sha512)", args.Algorithm)
+	}
+
+	// Resolve absolute path within the allowed paths
+	absPath, err := resolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+	defer f.Close()
+
+	h := newHash()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	return TextResult(fmt.Sprintf("%s  %s", hex.EncodeToString(h.Sum(nil)), absPath)), nil
+}
+
+// entryType names the type of a directory entry
+func entryType(info os.FileInfo) string {
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		return "symlink"
+	case info.IsDir():
+		return "directory"
+	case info.Mode().IsRegular():
+		return "file"
+	default:
+		return "other"
+	}
+}
+
+// detectMimeType guesses the MIME type of a file from its extension, falling
+// back to sniffing its first 512 bytes
+func detectMimeType(path string) string {
+	if mimeType := mime.TypeByExtension(filepath.Ext(path)); mimeType != "" {
+		return mimeType
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, _ := f.Read(buf)
+	return http.DetectContentType(buf[:n])
+}
@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// GetSetPermissionsTool returns the set_permissions tool definition
+func GetSetPermissionsTool() FileSystemTool {
+	return FileSystemTool{
+		Name:        "set_permissions",
+		Description: "Change the permissions of a file or directory, like chmod",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "The path to the file or directory",
+				},
+				"mode": map[string]interface{}{
+					"type":        "string",
+					"description": "Octal mode such as \"755\" or symbolic changes such as \"u+x,go-w\"",
+				},
+				"recursive": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also change everything below a directory (default: false); symlinks are skipped",
+				},
+			},
+			"required": []string{"path", "mode"},
+		},
+	}
+}
+
+// CallSetPermissions changes the permissions of a file or directory
+func CallSetPermissions(arguments map[string]interface{}) (*ToolResult, error) {
+	if err := checkWritable("set_permissions"); err != nil {
+		return nil, err
+	}
+
+	var args struct {
+		Path      string `arg:"path,required"`
+		Mode      string `arg:"mode,required"`
+		Recursive bool   `arg:"recursive"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	path, spec, recursive := args.Path, args.Mode, args.Recursive
+	// Validate the mode before touching anything
+	if _, err := applyModeSpec(spec, 0); err != nil {
+		return nil, err
+	}
+
+	// Resolve absolute path within the allowed paths
+	absPath, err := resolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("file or directory does not exist: %v", err)
+	}
+
+	count := 0
+	chmod := func(file string, mode fs.FileMode) error {
+		newMode, _ := applyModeSpec(spec, mode.Perm())
+		if err := os.Chmod(file, newMode); err != nil {
+			return err
+		}
+		count++
+		return nil
+	}
+
+	if !recursive || !info.IsDir() {
+		if err := chmod(absPath, info.Mode()); err != nil {
+			return nil, fmt.Errorf("failed to change permissions: %v", err)
+		}
+		return TextResult(fmt.Sprintf("Successfully changed the permissions of %s to %s", absPath, modeOf(absPath))), nil
+	}
+
+	err = filepath.WalkDir(absPath, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		entryInfo, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return chmod(file, entryInfo.Mode())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to change permissions after %d entries: %v", count, err)
+	}
+
+	return TextResult(fmt.Sprintf("Successfully changed the permissions of %d entries below %s", count, absPath)), nil
+}
+
+// modeOf formats the permissions of path for messages
+func modeOf(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "?"
+	}
+	return fmt.Sprintf("%s (%04o)", info.Mode().Perm(), info.Mode().Perm())
+}
+
+// applyModeSpec returns mode changed by spec: an octal mode ("755", "0644")
+// or comma-separated symbolic clauses ("u+x,go-w", "a=r") as understood by
+// chmod, without the special bits
+func applyModeSpec(spec string, mode fs.FileMode) (fs.FileMode, error) {
+	if octal, err := strconv.ParseUint(spec, 8, 32); err == nil {
+		if octal > 0777 {
+			return 0, fmt.Errorf("invalid mode %q: only permission bits (up to 0777) can be set", spec)
+		}
+		return fs.FileMode(octal), nil
+	}
+
+	for _, clause := range strings.Split(spec, ",") {
+		i := strings.IndexAny(clause, "+-=")
+		if i < 0 {
+			return 0, fmt.Errorf("invalid mode %q: expected an octal mode or clauses such as u+x", spec)
+		}
+
+		var who fs.FileMode
+		for _, c := range clause[:i] {
+			switch c {
+			case 'u':
+				who |= 0700
+			case 'g':
+				who |= 0070
+			case 'o':
+				who |= 0007
+			case 'a':
+				who |= 0777
+			default:
+				return 0, fmt.Errorf("invalid mode %q: unknown class %q", spec, c)
+			}
+		}
+		if who == 0 {
+			who = 0777
+		}
+
+		var perms fs.FileMode
+		for _, c := range clause[i+1:] {
+			switch c {
+			case 'r':
+				perms |= 0444
+			case 'w':
+				perms |= 0222
+			case 'x':
+				perms |= 0111
+			default:
+				return 0, fmt.Errorf("invalid mode %q: unknown permission %q", spec, c)
+			}
+		}
+
+		switch clause[i] {
+		case '+':
+			mode |= perms & who
+		case '-':
+			mode &^= perms & who
+		case '=':
+			mode = mode&^who | perms&who
+		}
+	}
+	return mode, nil
+}
@@ -0,0 +1,177 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// tailChunkSize is the size of the blocks read backwards by readTailLines
+const tailChunkSize = 64 * 1024
+
+// GetReadMultipleFilesTool returns the read_multiple_files tool definition
+func GetReadMultipleFilesTool() FileSystemTool {
+	return FileSystemTool{
+		Name:        "read_multiple_files",
+		Description: "Read several files in one call. Each file is returned under a header with its path; a file that cannot be read reports its error without failing the others",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"paths": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "The paths of the files to read",
+				},
+				"encoding": map[string]interface{}{
+					"type":        "string",
+					"enum":        append(append([]string(nil), textEncodings...), "base64"),
+					"description": "Decode the contents as read_file does: \"text\" (default) detects the encoding, \"base64\" for binary files",
+				},
+			},
+			"required": []string{"paths"},
+		},
+	}
+}
+
+// CallReadMultipleFiles reads each of the given files with read_file. The
+// read limit applies to the combined size, so files after the limit is
+// reached are skipped.
+func CallReadMultipleFiles(arguments map[string]interface{}) (*ToolResult, error) {
+	var args struct {
+		Paths    []string `arg:"paths,required"`
+		Encoding string   `arg:"encoding"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	maxRead, _ := sizeLimits()
+	var total int64
+	var b strings.Builder
+	for i, path := range args.Paths {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "=== %s ===\n", path)
+		if total >= maxRead {
+			fmt.Fprintf(&b, "Skipped: the combined read limit of %d bytes was reached\n", maxRead)
+			continue
+		}
+
+		readArguments := map[string]interface{}{"path": path}
+		if args.Encoding != "" {
+			readArguments["encoding"] = args.Encoding
+		}
+		result, err := CallReadFile(readArguments)
+		if err != nil {
+			fmt.Fprintf(&b, "Error: %v\n", err)
+			continue
+		}
+		content := result.Content[0].Text
+		total += int64(len(content))
+		b.WriteString(content)
+		if !strings.HasSuffix(content, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return TextResult(b.String()), nil
+}
+
+// readHead returns up to max bytes from the start of the file at path
+func readHead(path string, max int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, max))
+}
+
+// readLineRange returns up to limit lines (all when 0) of the file at path
+// after skipping offset lines, keeping their line endings. It stops once
+// max bytes have been collected.
+func readLineRange(path string, offset, limit int, max int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var out bytes.Buffer
+	reader := bufio.NewReader(f)
+	for line := 0; limit == 0 || line < offset+limit; line++ {
+		text, err := reader.ReadSlice('\n')
+		if line >= offset {
+			out.Write(text)
+			if int64(out.Len()) >= max {
+				return out.Bytes()[:max], nil
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			// A line longer than the buffer: keep reading the same line
+			line--
+			continue
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// readTailLines returns the last n lines of the file at path, reading it
+// backwards from the end. It stops once more than max bytes have been read,
+// returning at least the last max bytes.
+func readTailLines(path string, n int, max int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	end := info.Size()
+	var data []byte
+	for pos := end; pos > 0; {
+		size := int64(tailChunkSize)
+		if pos < size {
+			size = pos
+		}
+		pos -= size
+		chunk := make([]byte, size)
+		if _, err := f.ReadAt(chunk, pos); err != nil {
+			return nil, err
+		}
+		data = append(chunk, data...)
+
+		// A final line feed terminates the last line rather than starting
+		// another one
+		body := data
+		if pos+int64(len(data)) == end && bytes.HasSuffix(body, []byte("\n")) {
+			body = body[:len(body)-1]
+		}
+		if bytes.Count(body, []byte("\n")) >= n || int64(len(data)) > max {
+			break
+		}
+	}
+
+	// Drop the lines before the last n
+	start := len(bytes.TrimSuffix(data, []byte("\n")))
+	for ; n > 0; n-- {
+		start = bytes.LastIndexByte(data[:start], '\n')
+		if start < 0 {
+			return data, nil
+		}
+	}
+	return data[start+1:], nil
+}
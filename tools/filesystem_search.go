@@ -0,0 +1,414 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Defaults of the search_files and find_files tools
+const (
+	defaultMaxMatches = 100
+	defaultMaxResults = 1000
+	// maxSearchFileBytes skips files too large to be source or text
+	maxSearchFileBytes = 10 << 20
+)
+
+// GetSearchFilesTool returns the search_files tool definition
+func GetSearchFilesTool() FileSystemTool {
+	return FileSystemTool{
+		Name:        "search_files",
+		Description: "Search the contents of the files under a directory for a regular expression or literal text, returning file:line:text matches",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "The directory to search (or a single file)",
+				},
+				"pattern": map[string]interface{}{
+					"type":        "string",
+					"description": "The regular expression (RE2 syntax) or, with literal, the text to search for",
+				},
+				"literal": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Treat pattern as literal text rather than a regular expression (default: false)",
+				},
+				"case_sensitive": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Match case (default: true)",
+				},
+				"include": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Only search files matching one of these globs, e.g. \"*.go\" or \"src/**/*.ts\"",
+				},
+				"exclude": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Skip files and directories matching one of these globs, e.g. \"vendor\" or \"**/*_test.go\"",
+				},
+				"max_matches": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Stop after this many matching lines (default: %d)", defaultMaxMatches),
+				},
+				"context_lines": map[string]interface{}{
+					"type":        "integer",
+					"description": "Lines of context to show before and after each match (default: 0)",
+				},
+			},
+			"required": []string{"path", "pattern"},
+		},
+	}
+}
+
+// CallSearchFiles searches file contents under a directory
+func CallSearchFiles(arguments map[string]interface{}) (*ToolResult, error) {
+	args := struct {
+		Path          string   `arg:"path,required"`
+		Pattern       string   `arg:"pattern"` // May be only whitespace
+		Literal       bool     `arg:"literal"`
+		CaseSensitive bool     `arg:"case_sensitive"`
+		Include       []string `arg:"include"`
+		Exclude       []string `arg:"exclude"`
+		MaxMatches    int      `arg:"max_matches"`
+		ContextLines  int      `arg:"context_lines"`
+	}{CaseSensitive: true, MaxMatches: defaultMaxMatches}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Pattern == "" {
+		return nil, fmt.Errorf("pattern argument is required and must be a non-empty string")
+	}
+	root, pattern, include, exclude := args.Path, args.Pattern, args.Include, args.Exclude
+	maxMatches, contextLines := args.MaxMatches, args.ContextLines
+	if maxMatches <= 0 || contextLines < 0 {
+		return nil, fmt.Errorf("max_matches must be positive and context_lines must not be negative")
+	}
+	for _, glob := range append(append([]string(nil), include...), exclude...) {
+		if !validGlob(glob) {
+			return nil, fmt.Errorf("invalid glob pattern %q", glob)
+		}
+	}
+
+	if args.Literal {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if !args.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %v", err)
+	}
+
+	// Resolve absolute path within the allowed paths
+	absRoot, err := resolvePath(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var out strings.Builder
+	matches, files := 0, 0
+	truncated := false
+	err = walkFiles(absRoot, include, exclude, func(file string) error {
+		n, err := searchFile(&out, file, re, contextLines, maxMatches-matches)
+		if err != nil {
+			// Unreadable files are skipped like binary ones
+			return nil
+		}
+		if n > 0 {
+			files++
+		}
+		matches += n
+		if matches >= maxMatches {
+			truncated = true
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %v", err)
+	}
+
+	if matches == 0 {
+		return TextResult(fmt.Sprintf("No matches for %q under %s", args.Pattern, absRoot)), nil
+	}
+	summary := fmt.Sprintf("Found %d matching lines in %d files under %s", matches, files, absRoot)
+	if truncated {
+		summary += fmt.Sprintf(" (stopped at max_matches=%d)", maxMatches)
+	}
+	return TextResult(summary + ":\n" + out.String()), nil
+}
+
+// GetFindFilesTool returns the find_files tool definition
+func GetFindFilesTool() FileSystemTool {
+	return FileSystemTool{
+		Name:        "find_files",
+		Description: "Find files and directories under a directory whose relative path matches a glob pattern such as **/*.go",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "The directory to search",
+				},
+				"pattern": map[string]interface{}{
+					"type":        "string",
+					"description": "Glob matched against the path relative to the directory; ** matches any number of directories and a pattern without a slash matches the base name",
+				},
+				"type": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"any", "file", "directory"},
+					"description": "Only return entries of this type (default: any)",
+				},
+				"max_depth": map[string]interface{}{
+					"type":        "integer",
+					"description": "Only descend this many levels below the directory (default: unlimited)",
+				},
+				"max_results": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Stop after this many results (default: %d)", defaultMaxResults),
+				},
+			},
+			"required": []string{"path", "pattern"},
+		},
+	}
+}
+
+// CallFindFiles finds the entries under a directory matching a glob
+func CallFindFiles(arguments map[string]interface{}) (*ToolResult, error) {
+	args := struct {
+		Path       string `arg:"path,required"`
+		Pattern    string `arg:"pattern,required"`
+		Type       string `arg:"type"`
+		MaxDepth   int    `arg:"max_depth"`
+		MaxResults int    `arg:"max_results"`
+	}{Type: "any", MaxResults: defaultMaxResults}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	root, pattern, entryType := args.Path, args.Pattern, args.Type
+	if !validGlob(pattern) {
+		return nil, fmt.Errorf("invalid glob pattern %q", pattern)
+	}
+	if entryType != "any" && entryType != "file" && entryType != "directory" {
+		return nil, fmt.Errorf("type argument must be one of any, file or directory")
+	}
+	maxDepth, maxResults := args.MaxDepth, args.MaxResults
+	if maxDepth < 0 || maxResults <= 0 {
+		return nil, fmt.Errorf("max_depth must not be negative and max_results must be positive")
+	}
+
+	// Resolve absolute path within the allowed paths
+	absRoot, err := resolvePath(root)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(absRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+
+	var results []string
+	truncated := false
+	err = filepath.WalkDir(absRoot, func(file string, d fs.DirEntry, err error) error {
+		if err != nil || file == absRoot {
+			return nil
+		}
+		rel, _ := filepath.Rel(absRoot, file)
+		rel = filepath.ToSlash(rel)
+		depth := strings.Count(rel, "/") + 1
+
+		wanted := entryType == "any" ||
+			(entryType == "directory" && d.IsDir()) ||
+			(entryType == "file" && d.Type().IsRegular())
+		if wanted && matchGlob(pattern, rel) {
+			if len(results) == maxResults {
+				truncated = true
+				return fs.SkipAll
+			}
+			results = append(results, file)
+		}
+		// Entries at max_depth are reported but not descended into
+		if d.IsDir() && maxDepth > 0 && depth >= maxDepth {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %v", err)
+	}
+
+	if len(results) == 0 {
+		return TextResult(fmt.Sprintf("No entries matching %q under %s", pattern, absRoot)), nil
+	}
+	summary := fmt.Sprintf("Found %d entries matching %q under %s", len(results), pattern, absRoot)
+	if truncated {
+		summary += fmt.Sprintf(" (stopped at max_results=%d)", maxResults)
+	}
+	return TextResult(summary + ":\n" + strings.Join(results, "\n") + "\n"), nil
+}
+
+// walkFiles calls fn for every regular file under root (or root itself when
+// it is a file) that matches the include globs and none of the exclude globs.
+// Globs are matched against the path relative to root with forward slashes;
+// a glob without a slash matches the base name. Symlinks are not followed.
+func walkFiles(root string, include, exclude []string, fn func(path string) error) error {
+	return filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if file == root {
+				return err
+			}
+			return nil
+		}
+		if file != root {
+			rel, _ := filepath.Rel(root, file)
+			rel = filepath.ToSlash(rel)
+			if matchAnyGlob(exclude, rel) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() || !d.Type().IsRegular() {
+				return nil
+			}
+			if len(include) > 0 && !matchAnyGlob(include, rel) {
+				return nil
+			}
+		} else if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		return fn(file)
+	})
+}
+
+// searchFile writes the lines of file matching re, with contextLines of
+// context around them, and returns the number of matching lines (at most
+// limit). Binary and oversized files are skipped.
+func searchFile(out *strings.Builder, file string, re *regexp.Regexp, contextLines, limit int) (int, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() > maxSearchFileBytes {
+		return 0, nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return 0, err
+	}
+	if isBinary(data) {
+		return 0, nil
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSearchFileBytes)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	matches := 0
+	printed := -1 // index of the last line written
+	for i, line := range lines {
+		if matches >= limit {
+			break
+		}
+		if !re.MatchString(line) {
+			continue
+		}
+		matches++
+		start := i - contextLines
+		if start <= printed {
+			start = printed + 1
+		} else if printed >= 0 && contextLines > 0 {
+			out.WriteString("--\n")
+		}
+		if start < 0 {
+			start = 0
+		}
+		for j := start; j < i; j++ {
+			fmt.Fprintf(out, "%s-%d-%s\n", file, j+1, lines[j])
+		}
+		fmt.Fprintf(out, "%s:%d:%s\n", file, i+1, line)
+		printed = i
+		// Trailing context, which may run into further matches
+		for j := i + 1; j <= i+contextLines && j < len(lines) && !re.MatchString(lines[j]); j++ {
+			fmt.Fprintf(out, "%s-%d-%s\n", file, j+1, lines[j])
+			printed = j
+		}
+	}
+	return matches, nil
+}
+
+// isBinary reports whether data looks like a binary file: a NUL byte in its
+// first 8000 bytes, as git and grep decide
+func isBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// matchAnyGlob reports whether rel matches any of globs
+func matchAnyGlob(globs []string, rel string) bool {
+	for _, glob := range globs {
+		if matchGlob(glob, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash-separated relative path against glob, where "**"
+// matches any number of directories. A glob without a slash matches the base
+// name at any depth.
+func matchGlob(glob, rel string) bool {
+	if !strings.Contains(glob, "/") {
+		ok, _ := path.Match(glob, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(glob, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(glob, parts []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(glob[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(glob[0], parts[0]); !ok {
+			return false
+		}
+		glob, parts = glob[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// validGlob reports whether every segment of glob is a valid pattern
+func validGlob(glob string) bool {
+	for _, segment := range strings.Split(glob, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return false
+		}
+	}
+	return true
+}
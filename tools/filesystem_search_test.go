@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTree creates the given files, keyed by slash-separated relative path,
+// under root
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		file := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSearchFiles(t *testing.T) {
+	root := sandbox(t)
+	writeTree(t, root, map[string]string{
+		"main.go":           "package main\n\nfunc main() {\n\tTODO()\n}\n",
+		"pkg/util.go":       "package pkg\n// todo: tidy\n",
+		"pkg/util_test.go":  "package pkg\n// TODO test\n",
+		"vendor/lib/lib.go": "// TODO vendored\n",
+		"notes.txt":         "TODO.write notes\n",
+		"image.bin":         "TODO\x00binary",
+	})
+
+	result, err := resultText(CallSearchFiles(map[string]interface{}{
+		"path":    root,
+		"pattern": "TODO",
+		"include": []interface{}{"*.go"},
+		"exclude": []interface{}{"vendor", "**/*_test.go"},
+	}))
+	if err != nil {
+		t.Fatalf("CallSearchFiles failed: %v", err)
+	}
+	if !strings.Contains(result, filepath.Join(root, "main.go")+":4:\tTODO()") {
+		t.Errorf("Expected a file:line:text match, got %q", result)
+	}
+	for _, unwanted := range []string{"util.go", "util_test.go", "vendored", "notes.txt", "image.bin"} {
+		if strings.Contains(result, unwanted) {
+			t.Errorf("Did not expect %s in %q", unwanted, result)
+		}
+	}
+
+	// Case-insensitive search with context and a match limit
+	result, err = resultText(CallSearchFiles(map[string]interface{}{
+		"path":           root,
+		"pattern":        "todo:",
+		"case_sensitive": false,
+		"include":        "pkg/**",
+		"context_lines":  float64(1),
+	}))
+	if err != nil {
+		t.Fatalf("CallSearchFiles failed: %v", err)
+	}
+	if !strings.Contains(result, "util.go:2:// todo: tidy") || !strings.Contains(result, "util.go-1-package pkg") {
+		t.Errorf("Expected a match with context, got %q", result)
+	}
+	if strings.Contains(result, "TODO test") {
+		t.Errorf("Did not expect util_test.go to match, got %q", result)
+	}
+
+	// A literal dot matches only a dot
+	result, err = resultText(CallSearchFiles(map[string]interface{}{"path": root, "pattern": "TODO.", "literal": true}))
+	if err != nil || !strings.Contains(result, "notes.txt:1:") || strings.Contains(result, "main.go") {
+		t.Errorf("Expected only the literal match, got %q, %v", result, err)
+	}
+
+	result, err = resultText(CallSearchFiles(map[string]interface{}{"path": root, "pattern": "TODO", "max_matches": float64(1)}))
+	if err != nil || !strings.Contains(result, "stopped at max_matches=1") {
+		t.Errorf("Expected the search to stop at one match, got %q, %v", result, err)
+	}
+
+	// Invalid patterns and paths outside the sandbox are rejected
+	if _, err := CallSearchFiles(map[string]interface{}{"path": root, "pattern": "("}); err == nil {
+		t.Error("Expected an invalid regular expression to fail")
+	}
+	if _, err := CallSearchFiles(map[string]interface{}{"path": t.TempDir(), "pattern": "x"}); err == nil {
+		t.Error("Expected a directory outside the sandbox to be denied")
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		glob, path string
+		want       bool
+	}{
+		{"*.go", "a/b/c.go", true},
+		{"*.go", "a/b/c.txt", false},
+		{"**/*.go", "c.go", true},
+		{"**/*.go", "a/b/c.go", true},
+		{"src/**/*.ts", "src/app/x.ts", true},
+		{"src/**/*.ts", "lib/app/x.ts", false},
+		{"pkg/**", "pkg/a/b", true},
+		{"pkg/*", "pkg/a/b", false},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.glob, tt.path); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFindFiles(t *testing.T) {
+	root := sandbox(t)
+	writeTree(t, root, map[string]string{
+		"main.go":          "",
+		"pkg/util.go":      "",
+		"pkg/deep/deep.go": "",
+		"README.md":        "",
+	})
+
+	result, err := resultText(CallFindFiles(map[string]interface{}{"path": root, "pattern": "**/*.go"}))
+	if err != nil {
+		t.Fatalf("CallFindFiles failed: %v", err)
+	}
+	for _, want := range []string{"main.go", "util.go", "deep.go"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Expected %s in %q", want, result)
+		}
+	}
+	if strings.Contains(result, "README.md") {
+		t.Errorf("Did not expect README.md in %q", result)
+	}
+
+	// Depth and type filters
+	result, err = resultText(CallFindFiles(map[string]interface{}{"path": root, "pattern": "*", "max_depth": float64(2), "type": "file"}))
+	if err != nil {
+		t.Fatalf("CallFindFiles failed: %v", err)
+	}
+	if !strings.Contains(result, "util.go") || strings.Contains(result, "deep.go") || strings.Contains(result, filepath.Join(root, "pkg")+"\n") {
+		t.Errorf("Unexpected depth-limited files: %q", result)
+	}
+	result, err = resultText(CallFindFiles(map[string]interface{}{"path": root, "pattern": "**", "type": "directory"}))
+	if err != nil || !strings.Contains(result, "Found 2 entries") {
+		t.Errorf("Expected the two directories, got %q, %v", result, err)
+	}
+
+	if _, err := CallFindFiles(map[string]interface{}{"path": root, "pattern": "*", "type": "socket"}); err == nil {
+		t.Error("Expected an invalid type to fail")
+	}
+}
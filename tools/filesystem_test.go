@@ -0,0 +1,807 @@
+package tools
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sandbox restricts the filesystem tools to a new temporary directory for
+// the duration of the test and returns it
+func sandbox(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := SetAllowedPaths([]string{root}); err != nil {
+		t.Fatalf("SetAllowedPaths failed: %v", err)
+	}
+	t.Cleanup(func() { SetAllowedPaths(nil) })
+	resolved, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolved
+}
+
+func TestAllowedPaths(t *testing.T) {
+	root := sandbox(t)
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Paths inside the root work, including files that do not exist yet
+	inside := filepath.Join(root, "sub", "a.txt")
+	if _, err := CallWriteFile(map[string]interface{}{"path": inside, "content": "hello"}); err != nil {
+		t.Fatalf("CallWriteFile failed: %v", err)
+	}
+	if content, err := resultText(CallReadFile(map[string]interface{}{"path": inside})); err != nil || content != "hello" {
+		t.Errorf("Unexpected read: %q, %v", content, err)
+	}
+
+	// Paths outside, ".." escapes and symlinks pointing outside are rejected
+	link := filepath.Join(root, "link")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{
+		secret,
+		filepath.Join(root, "..", filepath.Base(outside), "secret.txt"),
+		filepath.Join(link, "secret.txt"),
+		filepath.Join(link, "new.txt"),
+	} {
+		if _, err := CallReadFile(map[string]interface{}{"path": path}); err == nil || !strings.Contains(err.Error(), "access denied") {
+			t.Errorf("Expected %s to be denied, got %v", path, err)
+		}
+		if _, err := CallWriteFile(map[string]interface{}{"path": path, "content": "x"}); err == nil {
+			t.Errorf("Expected writing %s to be denied", path)
+		}
+	}
+
+	// Deleting a symlink removes the link, not its target, and the root
+	// itself cannot be deleted
+	if _, err := CallDeleteFile(map[string]interface{}{"path": link}); err != nil {
+		t.Fatalf("CallDeleteFile failed: %v", err)
+	}
+	if _, err := os.Stat(secret); err != nil {
+		t.Errorf("Expected the link target to survive: %v", err)
+	}
+	if _, err := CallDeleteFile(map[string]interface{}{"path": root}); err == nil {
+		t.Error("Expected deleting the allowed root to be denied")
+	}
+}
+
+func TestDanglingSymlinkEscape(t *testing.T) {
+	root := sandbox(t)
+	outside := filepath.Join(t.TempDir(), "outside.txt")
+
+	// A dangling link to a missing file outside the root is not a way out
+	evil := filepath.Join(root, "evil")
+	if err := os.Symlink(outside, evil); err != nil {
+		t.Fatal(err)
+	}
+	chained := filepath.Join(root, "chained")
+	if err := os.Symlink("evil", chained); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{evil, chained} {
+		if _, err := CallAppendFile(map[string]interface{}{"path": path, "content": "x"}); err == nil || !strings.Contains(err.Error(), "access denied") {
+			t.Errorf("Expected appending to %s to be denied, got %v", path, err)
+		}
+		if _, err := CallWriteFile(map[string]interface{}{"path": path, "content": "x"}); err == nil {
+			t.Errorf("Expected writing %s to be denied", path)
+		}
+	}
+	if _, err := os.Lstat(outside); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be created outside the root, got %v", err)
+	}
+
+	// A dangling link inside the root creates its target
+	inside := filepath.Join(root, "inside")
+	if err := os.Symlink("target.txt", inside); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CallAppendFile(map[string]interface{}{"path": inside, "content": "x"}); err != nil {
+		t.Fatalf("CallAppendFile failed: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "target.txt")); err != nil || string(data) != "x" {
+		t.Errorf("Expected the link target to be created, got %q, %v", data, err)
+	}
+
+	// A cycle of links is an error rather than endless resolution
+	if err := os.Symlink("loop2", filepath.Join(root, "loop1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("loop1", filepath.Join(root, "loop2")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CallAppendFile(map[string]interface{}{"path": filepath.Join(root, "loop1"), "content": "x"}); err == nil {
+		t.Error("Expected a cycle of links to be rejected")
+	}
+}
+
+func TestSetAllowedPathsRejectsFiles(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetAllowedPaths([]string{file}); err == nil {
+		t.Error("Expected a file to be rejected as an allowed path")
+	}
+	if len(GetAllowedPaths()) != 0 {
+		t.Error("Expected a failed SetAllowedPaths to leave the sandbox unchanged")
+	}
+}
+
+func TestMoveFile(t *testing.T) {
+	root := sandbox(t)
+	src := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(src, []byte("a"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(root, "sub", "b.txt")
+	if _, err := CallMoveFile(map[string]interface{}{"source": src, "destination": dst}); err != nil {
+		t.Fatalf("CallMoveFile failed: %v", err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("Expected the source to be gone, got %v", err)
+	}
+	if content, err := os.ReadFile(dst); err != nil || string(content) != "a" {
+		t.Errorf("Unexpected destination: %q, %v", content, err)
+	}
+
+	// An existing destination is only replaced with overwrite
+	other := filepath.Join(root, "c.txt")
+	if err := os.WriteFile(other, []byte("c"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CallMoveFile(map[string]interface{}{"source": other, "destination": dst}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected an existing destination error, got %v", err)
+	}
+	if _, err := CallMoveFile(map[string]interface{}{"source": other, "destination": dst, "overwrite": true}); err != nil {
+		t.Fatalf("CallMoveFile with overwrite failed: %v", err)
+	}
+	if content, _ := os.ReadFile(dst); string(content) != "c" {
+		t.Errorf("Expected the destination to be replaced, got %q", content)
+	}
+
+	// Directories move with their contents but not into themselves or out of
+	// the sandbox
+	dir := filepath.Join(root, "sub")
+	if _, err := CallMoveFile(map[string]interface{}{"source": dir, "destination": filepath.Join(dir, "inner")}); err == nil {
+		t.Error("Expected moving a directory into itself to fail")
+	}
+	if _, err := CallMoveFile(map[string]interface{}{"source": dir, "destination": filepath.Join(t.TempDir(), "out")}); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("Expected moving outside the sandbox to be denied, got %v", err)
+	}
+	if _, err := CallMoveFile(map[string]interface{}{"source": dir, "destination": filepath.Join(root, "renamed")}); err != nil {
+		t.Fatalf("CallMoveFile of a directory failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "renamed", "b.txt")); err != nil {
+		t.Errorf("Expected the directory contents to move: %v", err)
+	}
+}
+
+func TestCopyPathPreservesPermissions(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.MkdirAll(filepath.Join(src, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(src, "nested", "run.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("nested/run.sh", filepath.Join(src, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "dst")
+	if err := copyPath(src, dst); err != nil {
+		t.Fatalf("copyPath failed: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dst, "nested", "run.sh"))
+	if err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("Expected an executable copy, got %v, %v", info, err)
+	}
+	if target, err := os.Readlink(filepath.Join(dst, "link")); err != nil || target != "nested/run.sh" {
+		t.Errorf("Expected the symlink to be copied as a link, got %q, %v", target, err)
+	}
+}
+
+func TestCopyFile(t *testing.T) {
+	root := sandbox(t)
+	dir := filepath.Join(root, "dir")
+	if err := os.MkdirAll(filepath.Join(dir, "nested"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "nested", "a.txt"), []byte("a"), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	copyDir := filepath.Join(root, "copy")
+	if _, err := CallCopyFile(map[string]interface{}{"source": dir, "destination": copyDir}); err != nil {
+		t.Fatalf("CallCopyFile failed: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(copyDir, "nested", "a.txt"))
+	if err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("Expected the file to be copied with its permissions, got %v, %v", info, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "nested", "a.txt")); err != nil {
+		t.Errorf("Expected the source to remain: %v", err)
+	}
+
+	// Existing destinations need overwrite; a directory cannot contain its copy
+	if _, err := CallCopyFile(map[string]interface{}{"source": dir, "destination": copyDir}); err == nil {
+		t.Error("Expected copying onto an existing destination to fail")
+	}
+	if _, err := CallCopyFile(map[string]interface{}{"source": dir, "destination": copyDir, "overwrite": true}); err != nil {
+		t.Errorf("CallCopyFile with overwrite failed: %v", err)
+	}
+	if _, err := CallCopyFile(map[string]interface{}{"source": dir, "destination": filepath.Join(dir, "self")}); err == nil {
+		t.Error("Expected copying a directory into itself to fail")
+	}
+}
+
+func TestGetFileInfo(t *testing.T) {
+	root := sandbox(t)
+	file := filepath.Join(root, "page.html")
+	if err := os.WriteFile(file, []byte("<html></html>"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("page.html", filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := resultText(CallGetFileInfo(map[string]interface{}{"path": file}))
+	if err != nil {
+		t.Fatalf("CallGetFileInfo failed: %v", err)
+	}
+	for _, want := range []string{"type: file", "size: 13", "(0640)", "mime_type: text/html", "modified: "} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Expected %q in %q", want, result)
+		}
+	}
+
+	result, err = resultText(CallGetFileInfo(map[string]interface{}{"path": filepath.Join(root, "link")}))
+	if err != nil || !strings.Contains(result, "type: symlink") || !strings.Contains(result, "target: page.html") {
+		t.Errorf("Expected the symlink to be described, got %q, %v", result, err)
+	}
+
+	if _, err := CallGetFileInfo(map[string]interface{}{"path": filepath.Join(root, "missing")}); err == nil {
+		t.Error("Expected a missing path to fail")
+	}
+}
+
+func TestEditFile(t *testing.T) {
+	root := sandbox(t)
+	file := filepath.Join(root, "main.go")
+	original := "package main\n\nfunc main() {\n\tprintln(\"hello\")\n\tprintln(\"hello\")\n}\n"
+	if err := os.WriteFile(file, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ambiguous and missing old_text are rejected without changes
+	if _, err := CallEditFile(map[string]interface{}{"path": file, "edits": []interface{}{
+		map[string]interface{}{"old_text": "println(\"hello\")", "new_text": "println(\"bye\")"},
+	}}); err == nil || !strings.Contains(err.Error(), "occurs 2 times") {
+		t.Errorf("Expected an ambiguous edit error, got %v", err)
+	}
+	if _, err := CallEditFile(map[string]interface{}{"path": file, "edits": []interface{}{
+		map[string]interface{}{"old_text": "missing", "new_text": "x"},
+	}}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+
+	// A dry run returns the diff only
+	edits := []interface{}{
+		map[string]interface{}{"old_text": "hello", "new_text": "bye", "replace_all": true},
+	}
+	result, err := resultText(CallEditFile(map[string]interface{}{"path": file, "edits": edits, "dry_run": true}))
+	if err != nil || !strings.Contains(result, "-\tprintln(\"hello\")\n") || !strings.Contains(result, "+\tprintln(\"bye\")\n") {
+		t.Errorf("Expected a diff, got %q, %v", result, err)
+	}
+	if content, _ := os.ReadFile(file); string(content) != original {
+		t.Errorf("Expected the dry run to leave the file alone, got %q", content)
+	}
+
+	if _, err := CallEditFile(map[string]interface{}{"path": file, "edits": edits}); err != nil {
+		t.Fatalf("CallEditFile failed: %v", err)
+	}
+	edited, _ := os.ReadFile(file)
+	if strings.Contains(string(edited), "hello") {
+		t.Errorf("Expected every occurrence to be replaced, got %q", edited)
+	}
+
+	// The diff of an edit can be applied as a patch
+	patch := unifiedDiff(file, file, string(edited), original)
+	if _, err := CallEditFile(map[string]interface{}{"path": file, "patch": patch}); err != nil {
+		t.Fatalf("CallEditFile with a patch failed: %v", err)
+	}
+	if content, _ := os.ReadFile(file); string(content) != original {
+		t.Errorf("Expected the patch to restore the file, got %q", content)
+	}
+
+	if _, err := CallEditFile(map[string]interface{}{"path": file}); err == nil {
+		t.Error("Expected an error without edits or patch")
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	root := sandbox(t)
+	file := filepath.Join(root, "run.sh")
+	if err := os.WriteFile(file, []byte("v1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CallWriteFile(map[string]interface{}{"path": file, "content": "v2", "backup": true}); err != nil {
+		t.Fatalf("CallWriteFile failed: %v", err)
+	}
+	if _, err := CallWriteFile(map[string]interface{}{"path": file, "content": "v3", "backup": true}); err != nil {
+		t.Fatalf("CallWriteFile failed: %v", err)
+	}
+	if content, _ := os.ReadFile(file); string(content) != "v3" {
+		t.Errorf("Unexpected content: %q", content)
+	}
+	if content, _ := os.ReadFile(file + ".bak"); string(content) != "v2" {
+		t.Errorf("Expected the backup to hold the previous content, got %q", content)
+	}
+	if info, _ := os.Stat(file); info.Mode().Perm() != 0755 {
+		t.Errorf("Expected the mode to be kept, got %v", info.Mode())
+	}
+
+	// No temporary files are left behind
+	entries, _ := os.ReadDir(root)
+	if len(entries) != 2 {
+		t.Errorf("Expected only the file and its backup, got %v", entries)
+	}
+}
+
+func TestAppendFile(t *testing.T) {
+	root := sandbox(t)
+	file := filepath.Join(root, "logs", "app.log")
+
+	if _, err := CallAppendFile(map[string]interface{}{"path": file, "content": "first"}); err != nil {
+		t.Fatalf("CallAppendFile failed: %v", err)
+	}
+	if _, err := CallAppendFile(map[string]interface{}{"path": file, "content": "second", "ensure_newline": true}); err != nil {
+		t.Fatalf("CallAppendFile failed: %v", err)
+	}
+	if _, err := CallAppendFile(map[string]interface{}{"path": file, "content": "third\n", "ensure_newline": true}); err != nil {
+		t.Fatalf("CallAppendFile failed: %v", err)
+	}
+	if content, _ := os.ReadFile(file); string(content) != "first\nsecond\nthird\n" {
+		t.Errorf("Unexpected content: %q", content)
+	}
+}
+
+func TestReadFileRanges(t *testing.T) {
+	root := sandbox(t)
+	file := filepath.Join(root, "app.log")
+	var content strings.Builder
+	for i := 1; i <= 20000; i++ {
+		content.WriteString(strings.Repeat("x", i%7) + "\n")
+	}
+	lines := strings.SplitAfter(content.String(), "\n")
+	if err := os.WriteFile(file, []byte(content.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		want      string
+	}{
+		{"offset and limit", map[string]interface{}{"offset": float64(10), "limit": float64(3)}, strings.Join(lines[10:13], "")},
+		{"offset only", map[string]interface{}{"offset": float64(19998)}, strings.Join(lines[19998:], "")},
+		{"limit only", map[string]interface{}{"limit": float64(2)}, strings.Join(lines[:2], "")},
+		{"tail", map[string]interface{}{"tail_lines": float64(15000)}, strings.Join(lines[5000:], "")},
+		{"tail beyond start", map[string]interface{}{"tail_lines": float64(50000)}, content.String()},
+	}
+	for _, tt := range tests {
+		tt.arguments["path"] = file
+		got, err := resultText(CallReadFile(tt.arguments))
+		if err != nil {
+			t.Errorf("%s: CallReadFile failed: %v", tt.name, err)
+		} else if got != tt.want {
+			t.Errorf("%s: got %d bytes, want %d", tt.name, len(got), len(tt.want))
+		}
+	}
+
+	// The last line need not end with a line feed
+	short := filepath.Join(root, "short.txt")
+	if err := os.WriteFile(short, []byte("a\nb\nc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := resultText(CallReadFile(map[string]interface{}{"path": short, "tail_lines": float64(2)})); err != nil || got != "b\nc" {
+		t.Errorf("Unexpected tail: %q, %v", got, err)
+	}
+
+	if _, err := CallReadFile(map[string]interface{}{"path": file, "tail_lines": float64(1), "offset": float64(1)}); err == nil {
+		t.Error("Expected tail_lines with offset to fail")
+	}
+}
+
+func TestReadMultipleFiles(t *testing.T) {
+	root := sandbox(t)
+	writeTree(t, root, map[string]string{"a.txt": "alpha\n", "b.txt": "beta"})
+
+	result, err := resultText(CallReadMultipleFiles(map[string]interface{}{
+		"paths": []interface{}{filepath.Join(root, "a.txt"), filepath.Join(root, "missing.txt"), filepath.Join(root, "b.txt")},
+	}))
+	if err != nil {
+		t.Fatalf("CallReadMultipleFiles failed: %v", err)
+	}
+	for _, want := range []string{
+		"=== " + filepath.Join(root, "a.txt") + " ===\nalpha\n",
+		"=== " + filepath.Join(root, "missing.txt") + " ===\nError: ",
+		"=== " + filepath.Join(root, "b.txt") + " ===\nbeta\n",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Expected %q in:\n%s", want, result)
+		}
+	}
+
+	// Files after the combined read limit are skipped
+	SetSizeLimits(4, DefaultMaxWriteBytes)
+	t.Cleanup(func() { SetSizeLimits(DefaultMaxReadBytes, DefaultMaxWriteBytes) })
+	result, _ = resultText(CallReadMultipleFiles(map[string]interface{}{
+		"paths": []interface{}{filepath.Join(root, "a.txt"), filepath.Join(root, "b.txt")},
+	}))
+	if !strings.Contains(result, "Skipped: the combined read limit") {
+		t.Errorf("Expected b.txt to be skipped, got:\n%s", result)
+	}
+
+	if _, err := CallReadMultipleFiles(map[string]interface{}{"paths": []interface{}{}}); err == nil {
+		t.Error("Expected an error without paths")
+	}
+}
+
+func TestBase64RoundTrip(t *testing.T) {
+	root := sandbox(t)
+	file := filepath.Join(root, "image.png")
+	binary := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff, 0xfe, '\n', 0x80}
+
+	encoded := "iVBORwD//gqA"
+	if _, err := CallWriteFile(map[string]interface{}{"path": file, "content": encoded, "encoding": "base64"}); err != nil {
+		t.Fatalf("CallWriteFile failed: %v", err)
+	}
+	if content, _ := os.ReadFile(file); string(content) != string(binary) {
+		t.Errorf("Expected the decoded bytes to be written, got %v", content)
+	}
+	if got, err := resultText(CallReadFile(map[string]interface{}{"path": file, "encoding": "base64"})); err != nil || got != encoded {
+		t.Errorf("Expected %q, got %q, %v", encoded, got, err)
+	}
+
+	if _, err := CallWriteFile(map[string]interface{}{"path": file, "content": "not base64!", "encoding": "base64"}); err == nil {
+		t.Error("Expected invalid base64 to be rejected")
+	}
+	if _, err := CallReadFile(map[string]interface{}{"path": file, "encoding": "hex"}); err == nil {
+		t.Error("Expected an unknown encoding to be rejected")
+	}
+}
+
+func TestDirectoryTree(t *testing.T) {
+	root := sandbox(t)
+	writeTree(t, root, map[string]string{
+		"a.txt":          "12345",
+		"src/main.go":    "123",
+		"src/pkg/x.go":   "1",
+		"src/pkg/y/z.go": "12",
+	})
+
+	result, err := resultText(CallDirectoryTree(map[string]interface{}{"path": root, "format": "json", "max_depth": float64(10)}))
+	if err != nil {
+		t.Fatalf("CallDirectoryTree failed: %v", err)
+	}
+	var tree treeNode
+	if err := json.Unmarshal([]byte(result), &tree); err != nil {
+		t.Fatalf("Invalid JSON tree: %v", err)
+	}
+	if tree.Size != 11 || tree.Entries != 2 || tree.Truncated {
+		t.Errorf("Unexpected root: %+v", tree)
+	}
+
+	// The depth limit truncates the listing and marks sizes as lower bounds
+	result, err = resultText(CallDirectoryTree(map[string]interface{}{"path": root, "max_depth": float64(2)}))
+	if err != nil {
+		t.Fatalf("CallDirectoryTree failed: %v", err)
+	}
+	for _, want := range []string{"├── a.txt (file, 5 bytes)", "└── src/ (2 entries, 3+ bytes)", "    ├── main.go (file, 3 bytes)", "    └── pkg/ (2 entries, 0+ bytes)"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Expected %q in\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "x.go") {
+		t.Errorf("Expected the tree to stop at depth 2:\n%s", result)
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	root := sandbox(t)
+	file := filepath.Join(root, "abc.txt")
+	if err := os.WriteFile(file, []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"":       "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		"md5":    "900150983cd24fb0d6963f7d28e17f72",
+		"SHA-1":  "a9993e364706816aba3e25717850c26c9cd0d89d",
+		"sha256": "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+	}
+	for algorithm, want := range tests {
+		arguments := map[string]interface{}{"path": file}
+		if algorithm != "" {
+			arguments["algorithm"] = algorithm
+		}
+		got, err := resultText(CallHashFile(arguments))
+		if err != nil || got != want+"  "+file {
+			t.Errorf("%q: got %q, %v", algorithm, got, err)
+		}
+	}
+
+	if _, err := CallHashFile(map[string]interface{}{"path": file, "algorithm": "crc32"}); err == nil {
+		t.Error("Expected an unsupported algorithm to fail")
+	}
+}
+
+func TestDiffFiles(t *testing.T) {
+	root := sandbox(t)
+	a := filepath.Join(root, "a.txt")
+	b := filepath.Join(root, "b.txt")
+	if err := os.WriteFile(a, []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(b, []byte("one\n2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := resultText(CallDiffFiles(map[string]interface{}{"path": a, "other_path": b}))
+	if err != nil || !strings.Contains(result, "--- "+a+"\n+++ "+b+"\n") || !strings.Contains(result, "-two\n+2\n") {
+		t.Errorf("Unexpected diff: %q, %v", result, err)
+	}
+	if result, err := resultText(CallDiffFiles(map[string]interface{}{"path": a, "content": "one\ntwo\n"})); err != nil || result != "No differences" {
+		t.Errorf("Expected no differences, got %q, %v", result, err)
+	}
+
+	// A file about to be created diffs against /dev/null
+	result, err = resultText(CallDiffFiles(map[string]interface{}{"path": filepath.Join(root, "new.txt"), "content": "hello\n"}))
+	if err != nil || !strings.Contains(result, "--- /dev/null") || !strings.Contains(result, "+hello\n") {
+		t.Errorf("Unexpected diff for a new file: %q, %v", result, err)
+	}
+
+	if _, err := CallDiffFiles(map[string]interface{}{"path": a}); err == nil {
+		t.Error("Expected an error without other_path or content")
+	}
+}
+
+func TestSetPermissions(t *testing.T) {
+	root := sandbox(t)
+	writeTree(t, root, map[string]string{"bin/run.sh": "#!/bin/sh\n", "bin/lib/x.sh": ""})
+	script := filepath.Join(root, "bin", "run.sh")
+
+	if _, err := CallSetPermissions(map[string]interface{}{"path": script, "mode": "u+x,go-r"}); err != nil {
+		t.Fatalf("CallSetPermissions failed: %v", err)
+	}
+	if info, _ := os.Stat(script); info.Mode().Perm() != 0700 {
+		t.Errorf("Expected rwx------, got %s", info.Mode().Perm())
+	}
+
+	if _, err := CallSetPermissions(map[string]interface{}{"path": filepath.Join(root, "bin"), "mode": "750", "recursive": true}); err != nil {
+		t.Fatalf("CallSetPermissions failed: %v", err)
+	}
+	for _, file := range []string{"bin", "bin/lib", "bin/lib/x.sh"} {
+		if info, _ := os.Stat(filepath.Join(root, file)); info.Mode().Perm() != 0750 {
+			t.Errorf("Expected %s to be 0750, got %s", file, info.Mode().Perm())
+		}
+	}
+
+	result, err := resultText(CallListDirectory(map[string]interface{}{"path": filepath.Join(root, "bin")}))
+	if err != nil || !strings.Contains(result, "run.sh [file] 10 bytes -rwxr-x---") {
+		t.Errorf("Expected list_directory to show the mode, got %q, %v", result, err)
+	}
+
+	for _, mode := range []string{"999", "7777", "u+z", "q+x"} {
+		if _, err := CallSetPermissions(map[string]interface{}{"path": script, "mode": mode}); err == nil {
+			t.Errorf("Expected mode %q to be rejected", mode)
+		}
+	}
+}
+
+func TestProtectedPaths(t *testing.T) {
+	// Unrestricted tools still refuse to remove a filesystem root
+	SetAllowedPaths(nil)
+	volume := filepath.VolumeName(t.TempDir()) + string(filepath.Separator)
+	if _, err := CallDeleteFile(map[string]interface{}{"path": volume}); err == nil || !strings.Contains(err.Error(), "protected") {
+		t.Errorf("Expected deleting %s to be denied, got %v", volume, err)
+	}
+	if _, err := CallMoveFile(map[string]interface{}{"source": volume, "destination": filepath.Join(t.TempDir(), "x")}); err == nil || !strings.Contains(err.Error(), "protected") {
+		t.Errorf("Expected moving %s to be denied, got %v", volume, err)
+	}
+
+	// Traversal out of the sandbox is rejected by every tool taking a path
+	root := sandbox(t)
+	escape := filepath.Join(root, "..", "..", "etc", "passwd")
+	for name, call := range map[string]func(map[string]interface{}) (*ToolResult, error){
+		"read_file":      CallReadFile,
+		"get_file_info":  CallGetFileInfo,
+		"hash_file":      CallHashFile,
+		"list_directory": CallListDirectory,
+		"directory_tree": CallDirectoryTree,
+		"watch_path":     CallWatchPath,
+		"delete_file":    CallDeleteFile,
+	} {
+		if _, err := call(map[string]interface{}{"path": escape}); err == nil || !strings.Contains(err.Error(), "access denied") {
+			t.Errorf("%s: expected %s to be denied, got %v", name, escape, err)
+		}
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	root := sandbox(t)
+	file := filepath.Join(root, "a.txt")
+	if err := os.WriteFile(file, []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	SetReadOnly(true)
+	defer SetReadOnly(false)
+
+	calls := map[string]func(map[string]interface{}) (*ToolResult, error){
+		"write_file":       CallWriteFile,
+		"append_file":      CallAppendFile,
+		"create_directory": CallCreateDirectory,
+		"delete_file":      CallDeleteFile,
+		"move_file":        CallMoveFile,
+		"copy_file":        CallCopyFile,
+		"set_permissions":  CallSetPermissions,
+		"create_archive":   CallCreateArchive,
+		"extract_archive":  CallExtractArchive,
+	}
+	for name, call := range calls {
+		if !IsMutatingTool(name) {
+			t.Errorf("Expected %s to be a mutating tool", name)
+		}
+		arguments := map[string]interface{}{"path": file, "content": "x", "source": file, "destination": file + ".zip", "mode": "600"}
+		if _, err := call(arguments); err == nil || !strings.Contains(err.Error(), "read-only") {
+			t.Errorf("%s: expected a read-only error, got %v", name, err)
+		}
+	}
+	if content, _ := os.ReadFile(file); string(content) != "a\n" {
+		t.Errorf("Expected the file to be untouched, got %q", content)
+	}
+
+	// Reading and previewing edits still work
+	if _, err := CallReadFile(map[string]interface{}{"path": file}); err != nil {
+		t.Errorf("CallReadFile failed: %v", err)
+	}
+	edits := []interface{}{map[string]interface{}{"old_text": "a", "new_text": "b"}}
+	if _, err := CallEditFile(map[string]interface{}{"path": file, "edits": edits, "dry_run": true}); err != nil {
+		t.Errorf("Expected a dry run to be allowed, got %v", err)
+	}
+	if _, err := CallEditFile(map[string]interface{}{"path": file, "edits": edits}); err == nil {
+		t.Error("Expected an edit to be rejected")
+	}
+	if IsMutatingTool("read_file") {
+		t.Error("Did not expect read_file to be a mutating tool")
+	}
+}
+
+func TestSizeLimits(t *testing.T) {
+	root := sandbox(t)
+	SetSizeLimits(10, 8)
+	defer SetSizeLimits(0, 0)
+
+	file := filepath.Join(root, "big.txt")
+	if err := os.WriteFile(file, []byte("0123456789abcdef\nline2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := resultText(CallReadFile(map[string]interface{}{"path": file}))
+	if err != nil || !strings.HasPrefix(result, "0123456789\n[Truncated: showing the first 10 bytes") {
+		t.Errorf("Expected a truncated read, got %q, %v", result, err)
+	}
+	result, err = resultText(CallReadFile(map[string]interface{}{"path": file, "tail_lines": float64(2)}))
+	if err != nil || !strings.HasPrefix(result, "def\nline2\n\n[Truncated: showing the last 10 bytes") {
+		t.Errorf("Expected a truncated tail, got %q, %v", result, err)
+	}
+	if result, err := resultText(CallReadFile(map[string]interface{}{"path": file, "offset": float64(1)})); err != nil || result != "line2\n" {
+		t.Errorf("Expected a range within the limit to be complete, got %q, %v", result, err)
+	}
+	if _, err := CallReadFile(map[string]interface{}{"path": file, "encoding": "base64"}); err == nil {
+		t.Error("Expected a base64 read beyond the limit to fail")
+	}
+
+	if _, err := CallWriteFile(map[string]interface{}{"path": file, "content": "123456789"}); err == nil || !strings.Contains(err.Error(), "write limit") {
+		t.Errorf("Expected a write beyond the limit to fail, got %v", err)
+	}
+	if _, err := CallAppendFile(map[string]interface{}{"path": file, "content": "12345678"}); err != nil {
+		t.Errorf("Expected a write within the limit to succeed, got %v", err)
+	}
+}
+
+func TestListDirectoryStructured(t *testing.T) {
+	root := sandbox(t)
+	writeTree(t, root, map[string]string{"a.txt": "abc", "sub/b.txt": ""})
+
+	result, err := CallListDirectory(map[string]interface{}{"path": root})
+	if err != nil {
+		t.Fatalf("CallListDirectory failed: %v", err)
+	}
+	text, listing := result.Content[0].Text, result.StructuredContent.(*DirectoryListing)
+	if !strings.Contains(text, "a.txt [file] 3 bytes") {
+		t.Errorf("Expected the text rendering to be kept, got %q", text)
+	}
+	if listing.Path != root || len(listing.Entries) != 2 {
+		t.Fatalf("Unexpected listing: %+v", listing)
+	}
+	a, sub := listing.Entries[0], listing.Entries[1]
+	if a.Name != "a.txt" || a.Type != "file" || a.Size != 3 || a.Mode != "-rw-r--r--" || a.ModTime == "" {
+		t.Errorf("Unexpected file entry: %+v", a)
+	}
+	if sub.Name != "sub" || sub.Type != "directory" {
+		t.Errorf("Unexpected directory entry: %+v", sub)
+	}
+
+	data, err := json.Marshal(listing)
+	if err != nil || !strings.Contains(string(data), `"mtime":`) {
+		t.Errorf("Unexpected JSON: %s, %v", data, err)
+	}
+}
+
+func TestSymlinkHandling(t *testing.T) {
+	root := sandbox(t)
+	outside := t.TempDir()
+	writeTree(t, root, map[string]string{"dir/a.txt": "abc"})
+	if err := os.Symlink("dir", filepath.Join(root, "inside")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("dir/a.txt", filepath.Join(root, "file-link")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Listings report link targets, and follow only links inside the roots
+	listed, err := CallListDirectory(map[string]interface{}{"path": root})
+	if err != nil {
+		t.Fatalf("CallListDirectory failed: %v", err)
+	}
+	text, listing := listed.Content[0].Text, listed.StructuredContent.(*DirectoryListing)
+	if !strings.Contains(text, "inside [symlink -> dir]") || !strings.Contains(text, "escape [symlink -> "+outside+", outside the allowed paths") {
+		t.Errorf("Expected symlink targets in %q", text)
+	}
+	for _, entry := range listing.Entries {
+		if entry.Name == "inside" && (entry.Type != "symlink" || entry.Target != "dir") {
+			t.Errorf("Unexpected entry: %+v", entry)
+		}
+	}
+	text, err = resultText(CallListDirectory(map[string]interface{}{"path": root, "follow_symlinks": true}))
+	if err != nil || !strings.Contains(text, "inside [directory via symlink -> dir]") || !strings.Contains(text, "escape [symlink -> ") {
+		t.Errorf("Expected only the inside link to be followed, got %q, %v", text, err)
+	}
+
+	// get_file_info follows on request, but never out of the roots
+	result, err := resultText(CallGetFileInfo(map[string]interface{}{"path": filepath.Join(root, "file-link"), "follow_symlinks": true}))
+	if err != nil || !strings.Contains(result, "type: file") || !strings.Contains(result, "size: 3") {
+		t.Errorf("Expected the target to be described, got %q, %v", result, err)
+	}
+	if _, err := CallGetFileInfo(map[string]interface{}{"path": filepath.Join(root, "escape"), "follow_symlinks": true}); err == nil {
+		t.Error("Expected following an escaping link to be denied")
+	}
+	if result, err := resultText(CallGetFileInfo(map[string]interface{}{"path": filepath.Join(root, "escape")})); err != nil || !strings.Contains(result, "target_status: outside") {
+		t.Errorf("Expected the escaping link to be flagged, got %q, %v", result, err)
+	}
+
+	// read_file can refuse links
+	if _, err := CallReadFile(map[string]interface{}{"path": filepath.Join(root, "file-link"), "follow_symlinks": false}); err == nil {
+		t.Error("Expected reading through a link to be refused")
+	}
+	if content, err := resultText(CallReadFile(map[string]interface{}{"path": filepath.Join(root, "file-link")})); err != nil || content != "abc" {
+		t.Errorf("Expected reading through a link by default, got %q, %v", content, err)
+	}
+}
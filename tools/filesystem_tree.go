@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Defaults of the directory_tree tool
+const (
+	defaultTreeDepth      = 3
+	defaultTreeMaxEntries = 1000
+)
+
+// treeNode is one entry of a directory_tree result
+type treeNode struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Size is the size of a file, or the total size of the files below a
+	// fully listed directory
+	Size int64 `json:"size"`
+	// Entries is the number of entries directly inside a directory
+	Entries  int         `json:"entries,omitempty"`
+	Children []*treeNode `json:"children,omitempty"`
+	// Truncated marks a directory whose contents were not (fully) listed
+	// because of the depth or entry limit
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// GetDirectoryTreeTool returns the directory_tree tool definition
+func GetDirectoryTreeTool() FileSystemTool {
+	return FileSystemTool{
+		Name:        "directory_tree",
+		Description: "Get the tree of files and directories below a directory, with sizes and entry counts, as indented text or nested JSON",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "The directory to describe",
+				},
+				"max_depth": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Levels of directories to descend (default: %d)", defaultTreeDepth),
+				},
+				"max_entries": map[string]interface{}{
+					"type":        "integer",
+					"description": fmt.Sprintf("Stop listing after this many entries in total (default: %d)", defaultTreeMaxEntries),
+				},
+				"format": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"text", "json"},
+					"description": "Indented text (default) or a nested JSON tree",
+				},
+			},
+			"required": []string{"path"},
+		},
+	}
+}
+
+// CallDirectoryTree describes the tree below a directory
+func CallDirectoryTree(arguments map[string]interface{}) (*ToolResult, error) {
+	args := struct {
+		Path       string `arg:"path,required"`
+		MaxDepth   int    `arg:"max_depth"`
+		MaxEntries int    `arg:"max_entries"`
+		Format     string `arg:"format"`
+	}{MaxDepth: defaultTreeDepth, MaxEntries: defaultTreeMaxEntries, Format: "text"}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	path, maxDepth, maxEntries, format := args.Path, args.MaxDepth, args.MaxEntries, args.Format
+	if maxDepth <= 0 || maxEntries <= 0 {
+		return nil, fmt.Errorf("max_depth and max_entries must be positive")
+	}
+	if format != "text" && format != "json" {
+		return nil, fmt.Errorf("format argument must be text or json")
+	}
+
+	// Resolve absolute path within the allowed paths
+	absPath, err := resolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", path)
+	}
+
+	budget := maxEntries
+	root := buildTree(absPath, filepath.Base(absPath), info, maxDepth, &budget)
+
+	if format == "json" {
+		data, err := json.MarshalIndent(root, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode tree: %v", err)
+		}
+		return TextResult(string(data)), nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s/ (%d entries, %s)\n", absPath, root.Entries, treeSize(root))
+	renderTree(&b, root.Children, "")
+	return TextResult(b.String()), nil
+}
+
+// buildTree describes the entry at path, descending depth levels into
+// directories while budget entries remain. Symlinks are not followed.
+func buildTree(path, name string, info os.FileInfo, depth int, budget *int) *treeNode {
+	node := &treeNode{Name: name, Type: entryType(info), Size: info.Size()}
+	if !info.IsDir() {
+		return node
+	}
+
+	node.Size = 0
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		node.Truncated = true
+		return node
+	}
+	node.Entries = len(entries)
+	if depth == 0 {
+		node.Truncated = len(entries) > 0
+		return node
+	}
+
+	for _, entry := range entries {
+		if *budget == 0 {
+			node.Truncated = true
+			break
+		}
+		*budget--
+		entryInfo, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		child := buildTree(filepath.Join(path, entry.Name()), entry.Name(), entryInfo, depth-1, budget)
+		if child.Truncated {
+			node.Truncated = true
+		}
+		node.Size += child.Size
+		node.Children = append(node.Children, child)
+	}
+	return node
+}
+
+// renderTree renders nodes as an indented tree
+func renderTree(b *strings.Builder, nodes []*treeNode, indent string) {
+	for i, node := range nodes {
+		branch, next := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, next = "└── ", "    "
+		}
+		if node.Type == "directory" {
+			fmt.Fprintf(b, "%s%s%s/ (%d entries, %s)\n", indent, branch, node.Name, node.Entries, treeSize(node))
+			renderTree(b, node.Children, indent+next)
+			continue
+		}
+		fmt.Fprintf(b, "%s%s%s (%s, %d bytes)\n", indent, branch, node.Name, node.Type, node.Size)
+	}
+}
+
+// treeSize describes the size of a directory node, which is a lower bound
+// when its contents were truncated
+func treeSize(node *treeNode) string {
+	if node.Truncated {
+		return fmt.Sprintf("%d+ bytes", node.Size)
+	}
+	return fmt.Sprintf("%d bytes", node.Size)
+}
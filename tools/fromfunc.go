@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FromFunc returns a tool whose input schema is derived from the argument
+// struct of fn and whose calls decode their arguments into it with
+// DecodeArgs before calling fn:
+//
+//	tool := FromFunc("greet", "Greet someone", func(ctx context.Context, args struct {
+//		Name  string `arg:"name,required" desc:"Who to greet"`
+//		Style string `arg:"style" enum:"formal,casual"`
+//	}) (string, error) {
+//		return "Hello, " + args.Name, nil
+//	})
+//
+// Fields are named by their arg tag as for DecodeArgs; an optional desc tag
+// describes the argument and an enum tag lists the allowed values of a
+// string, separated by commas. A string returned by fn becomes a text
+// result. FromFunc panics if the arguments are not a struct, as a
+// programming error.
+func FromFunc[A any, R string | *ToolResult](name, description string, fn func(ctx context.Context, args A) (R, error)) Tool {
+	t := reflect.TypeOf((*A)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("FromFunc %s: arguments must be a struct, got %s", name, t))
+	}
+	definition := Definition{
+		Name:        name,
+		Description: description,
+		InputSchema: typeSchema(t),
+	}
+	return NewTool(definition, func(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+		var args A
+		if err := DecodeArgs(arguments, &args); err != nil {
+			return nil, err
+		}
+		result, err := fn(ctx, args)
+		if err != nil {
+			return nil, err
+		}
+		switch result := any(result).(type) {
+		case string:
+			return TextResult(result), nil
+		case *ToolResult:
+			return result, nil
+		}
+		return nil, nil // Unreachable given the constraint on R
+	})
+}
+
+// typeSchema returns the JSON Schema of the values DecodeArgs decodes into a
+// field of type t
+func typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]interface{}{}
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag, ok := field.Tag.Lookup("arg")
+			if !ok || tag == "-" || !field.IsExported() {
+				continue
+			}
+			name, options, _ := strings.Cut(tag, ",")
+			property := typeSchema(field.Type)
+			if desc := field.Tag.Get("desc"); desc != "" {
+				property["description"] = desc
+			}
+			if enum := field.Tag.Get("enum"); enum != "" {
+				property["enum"] = strings.Split(enum, ",")
+			}
+			properties[name] = property
+			if options == "required" {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	// Interfaces take any value
+	return map[string]interface{}{}
+}
@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFromFunc(t *testing.T) {
+	type point struct {
+		X float64 `arg:"x,required"`
+		Y float64 `arg:"y"`
+	}
+	tool := FromFunc("greet", "Greet someone", func(_ context.Context, args struct {
+		Name   string            `arg:"name,required" desc:"Who to greet"`
+		Style  string            `arg:"style" enum:"formal,casual"`
+		Times  *uint             `arg:"times"`
+		Tags   []string          `arg:"tags"`
+		Labels map[string]string `arg:"labels"`
+		At     point             `arg:"at"`
+		Extra  interface{}       `arg:"extra"`
+		hidden string
+	}) (string, error) {
+		if args.Style == "formal" {
+			return "Good day, " + args.Name, nil
+		}
+		return "Hi " + args.Name, nil
+	})
+
+	schema, _ := json.Marshal(tool.Definition().InputSchema)
+	want := `{"properties":{"at":{"properties":{"x":{"type":"number"},"y":{"type":"number"}},"required":["x"],"type":"object"},` +
+		`"extra":{},"labels":{"additionalProperties":{"type":"string"},"type":"object"},` +
+		`"name":{"description":"Who to greet","type":"string"},"style":{"enum":["formal","casual"],"type":"string"},` +
+		`"tags":{"items":{"type":"string"},"type":"array"},"times":{"minimum":0,"type":"integer"}},"required":["name"],"type":"object"}`
+	if string(schema) != want {
+		t.Errorf("Unexpected schema:\n%s\nexpected:\n%s", schema, want)
+	}
+
+	r := NewRegistry()
+	r.MustRegister(tool)
+	result, err := r.Call(context.Background(), "greet", map[string]interface{}{"name": "Ann", "style": "formal"})
+	if err != nil || result.Content[0].Text != "Good day, Ann" {
+		t.Errorf("Unexpected result %+v, %v", result, err)
+	}
+	// Arguments are validated against the derived schema
+	if _, err := r.Call(context.Background(), "greet", map[string]interface{}{"style": "rude"}); err == nil || !strings.Contains(err.Error(), "name: is required; style: must be one of formal, casual") {
+		t.Errorf("Expected a validation error, got %v", err)
+	}
+
+	structured := FromFunc("origin", "", func(context.Context, struct{}) (*ToolResult, error) {
+		return StructuredResult("origin", point{}), nil
+	})
+	if result, err := structured.Execute(context.Background(), nil); err != nil || result.StructuredContent == nil {
+		t.Errorf("Expected the result to be returned as is, got %+v, %v", result, err)
+	}
+}
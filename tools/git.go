@@ -0,0 +1,429 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults for the git tools when the configuration leaves them unset
+const (
+	DefaultGitTimeout   = 30 * time.Second
+	DefaultGitMaxOutput = 1 << 20
+)
+
+// GitTool represents a git tool definition
+type GitTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// repoPathProperty is the schema of the repo_path argument of every git tool
+var repoPathProperty = map[string]interface{}{
+	"type":        "string",
+	"description": "Path of the repository, inside a configured repository root (optional when a single root is configured)",
+}
+
+// GetGitTools returns the definitions of the git tools
+func GetGitTools() []GitTool {
+	return []GitTool{
+		{
+			Name:        "git_status",
+			Description: "Show the working tree status of a git repository: the current branch and changed, staged and untracked files",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"repo_path": repoPathProperty,
+				},
+			},
+		},
+		{
+			Name:        "git_log",
+			Description: "Show the commit history of a git repository",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"repo_path": repoPathProperty,
+					"max_count": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of commits (default: 10)",
+						"default":     10,
+						"minimum":     1,
+					},
+					"revision": map[string]interface{}{
+						"type":        "string",
+						"description": "Branch, tag or revision range to list (default: HEAD)",
+					},
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list commits touching this path",
+					},
+				},
+			},
+		},
+		{
+			Name:        "git_diff",
+			Description: "Show changes of a git repository as a unified diff: unstaged changes by default, staged changes, or the changes since a revision",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"repo_path": repoPathProperty,
+					"staged": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Show staged changes instead of unstaged ones (default: false)",
+					},
+					"target": map[string]interface{}{
+						"type":        "string",
+						"description": "Compare the working tree with this revision, or give a range such as main..feature",
+					},
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Only show changes to this path",
+					},
+				},
+			},
+		},
+		{
+			Name:        "git_show",
+			Description: "Show a commit of a git repository with its message and diff",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"repo_path": repoPathProperty,
+					"revision": map[string]interface{}{
+						"type":        "string",
+						"description": "The commit to show (default: HEAD)",
+					},
+				},
+			},
+		},
+		{
+			Name:        "git_commit",
+			Description: "Record a commit in a git repository, staging the given files first",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"repo_path": repoPathProperty,
+					"message": map[string]interface{}{
+						"type":        "string",
+						"description": "The commit message",
+					},
+					"files": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Files to stage before committing",
+					},
+					"all": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Stage every change, including new files, before committing (default: false)",
+					},
+				},
+				"required": []string{"message"},
+			},
+		},
+		{
+			Name:        "git_branch",
+			Description: "List the branches of a git repository, or create or check out a branch",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"repo_path": repoPathProperty,
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"list", "create", "checkout"},
+						"description": "list (default), create a branch, or check out an existing one",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "The branch to create or check out",
+					},
+					"start_point": map[string]interface{}{
+						"type":        "string",
+						"description": "Revision a created branch starts from (default: HEAD)",
+					},
+					"checkout": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Check out a created branch (default: false)",
+					},
+				},
+			},
+		},
+	}
+}
+
+// IsGitTool reports whether name is one of the git tools
+func IsGitTool(name string) bool {
+	for _, tool := range GetGitTools() {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GitConfig holds the configuration for the git tools
+type GitConfig struct {
+	// Repositories are the directories the tools may work in, each a
+	// repository or a directory holding repositories
+	Repositories   []string
+	Timeout        time.Duration
+	MaxOutputBytes int64
+}
+
+var gitConfig *GitConfig
+
+// SetGitConfig enables the git tools. The repository roots are resolved to
+// absolute paths without symlinks, and a zero timeout or output limit selects
+// the default.
+func SetGitConfig(repositories []string, timeout time.Duration, maxOutputBytes int64) error {
+	roots := make([]string, 0, len(repositories))
+	for _, repository := range repositories {
+		root, err := filepath.Abs(repository)
+		if err != nil {
+			return err
+		}
+		if root, err = filepath.EvalSymlinks(root); err != nil {
+			return fmt.Errorf("invalid repository root %s: %v", repository, err)
+		}
+		roots = append(roots, root)
+	}
+	if timeout <= 0 {
+		timeout = DefaultGitTimeout
+	}
+	if maxOutputBytes <= 0 {
+		maxOutputBytes = DefaultGitMaxOutput
+	}
+	gitConfig = &GitConfig{Repositories: roots, Timeout: timeout, MaxOutputBytes: maxOutputBytes}
+	return nil
+}
+
+// GetGitConfig returns the current configuration, nil when the tools are
+// disabled
+func GetGitConfig() *GitConfig {
+	return gitConfig
+}
+
+// repository resolves the repo_path argument to a directory inside one of the
+// configured roots
+func (c *GitConfig) repository(path string) (string, error) {
+	if path == "" {
+		if len(c.Repositories) != 1 {
+			return "", fmt.Errorf("repo_path argument is required when several repository roots are configured")
+		}
+		return c.Repositories[0], nil
+	}
+
+	if !filepath.IsAbs(path) && len(c.Repositories) == 1 {
+		path = filepath.Join(c.Repositories[0], path)
+	}
+	resolved, err := filepath.Abs(path)
+	if err == nil {
+		resolved, err = filepath.EvalSymlinks(resolved)
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid repo_path: %v", err)
+	}
+	for _, root := range c.Repositories {
+		if resolved == root || strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("access denied: %s is outside the configured repository roots", path)
+}
+
+// gitArguments are the arguments of the git tools
+type gitArguments struct {
+	RepoPath   string   `arg:"repo_path"`
+	Path       string   `arg:"path"`
+	MaxCount   int      `arg:"max_count"`
+	Revision   string   `arg:"revision"`
+	Staged     bool     `arg:"staged"`
+	Target     string   `arg:"target"`
+	Message    string   `arg:"message"`
+	Files      []string `arg:"files"`
+	All        bool     `arg:"all"`
+	Action     string   `arg:"action"`
+	Name       string   `arg:"name"`
+	StartPoint string   `arg:"start_point"`
+	Checkout   bool     `arg:"checkout"`
+}
+
+// checkRevisions refuses revision arguments git would parse as options
+func (a *gitArguments) checkRevisions() error {
+	for _, revision := range []struct{ name, value string }{
+		{"revision", a.Revision}, {"target", a.Target}, {"name", a.Name}, {"start_point", a.StartPoint},
+	} {
+		if strings.HasPrefix(revision.value, "-") {
+			return fmt.Errorf("%s argument must not start with '-'", revision.name)
+		}
+	}
+	return nil
+}
+
+// runGit runs git in repo and returns its standard output, truncated to the
+// output limit. A failing command returns its standard error.
+func (c *GitConfig) runGit(ctx context.Context, repo string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	// Never page, prompt or colour the output
+	subcommand := args[0]
+	args = append([]string{"-C", repo, "--no-pager", "-c", "color.ui=false", "-c", "core.quotepath=false"}, args...)
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "LC_ALL=C")
+	limitCommand(ctx, cmd)
+	// Repository discovery must not climb out of the roots
+	ceilings := make([]string, len(c.Repositories))
+	for i, root := range c.Repositories {
+		ceilings[i] = filepath.Dir(root)
+	}
+	cmd.Env = append(cmd.Env, "GIT_CEILING_DIRECTORIES="+strings.Join(ceilings, string(filepath.ListSeparator)))
+	stdout := &limitedBuffer{max: c.MaxOutputBytes}
+	var stderr bytes.Buffer
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("git %s timed out after %s", subcommand, c.Timeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s failed: %s", subcommand, message)
+		}
+		return "", fmt.Errorf("git %s failed: %v", subcommand, err)
+	}
+
+	output := string(stdout.buf)
+	if stdout.dropped > 0 {
+		output += fmt.Sprintf("\n[Truncated: %d more bytes of output]", stdout.dropped)
+	}
+	return output, nil
+}
+
+// CallGitTool executes the named git tool
+func CallGitTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+	if gitConfig == nil {
+		return nil, fmt.Errorf("git tools not configured. Set git.enabled and repositories in the config file")
+	}
+	config := gitConfig
+
+	a := gitArguments{MaxCount: 10}
+	if err := DecodeArgs(arguments, &a); err != nil {
+		return nil, err
+	}
+	if err := a.checkRevisions(); err != nil {
+		return nil, err
+	}
+	repo, err := config.repository(a.RepoPath)
+	if err != nil {
+		return nil, err
+	}
+	path := a.Path
+
+	switch name {
+	case "git_status":
+		return textResult(config.runGit(ctx, repo, "status", "--branch", "--short"))
+
+	case "git_log":
+		args := []string{"log", "--max-count=" + strconv.Itoa(a.MaxCount), "--date=iso", "--format=commit %H%nAuthor: %an <%ae>%nDate:   %ad%n%n%w(0,4,4)%B"}
+		if a.Revision != "" {
+			args = append(args, a.Revision)
+		}
+		if path != "" {
+			args = append(args, "--", path)
+		}
+		output, err := config.runGit(ctx, repo, args...)
+		if err == nil && output == "" {
+			output = "No commits found"
+		}
+		return textResult(output, err)
+
+	case "git_diff":
+		args := []string{"diff", "--no-ext-diff"}
+		if a.Staged {
+			args = append(args, "--cached")
+		}
+		if a.Target != "" {
+			args = append(args, a.Target)
+		}
+		args = append(args, "--")
+		if path != "" {
+			args = append(args, path)
+		}
+		output, err := config.runGit(ctx, repo, args...)
+		if err == nil && output == "" {
+			output = "No changes"
+		}
+		return textResult(output, err)
+
+	case "git_show":
+		revision := a.Revision
+		if revision == "" {
+			revision = "HEAD"
+		}
+		return textResult(config.runGit(ctx, repo, "show", "--no-ext-diff", "--date=iso", revision, "--"))
+
+	case "git_commit":
+		message, files := a.Message, a.Files
+		if strings.TrimSpace(message) == "" {
+			return nil, fmt.Errorf("message argument is required and must be a non-empty string")
+		}
+		if a.All {
+			if _, err := config.runGit(ctx, repo, "add", "--all"); err != nil {
+				return nil, err
+			}
+		}
+		if len(files) > 0 {
+			if _, err := config.runGit(ctx, repo, append([]string{"add", "--"}, files...)...); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := config.runGit(ctx, repo, "commit", "--message", message); err != nil {
+			return nil, err
+		}
+		return textResult(config.runGit(ctx, repo, "log", "--max-count=1", "--stat", "--format=Committed %H%n%s%n"))
+
+	case "git_branch":
+		action, branch := a.Action, a.Name
+		switch action {
+		case "", "list":
+			return textResult(config.runGit(ctx, repo, "branch", "--list", "--verbose", "--all"))
+		case "create", "checkout":
+			if branch == "" {
+				return nil, fmt.Errorf("name argument is required to %s a branch", action)
+			}
+		default:
+			return nil, fmt.Errorf("action must be list, create or checkout")
+		}
+
+		if action == "create" {
+			startPoint, checkout := a.StartPoint, a.Checkout
+			args := []string{"branch", branch}
+			if checkout {
+				args = []string{"switch", "--create", branch}
+			}
+			if startPoint != "" {
+				args = append(args, startPoint)
+			}
+			if _, err := config.runGit(ctx, repo, args...); err != nil {
+				return nil, err
+			}
+			if checkout {
+				return TextResult(fmt.Sprintf("Created and checked out branch %s", branch)), nil
+			}
+			return TextResult(fmt.Sprintf("Created branch %s", branch)), nil
+		}
+		if _, err := config.runGit(ctx, repo, "switch", branch); err != nil {
+			return nil, err
+		}
+		return TextResult(fmt.Sprintf("Checked out branch %s", branch)), nil
+	}
+	return nil, fmt.Errorf("unknown git tool %s", name)
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitTools(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	root := t.TempDir()
+	repo := filepath.Join(root, "app")
+	if err := os.Mkdir(repo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := exec.Command("git", "-C", repo, "init", "--initial-branch=main").CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v: %s", err, out)
+	}
+	if err := SetGitConfig([]string{root}, 0, 0); err != nil {
+		t.Fatalf("SetGitConfig failed: %v", err)
+	}
+	defer func() { gitConfig = nil }()
+
+	call := func(name string, arguments map[string]interface{}) string {
+		t.Helper()
+		arguments["repo_path"] = "app"
+		result, err := resultText(CallGitTool(context.Background(), name, arguments))
+		if err != nil {
+			t.Fatalf("%s failed: %v", name, err)
+		}
+		return result
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if status := call("git_status", map[string]interface{}{}); !strings.Contains(status, "?? main.go") {
+		t.Errorf("Expected an untracked file, got:\n%s", status)
+	}
+	if result := call("git_commit", map[string]interface{}{"message": "Add main", "files": []interface{}{"main.go"}}); !strings.Contains(result, "Add main") || !strings.Contains(result, "main.go") {
+		t.Errorf("Unexpected commit result:\n%s", result)
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if diff := call("git_diff", map[string]interface{}{}); !strings.Contains(diff, "+func main() {}") {
+		t.Errorf("Expected the change in the diff, got:\n%s", diff)
+	}
+	if diff := call("git_diff", map[string]interface{}{"staged": true}); diff != "No changes" {
+		t.Errorf("Expected no staged changes, got:\n%s", diff)
+	}
+
+	call("git_branch", map[string]interface{}{"action": "create", "name": "feature", "checkout": true})
+	call("git_commit", map[string]interface{}{"message": "Add func main", "all": true})
+	if log := call("git_log", map[string]interface{}{"max_count": float64(1)}); !strings.Contains(log, "Add func main") || strings.Contains(log, "Add main\n") {
+		t.Errorf("Unexpected log:\n%s", log)
+	}
+	if show := call("git_show", map[string]interface{}{"revision": "main"}); !strings.Contains(show, "+package main") {
+		t.Errorf("Unexpected show:\n%s", show)
+	}
+	if branches := call("git_branch", map[string]interface{}{}); !strings.Contains(branches, "* feature") {
+		t.Errorf("Expected feature to be checked out, got:\n%s", branches)
+	}
+
+	// Repositories outside the roots and option-like revisions are rejected
+	if _, err := CallGitTool(context.Background(), "git_status", map[string]interface{}{"repo_path": t.TempDir()}); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("Expected a repository outside the roots to be denied, got %v", err)
+	}
+	if _, err := CallGitTool(context.Background(), "git_show", map[string]interface{}{"repo_path": "app", "revision": "--output=/tmp/x"}); err == nil {
+		t.Error("Expected an option-like revision to be rejected")
+	}
+}
@@ -0,0 +1,613 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Defaults for the GitHub tools when the configuration leaves them unset
+const (
+	DefaultGitHubAPIURL  = "https://api.github.com"
+	DefaultGitHubTimeout = 30 * time.Second
+)
+
+// GitHubTool represents a GitHub tool definition
+type GitHubTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// githubRepoProperty is the schema of the repo argument of the GitHub tools
+var githubRepoProperty = map[string]interface{}{
+	"type":        "string",
+	"description": "Repository as \"owner/name\"",
+}
+
+// githubMaxResultsProperty is the schema of the max_results argument of the
+// listing GitHub tools
+var githubMaxResultsProperty = map[string]interface{}{
+	"type":        "integer",
+	"description": "Number of results to return (1-100, default: 20)",
+	"default":     20,
+	"minimum":     1,
+	"maximum":     100,
+}
+
+// githubRepoPattern matches "owner/name" repository names
+var githubRepoPattern = regexp.MustCompile(`^[A-Za-z0-9-]+/[A-Za-z0-9._-]+$`)
+
+// githubWriteTools are the GitHub tools that change repositories
+var githubWriteTools = map[string]bool{
+	"github_create_issue":   true,
+	"github_create_comment": true,
+}
+
+// GetGitHubTools returns the definitions of the GitHub tools
+func GetGitHubTools() []GitHubTool {
+	return []GitHubTool{
+		{
+			Name:        "github_search_repositories",
+			Description: "Search GitHub repositories, e.g. \"language:go stars:>100 mcp\"",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "GitHub search query, with qualifiers such as language:, topic: or stars:",
+					},
+					"max_results": githubMaxResultsProperty,
+				},
+				"required": []string{"query"},
+			},
+		},
+		{
+			Name:        "github_search_issues",
+			Description: "Search issues and pull requests, e.g. \"is:open label:bug crash\"",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "GitHub search query, with qualifiers such as repo:, is:issue, is:pr, is:open, label: or author:",
+					},
+					"max_results": githubMaxResultsProperty,
+				},
+				"required": []string{"query"},
+			},
+		},
+		{
+			Name:        "github_get_file",
+			Description: "Read a file of a repository, or list a directory",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"repo": githubRepoProperty,
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Path in the repository (default: the root directory)",
+					},
+					"ref": map[string]interface{}{
+						"type":        "string",
+						"description": "Branch, tag or commit (default: the default branch)",
+					},
+				},
+				"required": []string{"repo"},
+			},
+		},
+		{
+			Name:        "github_list_pull_requests",
+			Description: "List the pull requests of a repository, most recently updated first",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"repo": githubRepoProperty,
+					"state": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"open", "closed", "all"},
+						"description": "State of the pull requests (default: open)",
+					},
+					"max_results": githubMaxResultsProperty,
+				},
+				"required": []string{"repo"},
+			},
+		},
+		{
+			Name:        "github_create_issue",
+			Description: "Open an issue in a repository",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"repo": githubRepoProperty,
+					"title": map[string]interface{}{
+						"type":        "string",
+						"description": "Title of the issue",
+					},
+					"body": map[string]interface{}{
+						"type":        "string",
+						"description": "Description of the issue (Markdown)",
+					},
+					"labels": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Labels to add",
+					},
+				},
+				"required": []string{"repo", "title"},
+			},
+		},
+		{
+			Name:        "github_create_comment",
+			Description: "Comment on an issue or pull request",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"repo": githubRepoProperty,
+					"number": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of the issue or pull request",
+					},
+					"body": map[string]interface{}{
+						"type":        "string",
+						"description": "Text of the comment (Markdown)",
+					},
+				},
+				"required": []string{"repo", "number", "body"},
+			},
+		},
+	}
+}
+
+// IsGitHubTool reports whether name is one of the GitHub tools
+func IsGitHubTool(name string) bool {
+	for _, tool := range GetGitHubTools() {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GitHubConfig holds the configuration for the GitHub tools
+type GitHubConfig struct {
+	APIURL string
+	Token  string // Empty = unauthenticated, public data only
+	// Repositories the tools may use, as "owner/name" or "owner/*"; empty
+	// allows every repository the token can access
+	Repositories []string
+	ReadOnly     bool // Hides the tools that create issues and comments
+	Timeout      time.Duration
+}
+
+var githubConfig *GitHubConfig
+
+// SetGitHubConfig enables the GitHub tools. An empty API URL or a zero
+// timeout selects the default.
+func SetGitHubConfig(apiURL, token string, repositories []string, readOnly bool, timeout time.Duration) {
+	if apiURL == "" {
+		apiURL = DefaultGitHubAPIURL
+	}
+	if timeout <= 0 {
+		timeout = DefaultGitHubTimeout
+	}
+	githubConfig = &GitHubConfig{
+		APIURL:       strings.TrimRight(apiURL, "/"),
+		Token:        token,
+		Repositories: repositories,
+		ReadOnly:     readOnly,
+		Timeout:      timeout,
+	}
+}
+
+// GetGitHubConfig returns the current configuration, nil when the tools are
+// disabled
+func GetGitHubConfig() *GitHubConfig {
+	return githubConfig
+}
+
+// GitHubToolEnabled reports whether the named GitHub tool is enabled
+func GitHubToolEnabled(name string) bool {
+	config := githubConfig
+	return config != nil && !(config.ReadOnly && githubWriteTools[name])
+}
+
+// repoAllowed reports whether the tools may use the repository
+func (c *GitHubConfig) repoAllowed(repo string) bool {
+	if len(c.Repositories) == 0 {
+		return true
+	}
+	repo = strings.ToLower(repo)
+	for _, allowed := range c.Repositories {
+		allowed = strings.ToLower(allowed)
+		if allowed == repo || strings.HasSuffix(allowed, "/*") && strings.HasPrefix(repo, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// githubArguments are the arguments of the GitHub tools
+type githubArguments struct {
+	Repo       string   `arg:"repo"`
+	Query      string   `arg:"query"`
+	MaxResults int      `arg:"max_results"`
+	Path       string   `arg:"path"`
+	Ref        string   `arg:"ref"`
+	State      string   `arg:"state"`
+	Title      string   `arg:"title"`
+	Body       string   `arg:"body"`
+	Labels     []string `arg:"labels"`
+	Number     int      `arg:"number"`
+}
+
+// repo returns the repository named by the repo argument
+func (c *GitHubConfig) repo(repo string) (string, error) {
+	if !githubRepoPattern.MatchString(repo) || strings.HasSuffix(repo, "/.") || strings.HasSuffix(repo, "/..") {
+		return "", fmt.Errorf("repo argument is required and must be \"owner/name\"")
+	}
+	if !c.repoAllowed(repo) {
+		return "", fmt.Errorf("repository %s is not allowed", repo)
+	}
+	return repo, nil
+}
+
+// CallGitHubTool executes the named GitHub tool
+func CallGitHubTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+	config := githubConfig
+	if config == nil {
+		return nil, fmt.Errorf("GitHub tools not configured. Set github.enabled in the config file")
+	}
+	if !GitHubToolEnabled(name) {
+		return nil, fmt.Errorf("%s is disabled because github.read_only is set", name)
+	}
+	args := &githubArguments{MaxResults: 20}
+	if err := DecodeArgs(arguments, args); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	switch name {
+	case "github_search_repositories":
+		return textResult(config.searchRepositories(ctx, args))
+	case "github_search_issues":
+		return textResult(config.searchIssues(ctx, args))
+	}
+
+	repo, err := config.repo(args.Repo)
+	if err != nil {
+		return nil, err
+	}
+	switch name {
+	case "github_get_file":
+		return textResult(config.getFile(ctx, repo, args))
+	case "github_list_pull_requests":
+		return textResult(config.listPullRequests(ctx, repo, args))
+	case "github_create_issue":
+		return textResult(config.createIssue(ctx, repo, args))
+	case "github_create_comment":
+		return textResult(config.createComment(ctx, repo, args))
+	default:
+		return nil, fmt.Errorf("unknown GitHub tool: %s", name)
+	}
+}
+
+// searchQuery returns the query and result count of a search call. With an
+// allowlist the query is narrowed to the allowed repositories.
+func (c *GitHubConfig) searchQuery(args *githubArguments) (url.Values, error) {
+	query, maxResults := args.Query, args.MaxResults
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("query argument is required and must be a non-empty string")
+	}
+	if maxResults < 1 || maxResults > 100 {
+		maxResults = 20
+	}
+	for _, allowed := range c.Repositories {
+		if owner, ok := strings.CutSuffix(allowed, "/*"); ok {
+			query += " user:" + owner
+		} else {
+			query += " repo:" + allowed
+		}
+	}
+	return url.Values{"q": {query}, "per_page": {strconv.Itoa(maxResults)}}, nil
+}
+
+// searchRepositories searches repositories
+func (c *GitHubConfig) searchRepositories(ctx context.Context, args *githubArguments) (string, error) {
+	query, err := c.searchQuery(args)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		TotalCount int `json:"total_count"`
+		Items      []struct {
+			FullName    string `json:"full_name"`
+			HTMLURL     string `json:"html_url"`
+			Description string `json:"description"`
+			Language    string `json:"language"`
+			Stars       int    `json:"stargazers_count"`
+		} `json:"items"`
+	}
+	if err := c.do(ctx, "GET", "/search/repositories", query, nil, &resp); err != nil {
+		return "", err
+	}
+	var results []SearchResult
+	for _, item := range resp.Items {
+		if !c.repoAllowed(item.FullName) {
+			continue
+		}
+		snippet := fmt.Sprintf("★ %d", item.Stars)
+		if item.Language != "" {
+			snippet += " · " + item.Language
+		}
+		if item.Description != "" {
+			snippet += " · " + item.Description
+		}
+		results = append(results, SearchResult{Title: item.FullName, Link: item.HTMLURL, Snippet: snippet})
+	}
+	return formatSearchResults(strconv.Itoa(resp.TotalCount), results), nil
+}
+
+// searchIssues searches issues and pull requests
+func (c *GitHubConfig) searchIssues(ctx context.Context, args *githubArguments) (string, error) {
+	query, err := c.searchQuery(args)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		TotalCount int `json:"total_count"`
+		Items      []struct {
+			Number        int       `json:"number"`
+			Title         string    `json:"title"`
+			HTMLURL       string    `json:"html_url"`
+			State         string    `json:"state"`
+			RepositoryURL string    `json:"repository_url"`
+			PullRequest   *struct{} `json:"pull_request"`
+			User          struct {
+				Login string `json:"login"`
+			} `json:"user"`
+			UpdatedAt string `json:"updated_at"`
+		} `json:"items"`
+	}
+	if err := c.do(ctx, "GET", "/search/issues", query, nil, &resp); err != nil {
+		return "", err
+	}
+	var results []SearchResult
+	for _, item := range resp.Items {
+		repo := item.RepositoryURL[strings.LastIndex(item.RepositoryURL, "/repos/")+len("/repos/"):]
+		if !c.repoAllowed(repo) {
+			continue
+		}
+		kind := "Issue"
+		if item.PullRequest != nil {
+			kind = "PR"
+		}
+		results = append(results, SearchResult{
+			Title:   fmt.Sprintf("%s#%d %s", repo, item.Number, item.Title),
+			Link:    item.HTMLURL,
+			Snippet: fmt.Sprintf("%s, %s, by %s, updated %s", kind, item.State, item.User.Login, item.UpdatedAt),
+		})
+	}
+	return formatSearchResults(strconv.Itoa(resp.TotalCount), results), nil
+}
+
+// getFile reads a file or lists a directory
+func (c *GitHubConfig) getFile(ctx context.Context, repo string, args *githubArguments) (string, error) {
+	path := strings.Trim(args.Path, "/")
+	query := url.Values{}
+	if args.Ref != "" {
+		query.Set("ref", args.Ref)
+	}
+	var escaped []string
+	if path != "" {
+		for _, segment := range strings.Split(path, "/") {
+			escaped = append(escaped, url.PathEscape(segment))
+		}
+	}
+
+	var raw json.RawMessage
+	if err := c.do(ctx, "GET", "/repos/"+repo+"/contents/"+strings.Join(escaped, "/"), query, nil, &raw); err != nil {
+		return "", err
+	}
+	// Directories are returned as arrays of entries
+	if len(raw) > 0 && raw[0] == '[' {
+		var entries []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+			Size int64  `json:"size"`
+		}
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			return "", fmt.Errorf("failed to parse response: %w", err)
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s/%s\n\n", repo, path)
+		for _, entry := range entries {
+			if entry.Type == "dir" {
+				fmt.Fprintf(&b, "[DIR]  %s\n", entry.Name)
+			} else {
+				fmt.Fprintf(&b, "[FILE] %s (%d bytes)\n", entry.Name, entry.Size)
+			}
+		}
+		return b.String(), nil
+	}
+
+	var file struct {
+		Type     string `json:"type"`
+		Size     int64  `json:"size"`
+		Encoding string `json:"encoding"`
+		Content  string `json:"content"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	if file.Type != "file" {
+		return "", fmt.Errorf("%s is a %s, not a file", path, file.Type)
+	}
+	if file.Encoding != "base64" {
+		return "", fmt.Errorf("%s is too large to read through the API (%d bytes)", path, file.Size)
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(file.Content, "\n", ""))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode file: %w", err)
+	}
+	if !utf8.Valid(data) {
+		return fmt.Sprintf("%s/%s (%d bytes, base64 encoded)\n\n%s", repo, path, len(data), base64.StdEncoding.EncodeToString(data)), nil
+	}
+	return fmt.Sprintf("%s/%s (%d bytes)\n\n%s", repo, path, len(data), data), nil
+}
+
+// listPullRequests lists the pull requests of a repository
+func (c *GitHubConfig) listPullRequests(ctx context.Context, repo string, args *githubArguments) (string, error) {
+	state, maxResults := args.State, args.MaxResults
+	if state == "" {
+		state = "open"
+	} else if state != "open" && state != "closed" && state != "all" {
+		return "", fmt.Errorf("unsupported state %q, use open, closed or all", state)
+	}
+	if maxResults < 1 || maxResults > 100 {
+		maxResults = 20
+	}
+	query := url.Values{"state": {state}, "sort": {"updated"}, "direction": {"desc"}, "per_page": {strconv.Itoa(maxResults)}}
+
+	var pulls []struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+		State   string `json:"state"`
+		Draft   bool   `json:"draft"`
+		User    struct {
+			Login string `json:"login"`
+		} `json:"user"`
+		Head struct {
+			Ref string `json:"ref"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+		MergedAt  string `json:"merged_at"`
+		UpdatedAt string `json:"updated_at"`
+	}
+	if err := c.do(ctx, "GET", "/repos/"+repo+"/pulls", query, nil, &pulls); err != nil {
+		return "", err
+	}
+	if len(pulls) == 0 {
+		return fmt.Sprintf("No %s pull requests in %s", state, repo), nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Pull requests of %s (%s)\n\n", repo, state)
+	for _, pull := range pulls {
+		status := pull.State
+		if pull.MergedAt != "" {
+			status = "merged"
+		} else if pull.Draft {
+			status = "draft"
+		}
+		fmt.Fprintf(&b, "#%d %s\n   %s, by %s, %s -> %s, updated %s\n   %s\n\n", pull.Number, pull.Title, status, pull.User.Login, pull.Head.Ref, pull.Base.Ref, pull.UpdatedAt, pull.HTMLURL)
+	}
+	return b.String(), nil
+}
+
+// createIssue opens an issue
+func (c *GitHubConfig) createIssue(ctx context.Context, repo string, args *githubArguments) (string, error) {
+	title := args.Title
+	if strings.TrimSpace(title) == "" {
+		return "", fmt.Errorf("title argument is required and must be a non-empty string")
+	}
+	request := map[string]interface{}{"title": title}
+	if args.Body != "" {
+		request["body"] = args.Body
+	}
+	if len(args.Labels) > 0 {
+		request["labels"] = args.Labels
+	}
+	var issue struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := c.do(ctx, "POST", "/repos/"+repo+"/issues", nil, request, &issue); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Created issue %s#%d: %s", repo, issue.Number, issue.HTMLURL), nil
+}
+
+// createComment comments on an issue or pull request
+func (c *GitHubConfig) createComment(ctx context.Context, repo string, args *githubArguments) (string, error) {
+	number, body := args.Number, args.Body
+	if number < 1 {
+		return "", fmt.Errorf("number argument is required and must be a positive integer")
+	}
+	if strings.TrimSpace(body) == "" {
+		return "", fmt.Errorf("body argument is required and must be a non-empty string")
+	}
+	var comment struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := c.do(ctx, "POST", fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number), nil, map[string]interface{}{"body": body}, &comment); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Commented on %s#%d: %s", repo, number, comment.HTMLURL), nil
+}
+
+// do sends a REST API request, with a JSON body when request is set, and
+// decodes the response into v. Responses other than 2xx are returned as
+// errors.
+func (c *GitHubConfig) do(ctx context.Context, method, path string, query url.Values, request interface{}, v interface{}) error {
+	u := c.APIURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var body io.Reader
+	if request != nil {
+		data, err := json.Marshal(request)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("GitHub request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("GitHub returned status %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("GitHub returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCallGitHubTool(t *testing.T) {
+	var searchQuery string
+	var created map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ghp_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"Bad credentials"}`))
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /search/issues":
+			searchQuery = r.URL.Query().Get("q")
+			w.Write([]byte(`{"total_count":2,"items":[` +
+				`{"number":7,"title":"Crash on start","html_url":"https://github.com/acme/api/issues/7","state":"open","repository_url":"https://api.github.com/repos/acme/api","user":{"login":"ada"},"updated_at":"2024-05-01T10:00:00Z"},` +
+				`{"number":3,"title":"Leak","html_url":"https://github.com/other/x/pull/3","state":"open","repository_url":"https://api.github.com/repos/other/x","pull_request":{},"user":{"login":"eve"}}]}`))
+		case "GET /repos/acme/api/contents/docs/READ ME.md":
+			if r.URL.Query().Get("ref") != "v1" {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"message":"Not Found"}`))
+				return
+			}
+			content := base64.StdEncoding.EncodeToString([]byte("# API\n"))
+			w.Write([]byte(`{"type":"file","size":6,"encoding":"base64","content":"` + content + `"}`))
+		case "GET /repos/acme/api/contents/":
+			w.Write([]byte(`[{"name":"docs","type":"dir"},{"name":"go.mod","type":"file","size":42}]`))
+		case "GET /repos/acme/api/pulls":
+			w.Write([]byte(`[{"number":12,"title":"Add retries","html_url":"https://github.com/acme/api/pull/12","state":"open","draft":true,` +
+				`"user":{"login":"ada"},"head":{"ref":"retries"},"base":{"ref":"main"},"updated_at":"2024-05-02T08:00:00Z"}]`))
+		case "POST /repos/acme/api/issues":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"number":13,"html_url":"https://github.com/acme/api/issues/13"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found"}`))
+		}
+	}))
+	defer server.Close()
+
+	SetGitHubConfig(server.URL, "ghp_test", []string{"acme/*"}, false, 0)
+	defer func() { githubConfig = nil }()
+	ctx := context.Background()
+
+	result, err := resultText(CallGitHubTool(ctx, "github_search_issues", map[string]interface{}{"query": "is:open crash"}))
+	if err != nil || !strings.Contains(result, "acme/api#7 Crash on start") || strings.Contains(result, "other/x") {
+		t.Errorf("github_search_issues = %q, %v", result, err)
+	}
+	if searchQuery != "is:open crash user:acme" {
+		t.Errorf("Expected the search to be narrowed to the allowlist, got %q", searchQuery)
+	}
+
+	result, err = resultText(CallGitHubTool(ctx, "github_get_file", map[string]interface{}{"repo": "acme/api", "path": "/docs/READ ME.md", "ref": "v1"}))
+	if err != nil || result != "acme/api/docs/READ ME.md (6 bytes)\n\n# API\n" {
+		t.Errorf("github_get_file = %q, %v", result, err)
+	}
+	result, err = resultText(CallGitHubTool(ctx, "github_get_file", map[string]interface{}{"repo": "acme/api"}))
+	if err != nil || !strings.Contains(result, "[DIR]  docs\n[FILE] go.mod (42 bytes)") {
+		t.Errorf("github_get_file of the root = %q, %v", result, err)
+	}
+	result, err = resultText(CallGitHubTool(ctx, "github_list_pull_requests", map[string]interface{}{"repo": "acme/api"}))
+	if err != nil || !strings.Contains(result, "#12 Add retries\n   draft, by ada, retries -> main") {
+		t.Errorf("github_list_pull_requests = %q, %v", result, err)
+	}
+	result, err = resultText(CallGitHubTool(ctx, "github_create_issue", map[string]interface{}{"repo": "acme/api", "title": "Flaky test", "labels": []interface{}{"ci"}}))
+	if err != nil || result != "Created issue acme/api#13: https://github.com/acme/api/issues/13" {
+		t.Errorf("github_create_issue = %q, %v", result, err)
+	}
+	if created["title"] != "Flaky test" || created["labels"] == nil {
+		t.Errorf("Unexpected request %v", created)
+	}
+
+	for _, repo := range []string{"other/x", "acme", "acme/..", "acme/api/../x"} {
+		if _, err := CallGitHubTool(ctx, "github_list_pull_requests", map[string]interface{}{"repo": repo}); err == nil {
+			t.Errorf("Expected repo %q to be rejected", repo)
+		}
+	}
+	if _, err := CallGitHubTool(ctx, "github_get_file", map[string]interface{}{"repo": "acme/api", "path": "missing"}); err == nil || !strings.Contains(err.Error(), "Not Found") {
+		t.Errorf("Expected GitHub's error, got %v", err)
+	}
+
+	SetGitHubConfig(server.URL, "ghp_test", nil, true, 0)
+	if GitHubToolEnabled("github_create_comment") || !GitHubToolEnabled("github_get_file") {
+		t.Error("Expected read_only to disable only the write tools")
+	}
+	if _, err := CallGitHubTool(ctx, "github_create_comment", map[string]interface{}{"repo": "acme/api", "number": float64(7), "body": "x"}); err == nil || !strings.Contains(err.Error(), "read_only") {
+		t.Errorf("Expected github_create_comment to be rejected, got %v", err)
+	}
+}
@@ -1,14 +1,19 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 )
 
+// googlePSEURL is the Google Custom Search JSON API endpoint
+var googlePSEURL = "https://www.googleapis.com/customsearch/v1"
+
 // GooglePSETool represents the Google PSE tool definition
 type GooglePSETool struct {
 	Name        string                 `json:"name"`
@@ -48,18 +53,15 @@ func GetGooglePSETool() GooglePSETool {
 }
 
 // GooglePSESearchResult represents a single search result
-type GooglePSESearchResult struct {
-	Title   string `json:"title"`
-	Link    string `json:"link"`
-	Snippet string `json:"snippet"`
-}
+type GooglePSESearchResult = SearchResult
 
 // GooglePSEResponse represents the Google PSE API response
 type GooglePSEResponse struct {
 	Items []struct {
-		Title   string `json:"title"`
-		Link    string `json:"link"`
-		Snippet string `json:"snippet"`
+		Title       string `json:"title"`
+		Link        string `json:"link"`
+		Snippet     string `json:"snippet"`
+		DisplayLink string `json:"displayLink"`
 	} `json:"items"`
 	SearchInformation struct {
 		TotalResults string `json:"totalResults"`
@@ -87,36 +89,39 @@ func GetGooglePSEConfig() *GooglePSEConfig {
 	return googlePSEConfig
 }
 
-// CallGooglePSE executes a Google PSE search
-func CallGooglePSE(arguments map[string]interface{}) (string, error) {
-	if googlePSEConfig == nil {
-		return "", fmt.Errorf("Google PSE not configured. Please set API key and Search Engine ID")
-	}
+// GooglePSEResults is the structured result of google_pse_search
+type GooglePSEResults struct {
+	Query string `json:"query"`
+	// TotalResults is Google's estimate of all matches
+	TotalResults int64          `json:"total_results"`
+	Results      []SearchResult `json:"results"`
+}
 
-	query, ok := arguments["query"].(string)
-	if !ok || query == "" {
-		return "", fmt.Errorf("query argument is required and must be a non-empty string")
+// CallGooglePSE executes a Google PSE search and returns the formatted
+// results, with the same results as structured content
+func CallGooglePSE(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+	if googlePSEConfig == nil {
+		return nil, fmt.Errorf("Google PSE not configured. Please set API key and Search Engine ID")
 	}
 
-	// Get optional parameters
-	num := 10
-	if n, ok := arguments["num"].(float64); ok {
-		num = int(n)
-		if num < 1 || num > 10 {
-			num = 10
-		}
+	args := struct {
+		Query string `arg:"query,required"`
+		Num   int    `arg:"num"`
+		Start int    `arg:"start"`
+	}{Num: 10, Start: 1}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
 	}
+	query := args.Query
 
-	start := 1
-	if s, ok := arguments["start"].(float64); ok {
-		start = int(s)
-		if start < 1 {
-			start = 1
-		}
+	// Clamp the optional parameters
+	num := args.Num
+	if num < 1 || num > 10 {
+		num = 10
 	}
+	start := max(args.Start, 1)
 
 	// Build Google Custom Search API URL
-	baseURL := "https://www.googleapis.com/customsearch/v1"
 	params := url.Values{}
 	params.Set("key", googlePSEConfig.APIKey)
 	params.Set("cx", googlePSEConfig.SearchEngineID)
@@ -124,46 +129,41 @@ func CallGooglePSE(arguments map[string]interface{}) (string, error) {
 	params.Set("num", fmt.Sprintf("%d", num))
 	params.Set("start", fmt.Sprintf("%d", start))
 
-	searchURL := fmt.Sprintf("%s?%s", baseURL, params.Encode())
+	searchURL := fmt.Sprintf("%s?%s", googlePSEURL, params.Encode())
 
 	// Make HTTP request
 	client := &http.Client{
 		Timeout: 10 * time.Second,
 	}
 
-	req, err := http.NewRequest("GET", searchURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute search: %w", err)
+		return nil, fmt.Errorf("failed to execute search: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("Google PSE API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("Google PSE API returned status %d: %s", resp.StatusCode, string(body))
 	}
 
 	// Parse response
 	var apiResp GooglePSEResponse
 	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Format results
-	if len(apiResp.Items) == 0 {
-		return "No results found for your search query.", nil
-	}
-
-	result := fmt.Sprintf("Found %s results:\n\n", apiResp.SearchInformation.TotalResults)
+	results := make([]SearchResult, len(apiResp.Items))
 	for i, item := range apiResp.Items {
-		result += fmt.Sprintf("%d. %s\n", i+1, item.Title)
-		result += fmt.Sprintf("   URL: %s\n", item.Link)
-		result += fmt.Sprintf("   %s\n\n", item.Snippet)
+		results[i] = SearchResult{Title: item.Title, Link: item.Link, Snippet: item.Snippet, DisplayLink: item.DisplayLink}
 	}
-
-	return result, nil
+	total, _ := strconv.ParseInt(apiResp.SearchInformation.TotalResults, 10, 64)
+	structured := &GooglePSEResults{Query: query, TotalResults: total, Results: results}
+	return StructuredResult(formatSearchResults(apiResp.SearchInformation.TotalResults, results), structured), nil
 }
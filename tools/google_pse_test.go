@@ -1,7 +1,11 @@
 package tools
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -76,7 +80,7 @@ func TestCallGooglePSEMissingConfig(t *testing.T) {
 		"query": "test query",
 	}
 
-	_, err := CallGooglePSE(arguments)
+	_, err := CallGooglePSE(context.Background(), arguments)
 	if err == nil {
 		t.Fatal("Expected error when config is not set")
 	}
@@ -91,7 +95,7 @@ func TestCallGooglePSEMissingQuery(t *testing.T) {
 
 	arguments := map[string]interface{}{}
 
-	_, err := CallGooglePSE(arguments)
+	_, err := CallGooglePSE(context.Background(), arguments)
 	if err == nil {
 		t.Fatal("Expected error when query is missing")
 	}
@@ -104,7 +108,7 @@ func TestCallGooglePSEEmptyQuery(t *testing.T) {
 		"query": "",
 	}
 
-	_, err := CallGooglePSE(arguments)
+	_, err := CallGooglePSE(context.Background(), arguments)
 	if err == nil {
 		t.Fatal("Expected error when query is empty")
 	}
@@ -120,7 +124,7 @@ func TestCallGooglePSEWithNum(t *testing.T) {
 
 	// This will fail because we don't have real API credentials in test
 	// But we can test that the function accepts the parameters
-	_, err := CallGooglePSE(arguments)
+	_, err := CallGooglePSE(context.Background(), arguments)
 	// We expect an error from the API call, not from parameter validation
 	if err != nil && err.Error() == "query argument is required and must be a non-empty string" {
 		t.Errorf("Unexpected error: %v", err)
@@ -147,3 +151,40 @@ func TestGooglePSEConfigFromEnv(t *testing.T) {
 	os.Unsetenv("GOOGLE_PSE_API_KEY")
 	os.Unsetenv("GOOGLE_PSE_SEARCH_ENGINE_ID")
 }
+
+func TestCallGooglePSEStructured(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "test-key" || r.URL.Query().Get("q") != "golang" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"searchInformation":{"totalResults":"1230"},"items":[{"title":"The Go Programming Language","link":"https://go.dev/","snippet":"Build simple software","displayLink":"go.dev"}]}`))
+	}))
+	defer server.Close()
+
+	defer func(previous string) { googlePSEURL = previous }(googlePSEURL)
+	googlePSEURL = server.URL
+	SetGooglePSEConfig("test-key", "test-id")
+	defer func() { googlePSEConfig = nil }()
+
+	searched, err := CallGooglePSE(context.Background(), map[string]interface{}{"query": "golang"})
+	if err != nil {
+		t.Fatalf("CallGooglePSE failed: %v", err)
+	}
+	text, results := searched.Content[0].Text, searched.StructuredContent
+	if want := "Found 1230 results:\n\n1. The Go Programming Language\n   URL: https://go.dev/\n   Build simple software\n\n"; text != want {
+		t.Errorf("Unexpected text:\n%q\nwant:\n%q", text, want)
+	}
+	want := &GooglePSEResults{Query: "golang", TotalResults: 1230, Results: []SearchResult{
+		{Title: "The Go Programming Language", Link: "https://go.dev/", Snippet: "Build simple software", DisplayLink: "go.dev"},
+	}}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Unexpected results %+v", results)
+	}
+
+	// The registry checks the results against the output schema
+	result, err := DefaultRegistry.Call(context.Background(), "google_pse_search", map[string]interface{}{"query": "golang"})
+	if err != nil || !reflect.DeepEqual(result.StructuredContent, want) {
+		t.Errorf("Unexpected result %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,499 @@
+package tools
+
+import (
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// htmlSkippedElements are left out of converted pages with their contents:
+// scripts and styles, and the navigation and chrome around the content
+var htmlSkippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true,
+	"svg": true, "iframe": true, "canvas": true, "form": true, "button": true,
+	"select": true, "nav": true, "header": true, "footer": true, "aside": true,
+	"head": true,
+}
+
+// htmlRawTextElements hold text that is not markup
+var htmlRawTextElements = map[string]bool{"script": true, "style": true, "textarea": true, "title": true}
+
+// htmlVoidElements have no end tag
+var htmlVoidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true,
+	"img": true, "input": true, "link": true, "meta": true, "source": true,
+	"track": true, "wbr": true,
+}
+
+// htmlBlockElements start on a new paragraph
+var htmlBlockElements = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true,
+	"table": true, "dl": true, "dt": true, "dd": true, "figure": true,
+	"figcaption": true, "address": true, "details": true, "summary": true,
+}
+
+// htmlToken is a tag or a run of text of an HTML document
+type htmlToken struct {
+	text    string // Unescaped text, for text tokens
+	tag     string // Lower-case tag name, empty for text tokens
+	end     bool
+	attrs   map[string]string
+	selfEnd bool
+}
+
+// tokenizeHTML splits an HTML document into tags and text. It is lenient:
+// comments and doctypes are dropped and the contents of raw text elements
+// become a single text token.
+func tokenizeHTML(doc string) []htmlToken {
+	var tokens []htmlToken
+	for len(doc) > 0 {
+		lt := strings.IndexByte(doc, '<')
+		if lt != 0 {
+			if lt < 0 {
+				lt = len(doc)
+			}
+			tokens = append(tokens, htmlToken{text: html.UnescapeString(doc[:lt])})
+			doc = doc[lt:]
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(doc, "<!--"):
+			end := strings.Index(doc, "-->")
+			if end < 0 {
+				return tokens
+			}
+			doc = doc[end+3:]
+			continue
+		case strings.HasPrefix(doc, "<!") || strings.HasPrefix(doc, "<?"):
+			end := strings.IndexByte(doc, '>')
+			if end < 0 {
+				return tokens
+			}
+			doc = doc[end+1:]
+			continue
+		}
+
+		token, rest, ok := parseHTMLTag(doc)
+		if !ok {
+			// A stray "<" is text
+			tokens = append(tokens, htmlToken{text: "<"})
+			doc = doc[1:]
+			continue
+		}
+		tokens = append(tokens, token)
+		doc = rest
+
+		if !token.end && htmlRawTextElements[token.tag] {
+			end := indexFold(doc, "</"+token.tag)
+			if end < 0 {
+				end = len(doc)
+			}
+			text := doc[:end]
+			if token.tag == "title" || token.tag == "textarea" {
+				text = html.UnescapeString(text)
+			}
+			tokens = append(tokens, htmlToken{text: text})
+			doc = doc[end:]
+		}
+	}
+	return tokens
+}
+
+// parseHTMLTag parses the tag at the start of doc
+func parseHTMLTag(doc string) (htmlToken, string, bool) {
+	var token htmlToken
+	i := 1
+	if i < len(doc) && doc[i] == '/' {
+		token.end = true
+		i++
+	}
+	start := i
+	for i < len(doc) && isTagNameByte(doc[i]) {
+		i++
+	}
+	if i == start {
+		return token, doc, false
+	}
+	token.tag = strings.ToLower(doc[start:i])
+
+	for i < len(doc) {
+		for i < len(doc) && isHTMLSpace(doc[i]) {
+			i++
+		}
+		if i >= len(doc) {
+			break
+		}
+		switch doc[i] {
+		case '>':
+			return token, doc[i+1:], true
+		case '/':
+			token.selfEnd = true
+			i++
+			continue
+		}
+
+		nameStart := i
+		for i < len(doc) && !isHTMLSpace(doc[i]) && doc[i] != '=' && doc[i] != '>' && doc[i] != '/' {
+			i++
+		}
+		name := strings.ToLower(doc[nameStart:i])
+		var value string
+		for i < len(doc) && isHTMLSpace(doc[i]) {
+			i++
+		}
+		if i < len(doc) && doc[i] == '=' {
+			i++
+			for i < len(doc) && isHTMLSpace(doc[i]) {
+				i++
+			}
+			if i < len(doc) && (doc[i] == '"' || doc[i] == '\'') {
+				quote := doc[i]
+				end := strings.IndexByte(doc[i+1:], quote)
+				if end < 0 {
+					return token, doc, false
+				}
+				value = doc[i+1 : i+1+end]
+				i += end + 2
+			} else {
+				valueStart := i
+				for i < len(doc) && !isHTMLSpace(doc[i]) && doc[i] != '>' {
+					i++
+				}
+				value = doc[valueStart:i]
+			}
+		}
+		if name != "" {
+			if token.attrs == nil {
+				token.attrs = make(map[string]string)
+			}
+			token.attrs[name] = html.UnescapeString(value)
+		}
+	}
+	return token, doc, false
+}
+
+func isTagNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == ':'
+}
+
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// indexFold is strings.Index ignoring ASCII case
+func indexFold(s, substr string) int {
+	return strings.Index(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// htmlTitle returns the text of the document's <title>
+func htmlTitle(tokens []htmlToken) string {
+	for i, token := range tokens {
+		if token.tag == "title" && !token.end && i+1 < len(tokens) && tokens[i+1].tag == "" {
+			return strings.Join(strings.Fields(tokens[i+1].text), " ")
+		}
+	}
+	return ""
+}
+
+// mainContent narrows tokens to the first <main> or <article> element when
+// the page has one, which drops most boilerplate around the content
+func mainContent(tokens []htmlToken) []htmlToken {
+	for _, container := range []string{"main", "article"} {
+		for i, token := range tokens {
+			if token.tag != container || token.end {
+				continue
+			}
+			depth := 0
+			for j := i; j < len(tokens); j++ {
+				if tokens[j].tag != container {
+					continue
+				}
+				if !tokens[j].end {
+					depth++
+				} else if depth--; depth == 0 {
+					return tokens[i+1 : j]
+				}
+			}
+			return tokens[i+1:]
+		}
+	}
+	return tokens
+}
+
+// markdownWriter accumulates converted text, collapsing whitespace and
+// keeping track of line starts so blocks are separated by blank lines
+type markdownWriter struct {
+	b        strings.Builder
+	newlines int  // Trailing newlines written, 2 at the start
+	space    bool // A space is due before the next word
+}
+
+func newMarkdownWriter() *markdownWriter {
+	return &markdownWriter{newlines: 2}
+}
+
+// text writes s with runs of whitespace collapsed to one space
+func (w *markdownWriter) text(s string) {
+	if s == "" {
+		return
+	}
+	if isHTMLSpace(s[0]) {
+		w.space = true
+	}
+	for i, word := range strings.Fields(s) {
+		if i > 0 {
+			w.space = true
+		}
+		w.raw(word)
+	}
+	if isHTMLSpace(s[len(s)-1]) {
+		w.space = true
+	}
+}
+
+// raw writes s as is, after a pending space
+func (w *markdownWriter) raw(s string) {
+	if s == "" {
+		return
+	}
+	if w.space && w.newlines == 0 {
+		w.b.WriteByte(' ')
+	}
+	w.space = false
+	w.b.WriteString(s)
+	w.newlines = 0
+	for i := len(s) - 1; i >= 0 && s[i] == '\n'; i-- {
+		w.newlines++
+	}
+}
+
+// block ends the current line and leaves n-1 blank lines
+func (w *markdownWriter) block(n int) {
+	for w.newlines < n {
+		w.b.WriteByte('\n')
+		w.newlines++
+	}
+	w.space = false
+}
+
+func (w *markdownWriter) String() string {
+	return strings.TrimSpace(w.b.String())
+}
+
+// htmlToText converts an HTML document to markdown, or to plain text when
+// plain is set. Relative links are resolved against base.
+func htmlToText(tokens []htmlToken, base *url.URL, plain bool) string {
+	writers := []*markdownWriter{newMarkdownWriter()}
+	w := writers[0]
+	type list struct {
+		ordered bool
+		index   int
+	}
+	var lists []list
+	var links []string // href of each open <a>, empty when not rendered as a link
+	skip, pre := 0, 0
+	var skipTag string
+	var tableRow, headerRow bool
+	var cells int
+
+	resolve := func(ref string) string {
+		if base == nil {
+			return ref
+		}
+		u, err := base.Parse(ref)
+		if err != nil {
+			return ref
+		}
+		return u.String()
+	}
+	emphasis := func(marker string) {
+		if !plain {
+			w.raw(marker)
+		}
+	}
+
+	for _, token := range tokens {
+		if skip > 0 {
+			if token.tag == skipTag {
+				if token.end {
+					skip--
+				} else if !token.selfEnd {
+					skip++
+				}
+			}
+			continue
+		}
+
+		if token.tag == "" {
+			if pre > 0 {
+				w.raw(token.text)
+			} else {
+				w.text(token.text)
+			}
+			continue
+		}
+
+		tag := token.tag
+		if !token.end && htmlSkippedElements[tag] {
+			if !token.selfEnd && !htmlVoidElements[tag] {
+				skip, skipTag = 1, tag
+			}
+			continue
+		}
+
+		switch {
+		case len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6':
+			w.block(2)
+			if !token.end && !plain {
+				w.raw(strings.Repeat("#", int(tag[1]-'0')) + " ")
+			}
+			if token.end {
+				w.block(2)
+			}
+		case htmlBlockElements[tag]:
+			w.block(2)
+		case tag == "br":
+			w.block(1)
+		case tag == "hr":
+			w.block(2)
+			if !plain {
+				w.raw("---")
+			}
+			w.block(2)
+		case tag == "ul" || tag == "ol":
+			if token.end {
+				if len(lists) > 0 {
+					lists = lists[:len(lists)-1]
+				}
+				if len(lists) == 0 {
+					w.block(2)
+				}
+			} else {
+				if len(lists) == 0 {
+					w.block(2)
+				}
+				lists = append(lists, list{ordered: tag == "ol"})
+			}
+		case tag == "li":
+			if token.end {
+				continue
+			}
+			w.block(1)
+			marker := "- "
+			if len(lists) > 0 {
+				current := &lists[len(lists)-1]
+				current.index++
+				if current.ordered {
+					marker = strconv.Itoa(current.index) + ". "
+				}
+				marker = strings.Repeat("  ", len(lists)-1) + marker
+			}
+			w.raw(marker)
+		case tag == "pre":
+			if token.end {
+				pre--
+				w.block(1)
+				emphasis("```")
+				w.block(2)
+			} else {
+				pre++
+				w.block(2)
+				emphasis("```")
+				w.block(1)
+			}
+		case tag == "code" && pre == 0:
+			emphasis("`")
+		case tag == "strong" || tag == "b":
+			emphasis("**")
+		case tag == "em" || tag == "i":
+			emphasis("_")
+		case tag == "a":
+			if token.end {
+				if len(links) > 0 {
+					href := links[len(links)-1]
+					links = links[:len(links)-1]
+					if href != "" {
+						w.raw("](" + href + ")")
+					}
+				}
+			} else {
+				href := token.attrs["href"]
+				if plain || href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+					links = append(links, "")
+				} else {
+					links = append(links, resolve(href))
+					w.raw("[")
+				}
+			}
+		case tag == "img":
+			if alt := strings.TrimSpace(token.attrs["alt"]); alt != "" && token.attrs["src"] != "" {
+				if plain {
+					w.text(alt)
+				} else {
+					w.raw("![" + alt + "](" + resolve(token.attrs["src"]) + ")")
+				}
+			}
+		case tag == "blockquote":
+			if token.end {
+				if len(writers) > 1 {
+					quoted := w.String()
+					writers = writers[:len(writers)-1]
+					w = writers[len(writers)-1]
+					w.block(2)
+					if plain {
+						w.raw(quoted)
+					} else {
+						w.raw("> " + strings.ReplaceAll(quoted, "\n", "\n> "))
+					}
+					w.block(2)
+				}
+			} else {
+				w = newMarkdownWriter()
+				writers = append(writers, w)
+			}
+		case tag == "tr":
+			if token.end {
+				if tableRow && headerRow && !plain {
+					w.block(1)
+					w.raw("|" + strings.Repeat("---|", cells))
+				}
+				tableRow, headerRow, cells = false, false, 0
+				w.block(1)
+			} else {
+				w.block(1)
+				tableRow = true
+				if !plain {
+					w.raw("|")
+				}
+			}
+		case tag == "td" || tag == "th":
+			switch {
+			case token.end:
+				cells++
+				if !plain {
+					w.raw(" |")
+				}
+			case plain:
+				if cells > 0 {
+					w.space = false
+					w.raw("\t")
+				}
+			default:
+				headerRow = headerRow || tag == "th"
+				w.space = true
+			}
+		}
+	}
+
+	// Close blockquotes left open by sloppy markup
+	for len(writers) > 1 {
+		quoted := w.String()
+		writers = writers[:len(writers)-1]
+		w = writers[len(writers)-1]
+		w.block(2)
+		w.raw(quoted)
+	}
+	return w.String()
+}
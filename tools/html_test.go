@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestHTMLToText(t *testing.T) {
+	page := `<!DOCTYPE html>
+<html><head><title>Go &amp; Friends</title><style>body { color: red }</style></head>
+<body>
+<nav><a href="/">Home</a></nav>
+<main>
+  <h1>Hello</h1>
+  <p>Some <b>bold</b> and <em>soft</em> text with a <a href="/docs?a=1&amp;b=2">link</a>.<br>Next line</p>
+  <script>alert("<p>no</p>")</script>
+  <ul><li>one</li><li>two<ol><li>nested</li></ol></li></ul>
+  <pre>x := 1
+y := 2</pre>
+  <blockquote><p>quoted</p></blockquote>
+  <table><tr><th>A</th><th>B</th></tr><tr><td>1</td><td>2</td></tr></table>
+</main>
+<footer>Copyright</footer>
+</body></html>`
+
+	tokens := tokenizeHTML(page)
+	if title := htmlTitle(tokens); title != "Go & Friends" {
+		t.Errorf("Unexpected title %q", title)
+	}
+
+	base, _ := url.Parse("https://example.com/page")
+	want := "# Hello\n\n" +
+		"Some **bold** and _soft_ text with a [link](https://example.com/docs?a=1&b=2).\nNext line\n\n" +
+		"- one\n- two\n  1. nested\n\n" +
+		"```\nx := 1\ny := 2\n```\n\n" +
+		"> quoted\n\n" +
+		"| A | B |\n|---|---|\n| 1 | 2 |"
+	if got := htmlToText(mainContent(tokens), base, false); got != want {
+		t.Errorf("Unexpected markdown:\n%s\nwant:\n%s", got, want)
+	}
+
+	wantText := "Hello\n\nSome bold and soft text with a link.\nNext line\n\n" +
+		"- one\n- two\n  1. nested\n\nx := 1\ny := 2\n\nquoted\n\nA\tB\n1\t2"
+	if got := htmlToText(mainContent(tokens), base, true); got != wantText {
+		t.Errorf("Unexpected text:\n%q\nwant:\n%q", got, wantText)
+	}
+}
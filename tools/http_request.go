@@ -0,0 +1,251 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Defaults for the http_request tool when the configuration leaves them unset
+const (
+	DefaultHTTPRequestTimeout     = 30 * time.Second
+	DefaultHTTPRequestMaxResponse = 1 << 20
+)
+
+// HTTPRequestTool represents the http_request tool definition
+type HTTPRequestTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// GetHTTPRequestTool returns the http_request tool definition
+func GetHTTPRequestTool() HTTPRequestTool {
+	return HTTPRequestTool{
+		Name:        "http_request",
+		Description: "Send an HTTP request to an allowed host and return the status, headers and body of the response",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"method": map[string]interface{}{
+					"type":        "string",
+					"description": "The HTTP method (default: GET)",
+					"default":     "GET",
+				},
+				"url": map[string]interface{}{
+					"type":        "string",
+					"description": "The http or https URL to request",
+				},
+				"headers": map[string]interface{}{
+					"type":                 "object",
+					"additionalProperties": map[string]interface{}{"type": "string"},
+					"description":          "Request headers",
+				},
+				"body": map[string]interface{}{
+					"type":        "string",
+					"description": "The request body",
+				},
+				"timeout": map[string]interface{}{
+					"type":        "integer",
+					"description": "Timeout in seconds, at most the configured timeout",
+					"minimum":     1,
+				},
+				"max_response_bytes": map[string]interface{}{
+					"type":        "integer",
+					"description": "Truncate the response body after this many bytes, at most the configured limit",
+					"minimum":     1,
+				},
+			},
+			"required": []string{"url"},
+		},
+	}
+}
+
+// HTTPRequestConfig holds the guardrails of the http_request tool
+type HTTPRequestConfig struct {
+	// AllowedHosts are the hosts requests may go to: "api.example.com",
+	// "*.example.com" for its subdomains, "host:port" to pin a port, or "*"
+	// for any host
+	AllowedHosts     []string
+	Timeout          time.Duration
+	MaxResponseBytes int64
+}
+
+var httpRequestConfig *HTTPRequestConfig
+
+// SetHTTPRequestConfig enables the http_request tool. A zero timeout or
+// response limit selects the default.
+func SetHTTPRequestConfig(allowedHosts []string, timeout time.Duration, maxResponseBytes int64) {
+	if timeout <= 0 {
+		timeout = DefaultHTTPRequestTimeout
+	}
+	if maxResponseBytes <= 0 {
+		maxResponseBytes = DefaultHTTPRequestMaxResponse
+	}
+	httpRequestConfig = &HTTPRequestConfig{
+		AllowedHosts:     allowedHosts,
+		Timeout:          timeout,
+		MaxResponseBytes: maxResponseBytes,
+	}
+}
+
+// GetHTTPRequestConfig returns the current configuration, nil when the tool
+// is disabled
+func GetHTTPRequestConfig() *HTTPRequestConfig {
+	return httpRequestConfig
+}
+
+// hostAllowed reports whether u may be requested under the allowlist
+func (c *HTTPRequestConfig) hostAllowed(u *url.URL) bool {
+	return hostMatches(c.AllowedHosts, u)
+}
+
+// hostMatches reports whether the host of u matches one of the patterns:
+// "api.example.com", "*.example.com" for its subdomains, "host:port" to pin
+// a port, or "*" for any host
+func hostMatches(patterns []string, u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "*" {
+			return true
+		}
+		patternHost, patternPort, err := net.SplitHostPort(pattern)
+		if err != nil {
+			patternHost, patternPort = pattern, ""
+		}
+		if patternPort != "" && patternPort != port {
+			continue
+		}
+		if strings.HasPrefix(patternHost, "*.") {
+			if strings.HasSuffix(host, patternHost[1:]) {
+				return true
+			}
+		} else if host == patternHost {
+			return true
+		}
+	}
+	return false
+}
+
+// checkURL rejects URLs that are not http(s) or whose host is not allowed
+func (c *HTTPRequestConfig) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL scheme %q, only http and https are allowed", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("URL must include a host")
+	}
+	if !c.hostAllowed(u) {
+		return fmt.Errorf("host %s is not in the allowed hosts", u.Host)
+	}
+	return nil
+}
+
+// CallHTTPRequest executes an HTTP request within the configured guardrails.
+// Redirects are followed only to allowed hosts, and the request is aborted
+// when ctx ends.
+func CallHTTPRequest(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+	if httpRequestConfig == nil {
+		return nil, fmt.Errorf("http_request not configured. Set http_request.enabled and allowed_hosts in the config file")
+	}
+	config := httpRequestConfig
+
+	var args struct {
+		URL              string            `arg:"url,required"`
+		Method           string            `arg:"method"`
+		Headers          map[string]string `arg:"headers"`
+		Body             string            `arg:"body"`
+		Timeout          int               `arg:"timeout"`
+		MaxResponseBytes int64             `arg:"max_response_bytes"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	target, err := url.Parse(args.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %v", err)
+	}
+	if err := config.checkURL(target); err != nil {
+		return nil, err
+	}
+
+	method := "GET"
+	if args.Method != "" {
+		method = strings.ToUpper(args.Method)
+	}
+
+	timeout := config.Timeout
+	if t := time.Duration(args.Timeout) * time.Second; t > 0 && t < timeout {
+		timeout = t
+	}
+	maxResponse := config.MaxResponseBytes
+	if m := args.MaxResponseBytes; m > 0 && m < maxResponse {
+		maxResponse = m
+	}
+
+	var body io.Reader
+	if args.Body != "" {
+		body = strings.NewReader(args.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range args.Headers {
+		req.Header.Set(name, value)
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			return config.checkURL(req.URL)
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxResponse+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	truncated := int64(len(content)) > maxResponse
+	if truncated {
+		content = content[:maxResponse]
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "HTTP %s\n", resp.Status)
+	names := make([]string, 0, len(resp.Header))
+	for name := range resp.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range resp.Header[name] {
+			fmt.Fprintf(&result, "%s: %s\n", name, value)
+		}
+	}
+	result.WriteString("\n")
+	result.Write(content)
+	if truncated {
+		fmt.Fprintf(&result, "\n[Truncated: showing the first %d bytes of the response]", maxResponse)
+	}
+	return TextResult(result.String()), nil
+}
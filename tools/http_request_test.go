@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPRequestHostAllowed(t *testing.T) {
+	config := &HTTPRequestConfig{AllowedHosts: []string{"api.example.com", "*.internal.test", "localhost:8080"}}
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://api.example.com/v1", true},
+		{"https://API.example.com/v1", true},
+		{"https://example.com/", false},
+		{"http://svc.internal.test/", true},
+		{"http://internal.test/", false},
+		{"http://localhost:8080/", true},
+		{"http://localhost:9090/", false},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if got := config.hostAllowed(u); got != tt.want {
+			t.Errorf("hostAllowed(%s) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestCallHTTPRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/echo":
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("X-Method", r.Method)
+			w.Write([]byte(r.Header.Get("X-Token") + ":" + string(body)))
+		case "/away":
+			http.Redirect(w, r, "http://elsewhere.test/", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	SetHTTPRequestConfig([]string{host}, time.Second, 8)
+	defer func() { httpRequestConfig = nil }()
+
+	result, err := resultText(CallHTTPRequest(context.Background(), map[string]interface{}{
+		"method":  "post",
+		"url":     server.URL + "/echo",
+		"headers": map[string]interface{}{"X-Token": "abc"},
+		"body":    "hello world",
+	}))
+	if err != nil {
+		t.Fatalf("CallHTTPRequest failed: %v", err)
+	}
+	for _, want := range []string{"HTTP 200 OK\n", "X-Method: POST\n", "\n\nabc:hell\n[Truncated: "} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Expected %q in:\n%s", want, result)
+		}
+	}
+
+	// Hosts outside the allowlist are rejected, also when redirected to
+	for _, target := range []string{"http://elsewhere.test/", server.URL + "/away", "file:///etc/passwd"} {
+		if _, err := CallHTTPRequest(context.Background(), map[string]interface{}{"url": target}); err == nil {
+			t.Errorf("Expected a request to %s to be rejected", target)
+		}
+	}
+}
+
+func TestCallHTTPRequestCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	SetHTTPRequestConfig([]string{strings.TrimPrefix(server.URL, "http://")}, time.Minute, 0)
+	defer func() { httpRequestConfig = nil }()
+
+	// The request is aborted when the caller goes away, long before the
+	// configured timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := CallHTTPRequest(ctx, map[string]interface{}{"url": server.URL}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the request to be cancelled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the request to stop with its context, took %v", elapsed)
+	}
+}
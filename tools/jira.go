@@ -0,0 +1,529 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultJiraTimeout bounds each Jira call when the configuration sets no
+// timeout
+const DefaultJiraTimeout = 30 * time.Second
+
+// jiraIssueKeyPattern matches issue keys such as "OPS-123"
+var jiraIssueKeyPattern = regexp.MustCompile(`^([A-Z][A-Z0-9_]*)-[1-9][0-9]*$`)
+
+// jiraOrderByPattern finds the ORDER BY clause of a JQL query
+var jiraOrderByPattern = regexp.MustCompile(`(?i)(^|\s+)order\s+by\s+`)
+
+// JiraTool represents a Jira tool definition
+type JiraTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// jiraKeyProperty is the schema of the key argument of the Jira tools
+var jiraKeyProperty = map[string]interface{}{
+	"type":        "string",
+	"description": "Key of the issue, e.g. \"OPS-123\"",
+}
+
+// jiraWriteTools are the Jira tools that change issues
+var jiraWriteTools = map[string]bool{
+	"jira_create_issue": true,
+	"jira_add_comment":  true,
+}
+
+// GetJiraTools returns the definitions of the Jira tools
+func GetJiraTools() []JiraTool {
+	return []JiraTool{
+		{
+			Name:        "jira_search",
+			Description: "Search Jira issues with JQL, e.g. \"status = Open AND assignee is EMPTY ORDER BY priority DESC\"",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"jql": map[string]interface{}{
+						"type":        "string",
+						"description": "JQL query",
+					},
+					"max_results": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of issues to return (1-100, default: 20)",
+						"default":     20,
+						"minimum":     1,
+						"maximum":     100,
+					},
+				},
+				"required": []string{"jql"},
+			},
+		},
+		{
+			Name:        "jira_get_issue",
+			Description: "Get a Jira issue with its description and latest comments",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"key": jiraKeyProperty,
+				},
+				"required": []string{"key"},
+			},
+		},
+		{
+			Name:        "jira_create_issue",
+			Description: "Create a Jira issue",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"project": map[string]interface{}{
+						"type":        "string",
+						"description": "Key of the project, e.g. \"OPS\"",
+					},
+					"summary": map[string]interface{}{
+						"type":        "string",
+						"description": "Summary (title) of the issue",
+					},
+					"description": map[string]interface{}{
+						"type":        "string",
+						"description": "Description of the issue",
+					},
+					"issue_type": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the issue type, e.g. \"Bug\" (default: Task)",
+					},
+					"labels": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Labels to add",
+					},
+				},
+				"required": []string{"project", "summary"},
+			},
+		},
+		{
+			Name:        "jira_add_comment",
+			Description: "Add a comment to a Jira issue",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"key": jiraKeyProperty,
+					"body": map[string]interface{}{
+						"type":        "string",
+						"description": "Text of the comment",
+					},
+				},
+				"required": []string{"key", "body"},
+			},
+		},
+	}
+}
+
+// IsJiraTool reports whether name is one of the Jira tools
+func IsJiraTool(name string) bool {
+	for _, tool := range GetJiraTools() {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// JiraConfig holds the configuration for the Jira tools
+type JiraConfig struct {
+	URL string // Base URL of the instance, e.g. "https://acme.atlassian.net"
+	// Email and APIToken authenticate to Jira Cloud; with no email the token
+	// is sent as the personal access token of Jira Server or Data Center
+	Email    string
+	APIToken string
+	// Projects the tools may use, by key; empty allows every project
+	Projects []string
+	ReadOnly bool // Hides the tools that create issues and comments
+	Timeout  time.Duration
+}
+
+var jiraConfig *JiraConfig
+
+// SetJiraConfig enables the Jira tools. A zero timeout selects the default.
+func SetJiraConfig(baseURL, email, apiToken string, projects []string, readOnly bool, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultJiraTimeout
+	}
+	jiraConfig = &JiraConfig{
+		URL:      strings.TrimRight(baseURL, "/"),
+		Email:    email,
+		APIToken: apiToken,
+		Projects: projects,
+		ReadOnly: readOnly,
+		Timeout:  timeout,
+	}
+}
+
+// GetJiraConfig returns the current configuration, nil when the tools are
+// disabled
+func GetJiraConfig() *JiraConfig {
+	return jiraConfig
+}
+
+// JiraToolEnabled reports whether the named Jira tool is enabled
+func JiraToolEnabled(name string) bool {
+	config := jiraConfig
+	return config != nil && !(config.ReadOnly && jiraWriteTools[name])
+}
+
+// projectAllowed reports whether the tools may use the project
+func (c *JiraConfig) projectAllowed(project string) bool {
+	if len(c.Projects) == 0 {
+		return true
+	}
+	for _, allowed := range c.Projects {
+		if strings.EqualFold(allowed, project) {
+			return true
+		}
+	}
+	return false
+}
+
+// jiraArguments are the arguments of the Jira tools
+type jiraArguments struct {
+	Key         string   `arg:"key"`
+	JQL         string   `arg:"jql"`
+	MaxResults  int      `arg:"max_results"`
+	Project     string   `arg:"project"`
+	Summary     string   `arg:"summary"`
+	IssueType   string   `arg:"issue_type"`
+	Description string   `arg:"description"`
+	Labels      []string `arg:"labels"`
+	Body        string   `arg:"body"`
+}
+
+// issueKey returns the issue key named by the key argument
+func (c *JiraConfig) issueKey(key string) (string, error) {
+	key = strings.ToUpper(strings.TrimSpace(key))
+	match := jiraIssueKeyPattern.FindStringSubmatch(key)
+	if match == nil {
+		return "", fmt.Errorf("key argument is required and must be an issue key such as \"OPS-123\"")
+	}
+	if !c.projectAllowed(match[1]) {
+		return "", fmt.Errorf("project %s is not allowed", match[1])
+	}
+	return key, nil
+}
+
+// CallJiraTool executes the named Jira tool
+func CallJiraTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+	config := jiraConfig
+	if config == nil {
+		return nil, fmt.Errorf("Jira tools not configured. Set jira.enabled and jira.url in the config file")
+	}
+	if !JiraToolEnabled(name) {
+		return nil, fmt.Errorf("%s is disabled because jira.read_only is set", name)
+	}
+	args := &jiraArguments{MaxResults: 20}
+	if err := DecodeArgs(arguments, args); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	switch name {
+	case "jira_search":
+		return textResult(config.search(ctx, args))
+	case "jira_get_issue":
+		return textResult(config.getIssue(ctx, args))
+	case "jira_create_issue":
+		return textResult(config.createIssue(ctx, args))
+	case "jira_add_comment":
+		return textResult(config.addComment(ctx, args))
+	default:
+		return nil, fmt.Errorf("unknown Jira tool: %s", name)
+	}
+}
+
+// jiraIssue is the part of a Jira issue the tools use
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string     `json:"summary"`
+		Description string     `json:"description"`
+		Status      jiraNamed  `json:"status"`
+		IssueType   jiraNamed  `json:"issuetype"`
+		Priority    *jiraNamed `json:"priority"`
+		Project     struct {
+			Key string `json:"key"`
+		} `json:"project"`
+		Assignee *jiraUser `json:"assignee"`
+		Reporter *jiraUser `json:"reporter"`
+		Labels   []string  `json:"labels"`
+		Created  string    `json:"created"`
+		Updated  string    `json:"updated"`
+		Comment  *struct {
+			Total    int `json:"total"`
+			Comments []struct {
+				Author  *jiraUser `json:"author"`
+				Body    string    `json:"body"`
+				Created string    `json:"created"`
+			} `json:"comments"`
+		} `json:"comment"`
+	} `json:"fields"`
+}
+
+// jiraNamed is a field Jira returns as an object with a name, such as the
+// status
+type jiraNamed struct {
+	Name string `json:"name"`
+}
+
+// jiraUser is the part of a Jira user the tools use
+type jiraUser struct {
+	DisplayName string `json:"displayName"`
+}
+
+// jiraUserName returns the display name of a user, "Unassigned" for none
+func jiraUserName(user *jiraUser) string {
+	if user == nil {
+		return "Unassigned"
+	}
+	return user.DisplayName
+}
+
+// search runs a JQL query. With an allowlist the query is narrowed to the
+// allowed projects.
+func (c *JiraConfig) search(ctx context.Context, args *jiraArguments) (string, error) {
+	jql, maxResults := args.JQL, args.MaxResults
+	if strings.TrimSpace(jql) == "" {
+		return "", fmt.Errorf("jql argument is required and must be a non-empty string")
+	}
+	if maxResults < 1 || maxResults > 100 {
+		maxResults = 20
+	}
+	if len(c.Projects) > 0 {
+		quoted := make([]string, len(c.Projects))
+		for i, project := range c.Projects {
+			quoted[i] = `"` + project + `"`
+		}
+		restriction := "project in (" + strings.Join(quoted, ", ") + ")"
+		condition, orderBy := jql, ""
+		if loc := jiraOrderByPattern.FindStringIndex(jql); loc != nil {
+			condition, orderBy = jql[:loc[0]], " ORDER BY "+jql[loc[1]:]
+		}
+		if strings.TrimSpace(condition) == "" {
+			jql = restriction + orderBy
+		} else {
+			jql = restriction + " AND (" + condition + ")" + orderBy
+		}
+	}
+
+	// Jira Cloud replaced the search endpoint with search/jql
+	path := "/rest/api/2/search"
+	if strings.HasSuffix(strings.ToLower(c.URL), ".atlassian.net") {
+		path = "/rest/api/2/search/jql"
+	}
+	request := map[string]interface{}{
+		"jql":        jql,
+		"maxResults": maxResults,
+		"fields":     []string{"summary", "status", "issuetype", "priority", "assignee", "updated"},
+	}
+	var resp struct {
+		Total  *int        `json:"total"`
+		IsLast *bool       `json:"isLast"`
+		Issues []jiraIssue `json:"issues"`
+	}
+	if err := c.do(ctx, "POST", path, request, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Issues) == 0 {
+		return "No issues found", nil
+	}
+
+	var b strings.Builder
+	if resp.Total != nil {
+		fmt.Fprintf(&b, "Showing %d of %d issues\n\n", len(resp.Issues), *resp.Total)
+	} else {
+		fmt.Fprintf(&b, "Showing %d issues\n\n", len(resp.Issues))
+	}
+	for _, issue := range resp.Issues {
+		priority := ""
+		if issue.Fields.Priority != nil {
+			priority = ", " + issue.Fields.Priority.Name
+		}
+		fmt.Fprintf(&b, "%s [%s] %s\n   %s%s, %s, updated %s\n   %s/browse/%s\n\n",
+			issue.Key, issue.Fields.Status.Name, issue.Fields.Summary,
+			issue.Fields.IssueType.Name, priority, jiraUserName(issue.Fields.Assignee), issue.Fields.Updated,
+			c.URL, issue.Key)
+	}
+	if resp.IsLast != nil && !*resp.IsLast {
+		b.WriteString("[More issues; narrow the query or raise max_results]\n")
+	}
+	return b.String(), nil
+}
+
+// getIssue returns an issue with its description and latest comments
+func (c *JiraConfig) getIssue(ctx context.Context, args *jiraArguments) (string, error) {
+	key, err := c.issueKey(args.Key)
+	if err != nil {
+		return "", err
+	}
+	var issue jiraIssue
+	fields := "summary,description,status,issuetype,priority,project,assignee,reporter,labels,created,updated,comment"
+	if err := c.do(ctx, "GET", "/rest/api/2/issue/"+key+"?fields="+fields, nil, &issue); err != nil {
+		return "", err
+	}
+	// The key may belong to an issue moved out of an allowed project
+	if !c.projectAllowed(issue.Fields.Project.Key) {
+		return "", fmt.Errorf("project %s is not allowed", issue.Fields.Project.Key)
+	}
+
+	f := issue.Fields
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s\n", issue.Key, f.Summary)
+	fmt.Fprintf(&b, "URL: %s/browse/%s\n", c.URL, issue.Key)
+	fmt.Fprintf(&b, "Type: %s\nStatus: %s\n", f.IssueType.Name, f.Status.Name)
+	if f.Priority != nil {
+		fmt.Fprintf(&b, "Priority: %s\n", f.Priority.Name)
+	}
+	fmt.Fprintf(&b, "Assignee: %s\nReporter: %s\n", jiraUserName(f.Assignee), jiraUserName(f.Reporter))
+	if len(f.Labels) > 0 {
+		fmt.Fprintf(&b, "Labels: %s\n", strings.Join(f.Labels, ", "))
+	}
+	fmt.Fprintf(&b, "Created: %s\nUpdated: %s\n", f.Created, f.Updated)
+	if f.Description != "" {
+		fmt.Fprintf(&b, "\nDescription:\n%s\n", f.Description)
+	}
+	if f.Comment != nil && len(f.Comment.Comments) > 0 {
+		comments := f.Comment.Comments
+		if len(comments) > 20 {
+			comments = comments[len(comments)-20:]
+		}
+		fmt.Fprintf(&b, "\nComments (%d of %d):\n", len(comments), f.Comment.Total)
+		for _, comment := range comments {
+			fmt.Fprintf(&b, "\n[%s] %s:\n%s\n", comment.Created, jiraUserName(comment.Author), comment.Body)
+		}
+	}
+	return b.String(), nil
+}
+
+// createIssue creates an issue
+func (c *JiraConfig) createIssue(ctx context.Context, args *jiraArguments) (string, error) {
+	project := strings.ToUpper(strings.TrimSpace(args.Project))
+	if project == "" {
+		return "", fmt.Errorf("project argument is required and must be a project key")
+	}
+	if !c.projectAllowed(project) {
+		return "", fmt.Errorf("project %s is not allowed", project)
+	}
+	summary, issueType := args.Summary, args.IssueType
+	if strings.TrimSpace(summary) == "" {
+		return "", fmt.Errorf("summary argument is required and must be a non-empty string")
+	}
+	if strings.ContainsAny(summary, "\r\n") {
+		return "", fmt.Errorf("summary must be a single line")
+	}
+	if issueType == "" {
+		issueType = "Task"
+	}
+
+	fields := map[string]interface{}{
+		"project":   map[string]interface{}{"key": project},
+		"summary":   summary,
+		"issuetype": map[string]interface{}{"name": issueType},
+	}
+	if args.Description != "" {
+		fields["description"] = args.Description
+	}
+	if len(args.Labels) > 0 {
+		fields["labels"] = args.Labels
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := c.do(ctx, "POST", "/rest/api/2/issue", map[string]interface{}{"fields": fields}, &created); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Created %s: %s/browse/%s", created.Key, c.URL, created.Key), nil
+}
+
+// addComment adds a comment to an issue
+func (c *JiraConfig) addComment(ctx context.Context, args *jiraArguments) (string, error) {
+	key, err := c.issueKey(args.Key)
+	if err != nil {
+		return "", err
+	}
+	body := args.Body
+	if strings.TrimSpace(body) == "" {
+		return "", fmt.Errorf("body argument is required and must be a non-empty string")
+	}
+	var comment struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(ctx, "POST", "/rest/api/2/issue/"+key+"/comment", map[string]interface{}{"body": body}, &comment); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Added comment %s to %s", comment.ID, key), nil
+}
+
+// do sends a REST API request, with a JSON body when request is set, and
+// decodes the response into v. Responses other than 2xx are returned as
+// errors.
+func (c *JiraConfig) do(ctx context.Context, method, path string, request interface{}, v interface{}) error {
+	var body io.Reader
+	if request != nil {
+		data, err := json.Marshal(request)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.URL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Email != "" {
+		req.SetBasicAuth(c.Email, c.APIToken)
+	} else if c.APIToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("Jira request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		var apiErr struct {
+			ErrorMessages []string          `json:"errorMessages"`
+			Errors        map[string]string `json:"errors"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil {
+			var fieldErrors []string
+			for field, message := range apiErr.Errors {
+				fieldErrors = append(fieldErrors, field+": "+message)
+			}
+			sort.Strings(fieldErrors)
+			messages := append(apiErr.ErrorMessages, fieldErrors...)
+			if len(messages) > 0 {
+				return fmt.Errorf("Jira returned status %d: %s", resp.StatusCode, strings.Join(messages, "; "))
+			}
+		}
+		return fmt.Errorf("Jira returned status %d", resp.StatusCode)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCallJiraTool(t *testing.T) {
+	var searched, created map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, token, ok := r.BasicAuth(); !ok || user != "bot@acme.com" || token != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /rest/api/2/search":
+			json.NewDecoder(r.Body).Decode(&searched)
+			w.Write([]byte(`{"total":1,"issues":[{"key":"OPS-7","fields":{"summary":"Disk full","status":{"name":"Open"},` +
+				`"issuetype":{"name":"Bug"},"priority":{"name":"High"},"assignee":null,"updated":"2024-05-01T10:00:00.000+0000"}}]}`))
+		case "GET /rest/api/2/issue/OPS-7":
+			w.Write([]byte(`{"key":"OPS-7","fields":{"summary":"Disk full","description":"/var is at 100%","status":{"name":"Open"},` +
+				`"issuetype":{"name":"Bug"},"project":{"key":"OPS"},"assignee":{"displayName":"Ada"},"reporter":{"displayName":"Eve"},` +
+				`"labels":["disk"],"comment":{"total":1,"comments":[{"author":{"displayName":"Ada"},"body":"Looking","created":"2024-05-01"}]}}}`))
+		case "GET /rest/api/2/issue/OPS-8":
+			w.Write([]byte(`{"key":"SEC-1","fields":{"project":{"key":"SEC"}}}`))
+		case "POST /rest/api/2/issue":
+			json.NewDecoder(r.Body).Decode(&created)
+			if fields, _ := created["fields"].(map[string]interface{}); fields["issuetype"].(map[string]interface{})["name"] == "Epic" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errorMessages":[],"errors":{"issuetype":"Specify a valid issue type"}}`))
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"10001","key":"OPS-9"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errorMessages":["Issue does not exist or you do not have permission to see it."]}`))
+		}
+	}))
+	defer server.Close()
+
+	SetJiraConfig(server.URL+"/", "bot@acme.com", "secret", []string{"OPS"}, false, 0)
+	defer func() { jiraConfig = nil }()
+	ctx := context.Background()
+
+	result, err := resultText(CallJiraTool(ctx, "jira_search", map[string]interface{}{"jql": "status = Open order by priority DESC"}))
+	if err != nil || !strings.Contains(result, "OPS-7 [Open] Disk full\n   Bug, High, Unassigned") {
+		t.Errorf("jira_search = %q, %v", result, err)
+	}
+	if jql := searched["jql"]; jql != `project in ("OPS") AND (status = Open) ORDER BY priority DESC` {
+		t.Errorf("Expected the query to be narrowed to the allowlist, got %q", jql)
+	}
+	if _, err := CallJiraTool(ctx, "jira_search", map[string]interface{}{"jql": "ORDER BY created"}); err != nil || searched["jql"] != `project in ("OPS") ORDER BY created` {
+		t.Errorf("Unexpected query %q for a bare ORDER BY, %v", searched["jql"], err)
+	}
+
+	result, err = resultText(CallJiraTool(ctx, "jira_get_issue", map[string]interface{}{"key": "ops-7"}))
+	if err != nil {
+		t.Fatalf("jira_get_issue failed: %v", err)
+	}
+	for _, want := range []string{"OPS-7: Disk full\n", "Assignee: Ada\nReporter: Eve\n", "Labels: disk\n", "Description:\n/var is at 100%", "Comments (1 of 1):\n\n[2024-05-01] Ada:\nLooking"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Result lacks %q:\n%s", want, result)
+		}
+	}
+	if _, err := CallJiraTool(ctx, "jira_get_issue", map[string]interface{}{"key": "OPS-8"}); err == nil || !strings.Contains(err.Error(), "SEC is not allowed") {
+		t.Errorf("Expected an issue moved to another project to be rejected, got %v", err)
+	}
+	if _, err := CallJiraTool(ctx, "jira_get_issue", map[string]interface{}{"key": "SEC-1"}); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("Expected a project outside the allowlist to be rejected, got %v", err)
+	}
+
+	result, err = resultText(CallJiraTool(ctx, "jira_create_issue", map[string]interface{}{"project": "OPS", "summary": "Rotate logs"}))
+	if err != nil || result != "Created OPS-9: "+server.URL+"/browse/OPS-9" {
+		t.Errorf("jira_create_issue = %q, %v", result, err)
+	}
+	if _, err := CallJiraTool(ctx, "jira_create_issue", map[string]interface{}{"project": "OPS", "summary": "x", "issue_type": "Epic"}); err == nil || !strings.Contains(err.Error(), "issuetype: Specify a valid issue type") {
+		t.Errorf("Expected Jira's field error, got %v", err)
+	}
+	if _, err := CallJiraTool(ctx, "jira_get_issue", map[string]interface{}{"key": "OPS-404"}); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Expected Jira's error, got %v", err)
+	}
+
+	SetJiraConfig(server.URL, "bot@acme.com", "secret", nil, true, 0)
+	if JiraToolEnabled("jira_add_comment") || !JiraToolEnabled("jira_search") {
+		t.Error("Expected read_only to disable only the write tools")
+	}
+}
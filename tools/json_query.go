@@ -0,0 +1,191 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"mcp-go/jq"
+)
+
+// Defaults for the json_query tool when the configuration leaves them unset
+const (
+	DefaultJSONQueryMaxInputBytes = 50 << 20
+	DefaultJSONQueryMaxLength     = 20000
+)
+
+// JSONQueryTool represents the json_query tool definition
+type JSONQueryTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// GetJSONQueryTool returns the json_query tool definition
+func GetJSONQueryTool() JSONQueryTool {
+	return JSONQueryTool{
+		Name:        "json_query",
+		Description: "Extract part of a JSON document with a jq or JSONPath expression, such as '.items[] | select(.status == \"failed\") | .id' or '$.items[?(@.status == \"failed\")].id'. Use it to slice large JSON payloads instead of reading them whole.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "The jq expression, or JSONPath expression starting with $",
+				},
+				// Any JSON value: the document as text, or already decoded
+				"json": map[string]interface{}{
+					"description": "The JSON document, as text or as a JSON value",
+				},
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Path of a JSON file in the allowed directories, instead of json",
+				},
+				"syntax": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"jq", "jsonpath"},
+					"description": "Query language (default: jsonpath when the query starts with $, jq otherwise)",
+				},
+			},
+			"required": []string{"query"},
+		},
+	}
+}
+
+// JSONQueryConfig holds the configuration for the json_query tool
+type JSONQueryConfig struct {
+	// AllowedPaths are the directories files may be read from; empty only
+	// allows passing the document
+	AllowedPaths  []string
+	MaxInputBytes int64
+	MaxLength     int // Characters of output returned
+}
+
+var jsonQueryConfig *JSONQueryConfig
+
+// SetJSONQueryConfig enables the json_query tool. Zero limits select the
+// defaults.
+func SetJSONQueryConfig(config JSONQueryConfig) error {
+	roots, err := resolveRoots(config.AllowedPaths)
+	if err != nil {
+		return err
+	}
+	config.AllowedPaths = roots
+	if config.MaxInputBytes <= 0 {
+		config.MaxInputBytes = DefaultJSONQueryMaxInputBytes
+	}
+	if config.MaxLength <= 0 {
+		config.MaxLength = DefaultJSONQueryMaxLength
+	}
+	jsonQueryConfig = &config
+	return nil
+}
+
+// GetJSONQueryConfig returns the current configuration, nil when the tool
+// is disabled
+func GetJSONQueryConfig() *JSONQueryConfig {
+	return jsonQueryConfig
+}
+
+// CallJSONQuery runs a jq or JSONPath query against a JSON document and
+// returns its outputs, one per line, strings raw and the rest as JSON
+func CallJSONQuery(arguments map[string]interface{}) (*ToolResult, error) {
+	config := jsonQueryConfig
+	if config == nil {
+		return nil, fmt.Errorf("json_query not configured. Set json_query.enabled in the config file")
+	}
+	var args struct {
+		Query  string      `arg:"query,required"`
+		Syntax string      `arg:"syntax"`
+		JSON   interface{} `arg:"json"`
+		Path   string      `arg:"path"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	expr, syntax := args.Query, args.Syntax
+	if syntax == "" {
+		syntax = "jq"
+		if strings.HasPrefix(strings.TrimSpace(expr), "$") {
+			syntax = "jsonpath"
+		}
+	}
+	var query *jq.Query
+	var err error
+	switch syntax {
+	case "jq":
+		query, err = jq.Compile(expr)
+	case "jsonpath":
+		query, err = jq.CompileJSONPath(expr)
+	default:
+		return nil, fmt.Errorf("syntax must be jq or jsonpath")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+
+	document, err := config.document(args.JSON, args.Path)
+	if err != nil {
+		return nil, err
+	}
+	values, err := query.Run(document)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	if len(values) == 0 {
+		return TextResult("The query matched nothing"), nil
+	}
+	result := jq.Format(values)
+	if total := utf8.RuneCountInString(result); total > config.MaxLength {
+		result = string([]rune(result)[:config.MaxLength]) +
+			fmt.Sprintf("\n\n[Output truncated at %d of %d characters; narrow the query]", config.MaxLength, total)
+	}
+	return TextResult(result), nil
+}
+
+// document returns the decoded JSON passed in the json argument or read
+// from the file of the path argument
+func (c *JSONQueryConfig) document(value interface{}, path string) (interface{}, error) {
+	present := value != nil
+	var data []byte
+	switch {
+	case present && path != "":
+		return nil, fmt.Errorf("pass either json or path, not both")
+	case present:
+		s, ok := value.(string)
+		if !ok {
+			return value, nil // Already decoded by the client
+		}
+		if int64(len(s)) > c.MaxInputBytes {
+			return nil, fmt.Errorf("json exceeds the limit of %d bytes", c.MaxInputBytes)
+		}
+		data = []byte(s)
+	case path != "":
+		if len(c.AllowedPaths) == 0 {
+			return nil, fmt.Errorf("reading files is disabled; pass the document as json or set json_query.allowed_paths")
+		}
+		resolved, err := resolveUnder(path, c.AllowedPaths)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(resolved)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		if info.Size() > c.MaxInputBytes {
+			return nil, fmt.Errorf("file of %d bytes exceeds the limit of %d bytes", info.Size(), c.MaxInputBytes)
+		}
+		if data, err = os.ReadFile(resolved); err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("json or path argument is required")
+	}
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	return document, nil
+}
@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCallJSONQuery(t *testing.T) {
+	dir := t.TempDir()
+	doc := `{"items":[{"id":1,"status":"ok"},{"id":2,"status":"failed"},{"id":3,"status":"failed"}]}`
+	os.WriteFile(filepath.Join(dir, "runs.json"), []byte(doc), 0o644)
+	if err := SetJSONQueryConfig(JSONQueryConfig{AllowedPaths: []string{dir}, MaxLength: 40}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { jsonQueryConfig = nil }()
+
+	tests := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"query": `.items[] | select(.status == "failed") | .id`, "json": doc}, "2\n3"},
+		{map[string]interface{}{"query": `$.items[?(@.status == 'failed')].id`, "path": filepath.Join(dir, "runs.json")}, "2\n3"},
+		{map[string]interface{}{"query": "$.items[0].status", "json": map[string]interface{}{"items": []interface{}{map[string]interface{}{"status": "ok"}}}}, "ok"},
+		{map[string]interface{}{"query": "$.missing", "json": doc}, "The query matched nothing"},
+		{map[string]interface{}{"query": ".items", "json": doc}, `[{"id":1,"status":"ok"},{"id":2,"status"`},
+	}
+	for _, tt := range tests {
+		result, err := resultText(CallJSONQuery(tt.args))
+		if err != nil || !strings.HasPrefix(result, tt.want) {
+			t.Errorf("json_query %v = %q, %v, want %q", tt.args["query"], result, err, tt.want)
+		}
+	}
+	if result, _ := resultText(CallJSONQuery(map[string]interface{}{"query": ".items", "json": doc})); !strings.Contains(result, "[Output truncated at 40 of 78 characters") {
+		t.Errorf("Expected the output to be truncated, got %q", result)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"query": ".items[", "json": doc},
+		{"query": ".", "json": "{not json"},
+		{"query": ".", "path": filepath.Join(dir, "..", "runs.json")},
+		{"query": ".", "json": doc, "syntax": "xpath"},
+		{"query": ".items.id", "json": doc},
+		{"query": "."},
+	} {
+		if _, err := CallJSONQuery(args); err == nil {
+			t.Errorf("Expected %v to be rejected", args)
+		}
+	}
+
+	if err := SetJSONQueryConfig(JSONQueryConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CallJSONQuery(map[string]interface{}{"query": ".", "path": filepath.Join(dir, "runs.json")}); err == nil || !strings.Contains(err.Error(), "json_query.allowed_paths") {
+		t.Errorf("Expected reading files to be disabled without allowed paths, got %v", err)
+	}
+}
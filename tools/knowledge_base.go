@@ -0,0 +1,467 @@
+package tools
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for the knowledge base when the configuration leaves them unset
+const (
+	DefaultKBChunkSize = 1000 // Characters per chunk
+	DefaultKBTopK      = 5
+)
+
+// KnowledgeBaseTool represents a knowledge base tool definition
+type KnowledgeBaseTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// GetKnowledgeBaseTools returns the definitions of the knowledge base tools
+func GetKnowledgeBaseTools() []KnowledgeBaseTool {
+	return []KnowledgeBaseTool{
+		{
+			Name:        "kb_add_document",
+			Description: "Add a document to the knowledge base so kb_search can find it. The text is split into passages that are embedded and indexed; adding a document with an existing id replaces it.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"text": map[string]interface{}{
+						"type":        "string",
+						"description": "The text of the document",
+					},
+					"id": map[string]interface{}{
+						"type":        "string",
+						"description": "Identifier of the document (default: the source, or a hash of the text)",
+					},
+					"title": map[string]interface{}{
+						"type":        "string",
+						"description": "Title of the document",
+					},
+					"source": map[string]interface{}{
+						"type":        "string",
+						"description": "Where the document comes from, e.g. a URL or file path",
+					},
+					"metadata": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+						"description":          "Metadata that kb_search can filter on",
+					},
+				},
+				"required": []string{"text"},
+			},
+		},
+		{
+			Name:        "kb_search",
+			Description: "Search the knowledge base for the passages closest in meaning to a query",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "What to look for, in natural language",
+					},
+					"top_k": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of passages to return (1-50, default: 5)",
+						"default":     DefaultKBTopK,
+						"minimum":     1,
+						"maximum":     50,
+					},
+					"min_score": map[string]interface{}{
+						"type":        "number",
+						"description": "Only return passages with at least this cosine similarity (-1 to 1, default: no minimum)",
+					},
+					"filter": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+						"description":          "Only search documents whose metadata has all of these values",
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+	}
+}
+
+// IsKnowledgeBaseTool reports whether name is one of the knowledge base tools
+func IsKnowledgeBaseTool(name string) bool {
+	for _, tool := range GetKnowledgeBaseTools() {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// kbDocument describes an indexed document
+type kbDocument struct {
+	Title    string            `json:"title,omitempty"`
+	Source   string            `json:"source,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Added    time.Time         `json:"added"`
+}
+
+// kbChunk is an indexed passage of a document. Vectors are normalized, so
+// the dot product of two is their cosine similarity.
+type kbChunk struct {
+	Document string    `json:"document"`
+	Text     string    `json:"text"`
+	Vector   []float32 `json:"vector"`
+}
+
+// kbIndex is the vector index, kept in memory and saved as JSON
+type kbIndex struct {
+	// Dimensions of the vectors; an index cannot mix embedding models
+	Dimensions int                   `json:"dimensions"`
+	Documents  map[string]kbDocument `json:"documents"`
+	Chunks     []kbChunk             `json:"chunks"`
+}
+
+// KnowledgeBaseConfig holds the configuration for the knowledge base tools
+type KnowledgeBaseConfig struct {
+	Embedder Embedder
+	// IndexPath is the file the index is saved to ("" = memory only)
+	IndexPath    string
+	ChunkSize    int
+	ChunkOverlap int
+}
+
+var (
+	kbConfig    *KnowledgeBaseConfig
+	kbMu        sync.RWMutex // Guards kbIndexData
+	kbIndexData kbIndex
+)
+
+// SetKnowledgeBaseConfig enables the knowledge base tools and loads the
+// index saved at indexPath, if any. A zero chunk size selects the default and
+// a zero overlap a tenth of the chunk size.
+func SetKnowledgeBaseConfig(embedder Embedder, indexPath string, chunkSize, chunkOverlap int) error {
+	if chunkSize <= 0 {
+		chunkSize = DefaultKBChunkSize
+	}
+	if chunkOverlap <= 0 {
+		chunkOverlap = chunkSize / 10
+	}
+	if chunkOverlap >= chunkSize {
+		return fmt.Errorf("chunk overlap %d must be smaller than the chunk size %d", chunkOverlap, chunkSize)
+	}
+
+	index := kbIndex{Documents: make(map[string]kbDocument)}
+	if indexPath != "" {
+		data, err := os.ReadFile(indexPath)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read knowledge base index: %w", err)
+		}
+		if err == nil {
+			if err := json.Unmarshal(data, &index); err != nil {
+				return fmt.Errorf("invalid knowledge base index %s: %w", indexPath, err)
+			}
+			if index.Documents == nil {
+				index.Documents = make(map[string]kbDocument)
+			}
+		}
+	}
+
+	kbMu.Lock()
+	defer kbMu.Unlock()
+	kbConfig = &KnowledgeBaseConfig{Embedder: embedder, IndexPath: indexPath, ChunkSize: chunkSize, ChunkOverlap: chunkOverlap}
+	kbIndexData = index
+	return nil
+}
+
+// GetKnowledgeBaseConfig returns the current configuration, nil when the
+// tools are disabled
+func GetKnowledgeBaseConfig() *KnowledgeBaseConfig {
+	return kbConfig
+}
+
+// CallKnowledgeBaseTool executes the named knowledge base tool
+func CallKnowledgeBaseTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+	config := kbConfig
+	if config == nil {
+		return nil, fmt.Errorf("knowledge base not configured. Set knowledge_base.enabled and an embedding endpoint in the config file")
+	}
+
+	switch name {
+	case "kb_add_document":
+		return textResult(config.addDocument(ctx, arguments))
+	case "kb_search":
+		return textResult(config.search(ctx, arguments))
+	default:
+		return nil, fmt.Errorf("unknown knowledge base tool: %s", name)
+	}
+}
+
+// addDocument chunks, embeds and indexes a document, then saves the index
+func (c *KnowledgeBaseConfig) addDocument(ctx context.Context, arguments map[string]interface{}) (string, error) {
+	var args struct {
+		Text     string            `arg:"text,required"`
+		Title    string            `arg:"title"`
+		Source   string            `arg:"source"`
+		Metadata map[string]string `arg:"metadata"`
+		ID       string            `arg:"id"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return "", err
+	}
+	text := args.Text
+	doc := kbDocument{Added: time.Now().UTC(), Title: args.Title, Source: args.Source, Metadata: args.Metadata}
+
+	id := args.ID
+	if id == "" {
+		id = doc.Source
+	}
+	if id == "" {
+		sum := sha256.Sum256([]byte(text))
+		id = "doc-" + hex.EncodeToString(sum[:6])
+	}
+
+	// Embed outside the lock: it is slow and needs no index state
+	passages := chunkText(text, c.ChunkSize, c.ChunkOverlap)
+	vectors, err := c.Embedder.Embed(ctx, passages)
+	if err != nil {
+		return "", err
+	}
+	if len(vectors) != len(passages) {
+		return "", fmt.Errorf("embedder returned %d vectors for %d passages", len(vectors), len(passages))
+	}
+
+	kbMu.Lock()
+	defer kbMu.Unlock()
+
+	dimensions := kbIndexData.Dimensions
+	chunks := make([]kbChunk, len(passages))
+	for i, passage := range passages {
+		if dimensions == 0 {
+			dimensions = len(vectors[i])
+		}
+		if len(vectors[i]) != dimensions {
+			return "", fmt.Errorf("embedding has %d dimensions but the index uses %d; was the embedding model changed?", len(vectors[i]), dimensions)
+		}
+		chunks[i] = kbChunk{Document: id, Text: passage, Vector: normalizeVector(vectors[i])}
+	}
+
+	_, replaced := kbIndexData.Documents[id]
+	updated := kbIndex{
+		Dimensions: dimensions,
+		Documents:  make(map[string]kbDocument, len(kbIndexData.Documents)+1),
+		Chunks:     make([]kbChunk, 0, len(kbIndexData.Chunks)+len(chunks)),
+	}
+	for docID, existing := range kbIndexData.Documents {
+		updated.Documents[docID] = existing
+	}
+	updated.Documents[id] = doc
+	for _, chunk := range kbIndexData.Chunks {
+		if chunk.Document != id {
+			updated.Chunks = append(updated.Chunks, chunk)
+		}
+	}
+	updated.Chunks = append(updated.Chunks, chunks...)
+
+	if err := c.save(&updated); err != nil {
+		return "", err
+	}
+	kbIndexData = updated
+
+	verb := "Added"
+	if replaced {
+		verb = "Replaced"
+	}
+	return fmt.Sprintf("%s document %q as %d passages (%d documents in the knowledge base)", verb, id, len(chunks), len(updated.Documents)), nil
+}
+
+// save writes the index to the index file, if one is configured
+func (c *KnowledgeBaseConfig) save(index *kbIndex) error {
+	if c.IndexPath == "" {
+		return nil
+	}
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.IndexPath), 0755); err != nil {
+		return fmt.Errorf("failed to save knowledge base index: %w", err)
+	}
+	if err := writeFileAtomic(c.IndexPath, data); err != nil {
+		return fmt.Errorf("failed to save knowledge base index: %w", err)
+	}
+	return nil
+}
+
+// kbMatch is a passage found by a search
+type kbMatch struct {
+	chunk *kbChunk
+	score float64
+}
+
+// search returns the passages most similar to the query
+func (c *KnowledgeBaseConfig) search(ctx context.Context, arguments map[string]interface{}) (string, error) {
+	args := struct {
+		Query    string            `arg:"query,required"`
+		TopK     int               `arg:"top_k"`
+		MinScore float64           `arg:"min_score"`
+		Filter   map[string]string `arg:"filter"`
+	}{TopK: DefaultKBTopK, MinScore: math.Inf(-1)}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return "", err
+	}
+	query, topK, minScore, filter := args.Query, args.TopK, args.MinScore, args.Filter
+	if topK < 1 || topK > 50 {
+		topK = DefaultKBTopK
+	}
+
+	kbMu.RLock()
+	empty := len(kbIndexData.Chunks) == 0
+	kbMu.RUnlock()
+	if empty {
+		return "The knowledge base is empty. Add documents with kb_add_document first.", nil
+	}
+
+	vectors, err := c.Embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return "", err
+	}
+	if len(vectors) != 1 {
+		return "", fmt.Errorf("embedder returned %d vectors for the query", len(vectors))
+	}
+	queryVector := normalizeVector(vectors[0])
+
+	kbMu.RLock()
+	defer kbMu.RUnlock()
+
+	if len(queryVector) != kbIndexData.Dimensions {
+		return "", fmt.Errorf("query embedding has %d dimensions but the index uses %d; was the embedding model changed?", len(queryVector), kbIndexData.Dimensions)
+	}
+
+	var matches []kbMatch
+	for i := range kbIndexData.Chunks {
+		chunk := &kbIndexData.Chunks[i]
+		if !metadataMatches(kbIndexData.Documents[chunk.Document].Metadata, filter) {
+			continue
+		}
+		var score float64
+		for j, v := range chunk.Vector {
+			score += float64(v) * float64(queryVector[j])
+		}
+		if score >= minScore {
+			matches = append(matches, kbMatch{chunk: chunk, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	if len(matches) > topK {
+		matches = matches[:topK]
+	}
+
+	if len(matches) == 0 {
+		return "No matching passages found in the knowledge base.", nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d matching passages:\n\n", len(matches))
+	for i, match := range matches {
+		doc := kbIndexData.Documents[match.chunk.Document]
+		label := match.chunk.Document
+		if doc.Title != "" {
+			label = fmt.Sprintf("%s (%s)", doc.Title, match.chunk.Document)
+		}
+		fmt.Fprintf(&b, "%d. %s [score %.3f]\n", i+1, label, match.score)
+		if doc.Source != "" {
+			fmt.Fprintf(&b, "   Source: %s\n", doc.Source)
+		}
+		fmt.Fprintf(&b, "   %s\n\n", strings.ReplaceAll(match.chunk.Text, "\n", "\n   "))
+	}
+	return b.String(), nil
+}
+
+// metadataMatches reports whether metadata has every value of filter
+func metadataMatches(metadata, filter map[string]string) bool {
+	for name, value := range filter {
+		if metadata[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeVector returns v scaled to unit length
+func normalizeVector(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	norm = math.Sqrt(norm)
+	normalized := make([]float32, len(v))
+	if norm == 0 {
+		return normalized
+	}
+	for i, x := range v {
+		normalized[i] = float32(float64(x) / norm)
+	}
+	return normalized
+}
+
+// chunkText splits text into passages of at most size characters, each
+// starting overlap characters before the previous one ended. Passages end at
+// a paragraph break, line break, sentence end or space where possible.
+func chunkText(text string, size, overlap int) []string {
+	runes := []rune(strings.TrimSpace(text))
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := start + size
+		if end >= len(runes) {
+			end = len(runes)
+		} else {
+			end = chunkBreak(runes, start+size/2, end)
+		}
+		if chunk := strings.TrimSpace(string(runes[start:end])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(runes) {
+			break
+		}
+
+		// Start the next passage at a word boundary inside the overlap, but
+		// never repeat text from before a paragraph break
+		next := end - overlap
+		if next <= start {
+			next = end
+		}
+		if strings.Contains(string(runes[next:end]), "\n\n") {
+			next = end
+		}
+		for i := next; i < end; i++ {
+			if runes[i] == ' ' || runes[i] == '\n' {
+				next = i + 1
+				break
+			}
+		}
+		start = next
+	}
+	return chunks
+}
+
+// chunkBreak returns where to end a passage that may end anywhere between
+// min and max: after the last paragraph break, line break, sentence end or
+// space in that range, in that order of preference, or at max
+func chunkBreak(runes []rune, min, max int) int {
+	for _, separator := range []string{"\n\n", "\n", ". ", " "} {
+		sep := []rune(separator)
+		for i := max - len(sep); i >= min; i-- {
+			if string(runes[i:i+len(sep)]) == separator {
+				return i + len(sep)
+			}
+		}
+	}
+	return max
+}
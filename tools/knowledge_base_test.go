@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// letterEmbedder embeds texts as their letter counts, so texts sharing words
+// are similar
+type letterEmbedder struct{}
+
+func (letterEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = make([]float32, 26)
+		for _, r := range strings.ToLower(text) {
+			if r >= 'a' && r <= 'z' {
+				vectors[i][r-'a']++
+			}
+		}
+	}
+	return vectors, nil
+}
+
+func TestKnowledgeBase(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), "kb", "index.json")
+	if err := SetKnowledgeBaseConfig(letterEmbedder{}, indexPath, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { kbConfig = nil }()
+	ctx := context.Background()
+
+	for _, doc := range []map[string]interface{}{
+		{"id": "zoo", "text": "Zebras and buzzing wasps at the zoo", "metadata": map[string]interface{}{"kind": "animals"}},
+		{"id": "kitchen", "text": "Cook the rice, then season it", "title": "Recipes"},
+	} {
+		if _, err := CallKnowledgeBaseTool(ctx, "kb_add_document", doc); err != nil {
+			t.Fatalf("kb_add_document failed: %v", err)
+		}
+	}
+
+	// Reload the index from disk to check it was saved
+	if err := SetKnowledgeBaseConfig(letterEmbedder{}, indexPath, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	result, err := resultText(CallKnowledgeBaseTool(ctx, "kb_search", map[string]interface{}{"query": "zebra zoo", "top_k": float64(1)}))
+	if err != nil {
+		t.Fatalf("kb_search failed: %v", err)
+	}
+	if !strings.Contains(result, "1. zoo [score") || strings.Contains(result, "kitchen") {
+		t.Errorf("Unexpected search result:\n%s", result)
+	}
+
+	result, err = resultText(CallKnowledgeBaseTool(ctx, "kb_search", map[string]interface{}{"query": "zebra zoo", "filter": map[string]interface{}{"kind": "food"}}))
+	if err != nil {
+		t.Fatalf("kb_search failed: %v", err)
+	}
+	if result != "No matching passages found in the knowledge base." {
+		t.Errorf("Expected the filter to exclude every document, got:\n%s", result)
+	}
+
+	result, err = resultText(CallKnowledgeBaseTool(ctx, "kb_add_document", map[string]interface{}{"id": "zoo", "text": "Lions"}))
+	if err != nil || !strings.HasPrefix(result, `Replaced document "zoo" as 1 passages (2 documents`) {
+		t.Errorf("Unexpected result replacing a document: %q, %v", result, err)
+	}
+}
+
+func TestChunkText(t *testing.T) {
+	text := "First paragraph here.\n\nSecond paragraph is a little longer. It has two sentences."
+	chunks := chunkText(text, 40, 10)
+	want := []string{"First paragraph here.", "Second paragraph is a little longer.", "longer. It has two sentences."}
+	if strings.Join(chunks, "|") != strings.Join(want, "|") {
+		t.Errorf("chunkText = %q, want %q", chunks, want)
+	}
+	for _, chunk := range chunkText(strings.Repeat("x", 95), 40, 10) {
+		if len(chunk) > 40 {
+			t.Errorf("Chunk of %d characters exceeds the size", len(chunk))
+		}
+	}
+}
+
+func TestOpenAIEmbedder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if r.URL.Path != "/v1/embeddings" || r.Header.Get("Authorization") != "Bearer secret" || json.NewDecoder(r.Body).Decode(&req) != nil || req.Model != "test-model" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		// Answer in reverse order, which the index field allows
+		w.Write([]byte(`{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}]}`))
+	}))
+	defer server.Close()
+
+	embedder := &OpenAIEmbedder{URL: server.URL + "/v1/", APIKey: "secret", Model: "test-model"}
+	vectors, err := embedder.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+	if len(vectors) != 2 || vectors[0][0] != 1 || vectors[1][1] != 1 {
+		t.Errorf("Unexpected vectors: %v", vectors)
+	}
+}
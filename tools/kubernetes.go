@@ -0,0 +1,1039 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for the Kubernetes tools when the configuration leaves them unset
+const (
+	DefaultKubernetesTimeout   = 30 * time.Second
+	DefaultKubernetesMaxOutput = 1 << 20
+)
+
+// inClusterDir holds the service account credentials mounted into pods
+const inClusterDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// KubernetesTool represents a Kubernetes tool definition
+type KubernetesTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// Schemas of the arguments shared by the Kubernetes tools
+var (
+	k8sResourceProperty = map[string]interface{}{
+		"type":        "string",
+		"description": "Resource type as kubectl accepts it, e.g. \"pods\", \"deploy\", \"ingresses.networking.k8s.io\" or a custom resource",
+	}
+	k8sNameProperty = map[string]interface{}{
+		"type":        "string",
+		"description": "Name of the object",
+	}
+	k8sNamespaceProperty = map[string]interface{}{
+		"type":        "string",
+		"description": "Namespace (default: the configured default namespace)",
+	}
+)
+
+// GetKubernetesTools returns the definitions of the Kubernetes tools.
+// k8s_apply is only enabled by its configuration flag; see
+// KubernetesToolEnabled.
+func GetKubernetesTools() []KubernetesTool {
+	return []KubernetesTool{
+		{
+			Name:        "k8s_list",
+			Description: "List Kubernetes objects of a resource type, with the columns kubectl get shows",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"resource":  k8sResourceProperty,
+					"namespace": k8sNamespaceProperty,
+					"all_namespaces": map[string]interface{}{
+						"type":        "boolean",
+						"description": "List the objects of every namespace (default: false)",
+					},
+					"label_selector": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list objects matching this label selector, e.g. \"app=web,tier!=cache\"",
+					},
+					"field_selector": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list objects matching this field selector, e.g. \"status.phase=Running\"",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of objects (default: 100)",
+						"default":     100,
+						"minimum":     1,
+					},
+					"continue": map[string]interface{}{
+						"type":        "string",
+						"description": "Token from a previous truncated listing to continue from",
+					},
+				},
+				"required": []string{"resource"},
+			},
+		},
+		{
+			Name:        "k8s_get",
+			Description: "Get a Kubernetes object as JSON. Values of Secrets are redacted.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"resource":  k8sResourceProperty,
+					"name":      k8sNameProperty,
+					"namespace": k8sNamespaceProperty,
+				},
+				"required": []string{"resource", "name"},
+			},
+		},
+		{
+			Name:        "k8s_describe",
+			Description: "Describe a Kubernetes object: its metadata, spec and status, and the recent events about it",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"resource":  k8sResourceProperty,
+					"name":      k8sNameProperty,
+					"namespace": k8sNamespaceProperty,
+				},
+				"required": []string{"resource", "name"},
+			},
+		},
+		{
+			Name:        "k8s_logs",
+			Description: "Show the logs of a container of a Kubernetes pod",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"pod": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the pod",
+					},
+					"namespace": k8sNamespaceProperty,
+					"container": map[string]interface{}{
+						"type":        "string",
+						"description": "Container of the pod (optional when it has a single container)",
+					},
+					"tail_lines": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of lines from the end of the logs (default: 100)",
+						"default":     100,
+						"minimum":     1,
+					},
+					"since_seconds": map[string]interface{}{
+						"type":        "integer",
+						"description": "Only show logs from the last this many seconds",
+						"minimum":     1,
+					},
+					"previous": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Show the logs of the previous, crashed instance of the container (default: false)",
+					},
+				},
+				"required": []string{"pod"},
+			},
+		},
+		{
+			Name:        "k8s_apply",
+			Description: "Create or update a Kubernetes object with server-side apply, like kubectl apply --server-side",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"manifest": map[string]interface{}{
+						"type":        "object",
+						"description": "The object as JSON, with apiVersion, kind and metadata.name",
+					},
+					"namespace": k8sNamespaceProperty,
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Validate the change on the server without persisting it (default: false)",
+					},
+					"force": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Take ownership of fields managed by other clients (default: false)",
+					},
+				},
+				"required": []string{"manifest"},
+			},
+		},
+	}
+}
+
+// IsKubernetesTool reports whether name is one of the Kubernetes tools
+func IsKubernetesTool(name string) bool {
+	for _, tool := range GetKubernetesTools() {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// KubernetesCluster is the API server and credentials the tools use
+type KubernetesCluster struct {
+	Server   string
+	CAData   []byte // PEM certificates of the server's CA (nil = system roots)
+	Insecure bool   // Skip verifying the server certificate
+	Token    string
+	// TokenFile is read for every request, so rotated service account
+	// tokens are picked up
+	TokenFile      string
+	ClientCertData []byte
+	ClientKeyData  []byte
+	// ExecCommand is a kubeconfig credential plugin printing an
+	// ExecCredential with a token
+	ExecCommand string
+	ExecArgs    []string
+	ExecEnv     []string
+}
+
+// InClusterKubernetes returns the cluster a pod runs in, authenticated with
+// its service account, and the namespace of the pod
+func InClusterKubernetes() (*KubernetesCluster, string, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, "", fmt.Errorf("not running in a Kubernetes pod: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are unset")
+	}
+	ca, err := os.ReadFile(inClusterDir + "/ca.crt")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read the service account CA: %w", err)
+	}
+	namespace, _ := os.ReadFile(inClusterDir + "/namespace")
+	cluster := &KubernetesCluster{
+		Server:    "https://" + net.JoinHostPort(host, port),
+		CAData:    ca,
+		TokenFile: inClusterDir + "/token",
+	}
+	return cluster, strings.TrimSpace(string(namespace)), nil
+}
+
+// KubernetesConfig holds the configuration for the Kubernetes tools
+type KubernetesConfig struct {
+	Cluster KubernetesCluster
+	// Namespaces the tools may use; empty allows every namespace and
+	// cluster-scoped resources
+	Namespaces       []string
+	DefaultNamespace string
+	AllowApply       bool
+	Timeout          time.Duration
+	MaxOutputBytes   int64
+
+	client *http.Client
+
+	mu         sync.Mutex
+	resources  []k8sResource // Discovered on first use
+	execToken  string
+	execExpiry time.Time
+}
+
+var kubernetesConfig *KubernetesConfig
+
+// SetKubernetesConfig enables the Kubernetes tools. The default namespace
+// falls back to the first allowed namespace, then "default"; a zero timeout
+// or output limit selects the default.
+func SetKubernetesConfig(cluster KubernetesCluster, namespaces []string, defaultNamespace string, allowApply bool, timeout time.Duration, maxOutputBytes int64) error {
+	if timeout <= 0 {
+		timeout = DefaultKubernetesTimeout
+	}
+	if maxOutputBytes <= 0 {
+		maxOutputBytes = DefaultKubernetesMaxOutput
+	}
+	if defaultNamespace == "" && len(namespaces) > 0 {
+		defaultNamespace = namespaces[0]
+	}
+	if defaultNamespace == "" {
+		defaultNamespace = "default"
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cluster.Insecure}
+	if len(cluster.CAData) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(cluster.CAData) {
+			return fmt.Errorf("invalid certificate authority data")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if len(cluster.ClientCertData) > 0 {
+		cert, err := tls.X509KeyPair(cluster.ClientCertData, cluster.ClientKeyData)
+		if err != nil {
+			return fmt.Errorf("invalid client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	config := &KubernetesConfig{
+		Cluster:          cluster,
+		Namespaces:       namespaces,
+		DefaultNamespace: defaultNamespace,
+		AllowApply:       allowApply,
+		Timeout:          timeout,
+		MaxOutputBytes:   maxOutputBytes,
+		client:           &http.Client{Transport: transport},
+	}
+	if err := config.checkNamespace(defaultNamespace); err != nil {
+		return fmt.Errorf("default namespace: %w", err)
+	}
+	kubernetesConfig = config
+	return nil
+}
+
+// GetKubernetesConfig returns the current configuration, nil when the tools
+// are disabled
+func GetKubernetesConfig() *KubernetesConfig {
+	return kubernetesConfig
+}
+
+// KubernetesToolEnabled reports whether the named Kubernetes tool is
+// enabled: k8s_apply needs its configuration flag
+func KubernetesToolEnabled(name string) bool {
+	config := kubernetesConfig
+	if config == nil || !IsKubernetesTool(name) {
+		return false
+	}
+	return name != "k8s_apply" || config.AllowApply
+}
+
+// CallKubernetesTool executes the named Kubernetes tool
+func CallKubernetesTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+	config := kubernetesConfig
+	if config == nil {
+		return nil, fmt.Errorf("Kubernetes tools not configured. Set kubernetes.enabled in the config file")
+	}
+	if !KubernetesToolEnabled(name) {
+		return nil, fmt.Errorf("%s is disabled; enable it with kubernetes.allow_apply", name)
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	switch name {
+	case "k8s_list":
+		return textResult(config.list(ctx, arguments))
+	case "k8s_get", "k8s_describe":
+		var args struct {
+			Resource  string `arg:"resource,required"`
+			Namespace string `arg:"namespace"`
+			Name      string `arg:"name,required"`
+		}
+		if err := DecodeArgs(arguments, &args); err != nil {
+			return nil, err
+		}
+		resource, namespace, err := config.target(ctx, args.Resource, args.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		body, err := config.do(ctx, "GET", resource.path(namespace, args.Name), nil, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		var object map[string]interface{}
+		if err := json.Unmarshal(body, &object); err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		cleanK8sObject(object)
+		if name == "k8s_describe" {
+			return textResult(config.describe(ctx, object))
+		}
+		return TextResult(config.limit(k8sJSON(object))), nil
+	case "k8s_logs":
+		return textResult(config.logs(ctx, arguments))
+	case "k8s_apply":
+		return textResult(config.apply(ctx, arguments))
+	default:
+		return nil, fmt.Errorf("unknown Kubernetes tool: %s", name)
+	}
+}
+
+// checkNamespace rejects namespaces outside the allowlist
+func (c *KubernetesConfig) checkNamespace(namespace string) error {
+	if len(c.Namespaces) == 0 {
+		return nil
+	}
+	for _, allowed := range c.Namespaces {
+		if namespace == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("namespace %q is not allowed, allowed: %s", namespace, strings.Join(c.Namespaces, ", "))
+}
+
+// target resolves the resource and namespace arguments of a call. Namespace
+// is empty for cluster-scoped resources.
+func (c *KubernetesConfig) target(ctx context.Context, name, namespace string) (k8sResource, string, error) {
+	resource, err := c.resource(ctx, name)
+	if err != nil {
+		return k8sResource{}, "", err
+	}
+	namespace, err = c.namespace(resource, namespace)
+	return resource, namespace, err
+}
+
+// namespace returns the namespace argument of a call on resource, checked
+// against the allowlist
+func (c *KubernetesConfig) namespace(resource k8sResource, namespace string) (string, error) {
+	if !resource.Namespaced {
+		if len(c.Namespaces) > 0 {
+			return "", fmt.Errorf("%s are cluster-scoped, which kubernetes.namespaces does not allow", resource.Name)
+		}
+		return "", nil
+	}
+	if namespace == "" {
+		namespace = c.DefaultNamespace
+	}
+	return namespace, c.checkNamespace(namespace)
+}
+
+// list lists the objects of a resource as the table kubectl get prints
+func (c *KubernetesConfig) list(ctx context.Context, arguments map[string]interface{}) (string, error) {
+	args := struct {
+		Resource      string `arg:"resource,required"`
+		Namespace     string `arg:"namespace"`
+		AllNamespaces bool   `arg:"all_namespaces"`
+		Limit         int    `arg:"limit"`
+		LabelSelector string `arg:"label_selector"`
+		FieldSelector string `arg:"field_selector"`
+		Continue      string `arg:"continue"`
+	}{Limit: 100}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return "", err
+	}
+	resource, err := c.resource(ctx, args.Resource)
+	if err != nil {
+		return "", err
+	}
+	namespace := ""
+	if !args.AllNamespaces || !resource.Namespaced {
+		if namespace, err = c.namespace(resource, args.Namespace); err != nil {
+			return "", err
+		}
+	} else if len(c.Namespaces) > 0 {
+		return "", fmt.Errorf("all_namespaces is not allowed when kubernetes.namespaces restricts the namespaces")
+	}
+
+	query := url.Values{}
+	if args.Limit > 0 {
+		query.Set("limit", strconv.Itoa(args.Limit))
+	}
+	for parameter, value := range map[string]string{"labelSelector": args.LabelSelector, "fieldSelector": args.FieldSelector, "continue": args.Continue} {
+		if value != "" {
+			query.Set(parameter, value)
+		}
+	}
+	query.Set("includeObject", "Metadata")
+
+	// Ask for the server-side table, which has the columns of kubectl get
+	body, err := c.do(ctx, "GET", resource.path(namespace, ""), query, "application/json;as=Table;v=v1;g=meta.k8s.io, application/json", nil)
+	if err != nil {
+		return "", err
+	}
+	var table struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Continue string `json:"continue"`
+		} `json:"metadata"`
+		ColumnDefinitions []struct {
+			Name     string `json:"name"`
+			Priority int    `json:"priority"`
+		} `json:"columnDefinitions"`
+		Rows []struct {
+			Cells  []interface{} `json:"cells"`
+			Object struct {
+				Metadata struct {
+					Name      string `json:"name"`
+					Namespace string `json:"namespace"`
+				} `json:"metadata"`
+			} `json:"object"`
+		} `json:"rows"`
+		Items []struct {
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &table); err != nil {
+		return "", fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	var b strings.Builder
+	showNamespace := args.AllNamespaces && resource.Namespaced
+	if table.Kind == "Table" {
+		if len(table.Rows) == 0 {
+			return fmt.Sprintf("No %s found.", resource.Name), nil
+		}
+		var columns []int
+		var header []string
+		if showNamespace {
+			header = append(header, "NAMESPACE")
+		}
+		for i, column := range table.ColumnDefinitions {
+			if column.Priority == 0 {
+				columns = append(columns, i)
+				header = append(header, strings.ToUpper(column.Name))
+			}
+		}
+		b.WriteString(strings.Join(header, "\t") + "\n")
+		for _, row := range table.Rows {
+			var cells []string
+			if showNamespace {
+				cells = append(cells, row.Object.Metadata.Namespace)
+			}
+			for _, i := range columns {
+				if i < len(row.Cells) {
+					cells = append(cells, fmt.Sprint(row.Cells[i]))
+				}
+			}
+			b.WriteString(strings.Join(cells, "\t") + "\n")
+		}
+	} else {
+		// Servers without table support return the plain list
+		if len(table.Items) == 0 {
+			return fmt.Sprintf("No %s found.", resource.Name), nil
+		}
+		for _, item := range table.Items {
+			if showNamespace {
+				b.WriteString(item.Metadata.Namespace + "\t")
+			}
+			b.WriteString(item.Metadata.Name + "\n")
+		}
+	}
+	if table.Metadata.Continue != "" {
+		fmt.Fprintf(&b, "\n[More objects; continue with continue %q]\n", table.Metadata.Continue)
+	}
+	return c.limit(b.String()), nil
+}
+
+// describe renders an object with the events about it
+func (c *KubernetesConfig) describe(ctx context.Context, object map[string]interface{}) (string, error) {
+	metadata, _ := object["metadata"].(map[string]interface{})
+	var b strings.Builder
+	for _, field := range []string{"name", "namespace", "uid", "creationTimestamp"} {
+		if value, ok := metadata[field]; ok {
+			fmt.Fprintf(&b, "%s: %v\n", field, value)
+		}
+	}
+	fmt.Fprintf(&b, "kind: %v\napiVersion: %v\n", object["kind"], object["apiVersion"])
+	for _, field := range []string{"labels", "annotations", "ownerReferences"} {
+		if value, ok := metadata[field]; ok {
+			fmt.Fprintf(&b, "%s: %s\n", field, k8sJSON(value))
+		}
+	}
+	// The remaining fields, such as spec and status
+	var fields []string
+	for name := range object {
+		if name != "apiVersion" && name != "kind" && name != "metadata" {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	for _, name := range fields {
+		fmt.Fprintf(&b, "\n%s:\n%s\n", name, k8sJSON(object[name]))
+	}
+
+	uid, _ := metadata["uid"].(string)
+	namespace, _ := metadata["namespace"].(string)
+	b.WriteString("\nEvents:\n")
+	query := url.Values{"fieldSelector": {"involvedObject.uid=" + uid}}
+	eventsPath := "/api/v1/events"
+	if namespace != "" {
+		eventsPath = "/api/v1/namespaces/" + url.PathEscape(namespace) + "/events"
+	}
+	body, err := c.do(ctx, "GET", eventsPath, query, "", nil)
+	if err != nil {
+		fmt.Fprintf(&b, "  (unavailable: %v)\n", err)
+		return c.limit(b.String()), nil
+	}
+	var events struct {
+		Items []struct {
+			Type          string `json:"type"`
+			Reason        string `json:"reason"`
+			Message       string `json:"message"`
+			Count         int    `json:"count"`
+			LastTimestamp string `json:"lastTimestamp"`
+			EventTime     string `json:"eventTime"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &events); err != nil {
+		return "", fmt.Errorf("failed to parse events: %w", err)
+	}
+	if len(events.Items) == 0 {
+		b.WriteString("  <none>\n")
+	}
+	sort.SliceStable(events.Items, func(i, j int) bool {
+		return events.Items[i].LastTimestamp+events.Items[i].EventTime < events.Items[j].LastTimestamp+events.Items[j].EventTime
+	})
+	for _, event := range events.Items {
+		when := event.LastTimestamp
+		if when == "" {
+			when = event.EventTime
+		}
+		fmt.Fprintf(&b, "  %s\t%s\t%s (x%d)\t%s\n", when, event.Type, event.Reason, event.Count, event.Message)
+	}
+	return c.limit(b.String()), nil
+}
+
+// logs returns the logs of a pod's container
+func (c *KubernetesConfig) logs(ctx context.Context, arguments map[string]interface{}) (string, error) {
+	args := struct {
+		Pod          string `arg:"pod,required"`
+		Namespace    string `arg:"namespace"`
+		Container    string `arg:"container"`
+		TailLines    int    `arg:"tail_lines"`
+		SinceSeconds int    `arg:"since_seconds"`
+		Previous     bool   `arg:"previous"`
+	}{TailLines: 100}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return "", err
+	}
+	pod := args.Pod
+	namespace, err := c.namespace(k8sResource{Name: "pods", Namespaced: true}, args.Namespace)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	if args.Container != "" {
+		query.Set("container", args.Container)
+	}
+	if args.TailLines > 0 {
+		query.Set("tailLines", strconv.Itoa(args.TailLines))
+	}
+	if args.SinceSeconds > 0 {
+		query.Set("sinceSeconds", strconv.Itoa(args.SinceSeconds))
+	}
+	if args.Previous {
+		query.Set("previous", "true")
+	}
+	path := "/api/v1/namespaces/" + url.PathEscape(namespace) + "/pods/" + url.PathEscape(pod) + "/log"
+	body, err := c.do(ctx, "GET", path, query, "", nil)
+	if err != nil {
+		return "", err
+	}
+	if len(body) == 0 {
+		return "No logs.", nil
+	}
+	return c.limit(string(body)), nil
+}
+
+// apply creates or updates an object with server-side apply
+func (c *KubernetesConfig) apply(ctx context.Context, arguments map[string]interface{}) (string, error) {
+	var args struct {
+		Manifest  map[string]interface{} `arg:"manifest,required"`
+		Namespace string                 `arg:"namespace"`
+		DryRun    bool                   `arg:"dry_run"`
+		Force     bool                   `arg:"force"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return "", err
+	}
+	manifest := args.Manifest
+	var object struct {
+		APIVersion string `arg:"apiVersion"`
+		Kind       string `arg:"kind"`
+		Metadata   struct {
+			Name      string `arg:"name"`
+			Namespace string `arg:"namespace"`
+		} `arg:"metadata"`
+	}
+	if err := DecodeArgs(manifest, &object); err != nil {
+		return "", fmt.Errorf("invalid manifest: %v", err)
+	}
+	apiVersion, kind, name := object.APIVersion, object.Kind, object.Metadata.Name
+	if apiVersion == "" || kind == "" || name == "" {
+		return "", fmt.Errorf("manifest needs apiVersion, kind and metadata.name")
+	}
+
+	resource, err := c.resourceForKind(ctx, apiVersion, kind)
+	if err != nil {
+		return "", err
+	}
+	// The manifest's own namespace takes the place of the argument
+	namespace := args.Namespace
+	if ns := object.Metadata.Namespace; ns != "" && resource.Namespaced {
+		if namespace != "" && namespace != ns {
+			return "", fmt.Errorf("namespace argument %q conflicts with metadata.namespace %q", namespace, ns)
+		}
+		namespace = ns
+	}
+	namespace, err = c.namespace(resource, namespace)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{"fieldManager": {"mcp-gateway"}}
+	if args.DryRun {
+		query.Set("dryRun", "All")
+	}
+	if args.Force {
+		query.Set("force", "true")
+	}
+	// JSON is valid YAML, so the manifest can be sent as an apply patch
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+	result, err := c.do(ctx, "PATCH", resource.path(namespace, name), query, "", &k8sBody{contentType: "application/apply-patch+yaml", data: body})
+	if err != nil {
+		return "", err
+	}
+	var applied struct {
+		Metadata struct {
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+	}
+	json.Unmarshal(result, &applied)
+
+	target := resource.Name + "/" + name
+	if namespace != "" {
+		target = namespace + "/" + target
+	}
+	if args.DryRun {
+		return fmt.Sprintf("Dry run: %s would be applied", target), nil
+	}
+	return fmt.Sprintf("Applied %s (resource version %s)", target, applied.Metadata.ResourceVersion), nil
+}
+
+// k8sBody is the body of a request with its content type
+type k8sBody struct {
+	contentType string
+	data        []byte
+}
+
+// do sends a request to the API server and returns the response body
+func (c *KubernetesConfig) do(ctx context.Context, method, path string, query url.Values, accept string, body *k8sBody) ([]byte, error) {
+	target := strings.TrimRight(c.Cluster.Server, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body.data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", body.contentType)
+	}
+	if accept == "" {
+		accept = "application/json"
+	}
+	req.Header.Set("Accept", accept)
+	token, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the Kubernetes API server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4*c.MaxOutputBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		var status struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &status) == nil && status.Message != "" {
+			return nil, fmt.Errorf("Kubernetes: %s", status.Message)
+		}
+		return nil, fmt.Errorf("Kubernetes API returned status %d", resp.StatusCode)
+	}
+	return data, nil
+}
+
+// token returns the bearer token of the configured credentials
+func (c *KubernetesConfig) token(ctx context.Context) (string, error) {
+	cluster := c.Cluster
+	switch {
+	case cluster.Token != "":
+		return cluster.Token, nil
+	case cluster.TokenFile != "":
+		token, err := os.ReadFile(cluster.TokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read token: %w", err)
+		}
+		return strings.TrimSpace(string(token)), nil
+	case cluster.ExecCommand != "":
+		return c.execCredential(ctx)
+	}
+	return "", nil
+}
+
+// execCredential runs the credential plugin, caching its token until it
+// expires
+func (c *KubernetesConfig) execCredential(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.execToken != "" && time.Now().Before(c.execExpiry) {
+		return c.execToken, nil
+	}
+
+	cmd := exec.CommandContext(ctx, c.Cluster.ExecCommand, c.Cluster.ExecArgs...)
+	cmd.Env = append(os.Environ(), c.Cluster.ExecEnv...)
+	cmd.Env = append(cmd.Env, `KUBERNETES_EXEC_INFO={"apiVersion":"client.authentication.k8s.io/v1beta1","kind":"ExecCredential","spec":{"interactive":false}}`)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("credential plugin %s failed: %v: %s", c.Cluster.ExecCommand, err, strings.TrimSpace(stderr.String()))
+	}
+	var credential struct {
+		Status struct {
+			Token               string `json:"token"`
+			ExpirationTimestamp string `json:"expirationTimestamp"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(output, &credential); err != nil || credential.Status.Token == "" {
+		return "", fmt.Errorf("credential plugin %s printed no token", c.Cluster.ExecCommand)
+	}
+
+	// Tokens without an expiry are refreshed every ten minutes
+	c.execToken = credential.Status.Token
+	c.execExpiry = time.Now().Add(10 * time.Minute)
+	if expiry, err := time.Parse(time.RFC3339, credential.Status.ExpirationTimestamp); err == nil {
+		c.execExpiry = expiry.Add(-30 * time.Second)
+	}
+	return c.execToken, nil
+}
+
+// limit returns output as a string, truncated to the configured size
+func (c *KubernetesConfig) limit(output string) string {
+	if int64(len(output)) > c.MaxOutputBytes {
+		return output[:c.MaxOutputBytes] + fmt.Sprintf("\n[Output truncated after %d bytes]", c.MaxOutputBytes)
+	}
+	return output
+}
+
+// k8sResource is an API resource found by discovery
+type k8sResource struct {
+	Group      string
+	Version    string
+	Name       string // Plural name used in paths, e.g. "deployments"
+	Singular   string
+	Kind       string
+	ShortNames []string
+	Namespaced bool
+}
+
+// path returns the API path of the resource's collection, or of the named
+// object
+func (r k8sResource) path(namespace, name string) string {
+	path := "/api/" + r.Version
+	if r.Group != "" {
+		path = "/apis/" + r.Group + "/" + r.Version
+	}
+	if r.Namespaced && namespace != "" {
+		path += "/namespaces/" + url.PathEscape(namespace)
+	}
+	path += "/" + r.Name
+	if name != "" {
+		path += "/" + url.PathEscape(name)
+	}
+	return path
+}
+
+// resource finds a resource by its plural, singular or short name or kind,
+// optionally qualified with its group as in "deployments.apps"
+func (c *KubernetesConfig) resource(ctx context.Context, name string) (k8sResource, error) {
+	resources, err := c.discover(ctx)
+	if err != nil {
+		return k8sResource{}, err
+	}
+	name = strings.ToLower(name)
+	group := ""
+	if dot := strings.Index(name, "."); dot >= 0 {
+		name, group = name[:dot], name[dot+1:]
+	}
+	for _, r := range resources {
+		if group != "" && r.Group != group {
+			continue
+		}
+		if r.Name == name || r.Singular == name || strings.ToLower(r.Kind) == name {
+			return r, nil
+		}
+		for _, short := range r.ShortNames {
+			if short == name {
+				return r, nil
+			}
+		}
+	}
+	return k8sResource{}, fmt.Errorf("the server has no resource type %q", name)
+}
+
+// resourceForKind finds the resource of an apiVersion and kind, as given in
+// a manifest
+func (c *KubernetesConfig) resourceForKind(ctx context.Context, apiVersion, kind string) (k8sResource, error) {
+	resources, err := c.discover(ctx)
+	if err != nil {
+		return k8sResource{}, err
+	}
+	group, version := "", apiVersion
+	if slash := strings.LastIndex(apiVersion, "/"); slash >= 0 {
+		group, version = apiVersion[:slash], apiVersion[slash+1:]
+	}
+	for _, r := range resources {
+		if r.Group == group && r.Kind == kind {
+			// Discovery lists the preferred version; the manifest's is used
+			r.Version = version
+			return r, nil
+		}
+	}
+	return k8sResource{}, fmt.Errorf("the server has no kind %s in %s", kind, apiVersion)
+}
+
+// discover lists the resources of the API server, preferred versions only,
+// with the core group first. The result is cached.
+func (c *KubernetesConfig) discover(ctx context.Context) ([]k8sResource, error) {
+	c.mu.Lock()
+	resources := c.resources
+	c.mu.Unlock()
+	if resources != nil {
+		return resources, nil
+	}
+
+	body, err := c.do(ctx, "GET", "/apis", nil, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	var groups struct {
+		Groups []struct {
+			PreferredVersion struct {
+				GroupVersion string `json:"groupVersion"`
+			} `json:"preferredVersion"`
+		} `json:"groups"`
+	}
+	if err := json.Unmarshal(body, &groups); err != nil {
+		return nil, fmt.Errorf("failed to parse API groups: %w", err)
+	}
+	paths := []string{"/api/v1"}
+	for _, group := range groups.Groups {
+		paths = append(paths, "/apis/"+group.PreferredVersion.GroupVersion)
+	}
+
+	// Fetch the groups concurrently; a group failing (such as an
+	// unavailable metrics server) only hides its resources
+	lists := make([][]k8sResource, len(paths))
+	errs := make([]error, len(paths))
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			lists[i], errs[i] = c.discoverGroupVersion(ctx, path)
+		}(i, path)
+	}
+	wg.Wait()
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	for _, list := range lists {
+		resources = append(resources, list...)
+	}
+
+	c.mu.Lock()
+	c.resources = resources
+	c.mu.Unlock()
+	return resources, nil
+}
+
+// discoverGroupVersion lists the resources of one group version
+func (c *KubernetesConfig) discoverGroupVersion(ctx context.Context, path string) ([]k8sResource, error) {
+	body, err := c.do(ctx, "GET", path, nil, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		GroupVersion string `json:"groupVersion"`
+		Resources    []struct {
+			Name         string   `json:"name"`
+			SingularName string   `json:"singularName"`
+			Namespaced   bool     `json:"namespaced"`
+			Kind         string   `json:"kind"`
+			ShortNames   []string `json:"shortNames"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse API resources: %w", err)
+	}
+	group, version := "", list.GroupVersion
+	if slash := strings.LastIndex(list.GroupVersion, "/"); slash >= 0 {
+		group, version = list.GroupVersion[:slash], list.GroupVersion[slash+1:]
+	}
+	var resources []k8sResource
+	for _, r := range list.Resources {
+		if strings.Contains(r.Name, "/") {
+			continue // Subresources such as pods/log
+		}
+		singular := r.SingularName
+		if singular == "" {
+			singular = strings.ToLower(r.Kind)
+		}
+		resources = append(resources, k8sResource{
+			Group:      group,
+			Version:    version,
+			Name:       r.Name,
+			Singular:   singular,
+			Kind:       r.Kind,
+			ShortNames: r.ShortNames,
+			Namespaced: r.Namespaced,
+		})
+	}
+	return resources, nil
+}
+
+// cleanK8sObject removes the noise kubectl hides, managed fields and the
+// last applied configuration, and redacts the values of Secrets
+func cleanK8sObject(object map[string]interface{}) {
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "managedFields")
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+		}
+	}
+	if object["kind"] == "Secret" {
+		for _, field := range []string{"data", "stringData"} {
+			if values, ok := object[field].(map[string]interface{}); ok {
+				for key := range values {
+					values[key] = "REDACTED"
+				}
+			}
+		}
+	}
+}
+
+// k8sJSON renders a value as indented JSON
+func k8sJSON(value interface{}) string {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCallKubernetesTool(t *testing.T) {
+	var applied string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, `{"message":"Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /apis":
+			w.Write([]byte(`{"groups":[{"name":"apps","preferredVersion":{"groupVersion":"apps/v1","version":"v1"}}]}`))
+		case "GET /api/v1":
+			w.Write([]byte(`{"groupVersion":"v1","resources":[
+				{"name":"pods","singularName":"pod","namespaced":true,"kind":"Pod","shortNames":["po"]},
+				{"name":"pods/log","singularName":"","namespaced":true,"kind":"Pod"},
+				{"name":"secrets","singularName":"secret","namespaced":true,"kind":"Secret"},
+				{"name":"nodes","singularName":"node","namespaced":false,"kind":"Node","shortNames":["no"]}]}`))
+		case "GET /apis/apps/v1":
+			w.Write([]byte(`{"groupVersion":"apps/v1","resources":[{"name":"deployments","singularName":"deployment","namespaced":true,"kind":"Deployment","shortNames":["deploy"]}]}`))
+		case "GET /api/v1/namespaces/dev/pods":
+			if !strings.Contains(r.Header.Get("Accept"), "as=Table") || r.URL.Query().Get("labelSelector") != "app=web" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"kind":"Table","columnDefinitions":[{"name":"Name","priority":0},{"name":"Status","priority":0},{"name":"IP","priority":1}],
+				"rows":[{"cells":["web-1","Running","10.0.0.1"],"object":{"metadata":{"name":"web-1","namespace":"dev"}}}]}`))
+		case "GET /api/v1/namespaces/dev/secrets/db":
+			w.Write([]byte(`{"kind":"Secret","apiVersion":"v1","metadata":{"name":"db","managedFields":[{}]},"data":{"password":"aHVudGVyMg=="}}`))
+		case "GET /api/v1/namespaces/dev/pods/web-1/log":
+			w.Write([]byte("listening on :8080\n"))
+		case "PATCH /apis/apps/v1/namespaces/dev/deployments/web":
+			if r.Header.Get("Content-Type") != "application/apply-patch+yaml" || r.URL.Query().Get("fieldManager") == "" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			applied = string(body)
+			w.Write([]byte(`{"metadata":{"resourceVersion":"42"}}`))
+		default:
+			http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cluster := KubernetesCluster{Server: server.URL, Token: "token"}
+	if err := SetKubernetesConfig(cluster, []string{"dev"}, "", false, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { kubernetesConfig = nil }()
+	ctx := context.Background()
+
+	result, err := resultText(CallKubernetesTool(ctx, "k8s_list", map[string]interface{}{"resource": "po", "label_selector": "app=web"}))
+	if err != nil || result != "NAME\tSTATUS\nweb-1\tRunning\n" {
+		t.Errorf("k8s_list = %q, %v", result, err)
+	}
+	result, err = resultText(CallKubernetesTool(ctx, "k8s_get", map[string]interface{}{"resource": "secret", "name": "db"}))
+	if err != nil || strings.Contains(result, "aHVudGVyMg") || strings.Contains(result, "managedFields") || !strings.Contains(result, `"password": "REDACTED"`) {
+		t.Errorf("k8s_get = %q, %v", result, err)
+	}
+	result, err = resultText(CallKubernetesTool(ctx, "k8s_logs", map[string]interface{}{"pod": "web-1"}))
+	if err != nil || result != "listening on :8080\n" {
+		t.Errorf("k8s_logs = %q, %v", result, err)
+	}
+
+	// The namespace allowlist
+	if _, err := CallKubernetesTool(ctx, "k8s_list", map[string]interface{}{"resource": "pods", "namespace": "kube-system"}); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("Expected kube-system to be rejected, got %v", err)
+	}
+	if _, err := CallKubernetesTool(ctx, "k8s_list", map[string]interface{}{"resource": "nodes"}); err == nil || !strings.Contains(err.Error(), "cluster-scoped") {
+		t.Errorf("Expected cluster-scoped resources to be rejected, got %v", err)
+	}
+
+	manifest := map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": map[string]interface{}{"name": "web"}}
+	if _, err := CallKubernetesTool(ctx, "k8s_apply", map[string]interface{}{"manifest": manifest}); err == nil {
+		t.Error("Expected k8s_apply to be disabled by default")
+	}
+	kubernetesConfig.AllowApply = true
+	result, err = resultText(CallKubernetesTool(ctx, "k8s_apply", map[string]interface{}{"manifest": manifest}))
+	if err != nil || result != "Applied dev/deployments/web (resource version 42)" || !strings.Contains(applied, `"kind":"Deployment"`) {
+		t.Errorf("k8s_apply = %q, %v (sent %s)", result, err, applied)
+	}
+}
@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits bound the resources of one tool call. Zero fields are not enforced.
+type Limits struct {
+	// Timeout bounds the wall-clock time of a call. A tool that ignores the
+	// cancelled context is abandoned and the call fails.
+	Timeout time.Duration
+	// MaxOutputBytes caps the text and binary content of a result; the rest
+	// is truncated and structured content that large fails the call
+	MaxOutputBytes int
+	// CPUSeconds and MemoryBytes limit the processes that exec-style tools,
+	// such as run_command, git and ocr_image, start (unix only)
+	CPUSeconds  int
+	MemoryBytes int64
+}
+
+// Limit returns middleware enforcing limits on the calls it wraps, meant to
+// be installed per tool with UseFor
+func Limit(limits Limits) Middleware {
+	return func(next CallFunc) CallFunc {
+		return func(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+			ctx = context.WithValue(ctx, limitsKey{}, limits)
+			if limits.Timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+				defer cancel()
+			}
+
+			type outcome struct {
+				result *ToolResult
+				err    error
+			}
+			done := make(chan outcome, 1)
+			go func() {
+				result, err := next(ctx, name, arguments)
+				done <- outcome{result, err}
+			}()
+
+			var result *ToolResult
+			select {
+			case out := <-done:
+				if out.err != nil {
+					return nil, out.err
+				}
+				result = out.result
+			case <-ctx.Done():
+				if ctx.Err() == context.DeadlineExceeded {
+					return nil, fmt.Errorf("tool %s exceeded its time limit of %v", name, limits.Timeout)
+				}
+				return nil, ctx.Err()
+			}
+
+			if limits.MaxOutputBytes > 0 && result != nil {
+				if err := limitOutput(name, result, limits.MaxOutputBytes); err != nil {
+					return nil, err
+				}
+			}
+			return result, nil
+		}
+	}
+}
+
+// LimitTools returns middleware enforcing the limits of each tool by name;
+// the "*" entry applies to the tools without one
+func LimitTools(limits map[string]Limits) Middleware {
+	return func(next CallFunc) CallFunc {
+		wrapped := make(map[string]CallFunc, len(limits))
+		for name, l := range limits {
+			wrapped[name] = Limit(l)(next)
+		}
+		return func(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+			if call, ok := wrapped[name]; ok {
+				return call(ctx, name, arguments)
+			}
+			if call, ok := wrapped["*"]; ok {
+				return call(ctx, name, arguments)
+			}
+			return next(ctx, name, arguments)
+		}
+	}
+}
+
+type limitsKey struct{}
+
+// callLimits returns the limits Limit set on the context of a call
+func callLimits(ctx context.Context) Limits {
+	limits, _ := ctx.Value(limitsKey{}).(Limits)
+	return limits
+}
+
+// limitOutput truncates the content of a result to max bytes, noting how much
+// was dropped
+func limitOutput(name string, result *ToolResult, max int) error {
+	if result.StructuredContent != nil {
+		data, err := json.Marshal(result.StructuredContent)
+		if err != nil {
+			return fmt.Errorf("tool %s returned structured content that cannot be encoded: %v", name, err)
+		}
+		if len(data) > max {
+			return fmt.Errorf("tool %s returned %d bytes of structured content, more than its limit of %d", name, len(data), max)
+		}
+	}
+
+	room := max
+	dropped := 0
+	content := make([]Content, 0, len(result.Content))
+	for _, item := range result.Content {
+		size := len(item.Text) + len(item.Data)
+		if item.Resource != nil {
+			size += len(item.Resource.Text) + len(item.Resource.Blob)
+		}
+		if size <= room {
+			content = append(content, item)
+			room -= size
+			continue
+		}
+		dropped += size - room
+		// Text is cut at a character boundary; other content cannot be cut
+		if item.Type == "text" && room > 0 {
+			cut := room
+			for cut > 0 && !utf8.RuneStart(item.Text[cut]) {
+				cut--
+			}
+			dropped += room - cut
+			item.Text = item.Text[:cut]
+			content = append(content, item)
+		} else {
+			dropped += room
+		}
+		room = 0
+	}
+	if dropped > 0 {
+		content = append(content, TextContent(fmt.Sprintf("[Truncated: %d more bytes of output]", dropped)))
+		result.Content = content
+	}
+	return nil
+}
+
+// limitCommand applies the CPU and memory limits of the call to a command
+// before it starts
+func limitCommand(ctx context.Context, cmd *exec.Cmd) {
+	limits := callLimits(ctx)
+	if limits.CPUSeconds > 0 || limits.MemoryBytes > 0 {
+		setResourceLimits(cmd, limits.CPUSeconds, limits.MemoryBytes)
+	}
+}
@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLimit(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(NewTool(Definition{Name: "stuck"}, func(context.Context, map[string]interface{}) (*ToolResult, error) {
+		time.Sleep(time.Second) // Ignores the context
+		return TextResult("late"), nil
+	}))
+	r.MustRegister(NewTool(Definition{Name: "chatty"}, func(context.Context, map[string]interface{}) (*ToolResult, error) {
+		return &ToolResult{Content: []Content{TextContent("héllo"), ImageContent([]byte("png"), "image/png")}}, nil
+	}))
+	r.MustRegister(NewTool(Definition{Name: "table"}, func(context.Context, map[string]interface{}) (*ToolResult, error) {
+		return StructuredResult("", map[string]string{"rows": "many"}), nil
+	}))
+	r.Use(LimitTools(map[string]Limits{
+		"stuck":  {Timeout: 50 * time.Millisecond},
+		"chatty": {MaxOutputBytes: 2},
+		"*":      {MaxOutputBytes: 10},
+	}))
+
+	start := time.Now()
+	if _, err := r.Call(context.Background(), "stuck", nil); err == nil || err.Error() != "tool stuck exceeded its time limit of 50ms" {
+		t.Errorf("Expected a time limit error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the call to be abandoned, took %v", elapsed)
+	}
+
+	// Text is cut before the two-byte é; the image is dropped
+	result, err := r.Call(context.Background(), "chatty", nil)
+	if err != nil || len(result.Content) != 2 || result.Content[0].Text != "h" || result.Content[1].Text != "[Truncated: 9 more bytes of output]" {
+		t.Errorf("Unexpected result %+v, %v", result, err)
+	}
+	if _, err := r.Call(context.Background(), "table", nil); err == nil || !strings.Contains(err.Error(), "more than its limit of 10") {
+		t.Errorf("Expected structured content over the default limit to fail, got %v", err)
+	}
+}
+
+func TestLimitCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("rlimits are not set on windows")
+	}
+	SetRunCommandConfig(RunCommandConfig{Unsafe: true})
+	defer func() { runCommandConfig = nil }()
+
+	r := NewRegistry()
+	r.MustRegister(NewTool(Definition{Name: "run_command"}, CallRunCommand))
+	r.UseFor("run_command", Limit(Limits{CPUSeconds: 7, MemoryBytes: 512 << 20}))
+	result, err := r.Call(context.Background(), "run_command", map[string]interface{}{
+		"command": "sh",
+		"args":    []interface{}{"-c", "ulimit -t; ulimit -v"},
+	})
+	if err != nil || !strings.HasSuffix(result.Content[0].Text, "\n7\n524288\n") {
+		t.Errorf("Expected the limits to apply to the command, got %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"mcp-go/logging"
+	"time"
+)
+
+// CallFunc executes a call to a local tool
+type CallFunc func(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error)
+
+// Middleware wraps a CallFunc with cross-cutting behavior such as logging,
+// timing, redaction or authorization, like gateway.Middleware does for
+// routed calls. A middleware may inspect or modify the call, short-circuit
+// it by not calling next, or post-process the result.
+type Middleware func(next CallFunc) CallFunc
+
+// Use appends middleware to the chain applied around every call to the
+// tools of the registry. Middleware registered first is the outermost and
+// sees the call first.
+func (r *Registry) Use(middleware ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// UseFor appends middleware to the chain applied around the calls of one
+// tool, inside the middleware registered with Use. The tool need not be
+// registered yet.
+func (r *Registry) UseFor(name string, middleware ...Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.toolMiddleware[name] = append(r.toolMiddleware[name], middleware...)
+}
+
+// chain wraps call with the middleware of the registry and of the tool
+func (r *Registry) chain(name string, call CallFunc) CallFunc {
+	r.mu.RLock()
+	middleware := append(append([]Middleware{}, r.middleware...), r.toolMiddleware[name]...)
+	r.mu.RUnlock()
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		call = middleware[i](call)
+	}
+	return call
+}
+
+// redacted replaces logged values of redacted arguments
+const redacted = "[REDACTED]"
+
+// Logging returns middleware logging every call with its arguments, how long
+// it took and its error, if any. The values of the redacted arguments, such
+// as passwords or tokens, are not logged.
+func Logging(redact ...string) Middleware {
+	return func(next CallFunc) CallFunc {
+		return func(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+			start := time.Now()
+			result, err := next(ctx, name, arguments)
+			elapsed := time.Since(start).Round(time.Millisecond)
+			logged := loggedArguments(arguments, redact)
+			if err != nil {
+				logging.Warnf("Tool %s %s failed after %v: %v", name, logged, elapsed, err)
+			} else {
+				logging.Infof("Tool %s %s completed in %v", name, logged, elapsed)
+			}
+			return result, err
+		}
+	}
+}
+
+// loggedArguments encodes arguments for a log line with the values of the
+// redacted ones replaced
+func loggedArguments(arguments map[string]interface{}, redact []string) string {
+	shown := make(map[string]interface{}, len(arguments))
+	for name, value := range arguments {
+		shown[name] = value
+	}
+	for _, name := range redact {
+		if _, ok := shown[name]; ok {
+			shown[name] = redacted
+		}
+	}
+	data, err := json.Marshal(shown)
+	if err != nil {
+		return "{...}"
+	}
+	return string(data)
+}
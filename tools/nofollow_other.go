@@ -0,0 +1,6 @@
+//go:build !unix
+
+package tools
+
+// openNoFollow is not available on this platform
+const openNoFollow = 0
@@ -0,0 +1,9 @@
+//go:build unix
+
+package tools
+
+import "syscall"
+
+// openNoFollow makes opening a file fail when its last component is a
+// symlink, so a link swapped in after the path was checked is not followed
+const openNoFollow = syscall.O_NOFOLLOW
@@ -0,0 +1,300 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Defaults for the ocr_image tool when the configuration leaves them unset
+const (
+	DefaultOCRTimeout       = 60 * time.Second
+	DefaultOCRMaxImageBytes = 20 << 20
+)
+
+// ocrVisionURL is the images:annotate method of the Google Cloud Vision API
+var ocrVisionURL = "https://vision.googleapis.com/v1/images:annotate"
+
+// OCRTool represents the ocr_image tool definition
+type OCRTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// GetOCRTool returns the ocr_image tool definition
+func GetOCRTool() OCRTool {
+	return OCRTool{
+		Name:        "ocr_image",
+		Description: "Extract the text of an image, such as a scanned document or a screenshot. Pass either the path of an image file or its base64 content.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "Path of a PNG, JPEG, GIF, BMP, TIFF or WebP image in the allowed directories",
+				},
+				"content": map[string]interface{}{
+					"type":        "string",
+					"description": "Base64 encoded image, instead of path",
+				},
+				"language": map[string]interface{}{
+					"type":        "string",
+					"description": "Language of the text (default: the configured language), as Tesseract codes such as \"eng+deu\" or, with Google Cloud Vision, hints such as \"en,de\"",
+				},
+			},
+		},
+	}
+}
+
+// OCRConfig holds the configuration for the ocr_image tool
+type OCRConfig struct {
+	// Engine is "tesseract", running the Tesseract command line program, or
+	// "google_vision", calling the Google Cloud Vision API
+	Engine        string
+	TesseractPath string // Empty = "tesseract" from PATH
+	VisionAPIKey  string
+	Language      string // Empty = the engine's default
+	// AllowedPaths are the directories images may be read from; empty only
+	// allows base64 content
+	AllowedPaths  []string
+	MaxImageBytes int64
+	Timeout       time.Duration
+}
+
+var ocrConfig *OCRConfig
+
+// SetOCRConfig enables the ocr_image tool. A zero size limit or timeout
+// selects the default.
+func SetOCRConfig(config OCRConfig) error {
+	switch config.Engine {
+	case "", "tesseract":
+		config.Engine = "tesseract"
+		if config.TesseractPath == "" {
+			config.TesseractPath = "tesseract"
+		}
+		path, err := exec.LookPath(config.TesseractPath)
+		if err != nil {
+			return fmt.Errorf("tesseract not found: %v", err)
+		}
+		config.TesseractPath = path
+	case "google_vision":
+		if config.VisionAPIKey == "" {
+			return fmt.Errorf("the google_vision engine requires an API key")
+		}
+	default:
+		return fmt.Errorf("unknown OCR engine %q, use tesseract or google_vision", config.Engine)
+	}
+
+	roots, err := resolveRoots(config.AllowedPaths)
+	if err != nil {
+		return err
+	}
+	config.AllowedPaths = roots
+	if config.MaxImageBytes <= 0 {
+		config.MaxImageBytes = DefaultOCRMaxImageBytes
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultOCRTimeout
+	}
+	ocrConfig = &config
+	return nil
+}
+
+// GetOCRConfig returns the current configuration, nil when the tool is
+// disabled
+func GetOCRConfig() *OCRConfig {
+	return ocrConfig
+}
+
+// CallOCRImage extracts the text of an image
+func CallOCRImage(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+	config := ocrConfig
+	if config == nil {
+		return nil, fmt.Errorf("ocr_image not configured. Set ocr.enabled in the config file")
+	}
+	var args struct {
+		Path     string `arg:"path"`
+		Content  string `arg:"content"`
+		Language string `arg:"language"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	image, err := config.image(args.Path, args.Content)
+	if err != nil {
+		return nil, err
+	}
+	language := args.Language
+	if language == "" {
+		language = config.Language
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	var text string
+	if config.Engine == "google_vision" {
+		text, err = config.visionText(ctx, image, language)
+	} else {
+		text, err = config.tesseractText(ctx, image, language)
+	}
+	if err != nil {
+		return nil, err
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return TextResult("No text found in the image"), nil
+	}
+	return TextResult(text), nil
+}
+
+// image returns the image named by the path or content argument
+func (c *OCRConfig) image(path, content string) ([]byte, error) {
+	var data []byte
+	switch {
+	case path != "" && content != "":
+		return nil, fmt.Errorf("pass either path or content, not both")
+	case content != "":
+		if int64(base64.StdEncoding.DecodedLen(len(content))) > c.MaxImageBytes+2 {
+			return nil, fmt.Errorf("image exceeds the limit of %d bytes", c.MaxImageBytes)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return nil, fmt.Errorf("content is not valid base64: %w", err)
+		}
+		data = decoded
+	case path != "":
+		resolved, err := c.resolve(path)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(resolved)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image: %w", err)
+		}
+		if info.Size() > c.MaxImageBytes {
+			return nil, fmt.Errorf("image of %d bytes exceeds the limit of %d bytes", info.Size(), c.MaxImageBytes)
+		}
+		if data, err = os.ReadFile(resolved); err != nil {
+			return nil, fmt.Errorf("failed to read image: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("path or content argument is required")
+	}
+	if int64(len(data)) > c.MaxImageBytes {
+		return nil, fmt.Errorf("image exceeds the limit of %d bytes", c.MaxImageBytes)
+	}
+	if !isImage(data) {
+		return nil, fmt.Errorf("not a supported image (detected %s)", http.DetectContentType(data))
+	}
+	return data, nil
+}
+
+// resolve returns the symlink-resolved form of path, rejecting it when it
+// falls outside every allowed directory
+func (c *OCRConfig) resolve(path string) (string, error) {
+	if len(c.AllowedPaths) == 0 {
+		return "", fmt.Errorf("reading image files is disabled; pass the image as base64 content or set ocr.allowed_paths")
+	}
+	return resolveUnder(path, c.AllowedPaths)
+}
+
+// isImage reports whether data is an image format the engines read
+func isImage(data []byte) bool {
+	if bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*")) {
+		return true // TIFF, which DetectContentType does not know
+	}
+	return strings.HasPrefix(http.DetectContentType(data), "image/")
+}
+
+// tesseractText runs Tesseract on an image passed on its standard input
+func (c *OCRConfig) tesseractText(ctx context.Context, image []byte, language string) (string, error) {
+	args := []string{"stdin", "stdout"}
+	if language != "" {
+		args = append(args, "-l", language)
+	}
+	cmd := exec.CommandContext(ctx, c.TesseractPath, args...)
+	cmd.Stdin = bytes.NewReader(image)
+	limitCommand(ctx, cmd)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("tesseract timed out after %v", c.Timeout)
+		}
+		return "", fmt.Errorf("tesseract failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// visionText runs document text detection of the Google Cloud Vision API
+func (c *OCRConfig) visionText(ctx context.Context, image []byte, language string) (string, error) {
+	request := map[string]interface{}{
+		"image":    map[string]interface{}{"content": base64.StdEncoding.EncodeToString(image)},
+		"features": []map[string]interface{}{{"type": "DOCUMENT_TEXT_DETECTION"}},
+	}
+	if language != "" {
+		request["imageContext"] = map[string]interface{}{"languageHints": strings.Split(language, ",")}
+	}
+	body, err := json.Marshal(map[string]interface{}{"requests": []interface{}{request}})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", ocrVisionURL+"?key="+url.QueryEscape(c.VisionAPIKey), bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The URL holds the API key; keep it out of the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return "", fmt.Errorf("Vision API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	var apiResp struct {
+		Responses []struct {
+			FullTextAnnotation struct {
+				Text string `json:"text"`
+			} `json:"fullTextAnnotation"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"responses"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &apiResp); err != nil {
+		return "", fmt.Errorf("Vision API returned status %d", resp.StatusCode)
+	}
+	if apiResp.Error != nil {
+		return "", fmt.Errorf("Vision API returned status %d: %s", resp.StatusCode, apiResp.Error.Message)
+	}
+	if len(apiResp.Responses) == 0 {
+		return "", fmt.Errorf("Vision API returned no result")
+	}
+	if result := apiResp.Responses[0]; result.Error != nil {
+		return "", fmt.Errorf("Vision API failed: %s", result.Error.Message)
+	}
+	return apiResp.Responses[0].FullTextAnnotation.Text, nil
+}
@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// testPNG is the signature and header chunk of a PNG image
+const testPNG = "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+
+func TestCallOCRImageTesseract(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake tesseract is a shell script")
+	}
+	dir := t.TempDir()
+	// The fake tesseract prints its arguments and the size of its input
+	tesseract := filepath.Join(dir, "tesseract")
+	script := "#!/bin/sh\necho \"args: $*\"\necho \"bytes: $(wc -c | tr -d ' ')\"\n"
+	if err := os.WriteFile(tesseract, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	images := filepath.Join(dir, "scans")
+	os.Mkdir(images, 0o755)
+	os.WriteFile(filepath.Join(images, "page.png"), []byte(testPNG), 0o644)
+	os.WriteFile(filepath.Join(dir, "secret.png"), []byte(testPNG), 0o644)
+	os.WriteFile(filepath.Join(images, "notes.txt"), []byte("plain text"), 0o644)
+
+	err := SetOCRConfig(OCRConfig{TesseractPath: tesseract, Language: "eng", AllowedPaths: []string{images}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { ocrConfig = nil }()
+	ctx := context.Background()
+
+	result, err := resultText(CallOCRImage(ctx, map[string]interface{}{"path": filepath.Join(images, "page.png"), "language": "eng+deu"}))
+	if err != nil || result != "args: stdin stdout -l eng+deu\nbytes: 16" {
+		t.Errorf("ocr_image of a file = %q, %v", result, err)
+	}
+	result, err = resultText(CallOCRImage(ctx, map[string]interface{}{"content": base64.StdEncoding.EncodeToString([]byte(testPNG))}))
+	if err != nil || !strings.HasPrefix(result, "args: stdin stdout -l eng\n") {
+		t.Errorf("ocr_image of content = %q, %v", result, err)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"path": filepath.Join(dir, "secret.png")},
+		{"path": filepath.Join(images, "..", "secret.png")},
+		{"path": filepath.Join(images, "notes.txt")},
+		{"content": "not base64!"},
+		{},
+	} {
+		if _, err := CallOCRImage(ctx, args); err == nil {
+			t.Errorf("Expected %v to be rejected", args)
+		}
+	}
+}
+
+func TestCallOCRImageGoogleVision(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "vision-key" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":{"code":403,"message":"API key not valid"}}`))
+			return
+		}
+		var request struct {
+			Requests []struct {
+				Image struct {
+					Content string `json:"content"`
+				} `json:"image"`
+				ImageContext struct {
+					LanguageHints []string `json:"languageHints"`
+				} `json:"imageContext"`
+			} `json:"requests"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		if len(request.Requests) != 1 || request.Requests[0].Image.Content == "" || len(request.Requests[0].ImageContext.LanguageHints) != 2 {
+			w.Write([]byte(`{"responses":[{"error":{"message":"bad request"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"responses":[{"fullTextAnnotation":{"text":"INVOICE 2024-117\nTotal: 42.00\n"}}]}`))
+	}))
+	defer server.Close()
+
+	defer func(original string) { ocrVisionURL = original }(ocrVisionURL)
+	ocrVisionURL = server.URL
+	if err := SetOCRConfig(OCRConfig{Engine: "google_vision", VisionAPIKey: "vision-key", Language: "en,de"}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { ocrConfig = nil }()
+	content := base64.StdEncoding.EncodeToString([]byte(testPNG))
+
+	result, err := resultText(CallOCRImage(context.Background(), map[string]interface{}{"content": content}))
+	if err != nil || result != "INVOICE 2024-117\nTotal: 42.00" {
+		t.Errorf("ocr_image = %q, %v", result, err)
+	}
+	if _, err := CallOCRImage(context.Background(), map[string]interface{}{"path": "/tmp/scan.png"}); err == nil || !strings.Contains(err.Error(), "ocr.allowed_paths") {
+		t.Errorf("Expected files to be rejected without allowed paths, got %v", err)
+	}
+	ocrConfig.VisionAPIKey = "wrong"
+	if _, err := CallOCRImage(context.Background(), map[string]interface{}{"content": content}); err == nil || !strings.Contains(err.Error(), "API key not valid") || strings.Contains(err.Error(), "wrong") {
+		t.Errorf("Expected the API's error without the key, got %v", err)
+	}
+}
@@ -0,0 +1,102 @@
+package tools
+
+// builtinOutputSchemas are the output schemas of the built-in and
+// filesystem tools returning structured content. Lists that may be empty
+// are encoded as null, so they are not required.
+var builtinOutputSchemas = map[string]map[string]interface{}{
+	"list_directory":    directoryListingSchema,
+	"read_csv":          tableSchema,
+	"read_xlsx":         tableSchema,
+	"crawl_site":        crawlResultSchema,
+	"google_pse_search": googlePSEResultsSchema,
+}
+
+// directoryListingSchema describes a DirectoryListing
+var directoryListingSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"path": map[string]interface{}{"type": "string"},
+		"entries": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name":   map[string]interface{}{"type": "string"},
+					"type":   map[string]interface{}{"type": "string", "enum": []string{"file", "directory", "symlink"}},
+					"size":   map[string]interface{}{"type": "integer"},
+					"mode":   map[string]interface{}{"type": "string"},
+					"mtime":  map[string]interface{}{"type": "string"},
+					"target": map[string]interface{}{"type": "string", "description": "Destination of a symlink"},
+				},
+				"required": []string{"name", "type", "size", "mode", "mtime"},
+			},
+		},
+	},
+	"required": []string{"path"},
+}
+
+// tableSchema describes a Table
+var tableSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"sheet":   map[string]interface{}{"type": "string"},
+		"sheets":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		"columns": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		"rows": map[string]interface{}{
+			"type":  "array",
+			"items": map[string]interface{}{"type": "array"},
+		},
+		"start_row":  map[string]interface{}{"type": "integer", "minimum": 0},
+		"total_rows": map[string]interface{}{"type": "integer", "minimum": 0},
+		"truncated":  map[string]interface{}{"type": "boolean"},
+	},
+	"required": []string{"start_row", "total_rows", "truncated"},
+}
+
+// crawlResultSchema describes a CrawlResult
+var crawlResultSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"site": map[string]interface{}{"type": "string"},
+		"pages": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"url":       map[string]interface{}{"type": "string"},
+					"title":     map[string]interface{}{"type": "string"},
+					"depth":     map[string]interface{}{"type": "integer", "minimum": 0},
+					"text":      map[string]interface{}{"type": "string"},
+					"truncated": map[string]interface{}{"type": "boolean"},
+				},
+				"required": []string{"url", "depth", "text"},
+			},
+		},
+		"not_visited": map[string]interface{}{"type": "integer", "minimum": 0},
+		"errors":      map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+	},
+	"required": []string{"site", "not_visited"},
+}
+
+// googlePSEResultsSchema describes GooglePSEResults
+var googlePSEResultsSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"query":         map[string]interface{}{"type": "string"},
+		"total_results": map[string]interface{}{"type": "integer", "minimum": 0},
+		"results": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"title":       map[string]interface{}{"type": "string"},
+					"link":        map[string]interface{}{"type": "string"},
+					"snippet":     map[string]interface{}{"type": "string"},
+					"displayLink": map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"title", "link", "snippet"},
+			},
+		},
+	},
+	"required": []string{"query", "total_results", "results"},
+}
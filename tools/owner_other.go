@@ -0,0 +1,10 @@
+//go:build !unix
+
+package tools
+
+import "os"
+
+// fileOwner is not available on this platform
+func fileOwner(info os.FileInfo) string {
+	return ""
+}
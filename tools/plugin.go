@@ -0,0 +1,138 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Defaults for plugins when the configuration leaves them unset
+const (
+	DefaultPluginTimeout   = 60 * time.Second
+	DefaultPluginMaxOutput = 1 << 20
+)
+
+// PluginConfig holds the configuration of a tool implemented by an external
+// program
+type PluginConfig struct {
+	Name        string
+	Description string
+	InputSchema map[string]interface{} // nil = any object
+	ReadOnly    bool
+	Command     string
+	Args        []string
+	Env         []string // "NAME=value" entries added to the environment
+	WorkingDir  string
+	Timeout     time.Duration
+	// MaxOutputBytes fails calls printing more
+	MaxOutputBytes int64
+}
+
+// Plugin is a tool implemented by an external program. Each call starts the
+// program with the arguments as a JSON object on stdin and MCP_TOOL_NAME set
+// to the tool's name. What it prints on stdout is the result: a JSON tool
+// result with a "content" list, for images, structured content or isError,
+// or else plain text. A non-zero exit fails the call with its stderr.
+type Plugin struct {
+	config PluginConfig
+}
+
+// NewPlugin returns the tool of a plugin. A zero timeout or output limit
+// selects the default.
+func NewPlugin(config PluginConfig) (*Plugin, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("plugin name is required")
+	}
+	if config.Command == "" {
+		return nil, fmt.Errorf("plugin %s: command is required", config.Name)
+	}
+	if _, err := exec.LookPath(config.Command); err != nil {
+		return nil, fmt.Errorf("plugin %s: %v", config.Name, err)
+	}
+	if config.InputSchema == nil {
+		config.InputSchema = map[string]interface{}{"type": "object"}
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultPluginTimeout
+	}
+	if config.MaxOutputBytes <= 0 {
+		config.MaxOutputBytes = DefaultPluginMaxOutput
+	}
+	return &Plugin{config: config}, nil
+}
+
+func (p *Plugin) Definition() Definition {
+	annotations := changes(p.config.Name, true, false, true)
+	if p.config.ReadOnly {
+		annotations = inspects(p.config.Name, true)
+	}
+	return Definition{
+		Name:        p.config.Name,
+		Description: p.config.Description,
+		InputSchema: p.config.InputSchema,
+		Annotations: annotations,
+	}
+}
+
+// Execute runs the program once for the call
+func (p *Plugin) Execute(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+	input, err := json.Marshal(arguments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode arguments for plugin %s: %v", p.config.Name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{max: p.config.MaxOutputBytes}
+	stderr := &limitedBuffer{max: 4096}
+	cmd := exec.CommandContext(ctx, p.config.Command, p.config.Args...)
+	cmd.Dir = p.config.WorkingDir
+	cmd.Env = append(append(os.Environ(), p.config.Env...), "MCP_TOOL_NAME="+p.config.Name)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	setProcessGroup(cmd)
+	limitCommand(ctx, cmd)
+	cmd.Cancel = func() error { return killProcessGroup(cmd) }
+	cmd.WaitDelay = 2 * time.Second
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("plugin %s timed out after %v", p.config.Name, p.config.Timeout)
+		}
+		if message := strings.TrimSpace(string(stderr.buf)); message != "" {
+			return nil, fmt.Errorf("plugin %s failed: %v: %s", p.config.Name, err, message)
+		}
+		return nil, fmt.Errorf("plugin %s failed: %v", p.config.Name, err)
+	}
+	if stdout.dropped > 0 {
+		return nil, fmt.Errorf("plugin %s printed more than %d bytes", p.config.Name, p.config.MaxOutputBytes)
+	}
+	return pluginResult(stdout.buf), nil
+}
+
+// pluginResult reads the output of a plugin as a tool result if it is one,
+// and as text otherwise
+func pluginResult(output []byte) *ToolResult {
+	trimmed := bytes.TrimSpace(output)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var result struct {
+			ToolResult
+			Content *[]Content `json:"content"`
+		}
+		if json.Unmarshal(trimmed, &result) == nil && result.Content != nil {
+			result.ToolResult.Content = *result.Content
+			return &result.ToolResult
+		}
+	}
+	return TextResult(strings.TrimRight(string(output), "\n"))
+}
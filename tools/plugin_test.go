@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts need a unix shell")
+	}
+	script := filepath.Join(t.TempDir(), "plugin.sh")
+	os.WriteFile(script, []byte(`#!/bin/sh
+input=$(cat)
+case "$input" in
+*image*) echo '{"content": [{"type": "text", "text": "chart"}, {"type": "image", "data": "cG5n", "mimeType": "image/png"}]}' ;;
+*fail*) echo "bad input" >&2; exit 3 ;;
+*sleep*) sleep 5 ;;
+*) echo "$MCP_TOOL_NAME $GREETING $input" ;;
+esac
+`), 0755)
+
+	if _, err := NewPlugin(PluginConfig{Name: "missing", Command: "./no-such-plugin"}); err == nil {
+		t.Error("Expected a missing command to be refused")
+	}
+	plugin, err := NewPlugin(PluginConfig{Name: "greet", Command: script, Env: []string{"GREETING=hello"}, Timeout: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if definition := plugin.Definition(); definition.InputSchema["type"] != "object" || definition.Annotations.ReadOnly() {
+		t.Errorf("Unexpected definition %+v", definition)
+	}
+
+	result, err := plugin.Execute(context.Background(), map[string]interface{}{"name": "Ann"})
+	if err != nil || result.Content[0].Text != `greet hello {"name":"Ann"}` {
+		t.Errorf("Unexpected result %+v, %v", result, err)
+	}
+	result, err = plugin.Execute(context.Background(), map[string]interface{}{"kind": "image"})
+	if err != nil || len(result.Content) != 2 || result.Content[1].MimeType != "image/png" {
+		t.Errorf("Expected a text and an image, got %+v, %v", result, err)
+	}
+	if _, err := plugin.Execute(context.Background(), map[string]interface{}{"kind": "fail"}); err == nil || err.Error() != "plugin greet failed: exit status 3: bad input" {
+		t.Errorf("Expected the plugin's error, got %v", err)
+	}
+	if _, err := plugin.Execute(context.Background(), map[string]interface{}{"kind": "sleep"}); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+}
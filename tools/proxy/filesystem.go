@@ -85,6 +85,36 @@ func (p *FileSystemProxy) DeleteFile(ctx context.Context, filePath string) (*tra
 	return p.gateway.CallTool(ctx, "filesystem:delete_file", arguments)
 }
 
+// MoveFile moves or renames a file or directory through the File System MCP
+func (p *FileSystemProxy) MoveFile(ctx context.Context, source, destination string, overwrite bool) (*transport.ToolResponse, error) {
+	if p.gateway == nil {
+		return nil, fmt.Errorf("gateway not initialized")
+	}
+
+	arguments := map[string]interface{}{
+		"source":      source,
+		"destination": destination,
+		"overwrite":   overwrite,
+	}
+
+	return p.gateway.CallTool(ctx, "filesystem:move_file", arguments)
+}
+
+// CopyFile copies a file or directory through the File System MCP
+func (p *FileSystemProxy) CopyFile(ctx context.Context, source, destination string, overwrite bool) (*transport.ToolResponse, error) {
+	if p.gateway == nil {
+		return nil, fmt.Errorf("gateway not initialized")
+	}
+
+	arguments := map[string]interface{}{
+		"source":      source,
+		"destination": destination,
+		"overwrite":   overwrite,
+	}
+
+	return p.gateway.CallTool(ctx, "filesystem:copy_file", arguments)
+}
+
 // ListFileSystemTools lists all available File System tools
 func (p *FileSystemProxy) ListFileSystemTools(ctx context.Context) ([]transport.Tool, error) {
 	if p.gateway == nil {
@@ -0,0 +1,266 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mcp-go/jsonschema"
+	"mcp-go/transport"
+	"sync"
+)
+
+// Definition describes a tool to clients in tools/list
+type Definition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+	// OutputSchema describes the structured content of the tool's results,
+	// which Registry.Call checks against it
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
+	Annotations  *Annotations           `json:"annotations,omitempty"`
+}
+
+// Annotations are the MCP hints describing how a tool behaves, shared with
+// the transport so they survive proxying
+type Annotations = transport.ToolAnnotations
+
+// Content is an item of a tool result: text, an image with its base64 data
+// and MIME type, or an embedded resource. It is the transport's content
+// item, so local and proxied results carry the same items.
+type Content = transport.ContentItem
+
+// EmbeddedResource is the content of a resource returned by a tool
+type EmbeddedResource = transport.EmbeddedResource
+
+// ToolResult is the result of a tool call
+type ToolResult struct {
+	Content []Content `json:"content"`
+	// StructuredContent is the machine-readable form of the result, when
+	// the tool provides one
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	// IsError marks a result reporting that the tool failed, which the
+	// model reads like any other result
+	IsError bool `json:"isError,omitempty"`
+}
+
+// Tool is a tool served by a Registry
+type Tool interface {
+	Definition() Definition
+	Execute(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error)
+}
+
+// Conditional is implemented by tools that are only served while enabled,
+// typically once their configuration is set. A tool that is not enabled is
+// neither listed nor callable.
+type Conditional interface {
+	Enabled() bool
+}
+
+// Registry holds tools by name, in the order they were registered
+type Registry struct {
+	mu             sync.RWMutex
+	tools          []Tool
+	index          map[string]Tool
+	middleware     []Middleware            // Applied to every call, see Use
+	toolMiddleware map[string][]Middleware // Applied to the calls of one tool, see UseFor
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{index: make(map[string]Tool), toolMiddleware: make(map[string][]Middleware)}
+}
+
+// DefaultRegistry holds the built-in tools of the gateway server
+var DefaultRegistry = newBuiltinRegistry()
+
+// Register adds a tool; its name must not be taken
+func (r *Registry) Register(tool Tool) error {
+	name := tool.Definition().Name
+	if name == "" {
+		return fmt.Errorf("tool has no name")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, taken := r.index[name]; taken {
+		return fmt.Errorf("tool %q is already registered", name)
+	}
+	r.tools = append(r.tools, tool)
+	r.index[name] = tool
+	return nil
+}
+
+// MustRegister adds a tool, panicking if its name is taken. It is meant for
+// registrations at startup, where a clash is a programming error.
+func (r *Registry) MustRegister(tool Tool) {
+	if err := r.Register(tool); err != nil {
+		panic(err)
+	}
+}
+
+// Unregister removes the tool of a name and reports whether it was
+// registered. Middleware added for it with UseFor is kept, so a tool
+// registered again under the name runs through it too.
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tool, ok := r.index[name]
+	if !ok {
+		return false
+	}
+	delete(r.index, name)
+	for i, registered := range r.tools {
+		if registered == tool {
+			r.tools = append(r.tools[:i:i], r.tools[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Lookup returns the enabled tool of a name
+func (r *Registry) Lookup(name string) (Tool, bool) {
+	r.mu.RLock()
+	tool, ok := r.index[name]
+	r.mu.RUnlock()
+	if !ok || !toolEnabled(tool) {
+		return nil, false
+	}
+	return tool, true
+}
+
+// Tools returns the enabled tools in registration order
+func (r *Registry) Tools() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var enabled []Tool
+	for _, tool := range r.tools {
+		if toolEnabled(tool) {
+			enabled = append(enabled, tool)
+		}
+	}
+	return enabled
+}
+
+// ErrToolNotFound is matched, through errors.Is, by the error of a call to
+// a tool a registry does not serve
+var ErrToolNotFound = errors.New("tool not found")
+
+// notFoundError is the error of a call to an unknown tool
+type notFoundError string
+
+func (e notFoundError) Error() string {
+	return fmt.Sprintf("tool '%s' not found", string(e))
+}
+
+func (e notFoundError) Is(target error) bool {
+	return target == ErrToolNotFound
+}
+
+// Call executes the enabled tool of a name through the registered
+// middleware. The arguments the middleware passes on are checked against
+// the input schema of the tool; arguments that do not match are refused
+// with a *jsonschema.ValidationError listing the fields at fault. The
+// structured content of the result is checked against the output schema of
+// the tool, if it declares one.
+func (r *Registry) Call(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+	tool, ok := r.Lookup(name)
+	if !ok {
+		return nil, notFoundError(name)
+	}
+	execute := func(ctx context.Context, _ string, arguments map[string]interface{}) (*ToolResult, error) {
+		definition := tool.Definition()
+		if arguments == nil {
+			arguments = make(map[string]interface{})
+		}
+		if err := jsonschema.Validate(definition.InputSchema, arguments); err != nil {
+			return nil, err
+		}
+		result, err := tool.Execute(ctx, arguments)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkStructuredContent(definition, result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	return r.chain(name, execute)(ctx, name, arguments)
+}
+
+// checkStructuredContent checks the structured content of a result against
+// the output schema of the tool. A tool declaring an output schema must
+// return structured content; a mismatch is the tool's fault, so it is not
+// reported as a *jsonschema.ValidationError.
+func checkStructuredContent(definition Definition, result *ToolResult) error {
+	if definition.OutputSchema == nil || result == nil {
+		return nil
+	}
+	if result.StructuredContent == nil {
+		return fmt.Errorf("tool '%s' returned no structured content for its output schema", definition.Name)
+	}
+	// Validate the JSON clients receive rather than the Go value
+	data, err := json.Marshal(result.StructuredContent)
+	if err != nil {
+		return fmt.Errorf("tool '%s' returned structured content that cannot be encoded: %w", definition.Name, err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("tool '%s' returned structured content that cannot be decoded: %w", definition.Name, err)
+	}
+	var invalid *jsonschema.ValidationError
+	if errors.As(jsonschema.Validate(definition.OutputSchema, value), &invalid) {
+		return fmt.Errorf("tool '%s' returned structured content not matching its output schema: %s", definition.Name, invalid.Details())
+	}
+	return nil
+}
+
+// toolEnabled reports whether a tool is served
+func toolEnabled(tool Tool) bool {
+	conditional, ok := tool.(Conditional)
+	return !ok || conditional.Enabled()
+}
+
+// Handler runs a tool call
+type Handler func(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error)
+
+// NewTool returns a tool made of a definition and a handler, for tools
+// registered by programs embedding the server
+func NewTool(definition Definition, handler Handler) Tool {
+	return &builtinTool{definition: definition, execute: handler}
+}
+
+// builtinTool is a Tool made of a definition, an optional enabled check and
+// a function
+type builtinTool struct {
+	definition Definition
+	enabled    func() bool // nil = always enabled
+	execute    Handler
+}
+
+func (t *builtinTool) Definition() Definition {
+	return t.definition
+}
+
+func (t *builtinTool) Execute(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+	return t.execute(ctx, arguments)
+}
+
+func (t *builtinTool) Enabled() bool {
+	return t.enabled == nil || t.enabled()
+}
+
+// contextFree adapts a tool function that takes no context
+func contextFree(call func(map[string]interface{}) (*ToolResult, error)) Handler {
+	return func(_ context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+		return call(arguments)
+	}
+}
+
+// namedTool adapts the function of a tool family, which takes the name of
+// the tool called
+func namedTool(name string, call func(context.Context, string, map[string]interface{}) (*ToolResult, error)) Handler {
+	return func(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+		return call(ctx, name, arguments)
+	}
+}
@@ -0,0 +1,248 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	enabled := false
+	r := NewRegistry()
+	r.MustRegister(&builtinTool{
+		definition: Definition{Name: "first"},
+		execute: func(_ context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+			return TextResult("first " + arguments["x"].(string)), nil
+		},
+	})
+	r.MustRegister(&builtinTool{definition: Definition{Name: "second"}, enabled: func() bool { return enabled }})
+	if err := r.Register(&builtinTool{definition: Definition{Name: "first"}}); err == nil {
+		t.Error("Expected a second tool of the same name to be refused")
+	}
+
+	// Tools that are not enabled are neither listed nor callable
+	if tools := r.Tools(); len(tools) != 1 || tools[0].Definition().Name != "first" {
+		t.Errorf("Expected only the first tool, got %v", tools)
+	}
+	if _, err := r.Call(context.Background(), "second", nil); err == nil || err.Error() != "tool 'second' not found" {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+	enabled = true
+	if tools := r.Tools(); len(tools) != 2 || tools[1].Definition().Name != "second" {
+		t.Errorf("Expected both tools in registration order, got %v", tools)
+	}
+
+	result, err := r.Call(context.Background(), "first", map[string]interface{}{"x": "call"})
+	if err != nil || len(result.Content) != 1 || result.Content[0].Text != "first call" {
+		t.Errorf("Unexpected result %+v, %v", result, err)
+	}
+
+	// Tools can be replaced at runtime
+	if !r.Unregister("first") || r.Unregister("first") {
+		t.Error("Expected the first tool to be unregistered once")
+	}
+	r.MustRegister(NewTool(Definition{Name: "first"}, func(context.Context, map[string]interface{}) (*ToolResult, error) {
+		return TextResult("replaced"), nil
+	}))
+	if tools := r.Tools(); len(tools) != 2 || tools[0].Definition().Name != "second" {
+		t.Errorf("Expected the new tool to be listed last, got %v", tools)
+	}
+	if result, err := r.Call(context.Background(), "first", nil); err != nil || result.Content[0].Text != "replaced" {
+		t.Errorf("Unexpected result %+v, %v", result, err)
+	}
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	// Built-in tools are served once configured
+	if _, ok := DefaultRegistry.Lookup("whois"); ok {
+		t.Fatal("Expected whois to be hidden until configured")
+	}
+	SetWhoisConfig(time.Second, 0)
+	defer func() { whoisConfig = nil }()
+	if _, ok := DefaultRegistry.Lookup("whois"); !ok {
+		t.Error("Expected whois to be served once configured")
+	}
+
+	result, err := DefaultRegistry.Call(context.Background(), "echo", map[string]interface{}{"message": "hello"})
+	if err != nil || !strings.Contains(result.Content[0].Text, "hello") {
+		t.Errorf("Unexpected echo result %+v, %v", result, err)
+	}
+
+	var names []string
+	for _, tool := range FilesystemRegistry.Tools() {
+		names = append(names, tool.Definition().Name)
+	}
+	if len(names) != 22 || names[0] != "read_file" || names[21] != "get_file_events" {
+		t.Errorf("Unexpected filesystem tools: %v", names)
+	}
+}
+
+func TestRegistryMiddleware(t *testing.T) {
+	r := NewRegistry()
+	for _, name := range []string{"first", "second"} {
+		r.MustRegister(&builtinTool{
+			definition: Definition{Name: name, InputSchema: map[string]interface{}{
+				"type":     "object",
+				"required": []string{"token"},
+			}},
+			execute: func(_ context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+				return TextResult(arguments["token"].(string)), nil
+			},
+		})
+	}
+
+	var order []string
+	trace := func(label string) Middleware {
+		return func(next CallFunc) CallFunc {
+			return func(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+				order = append(order, label)
+				return next(ctx, name, arguments)
+			}
+		}
+	}
+	r.Use(trace("outer"), trace("inner"))
+	// Middleware may refuse calls and fill in arguments before they are
+	// validated
+	r.UseFor("second", func(next CallFunc) CallFunc {
+		return func(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+			if arguments["deny"] == true {
+				return nil, fmt.Errorf("not authorized to call %s", name)
+			}
+			return next(ctx, name, map[string]interface{}{"token": "injected"})
+		}
+	})
+
+	if _, err := r.Call(context.Background(), "first", nil); err == nil || !strings.Contains(err.Error(), "token: is required") {
+		t.Errorf("Expected a validation error, got %v", err)
+	}
+	if len(order) != 2 || order[0] != "outer" || order[1] != "inner" {
+		t.Errorf("Expected [outer inner], got %v", order)
+	}
+	result, err := r.Call(context.Background(), "second", nil)
+	if err != nil || result.Content[0].Text != "injected" {
+		t.Errorf("Unexpected result %+v, %v", result, err)
+	}
+	if _, err := r.Call(context.Background(), "second", map[string]interface{}{"deny": true}); err == nil || err.Error() != "not authorized to call second" {
+		t.Errorf("Expected the call to be refused, got %v", err)
+	}
+}
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	r := NewRegistry()
+	r.MustRegister(&builtinTool{definition: Definition{Name: "login"}, execute: func(context.Context, map[string]interface{}) (*ToolResult, error) {
+		return TextResult("ok"), nil
+	}})
+	r.Use(Logging("password"))
+	if _, err := r.Call(context.Background(), "login", map[string]interface{}{"user": "ann", "password": "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	logged := buf.String()
+	if !strings.Contains(logged, `Tool login {"password":"[REDACTED]","user":"ann"} completed in`) || strings.Contains(logged, "secret") {
+		t.Errorf("Unexpected log: %s", logged)
+	}
+}
+
+func TestBuiltinAnnotations(t *testing.T) {
+	registered := map[string]bool{}
+	for _, r := range []*Registry{DefaultRegistry, FilesystemRegistry} {
+		for _, tool := range r.tools {
+			definition := tool.Definition()
+			registered[definition.Name] = true
+			if a := definition.Annotations; a == nil || a.Title == "" || a.ReadOnlyHint == nil || a.OpenWorldHint == nil {
+				t.Errorf("Expected %s to be annotated, got %+v", definition.Name, a)
+			}
+		}
+	}
+	for name := range builtinAnnotations {
+		if !registered[name] {
+			t.Errorf("Annotations of unknown tool %s", name)
+		}
+	}
+	if a := builtinAnnotations["delete_file"]; a.ReadOnly() || !*a.DestructiveHint {
+		t.Errorf("Expected delete_file to be destructive, got %+v", a)
+	}
+}
+
+func TestOutputSchema(t *testing.T) {
+	var structured interface{}
+	r := NewRegistry()
+	r.MustRegister(NewTool(Definition{
+		Name: "count",
+		OutputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"count": map[string]interface{}{"type": "integer"}},
+			"required":   []string{"count"},
+		},
+	}, func(context.Context, map[string]interface{}) (*ToolResult, error) {
+		return StructuredResult("counted", structured), nil
+	}))
+
+	structured = struct {
+		Count int `json:"count"`
+	}{3}
+	if _, err := r.Call(context.Background(), "count", nil); err != nil {
+		t.Errorf("Expected matching content to be returned, got %v", err)
+	}
+	structured = map[string]interface{}{"count": "three"}
+	if _, err := r.Call(context.Background(), "count", nil); err == nil || err.Error() != "tool 'count' returned structured content not matching its output schema: count: must be an integer" {
+		t.Errorf("Expected an output schema error, got %v", err)
+	}
+	structured = nil
+	if _, err := r.Call(context.Background(), "count", nil); err == nil {
+		t.Error("Expected missing structured content to be refused")
+	}
+
+	// The structured results of the built-in tools match their schemas,
+	// including empty lists
+	results := map[string]interface{}{
+		"list_directory": &DirectoryListing{Path: "/tmp"},
+		"read_csv":       &Table{Columns: []string{"a"}, Rows: [][]interface{}{{1.5, "x", nil}}, TotalRows: 1},
+		"crawl_site": &CrawlResult{Site: "https://example.com", Pages: []CrawledPage{
+			{URL: "https://example.com/", Depth: 0, Text: "home"},
+		}},
+	}
+	for name, content := range results {
+		definition := Definition{Name: name, OutputSchema: builtinOutputSchemas[name]}
+		if err := checkStructuredContent(definition, StructuredResult("", content)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestResultBuilders(t *testing.T) {
+	tests := []struct {
+		result *ToolResult
+		want   string
+	}{
+		{TextResult("hi"), `{"content":[{"type":"text","text":"hi"}]}`},
+		{ErrorResult("no such city"), `{"content":[{"type":"text","text":"no such city"}],"isError":true}`},
+		{ImageResult([]byte("png"), "image/png"), `{"content":[{"type":"image","text":"","data":"cG5n","mimeType":"image/png"}]}`},
+		{ResourceResult("file:///a.txt", "text/plain", "A"), `{"content":[{"type":"resource","text":"","resource":{"uri":"file:///a.txt","mimeType":"text/plain","text":"A"}}]}`},
+		{StructuredResult("1 row", map[string]int{"rows": 1}), `{"content":[{"type":"text","text":"1 row"}],"structuredContent":{"rows":1}}`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.result)
+		if err != nil || string(data) != tt.want {
+			t.Errorf("Got %s, %v, expected %s", data, err, tt.want)
+		}
+	}
+}
+
+// resultText returns the text of a tool result, for tests written against
+// the text of a handler
+func resultText(result *ToolResult, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	return result.Content[0].Text, nil
+}
@@ -0,0 +1,54 @@
+package tools
+
+import "encoding/base64"
+
+// TextResult returns a result holding text
+func TextResult(text string) *ToolResult {
+	return &ToolResult{Content: []Content{TextContent(text)}}
+}
+
+// StructuredResult returns a result holding text and its machine-readable
+// form
+func StructuredResult(text string, structured interface{}) *ToolResult {
+	return &ToolResult{Content: []Content{TextContent(text)}, StructuredContent: structured}
+}
+
+// ErrorResult returns a result reporting that the tool failed. Unlike an
+// error returned by Execute, which becomes a protocol error, it reaches the
+// model so it can correct the call.
+func ErrorResult(message string) *ToolResult {
+	return &ToolResult{Content: []Content{TextContent(message)}, IsError: true}
+}
+
+// ImageResult returns a result holding an image
+func ImageResult(data []byte, mimeType string) *ToolResult {
+	return &ToolResult{Content: []Content{ImageContent(data, mimeType)}}
+}
+
+// ResourceResult returns a result embedding the text of a resource
+func ResourceResult(uri, mimeType, text string) *ToolResult {
+	return &ToolResult{Content: []Content{ResourceContent(uri, mimeType, text)}}
+}
+
+// textResult returns the text produced by a helper as a result, or its error
+func textResult(text string, err error) (*ToolResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	return TextResult(text), nil
+}
+
+// TextContent returns a text item
+func TextContent(text string) Content {
+	return Content{Type: "text", Text: text}
+}
+
+// ImageContent returns an image item with its data encoded in base64
+func ImageContent(data []byte, mimeType string) Content {
+	return Content{Type: "image", Data: base64.StdEncoding.EncodeToString(data), MimeType: mimeType}
+}
+
+// ResourceContent returns an item embedding the text of a resource
+func ResourceContent(uri, mimeType, text string) Content {
+	return Content{Type: "resource", Resource: &EmbeddedResource{URI: uri, MimeType: mimeType, Text: text}}
+}
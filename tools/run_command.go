@@ -0,0 +1,304 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Defaults for the run_command tool when the configuration leaves them unset
+const (
+	DefaultRunCommandTimeout   = 60 * time.Second
+	DefaultRunCommandMaxOutput = 1 << 20
+)
+
+// RunCommandTool represents the run_command tool definition
+type RunCommandTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// GetRunCommandTool returns the run_command tool definition
+func GetRunCommandTool() RunCommandTool {
+	description := "Run a command without a shell and return its exit code and combined stdout and stderr"
+	if config := GetRunCommandConfig(); config != nil && !config.Unsafe {
+		description += fmt.Sprintf(". Allowed commands: %s", strings.Join(config.AllowedCommands, ", "))
+	}
+	if config := GetRunCommandConfig(); config != nil && len(config.AllowedEnv) > 0 {
+		description += fmt.Sprintf(". Allowed environment variables: %s", strings.Join(config.AllowedEnv, ", "))
+	}
+	return RunCommandTool{
+		Name:        "run_command",
+		Description: description,
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"command": map[string]interface{}{
+					"type":        "string",
+					"description": "The program to run, e.g. \"go\"",
+				},
+				"args": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Arguments passed to the program, e.g. [\"test\", \"./...\"]",
+				},
+				"cwd": map[string]interface{}{
+					"type":        "string",
+					"description": "Working directory inside the configured working directory, relative to it",
+				},
+				"env": map[string]interface{}{
+					"type":                 "object",
+					"additionalProperties": map[string]interface{}{"type": "string"},
+					"description":          "Environment variables added to the server's environment; only the configured names may be set",
+				},
+				"timeout": map[string]interface{}{
+					"type":        "integer",
+					"description": "Timeout in seconds, at most the configured timeout",
+					"minimum":     1,
+				},
+			},
+			"required": []string{"command"},
+		},
+	}
+}
+
+// RunCommandConfig holds the configuration for the run_command tool
+type RunCommandConfig struct {
+	// AllowedCommands are the programs that may run, by name ("go") or by
+	// exact absolute path ("/usr/bin/make")
+	AllowedCommands []string
+	// Unsafe allows any command
+	Unsafe bool
+	// AllowedEnv are the environment variables callers may set. PATH and
+	// the dynamic loader's LD_* and DYLD_* variables are refused even when
+	// listed, as they would run other code than the allowed commands.
+	AllowedEnv []string
+	// WorkingDir is the directory commands run in; a cwd argument must stay
+	// inside it
+	WorkingDir     string
+	Timeout        time.Duration
+	MaxOutputBytes int64
+}
+
+var runCommandConfig *RunCommandConfig
+
+// SetRunCommandConfig enables the run_command tool. A zero timeout or output
+// limit selects the default, and an empty working directory the current one.
+func SetRunCommandConfig(config RunCommandConfig) {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultRunCommandTimeout
+	}
+	if config.MaxOutputBytes <= 0 {
+		config.MaxOutputBytes = DefaultRunCommandMaxOutput
+	}
+	runCommandConfig = &config
+}
+
+// GetRunCommandConfig returns the current configuration, nil when the tool
+// is disabled
+func GetRunCommandConfig() *RunCommandConfig {
+	return runCommandConfig
+}
+
+// commandAllowed reports whether command may run under the allowlist. A bare
+// name matches entries of that name; a path must be absolute and only matches
+// an absolute entry of the same path. A relative path such as "./go" would
+// run a file from the working directory, so it never matches the name "go".
+func (c *RunCommandConfig) commandAllowed(command string) bool {
+	if c.Unsafe {
+		return true
+	}
+	hasPath := strings.ContainsRune(command, '/') || strings.ContainsRune(command, filepath.Separator)
+	if hasPath && !filepath.IsAbs(command) {
+		return false
+	}
+	for _, allowed := range c.AllowedCommands {
+		if hasPath {
+			if filepath.IsAbs(allowed) && filepath.Clean(command) == filepath.Clean(allowed) {
+				return true
+			}
+		} else if command == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// envAllowed reports whether callers may set the environment variable name
+func (c *RunCommandConfig) envAllowed(name string) bool {
+	if protectedEnv(name) {
+		return false
+	}
+	for _, allowed := range c.AllowedEnv {
+		if name == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// protectedEnv reports whether the environment variable name changes which
+// program or library runs, so it may never be set by a caller
+func protectedEnv(name string) bool {
+	upper := strings.ToUpper(name)
+	return upper == "PATH" || strings.HasPrefix(upper, "LD_") || strings.HasPrefix(upper, "DYLD_")
+}
+
+// commandDir returns the directory a command with the cwd argument runs in,
+// rejecting directories outside the working directory after symlink
+// resolution
+func (c *RunCommandConfig) commandDir(cwd string) (string, error) {
+	if cwd == "" {
+		return c.WorkingDir, nil
+	}
+	root := c.WorkingDir
+	if root == "" {
+		root = "."
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the working directory: %v", err)
+	}
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return "", fmt.Errorf("failed to resolve the working directory: %v", err)
+	}
+	dir := cwd
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(root, dir)
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("cwd %s: %v", cwd, err)
+	}
+	if !withinRoot(resolved, root) {
+		return "", fmt.Errorf("cwd %s is outside the working directory", cwd)
+	}
+	return resolved, nil
+}
+
+// limitedBuffer keeps the first max bytes written to it and counts the rest
+type limitedBuffer struct {
+	buf     []byte
+	max     int64
+	dropped int64
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	room := b.max - int64(len(b.buf))
+	if room < int64(len(p)) {
+		if room > 0 {
+			b.buf = append(b.buf, p[:room]...)
+		}
+		b.dropped += int64(len(p)) - max(room, 0)
+		return len(p), nil
+	}
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+// CallRunCommand runs an allowed command and reports its exit code and
+// output. The command and its children are killed when ctx is cancelled or
+// the timeout expires.
+func CallRunCommand(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+	if runCommandConfig == nil {
+		return nil, fmt.Errorf("run_command not configured. Set run_command.enabled and allowed_commands in the config file")
+	}
+	config := runCommandConfig
+
+	var args struct {
+		Command string            `arg:"command,required"`
+		Args    []string          `arg:"args"`
+		Cwd     string            `arg:"cwd"`
+		Env     map[string]string `arg:"env"`
+		Timeout int               `arg:"timeout"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	command := args.Command
+	if !config.commandAllowed(command) {
+		return nil, fmt.Errorf("command %q is not allowed", command)
+	}
+
+	dir, err := config.commandDir(args.Cwd)
+	if err != nil {
+		return nil, err
+	}
+
+	env := os.Environ()
+	names := make([]string, 0, len(args.Env))
+	for name := range args.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !config.envAllowed(name) {
+			return nil, fmt.Errorf("env variable %s is not allowed", name)
+		}
+		env = append(env, name+"="+args.Env[name])
+	}
+
+	timeout := config.Timeout
+	if t := time.Duration(args.Timeout) * time.Second; t > 0 && t < timeout {
+		timeout = t
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	output := &limitedBuffer{max: config.MaxOutputBytes}
+	cmd := exec.CommandContext(ctx, command, args.Args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdout = output
+	cmd.Stderr = output
+	setProcessGroup(cmd)
+	limitCommand(ctx, cmd)
+	cmd.Cancel = func() error { return killProcessGroup(cmd) }
+	// Children holding the output pipes open must not keep Wait blocked
+	cmd.WaitDelay = 2 * time.Second
+
+	start := time.Now()
+	err = cmd.Run()
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	// A command that fails or is killed still returns its output, flagged
+	// as an error the model can react to
+	var exitErr *exec.ExitError
+	var status string
+	failed := true
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		status = fmt.Sprintf("Killed: timed out after %s", timeout)
+	case ctx.Err() != nil:
+		status = "Killed: cancelled"
+	case err == nil:
+		status, failed = "Exit code: 0", false
+	case errors.As(err, &exitErr):
+		status = fmt.Sprintf("Exit code: %d", exitErr.ExitCode())
+	case errors.Is(err, exec.ErrWaitDelay):
+		status, failed = "Exit code: 0 (output still held open by a child process)", false
+	default:
+		return nil, fmt.Errorf("failed to run %s: %v", command, err)
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "%s (%s)\n", status, elapsed)
+	if len(output.buf) > 0 {
+		result.WriteString("\n")
+		result.Write(output.buf)
+	}
+	if output.dropped > 0 {
+		fmt.Fprintf(&result, "\n[Truncated: %d more bytes of output]", output.dropped)
+	}
+	if failed {
+		return ErrorResult(result.String()), nil
+	}
+	return TextResult(result.String()), nil
+}
@@ -0,0 +1,139 @@
+//go:build unix
+
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCallRunCommand(t *testing.T) {
+	dir := t.TempDir()
+	SetRunCommandConfig(RunCommandConfig{AllowedCommands: []string{"sh", "/bin/echo"}, AllowedEnv: []string{"GREETING"}, WorkingDir: dir, MaxOutputBytes: 16})
+	defer func() { runCommandConfig = nil }()
+
+	result, err := resultText(CallRunCommand(context.Background(), map[string]interface{}{
+		"command": "sh",
+		"args":    []interface{}{"-c", "pwd; echo $GREETING >&2; exit 3"},
+		"env":     map[string]interface{}{"GREETING": "hi"},
+	}))
+	if err != nil {
+		t.Fatalf("CallRunCommand failed: %v", err)
+	}
+	if !strings.HasPrefix(result, "Exit code: 3 (") || !strings.Contains(result, "\n\n"+dir[:min(len(dir), 16)]) {
+		t.Errorf("Unexpected result:\n%s", result)
+	}
+	if !strings.Contains(result, "[Truncated: ") {
+		t.Errorf("Expected the output to be truncated:\n%s", result)
+	}
+
+	// Only a failing command is flagged as an error
+	for command, failed := range map[string]bool{"exit 0": false, "exit 1": true} {
+		executed, err := CallRunCommand(context.Background(), map[string]interface{}{"command": "sh", "args": []interface{}{"-c", command}})
+		if err != nil || executed.IsError != failed {
+			t.Errorf("Expected %q to set isError %v, got %+v, %v", command, failed, executed, err)
+		}
+	}
+
+	for _, command := range []string{"rm", "echo", "/usr/bin/sh"} {
+		if _, err := CallRunCommand(context.Background(), map[string]interface{}{"command": command}); err == nil || !strings.Contains(err.Error(), "not allowed") {
+			t.Errorf("Expected %s to be rejected, got %v", command, err)
+		}
+	}
+}
+
+func TestCallRunCommandConfinement(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(t.TempDir(), filepath.Join(dir, "out")); err != nil {
+		t.Fatal(err)
+	}
+	SetRunCommandConfig(RunCommandConfig{AllowedCommands: []string{"pwd", "env"}, AllowedEnv: []string{"GOFLAGS", "LD_PRELOAD", "PATH"}, WorkingDir: dir})
+	defer func() { runCommandConfig = nil }()
+
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := resultText(CallRunCommand(context.Background(), map[string]interface{}{"command": "pwd", "cwd": "sub"}))
+	if err != nil || !strings.Contains(result, filepath.Join(resolved, "sub")) {
+		t.Errorf("Expected to run in sub, got %q, %v", result, err)
+	}
+
+	// The working directory cannot be left by path, by ".." or by a symlink
+	for _, cwd := range []string{"/", "../..", "sub/../..", "out"} {
+		if _, err := CallRunCommand(context.Background(), map[string]interface{}{"command": "pwd", "cwd": cwd}); err == nil || !strings.Contains(err.Error(), "outside the working directory") {
+			t.Errorf("Expected cwd %s to be rejected, got %v", cwd, err)
+		}
+	}
+
+	// Only allowed variables are set, and never those choosing what runs
+	result, err = resultText(CallRunCommand(context.Background(), map[string]interface{}{"command": "env", "env": map[string]interface{}{"GOFLAGS": "-v"}}))
+	if err != nil || !strings.Contains(result, "GOFLAGS=-v") {
+		t.Errorf("Expected GOFLAGS to be set, got %q, %v", result, err)
+	}
+	for _, name := range []string{"BASH_ENV", "LD_PRELOAD", "PATH", "DYLD_INSERT_LIBRARIES"} {
+		if _, err := CallRunCommand(context.Background(), map[string]interface{}{"command": "env", "env": map[string]interface{}{name: "x"}}); err == nil || !strings.Contains(err.Error(), "is not allowed") {
+			t.Errorf("Expected %s to be rejected, got %v", name, err)
+		}
+	}
+}
+
+func TestCallRunCommandRelativePath(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	script := "#!/bin/sh\necho PWNED\n"
+	for _, path := range []string{"go", "sub/go"} {
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	SetRunCommandConfig(RunCommandConfig{AllowedCommands: []string{"go", "sub/go", "/bin/echo"}, WorkingDir: dir})
+	defer func() { runCommandConfig = nil }()
+
+	// A file written into the working directory cannot pass for an allowed
+	// name, and relative entries never match
+	for _, command := range []string{"./go", "sub/../go", "sub/go", "./sub/go"} {
+		result, err := resultText(CallRunCommand(context.Background(), map[string]interface{}{"command": command, "cwd": "sub"}))
+		if err == nil || !strings.Contains(err.Error(), "is not allowed") || strings.Contains(result, "PWNED") {
+			t.Errorf("Expected %s to be rejected, got %q, %v", command, result, err)
+		}
+	}
+	if _, err := CallRunCommand(context.Background(), map[string]interface{}{"command": "/bin/../bin/echo", "args": []interface{}{"hi"}}); err != nil {
+		t.Errorf("Expected an absolute path to match its entry, got %v", err)
+	}
+}
+
+func TestCallRunCommandKillsOnCancel(t *testing.T) {
+	SetRunCommandConfig(RunCommandConfig{AllowedCommands: []string{"sh"}})
+	defer func() { runCommandConfig = nil }()
+
+	// The background sleep holds the output open; it must die with the shell
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	result, err := resultText(CallRunCommand(ctx, map[string]interface{}{"command": "sh", "args": []interface{}{"-c", "sleep 30 & sleep 30"}}))
+	if err != nil {
+		t.Fatalf("CallRunCommand failed: %v", err)
+	}
+	if !strings.HasPrefix(result, "Killed: ") {
+		t.Errorf("Expected the command to be killed, got:\n%s", result)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the command to be killed promptly, took %s", elapsed)
+	}
+
+	SetRunCommandConfig(RunCommandConfig{AllowedCommands: []string{"sh"}, Timeout: 100 * time.Millisecond})
+	result, _ = resultText(CallRunCommand(context.Background(), map[string]interface{}{"command": "sh", "args": []interface{}{"-c", "sleep 30"}}))
+	if !strings.HasPrefix(result, "Killed: timed out after 100ms") {
+		t.Errorf("Expected a timeout, got:\n%s", result)
+	}
+}
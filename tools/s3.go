@@ -0,0 +1,547 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Defaults for the S3 tools when the configuration leaves them unset
+const (
+	DefaultS3Timeout        = 60 * time.Second
+	DefaultS3MaxObjectBytes = 10 << 20
+	DefaultS3Region         = "us-east-1"
+)
+
+// S3Tool represents an S3 tool definition
+type S3Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// s3BucketProperty is the schema of the bucket argument of every S3 tool
+var s3BucketProperty = map[string]interface{}{
+	"type":        "string",
+	"description": "Name of the configured bucket (optional when a single bucket is configured)",
+}
+
+// s3KeyProperty is the schema of the key argument of the object tools
+var s3KeyProperty = map[string]interface{}{
+	"type":        "string",
+	"description": "Key of the object, e.g. \"reports/2024/summary.csv\"",
+}
+
+// GetS3Tools returns the definitions of the S3 tools
+func GetS3Tools() []S3Tool {
+	return []S3Tool{
+		{
+			Name:        "s3_list",
+			Description: "List the objects of an S3 bucket, optionally under a prefix. Keys sharing the part up to the delimiter are grouped as common prefixes, like directories.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"bucket": s3BucketProperty,
+					"prefix": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list keys starting with this prefix",
+					},
+					"delimiter": map[string]interface{}{
+						"type":        "string",
+						"description": "Group keys up to this character into common prefixes (default: \"/\"; empty lists every key)",
+						"default":     "/",
+					},
+					"max_keys": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of entries (1-1000, default: 100)",
+						"default":     100,
+						"minimum":     1,
+						"maximum":     1000,
+					},
+					"continuation_token": map[string]interface{}{
+						"type":        "string",
+						"description": "Token from a previous truncated listing to continue from",
+					},
+				},
+			},
+		},
+		{
+			Name:        "s3_get_object",
+			Description: "Read an object of an S3 bucket. Text is returned as an embedded resource, other content base64 encoded.",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"bucket": s3BucketProperty,
+					"key":    s3KeyProperty,
+				},
+				"required": []string{"key"},
+			},
+		},
+		{
+			Name:        "s3_put_object",
+			Description: "Write an object to an S3 bucket, replacing any object with the same key",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"bucket": s3BucketProperty,
+					"key":    s3KeyProperty,
+					"content": map[string]interface{}{
+						"type":        "string",
+						"description": "Content of the object",
+					},
+					"encoding": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"text", "base64"},
+						"description": "Encoding of content (default: text)",
+					},
+					"content_type": map[string]interface{}{
+						"type":        "string",
+						"description": "MIME type of the object (default: detected from the content)",
+					},
+				},
+				"required": []string{"key", "content"},
+			},
+		},
+		{
+			Name:        "s3_delete_object",
+			Description: "Delete an object from an S3 bucket",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"bucket": s3BucketProperty,
+					"key":    s3KeyProperty,
+				},
+				"required": []string{"key"},
+			},
+		},
+	}
+}
+
+// IsS3Tool reports whether name is one of the S3 tools
+func IsS3Tool(name string) bool {
+	for _, tool := range GetS3Tools() {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// S3Bucket is a bucket the S3 tools may use, with its credentials
+type S3Bucket struct {
+	Bucket string // Name of the bucket in the object store
+	// Endpoint is the base URL of the service (empty = AWS S3 in Region)
+	Endpoint        string
+	Region          string // Empty = us-east-1
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // For temporary credentials
+	// PathStyle addresses the bucket in the path instead of the host name,
+	// as MinIO and most other S3-compatible stores expect
+	PathStyle bool
+	ReadOnly  bool // Rejects s3_put_object and s3_delete_object
+}
+
+// S3Config holds the configuration for the S3 tools
+type S3Config struct {
+	Buckets        map[string]S3Bucket // Keyed by the name the tools use
+	Timeout        time.Duration
+	MaxObjectBytes int64
+}
+
+var s3Config *S3Config
+
+// SetS3Config enables the S3 tools for the buckets. A zero timeout or object
+// size limit selects the default.
+func SetS3Config(buckets map[string]S3Bucket, timeout time.Duration, maxObjectBytes int64) {
+	if timeout <= 0 {
+		timeout = DefaultS3Timeout
+	}
+	if maxObjectBytes <= 0 {
+		maxObjectBytes = DefaultS3MaxObjectBytes
+	}
+	s3Config = &S3Config{Buckets: buckets, Timeout: timeout, MaxObjectBytes: maxObjectBytes}
+}
+
+// GetS3Config returns the current configuration, nil when the tools are
+// disabled
+func GetS3Config() *S3Config {
+	return s3Config
+}
+
+// bucketNames returns the configured bucket names, sorted
+func (c *S3Config) bucketNames() []string {
+	names := make([]string, 0, len(c.Buckets))
+	for name := range c.Buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// bucket returns the bucket named by the bucket argument
+func (c *S3Config) bucket(name string) (string, S3Bucket, error) {
+	if name == "" {
+		if len(c.Buckets) != 1 {
+			return "", S3Bucket{}, fmt.Errorf("bucket argument is required, configured: %s", strings.Join(c.bucketNames(), ", "))
+		}
+		name = c.bucketNames()[0]
+	}
+	bucket, ok := c.Buckets[name]
+	if !ok {
+		return "", S3Bucket{}, fmt.Errorf("unknown bucket %q, configured: %s", name, strings.Join(c.bucketNames(), ", "))
+	}
+	return name, bucket, nil
+}
+
+// CallS3Tool executes the named S3 tool
+func CallS3Tool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+	config := s3Config
+	if config == nil {
+		return nil, fmt.Errorf("S3 tools not configured. Set s3.enabled and buckets in the config file")
+	}
+	args := &s3Arguments{Delimiter: "/", MaxKeys: 100}
+	if err := DecodeArgs(arguments, args); err != nil {
+		return nil, err
+	}
+	bucketName, bucket, err := config.bucket(args.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	if name == "s3_list" {
+		return textResult(config.list(ctx, bucket, args))
+	}
+
+	key := args.Key
+	if key == "" {
+		return nil, fmt.Errorf("key argument is required and must be a non-empty string")
+	}
+	if bucket.ReadOnly && (name == "s3_put_object" || name == "s3_delete_object") {
+		return nil, fmt.Errorf("bucket %q is read-only", bucketName)
+	}
+
+	switch name {
+	case "s3_get_object":
+		return config.getObject(ctx, bucket, key)
+	case "s3_put_object":
+		return textResult(config.putObject(ctx, bucket, key, args))
+	case "s3_delete_object":
+		resp, err := bucket.do(ctx, "DELETE", key, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		return TextResult(fmt.Sprintf("Deleted s3://%s/%s", bucket.Bucket, key)), nil
+	default:
+		return nil, fmt.Errorf("unknown S3 tool: %s", name)
+	}
+}
+
+// s3Arguments are the arguments of the S3 tools
+type s3Arguments struct {
+	Bucket            string  `arg:"bucket"`
+	Key               string  `arg:"key"`
+	Prefix            string  `arg:"prefix"`
+	Delimiter         string  `arg:"delimiter"`
+	MaxKeys           int     `arg:"max_keys"`
+	ContinuationToken string  `arg:"continuation_token"`
+	Content           *string `arg:"content"` // May be empty
+	Encoding          string  `arg:"encoding"`
+	ContentType       string  `arg:"content_type"`
+}
+
+// s3ListResult is the part of a ListObjectsV2 response the tool uses
+type s3ListResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string `xml:"Key"`
+		Size         int64  `xml:"Size"`
+		LastModified string `xml:"LastModified"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+}
+
+// list lists the objects of a bucket
+func (c *S3Config) list(ctx context.Context, bucket S3Bucket, args *s3Arguments) (string, error) {
+	maxKeys := args.MaxKeys
+	if maxKeys < 1 || maxKeys > 1000 {
+		maxKeys = 100
+	}
+	query := url.Values{}
+	query.Set("list-type", "2")
+	query.Set("max-keys", strconv.Itoa(maxKeys))
+	delimiter, prefix := args.Delimiter, args.Prefix
+	if delimiter != "" {
+		query.Set("delimiter", delimiter)
+	}
+	if prefix != "" {
+		query.Set("prefix", prefix)
+	}
+	if args.ContinuationToken != "" {
+		query.Set("continuation-token", args.ContinuationToken)
+	}
+
+	resp, err := bucket.do(ctx, "GET", "", query, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result s3ListResult
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to parse listing: %w", err)
+	}
+
+	if len(result.Contents) == 0 && len(result.CommonPrefixes) == 0 {
+		return fmt.Sprintf("No objects in s3://%s/%s", bucket.Bucket, prefix), nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "s3://%s/%s\n\n", bucket.Bucket, prefix)
+	for _, p := range result.CommonPrefixes {
+		fmt.Fprintf(&b, "[PREFIX] %s\n", p.Prefix)
+	}
+	for _, object := range result.Contents {
+		fmt.Fprintf(&b, "%s\t%d bytes\t%s\n", object.Key, object.Size, object.LastModified)
+	}
+	if result.IsTruncated {
+		fmt.Fprintf(&b, "\n[More entries; continue with continuation_token %q]\n", result.NextContinuationToken)
+	}
+	return b.String(), nil
+}
+
+// getObject reads an object, up to the configured size. Text is embedded as
+// a resource named by its s3:// URI.
+func (c *S3Config) getObject(ctx context.Context, bucket S3Bucket, key string) (*ToolResult, error) {
+	resp, err := bucket.do(ctx, "GET", key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, c.MaxObjectBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+	note := ""
+	if int64(len(data)) > c.MaxObjectBytes {
+		data = data[:c.MaxObjectBytes]
+		note = fmt.Sprintf("[Truncated after %d bytes]", c.MaxObjectBytes)
+	}
+	uri := fmt.Sprintf("s3://%s/%s", bucket.Bucket, key)
+	contentType := resp.Header.Get("Content-Type")
+	if !utf8.Valid(data) {
+		return TextResult(fmt.Sprintf("%s (%s, %d bytes, base64 encoded)\n\n%s\n%s", uri, contentType, len(data), base64.StdEncoding.EncodeToString(data), note)), nil
+	}
+	result := ResourceResult(uri, contentType, string(data))
+	if note != "" {
+		result.Content = append(result.Content, TextContent(note))
+	}
+	return result, nil
+}
+
+// putObject writes an object
+func (c *S3Config) putObject(ctx context.Context, bucket S3Bucket, key string, args *s3Arguments) (string, error) {
+	if args.Content == nil {
+		return "", fmt.Errorf("content argument is required and must be a string")
+	}
+	content := *args.Content
+	data := []byte(content)
+	if encoding := args.Encoding; encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return "", fmt.Errorf("content is not valid base64: %w", err)
+		}
+		data = decoded
+	} else if encoding != "" && encoding != "text" {
+		return "", fmt.Errorf("unsupported encoding %q, use text or base64", encoding)
+	}
+	if int64(len(data)) > c.MaxObjectBytes {
+		return "", fmt.Errorf("object of %d bytes exceeds the limit of %d bytes", len(data), c.MaxObjectBytes)
+	}
+	contentType := args.ContentType
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+
+	resp, err := bucket.do(ctx, "PUT", key, nil, http.Header{"Content-Type": {contentType}}, data)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return fmt.Sprintf("Wrote %d bytes to s3://%s/%s", len(data), bucket.Bucket, key), nil
+}
+
+// do sends a signed request for an object of the bucket, or for the bucket
+// itself when key is empty. Responses other than 2xx are returned as errors.
+func (b S3Bucket) do(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	region := b.Region
+	if region == "" {
+		region = DefaultS3Region
+	}
+	endpoint := b.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	u, err := url.Parse(strings.TrimRight(endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: %w", endpoint, err)
+	}
+	path := u.Path
+	if b.PathStyle {
+		path += "/" + b.Bucket
+	} else {
+		u.Host = b.Bucket + "." + u.Host
+	}
+	u.Path = path + "/" + key
+	u.RawPath = s3EscapePath(path) + "/" + s3EscapePath(key)
+	u.RawQuery = s3CanonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	signS3Request(req, body, region, b.AccessKeyID, b.SecretAccessKey, b.SessionToken, time.Now())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("S3 request failed: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		var s3Err struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if xml.Unmarshal(data, &s3Err) == nil && s3Err.Code != "" {
+			return nil, fmt.Errorf("S3 returned %s (status %d): %s", s3Err.Code, resp.StatusCode, s3Err.Message)
+		}
+		return nil, fmt.Errorf("S3 returned status %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// signS3Request adds AWS Signature Version 4 headers to req
+func signS3Request(req *http.Request, body []byte, region, accessKeyID, secretAccessKey, sessionToken string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	// Sign the host and every x-amz-* header
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	signature := hex.EncodeToString(hmacSHA256(s3SigningKey(secretAccessKey, date, region, "s3"), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKeyID, scope, signedHeaders, signature))
+}
+
+// s3SigningKey derives the Signature Version 4 signing key for a day
+func s3SigningKey(secretAccessKey, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// s3Escape percent-encodes everything but the unreserved characters of
+// RFC 3986, as Signature Version 4 requires
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3EscapePath escapes each segment of a path, keeping the slashes
+func s3EscapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3CanonicalQuery encodes a query string sorted by name, as signed
+func s3CanonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			parts = append(parts, s3Escape(name)+"="+s3Escape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
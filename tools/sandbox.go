@@ -0,0 +1,343 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// allowedRoots are the directories the filesystem tools may touch, in
+// absolute symlink-resolved form. Empty means unrestricted.
+var (
+	allowedRoots  []string
+	readOnly      bool
+	maxReadBytes  int64 = DefaultMaxReadBytes
+	maxWriteBytes int64 = DefaultMaxWriteBytes
+	sandboxMu     sync.RWMutex
+)
+
+// Default size limits of the filesystem tools
+const (
+	DefaultMaxReadBytes  = 10 << 20
+	DefaultMaxWriteBytes = 10 << 20
+)
+
+// mutatingTools are the filesystem tools that change the filesystem, hidden
+// and rejected in read-only mode
+var mutatingTools = map[string]bool{
+	"write_file":       true,
+	"append_file":      true,
+	"edit_file":        true,
+	"create_directory": true,
+	"delete_file":      true,
+	"move_file":        true,
+	"copy_file":        true,
+	"set_permissions":  true,
+	"create_archive":   true,
+	"extract_archive":  true,
+}
+
+// SetReadOnly turns read-only mode on or off. In read-only mode the tools
+// that change the filesystem fail with an access denied error.
+func SetReadOnly(enabled bool) {
+	sandboxMu.Lock()
+	defer sandboxMu.Unlock()
+	readOnly = enabled
+}
+
+// IsReadOnly reports whether read-only mode is on
+func IsReadOnly() bool {
+	sandboxMu.RLock()
+	defer sandboxMu.RUnlock()
+	return readOnly
+}
+
+// SetSizeLimits sets the largest content, in bytes, the filesystem tools
+// return from a read and accept for a write. Reads beyond the limit are
+// truncated with a notice; larger writes are rejected. Zero restores the
+// default.
+func SetSizeLimits(maxRead, maxWrite int64) {
+	if maxRead <= 0 {
+		maxRead = DefaultMaxReadBytes
+	}
+	if maxWrite <= 0 {
+		maxWrite = DefaultMaxWriteBytes
+	}
+	sandboxMu.Lock()
+	defer sandboxMu.Unlock()
+	maxReadBytes, maxWriteBytes = maxRead, maxWrite
+}
+
+// sizeLimits returns the read and write size limits
+func sizeLimits() (maxRead, maxWrite int64) {
+	sandboxMu.RLock()
+	defer sandboxMu.RUnlock()
+	return maxReadBytes, maxWriteBytes
+}
+
+// checkWriteSize rejects writing size bytes when it exceeds the write limit
+func checkWriteSize(size int) error {
+	if _, maxWrite := sizeLimits(); int64(size) > maxWrite {
+		return fmt.Errorf("content is %d bytes, more than the %d byte write limit", size, maxWrite)
+	}
+	return nil
+}
+
+// IsMutatingTool reports whether the filesystem tool name changes the
+// filesystem, so servers can hide it in read-only mode
+func IsMutatingTool(name string) bool {
+	return mutatingTools[name]
+}
+
+// checkWritable rejects a call to the mutating tool name in read-only mode
+func checkWritable(name string) error {
+	if IsReadOnly() {
+		return fmt.Errorf("access denied: %s is disabled in read-only mode", name)
+	}
+	return nil
+}
+
+// SetAllowedPaths restricts the filesystem tools to the given directories.
+// Every path argument must resolve inside one of them after symlink
+// resolution, otherwise the call is rejected. An empty list lifts the
+// restriction.
+func SetAllowedPaths(paths []string) error {
+	roots := make([]string, 0, len(paths))
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fmt.Errorf("failed to resolve allowed path %s: %v", path, err)
+		}
+		resolved, err := filepath.EvalSymlinks(abs)
+		if err != nil {
+			return fmt.Errorf("allowed path %s: %v", path, err)
+		}
+		info, err := os.Stat(resolved)
+		if err != nil {
+			return fmt.Errorf("allowed path %s: %v", path, err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("allowed path %s is not a directory", path)
+		}
+		roots = append(roots, resolved)
+	}
+
+	sandboxMu.Lock()
+	defer sandboxMu.Unlock()
+	allowedRoots = roots
+	return nil
+}
+
+// GetAllowedPaths returns the directories the filesystem tools are
+// restricted to, or nil when unrestricted
+func GetAllowedPaths() []string {
+	sandboxMu.RLock()
+	defer sandboxMu.RUnlock()
+	return append([]string(nil), allowedRoots...)
+}
+
+// resolvePath returns the absolute, symlink-resolved form of path, rejecting
+// it when it falls outside every allowed root. The path itself need not
+// exist, so it can be used for files about to be created.
+func resolvePath(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %v", err)
+	}
+
+	roots := GetAllowedPaths()
+	if len(roots) == 0 {
+		return absPath, nil
+	}
+
+	resolved, err := evalSymlinksAllowMissing(absPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %v", err)
+	}
+	for _, root := range roots {
+		if withinRoot(resolved, root) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("access denied: %s is outside the allowed paths", path)
+}
+
+// resolveEntryPath is like resolvePath but keeps a final symlink unresolved,
+// for operations such as deletion that act on the link rather than on its
+// target
+func resolveEntryPath(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %v", err)
+	}
+	if len(GetAllowedPaths()) == 0 {
+		return absPath, nil
+	}
+	parent, err := resolvePath(filepath.Dir(absPath))
+	if err != nil {
+		return "", fmt.Errorf("access denied: %s is outside the allowed paths", path)
+	}
+	return filepath.Join(parent, filepath.Base(absPath)), nil
+}
+
+// resolveFollow resolves path with resolvePath when follow is set, and
+// otherwise with resolveEntryPath so a final symlink is kept
+func resolveFollow(path string, follow bool) (string, error) {
+	if follow {
+		return resolvePath(path)
+	}
+	return resolveEntryPath(path)
+}
+
+// sandboxOpenFlags returns openNoFollow while the tools are restricted to
+// allowed paths. resolvePath has then already resolved every symlink, so a
+// link found when opening was swapped in after the check.
+func sandboxOpenFlags() int {
+	if len(GetAllowedPaths()) == 0 {
+		return 0
+	}
+	return openNoFollow
+}
+
+// symlinkEscapes reports whether the symlink at path points outside every
+// allowed root, or at nothing when unrestricted
+func symlinkEscapes(path string) bool {
+	if _, err := resolvePath(path); err != nil {
+		return true
+	}
+	_, err := os.Stat(path)
+	return err != nil
+}
+
+// followSymlinksProperty is the input schema of the follow_symlinks argument
+func followSymlinksProperty(description string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "boolean",
+		"description": description,
+	}
+}
+
+// isProtectedPath reports whether path may not be removed, moved or
+// replaced: one of the allowed roots, or the root of a filesystem volume,
+// which is protected even when the tools are unrestricted
+func isProtectedPath(path string) bool {
+	if filepath.Dir(path) == path {
+		return true
+	}
+	for _, root := range GetAllowedPaths() {
+		if path == root {
+			return true
+		}
+	}
+	return false
+}
+
+// maxSymlinks bounds the symlinks followed while resolving one path, as
+// the kernel does, so a cycle of dangling links cannot recurse forever
+const maxSymlinks = 255
+
+// evalSymlinksAllowMissing resolves the symlinks of the longest existing
+// ancestor of path and appends the missing remainder. A dangling symlink is
+// followed to where its target would be created, so it cannot be used to
+// write outside the allowed roots.
+func evalSymlinksAllowMissing(path string) (string, error) {
+	volume := filepath.VolumeName(path)
+	links := 0
+	return resolveComponents(volume+string(filepath.Separator), path[len(volume):], &links)
+}
+
+// resolveLink returns where the symlink target link leads when read in the
+// resolved directory dir, following further symlinks on the way
+func resolveLink(dir, link string) (string, error) {
+	links := 0
+	return resolveLinkFrom(dir, link, &links)
+}
+
+func resolveLinkFrom(dir, link string, links *int) (string, error) {
+	if filepath.IsAbs(link) {
+		volume := filepath.VolumeName(link)
+		return resolveComponents(volume+string(filepath.Separator), link[len(volume):], links)
+	}
+	return resolveComponents(dir, link, links)
+}
+
+// resolveComponents walks rest from the resolved directory dir one
+// component at a time, as the kernel does: a symlink is replaced by its
+// target before the components after it, so "link/.." is the parent of
+// where link leads rather than the directory holding link. Components past
+// a missing one are joined as they are.
+func resolveComponents(dir, rest string, links *int) (string, error) {
+	for _, name := range strings.Split(filepath.ToSlash(rest), "/") {
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			dir = filepath.Dir(dir)
+			continue
+		}
+		next := filepath.Join(dir, name)
+		info, err := os.Lstat(next)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			dir = next
+			continue
+		}
+		if *links++; *links > maxSymlinks {
+			return "", fmt.Errorf("too many links in %s", next)
+		}
+		target, err := os.Readlink(next)
+		if err != nil {
+			return "", err
+		}
+		if dir, err = resolveLinkFrom(dir, target, links); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// withinRoot reports whether path is root or lies below it
+func withinRoot(path, root string) bool {
+	if path == root {
+		return true
+	}
+	return strings.HasPrefix(path, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// resolveRoots returns the absolute, symlink-resolved form of the
+// directories a tool may read from
+func resolveRoots(paths []string) ([]string, error) {
+	roots := make([]string, 0, len(paths))
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve allowed path %s: %v", path, err)
+		}
+		resolved, err := filepath.EvalSymlinks(abs)
+		if err != nil {
+			return nil, fmt.Errorf("allowed path %s: %v", path, err)
+		}
+		roots = append(roots, resolved)
+	}
+	return roots, nil
+}
+
+// resolveUnder returns the symlink-resolved form of path, rejecting it when
+// it falls outside every root
+func resolveUnder(path string, roots []string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path: %v", err)
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	for _, root := range roots {
+		if withinRoot(resolved, root) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("access denied: %s is outside the allowed paths", path)
+}
@@ -0,0 +1,48 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SearchResult is a single result of a web search tool
+type SearchResult struct {
+	Title   string `json:"title"`
+	Link    string `json:"link"`
+	Snippet string `json:"snippet"`
+	// DisplayLink is the shortened link engines show, such as "go.dev"
+	DisplayLink string `json:"displayLink,omitempty"`
+}
+
+// formatSearchResults renders the results of a web search tool. total is the
+// engine's estimate of all matches, empty when it gives none.
+func formatSearchResults(total string, results []SearchResult) string {
+	if len(results) == 0 {
+		return "No results found for your search query."
+	}
+
+	var b strings.Builder
+	if total != "" {
+		fmt.Fprintf(&b, "Found %s results:\n\n", total)
+	} else {
+		fmt.Fprintf(&b, "Found %d results:\n\n", len(results))
+	}
+	for i, result := range results {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, result.Title)
+		fmt.Fprintf(&b, "   URL: %s\n", result.Link)
+		fmt.Fprintf(&b, "   %s\n\n", result.Snippet)
+	}
+	return b.String()
+}
+
+// stripHTMLTags returns the text of an HTML fragment, such as a snippet with
+// highlighted matches
+func stripHTMLTags(fragment string) string {
+	var b strings.Builder
+	for _, token := range tokenizeHTML(fragment) {
+		if token.tag == "" {
+			b.WriteString(token.text)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SearXNGTool represents the SearXNG search tool definition
+type SearXNGTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// GetSearXNGTool returns the SearXNG search tool definition
+func GetSearXNGTool() SearXNGTool {
+	return SearXNGTool{
+		Name:        "searxng_search",
+		Description: "Search the web using a self-hosted SearXNG metasearch instance",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "The search query",
+				},
+				"max_results": map[string]interface{}{
+					"type":        "integer",
+					"description": "Number of results to return (1-50, default: 10)",
+					"default":     10,
+					"minimum":     1,
+					"maximum":     50,
+				},
+				"page": map[string]interface{}{
+					"type":        "integer",
+					"description": "Page of results (default: 1)",
+					"default":     1,
+					"minimum":     1,
+				},
+				"categories": map[string]interface{}{
+					"type":        "string",
+					"description": "Comma-separated SearXNG categories, e.g. \"general\", \"news\" or \"it\"",
+				},
+				"language": map[string]interface{}{
+					"type":        "string",
+					"description": "Language of the results, e.g. \"en\" or \"de\"",
+				},
+				"time_range": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"day", "month", "year"},
+					"description": "Only return results from this period",
+				},
+			},
+			"required": []string{"query"},
+		},
+	}
+}
+
+// searXNGResponse is the part of the SearXNG JSON response the tool uses
+type searXNGResponse struct {
+	NumberOfResults float64 `json:"number_of_results"`
+	Results         []struct {
+		Title   string `json:"title"`
+		URL     string `json:"url"`
+		Content string `json:"content"`
+	} `json:"results"`
+}
+
+var searXNGURL string
+
+// SetSearXNGConfig enables the SearXNG search tool with the base URL of an
+// instance, e.g. "https://search.example.com"
+func SetSearXNGConfig(instanceURL string) {
+	searXNGURL = strings.TrimRight(instanceURL, "/")
+}
+
+// SearXNGConfigured reports whether a SearXNG instance is configured
+func SearXNGConfigured() bool {
+	return searXNGURL != ""
+}
+
+// CallSearXNG executes a search on the configured SearXNG instance. The
+// instance must have the JSON output format enabled.
+func CallSearXNG(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+	if searXNGURL == "" {
+		return nil, fmt.Errorf("SearXNG not configured. Please set the instance URL")
+	}
+
+	args := struct {
+		Query      string `arg:"query,required"`
+		MaxResults int    `arg:"max_results"`
+		Page       int    `arg:"page"`
+		Categories string `arg:"categories"`
+		Language   string `arg:"language"`
+		TimeRange  string `arg:"time_range"`
+	}{MaxResults: 10}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	maxResults := args.MaxResults
+	if maxResults < 1 || maxResults > 50 {
+		maxResults = 10
+	}
+
+	params := url.Values{}
+	params.Set("q", args.Query)
+	params.Set("format", "json")
+	if args.Page > 1 {
+		params.Set("pageno", strconv.Itoa(args.Page))
+	}
+	for name, value := range map[string]string{"categories": args.Categories, "language": args.Language, "time_range": args.TimeRange} {
+		if value != "" {
+			params.Set(name, value)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", searXNGURL+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute search: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden {
+		return nil, fmt.Errorf("SearXNG refused the request; enable the json format under search.formats in its settings.yml")
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("SearXNG returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var apiResp searXNGResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	results := make([]SearchResult, 0, len(apiResp.Results))
+	for _, item := range apiResp.Results {
+		if len(results) == maxResults {
+			break
+		}
+		results = append(results, SearchResult{Title: item.Title, Link: item.URL, Snippet: item.Content})
+	}
+	// Many engines give no estimate, in which case SearXNG reports 0
+	total := ""
+	if apiResp.NumberOfResults > 0 {
+		total = strconv.FormatFloat(apiResp.NumberOfResults, 'f', 0, 64)
+	}
+	return TextResult(formatSearchResults(total, results)), nil
+}
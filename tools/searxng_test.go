@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCallSearXNG(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/search" || query.Get("format") != "json" || query.Get("q") != "golang" || query.Get("time_range") != "year" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"number_of_results":0,"results":[
+			{"title":"Go","url":"https://go.dev/","content":"The Go programming language"},
+			{"title":"Go Packages","url":"https://pkg.go.dev/","content":"Go package documentation"}]}`))
+	}))
+	defer server.Close()
+
+	SetSearXNGConfig(server.URL + "/")
+	defer SetSearXNGConfig("")
+
+	result, err := resultText(CallSearXNG(context.Background(), map[string]interface{}{"query": "golang", "time_range": "year", "max_results": float64(1)}))
+	if err != nil {
+		t.Fatalf("CallSearXNG failed: %v", err)
+	}
+	want := "Found 1 results:\n\n1. Go\n   URL: https://go.dev/\n   The Go programming language\n\n"
+	if result != want {
+		t.Errorf("Unexpected result:\n%q\nwant:\n%q", result, want)
+	}
+}
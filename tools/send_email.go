@@ -0,0 +1,355 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for send_email when the configuration leaves them unset
+const (
+	DefaultEmailMaxPerHour    = 20
+	DefaultEmailMaxRecipients = 10
+)
+
+// SendEmailTool represents the send_email tool definition
+type SendEmailTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// GetSendEmailTool returns the send_email tool definition
+func GetSendEmailTool() SendEmailTool {
+	addresses := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": description,
+		}
+	}
+	return SendEmailTool{
+		Name:        "send_email",
+		Description: "Send an email. Recipients must be in the domains the operator allows, and the number of emails per hour is limited.",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"to":  addresses("Recipient addresses, e.g. [\"Ops <ops@example.com>\"]"),
+				"cc":  addresses("Carbon copy addresses"),
+				"bcc": addresses("Blind carbon copy addresses"),
+				"subject": map[string]interface{}{
+					"type":        "string",
+					"description": "Subject line",
+				},
+				"body": map[string]interface{}{
+					"type":        "string",
+					"description": "Body of the email",
+				},
+				"html": map[string]interface{}{
+					"type":        "boolean",
+					"description": "The body is HTML rather than plain text (default: false)",
+				},
+				"reply_to": map[string]interface{}{
+					"type":        "string",
+					"description": "Address replies should go to",
+				},
+			},
+			"required": []string{"to", "subject", "body"},
+		},
+	}
+}
+
+// EmailConfig holds the configuration for the send_email tool
+type EmailConfig struct {
+	Host     string
+	Port     int
+	Username string // Empty = no authentication
+	Password string
+	From     string
+	// TLS is "starttls", "tls" (implicit TLS, usually port 465) or "none"
+	TLS string
+	// AllowedRecipientDomains are the domains mail may go to; "*.example.com"
+	// also allows subdomains and "*" any domain
+	AllowedRecipientDomains []string
+	MaxPerHour              int
+	MaxRecipients           int
+
+	mu   sync.Mutex
+	sent []time.Time // Send times within the last hour
+}
+
+var emailConfig *EmailConfig
+
+// SetEmailConfig enables the send_email tool. A zero rate or recipient limit
+// selects the default.
+func SetEmailConfig(config *EmailConfig) error {
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address %q: %v", config.From, err)
+	}
+	config.From = from.String()
+	switch config.TLS {
+	case "":
+		config.TLS = "starttls"
+	case "starttls", "tls", "none":
+	default:
+		return fmt.Errorf("invalid tls mode %q, use starttls, tls or none", config.TLS)
+	}
+	if config.Port == 0 {
+		config.Port = 587
+		if config.TLS == "tls" {
+			config.Port = 465
+		}
+	}
+	if config.MaxPerHour <= 0 {
+		config.MaxPerHour = DefaultEmailMaxPerHour
+	}
+	if config.MaxRecipients <= 0 {
+		config.MaxRecipients = DefaultEmailMaxRecipients
+	}
+	emailConfig = config
+	return nil
+}
+
+// GetEmailConfig returns the current configuration, nil when the tool is
+// disabled
+func GetEmailConfig() *EmailConfig {
+	return emailConfig
+}
+
+// CallSendEmail sends an email
+func CallSendEmail(arguments map[string]interface{}) (*ToolResult, error) {
+	config := emailConfig
+	if config == nil {
+		return nil, fmt.Errorf("send_email not configured. Set email.enabled and an SMTP server in the config file")
+	}
+
+	var args struct {
+		To      []string `arg:"to"`
+		Cc      []string `arg:"cc"`
+		Bcc     []string `arg:"bcc"`
+		Subject string   `arg:"subject,required"`
+		Body    *string  `arg:"body"` // May be empty
+		HTML    bool     `arg:"html"`
+		ReplyTo string   `arg:"reply_to"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	var to, cc, bcc []*mail.Address
+	for _, list := range []struct {
+		name      string
+		values    []string
+		addresses *[]*mail.Address
+	}{{"to", args.To, &to}, {"cc", args.Cc, &cc}, {"bcc", args.Bcc, &bcc}} {
+		for _, value := range list.values {
+			address, err := mail.ParseAddress(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s address %q: %v", list.name, value, err)
+			}
+			if !config.recipientAllowed(address.Address) {
+				return nil, fmt.Errorf("recipient %s is not in an allowed domain", address.Address)
+			}
+			*list.addresses = append(*list.addresses, address)
+		}
+	}
+	if len(to) == 0 {
+		return nil, fmt.Errorf("to argument is required and must list at least one address")
+	}
+	recipients := len(to) + len(cc) + len(bcc)
+	if recipients > config.MaxRecipients {
+		return nil, fmt.Errorf("%d recipients exceed the limit of %d", recipients, config.MaxRecipients)
+	}
+
+	subject := args.Subject
+	if strings.ContainsAny(subject, "\r\n") {
+		return nil, fmt.Errorf("subject must be a single line")
+	}
+	if args.Body == nil {
+		return nil, fmt.Errorf("body argument is required and must be a string")
+	}
+	body, html := *args.Body, args.HTML
+	var replyTo *mail.Address
+	var err error
+	if value := args.ReplyTo; value != "" {
+		if replyTo, err = mail.ParseAddress(value); err != nil {
+			return nil, fmt.Errorf("invalid reply_to address %q: %v", value, err)
+		}
+	}
+
+	if err := config.reserve(); err != nil {
+		return nil, err
+	}
+	message, err := config.message(to, cc, replyTo, subject, body, html)
+	if err != nil {
+		return nil, err
+	}
+	envelope := make([]string, 0, recipients)
+	for _, addresses := range [][]*mail.Address{to, cc, bcc} {
+		for _, address := range addresses {
+			envelope = append(envelope, address.Address)
+		}
+	}
+	if err := config.send(envelope, message); err != nil {
+		return nil, err
+	}
+	return TextResult(fmt.Sprintf("Sent %q to %d recipients", subject, recipients)), nil
+}
+
+// recipientAllowed reports whether address is in an allowed domain
+func (c *EmailConfig) recipientAllowed(address string) bool {
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(address[at+1:])
+	for _, allowed := range c.AllowedRecipientDomains {
+		allowed = strings.ToLower(allowed)
+		switch {
+		case allowed == "*":
+			return true
+		case strings.HasPrefix(allowed, "*."):
+			if domain == allowed[2:] || strings.HasSuffix(domain, allowed[1:]) {
+				return true
+			}
+		case domain == allowed:
+			return true
+		}
+	}
+	return false
+}
+
+// reserve counts an email against the hourly limit, failing when it is
+// reached
+func (c *EmailConfig) reserve() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	recent := c.sent[:0]
+	for _, sent := range c.sent {
+		if now.Sub(sent) < time.Hour {
+			recent = append(recent, sent)
+		}
+	}
+	c.sent = recent
+	if len(c.sent) >= c.MaxPerHour {
+		retry := c.sent[0].Add(time.Hour).Sub(now).Round(time.Minute)
+		return fmt.Errorf("the limit of %d emails per hour is reached; try again in %v", c.MaxPerHour, retry)
+	}
+	c.sent = append(c.sent, now)
+	return nil
+}
+
+// message renders an email with a quoted-printable UTF-8 body
+func (c *EmailConfig) message(to, cc []*mail.Address, replyTo *mail.Address, subject, body string, html bool) ([]byte, error) {
+	join := func(addresses []*mail.Address) string {
+		rendered := make([]string, len(addresses))
+		for i, address := range addresses {
+			rendered[i] = address.String()
+		}
+		return strings.Join(rendered, ", ")
+	}
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	from, _ := mail.ParseAddress(c.From)
+	domain := from.Address[strings.LastIndex(from.Address, "@")+1:]
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", c.From)
+	fmt.Fprintf(&b, "To: %s\r\n", join(to))
+	if len(cc) > 0 {
+		fmt.Fprintf(&b, "Cc: %s\r\n", join(cc))
+	}
+	if replyTo != nil {
+		fmt.Fprintf(&b, "Reply-To: %s\r\n", replyTo.String())
+	}
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	contentType := "text/plain"
+	if html {
+		contentType = "text/html"
+	}
+	fmt.Fprintf(&b, "Content-Type: %s; charset=utf-8\r\n", contentType)
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	writer := quotedprintable.NewWriter(&b)
+	if _, err := writer.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// send delivers a message to the SMTP server
+func (c *EmailConfig) send(recipients []string, message []byte) error {
+	address := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	tlsConfig := &tls.Config{ServerName: c.Host}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	if c.TLS == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to the SMTP server: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(2 * time.Minute))
+	client, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SMTP handshake failed: %w", err)
+	}
+	defer client.Close()
+
+	if c.TLS == "starttls" {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	if c.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.Username, c.Password, c.Host)); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+	from, _ := mail.ParseAddress(c.From)
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("SMTP server rejected the sender: %w", err)
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("SMTP server rejected %s: %w", recipient, err)
+		}
+	}
+	data, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP server rejected the message: %w", err)
+	}
+	if _, err := data.Write(message); err != nil {
+		return fmt.Errorf("failed to send the message: %w", err)
+	}
+	if err := data.Close(); err != nil {
+		return fmt.Errorf("SMTP server rejected the message: %w", err)
+	}
+	return client.Quit()
+}
@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeSMTPServer accepts SMTP sessions on a local port and sends the
+// envelope recipients and data of each message on the returned channel
+func fakeSMTPServer(t *testing.T) (string, int, <-chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	messages := make(chan []string, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+				reply("220 localhost ESMTP")
+				var message []string
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					command := strings.ToUpper(strings.TrimSpace(line))
+					switch {
+					case strings.HasPrefix(command, "EHLO"):
+						reply("250 localhost")
+					case strings.HasPrefix(command, "RCPT TO:"):
+						message = append(message, strings.TrimSpace(line)[len("RCPT TO:"):])
+						reply("250 OK")
+					case command == "DATA":
+						reply("354 Go ahead")
+						var data strings.Builder
+						for {
+							line, err := reader.ReadString('\n')
+							if err != nil || line == ".\r\n" {
+								break
+							}
+							data.WriteString(line)
+						}
+						messages <- append(message, data.String())
+						message = nil
+						reply("250 Queued")
+					case command == "QUIT":
+						reply("221 Bye")
+						return
+					default:
+						reply("250 OK")
+					}
+				}
+			}()
+		}
+	}()
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, messages
+}
+
+func TestCallSendEmail(t *testing.T) {
+	host, port, messages := fakeSMTPServer(t)
+	err := SetEmailConfig(&EmailConfig{
+		Host:                    host,
+		Port:                    port,
+		From:                    "Gateway <gateway@example.com>",
+		TLS:                     "none",
+		AllowedRecipientDomains: []string{"example.com", "*.example.org"},
+		MaxPerHour:              2,
+		MaxRecipients:           2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { emailConfig = nil }()
+
+	result, err := resultText(CallSendEmail(map[string]interface{}{
+		"to":      []interface{}{"Ops <ops@example.com>"},
+		"bcc":     []interface{}{"audit@eu.example.org"},
+		"subject": "Nightly report ✓",
+		"body":    "All jobs passed.\nSee you tomorrow.",
+	}))
+	if err != nil {
+		t.Fatalf("CallSendEmail failed: %v", err)
+	}
+	if !strings.Contains(result, "2 recipients") {
+		t.Errorf("Unexpected result %q", result)
+	}
+	message := <-messages
+	if len(message) != 3 || message[0] != "<ops@example.com>" || message[1] != "<audit@eu.example.org>" {
+		t.Fatalf("Unexpected envelope %q", message)
+	}
+	data := message[2]
+	for _, want := range []string{"From: \"Gateway\" <gateway@example.com>\r\n", "To: \"Ops\" <ops@example.com>\r\n", "Subject: =?utf-8?q?Nightly_report_=E2=9C=93?=\r\n", "All jobs passed.\r\nSee you tomorrow."} {
+		if !strings.Contains(data, want) {
+			t.Errorf("Message lacks %q:\n%s", want, data)
+		}
+	}
+	if strings.Contains(data, "audit@") {
+		t.Errorf("Bcc recipient leaked into the message:\n%s", data)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"to": []interface{}{"someone@evil.com"}, "subject": "x", "body": "x"},
+		{"to": []interface{}{"a@example.com", "b@example.com", "c@example.com"}, "subject": "x", "body": "x"},
+		{"to": []interface{}{"a@example.com"}, "subject": "x\r\nBcc: someone@evil.com", "body": "x"},
+		{"subject": "x", "body": "x"},
+	} {
+		if _, err := CallSendEmail(args); err == nil {
+			t.Errorf("Expected %v to be rejected", args)
+		}
+	}
+
+	args := map[string]interface{}{"to": []interface{}{"ops@example.com"}, "subject": "Again", "body": "x"}
+	if _, err := CallSendEmail(args); err != nil {
+		t.Fatalf("Second email failed: %v", err)
+	}
+	<-messages
+	if _, err := CallSendEmail(args); err == nil || !strings.Contains(err.Error(), "per hour") {
+		t.Errorf("Expected the hourly limit to apply, got %v", err)
+	}
+}
+
+func TestEmailRecipientAllowed(t *testing.T) {
+	config := &EmailConfig{AllowedRecipientDomains: []string{"Example.com", "*.corp.example.net"}}
+	for address, want := range map[string]bool{
+		"a@example.com":          true,
+		"a@EXAMPLE.COM":          true,
+		"a@sub.example.com":      false,
+		"a@corp.example.net":     true,
+		"a@eu.corp.example.net":  true,
+		"a@evilcorp.example.net": false,
+		"a@example.com.evil.org": false,
+	} {
+		if got := config.recipientAllowed(address); got != want {
+			t.Errorf("recipientAllowed(%q) = %v, want %v", address, got, want)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSlackTimeout bounds each Slack call when the configuration sets no
+// timeout
+const DefaultSlackTimeout = 30 * time.Second
+
+// slackAPIURL is the base URL of the Slack Web API methods
+var slackAPIURL = "https://slack.com/api/"
+
+// SlackTool represents a Slack tool definition
+type SlackTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// slackChannelProperty is the schema of the channel argument of the Slack
+// tools
+var slackChannelProperty = map[string]interface{}{
+	"type":        "string",
+	"description": "Name of the configured channel (optional when a single channel is configured)",
+}
+
+// slackThreadProperty is the schema of the thread_ts argument of the Slack
+// tools
+var slackThreadProperty = map[string]interface{}{
+	"type":        "string",
+	"description": "Timestamp (ts) of the message starting the thread",
+}
+
+// GetSlackTools returns the definitions of the Slack tools
+func GetSlackTools() []SlackTool {
+	return []SlackTool{
+		{
+			Name:        "slack_post_message",
+			Description: "Post a message to a Slack channel, or reply in a thread. Text uses Slack mrkdwn formatting (*bold*, _italic_, `code`, <url|label>).",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"channel": slackChannelProperty,
+					"text": map[string]interface{}{
+						"type":        "string",
+						"description": "Text of the message",
+					},
+					"thread_ts": slackThreadProperty,
+				},
+				"required": []string{"text"},
+			},
+		},
+		{
+			Name:        "slack_read_channel",
+			Description: "Read the latest messages of a Slack channel, newest first, or the replies of a thread, oldest first",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"channel":   slackChannelProperty,
+					"thread_ts": slackThreadProperty,
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of messages to return (1-200, default: 20)",
+						"default":     20,
+						"minimum":     1,
+						"maximum":     200,
+					},
+				},
+			},
+		},
+	}
+}
+
+// IsSlackTool reports whether name is one of the Slack tools
+func IsSlackTool(name string) bool {
+	for _, tool := range GetSlackTools() {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SlackConfig holds the configuration for the Slack tools
+type SlackConfig struct {
+	BotToken string
+	Channels map[string]string // Channel IDs keyed by the name the tools use
+	Timeout  time.Duration
+
+	mu    sync.Mutex
+	users map[string]string // Display names keyed by user ID
+}
+
+var slackConfig *SlackConfig
+
+// SetSlackConfig enables the Slack tools for the channels. A zero timeout
+// selects the default.
+func SetSlackConfig(botToken string, channels map[string]string, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultSlackTimeout
+	}
+	slackConfig = &SlackConfig{BotToken: botToken, Channels: channels, Timeout: timeout, users: map[string]string{}}
+}
+
+// GetSlackConfig returns the current configuration, nil when the tools are
+// disabled
+func GetSlackConfig() *SlackConfig {
+	return slackConfig
+}
+
+// channelNames returns the configured channel names, sorted
+func (c *SlackConfig) channelNames() []string {
+	names := make([]string, 0, len(c.Channels))
+	for name := range c.Channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// channel returns the name and ID of the channel named by the channel
+// argument. A leading "#" is ignored.
+func (c *SlackConfig) channel(name string) (string, string, error) {
+	name = strings.TrimPrefix(name, "#")
+	if name == "" {
+		if len(c.Channels) != 1 {
+			return "", "", fmt.Errorf("channel argument is required, configured: %s", strings.Join(c.channelNames(), ", "))
+		}
+		name = c.channelNames()[0]
+	}
+	id, ok := c.Channels[name]
+	if !ok {
+		return "", "", fmt.Errorf("unknown channel %q, configured: %s", name, strings.Join(c.channelNames(), ", "))
+	}
+	return name, id, nil
+}
+
+// CallSlackTool executes the named Slack tool
+func CallSlackTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+	config := slackConfig
+	if config == nil {
+		return nil, fmt.Errorf("Slack tools not configured. Set slack.enabled, a bot token and channels in the config file")
+	}
+	args := struct {
+		Channel  string `arg:"channel"`
+		ThreadTS string `arg:"thread_ts"`
+		Text     string `arg:"text"`
+		Limit    int    `arg:"limit"`
+	}{Limit: 20}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	channelName, channelID, err := config.channel(args.Channel)
+	if err != nil {
+		return nil, err
+	}
+	threadTS := args.ThreadTS
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	switch name {
+	case "slack_post_message":
+		text := args.Text
+		if strings.TrimSpace(text) == "" {
+			return nil, fmt.Errorf("text argument is required and must be a non-empty string")
+		}
+		request := map[string]interface{}{"channel": channelID, "text": text}
+		if threadTS != "" {
+			request["thread_ts"] = threadTS
+		}
+		var resp struct {
+			TS string `json:"ts"`
+		}
+		if err := config.call(ctx, "chat.postMessage", nil, request, &resp); err != nil {
+			return nil, err
+		}
+		return TextResult(fmt.Sprintf("Posted to #%s (ts %s)", channelName, resp.TS)), nil
+	case "slack_read_channel":
+		return textResult(config.read(ctx, channelName, channelID, threadTS, args.Limit))
+	default:
+		return nil, fmt.Errorf("unknown Slack tool: %s", name)
+	}
+}
+
+// slackMessage is the part of a Slack message the tools use
+type slackMessage struct {
+	TS         string `json:"ts"`
+	User       string `json:"user"`
+	Username   string `json:"username"` // Set for messages of bots and integrations
+	Text       string `json:"text"`
+	ReplyCount int    `json:"reply_count"`
+	ThreadTS   string `json:"thread_ts"`
+}
+
+// read returns the latest messages of a channel, or the replies of a thread
+func (c *SlackConfig) read(ctx context.Context, channelName, channelID, threadTS string, limit int) (string, error) {
+	if limit < 1 || limit > 200 {
+		limit = 20
+	}
+	query := url.Values{}
+	query.Set("channel", channelID)
+	query.Set("limit", strconv.Itoa(limit))
+	method := "conversations.history"
+	if threadTS != "" {
+		method = "conversations.replies"
+		query.Set("ts", threadTS)
+	}
+	var resp struct {
+		Messages []slackMessage `json:"messages"`
+		HasMore  bool           `json:"has_more"`
+	}
+	if err := c.call(ctx, method, query, nil, &resp); err != nil {
+		return "", err
+	}
+	if len(resp.Messages) == 0 {
+		return fmt.Sprintf("No messages in #%s", channelName), nil
+	}
+
+	var b strings.Builder
+	if threadTS != "" {
+		fmt.Fprintf(&b, "Thread %s in #%s\n\n", threadTS, channelName)
+	} else {
+		fmt.Fprintf(&b, "Latest messages in #%s\n\n", channelName)
+	}
+	for _, message := range resp.Messages {
+		author := message.Username
+		if message.User != "" {
+			author = c.userName(ctx, message.User)
+		}
+		fmt.Fprintf(&b, "[%s] %s: %s\n", slackTime(message.TS), author, message.Text)
+		fmt.Fprintf(&b, "  ts: %s", message.TS)
+		if message.ReplyCount > 0 && threadTS == "" {
+			fmt.Fprintf(&b, ", %d replies (read them with thread_ts %s)", message.ReplyCount, message.ThreadTS)
+		}
+		b.WriteString("\n\n")
+	}
+	if resp.HasMore {
+		b.WriteString("[More messages; raise limit to read further]\n")
+	}
+	return b.String(), nil
+}
+
+// userName returns the display name of a user, or the ID when it cannot be
+// looked up. Names are cached for the life of the process.
+func (c *SlackConfig) userName(ctx context.Context, id string) string {
+	c.mu.Lock()
+	name, ok := c.users[id]
+	c.mu.Unlock()
+	if ok {
+		return name
+	}
+	var resp struct {
+		User struct {
+			Name    string `json:"name"`
+			Profile struct {
+				DisplayName string `json:"display_name"`
+				RealName    string `json:"real_name"`
+			} `json:"profile"`
+		} `json:"user"`
+	}
+	if err := c.call(ctx, "users.info", url.Values{"user": {id}}, nil, &resp); err != nil {
+		return id
+	}
+	name = resp.User.Profile.DisplayName
+	if name == "" {
+		name = resp.User.Profile.RealName
+	}
+	if name == "" {
+		name = resp.User.Name
+	}
+	if name == "" {
+		name = id
+	}
+	c.mu.Lock()
+	c.users[id] = name
+	c.mu.Unlock()
+	return name
+}
+
+// slackTime formats a message timestamp ("1700000000.000100") as UTC time
+func slackTime(ts string) string {
+	seconds, err := strconv.ParseFloat(ts, 64)
+	if err != nil {
+		return ts
+	}
+	return time.Unix(int64(seconds), 0).UTC().Format("2006-01-02 15:04:05")
+}
+
+// call invokes a Web API method, with a JSON body when request is set, and
+// decodes the response into v. Responses with "ok": false are returned as
+// errors.
+func (c *SlackConfig) call(ctx context.Context, method string, query url.Values, request interface{}, v interface{}) error {
+	u := slackAPIURL + method
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	httpMethod := "GET"
+	var body io.Reader
+	if request != nil {
+		data, err := json.Marshal(request)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		httpMethod = "POST"
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, httpMethod, u, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.BotToken)
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("Slack request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Slack returned status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if !status.OK {
+		return fmt.Errorf("Slack %s failed: %s", method, status.Error)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCallSlackTool(t *testing.T) {
+	var posted map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			w.Write([]byte(`{"ok":false,"error":"invalid_auth"}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/chat.postMessage":
+			json.NewDecoder(r.Body).Decode(&posted)
+			w.Write([]byte(`{"ok":true,"ts":"1700000000.000200"}`))
+		case "/conversations.history":
+			if r.URL.Query().Get("channel") != "C01" {
+				w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+				return
+			}
+			w.Write([]byte(`{"ok":true,"has_more":true,"messages":[` +
+				`{"ts":"1700000000.000100","user":"U1","text":"deploy done?","reply_count":2,"thread_ts":"1700000000.000100"},` +
+				`{"ts":"1699999999.000100","username":"ci-bot","text":"build passed"}]}`))
+		case "/conversations.replies":
+			w.Write([]byte(`{"ok":true,"messages":[{"ts":"1700000000.000100","user":"U1","text":"deploy done?"}]}`))
+		case "/users.info":
+			w.Write([]byte(`{"ok":true,"user":{"name":"ada","profile":{"display_name":"Ada"}}}`))
+		default:
+			w.Write([]byte(`{"ok":false,"error":"unknown_method"}`))
+		}
+	}))
+	defer server.Close()
+
+	defer func(original string) { slackAPIURL = original }(slackAPIURL)
+	slackAPIURL = server.URL + "/"
+	SetSlackConfig("xoxb-test", map[string]string{"ops": "C01", "general": "C02"}, 0)
+	defer func() { slackConfig = nil }()
+	ctx := context.Background()
+
+	result, err := resultText(CallSlackTool(ctx, "slack_post_message", map[string]interface{}{"channel": "#ops", "text": "Job finished", "thread_ts": "1700000000.000100"}))
+	if err != nil || result != "Posted to #ops (ts 1700000000.000200)" {
+		t.Errorf("slack_post_message = %q, %v", result, err)
+	}
+	if posted["channel"] != "C01" || posted["text"] != "Job finished" || posted["thread_ts"] != "1700000000.000100" {
+		t.Errorf("Unexpected request %v", posted)
+	}
+
+	result, err = resultText(CallSlackTool(ctx, "slack_read_channel", map[string]interface{}{"channel": "ops"}))
+	if err != nil {
+		t.Fatalf("slack_read_channel failed: %v", err)
+	}
+	for _, want := range []string{"[2023-11-14 22:13:20] Ada: deploy done?", "2 replies (read them with thread_ts 1700000000.000100)", "ci-bot: build passed", "[More messages"} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Result lacks %q:\n%s", want, result)
+		}
+	}
+	result, err = resultText(CallSlackTool(ctx, "slack_read_channel", map[string]interface{}{"channel": "ops", "thread_ts": "1700000000.000100"}))
+	if err != nil || !strings.HasPrefix(result, "Thread 1700000000.000100 in #ops") {
+		t.Errorf("slack_read_channel of a thread = %q, %v", result, err)
+	}
+
+	if _, err := CallSlackTool(ctx, "slack_post_message", map[string]interface{}{"channel": "random", "text": "hi"}); err == nil || !strings.Contains(err.Error(), "unknown channel") {
+		t.Errorf("Expected an unconfigured channel to be rejected, got %v", err)
+	}
+	if _, err := CallSlackTool(ctx, "slack_post_message", map[string]interface{}{"text": "hi"}); err == nil || !strings.Contains(err.Error(), "channel argument is required") {
+		t.Errorf("Expected the channel to be required with several configured, got %v", err)
+	}
+	if _, err := CallSlackTool(ctx, "slack_read_channel", map[string]interface{}{"channel": "general"}); err == nil || !strings.Contains(err.Error(), "channel_not_found") {
+		t.Errorf("Expected Slack's error, got %v", err)
+	}
+}
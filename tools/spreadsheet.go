@@ -0,0 +1,512 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Defaults for the spreadsheet tools when the configuration leaves them unset
+const (
+	DefaultSpreadsheetMaxRows      = 1000
+	DefaultSpreadsheetMaxFileBytes = 20 << 20
+	// defaultSpreadsheetLimit is the number of rows returned without a limit
+	// argument
+	defaultSpreadsheetLimit = 100
+)
+
+// SpreadsheetTool represents a spreadsheet tool definition
+type SpreadsheetTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// spreadsheetProperties returns the arguments shared by the spreadsheet
+// tools, with the description of the content argument
+func spreadsheetProperties(content string) map[string]interface{} {
+	return map[string]interface{}{
+		"path": map[string]interface{}{
+			"type":        "string",
+			"description": "Path of the file in the allowed directories",
+		},
+		"content": map[string]interface{}{
+			"type":        "string",
+			"description": content,
+		},
+		"header": map[string]interface{}{
+			"type":        "boolean",
+			"description": "Whether the first row holds the column names (default: detected)",
+		},
+		"columns": map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "string"},
+			"description": "Columns to return, by name or by letter such as \"C\" (default: all)",
+		},
+		"start_row": map[string]interface{}{
+			"type":        "integer",
+			"description": "First data row to return, counting from 1 after the header (default: 1)",
+			"default":     1,
+			"minimum":     1,
+		},
+		"end_row": map[string]interface{}{
+			"type":        "integer",
+			"description": "Last data row to return (default: the last row)",
+			"minimum":     1,
+		},
+		"limit": map[string]interface{}{
+			"type":        "integer",
+			"description": "Maximum number of rows to return, at most the configured limit (default: 100)",
+			"minimum":     1,
+		},
+	}
+}
+
+// GetSpreadsheetTools returns the read_csv and read_xlsx tool definitions
+func GetSpreadsheetTools() []SpreadsheetTool {
+	csvProperties := spreadsheetProperties("CSV text, instead of path")
+	csvProperties["delimiter"] = map[string]interface{}{
+		"type":        "string",
+		"description": "Field delimiter (default: detected among comma, semicolon, tab and pipe)",
+	}
+	xlsxProperties := spreadsheetProperties("Base64 encoded .xlsx file, instead of path")
+	xlsxProperties["sheet"] = map[string]interface{}{
+		"type":        "string",
+		"description": "Name of the sheet to read (default: the first sheet)",
+	}
+	return []SpreadsheetTool{
+		{
+			Name:        "read_csv",
+			Description: "Read a CSV file into typed rows: numbers, booleans and empty cells as null are converted, and the header row is detected",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": csvProperties,
+			},
+		},
+		{
+			Name:        "read_xlsx",
+			Description: "Read a sheet of an Excel .xlsx workbook into typed rows, with dates as ISO 8601 strings, and list the sheets of the workbook",
+			InputSchema: map[string]interface{}{
+				"type":       "object",
+				"properties": xlsxProperties,
+			},
+		},
+	}
+}
+
+// IsSpreadsheetTool reports whether name is one of the spreadsheet tools
+func IsSpreadsheetTool(name string) bool {
+	for _, tool := range GetSpreadsheetTools() {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SpreadsheetConfig holds the configuration for the spreadsheet tools
+type SpreadsheetConfig struct {
+	// AllowedPaths are the directories files may be read from; empty only
+	// allows passing the content
+	AllowedPaths []string
+	MaxRows      int // Most rows returned by a call
+	MaxFileBytes int64
+}
+
+var spreadsheetConfig *SpreadsheetConfig
+
+// SetSpreadsheetConfig enables the spreadsheet tools. Zero limits select the
+// defaults.
+func SetSpreadsheetConfig(config SpreadsheetConfig) error {
+	roots, err := resolveRoots(config.AllowedPaths)
+	if err != nil {
+		return err
+	}
+	config.AllowedPaths = roots
+	if config.MaxRows <= 0 {
+		config.MaxRows = DefaultSpreadsheetMaxRows
+	}
+	if config.MaxFileBytes <= 0 {
+		config.MaxFileBytes = DefaultSpreadsheetMaxFileBytes
+	}
+	spreadsheetConfig = &config
+	return nil
+}
+
+// GetSpreadsheetConfig returns the current configuration, nil when the tools
+// are disabled
+func GetSpreadsheetConfig() *SpreadsheetConfig {
+	return spreadsheetConfig
+}
+
+// Table is the structured result of the spreadsheet tools. Cells are
+// strings, numbers, booleans or null for empty cells.
+type Table struct {
+	Sheet     string          `json:"sheet,omitempty"`
+	Sheets    []string        `json:"sheets,omitempty"`
+	Columns   []string        `json:"columns"`
+	Rows      [][]interface{} `json:"rows"`
+	StartRow  int             `json:"start_row"`
+	TotalRows int             `json:"total_rows"`
+	Truncated bool            `json:"truncated"`
+}
+
+// CallSpreadsheetTool runs read_csv or read_xlsx, returning the rows as a
+// text table and as structured content
+func CallSpreadsheetTool(name string, arguments map[string]interface{}) (*ToolResult, error) {
+	config := spreadsheetConfig
+	if config == nil {
+		return nil, fmt.Errorf("spreadsheet tools not configured. Set spreadsheet.enabled in the config file")
+	}
+	args := spreadsheetArguments{StartRow: 1, Limit: defaultSpreadsheetLimit}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	var rows [][]interface{}
+	var table Table
+	switch name {
+	case "read_csv":
+		data, err := config.file(args.Path, args.Content, false)
+		if err != nil {
+			return nil, err
+		}
+		if rows, err = parseCSV(data, args.Delimiter); err != nil {
+			return nil, err
+		}
+	case "read_xlsx":
+		data, err := config.file(args.Path, args.Content, true)
+		if err != nil {
+			return nil, err
+		}
+		workbook, err := parseXLSX(data, args.Sheet)
+		if err != nil {
+			return nil, err
+		}
+		rows, table.Sheet, table.Sheets = workbook.rows, workbook.sheet, workbook.sheets
+	default:
+		return nil, fmt.Errorf("unknown spreadsheet tool: %s", name)
+	}
+	if err := config.selectRows(&table, rows, &args); err != nil {
+		return nil, err
+	}
+	return StructuredResult(formatTable(&table), &table), nil
+}
+
+// spreadsheetArguments are the arguments of read_csv and read_xlsx
+type spreadsheetArguments struct {
+	Path      string   `arg:"path"`
+	Content   string   `arg:"content"`
+	Delimiter string   `arg:"delimiter"`
+	Sheet     string   `arg:"sheet"`
+	Header    *bool    `arg:"header"` // nil = detect
+	Columns   []string `arg:"columns"`
+	StartRow  int      `arg:"start_row"`
+	EndRow    *int     `arg:"end_row"` // nil = the last row
+	Limit     int      `arg:"limit"`
+}
+
+// file returns the file named by the path argument or passed as content,
+// base64 encoded when binary
+func (c *SpreadsheetConfig) file(path, content string, binary bool) ([]byte, error) {
+	switch {
+	case path != "" && content != "":
+		return nil, fmt.Errorf("pass either path or content, not both")
+	case content != "" && binary:
+		if int64(base64.StdEncoding.DecodedLen(len(content))) > c.MaxFileBytes+2 {
+			return nil, fmt.Errorf("file exceeds the limit of %d bytes", c.MaxFileBytes)
+		}
+		data, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return nil, fmt.Errorf("content is not valid base64: %w", err)
+		}
+		return data, nil
+	case content != "":
+		if int64(len(content)) > c.MaxFileBytes {
+			return nil, fmt.Errorf("content exceeds the limit of %d bytes", c.MaxFileBytes)
+		}
+		return []byte(content), nil
+	case path != "":
+		if len(c.AllowedPaths) == 0 {
+			return nil, fmt.Errorf("reading files is disabled; pass the content or set spreadsheet.allowed_paths")
+		}
+		resolved, err := resolveUnder(path, c.AllowedPaths)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(resolved)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		if info.Size() > c.MaxFileBytes {
+			return nil, fmt.Errorf("file of %d bytes exceeds the limit of %d bytes", info.Size(), c.MaxFileBytes)
+		}
+		data, err := os.ReadFile(resolved)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("path or content argument is required")
+}
+
+// parseCSV reads CSV data into typed rows
+func parseCSV(data []byte, delimiter string) ([][]interface{}, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("the file is not UTF-8 text")
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	switch {
+	case delimiter == "\\t":
+		reader.Comma = '\t'
+	case delimiter != "":
+		r, size := utf8.DecodeRuneInString(delimiter)
+		if size != len(delimiter) || r == '"' || r == '\r' || r == '\n' {
+			return nil, fmt.Errorf("delimiter must be a single character other than a quote or newline")
+		}
+		reader.Comma = r
+	default:
+		reader.Comma = detectDelimiter(data)
+	}
+
+	var rows [][]interface{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		row := make([]interface{}, len(record))
+		for i, field := range record {
+			row[i] = csvValue(field)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// detectDelimiter returns the most frequent of the usual delimiters in the
+// first line, outside quotes
+func detectDelimiter(data []byte) rune {
+	counts := map[rune]int{}
+	quoted := false
+	for _, r := range string(data[:min(len(data), 4096)]) {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == '\n' && !quoted {
+			break
+		} else if !quoted && strings.ContainsRune(",;\t|", r) {
+			counts[r]++
+		}
+	}
+	best := ','
+	for _, r := range ";\t|" {
+		if counts[r] > counts[best] {
+			best = r
+		}
+	}
+	return best
+}
+
+// csvValue converts a CSV field to a number, boolean or null when it reads
+// as one. Numbers with leading zeros, such as postal codes, stay strings.
+func csvValue(field string) interface{} {
+	s := strings.TrimSpace(field)
+	switch strings.ToLower(s) {
+	case "":
+		return nil
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	digits := strings.TrimLeft(s, "+-")
+	if digits == "" || (digits[0] < '0' || digits[0] > '9') && digits[0] != '.' {
+		return field
+	}
+	if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' {
+		return field
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return field
+}
+
+// selectRows fills the table with the columns and rows the arguments ask
+// for, naming the columns after the header row when there is one
+func (c *SpreadsheetConfig) selectRows(table *Table, rows [][]interface{}, args *spreadsheetArguments) error {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	header := len(rows) > 0 && looksLikeHeader(rows[0])
+	if args.Header != nil {
+		header = *args.Header
+	}
+	columns := make([]string, width)
+	for i := range columns {
+		columns[i] = columnLetters(i)
+	}
+	if header && len(rows) > 0 {
+		for i, cell := range rows[0] {
+			if name := strings.TrimSpace(fmt.Sprint(cell)); cell != nil && name != "" {
+				columns[i] = name
+			}
+		}
+		rows = rows[1:]
+	}
+
+	// Columns are picked by header name first, then by letter
+	indexes := make([]int, 0, width)
+	names := args.Columns
+	if len(names) == 0 {
+		for i := range columns {
+			indexes = append(indexes, i)
+		}
+	}
+	for _, name := range names {
+		index := -1
+		for i, column := range columns {
+			if strings.EqualFold(column, name) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			index = columnIndex(name)
+		}
+		if index < 0 || index >= width {
+			return fmt.Errorf("unknown column %q; the columns are %s", name, strings.Join(columns, ", "))
+		}
+		indexes = append(indexes, index)
+	}
+
+	start, end, limit := args.StartRow, len(rows), args.Limit
+	if args.EndRow != nil {
+		end = *args.EndRow
+	}
+	if start < 1 {
+		return fmt.Errorf("start_row must be at least 1")
+	}
+	limit = min(max(limit, 1), c.MaxRows)
+	end = min(end, len(rows))
+
+	table.Columns = make([]string, len(indexes))
+	for i, index := range indexes {
+		table.Columns[i] = columns[index]
+	}
+	table.Rows = [][]interface{}{}
+	table.StartRow = start
+	table.TotalRows = len(rows)
+	for n := start; n <= end; n++ {
+		if len(table.Rows) == limit {
+			table.Truncated = true
+			break
+		}
+		row := rows[n-1]
+		selected := make([]interface{}, len(indexes))
+		for i, index := range indexes {
+			if index < len(row) {
+				selected[i] = row[index]
+			}
+		}
+		table.Rows = append(table.Rows, selected)
+	}
+	return nil
+}
+
+// looksLikeHeader guesses whether a first row names the columns: every cell
+// is distinct text rather than a number, boolean or empty
+func looksLikeHeader(row []interface{}) bool {
+	seen := map[string]bool{}
+	for _, cell := range row {
+		s, ok := cell.(string)
+		if !ok || strings.TrimSpace(s) == "" || seen[s] {
+			return false
+		}
+		seen[s] = true
+	}
+	return len(row) > 0
+}
+
+// columnLetters returns the spreadsheet letters of the column at index,
+// such as "A" for 0 and "AA" for 26
+func columnLetters(index int) string {
+	var letters []byte
+	for index++; index > 0; index = (index - 1) / 26 {
+		letters = append([]byte{byte('A' + (index-1)%26)}, letters...)
+	}
+	return string(letters)
+}
+
+// columnIndex returns the index of the column with the given letters, or
+// -1 when name is not a column reference
+func columnIndex(name string) int {
+	if name == "" || len(name) > 3 {
+		return -1
+	}
+	index := 0
+	for _, r := range strings.ToUpper(name) {
+		if r < 'A' || r > 'Z' {
+			return -1
+		}
+		index = index*26 + int(r-'A') + 1
+	}
+	return index - 1
+}
+
+// formatTable renders a table as a markdown table with a summary line
+func formatTable(table *Table) string {
+	var result strings.Builder
+	if table.Sheet != "" {
+		fmt.Fprintf(&result, "Sheet: %s (sheets: %s)\n", table.Sheet, strings.Join(table.Sheets, ", "))
+	}
+	if len(table.Rows) == 0 {
+		fmt.Fprintf(&result, "No rows in range (%d rows in total)", table.TotalRows)
+		return result.String()
+	}
+	first := table.StartRow
+	fmt.Fprintf(&result, "Rows %d-%d of %d\n\n", first, first+len(table.Rows)-1, table.TotalRows)
+
+	cell := func(value interface{}) string {
+		if value == nil {
+			return ""
+		}
+		s := fmt.Sprint(value)
+		s = strings.ReplaceAll(s, "|", "\\|")
+		return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", " "), "\n", " ")
+	}
+	result.WriteString("|")
+	for _, column := range table.Columns {
+		result.WriteString(" " + cell(column) + " |")
+	}
+	result.WriteString("\n|")
+	for range table.Columns {
+		result.WriteString(" --- |")
+	}
+	for _, row := range table.Rows {
+		result.WriteString("\n|")
+		for _, value := range row {
+			result.WriteString(" " + cell(value) + " |")
+		}
+	}
+	if table.Truncated {
+		next := first + len(table.Rows)
+		fmt.Fprintf(&result, "\n\n[Truncated at row %d; call again with start_row %d to continue]", next-1, next)
+	}
+	return result.String()
+}
@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCallSpreadsheetToolCSV(t *testing.T) {
+	dir := t.TempDir()
+	data := "\xef\xbb\xbfcity;zip;population;capital\nParis;75001;2102650;true\nLyon;69001;;false\n\"Saint-Denis; Réunion\";97400;153810.5;false\n"
+	os.WriteFile(filepath.Join(dir, "cities.csv"), []byte(data), 0o644)
+	if err := SetSpreadsheetConfig(SpreadsheetConfig{AllowedPaths: []string{dir}}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { spreadsheetConfig = nil }()
+
+	text, table, err := readSpreadsheet("read_csv", map[string]interface{}{"path": filepath.Join(dir, "cities.csv")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Table{
+		Columns: []string{"city", "zip", "population", "capital"},
+		Rows: [][]interface{}{
+			{"Paris", int64(75001), int64(2102650), true},
+			{"Lyon", int64(69001), nil, false},
+			{"Saint-Denis; Réunion", int64(97400), 153810.5, false},
+		},
+		StartRow:  1,
+		TotalRows: 3,
+	}
+	if !reflect.DeepEqual(table, want) {
+		t.Errorf("read_csv = %+v, want %+v", table, want)
+	}
+	if !strings.Contains(text, "Rows 1-3 of 3\n\n| city | zip | population | capital |\n| --- | --- | --- | --- |\n| Paris | 75001 | 2102650 | true |") {
+		t.Errorf("Unexpected text:\n%s", text)
+	}
+
+	// Headerless data is named by column letters, which select columns too
+	_, table, err = readSpreadsheet("read_csv", map[string]interface{}{
+		"content": "1,007,x\n2,008,y\n3,009,z\n", "columns": []interface{}{"C", "b"}, "start_row": float64(2), "limit": float64(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = &Table{Columns: []string{"C", "B"}, Rows: [][]interface{}{{"y", "008"}}, StartRow: 2, TotalRows: 3, Truncated: true}
+	if !reflect.DeepEqual(table, want) {
+		t.Errorf("read_csv = %+v, want %+v", table, want)
+	}
+	if out, _ := json.Marshal(table); !strings.Contains(string(out), `"rows":[["y","008"]]`) {
+		t.Errorf("Unexpected structured content %s", out)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"path": filepath.Join(dir, "..", "cities.csv")},
+		{"content": "a,b\n1,2\n", "columns": []interface{}{"c"}},
+		{"content": "a,b\n1,2\n", "delimiter": "ab"},
+		{},
+	} {
+		if _, _, err := readSpreadsheet("read_csv", args); err == nil {
+			t.Errorf("Expected %v to be rejected", args)
+		}
+	}
+}
+
+// testXLSX builds a workbook of two sheets with shared and inline strings,
+// numbers, booleans, a date and a formula result
+// readSpreadsheet runs read_csv or read_xlsx, returning the text and the
+// structured table
+func readSpreadsheet(name string, arguments map[string]interface{}) (string, *Table, error) {
+	result, err := CallSpreadsheetTool(name, arguments)
+	if err != nil {
+		return "", nil, err
+	}
+	return result.Content[0].Text, result.StructuredContent.(*Table), nil
+}
+
+func testXLSX(t *testing.T) []byte {
+	t.Helper()
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Summary" sheetId="1" r:id="rId1"/><sheet name="Orders" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="/xl/worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":     `<sst><si><t>Order</t></si><si><t>Date</t></si><si><r><t>Pa</t></r><r><t>id</t></r></si><si><t>Total</t></si></sst>`,
+		"xl/styles.xml":            `<styleSheet><numFmts><numFmt numFmtId="164" formatCode="yyyy\-mm\-dd"/></numFmts><cellXfs><xf numFmtId="0"/><xf numFmtId="164"/><xf numFmtId="2"/></cellXfs></styleSheet>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>see Orders</t></is></c></row></sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c><c r="D1" t="s"><v>3</v></c></row>
+<row r="2"><c r="A2"><v>1001</v></c><c r="B2" s="1"><v>45292</v></c><c r="C2" t="b"><v>1</v></c><c r="D2" s="2"><f>SUM(E2:F2)</f><v>19.99</v></c></row>
+<row r="4"><c r="A4"><v>1002</v></c><c r="C4" t="b"><v>0</v></c></row>
+</sheetData></worksheet>`,
+	}
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, _ := archive.Create(name)
+		w.Write([]byte(content))
+	}
+	archive.Close()
+	return buf.Bytes()
+}
+
+func TestCallSpreadsheetToolXLSX(t *testing.T) {
+	if err := SetSpreadsheetConfig(SpreadsheetConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { spreadsheetConfig = nil }()
+	content := base64.StdEncoding.EncodeToString(testXLSX(t))
+
+	text, table, err := readSpreadsheet("read_xlsx", map[string]interface{}{"content": content, "sheet": "orders"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Table{
+		Sheet:   "Orders",
+		Sheets:  []string{"Summary", "Orders"},
+		Columns: []string{"Order", "Date", "Paid", "Total"},
+		Rows: [][]interface{}{
+			{int64(1001), "2024-01-01", true, 19.99},
+			{nil, nil, nil, nil},
+			{int64(1002), nil, false, nil},
+		},
+		StartRow:  1,
+		TotalRows: 3,
+	}
+	if !reflect.DeepEqual(table, want) {
+		t.Errorf("read_xlsx = %+v, want %+v", table, want)
+	}
+	if !strings.HasPrefix(text, "Sheet: Orders (sheets: Summary, Orders)\n") {
+		t.Errorf("Unexpected text:\n%s", text)
+	}
+
+	_, table, err = readSpreadsheet("read_xlsx", map[string]interface{}{"content": content})
+	if err != nil || table.Sheet != "Summary" || !reflect.DeepEqual(table.Rows, [][]interface{}{}) || table.Columns[0] != "see Orders" {
+		t.Errorf("Expected the first sheet, got %+v, %v", table, err)
+	}
+	if _, _, err := readSpreadsheet("read_xlsx", map[string]interface{}{"content": content, "sheet": "Missing"}); err == nil || !strings.Contains(err.Error(), "Summary, Orders") {
+		t.Errorf("Expected an unknown sheet to list the sheets, got %v", err)
+	}
+	if _, _, err := readSpreadsheet("read_xlsx", map[string]interface{}{"path": "/tmp/book.xlsx"}); err == nil || !strings.Contains(err.Error(), "spreadsheet.allowed_paths") {
+		t.Errorf("Expected files to be rejected without allowed paths, got %v", err)
+	}
+}
+
+func TestExcelDate(t *testing.T) {
+	tests := []struct {
+		serial   float64
+		date1904 bool
+		want     string
+	}{
+		{45292, false, "2024-01-01"},
+		{45292.75, false, "2024-01-01T18:00:00"},
+		{0.5, false, "12:00:00"},
+		{0, true, "1904-01-01"},
+	}
+	for _, tt := range tests {
+		if got := excelDate(tt.serial, tt.date1904); got != tt.want {
+			t.Errorf("excelDate(%v, %v) = %q, want %q", tt.serial, tt.date1904, got, tt.want)
+		}
+	}
+	if isDateFormat(164, `#,##0.00 "days"`) || !isDateFormat(164, "dd/mm/yyyy hh:mm") || !isDateFormat(22, "") {
+		t.Error("isDateFormat misclassified a format")
+	}
+}
@@ -0,0 +1,269 @@
+package tools
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xlsxMaxPartBytes bounds the uncompressed size of a part of a workbook,
+// against archives that inflate far beyond the file size limit
+const xlsxMaxPartBytes = 256 << 20
+
+// xlsxWorkbook is the sheet read from a workbook with the names of all its
+// sheets
+type xlsxWorkbook struct {
+	sheet  string
+	sheets []string
+	rows   [][]interface{}
+}
+
+// parseXLSX reads a sheet of an .xlsx workbook, the first one when sheet is
+// empty, into typed rows
+func parseXLSX(data []byte, sheet string) (*xlsxWorkbook, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not an .xlsx file: %v", err)
+	}
+	parts := map[string]*zip.File{}
+	for _, file := range archive.File {
+		parts[file.Name] = file
+	}
+	readPart := func(name string, v interface{}) error {
+		file, ok := parts[name]
+		if !ok {
+			return fmt.Errorf("the workbook lacks %s", name)
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", name, err)
+		}
+		defer reader.Close()
+		if err := xml.NewDecoder(io.LimitReader(reader, xlsxMaxPartBytes)).Decode(v); err != nil {
+			return fmt.Errorf("invalid %s: %v", name, err)
+		}
+		return nil
+	}
+
+	var workbook struct {
+		Properties struct {
+			Date1904 bool `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := readPart("xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var relationships struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := readPart("xl/_rels/workbook.xml.rels", &relationships); err != nil {
+		return nil, err
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("the workbook has no sheets")
+	}
+
+	result := &xlsxWorkbook{}
+	var sheetID string
+	for _, s := range workbook.Sheets {
+		result.sheets = append(result.sheets, s.Name)
+		if sheetID == "" && (sheet == "" || strings.EqualFold(s.Name, sheet)) {
+			result.sheet, sheetID = s.Name, s.ID
+		}
+	}
+	if sheetID == "" {
+		return nil, fmt.Errorf("no sheet named %q; the sheets are %s", sheet, strings.Join(result.sheets, ", "))
+	}
+	var sheetPart string
+	for _, rel := range relationships.Relationships {
+		if rel.ID == sheetID {
+			// Targets are relative to xl/, or absolute within the package
+			if strings.HasPrefix(rel.Target, "/") {
+				sheetPart = strings.TrimPrefix(rel.Target, "/")
+			} else {
+				sheetPart = path.Join("xl", rel.Target)
+			}
+		}
+	}
+	if sheetPart == "" {
+		return nil, fmt.Errorf("the workbook lacks the part of sheet %s", result.sheet)
+	}
+
+	var sharedStrings struct {
+		Items []xlsxText `xml:"si"`
+	}
+	if _, ok := parts["xl/sharedStrings.xml"]; ok {
+		if err := readPart("xl/sharedStrings.xml", &sharedStrings); err != nil {
+			return nil, err
+		}
+	}
+	var styles struct {
+		NumberFormats []struct {
+			ID   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		CellFormats []struct {
+			NumberFormat int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+	if _, ok := parts["xl/styles.xml"]; ok {
+		if err := readPart("xl/styles.xml", &styles); err != nil {
+			return nil, err
+		}
+	}
+	customFormats := map[int]string{}
+	for _, format := range styles.NumberFormats {
+		customFormats[format.ID] = format.Code
+	}
+	// dateStyles marks the cell styles whose numbers are dates or times
+	dateStyles := make([]bool, len(styles.CellFormats))
+	for i, format := range styles.CellFormats {
+		dateStyles[i] = isDateFormat(format.NumberFormat, customFormats[format.NumberFormat])
+	}
+
+	var worksheet struct {
+		Rows []struct {
+			Number int `xml:"r,attr"`
+			Cells  []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Style  int      `xml:"s,attr"`
+				Value  string   `xml:"v"`
+				Inline xlsxText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := readPart(sheetPart, &worksheet); err != nil {
+		return nil, err
+	}
+
+	for _, row := range worksheet.Rows {
+		// Rows and cells may be omitted when empty, so place them by their
+		// references
+		number := row.Number
+		if number == 0 {
+			number = len(result.rows) + 1
+		}
+		for len(result.rows) < number {
+			result.rows = append(result.rows, nil)
+		}
+		var cells []interface{}
+		for _, cell := range row.Cells {
+			column := len(cells)
+			if cell.Ref != "" {
+				column = columnIndex(strings.TrimRight(cell.Ref, "0123456789"))
+			}
+			if column < 0 || column > 16383 {
+				return nil, fmt.Errorf("invalid cell reference %q", cell.Ref)
+			}
+			for len(cells) <= column {
+				cells = append(cells, nil)
+			}
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(sharedStrings.Items) {
+					return nil, fmt.Errorf("invalid shared string in cell %s", cell.Ref)
+				}
+				cells[column] = sharedStrings.Items[index].String()
+			case "inlineStr":
+				cells[column] = cell.Inline.String()
+			case "str", "e":
+				cells[column] = cell.Value
+			case "b":
+				cells[column] = cell.Value == "1"
+			default:
+				if cell.Value == "" {
+					continue
+				}
+				n, err := strconv.ParseFloat(cell.Value, 64)
+				if err != nil {
+					cells[column] = cell.Value
+				} else if cell.Style < len(dateStyles) && dateStyles[cell.Style] {
+					cells[column] = excelDate(n, workbook.Properties.Date1904)
+				} else if n == math.Trunc(n) && math.Abs(n) < 1<<53 {
+					cells[column] = int64(n)
+				} else {
+					cells[column] = n
+				}
+			}
+		}
+		result.rows[number-1] = cells
+	}
+	return result, nil
+}
+
+// xlsxText is a string of a workbook, plain or made of rich text runs
+type xlsxText struct {
+	Text string   `xml:"t"`
+	Runs []string `xml:"r>t"`
+}
+
+// String returns the text, joining the runs of rich text
+func (t xlsxText) String() string {
+	return t.Text + strings.Join(t.Runs, "")
+}
+
+// isDateFormat reports whether a number format displays dates or times:
+// the built-in formats 14 to 22 and 45 to 47, or a custom format with date
+// or time codes outside quoted text and brackets
+func isDateFormat(id int, code string) bool {
+	if id >= 14 && id <= 22 || id >= 45 && id <= 47 {
+		return true
+	}
+	if code == "" {
+		return false
+	}
+	quoted, bracketed := false, false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case c == '\\' || c == '_' || c == '*':
+			i++ // The next character is literal or padding
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[':
+			bracketed = true
+		case c == ']':
+			bracketed = false
+		case bracketed:
+		case strings.IndexByte("dmyhsDMYHS", c) >= 0:
+			return true
+		}
+	}
+	return false
+}
+
+// excelDate converts a date serial number to an ISO 8601 date, or date and
+// time when it has a time of day
+func excelDate(serial float64, date1904 bool) string {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	days := math.Floor(serial)
+	seconds := math.Round((serial - days) * 86400)
+	t := epoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
+	switch {
+	case seconds == 0:
+		return t.Format("2006-01-02")
+	case days == 0 && !date1904:
+		return t.Format("15:04:05") // A time without a date
+	}
+	return t.Format("2006-01-02T15:04:05")
+}
@@ -0,0 +1,447 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Defaults for the sql_query tool when the configuration leaves them unset
+const (
+	DefaultSQLQueryTimeout = 30 * time.Second
+	DefaultSQLQueryMaxRows = 1000
+)
+
+// sqlDriverNames maps the database kinds of the configuration to the names
+// their database/sql drivers register under, most common first. Drivers are
+// not bundled; a build links the ones it needs with a blank import, such as
+// modernc.org/sqlite, github.com/jackc/pgx/v5/stdlib or
+// github.com/go-sql-driver/mysql.
+var sqlDriverNames = map[string][]string{
+	"sqlite":   {"sqlite", "sqlite3"},
+	"postgres": {"pgx", "postgres"},
+	"mysql":    {"mysql"},
+}
+
+// sqlReadKeywords start the statements a read-only connection accepts
+var sqlReadKeywords = map[string]bool{
+	"SELECT": true, "WITH": true, "EXPLAIN": true, "SHOW": true,
+	"DESCRIBE": true, "DESC": true, "VALUES": true, "TABLE": true,
+}
+
+// SQLQueryTool represents the sql_query tool definition
+type SQLQueryTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// GetSQLQueryTool returns the sql_query tool definition, listing the
+// configured connections
+func GetSQLQueryTool() SQLQueryTool {
+	description := "Run a parameterized SQL query against a configured database connection and return the rows as a table or JSON"
+	connection := map[string]interface{}{
+		"type":        "string",
+		"description": "Name of the connection (optional when a single connection is configured)",
+	}
+	if config := GetSQLQueryConfig(); config != nil {
+		var names []string
+		for _, name := range config.connectionNames() {
+			c := config.Connections[name]
+			mode := ""
+			if c.ReadOnly {
+				mode = ", read-only"
+			}
+			names = append(names, fmt.Sprintf("%s (%s%s)", name, c.Driver, mode))
+		}
+		description += ". Connections: " + strings.Join(names, ", ")
+		connection["enum"] = config.connectionNames()
+	}
+	return SQLQueryTool{
+		Name:        "sql_query",
+		Description: description,
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"connection": connection,
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "A single SQL statement, with placeholders for params (? for SQLite and MySQL, $1 for Postgres)",
+				},
+				"params": map[string]interface{}{
+					"type":        "array",
+					"description": "Values bound to the placeholders of the query",
+				},
+				"max_rows": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of rows to return, at most the configured limit",
+					"minimum":     1,
+				},
+				"format": map[string]interface{}{
+					"type":        "string",
+					"enum":        []string{"table", "json"},
+					"description": "Output format (default: table)",
+					"default":     "table",
+				},
+			},
+			"required": []string{"query"},
+		},
+	}
+}
+
+// SQLConnection describes a named database connection
+type SQLConnection struct {
+	// Driver is the kind of database, "sqlite", "postgres" or "mysql", or
+	// the name of any registered database/sql driver
+	Driver string
+	DSN    string
+	// ReadOnly accepts only queries, run in a read-only transaction that is
+	// rolled back
+	ReadOnly bool
+}
+
+// SQLQueryConfig holds the configuration for the sql_query tool
+type SQLQueryConfig struct {
+	Connections map[string]SQLConnection
+	Timeout     time.Duration
+	MaxRows     int
+
+	mu  sync.Mutex
+	dbs map[string]*sql.DB
+}
+
+var sqlQueryConfig *SQLQueryConfig
+
+// SetSQLQueryConfig enables the sql_query tool, closing the databases of a
+// previous configuration. Connections are opened when first used. A zero
+// timeout or row limit selects the default.
+func SetSQLQueryConfig(connections map[string]SQLConnection, timeout time.Duration, maxRows int) {
+	if timeout <= 0 {
+		timeout = DefaultSQLQueryTimeout
+	}
+	if maxRows <= 0 {
+		maxRows = DefaultSQLQueryMaxRows
+	}
+	if previous := sqlQueryConfig; previous != nil {
+		previous.close()
+	}
+	sqlQueryConfig = &SQLQueryConfig{
+		Connections: connections,
+		Timeout:     timeout,
+		MaxRows:     maxRows,
+		dbs:         make(map[string]*sql.DB),
+	}
+}
+
+// GetSQLQueryConfig returns the current configuration, nil when the tool is
+// disabled
+func GetSQLQueryConfig() *SQLQueryConfig {
+	return sqlQueryConfig
+}
+
+// connectionNames returns the sorted names of the connections
+func (c *SQLQueryConfig) connectionNames() []string {
+	names := make([]string, 0, len(c.Connections))
+	for name := range c.Connections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// db returns the database of the named connection, opening it on first use
+func (c *SQLQueryConfig) db(name string) (*sql.DB, SQLConnection, error) {
+	connection, ok := c.Connections[name]
+	if !ok {
+		return nil, connection, fmt.Errorf("unknown connection %q, configured: %s", name, strings.Join(c.connectionNames(), ", "))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if db, ok := c.dbs[name]; ok {
+		return db, connection, nil
+	}
+	driver, err := sqlDriver(connection.Driver)
+	if err != nil {
+		return nil, connection, err
+	}
+	db, err := sql.Open(driver, connection.DSN)
+	if err != nil {
+		return nil, connection, fmt.Errorf("failed to open connection %s: %v", name, err)
+	}
+	c.dbs[name] = db
+	return db, connection, nil
+}
+
+// close closes the opened databases
+func (c *SQLQueryConfig) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for name, db := range c.dbs {
+		db.Close()
+		delete(c.dbs, name)
+	}
+}
+
+// sqlDriver returns the registered database/sql driver for a database kind
+func sqlDriver(kind string) (string, error) {
+	registered := make(map[string]bool)
+	for _, name := range sql.Drivers() {
+		registered[name] = true
+	}
+	candidates, known := sqlDriverNames[strings.ToLower(kind)]
+	if !known {
+		candidates = []string{kind}
+	}
+	for _, name := range candidates {
+		if registered[name] {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no database/sql driver for %q is compiled into this build (registered: %s)", kind, strings.Join(sql.Drivers(), ", "))
+}
+
+// firstSQLKeyword returns the first keyword of a statement in upper case,
+// skipping comments and opening parentheses
+func firstSQLKeyword(query string) string {
+	for {
+		query = strings.TrimLeftFunc(query, func(r rune) bool { return unicode.IsSpace(r) || r == '(' })
+		switch {
+		case strings.HasPrefix(query, "--"):
+			end := strings.IndexByte(query, '\n')
+			if end < 0 {
+				return ""
+			}
+			query = query[end+1:]
+		case strings.HasPrefix(query, "/*"):
+			end := strings.Index(query, "*/")
+			if end < 0 {
+				return ""
+			}
+			query = query[end+2:]
+		default:
+			end := strings.IndexFunc(query, func(r rune) bool { return !unicode.IsLetter(r) })
+			if end < 0 {
+				end = len(query)
+			}
+			return strings.ToUpper(query[:end])
+		}
+	}
+}
+
+// hasMultipleStatements reports whether query holds more than one statement,
+// ignoring semicolons in quotes and comments and a trailing one
+func hasMultipleStatements(query string) bool {
+	var quote rune
+	for i := 0; i < len(query); i++ {
+		c := rune(query[i])
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				return false
+			}
+			i += end
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i:], "*/")
+			if end < 0 {
+				return false
+			}
+			i += end + 1
+		case c == ';':
+			return strings.TrimSpace(strings.TrimRight(query[i:], "; \t\r\n")) != ""
+		}
+	}
+	return false
+}
+
+// sqlValue converts a scanned column value for output
+func sqlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return v
+	}
+}
+
+// CallSQLQuery runs a query on a configured connection. Statements that do
+// not return rows report the number of rows they affected.
+func CallSQLQuery(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+	if sqlQueryConfig == nil {
+		return nil, fmt.Errorf("sql_query not configured. Set sql.enabled and connections in the config file")
+	}
+	config := sqlQueryConfig
+
+	args := struct {
+		Connection string        `arg:"connection"`
+		Query      string        `arg:"query,required"`
+		Params     []interface{} `arg:"params"`
+		MaxRows    int           `arg:"max_rows"`
+		Format     string        `arg:"format"`
+	}{MaxRows: config.MaxRows}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+
+	name := args.Connection
+	if name == "" {
+		if len(config.Connections) != 1 {
+			return nil, fmt.Errorf("connection argument is required, configured: %s", strings.Join(config.connectionNames(), ", "))
+		}
+		name = config.connectionNames()[0]
+	}
+	query, params, maxRows, format := args.Query, args.Params, args.MaxRows, args.Format
+	if maxRows < 1 || maxRows > config.MaxRows {
+		maxRows = config.MaxRows
+	}
+	if format == "" {
+		format = "table"
+	} else if format != "table" && format != "json" {
+		return nil, fmt.Errorf("format must be table or json")
+	}
+
+	db, connection, err := config.db(name)
+	if err != nil {
+		return nil, err
+	}
+	keyword := firstSQLKeyword(query)
+	returnsRows := sqlReadKeywords[keyword] || keyword == "PRAGMA"
+	if connection.ReadOnly {
+		if !sqlReadKeywords[keyword] {
+			return nil, fmt.Errorf("connection %s is read-only and only accepts queries, not %s", name, keyword)
+		}
+		if hasMultipleStatements(query) {
+			return nil, fmt.Errorf("connection %s is read-only and accepts a single statement", name)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	// Read-only connections run in a read-only transaction that is never
+	// committed, so even a data-modifying CTE cannot change anything
+	var tx *sql.Tx
+	if connection.ReadOnly {
+		if tx, err = db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true}); err != nil {
+			return nil, fmt.Errorf("failed to start a read-only transaction: %v", err)
+		}
+		defer tx.Rollback()
+	}
+
+	if !returnsRows {
+		var result sql.Result
+		if tx != nil {
+			result, err = tx.ExecContext(ctx, query, params...)
+		} else {
+			result, err = db.ExecContext(ctx, query, params...)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("query failed: %v", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return TextResult("Statement executed"), nil
+		}
+		return TextResult(fmt.Sprintf("Statement executed, %d rows affected", affected)), nil
+	}
+
+	var rows *sql.Rows
+	if tx != nil {
+		rows, err = tx.QueryContext(ctx, query, params...)
+	} else {
+		rows, err = db.QueryContext(ctx, query, params...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %v", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %v", err)
+	}
+	var records [][]interface{}
+	truncated := false
+	for rows.Next() {
+		if len(records) == maxRows {
+			truncated = true
+			break
+		}
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to read row: %v", err)
+		}
+		for i := range values {
+			values[i] = sqlValue(values[i])
+		}
+		records = append(records, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query failed: %v", err)
+	}
+
+	var output string
+	if format == "json" {
+		objects := make([]map[string]interface{}, len(records))
+		for i, record := range records {
+			objects[i] = make(map[string]interface{}, len(columns))
+			for j, column := range columns {
+				objects[i][column] = record[j]
+			}
+		}
+		data, err := json.MarshalIndent(objects, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode rows: %v", err)
+		}
+		output = string(data)
+	} else {
+		output = sqlTable(columns, records)
+	}
+	if truncated {
+		output += fmt.Sprintf("\n[Truncated after %d rows]", maxRows)
+	}
+	return TextResult(output), nil
+}
+
+// sqlTable renders rows as a markdown table
+func sqlTable(columns []string, records [][]interface{}) string {
+	var b strings.Builder
+	cell := func(value interface{}) string {
+		if value == nil {
+			return "NULL"
+		}
+		s := fmt.Sprint(value)
+		s = strings.ReplaceAll(s, "|", "\\|")
+		return strings.ReplaceAll(s, "\n", " ")
+	}
+	b.WriteString("| " + strings.Join(columns, " | ") + " |\n")
+	b.WriteString("|" + strings.Repeat("---|", len(columns)) + "\n")
+	for _, record := range records {
+		cells := make([]string, len(record))
+		for i, value := range record {
+			cells[i] = cell(value)
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	fmt.Fprintf(&b, "(%d rows)", len(records))
+	return b.String()
+}
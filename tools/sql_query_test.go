@@ -0,0 +1,129 @@
+package tools
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakeSQLDriver answers every query with the rows (1, "alice"), (2, NULL),
+// (3, the first parameter) and every other statement with 3 affected rows
+type fakeSQLDriver struct{}
+
+type fakeSQLConn struct{}
+
+type fakeSQLStmt struct{ query string }
+
+type fakeSQLRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func (fakeSQLDriver) Open(string) (driver.Conn, error) { return &fakeSQLConn{}, nil }
+
+func (c *fakeSQLConn) Prepare(query string) (driver.Stmt, error) { return &fakeSQLStmt{query}, nil }
+func (c *fakeSQLConn) Close() error                              { return nil }
+func (c *fakeSQLConn) Begin() (driver.Tx, error)                 { return c, nil }
+func (c *fakeSQLConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	fakeSQLReadOnly = opts.ReadOnly
+	return c, nil
+}
+func (c *fakeSQLConn) Commit() error   { return nil }
+func (c *fakeSQLConn) Rollback() error { return nil }
+
+func (s *fakeSQLStmt) Close() error  { return nil }
+func (s *fakeSQLStmt) NumInput() int { return -1 }
+func (s *fakeSQLStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(3), nil
+}
+func (s *fakeSQLStmt) Query(args []driver.Value) (driver.Rows, error) {
+	var param driver.Value
+	if len(args) > 0 {
+		param = args[0]
+	}
+	return &fakeSQLRows{rows: [][]driver.Value{{int64(1), []byte("alice")}, {int64(2), nil}, {int64(3), param}}}, nil
+}
+
+func (r *fakeSQLRows) Columns() []string { return []string{"id", "name"} }
+func (r *fakeSQLRows) Close() error      { return nil }
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if r.next == len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+// fakeSQLReadOnly records whether the last transaction was read-only
+var fakeSQLReadOnly bool
+
+func init() {
+	sql.Register("fakesql", fakeSQLDriver{})
+}
+
+func TestCallSQLQuery(t *testing.T) {
+	SetSQLQueryConfig(map[string]SQLConnection{
+		"app":     {Driver: "fakesql"},
+		"reports": {Driver: "fakesql", ReadOnly: true},
+	}, 0, 2)
+	defer func() { sqlQueryConfig = nil }()
+	ctx := context.Background()
+
+	result, err := resultText(CallSQLQuery(ctx, map[string]interface{}{"connection": "app", "query": "SELECT id, name FROM users", "max_rows": float64(5)}))
+	if err != nil {
+		t.Fatalf("CallSQLQuery failed: %v", err)
+	}
+	want := "| id | name |\n|---|---|\n| 1 | alice |\n| 2 | NULL |\n(2 rows)\n[Truncated after 2 rows]"
+	if result != want {
+		t.Errorf("Unexpected table:\n%s\nwant:\n%s", result, want)
+	}
+
+	result, err = resultText(CallSQLQuery(ctx, map[string]interface{}{"connection": "reports", "query": "/* report */ select * from users where name = ?", "params": []interface{}{"bob"}, "format": "json"}))
+	if err != nil {
+		t.Fatalf("CallSQLQuery failed: %v", err)
+	}
+	if !strings.Contains(result, `"name": "alice"`) || !fakeSQLReadOnly {
+		t.Errorf("Expected JSON rows from a read-only transaction, got:\n%s", result)
+	}
+
+	if result, err := resultText(CallSQLQuery(ctx, map[string]interface{}{"connection": "app", "query": "DELETE FROM users"})); err != nil || result != "Statement executed, 3 rows affected" {
+		t.Errorf("Unexpected exec result %q, %v", result, err)
+	}
+
+	// Read-only connections refuse writes and statement batches
+	for _, query := range []string{"DELETE FROM users", "SELECT 1; DROP TABLE users"} {
+		if _, err := CallSQLQuery(ctx, map[string]interface{}{"connection": "reports", "query": query}); err == nil || !strings.Contains(err.Error(), "read-only") {
+			t.Errorf("Expected %q to be refused, got %v", query, err)
+		}
+	}
+	if _, err := CallSQLQuery(ctx, map[string]interface{}{"query": "SELECT 1"}); err == nil {
+		t.Error("Expected an error without a connection when several are configured")
+	}
+}
+
+func TestSQLStatementParsing(t *testing.T) {
+	for query, want := range map[string]string{
+		"  select 1":                 "SELECT",
+		"-- note\n(WITH x AS (...))": "WITH",
+		"/* a */ Insert into t":      "INSERT",
+	} {
+		if got := firstSQLKeyword(query); got != want {
+			t.Errorf("firstSQLKeyword(%q) = %q, want %q", query, got, want)
+		}
+	}
+	for query, want := range map[string]bool{
+		"SELECT 1;":                false,
+		"SELECT ';' FROM t":        false,
+		"SELECT 1 -- ; x":          false,
+		"SELECT 1; DELETE FROM t":  true,
+		"SELECT 1 /* ; */; SELECT": true,
+	} {
+		if got := hasMultipleStatements(query); got != want {
+			t.Errorf("hasMultipleStatements(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
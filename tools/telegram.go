@@ -0,0 +1,327 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTelegramTimeout bounds each Telegram call when the configuration
+// sets no timeout
+const DefaultTelegramTimeout = 30 * time.Second
+
+// telegramAPIURL is the Bot API method URL, with %s standing for the bot
+// token and the method
+var telegramAPIURL = "https://api.telegram.org/bot%s/%s"
+
+// TelegramTool represents a Telegram tool definition
+type TelegramTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// GetTelegramTools returns the definitions of the Telegram tools
+func GetTelegramTools() []TelegramTool {
+	return []TelegramTool{
+		{
+			Name:        "telegram_send_message",
+			Description: "Send a message to a Telegram chat as the configured bot",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"chat_id": map[string]interface{}{
+						"type":        "integer",
+						"description": "ID of an allowed chat (optional when a single chat is allowed)",
+					},
+					"text": map[string]interface{}{
+						"type":        "string",
+						"description": "Text of the message",
+					},
+					"parse_mode": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"HTML", "MarkdownV2"},
+						"description": "Formatting of text (default: plain text)",
+					},
+					"reply_to_message_id": map[string]interface{}{
+						"type":        "integer",
+						"description": "ID of the message to reply to",
+					},
+				},
+				"required": []string{"text"},
+			},
+		},
+		{
+			Name:        "telegram_get_updates",
+			Description: "Get the messages the bot received in the allowed chats since the previous call",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of updates to fetch (1-100, default: 20)",
+						"default":     20,
+						"minimum":     1,
+						"maximum":     100,
+					},
+				},
+			},
+		},
+	}
+}
+
+// IsTelegramTool reports whether name is one of the Telegram tools
+func IsTelegramTool(name string) bool {
+	for _, tool := range GetTelegramTools() {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// TelegramConfig holds the configuration for the Telegram tools
+type TelegramConfig struct {
+	BotToken string
+	ChatIDs  []int64 // Chats the tools may use
+	Timeout  time.Duration
+
+	mu     sync.Mutex
+	offset int64 // First update getUpdates has not returned yet
+}
+
+var telegramConfig *TelegramConfig
+
+// SetTelegramConfig enables the Telegram tools for the chats. A zero timeout
+// selects the default.
+func SetTelegramConfig(botToken string, chatIDs []int64, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = DefaultTelegramTimeout
+	}
+	telegramConfig = &TelegramConfig{BotToken: botToken, ChatIDs: chatIDs, Timeout: timeout}
+}
+
+// GetTelegramConfig returns the current configuration, nil when the tools
+// are disabled
+func GetTelegramConfig() *TelegramConfig {
+	return telegramConfig
+}
+
+// chatAllowed reports whether the tools may use the chat
+func (c *TelegramConfig) chatAllowed(id int64) bool {
+	for _, allowed := range c.ChatIDs {
+		if allowed == id {
+			return true
+		}
+	}
+	return false
+}
+
+// CallTelegramTool executes the named Telegram tool
+func CallTelegramTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+	config := telegramConfig
+	if config == nil {
+		return nil, fmt.Errorf("Telegram tools not configured. Set telegram.enabled, a bot token and chat_ids in the config file")
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	switch name {
+	case "telegram_send_message":
+		return textResult(config.sendMessage(ctx, arguments))
+	case "telegram_get_updates":
+		return textResult(config.getUpdates(ctx, arguments))
+	default:
+		return nil, fmt.Errorf("unknown Telegram tool: %s", name)
+	}
+}
+
+// sendMessage sends a message to an allowed chat
+func (c *TelegramConfig) sendMessage(ctx context.Context, arguments map[string]interface{}) (string, error) {
+	var args struct {
+		ChatID           *int64 `arg:"chat_id"`
+		Text             string `arg:"text,required"`
+		ParseMode        string `arg:"parse_mode"`
+		ReplyToMessageID int    `arg:"reply_to_message_id"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return "", err
+	}
+	var chatID int64
+	if args.ChatID != nil {
+		chatID = *args.ChatID
+	} else if len(c.ChatIDs) == 1 {
+		chatID = c.ChatIDs[0]
+	} else {
+		return "", fmt.Errorf("chat_id argument is required when several chats are allowed")
+	}
+	if !c.chatAllowed(chatID) {
+		return "", fmt.Errorf("chat %d is not allowed", chatID)
+	}
+
+	request := map[string]interface{}{"chat_id": chatID, "text": args.Text}
+	if parseMode := args.ParseMode; parseMode != "" {
+		if parseMode != "HTML" && parseMode != "MarkdownV2" {
+			return "", fmt.Errorf("unsupported parse_mode %q, use HTML or MarkdownV2", parseMode)
+		}
+		request["parse_mode"] = parseMode
+	}
+	if replyTo := args.ReplyToMessageID; replyTo > 0 {
+		request["reply_parameters"] = map[string]interface{}{"message_id": replyTo}
+	}
+
+	var message telegramMessage
+	if err := c.call(ctx, "sendMessage", request, &message); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Sent message %d to chat %d", message.MessageID, chatID), nil
+}
+
+// telegramMessage is the part of a Telegram message the tools use
+type telegramMessage struct {
+	MessageID int64  `json:"message_id"`
+	Date      int64  `json:"date"`
+	Text      string `json:"text"`
+	Caption   string `json:"caption"`
+	Chat      struct {
+		ID    int64  `json:"id"`
+		Title string `json:"title"`
+		Type  string `json:"type"`
+	} `json:"chat"`
+	From *struct {
+		Username  string `json:"username"`
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+	} `json:"from"`
+	ReplyTo *struct {
+		MessageID int64 `json:"message_id"`
+	} `json:"reply_to_message"`
+}
+
+// getUpdates returns the messages received in the allowed chats since the
+// previous call. Fetched updates are confirmed, so Telegram drops them.
+func (c *TelegramConfig) getUpdates(ctx context.Context, arguments map[string]interface{}) (string, error) {
+	args := struct {
+		Limit int `arg:"limit"`
+	}{Limit: 20}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return "", err
+	}
+	limit := args.Limit
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	request := map[string]interface{}{
+		"limit":           limit,
+		"allowed_updates": []string{"message", "channel_post"},
+	}
+	if c.offset != 0 {
+		request["offset"] = c.offset
+	}
+	var updates []struct {
+		UpdateID    int64            `json:"update_id"`
+		Message     *telegramMessage `json:"message"`
+		ChannelPost *telegramMessage `json:"channel_post"`
+	}
+	if err := c.call(ctx, "getUpdates", request, &updates); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	count := 0
+	for _, update := range updates {
+		if update.UpdateID >= c.offset {
+			c.offset = update.UpdateID + 1
+		}
+		message := update.Message
+		if message == nil {
+			message = update.ChannelPost
+		}
+		if message == nil || !c.chatAllowed(message.Chat.ID) {
+			continue
+		}
+		count++
+		chat := strconv.FormatInt(message.Chat.ID, 10)
+		if message.Chat.Title != "" {
+			chat += " (" + message.Chat.Title + ")"
+		}
+		author := "channel"
+		if from := message.From; from != nil {
+			author = strings.TrimSpace(from.FirstName + " " + from.LastName)
+			if from.Username != "" {
+				author += " (@" + from.Username + ")"
+			}
+		}
+		text := message.Text
+		if text == "" {
+			text = message.Caption
+		}
+		if text == "" {
+			text = "[non-text message]"
+		}
+		fmt.Fprintf(&b, "[%s] chat %s, message %d", time.Unix(message.Date, 0).UTC().Format("2006-01-02 15:04:05"), chat, message.MessageID)
+		if message.ReplyTo != nil {
+			fmt.Fprintf(&b, ", reply to %d", message.ReplyTo.MessageID)
+		}
+		fmt.Fprintf(&b, "\n%s: %s\n\n", author, text)
+	}
+	if count == 0 {
+		return "No new messages", nil
+	}
+	return fmt.Sprintf("%d new messages\n\n%s", count, b.String()), nil
+}
+
+// call invokes a Bot API method and decodes its result into v
+func (c *TelegramConfig) call(ctx context.Context, method string, request interface{}, v interface{}) error {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf(telegramAPIURL, c.BotToken, method), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The URL holds the bot token; keep it out of the error
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("Telegram request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	var apiResp struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return fmt.Errorf("Telegram returned status %d", resp.StatusCode)
+	}
+	if !apiResp.OK {
+		return fmt.Errorf("Telegram %s failed: %s", method, apiResp.Description)
+	}
+	if err := json.Unmarshal(apiResp.Result, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
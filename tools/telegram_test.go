@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCallTelegramTool(t *testing.T) {
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&request)
+		requests = append(requests, request)
+		switch r.URL.Path {
+		case "/bot123:secret/sendMessage":
+			w.Write([]byte(`{"ok":true,"result":{"message_id":42,"chat":{"id":-100}}}`))
+		case "/bot123:secret/getUpdates":
+			if request["offset"] != nil {
+				w.Write([]byte(`{"ok":true,"result":[]}`))
+				return
+			}
+			w.Write([]byte(`{"ok":true,"result":[` +
+				`{"update_id":7,"message":{"message_id":10,"date":1700000000,"chat":{"id":-100,"title":"Ops"},"from":{"first_name":"Ada","username":"ada"},"text":"status?"}},` +
+				`{"update_id":8,"message":{"message_id":3,"date":1700000001,"chat":{"id":555},"from":{"first_name":"Eve"},"text":"hi bot"}}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"ok":false,"description":"Not Found"}`))
+		}
+	}))
+	defer server.Close()
+
+	defer func(original string) { telegramAPIURL = original }(telegramAPIURL)
+	telegramAPIURL = server.URL + "/bot%s/%s"
+	SetTelegramConfig("123:secret", []int64{-100}, 0)
+	defer func() { telegramConfig = nil }()
+	ctx := context.Background()
+
+	result, err := resultText(CallTelegramTool(ctx, "telegram_send_message", map[string]interface{}{"text": "Backup completed", "reply_to_message_id": float64(10)}))
+	if err != nil || result != "Sent message 42 to chat -100" {
+		t.Errorf("telegram_send_message = %q, %v", result, err)
+	}
+	if request := requests[0]; request["chat_id"] != float64(-100) || request["reply_parameters"] == nil {
+		t.Errorf("Unexpected request %v", request)
+	}
+	if _, err := CallTelegramTool(ctx, "telegram_send_message", map[string]interface{}{"chat_id": float64(555), "text": "hi"}); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Errorf("Expected a chat outside the allowlist to be rejected, got %v", err)
+	}
+
+	result, err = resultText(CallTelegramTool(ctx, "telegram_get_updates", map[string]interface{}{}))
+	if err != nil || !strings.Contains(result, "1 new messages") || !strings.Contains(result, "chat -100 (Ops), message 10\nAda (@ada): status?") {
+		t.Errorf("telegram_get_updates = %q, %v", result, err)
+	}
+	if strings.Contains(result, "hi bot") {
+		t.Errorf("Message from a chat outside the allowlist returned:\n%s", result)
+	}
+	result, err = resultText(CallTelegramTool(ctx, "telegram_get_updates", map[string]interface{}{}))
+	if err != nil || result != "No new messages" {
+		t.Errorf("Second telegram_get_updates = %q, %v", result, err)
+	}
+	if offset := requests[len(requests)-1]["offset"]; offset != float64(9) {
+		t.Errorf("Expected the updates to be confirmed with offset 9, got %v", offset)
+	}
+
+	telegramAPIURL = "http://127.0.0.1:0/bot%s/%s"
+	if _, err := CallTelegramTool(ctx, "telegram_get_updates", map[string]interface{}{}); err == nil || strings.Contains(err.Error(), "secret") {
+		t.Errorf("Expected an error without the bot token, got %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package toolstest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mcp-go/tools"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// UpdateGoldenEnv names the environment variable that rewrites golden files
+// with the responses received instead of comparing them:
+//
+//	UPDATE_GOLDEN=1 go test ./...
+const UpdateGoldenEnv = "UPDATE_GOLDEN"
+
+// Case is a tool call and its expected outcome, for Run
+type Case struct {
+	Name      string
+	Tool      string
+	Arguments map[string]interface{}
+	// Want is the expected text of the result, its text items joined by
+	// newlines
+	Want string
+	// Golden names a file under testdata holding the expected result as
+	// JSON, checked with AssertGolden
+	Golden string
+	// WantError is part of the expected error, or of the text of a result
+	// flagged isError
+	WantError string
+	// WantCode is the expected JSON-RPC error code, such as -32602 for
+	// invalid arguments
+	WantCode int
+}
+
+// Run calls the tool of each case through client as a subtest and checks
+// its outcome
+func Run(t *testing.T, client *Client, cases []Case) {
+	t.Helper()
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			result, err := client.Call(context.Background(), tc.Tool, tc.Arguments)
+
+			if tc.WantError != "" || tc.WantCode != 0 {
+				var rpcErr *RPCError
+				switch {
+				case err == nil && !result.IsError:
+					t.Fatalf("Expected an error, got %s", text(result.Content))
+				case err == nil:
+					if !strings.Contains(text(result.Content), tc.WantError) || tc.WantCode != 0 {
+						t.Errorf("Expected error %q (code %d), got the error result %q", tc.WantError, tc.WantCode, text(result.Content))
+					}
+				case !strings.Contains(err.Error(), tc.WantError):
+					t.Errorf("Expected error %q, got %v", tc.WantError, err)
+				case tc.WantCode != 0 && (!errors.As(err, &rpcErr) || rpcErr.Code != tc.WantCode):
+					t.Errorf("Expected error code %d, got %v", tc.WantCode, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.IsError {
+				t.Fatalf("Unexpected error result: %s", text(result.Content))
+			}
+			if tc.Want != "" {
+				if got := text(result.Content); got != tc.Want {
+					t.Errorf("Expected %q, got %q", tc.Want, got)
+				}
+			}
+			if tc.Golden != "" {
+				AssertGolden(t, tc.Golden, result)
+			}
+		})
+	}
+}
+
+// AssertGolden compares got with the content of testdata/<name>.golden.
+// Strings and byte slices are compared as they are, other values as
+// indented JSON. With UPDATE_GOLDEN set, the file is written instead.
+func AssertGolden(t testing.TB, name string, got interface{}) {
+	t.Helper()
+	var data []byte
+	switch got := got.(type) {
+	case string:
+		data = []byte(got)
+	case []byte:
+		data = got
+	default:
+		encoded, err := json.MarshalIndent(got, "", "  ")
+		if err != nil {
+			t.Fatalf("Failed to encode %s: %v", name, err)
+		}
+		data = append(encoded, '\n')
+	}
+
+	path := filepath.Join("testdata", name+".golden")
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with %s=1 to create it): %v", UpdateGoldenEnv, err)
+	}
+	if !bytes.Equal(data, want) {
+		t.Errorf("%s does not match %s:\n got: %s\nwant: %s", name, path, data, want)
+	}
+}
+
+// text joins the text items of a result
+func text(content []tools.Content) string {
+	var texts []string
+	for _, item := range content {
+		if item.Type == "text" {
+			texts = append(texts, item.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
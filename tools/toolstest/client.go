@@ -0,0 +1,195 @@
+// Package toolstest helps tool authors test their tools through the MCP
+// protocol without starting HTTP servers: an in-memory server and client
+// pair, table-driven cases, golden-response assertions and fake transports
+// for upstream servers.
+package toolstest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mcp-go/server"
+	"mcp-go/tools"
+	"mcp-go/transport"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// Client is an MCP client connected in-process to a server. It implements
+// transport.Transport, so it can also stand in for an upstream server.
+type Client struct {
+	handler      http.Handler
+	mu           sync.Mutex
+	sessionID    string
+	nextID       int
+	capabilities map[string]interface{}
+}
+
+// NewClient serves the tools of registry on an in-memory MCP server without
+// a gateway and returns a client connected to it
+func NewClient(registry *tools.Registry) *Client {
+	return Connect(server.NewServerWithRegistry(nil, registry))
+}
+
+// Connect returns a client sending requests to an MCP endpoint handler, such
+// as a *server.Server, in-process
+func Connect(handler http.Handler) *Client {
+	return &Client{handler: handler, nextID: 1}
+}
+
+// RPCError is a JSON-RPC error returned by the server, such as -32602 for
+// arguments not matching the input schema of a tool
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// Request sends a JSON-RPC request and decodes its result into result, if
+// not nil. A JSON-RPC error is returned as a *RPCError.
+func (c *Client) Request(ctx context.Context, method string, params interface{}, result interface{}) error {
+	c.mu.Lock()
+	id := c.nextID
+	c.nextID++
+	sessionID := c.sessionID
+	c.mu.Unlock()
+
+	body, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s request: %w", method, err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/mcp", bytes.NewReader(body)).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", sessionID)
+	}
+	w := httptest.NewRecorder()
+	c.handler.ServeHTTP(w, req)
+
+	if session := w.Header().Get("Mcp-Session-Id"); session != "" {
+		c.mu.Lock()
+		c.sessionID = session
+		c.mu.Unlock()
+	}
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		return fmt.Errorf("%s returned status %d and an invalid response %q: %w", method, w.Code, w.Body.String(), err)
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return fmt.Errorf("failed to decode %s result: %w", method, err)
+	}
+	return nil
+}
+
+// Call calls a tool and returns its result as the client received it,
+// including results flagged isError
+func (c *Client) Call(ctx context.Context, name string, arguments map[string]interface{}) (*tools.ToolResult, error) {
+	var result tools.ToolResult
+	err := c.Request(ctx, "tools/call", map[string]interface{}{"name": name, "arguments": arguments}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Initialize sends the initialize request
+func (c *Client) Initialize(ctx context.Context, config map[string]interface{}) error {
+	var result transport.InitializeResponse
+	err := c.Request(ctx, "initialize", map[string]interface{}{
+		"protocolVersion": "2024-11-05",
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]interface{}{"name": "toolstest", "version": "1.0.0"},
+	}, &result)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.capabilities = result.Capabilities
+	c.mu.Unlock()
+	return nil
+}
+
+// ListTools returns the tools the server lists
+func (c *Client) ListTools(ctx context.Context) ([]transport.Tool, error) {
+	var result transport.ToolsListResponse
+	if err := c.Request(ctx, "tools/list", map[string]interface{}{}, &result); err != nil {
+		return nil, err
+	}
+	return result.Tools, nil
+}
+
+// CallTool calls a tool and returns its response as a transport does
+func (c *Client) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*transport.ToolResponse, error) {
+	var result transport.ToolResponse
+	err := c.Request(ctx, "tools/call", map[string]interface{}{"name": name, "arguments": arguments}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListResources returns the resources the server lists
+func (c *Client) ListResources(ctx context.Context) ([]transport.Resource, error) {
+	var result transport.ResourcesListResponse
+	if err := c.Request(ctx, "resources/list", map[string]interface{}{}, &result); err != nil {
+		return nil, err
+	}
+	return result.Resources, nil
+}
+
+// ReadResource reads the contents of a resource
+func (c *Client) ReadResource(ctx context.Context, uri string) ([]transport.ResourceContent, error) {
+	var result transport.ResourceReadResponse
+	if err := c.Request(ctx, "resources/read", map[string]interface{}{"uri": uri}, &result); err != nil {
+		return nil, err
+	}
+	return result.Contents, nil
+}
+
+// ListPrompts returns the prompts the server lists
+func (c *Client) ListPrompts(ctx context.Context) ([]transport.Prompt, error) {
+	var result transport.PromptsListResponse
+	if err := c.Request(ctx, "prompts/list", map[string]interface{}{}, &result); err != nil {
+		return nil, err
+	}
+	return result.Prompts, nil
+}
+
+// GetPrompt renders a prompt
+func (c *Client) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*transport.PromptResult, error) {
+	var result transport.PromptResult
+	err := c.Request(ctx, "prompts/get", map[string]interface{}{"name": name, "arguments": arguments}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Capabilities returns the capabilities the server advertised, or nil
+// before Initialize
+func (c *Client) Capabilities() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.capabilities
+}
+
+// Close does nothing; the in-memory server needs no cleanup
+func (c *Client) Close() error {
+	return nil
+}
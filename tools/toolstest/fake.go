@@ -0,0 +1,127 @@
+package toolstest
+
+import (
+	"context"
+	"fmt"
+	"mcp-go/client"
+	"mcp-go/config"
+	"mcp-go/transport"
+	"sync"
+)
+
+// FakeTransport is a scripted upstream MCP server for testing code that
+// calls tools through a gateway or a transport. It records every call.
+type FakeTransport struct {
+	Tools     []transport.Tool
+	Resources []transport.Resource
+	Prompts   []transport.Prompt
+	// Responses answer calls by tool name
+	Responses map[string]*transport.ToolResponse
+	// Contents answer resource reads by URI, and PromptResults prompt
+	// requests by name
+	Contents      map[string][]transport.ResourceContent
+	PromptResults map[string]*transport.PromptResult
+	// Handler answers the calls of tools without a response, when set
+	Handler func(ctx context.Context, name string, arguments map[string]interface{}) (*transport.ToolResponse, error)
+	// InitializeError fails Initialize, as an unreachable server would
+	InitializeError error
+
+	mu     sync.Mutex
+	calls  []Call
+	closed bool
+}
+
+// Call is a tool call received by a FakeTransport
+type Call struct {
+	Name      string
+	Arguments map[string]interface{}
+}
+
+// Text returns a response of one text item, for FakeTransport.Responses
+func Text(text string) *transport.ToolResponse {
+	return &transport.ToolResponse{Content: []transport.ContentItem{{Type: "text", Text: text}}}
+}
+
+// NewFakeClient returns a gateway client named name that reaches t, with
+// tool names prefixed by prefix
+func NewFakeClient(name, prefix string, t transport.Transport) client.Client {
+	return client.NewClientWithTransport(config.MCPConfig{Name: name, Prefix: prefix, Enabled: true}, t)
+}
+
+// Calls returns the tool calls received so far, in order
+func (f *FakeTransport) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// Closed reports whether Close was called
+func (f *FakeTransport) Closed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+func (f *FakeTransport) Initialize(ctx context.Context, config map[string]interface{}) error {
+	return f.InitializeError
+}
+
+func (f *FakeTransport) ListTools(ctx context.Context) ([]transport.Tool, error) {
+	return append([]transport.Tool(nil), f.Tools...), nil
+}
+
+func (f *FakeTransport) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*transport.ToolResponse, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, Call{Name: name, Arguments: arguments})
+	f.mu.Unlock()
+
+	if response, ok := f.Responses[name]; ok {
+		return response, nil
+	}
+	if f.Handler != nil {
+		return f.Handler(ctx, name, arguments)
+	}
+	return nil, fmt.Errorf("tool '%s' not found", name)
+}
+
+func (f *FakeTransport) ListResources(ctx context.Context) ([]transport.Resource, error) {
+	return f.Resources, nil
+}
+
+func (f *FakeTransport) ReadResource(ctx context.Context, uri string) ([]transport.ResourceContent, error) {
+	if contents, ok := f.Contents[uri]; ok {
+		return contents, nil
+	}
+	return nil, fmt.Errorf("resource '%s' not found", uri)
+}
+
+func (f *FakeTransport) ListPrompts(ctx context.Context) ([]transport.Prompt, error) {
+	return f.Prompts, nil
+}
+
+func (f *FakeTransport) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*transport.PromptResult, error) {
+	if result, ok := f.PromptResults[name]; ok {
+		return result, nil
+	}
+	return nil, fmt.Errorf("prompt '%s' not found", name)
+}
+
+// Capabilities advertises tools, and resources and prompts when the fake
+// has some
+func (f *FakeTransport) Capabilities() map[string]interface{} {
+	capabilities := map[string]interface{}{"tools": map[string]interface{}{}}
+	if len(f.Resources) > 0 {
+		capabilities["resources"] = map[string]interface{}{}
+	}
+	if len(f.Prompts) > 0 {
+		capabilities["prompts"] = map[string]interface{}{}
+	}
+	return capabilities
+}
+
+func (f *FakeTransport) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
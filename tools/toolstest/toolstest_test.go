@@ -0,0 +1,77 @@
+package toolstest
+
+import (
+	"context"
+	"mcp-go/gateway"
+	"mcp-go/server"
+	"mcp-go/tools"
+	"mcp-go/transport"
+	"testing"
+)
+
+func greetRegistry() *tools.Registry {
+	r := tools.NewRegistry()
+	r.MustRegister(tools.NewTool(tools.Definition{
+		Name:        "greet",
+		Description: "Greet someone",
+		InputSchema: map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"name": map[string]interface{}{"type": "string"}},
+			"required":   []string{"name"},
+		},
+	}, func(_ context.Context, arguments map[string]interface{}) (*tools.ToolResult, error) {
+		var args struct {
+			Name string `arg:"name,required"`
+		}
+		if err := tools.DecodeArgs(arguments, &args); err != nil {
+			return nil, err
+		}
+		if args.Name == "nobody" {
+			return tools.ErrorResult("nobody cannot be greeted"), nil
+		}
+		return tools.StructuredResult("Hello, "+args.Name, map[string]string{"greeting": "Hello", "name": args.Name}), nil
+	}))
+	return r
+}
+
+func TestRun(t *testing.T) {
+	client := NewClient(greetRegistry())
+	Run(t, client, []Case{
+		{Name: "text", Tool: "greet", Arguments: map[string]interface{}{"name": "Ann"}, Want: "Hello, Ann"},
+		{Name: "golden", Tool: "greet", Arguments: map[string]interface{}{"name": "Bob"}, Golden: "greet"},
+		{Name: "invalid", Tool: "greet", Arguments: map[string]interface{}{}, WantError: "name: is required", WantCode: -32602},
+		{Name: "error result", Tool: "greet", Arguments: map[string]interface{}{"name": "nobody"}, WantError: "cannot be greeted"},
+		{Name: "unknown", Tool: "wave", WantError: "tool 'wave' not found"},
+	})
+
+	listed, err := client.ListTools(context.Background())
+	if err != nil || len(listed) != 1 || listed[0].Name != "greet" {
+		t.Errorf("Expected the greet tool to be listed, got %v, %v", listed, err)
+	}
+}
+
+func TestFakeTransport(t *testing.T) {
+	fake := &FakeTransport{
+		Tools:     []transport.Tool{{Name: "ping"}},
+		Responses: map[string]*transport.ToolResponse{"ping": Text("pong")},
+	}
+	gw := gateway.NewGateway()
+	if err := gw.AddClient(NewFakeClient("upstream", "up:", fake)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Local tools and upstream tools are served side by side
+	client := Connect(server.NewServerWithRegistry(gw, greetRegistry()))
+	if err := client.Initialize(context.Background(), nil); err != nil || client.Capabilities() == nil {
+		t.Fatalf("Failed to initialize: %v", err)
+	}
+	Run(t, client, []Case{
+		{Name: "upstream", Tool: "up:ping", Arguments: map[string]interface{}{"n": 1.0}, Want: "pong"},
+		{Name: "local", Tool: "greet", Arguments: map[string]interface{}{"name": "Cy"}, Want: "Hello, Cy"},
+	})
+
+	calls := fake.Calls()
+	if len(calls) != 1 || calls[0].Name != "ping" || calls[0].Arguments["n"] != 1.0 {
+		t.Errorf("Expected one call to ping, got %+v", calls)
+	}
+}
@@ -0,0 +1,329 @@
+package tools
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits of the file watcher
+const (
+	maxWatchSubscriptions = 64
+	// maxWatchedFiles bounds the snapshot of one subscription
+	maxWatchedFiles = 10000
+	// maxBufferedEvents bounds the events kept for get_file_events
+	maxBufferedEvents = 1000
+)
+
+// defaultWatchInterval is how often watched paths are polled for changes.
+// Polling keeps the watcher portable and free of dependencies.
+const defaultWatchInterval = time.Second
+
+// FileEvent is a change to a watched path
+type FileEvent struct {
+	Subscription string    `json:"subscription"`
+	Path         string    `json:"path"`
+	Op           string    `json:"op"` // created, modified or removed
+	Time         time.Time `json:"time"`
+}
+
+// fileState is what the watcher compares between polls
+type fileState struct {
+	size    int64
+	modTime time.Time
+	mode    os.FileMode
+}
+
+// subscription is one watch_path registration
+type subscription struct {
+	id        string
+	path      string
+	recursive bool
+	snapshot  map[string]fileState
+	events    []FileEvent
+	dropped   int
+}
+
+// watcher holds the subscriptions, polled by a single goroutine while any
+// exist
+var watcher = struct {
+	sync.Mutex
+	subscriptions map[string]*subscription
+	notify        func(FileEvent)
+	interval      time.Duration
+	running       bool
+}{subscriptions: make(map[string]*subscription), interval: defaultWatchInterval}
+
+// SetWatchNotifier registers fn to be called for every change to a watched
+// path, e.g. to push notifications to connected clients. Events are buffered
+// for get_file_events either way.
+func SetWatchNotifier(fn func(FileEvent)) {
+	watcher.Lock()
+	defer watcher.Unlock()
+	watcher.notify = fn
+}
+
+// GetWatchPathTool returns the watch_path tool definition
+func GetWatchPathTool() FileSystemTool {
+	return FileSystemTool{
+		Name:        "watch_path",
+		Description: "Watch a file or directory for changes; changes are sent as notifications and can be fetched with get_file_events",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"path": map[string]interface{}{
+					"type":        "string",
+					"description": "The file or directory to watch",
+				},
+				"recursive": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Also watch everything below a directory (default: only its direct entries)",
+				},
+			},
+			"required": []string{"path"},
+		},
+	}
+}
+
+// GetUnwatchPathTool returns the unwatch_path tool definition
+func GetUnwatchPathTool() FileSystemTool {
+	return FileSystemTool{
+		Name:        "unwatch_path",
+		Description: "Stop a watch_path subscription",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"subscription": map[string]interface{}{
+					"type":        "string",
+					"description": "The subscription id returned by watch_path",
+				},
+			},
+			"required": []string{"subscription"},
+		},
+	}
+}
+
+// GetFileEventsTool returns the get_file_events tool definition
+func GetFileEventsTool() FileSystemTool {
+	return FileSystemTool{
+		Name:        "get_file_events",
+		Description: "Return and clear the changes seen by a watch_path subscription since the last call",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"subscription": map[string]interface{}{
+					"type":        "string",
+					"description": "The subscription id returned by watch_path",
+				},
+			},
+			"required": []string{"subscription"},
+		},
+	}
+}
+
+// CallWatchPath registers a subscription for changes below a path
+func CallWatchPath(arguments map[string]interface{}) (*ToolResult, error) {
+	var args struct {
+		Path      string `arg:"path,required"`
+		Recursive bool   `arg:"recursive"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	path, recursive := args.Path, args.Recursive
+
+	// Resolve absolute path within the allowed paths
+	absPath, err := resolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(absPath); err != nil {
+		return nil, fmt.Errorf("file or directory does not exist: %v", err)
+	}
+
+	id, err := newSubscriptionID()
+	if err != nil {
+		return nil, err
+	}
+	sub := &subscription{id: id, path: absPath, recursive: recursive}
+	sub.snapshot = sub.scan()
+
+	watcher.Lock()
+	defer watcher.Unlock()
+	if len(watcher.subscriptions) >= maxWatchSubscriptions {
+		return nil, fmt.Errorf("too many watch subscriptions (at most %d); unwatch some first", maxWatchSubscriptions)
+	}
+	watcher.subscriptions[id] = sub
+	if !watcher.running {
+		watcher.running = true
+		go pollWatches()
+	}
+
+	return TextResult(fmt.Sprintf("Watching %s (%d entries) with subscription %s", absPath, len(sub.snapshot), id)), nil
+}
+
+// CallUnwatchPath removes a subscription
+func CallUnwatchPath(arguments map[string]interface{}) (*ToolResult, error) {
+	var args struct {
+		Subscription string `arg:"subscription,required"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	id := args.Subscription
+
+	watcher.Lock()
+	defer watcher.Unlock()
+	sub, ok := watcher.subscriptions[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown subscription %s", id)
+	}
+	delete(watcher.subscriptions, id)
+
+	return TextResult(fmt.Sprintf("Stopped watching %s", sub.path)), nil
+}
+
+// CallGetFileEvents returns and clears the buffered events of a subscription
+func CallGetFileEvents(arguments map[string]interface{}) (*ToolResult, error) {
+	var args struct {
+		Subscription string `arg:"subscription,required"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	id := args.Subscription
+
+	watcher.Lock()
+	sub, ok := watcher.subscriptions[id]
+	if !ok {
+		watcher.Unlock()
+		return nil, fmt.Errorf("unknown subscription %s", id)
+	}
+	events, dropped := sub.events, sub.dropped
+	sub.events, sub.dropped = nil, 0
+	watcher.Unlock()
+
+	if len(events) == 0 {
+		return TextResult(fmt.Sprintf("No changes to %s", sub.path)), nil
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d changes to %s:\n", len(events), sub.path)
+	if dropped > 0 {
+		fmt.Fprintf(&b, "  (%d older changes were dropped)\n", dropped)
+	}
+	for _, event := range events {
+		fmt.Fprintf(&b, "  %s %s %s\n", event.Time.UTC().Format(time.RFC3339), event.Op, event.Path)
+	}
+	return TextResult(b.String()), nil
+}
+
+// pollWatches polls the subscriptions until none is left
+func pollWatches() {
+	for {
+		watcher.Lock()
+		interval := watcher.interval
+		watcher.Unlock()
+		time.Sleep(interval)
+
+		watcher.Lock()
+		if len(watcher.subscriptions) == 0 {
+			watcher.running = false
+			watcher.Unlock()
+			return
+		}
+		subs := make([]*subscription, 0, len(watcher.subscriptions))
+		for _, sub := range watcher.subscriptions {
+			subs = append(subs, sub)
+		}
+		watcher.Unlock()
+
+		// Scan without holding the lock; only this goroutine touches snapshots
+		for _, sub := range subs {
+			current := sub.scan()
+			events := diffSnapshots(sub.id, sub.snapshot, current)
+			sub.snapshot = current
+			if len(events) == 0 {
+				continue
+			}
+
+			watcher.Lock()
+			sub.events = append(sub.events, events...)
+			if over := len(sub.events) - maxBufferedEvents; over > 0 {
+				sub.events = sub.events[over:]
+				sub.dropped += over
+			}
+			notify := watcher.notify
+			watcher.Unlock()
+
+			if notify != nil {
+				for _, event := range events {
+					notify(event)
+				}
+			}
+		}
+	}
+}
+
+// scan returns the state of the watched path and, for directories, of the
+// entries below it. Symlinks are not followed.
+func (s *subscription) scan() map[string]fileState {
+	snapshot := make(map[string]fileState)
+	filepath.WalkDir(s.path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if len(snapshot) >= maxWatchedFiles {
+			return fs.SkipAll
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		snapshot[file] = fileState{size: info.Size(), modTime: info.ModTime(), mode: info.Mode()}
+		if d.IsDir() && file != s.path && !s.recursive {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return snapshot
+}
+
+// diffSnapshots returns the events turning before into after, sorted by path
+func diffSnapshots(id string, before, after map[string]fileState) []FileEvent {
+	now := time.Now()
+	var events []FileEvent
+	for path, state := range after {
+		old, existed := before[path]
+		switch {
+		case !existed:
+			events = append(events, FileEvent{Subscription: id, Path: path, Op: "created", Time: now})
+		case old != state && !state.mode.IsDir():
+			// A directory's mtime changes with its entries, which are
+			// reported themselves
+			events = append(events, FileEvent{Subscription: id, Path: path, Op: "modified", Time: now})
+		}
+	}
+	for path := range before {
+		if _, exists := after[path]; !exists {
+			events = append(events, FileEvent{Subscription: id, Path: path, Op: "removed", Time: now})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Path < events[j].Path })
+	return events
+}
+
+// newSubscriptionID returns a random subscription id
+func newSubscriptionID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to create subscription: %v", err)
+	}
+	return "watch-" + hex.EncodeToString(b), nil
+}
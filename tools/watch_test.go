@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchPath(t *testing.T) {
+	root := sandbox(t)
+	setWatchInterval(10 * time.Millisecond)
+	defer setWatchInterval(defaultWatchInterval)
+
+	events := make(chan FileEvent, 16)
+	SetWatchNotifier(func(event FileEvent) { events <- event })
+	defer SetWatchNotifier(nil)
+
+	existing := filepath.Join(root, "existing.txt")
+	if err := os.WriteFile(existing, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := resultText(CallWatchPath(map[string]interface{}{"path": root, "recursive": true}))
+	if err != nil {
+		t.Fatalf("CallWatchPath failed: %v", err)
+	}
+	id := result[strings.LastIndex(result, " ")+1:]
+	defer CallUnwatchPath(map[string]interface{}{"subscription": id})
+
+	created := filepath.Join(root, "sub", "new.txt")
+	writeTree(t, root, map[string]string{"sub/new.txt": "b"})
+	if err := os.Remove(existing); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{filepath.Join(root, "sub"): "created", created: "created", existing: "removed"}
+	deadline := time.After(5 * time.Second)
+	for len(want) > 0 {
+		select {
+		case event := <-events:
+			if want[event.Path] == event.Op {
+				delete(want, event.Path)
+			}
+			if event.Subscription != id {
+				t.Errorf("Unexpected subscription %s", event.Subscription)
+			}
+		case <-deadline:
+			t.Fatalf("Missing events: %v", want)
+		}
+	}
+
+	// The same events are buffered for get_file_events, once
+	result, err = resultText(CallGetFileEvents(map[string]interface{}{"subscription": id}))
+	if err != nil || !strings.Contains(result, "created "+created) || !strings.Contains(result, "removed "+existing) {
+		t.Errorf("Unexpected events: %q, %v", result, err)
+	}
+	if result, _ := resultText(CallGetFileEvents(map[string]interface{}{"subscription": id})); !strings.HasPrefix(result, "No changes") {
+		t.Errorf("Expected the events to be cleared, got %q", result)
+	}
+
+	if _, err := CallUnwatchPath(map[string]interface{}{"subscription": id}); err != nil {
+		t.Errorf("CallUnwatchPath failed: %v", err)
+	}
+	if _, err := CallGetFileEvents(map[string]interface{}{"subscription": id}); err == nil {
+		t.Error("Expected an unknown subscription after unwatching")
+	}
+}
+
+// setWatchInterval changes the polling interval of the watcher
+func setWatchInterval(interval time.Duration) {
+	watcher.Lock()
+	defer watcher.Unlock()
+	watcher.interval = interval
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	now := time.Now()
+	before := map[string]fileState{
+		"/a": {size: 1, modTime: now},
+		"/b": {size: 1, modTime: now},
+		"/d": {mode: os.ModeDir, modTime: now},
+	}
+	after := map[string]fileState{
+		"/a": {size: 2, modTime: now},
+		"/c": {size: 1, modTime: now},
+		"/d": {mode: os.ModeDir, modTime: now.Add(time.Second)},
+	}
+	var got []string
+	for _, event := range diffSnapshots("id", before, after) {
+		got = append(got, event.Op+" "+event.Path)
+	}
+	if strings.Join(got, ",") != "modified /a,removed /b,created /c" {
+		t.Errorf("Unexpected events: %v", got)
+	}
+}
@@ -0,0 +1,211 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Defaults for the whois tool when the configuration leaves them unset
+const (
+	DefaultWhoisTimeout   = 15 * time.Second
+	DefaultWhoisMaxLength = 20000
+)
+
+// whoisMaxResponseBytes bounds the response read from a WHOIS server
+const whoisMaxResponseBytes = 1 << 20
+
+// whoisMaxReferrals bounds the chain of servers a query follows
+const whoisMaxReferrals = 3
+
+// whoisRootServer is the server a query starts at, which refers it to the
+// registry of the TLD or address block. A variable so tests can replace it.
+var whoisRootServer = "whois.iana.org:43"
+
+// WhoisTool represents the whois tool definition
+type WhoisTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// GetWhoisTool returns the whois tool definition
+func GetWhoisTool() WhoisTool {
+	return WhoisTool{
+		Name:        "whois",
+		Description: "Look up the WHOIS registration of a domain name, IP address or AS number, following referrals from IANA to the registry and registrar",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "The domain name, IP address or AS number, such as example.com, 192.0.2.1 or AS15169",
+				},
+				"server": map[string]interface{}{
+					"type":        "string",
+					"description": "WHOIS server to ask directly instead of following referrals from IANA, such as whois.verisign-grs.com",
+				},
+			},
+			"required": []string{"query"},
+		},
+	}
+}
+
+// WhoisConfig holds the configuration for the whois tool
+type WhoisConfig struct {
+	Timeout   time.Duration // Of the whole lookup, referrals included
+	MaxLength int           // Characters of the response returned
+}
+
+var whoisConfig *WhoisConfig
+
+// SetWhoisConfig enables the whois tool. Zero values select the defaults.
+func SetWhoisConfig(timeout time.Duration, maxLength int) {
+	if timeout <= 0 {
+		timeout = DefaultWhoisTimeout
+	}
+	if maxLength <= 0 {
+		maxLength = DefaultWhoisMaxLength
+	}
+	whoisConfig = &WhoisConfig{Timeout: timeout, MaxLength: maxLength}
+}
+
+// GetWhoisConfig returns the current configuration, nil when the tool is
+// disabled
+func GetWhoisConfig() *WhoisConfig {
+	return whoisConfig
+}
+
+// CallWhois looks up a domain, address or AS number and returns the
+// response of the most specific server reached
+func CallWhois(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+	config := whoisConfig
+	if config == nil {
+		return nil, fmt.Errorf("whois not configured. Set whois.enabled in the config file")
+	}
+	var args struct {
+		Query  string `arg:"query,required"`
+		Server string `arg:"server"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	query := strings.TrimSuffix(strings.TrimSpace(args.Query), ".")
+	if query == "" {
+		return nil, fmt.Errorf("query argument is required and must be a non-empty string")
+	}
+	if strings.ContainsAny(query, "\r\n") {
+		return nil, fmt.Errorf("query must be a single line")
+	}
+	server := whoisRootServer
+	explicit := args.Server
+	if explicit != "" {
+		server = whoisAddress(explicit)
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	response, err := whoisQuery(ctx, server, query)
+	if err != nil {
+		return nil, err
+	}
+	servers := []string{server}
+	if explicit == "" {
+		for i := 0; i < whoisMaxReferrals; i++ {
+			referral := whoisReferral(response)
+			if referral == "" || containsFold(servers, referral) {
+				break
+			}
+			next, err := whoisQuery(ctx, referral, query)
+			if err != nil {
+				// Registrar servers are often flaky; keep the registry's answer
+				response += fmt.Sprintf("\n[Referral to %s failed: %v]", referral, err)
+				break
+			}
+			servers, response = append(servers, referral), next
+		}
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "WHOIS %s (via %s)\n\n", query, strings.Join(servers, " -> "))
+	response = strings.TrimSpace(strings.ReplaceAll(response, "\r\n", "\n"))
+	if total := utf8.RuneCountInString(response); total > config.MaxLength {
+		response = string([]rune(response)[:config.MaxLength]) +
+			fmt.Sprintf("\n\n[Response truncated at %d of %d characters]", config.MaxLength, total)
+	}
+	result.WriteString(response)
+	return TextResult(result.String()), nil
+}
+
+// whoisQuery sends a query to a WHOIS server and reads the response
+func whoisQuery(ctx context.Context, server, query string) (string, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to %s: %w", server, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if _, err := io.WriteString(conn, query+"\r\n"); err != nil {
+		return "", fmt.Errorf("failed to query %s: %w", server, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(conn, whoisMaxResponseBytes))
+	if err != nil && len(data) == 0 {
+		return "", fmt.Errorf("failed to read the response of %s: %w", server, err)
+	}
+	if !utf8.Valid(data) {
+		data = []byte(strings.ToValidUTF8(string(data), "�"))
+	}
+	return string(data), nil
+}
+
+// whoisReferral returns the address of the server a response refers the
+// query to: the refer line of IANA, or the registrar server of a thin
+// registry
+func whoisReferral(response string) string {
+	scanner := bufio.NewScanner(strings.NewReader(response))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !found {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "refer", "whois", "registrar whois server", "referralserver":
+			value = strings.TrimSpace(value)
+			// ARIN refers with URLs; only whois:// speaks this protocol
+			if strings.Contains(value, "://") && !strings.HasPrefix(value, "whois://") {
+				continue
+			}
+			if value != "" && !strings.ContainsAny(value, " \t") {
+				return whoisAddress(value)
+			}
+		}
+	}
+	return ""
+}
+
+// whoisAddress adds the WHOIS port to a server name without one
+func whoisAddress(server string) string {
+	server = strings.TrimPrefix(server, "whois://")
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+	return net.JoinHostPort(server, "43")
+}
+
+// containsFold reports whether list holds s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
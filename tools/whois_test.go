@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveWhois answers each query with respond on a local TCP port and
+// returns its address
+func serveWhois(t *testing.T, respond func(query string) string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			query, _ := bufio.NewReader(conn).ReadString('\n')
+			conn.Write([]byte(respond(strings.TrimSpace(query))))
+			conn.Close()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestCallWhois(t *testing.T) {
+	registrar := serveWhois(t, func(query string) string {
+		return "Domain Name: " + query + "\r\nRegistrant: Example Inc.\r\n"
+	})
+	registry := serveWhois(t, func(query string) string {
+		return "Domain Name: EXAMPLE.TEST\r\nRegistrar WHOIS Server: " + registrar + "\r\n"
+	})
+	root := serveWhois(t, func(query string) string {
+		return "% IANA WHOIS server\n\nrefer:        " + registry + "\n"
+	})
+	defer func(server string) { whoisRootServer = server }(whoisRootServer)
+	whoisRootServer = root
+	defer func() { whoisConfig = nil }()
+	SetWhoisConfig(time.Second, 0)
+
+	result, err := resultText(CallWhois(context.Background(), map[string]interface{}{"query": "example.test"}))
+	if err != nil {
+		t.Fatalf("CallWhois failed: %v", err)
+	}
+	expected := "WHOIS example.test (via " + root + " -> " + registry + " -> " + registrar + ")\n\nDomain Name: example.test\nRegistrant: Example Inc."
+	if result != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, result)
+	}
+
+	// An explicit server is asked without following its referrals
+	result, err = resultText(CallWhois(context.Background(), map[string]interface{}{"query": "example.test", "server": registry}))
+	if err != nil || !strings.HasPrefix(result, "WHOIS example.test (via "+registry+")\n\nDomain Name: EXAMPLE.TEST") {
+		t.Errorf("Expected the registry's answer, got %q, %v", result, err)
+	}
+
+	if _, err := CallWhois(context.Background(), map[string]interface{}{"query": "example.test\r\nother"}); err == nil {
+		t.Error("Expected a multi-line query to be refused")
+	}
+}
+
+func TestWhoisReferral(t *testing.T) {
+	tests := map[string]string{
+		"refer:        whois.verisign-grs.com\n":              "whois.verisign-grs.com:43",
+		"   Registrar WHOIS Server: whois.markmonitor.com\n":  "whois.markmonitor.com:43",
+		"ReferralServer:  whois://whois.ripe.net\n":           "whois.ripe.net:43",
+		"ReferralServer:  rwhois://rwhois.example.net:4321\n": "",
+		"Domain Name: EXAMPLE.COM\n":                          "",
+	}
+	for response, expected := range tests {
+		if got := whoisReferral(response); got != expected {
+			t.Errorf("whoisReferral(%q) = %q, expected %q", response, got, expected)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// wikipediaAPIURL is the MediaWiki action API of a Wikipedia, with %s
+// standing for the language code
+var wikipediaAPIURL = "https://%s.wikipedia.org/w/api.php"
+
+// wikipediaLanguagePattern matches Wikipedia language codes such as "en",
+// "simple" or "zh-min-nan", which become part of the API host name
+var wikipediaLanguagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z]{1,8})*$|^simple$`)
+
+// WikipediaTool represents a Wikipedia tool definition
+type WikipediaTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// wikipediaLanguageProperty is the schema of the language argument of the
+// Wikipedia tools
+var wikipediaLanguageProperty = map[string]interface{}{
+	"type":        "string",
+	"description": "Language code of the Wikipedia to use, e.g. \"en\", \"de\" or \"fa\" (default: the configured language)",
+}
+
+// GetWikipediaTools returns the definitions of the Wikipedia tools
+func GetWikipediaTools() []WikipediaTool {
+	return []WikipediaTool{
+		{
+			Name:        "wikipedia_search",
+			Description: "Search Wikipedia for articles matching a query",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "The search query",
+					},
+					"language": wikipediaLanguageProperty,
+					"max_results": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of results to return (1-50, default: 10)",
+						"default":     10,
+						"minimum":     1,
+						"maximum":     50,
+					},
+				},
+				"required": []string{"query"},
+			},
+		},
+		{
+			Name:        "wikipedia_summary",
+			Description: "Get the introduction of a Wikipedia article as plain text",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"title": map[string]interface{}{
+						"type":        "string",
+						"description": "Title of the article; redirects are followed",
+					},
+					"language": wikipediaLanguageProperty,
+					"sentences": map[string]interface{}{
+						"type":        "integer",
+						"description": "Only return this many sentences (1-10, default: the whole introduction)",
+						"minimum":     1,
+						"maximum":     10,
+					},
+				},
+				"required": []string{"title"},
+			},
+		},
+	}
+}
+
+// IsWikipediaTool reports whether name is one of the Wikipedia tools
+func IsWikipediaTool(name string) bool {
+	for _, tool := range GetWikipediaTools() {
+		if tool.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+var wikipediaLanguage string
+
+// SetWikipediaConfig enables the Wikipedia tools with the language used when
+// a call gives none. An empty language disables them.
+func SetWikipediaConfig(language string) error {
+	if language != "" && !wikipediaLanguagePattern.MatchString(language) {
+		return fmt.Errorf("invalid Wikipedia language %q", language)
+	}
+	wikipediaLanguage = language
+	return nil
+}
+
+// WikipediaEnabled reports whether the Wikipedia tools are enabled
+func WikipediaEnabled() bool {
+	return wikipediaLanguage != ""
+}
+
+// CallWikipediaTool executes the named Wikipedia tool
+func CallWikipediaTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResult, error) {
+	if wikipediaLanguage == "" {
+		return nil, fmt.Errorf("Wikipedia tools not configured. Set wikipedia.enabled in the config file")
+	}
+
+	var args struct {
+		Language string `arg:"language"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	language := wikipediaLanguage
+	if args.Language != "" {
+		language = strings.ToLower(args.Language)
+		if !wikipediaLanguagePattern.MatchString(language) {
+			return nil, fmt.Errorf("invalid language code %q", args.Language)
+		}
+	}
+
+	switch name {
+	case "wikipedia_search":
+		return textResult(wikipediaSearch(ctx, language, arguments))
+	case "wikipedia_summary":
+		return textResult(wikipediaSummary(ctx, language, arguments))
+	default:
+		return nil, fmt.Errorf("unknown Wikipedia tool: %s", name)
+	}
+}
+
+// wikipediaSearch runs a full-text search of the articles
+func wikipediaSearch(ctx context.Context, language string, arguments map[string]interface{}) (string, error) {
+	args := struct {
+		Query      string `arg:"query,required"`
+		MaxResults int    `arg:"max_results"`
+	}{MaxResults: 10}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return "", err
+	}
+	if args.MaxResults < 1 || args.MaxResults > 50 {
+		args.MaxResults = 10
+	}
+
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("list", "search")
+	params.Set("srsearch", args.Query)
+	params.Set("srlimit", strconv.Itoa(args.MaxResults))
+
+	var apiResp struct {
+		Query struct {
+			SearchInfo struct {
+				TotalHits int `json:"totalhits"`
+			} `json:"searchinfo"`
+			Search []struct {
+				Title   string `json:"title"`
+				Snippet string `json:"snippet"`
+			} `json:"search"`
+		} `json:"query"`
+	}
+	if err := wikipediaRequest(ctx, language, params, &apiResp); err != nil {
+		return "", err
+	}
+
+	// Snippets mark the matches with <span class="searchmatch">
+	results := make([]SearchResult, len(apiResp.Query.Search))
+	for i, item := range apiResp.Query.Search {
+		results[i] = SearchResult{
+			Title:   item.Title,
+			Link:    wikipediaArticleURL(language, item.Title),
+			Snippet: stripHTMLTags(item.Snippet),
+		}
+	}
+	total := ""
+	if apiResp.Query.SearchInfo.TotalHits > 0 {
+		total = strconv.Itoa(apiResp.Query.SearchInfo.TotalHits)
+	}
+	return formatSearchResults(total, results), nil
+}
+
+// wikipediaSummary returns the plain text introduction of an article
+func wikipediaSummary(ctx context.Context, language string, arguments map[string]interface{}) (string, error) {
+	var args struct {
+		Title     string `arg:"title,required"`
+		Sentences int    `arg:"sentences"`
+	}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return "", err
+	}
+	title, sentences := args.Title, args.Sentences
+
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("prop", "extracts|info|pageprops")
+	params.Set("titles", title)
+	params.Set("redirects", "1")
+	params.Set("exintro", "1")
+	params.Set("explaintext", "1")
+	params.Set("inprop", "url")
+	params.Set("ppprop", "disambiguation")
+	if sentences >= 1 && sentences <= 10 {
+		params.Set("exsentences", strconv.Itoa(sentences))
+	}
+
+	var apiResp struct {
+		Query struct {
+			Pages []struct {
+				Title     string `json:"title"`
+				Missing   bool   `json:"missing"`
+				Invalid   bool   `json:"invalid"`
+				Extract   string `json:"extract"`
+				FullURL   string `json:"fullurl"`
+				PageProps struct {
+					Disambiguation *string `json:"disambiguation"`
+				} `json:"pageprops"`
+			} `json:"pages"`
+		} `json:"query"`
+	}
+	if err := wikipediaRequest(ctx, language, params, &apiResp); err != nil {
+		return "", err
+	}
+	if len(apiResp.Query.Pages) == 0 {
+		return "", fmt.Errorf("no Wikipedia article titled %q", title)
+	}
+	page := apiResp.Query.Pages[0]
+	if page.Missing || page.Invalid {
+		return "", fmt.Errorf("no Wikipedia article titled %q; use wikipedia_search to find the exact title", title)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", page.Title)
+	if page.PageProps.Disambiguation != nil {
+		b.WriteString("This is a disambiguation page listing articles with similar titles; use wikipedia_search to pick one.\n\n")
+	}
+	if extract := strings.TrimSpace(page.Extract); extract != "" {
+		b.WriteString(extract + "\n\n")
+	} else {
+		b.WriteString("The article has no introduction.\n\n")
+	}
+	if page.FullURL == "" {
+		page.FullURL = wikipediaArticleURL(language, page.Title)
+	}
+	fmt.Fprintf(&b, "Source: %s", page.FullURL)
+	return b.String(), nil
+}
+
+// wikipediaRequest calls the action API of the Wikipedia in language and
+// decodes its JSON response into v
+func wikipediaRequest(ctx context.Context, language string, params url.Values, v interface{}) error {
+	params.Set("format", "json")
+	params.Set("formatversion", "2")
+	params.Set("utf8", "1")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf(wikipediaAPIURL, language)+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	// Wikimedia asks API clients to identify themselves
+	req.Header.Set("User-Agent", "mcp-go (https://github.com/GhiaC/mcp-server)")
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query Wikipedia: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("Wikipedia returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	// Errors such as an unknown language variant come back with status 200
+	var apiError struct {
+		Error *struct {
+			Code string `json:"code"`
+			Info string `json:"info"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &apiError); err == nil && apiError.Error != nil {
+		return fmt.Errorf("Wikipedia API error %s: %s", apiError.Error.Code, apiError.Error.Info)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// wikipediaArticleURL returns the address of an article
+func wikipediaArticleURL(language, title string) string {
+	path := url.PathEscape(strings.ReplaceAll(title, " ", "_"))
+	return fmt.Sprintf("https://%s.wikipedia.org/wiki/%s", language, path)
+}
@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCallWikipediaTool(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.URL.Path != "/de/w/api.php" || query.Get("format") != "json":
+			http.Error(w, "bad request", http.StatusBadRequest)
+		case query.Get("list") == "search":
+			w.Write([]byte(`{"query":{"searchinfo":{"totalhits":42},"search":[
+				{"title":"Go (Programmiersprache)","snippet":"<span class=\"searchmatch\">Go</span> ist eine Programmiersprache"}]}}`))
+		case query.Get("titles") == "Golang":
+			w.Write([]byte(`{"query":{"pages":[{"title":"Go (Programmiersprache)",
+				"extract":"Go ist eine kompilierbare Programmiersprache.","fullurl":"https://de.wikipedia.org/wiki/Go_(Programmiersprache)"}]}}`))
+		default:
+			w.Write([]byte(`{"query":{"pages":[{"title":"` + query.Get("titles") + `","missing":true}]}}`))
+		}
+	}))
+	defer server.Close()
+
+	oldURL := wikipediaAPIURL
+	wikipediaAPIURL = server.URL + "/%s/w/api.php"
+	defer func() { wikipediaAPIURL = oldURL }()
+	if err := SetWikipediaConfig("de"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetWikipediaConfig("")
+
+	result, err := resultText(CallWikipediaTool(context.Background(), "wikipedia_search", map[string]interface{}{"query": "go"}))
+	if err != nil {
+		t.Fatalf("wikipedia_search failed: %v", err)
+	}
+	want := "Found 42 results:\n\n1. Go (Programmiersprache)\n   URL: https://de.wikipedia.org/wiki/Go_%28Programmiersprache%29\n   Go ist eine Programmiersprache\n\n"
+	if result != want {
+		t.Errorf("Unexpected search result:\n%q\nwant:\n%q", result, want)
+	}
+
+	result, err = resultText(CallWikipediaTool(context.Background(), "wikipedia_summary", map[string]interface{}{"title": "Golang"}))
+	if err != nil {
+		t.Fatalf("wikipedia_summary failed: %v", err)
+	}
+	if !strings.HasPrefix(result, "# Go (Programmiersprache)\n\nGo ist eine kompilierbare Programmiersprache.") {
+		t.Errorf("Unexpected summary:\n%s", result)
+	}
+
+	if _, err := CallWikipediaTool(context.Background(), "wikipedia_summary", map[string]interface{}{"title": "Nothing"}); err == nil {
+		t.Error("Expected an error for a missing article")
+	}
+	if _, err := CallWikipediaTool(context.Background(), "wikipedia_search", map[string]interface{}{"query": "go", "language": "evil.example.com/"}); err == nil {
+		t.Error("Expected an error for an invalid language")
+	}
+}
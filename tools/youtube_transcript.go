@@ -0,0 +1,452 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Defaults for the youtube_transcript tool when the configuration leaves
+// them unset
+const (
+	DefaultYouTubeTranscriptTimeout   = 20 * time.Second
+	DefaultYouTubeTranscriptMaxLength = 20000
+)
+
+// youtubeBaseURL is the YouTube site. A variable so tests can replace it.
+var youtubeBaseURL = "https://www.youtube.com"
+
+// youtubeInnertubeClient is the client the player API is asked as; the
+// caption URLs it returns need no proof-of-origin token, unlike the web
+// client's
+var youtubeInnertubeClient = map[string]interface{}{
+	"clientName":    "ANDROID",
+	"clientVersion": "20.10.38",
+}
+
+var (
+	youtubeVideoIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	youtubeAPIKeyPattern  = regexp.MustCompile(`"INNERTUBE_API_KEY":\s*"([^"]+)"`)
+)
+
+// YouTubeTranscriptTool represents the youtube_transcript tool definition
+type YouTubeTranscriptTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+}
+
+// GetYouTubeTranscriptTool returns the youtube_transcript tool definition
+func GetYouTubeTranscriptTool() YouTubeTranscriptTool {
+	return YouTubeTranscriptTool{
+		Name:        "youtube_transcript",
+		Description: "Fetch the transcript of a YouTube video from its captions, manual or auto-generated, in a chosen language",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"url": map[string]interface{}{
+					"type":        "string",
+					"description": "The video URL, such as https://www.youtube.com/watch?v=dQw4w9WgXcQ or https://youtu.be/dQw4w9WgXcQ, or the video ID",
+				},
+				"languages": map[string]interface{}{
+					"type":        "array",
+					"items":       map[string]interface{}{"type": "string"},
+					"description": "Language codes in order of preference, such as [\"de\", \"en\"] (default: the configured languages). Manual captions are preferred over auto-generated ones of the same language.",
+				},
+				"timestamps": map[string]interface{}{
+					"type":        "boolean",
+					"description": "Prefix each caption with its start time (default: false)",
+				},
+				"max_length": map[string]interface{}{
+					"type":        "integer",
+					"description": "Maximum number of characters to return, at most the configured limit",
+					"minimum":     1,
+				},
+				"start_index": map[string]interface{}{
+					"type":        "integer",
+					"description": "Character offset to start from, to continue a truncated transcript (default: 0)",
+					"minimum":     0,
+				},
+			},
+			"required": []string{"url"},
+		},
+	}
+}
+
+// YouTubeTranscriptConfig holds the configuration for the youtube_transcript
+// tool
+type YouTubeTranscriptConfig struct {
+	Timeout   time.Duration
+	MaxLength int
+	// Languages are the preferred caption languages when a call names none;
+	// empty takes the video's first track
+	Languages []string
+}
+
+var youtubeTranscriptConfig *YouTubeTranscriptConfig
+
+// SetYouTubeTranscriptConfig enables the youtube_transcript tool. Zero
+// values select the defaults.
+func SetYouTubeTranscriptConfig(config YouTubeTranscriptConfig) {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultYouTubeTranscriptTimeout
+	}
+	if config.MaxLength <= 0 {
+		config.MaxLength = DefaultYouTubeTranscriptMaxLength
+	}
+	youtubeTranscriptConfig = &config
+}
+
+// GetYouTubeTranscriptConfig returns the current configuration, nil when the
+// tool is disabled
+func GetYouTubeTranscriptConfig() *YouTubeTranscriptConfig {
+	return youtubeTranscriptConfig
+}
+
+// youtubeCaptionTrack is a caption track listed by the player response
+type youtubeCaptionTrack struct {
+	BaseURL      string `json:"baseUrl"`
+	LanguageCode string `json:"languageCode"`
+	Kind         string `json:"kind"` // "asr" for auto-generated captions
+	Name         struct {
+		SimpleText string `json:"simpleText"`
+		Runs       []struct {
+			Text string `json:"text"`
+		} `json:"runs"`
+	} `json:"name"`
+}
+
+// label returns the display name of the track
+func (t youtubeCaptionTrack) label() string {
+	name := t.Name.SimpleText
+	for _, run := range t.Name.Runs {
+		name += run.Text
+	}
+	if name == "" {
+		name = t.LanguageCode
+	}
+	if t.Kind == "asr" && !strings.Contains(name, "auto-generated") {
+		name += " (auto-generated)"
+	}
+	return name
+}
+
+// youtubePlayerResponse is the part of a player response the tool reads
+type youtubePlayerResponse struct {
+	PlayabilityStatus struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	} `json:"playabilityStatus"`
+	VideoDetails struct {
+		Title  string `json:"title"`
+		Author string `json:"author"`
+	} `json:"videoDetails"`
+	Captions struct {
+		Renderer struct {
+			Tracks []youtubeCaptionTrack `json:"captionTracks"`
+		} `json:"playerCaptionsTracklistRenderer"`
+	} `json:"captions"`
+}
+
+// youtubeCaption is a line of a transcript
+type youtubeCaption struct {
+	start time.Duration
+	text  string
+}
+
+// CallYouTubeTranscript fetches the transcript of a video
+func CallYouTubeTranscript(ctx context.Context, arguments map[string]interface{}) (*ToolResult, error) {
+	config := youtubeTranscriptConfig
+	if config == nil {
+		return nil, fmt.Errorf("youtube_transcript not configured. Set youtube_transcript.enabled in the config file")
+	}
+	args := struct {
+		URL        string   `arg:"url"`
+		Languages  []string `arg:"languages"`
+		Timestamps bool     `arg:"timestamps"`
+		MaxLength  int      `arg:"max_length"`
+		StartIndex int      `arg:"start_index"`
+	}{MaxLength: config.MaxLength}
+	if err := DecodeArgs(arguments, &args); err != nil {
+		return nil, err
+	}
+	videoID, err := youtubeVideoID(strings.TrimSpace(args.URL))
+	if err != nil {
+		return nil, err
+	}
+	if len(args.Languages) == 0 {
+		args.Languages = config.Languages
+	}
+	if args.MaxLength < 1 || args.StartIndex < 0 {
+		return nil, fmt.Errorf("max_length must be positive and start_index not negative")
+	}
+	args.MaxLength = min(args.MaxLength, config.MaxLength)
+
+	client := &http.Client{Timeout: config.Timeout}
+	player, err := youtubePlayer(ctx, client, videoID)
+	if err != nil {
+		return nil, err
+	}
+	if status := player.PlayabilityStatus.Status; status != "" && status != "OK" {
+		reason := player.PlayabilityStatus.Reason
+		if reason == "" {
+			reason = status
+		}
+		return nil, fmt.Errorf("video %s is not playable: %s", videoID, reason)
+	}
+	tracks := player.Captions.Renderer.Tracks
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("video %s has no captions", videoID)
+	}
+	track, ok := selectCaptionTrack(tracks, args.Languages)
+	if !ok {
+		var available []string
+		for _, t := range tracks {
+			available = append(available, fmt.Sprintf("%s (%s)", t.LanguageCode, t.label()))
+		}
+		return nil, fmt.Errorf("no captions in %s; available: %s", strings.Join(args.Languages, ", "), strings.Join(available, ", "))
+	}
+	captions, err := youtubeCaptions(ctx, client, track.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var transcript strings.Builder
+	for _, caption := range captions {
+		if args.Timestamps {
+			fmt.Fprintf(&transcript, "[%s] %s\n", formatCaptionTime(caption.start), caption.text)
+		} else {
+			transcript.WriteString(caption.text)
+			transcript.WriteString(" ")
+		}
+	}
+
+	var result strings.Builder
+	if title := player.VideoDetails.Title; title != "" {
+		fmt.Fprintf(&result, "Title: %s\n", title)
+	}
+	if author := player.VideoDetails.Author; author != "" {
+		fmt.Fprintf(&result, "Channel: %s\n", author)
+	}
+	fmt.Fprintf(&result, "URL: https://www.youtube.com/watch?v=%s\n", videoID)
+	fmt.Fprintf(&result, "Captions: %s [%s]\n\n", track.label(), track.LanguageCode)
+	writeContentWindow(&result, strings.TrimSpace(transcript.String()), args.StartIndex, args.MaxLength)
+	return TextResult(result.String()), nil
+}
+
+// youtubeVideoID extracts the video ID of a watch, short, embed, live or
+// youtu.be URL, or accepts a bare ID
+func youtubeVideoID(rawURL string) (string, error) {
+	if rawURL == "" {
+		return "", fmt.Errorf("url argument is required and must be a non-empty string")
+	}
+	if youtubeVideoIDPattern.MatchString(rawURL) {
+		return rawURL, nil
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %v", err)
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	var id string
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	switch {
+	case host == "youtu.be":
+		id = segments[0]
+	case host == "youtube.com" || host == "music.youtube.com" || host == "youtube-nocookie.com":
+		if u.Path == "/watch" {
+			id = u.Query().Get("v")
+		} else if len(segments) == 2 && (segments[0] == "shorts" || segments[0] == "embed" || segments[0] == "live" || segments[0] == "v") {
+			id = segments[1]
+		}
+	default:
+		return "", fmt.Errorf("%s is not a YouTube URL", rawURL)
+	}
+	if !youtubeVideoIDPattern.MatchString(id) {
+		return "", fmt.Errorf("no video ID found in %s", rawURL)
+	}
+	return id, nil
+}
+
+// youtubePlayer returns the player response of a video. The watch page
+// holds the API key of the player API, asked as the Android client; when
+// the key is missing the response embedded in the page is used instead.
+func youtubePlayer(ctx context.Context, client *http.Client, videoID string) (*youtubePlayerResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", youtubeBaseURL+"/watch?v="+videoID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept-Language", "en-US,en;q=0.8")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the video page: %w", err)
+	}
+	page, err := io.ReadAll(io.LimitReader(resp.Body, fetchPageMaxDownload))
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the video page: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("YouTube returned status %d for video %s", resp.StatusCode, videoID)
+	}
+
+	match := youtubeAPIKeyPattern.FindSubmatch(page)
+	if match == nil {
+		return embeddedPlayerResponse(page, videoID)
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"context": map[string]interface{}{"client": youtubeInnertubeClient},
+		"videoId": videoID,
+	})
+	req, err = http.NewRequestWithContext(ctx, "POST", youtubeBaseURL+"/youtubei/v1/player?key="+url.QueryEscape(string(match[1])), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the player API: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the player API returned status %d", resp.StatusCode)
+	}
+	var player youtubePlayerResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, fetchPageMaxDownload)).Decode(&player); err != nil {
+		return nil, fmt.Errorf("invalid player API response: %v", err)
+	}
+	return &player, nil
+}
+
+// embeddedPlayerResponse decodes the ytInitialPlayerResponse object of a
+// watch page
+func embeddedPlayerResponse(page []byte, videoID string) (*youtubePlayerResponse, error) {
+	marker := []byte("ytInitialPlayerResponse")
+	i := bytes.Index(page, marker)
+	if i < 0 {
+		return nil, fmt.Errorf("no player data found on the page of video %s", videoID)
+	}
+	start := bytes.IndexByte(page[i:], '{')
+	if start < 0 {
+		return nil, fmt.Errorf("no player data found on the page of video %s", videoID)
+	}
+	var player youtubePlayerResponse
+	// The decoder stops at the end of the object, before the rest of the script
+	if err := json.NewDecoder(bytes.NewReader(page[i+start:])).Decode(&player); err != nil {
+		return nil, fmt.Errorf("invalid player data on the page of video %s: %v", videoID, err)
+	}
+	return &player, nil
+}
+
+// selectCaptionTrack picks the first language of the preference list the
+// video has captions in, manual captions before auto-generated ones. With no
+// preference the first track is taken.
+func selectCaptionTrack(tracks []youtubeCaptionTrack, languages []string) (youtubeCaptionTrack, bool) {
+	if len(languages) == 0 {
+		return tracks[0], true
+	}
+	for _, language := range languages {
+		var generated *youtubeCaptionTrack
+		for i, track := range tracks {
+			code := strings.ToLower(track.LanguageCode)
+			want := strings.ToLower(language)
+			// "en" matches "en-US" and "en-GB"
+			if code != want && !strings.HasPrefix(code, want+"-") {
+				continue
+			}
+			if track.Kind != "asr" {
+				return track, true
+			}
+			if generated == nil {
+				generated = &tracks[i]
+			}
+		}
+		if generated != nil {
+			return *generated, true
+		}
+	}
+	return youtubeCaptionTrack{}, false
+}
+
+// youtubeCaptions downloads a caption track in either timed text format:
+// <text start="1.5" dur="2"> elements in seconds, or format 3 <p t="1500">
+// elements in milliseconds whose words may be split into <s> elements
+func youtubeCaptions(ctx context.Context, client *http.Client, baseURL string) ([]youtubeCaption, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid caption URL: %v", err)
+	}
+	query := u.Query()
+	query.Del("fmt") // The default XML format, rather than JSON or WebVTT
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch captions: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching captions returned status %d", resp.StatusCode)
+	}
+	var doc struct {
+		Texts []struct {
+			Start float64 `xml:"start,attr"`
+			Text  string  `xml:",chardata"`
+		} `xml:"text"`
+		Paragraphs []struct {
+			Time  int64    `xml:"t,attr"`
+			Text  string   `xml:",chardata"`
+			Words []string `xml:"s"`
+		} `xml:"body>p"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, fetchPageMaxDownload)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid captions: %v", err)
+	}
+	var captions []youtubeCaption
+	add := func(start time.Duration, text string) {
+		// Captions escape their markup once more inside the XML
+		text = strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+		if text != "" {
+			captions = append(captions, youtubeCaption{start: start, text: text})
+		}
+	}
+	for _, t := range doc.Texts {
+		add(time.Duration(t.Start*float64(time.Second)), t.Text)
+	}
+	for _, p := range doc.Paragraphs {
+		text := p.Text
+		if len(p.Words) > 0 {
+			text = strings.Join(p.Words, "")
+		}
+		add(time.Duration(p.Time)*time.Millisecond, text)
+	}
+	if len(captions) == 0 {
+		return nil, fmt.Errorf("the caption track is empty")
+	}
+	return captions, nil
+}
+
+// formatCaptionTime formats an offset as m:ss, or h:mm:ss past an hour
+func formatCaptionTime(d time.Duration) string {
+	seconds := int(d / time.Second)
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
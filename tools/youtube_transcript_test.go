@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCallYouTubeTranscript(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/watch":
+			w.Write([]byte(`<script>ytcfg.set({"INNERTUBE_API_KEY": "test-key"});</script>`))
+		case "/youtubei/v1/player":
+			var body struct {
+				VideoID string `json:"videoId"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if r.URL.Query().Get("key") != "test-key" || body.VideoID != "dQw4w9WgXcQ" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{
+				"playabilityStatus": {"status": "OK"},
+				"videoDetails": {"title": "Test Video", "author": "Test Channel"},
+				"captions": {"playerCaptionsTracklistRenderer": {"captionTracks": [
+					{"baseUrl": "` + server.URL + `/timedtext?lang=en&kind=asr&fmt=srv3", "languageCode": "en", "kind": "asr", "name": {"runs": [{"text": "English (auto-generated)"}]}},
+					{"baseUrl": "` + server.URL + `/timedtext?lang=en-GB", "languageCode": "en-GB", "name": {"simpleText": "English (UK)"}},
+					{"baseUrl": "` + server.URL + `/timedtext?lang=de&fmt=srv3", "languageCode": "de", "name": {"simpleText": "German"}}
+				]}}
+			}`))
+		case "/timedtext":
+			if r.URL.Query().Get("fmt") != "" {
+				http.Error(w, "unexpected format", http.StatusBadRequest)
+				return
+			}
+			if r.URL.Query().Get("lang") == "de" {
+				w.Write([]byte(`<?xml version="1.0"?><timedtext format="3"><body><p t="0" d="1000"><s>Hallo</s><s> Welt</s></p><p t="3723000" d="1000">Ende</p></body></timedtext>`))
+				return
+			}
+			w.Write([]byte(`<?xml version="1.0"?><transcript><text start="0.5" dur="2">Never gonna</text><text start="65.2" dur="2">give you &amp;#39;up&amp;#39;</text></transcript>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	defer func(base string) { youtubeBaseURL = base }(youtubeBaseURL)
+	youtubeBaseURL = server.URL
+	defer func() { youtubeTranscriptConfig = nil }()
+	SetYouTubeTranscriptConfig(YouTubeTranscriptConfig{Languages: []string{"en"}})
+
+	// Manual captions are preferred over auto-generated ones of the language
+	result, err := resultText(CallYouTubeTranscript(context.Background(), map[string]interface{}{"url": "https://youtu.be/dQw4w9WgXcQ?t=10", "timestamps": true}))
+	if err != nil {
+		t.Fatalf("CallYouTubeTranscript failed: %v", err)
+	}
+	expected := "Title: Test Video\nChannel: Test Channel\nURL: https://www.youtube.com/watch?v=dQw4w9WgXcQ\nCaptions: English (UK) [en-GB]\n\n[0:00] Never gonna\n[1:05] give you 'up'"
+	if result != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, result)
+	}
+
+	result, err = resultText(CallYouTubeTranscript(context.Background(), map[string]interface{}{"url": "https://www.youtube.com/shorts/dQw4w9WgXcQ", "languages": []interface{}{"fr", "de"}, "max_length": float64(11)}))
+	if err != nil || !strings.Contains(result, "Captions: German [de]\n\nHallo Welt \n\n[Content truncated at 11 of 15 characters") {
+		t.Errorf("Expected the German captions, truncated, got %q, %v", result, err)
+	}
+
+	if _, err := CallYouTubeTranscript(context.Background(), map[string]interface{}{"url": "dQw4w9WgXcQ", "languages": []interface{}{"fr"}}); err == nil || !strings.Contains(err.Error(), "available: en (English (auto-generated))") {
+		t.Errorf("Expected the available languages to be listed, got %v", err)
+	}
+}
+
+func TestYouTubeVideoID(t *testing.T) {
+	tests := map[string]string{
+		"dQw4w9WgXcQ": "dQw4w9WgXcQ",
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=x": "dQw4w9WgXcQ",
+		"youtube.com/embed/dQw4w9WgXcQ":                      "dQw4w9WgXcQ",
+		"https://m.youtube.com/live/dQw4w9WgXcQ":             "dQw4w9WgXcQ",
+		"https://youtu.be/dQw4w9WgXcQ":                       "dQw4w9WgXcQ",
+		"https://example.com/watch?v=dQw4w9WgXcQ":            "",
+		"https://www.youtube.com/channel/abc":                "",
+	}
+	for input, expected := range tests {
+		id, err := youtubeVideoID(input)
+		if id != expected || (expected == "") != (err != nil) {
+			t.Errorf("youtubeVideoID(%q) = %q, %v, expected %q", input, id, err, expected)
+		}
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"strings"
 	"time"
@@ -17,9 +19,13 @@ type HTTPTransport struct {
 	baseURL           string
 	httpClient        *http.Client
 	headers           map[string]string
-	sessionID         string // Session ID for streamable-http (Cloudflare)
-	useStreamableHTTP bool   // Whether to use streamable-http protocol
-	requestID         int    // Counter for JSON-RPC request IDs
+	headerFuncs       map[string]HeaderFunc  // Headers computed for each request, such as short-lived tokens
+	sessionID         string                 // Session ID for streamable-http (Cloudflare)
+	useStreamableHTTP bool                   // Whether to use streamable-http protocol
+	requestID         int                    // Counter for JSON-RPC request IDs
+	capabilities      map[string]interface{} // Capabilities advertised by the server
+	options           Options
+	slots             chan struct{} // Limits concurrent requests when options.MaxConcurrent is set
 }
 
 // NewHTTPTransport creates a new HTTP transport
@@ -43,11 +49,118 @@ func NewHTTPTransport(baseURL string) *HTTPTransport {
 	}
 }
 
+// SetOptions applies per-server timeout, retry and limit settings
+func (t *HTTPTransport) SetOptions(opts Options) {
+	t.options = opts
+	if opts.Timeout > 0 {
+		t.httpClient.Timeout = opts.Timeout
+	}
+	t.slots = nil
+	if opts.MaxConcurrent > 0 {
+		t.slots = make(chan struct{}, opts.MaxConcurrent)
+	}
+	if opts.TLS != nil {
+		httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+		httpTransport.TLSClientConfig = opts.TLS
+		t.httpClient.Transport = httpTransport
+	}
+}
+
+// do sends a request within the concurrency limit, retrying failures that
+// are safe to repeat, and limits the size of the response body. The
+// concurrency slot is held until the body is closed.
+func (t *HTTPTransport) do(req *http.Request) (*http.Response, error) {
+	if t.slots != nil {
+		select {
+		case t.slots <- struct{}{}:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	release := func() {
+		if t.slots != nil {
+			<-t.slots
+		}
+	}
+
+	for key, fn := range t.headerFuncs {
+		value, err := fn(req.Context())
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("failed to get %s header: %w", key, err)
+		}
+		req.Header.Set(key, value)
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.httpClient.Do(req)
+		if attempt < t.options.Retries && req.Context().Err() == nil && isRetryableHTTPFailure(resp, err) {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if req.GetBody != nil {
+				body, bodyErr := req.GetBody()
+				if bodyErr != nil {
+					release()
+					return nil, bodyErr
+				}
+				req.Body = body
+			}
+			select {
+			case <-req.Context().Done():
+				release()
+				return nil, req.Context().Err()
+			case <-time.After(time.Duration(attempt+1) * 100 * time.Millisecond):
+			}
+			continue
+		}
+		if err != nil {
+			release()
+			return nil, err
+		}
+
+		resp.Body = &limitedBody{
+			body:      resp.Body,
+			remaining: t.options.MaxResponseBytes,
+			limit:     t.options.MaxResponseBytes,
+			onClose:   release,
+		}
+		return resp, nil
+	}
+}
+
+// isRetryableHTTPFailure reports whether a request failed in a way that
+// means the server did not process it: the connection could not be
+// established, or a proxy reported the server unavailable
+func isRetryableHTTPFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		var opErr *net.OpError
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
 // SetHeader sets a custom header for all requests
 func (t *HTTPTransport) SetHeader(key, value string) {
 	t.headers[key] = value
 }
 
+// HeaderFunc returns the current value of a header
+type HeaderFunc func(ctx context.Context) (string, error)
+
+// SetHeaderFunc sets a header whose value is obtained from fn for every
+// request, overriding a static value set with SetHeader
+func (t *HTTPTransport) SetHeaderFunc(key string, fn HeaderFunc) {
+	if t.headerFuncs == nil {
+		t.headerFuncs = make(map[string]HeaderFunc)
+	}
+	t.headerFuncs[key] = fn
+}
+
 // parseSSEResponse parses a Server-Sent Events (SSE) stream and extracts JSON-RPC messages
 // SSE format: "data: {json}\n\n" or "event: message\ndata: {json}\n\n"
 func parseSSEResponse(body io.Reader) ([]byte, error) {
@@ -159,7 +272,7 @@ func (t *HTTPTransport) initializeREST(ctx context.Context) error {
 		req.Header.Set(k, v)
 	}
 
-	resp, err := t.httpClient.Do(req)
+	resp, err := t.do(req)
 	if err != nil {
 		return fmt.Errorf("failed to initialize: %w", err)
 	}
@@ -180,6 +293,7 @@ func (t *HTTPTransport) initializeREST(ctx context.Context) error {
 		return fmt.Errorf("unsupported protocol version: %s", initResp.ProtocolVersion)
 	}
 
+	t.capabilities = advertisedCapabilities(initResp.Capabilities)
 	return nil
 }
 
@@ -220,7 +334,7 @@ func (t *HTTPTransport) initializeStreamableHTTP(ctx context.Context) error {
 		req.Header.Set(k, v)
 	}
 
-	resp, err := t.httpClient.Do(req)
+	resp, err := t.do(req)
 	if err != nil {
 		return fmt.Errorf("failed to initialize: %w", err)
 	}
@@ -264,9 +378,26 @@ func (t *HTTPTransport) initializeStreamableHTTP(ctx context.Context) error {
 		return fmt.Errorf("unsupported protocol version: %s", jsonRPCResp.Result.ProtocolVersion)
 	}
 
+	t.capabilities = advertisedCapabilities(jsonRPCResp.Result.Capabilities)
 	return nil
 }
 
+// Capabilities returns the capabilities the server advertised when it was
+// initialized, or nil before initialization
+func (t *HTTPTransport) Capabilities() map[string]interface{} {
+	return t.capabilities
+}
+
+// advertisedCapabilities returns the capabilities of an initialize response,
+// never nil so an initialized server that advertises nothing is told apart
+// from one that has not been initialized
+func advertisedCapabilities(capabilities map[string]interface{}) map[string]interface{} {
+	if capabilities == nil {
+		return map[string]interface{}{}
+	}
+	return capabilities
+}
+
 // ListTools returns all available tools from the remote MCP server
 func (t *HTTPTransport) ListTools(ctx context.Context) ([]Tool, error) {
 	if t.useStreamableHTTP {
@@ -287,7 +418,7 @@ func (t *HTTPTransport) listToolsREST(ctx context.Context) ([]Tool, error) {
 		req.Header.Set(k, v)
 	}
 
-	resp, err := t.httpClient.Do(req)
+	resp, err := t.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list tools: %w", err)
 	}
@@ -338,7 +469,7 @@ func (t *HTTPTransport) listToolsStreamableHTTP(ctx context.Context) ([]Tool, er
 		req.Header.Set(k, v)
 	}
 
-	resp, err := t.httpClient.Do(req)
+	resp, err := t.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list tools: %w", err)
 	}
@@ -405,7 +536,7 @@ func (t *HTTPTransport) callToolREST(ctx context.Context, name string, arguments
 		req.Header.Set(k, v)
 	}
 
-	resp, err := t.httpClient.Do(req)
+	resp, err := t.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call tool: %w", err)
 	}
@@ -463,7 +594,7 @@ func (t *HTTPTransport) callToolStreamableHTTP(ctx context.Context, name string,
 		req.Header.Set(k, v)
 	}
 
-	resp, err := t.httpClient.Do(req)
+	resp, err := t.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to call tool: %w", err)
 	}
@@ -476,11 +607,9 @@ func (t *HTTPTransport) callToolStreamableHTTP(ctx context.Context, name string,
 
 	// Parse JSON-RPC response (handles both JSON and SSE formats)
 	var jsonRPCResp struct {
-		JSONRPC string `json:"jsonrpc"`
-		Result  struct {
-			Content []ContentItem `json:"content"`
-		} `json:"result"`
-		Error *struct {
+		JSONRPC string       `json:"jsonrpc"`
+		Result  ToolResponse `json:"result"`
+		Error   *struct {
 			Code    int    `json:"code"`
 			Message string `json:"message"`
 		} `json:"error"`
@@ -498,9 +627,196 @@ func (t *HTTPTransport) callToolStreamableHTTP(ctx context.Context, name string,
 		return nil, fmt.Errorf("JSON-RPC error: %d - %s", jsonRPCResp.Error.Code, jsonRPCResp.Error.Message)
 	}
 
-	return &ToolResponse{
-		Content: jsonRPCResp.Result.Content,
-	}, nil
+	return &jsonRPCResp.Result, nil
+}
+
+// ListResources returns all resources exposed by the remote MCP server
+func (t *HTTPTransport) ListResources(ctx context.Context) ([]Resource, error) {
+	var result ResourcesListResponse
+	var err error
+	if t.useStreamableHTTP {
+		err = t.callJSONRPC(ctx, "resources/list", map[string]interface{}{}, &result)
+	} else {
+		err = t.callREST(ctx, "GET", "/resources/list", nil, &result)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resources: %w", err)
+	}
+	return result.Resources, nil
+}
+
+// ReadResource reads the contents of a resource on the remote MCP server
+func (t *HTTPTransport) ReadResource(ctx context.Context, uri string) ([]ResourceContent, error) {
+	params := map[string]interface{}{
+		"uri": uri,
+	}
+
+	var result ResourceReadResponse
+	var err error
+	if t.useStreamableHTTP {
+		err = t.callJSONRPC(ctx, "resources/read", params, &result)
+	} else {
+		err = t.callREST(ctx, "POST", "/resources/read", params, &result)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource %s: %w", uri, err)
+	}
+	return result.Contents, nil
+}
+
+// ListPrompts returns all prompts exposed by the remote MCP server
+func (t *HTTPTransport) ListPrompts(ctx context.Context) ([]Prompt, error) {
+	var result PromptsListResponse
+	var err error
+	if t.useStreamableHTTP {
+		err = t.callJSONRPC(ctx, "prompts/list", map[string]interface{}{}, &result)
+	} else {
+		err = t.callREST(ctx, "GET", "/prompts/list", nil, &result)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompts: %w", err)
+	}
+	return result.Prompts, nil
+}
+
+// GetPrompt renders a prompt on the remote MCP server
+func (t *HTTPTransport) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*PromptResult, error) {
+	params := map[string]interface{}{
+		"name":      name,
+		"arguments": arguments,
+	}
+
+	var result PromptResult
+	var err error
+	if t.useStreamableHTTP {
+		err = t.callJSONRPC(ctx, "prompts/get", params, &result)
+	} else {
+		err = t.callREST(ctx, "POST", "/prompts/get", params, &result)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt %s: %w", name, err)
+	}
+	return &result, nil
+}
+
+// callREST sends a request to a REST-style endpoint and decodes the JSON
+// response into result. A nil body sends no request body.
+func (t *HTTPTransport) callREST(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewBuffer(bodyBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, t.baseURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set headers
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := t.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s not found", path)
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// callJSONRPC sends a JSON-RPC 2.0 request over streamable-http and decodes
+// the result into result
+func (t *HTTPTransport) callJSONRPC(ctx context.Context, method string, params map[string]interface{}, result interface{}) error {
+	requestID := t.requestID
+	t.requestID++
+
+	jsonRPCRequest := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+		"id":      requestID,
+	}
+
+	bodyBytes, err := json.Marshal(jsonRPCRequest)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON-RPC request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", t.baseURL, bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set headers
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := t.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s failed with status %d: %s", method, resp.StatusCode, string(body))
+	}
+
+	// Parse JSON-RPC response (handles both JSON and SSE formats)
+	var jsonRPCResp struct {
+		JSONRPC string          `json:"jsonrpc"`
+		Result  json.RawMessage `json:"result"`
+		Error   *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+		ID interface{} `json:"id"`
+	}
+
+	if err := parseStreamableHTTPResponse(resp, &jsonRPCResp); err != nil {
+		return fmt.Errorf("failed to decode JSON-RPC response: %w", err)
+	}
+
+	if jsonRPCResp.Error != nil {
+		if jsonRPCResp.Error.Code == -32601 {
+			return fmt.Errorf("method %s not found", method)
+		}
+		return fmt.Errorf("JSON-RPC error: %d - %s", jsonRPCResp.Error.Code, jsonRPCResp.Error.Message)
+	}
+
+	if len(jsonRPCResp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(jsonRPCResp.Result, result); err != nil {
+		return fmt.Errorf("failed to decode JSON-RPC result: %w", err)
+	}
+	return nil
 }
 
 // Close closes the transport connection (no-op for HTTP)
@@ -15,6 +15,22 @@ type Transport interface {
 	// CallTool executes a tool on the remote MCP server
 	CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResponse, error)
 
+	// ListResources returns all resources exposed by the remote MCP server
+	ListResources(ctx context.Context) ([]Resource, error)
+
+	// ReadResource reads the contents of a resource on the remote MCP server
+	ReadResource(ctx context.Context, uri string) ([]ResourceContent, error)
+
+	// ListPrompts returns all prompts exposed by the remote MCP server
+	ListPrompts(ctx context.Context) ([]Prompt, error)
+
+	// GetPrompt renders a prompt on the remote MCP server
+	GetPrompt(ctx context.Context, name string, arguments map[string]string) (*PromptResult, error)
+
+	// Capabilities returns the capabilities the server advertised when it
+	// was initialized, or nil before initialization
+	Capabilities() map[string]interface{}
+
 	// Close closes the transport connection
 	Close() error
 }
@@ -24,17 +40,61 @@ type Tool struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
 	InputSchema map[string]interface{} `json:"inputSchema"`
+	// OutputSchema describes the structuredContent of the tool's results
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
+	Annotations  *ToolAnnotations       `json:"annotations,omitempty"`
+}
+
+// ToolAnnotations describe how a tool behaves. They are hints declared by
+// the server; unset hints take the MCP defaults (not read-only, destructive,
+// not idempotent, open world).
+type ToolAnnotations struct {
+	Title           string `json:"title,omitempty"`           // Human-readable name of the tool
+	ReadOnlyHint    *bool  `json:"readOnlyHint,omitempty"`    // The tool does not change its environment
+	DestructiveHint *bool  `json:"destructiveHint,omitempty"` // Changes may delete or overwrite, not only add
+	IdempotentHint  *bool  `json:"idempotentHint,omitempty"`  // Repeating a call with the same arguments has no further effect
+	OpenWorldHint   *bool  `json:"openWorldHint,omitempty"`   // The tool reaches entities outside a closed domain, such as the web
+}
+
+// ReadOnly reports whether the tool is hinted read-only
+func (a *ToolAnnotations) ReadOnly() bool {
+	return a != nil && a.ReadOnlyHint != nil && *a.ReadOnlyHint
+}
+
+// Idempotent reports whether calls to the tool are hinted safe to repeat,
+// which read-only tools always are
+func (a *ToolAnnotations) Idempotent() bool {
+	return a.ReadOnly() || (a != nil && a.IdempotentHint != nil && *a.IdempotentHint)
 }
 
 // ToolResponse represents the response from a tool call
 type ToolResponse struct {
 	Content []ContentItem `json:"content"`
+	// StructuredContent is the machine-readable form of the result, when the
+	// tool provides one
+	StructuredContent interface{} `json:"structuredContent,omitempty"`
+	// IsError marks a result reporting that the tool failed
+	IsError bool `json:"isError,omitempty"`
 }
 
-// ContentItem represents a content item in the tool response
+// ContentItem represents a content item in the tool response: text, an
+// image or audio clip with its base64 data and MIME type, or an embedded
+// resource
 type ContentItem struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type     string            `json:"type"`
+	Text     string            `json:"text"`
+	Data     string            `json:"data,omitempty"`
+	MimeType string            `json:"mimeType,omitempty"`
+	Resource *EmbeddedResource `json:"resource,omitempty"`
+}
+
+// EmbeddedResource is the content of a resource returned by a tool, as text
+// or as base64 data in Blob
+type EmbeddedResource struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
 }
 
 // InitializeResponse represents the initialize response from MCP server
@@ -54,3 +114,61 @@ type ServerInfo struct {
 type ToolsListResponse struct {
 	Tools []Tool `json:"tools"`
 }
+
+// Resource represents a resource definition from an MCP server
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContent represents the contents of a resource. Exactly one of Text
+// or Blob (base64-encoded) is set.
+type ResourceContent struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// ResourcesListResponse represents the resources list response
+type ResourcesListResponse struct {
+	Resources []Resource `json:"resources"`
+}
+
+// ResourceReadResponse represents the resource read response
+type ResourceReadResponse struct {
+	Contents []ResourceContent `json:"contents"`
+}
+
+// Prompt represents a prompt template from an MCP server
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument describes an argument accepted by a prompt
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptMessage is a single message of a rendered prompt
+type PromptMessage struct {
+	Role    string      `json:"role"`
+	Content ContentItem `json:"content"`
+}
+
+// PromptResult represents a rendered prompt
+type PromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// PromptsListResponse represents the prompts list response
+type PromptsListResponse struct {
+	Prompts []Prompt `json:"prompts"`
+}
@@ -0,0 +1,56 @@
+package transport
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Options tunes a transport for one upstream server. Zero values keep the
+// transport's defaults.
+type Options struct {
+	Timeout          time.Duration // Maximum duration of a single request
+	Retries          int           // Extra attempts for requests that failed before reaching the server or got 502/503/504 (HTTP only)
+	MaxConcurrent    int           // Requests in flight at the same time (0 = unlimited)
+	MaxResponseBytes int64         // Largest response accepted (0 = unlimited)
+	TLS              *tls.Config   // Settings for https connections (nil = system defaults; HTTP only)
+}
+
+// limitedBody fails reads once more than limit bytes have been read, so a
+// misbehaving server cannot make the gateway buffer unbounded responses
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+	limit     int64
+	onClose   func()
+}
+
+// Read implements io.Reader
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.limit > 0 {
+		if b.remaining <= 0 {
+			// Probe for more data: a response of exactly limit bytes is fine
+			var probe [1]byte
+			if n, _ := b.body.Read(probe[:]); n > 0 {
+				return 0, fmt.Errorf("response exceeds the limit of %d bytes", b.limit)
+			}
+			return 0, io.EOF
+		}
+		if int64(len(p)) > b.remaining {
+			p = p[:b.remaining]
+		}
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// Close implements io.Closer
+func (b *limitedBody) Close() error {
+	if b.onClose != nil {
+		b.onClose()
+		b.onClose = nil
+	}
+	return b.body.Close()
+}
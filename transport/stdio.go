@@ -0,0 +1,432 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mcp-go/logging"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// stdioCloseTimeout is how long Close waits for the server process to exit
+// after its stdin is closed before killing it
+const stdioCloseTimeout = 2 * time.Second
+
+// StdioTransport implements Transport by running an MCP server as a child
+// process and exchanging newline-delimited JSON-RPC 2.0 messages over its
+// stdin and stdout, like Claude Desktop does
+type StdioTransport struct {
+	command string
+	args    []string
+	env     map[string]string
+	dir     string
+
+	mu           sync.Mutex
+	cmd          *exec.Cmd
+	stdin        io.WriteCloser
+	pending      map[int64]chan stdioMessage // Requests waiting for a response, by ID
+	nextID       int64
+	exited       chan struct{} // Closed when the process exits
+	exitErr      error
+	capabilities map[string]interface{}
+
+	writeMu sync.Mutex // Serializes messages written to stdin
+
+	options Options
+	slots   chan struct{} // Limits concurrent requests when options.MaxConcurrent is set
+}
+
+// stdioMessage is a JSON-RPC message read from the server
+type stdioMessage struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewStdioTransport creates a transport for the server started by command
+// with args. env is added to the gateway's own environment and dir is the
+// working directory (empty means the gateway's). The process is started by
+// Initialize.
+func NewStdioTransport(command string, args []string, env map[string]string, dir string) *StdioTransport {
+	return &StdioTransport{
+		command: command,
+		args:    args,
+		env:     env,
+		dir:     dir,
+		pending: make(map[int64]chan stdioMessage),
+		nextID:  1,
+	}
+}
+
+// SetOptions applies per-server timeout and limit settings. Retries do not
+// apply to stdio servers.
+func (t *StdioTransport) SetOptions(opts Options) {
+	t.options = opts
+	t.slots = nil
+	if opts.MaxConcurrent > 0 {
+		t.slots = make(chan struct{}, opts.MaxConcurrent)
+	}
+}
+
+// Initialize starts the server process and performs the MCP handshake
+func (t *StdioTransport) Initialize(ctx context.Context, config map[string]interface{}) error {
+	if err := t.start(); err != nil {
+		return err
+	}
+
+	params := map[string]interface{}{
+		"protocolVersion": "2024-11-05",
+		"capabilities":    map[string]interface{}{},
+		"clientInfo": map[string]interface{}{
+			"name":    "mcp-go-client",
+			"version": "1.0.0",
+		},
+	}
+	var result InitializeResponse
+	if err := t.call(ctx, "initialize", params, &result); err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	if result.ProtocolVersion == "" {
+		return fmt.Errorf("initialize response has no protocol version")
+	}
+
+	t.mu.Lock()
+	t.capabilities = advertisedCapabilities(result.Capabilities)
+	t.mu.Unlock()
+
+	return t.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "notifications/initialized",
+	})
+}
+
+// start launches the server process unless it is already running
+func (t *StdioTransport) start() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cmd != nil {
+		select {
+		case <-t.exited:
+			// Start a fresh process after the previous one exited
+		default:
+			return nil
+		}
+	}
+
+	cmd := exec.Command(t.command, t.args...)
+	cmd.Dir = t.dir
+	cmd.Env = os.Environ()
+	keys := make([]string, 0, len(t.env))
+	for key := range t.env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		cmd.Env = append(cmd.Env, key+"="+t.env[key])
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open stdin of %s: %w", t.command, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open stdout of %s: %w", t.command, err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open stderr of %s: %w", t.command, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", t.command, err)
+	}
+
+	t.cmd = cmd
+	t.stdin = stdin
+	t.exited = make(chan struct{})
+	t.exitErr = nil
+
+	go t.logStderr(stderr)
+	go t.readLoop(cmd, stdout, t.exited)
+	return nil
+}
+
+// readLoop delivers responses to waiting requests until stdout closes, then
+// reaps the process
+func (t *StdioTransport) readLoop(cmd *exec.Cmd, stdout io.Reader, exited chan struct{}) {
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			t.handleMessage(line)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	waitErr := cmd.Wait()
+
+	t.mu.Lock()
+	t.exitErr = fmt.Errorf("%s exited", t.command)
+	if waitErr != nil {
+		t.exitErr = fmt.Errorf("%s exited: %v", t.command, waitErr)
+	}
+	close(exited)
+	t.mu.Unlock()
+}
+
+// handleMessage routes one message from the server
+func (t *StdioTransport) handleMessage(line []byte) {
+	var msg stdioMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		logging.Warnf("Ignoring malformed message from %s: %v", t.command, err)
+		return
+	}
+
+	// Server-initiated requests: answer pings, ignore notifications
+	if msg.Method != "" {
+		if msg.Method == "ping" && len(msg.ID) > 0 {
+			t.write(map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      msg.ID,
+				"result":  map[string]interface{}{},
+			})
+		}
+		return
+	}
+
+	var id int64
+	if err := json.Unmarshal(msg.ID, &id); err != nil {
+		return
+	}
+	t.mu.Lock()
+	ch, ok := t.pending[id]
+	delete(t.pending, id)
+	t.mu.Unlock()
+	if ok {
+		ch <- msg
+	}
+}
+
+// logStderr forwards the server's stderr to the gateway log
+func (t *StdioTransport) logStderr(stderr io.Reader) {
+	name := filepath.Base(t.command)
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		logging.Infof("[%s] %s", name, scanner.Text())
+	}
+}
+
+// write sends one message to the server
+func (t *StdioTransport) write(message interface{}) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON-RPC message: %w", err)
+	}
+
+	t.mu.Lock()
+	stdin := t.stdin
+	t.mu.Unlock()
+	if stdin == nil {
+		return fmt.Errorf("%s is not running", t.command)
+	}
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write to %s: %w", t.command, err)
+	}
+	return nil
+}
+
+// call sends a request and decodes the result of its response into result
+func (t *StdioTransport) call(ctx context.Context, method string, params map[string]interface{}, result interface{}) error {
+	if t.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.options.Timeout)
+		defer cancel()
+	}
+	if t.slots != nil {
+		select {
+		case t.slots <- struct{}{}:
+			defer func() { <-t.slots }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	ch := make(chan stdioMessage, 1)
+	t.mu.Lock()
+	if t.cmd == nil {
+		t.mu.Unlock()
+		return fmt.Errorf("%s is not running", t.command)
+	}
+	id := t.nextID
+	t.nextID++
+	t.pending[id] = ch
+	exited := t.exited
+	t.mu.Unlock()
+
+	forget := func() {
+		t.mu.Lock()
+		delete(t.pending, id)
+		t.mu.Unlock()
+	}
+
+	err := t.write(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+		"id":      id,
+	})
+	if err != nil {
+		forget()
+		return err
+	}
+
+	var msg stdioMessage
+	select {
+	case msg = <-ch:
+	case <-exited:
+		forget()
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return t.exitErr
+	case <-ctx.Done():
+		forget()
+		return ctx.Err()
+	}
+
+	if msg.Error != nil {
+		if msg.Error.Code == -32601 {
+			return fmt.Errorf("method %s not found", method)
+		}
+		return fmt.Errorf("JSON-RPC error: %d - %s", msg.Error.Code, msg.Error.Message)
+	}
+	if len(msg.Result) == 0 || result == nil {
+		return nil
+	}
+	if limit := t.options.MaxResponseBytes; limit > 0 && int64(len(msg.Result)) > limit {
+		return fmt.Errorf("response exceeds the limit of %d bytes", limit)
+	}
+	if err := json.Unmarshal(msg.Result, result); err != nil {
+		return fmt.Errorf("failed to decode JSON-RPC result: %w", err)
+	}
+	return nil
+}
+
+// ListTools returns all available tools from the server
+func (t *StdioTransport) ListTools(ctx context.Context) ([]Tool, error) {
+	var result ToolsListResponse
+	if err := t.call(ctx, "tools/list", map[string]interface{}{}, &result); err != nil {
+		return nil, fmt.Errorf("failed to list tools: %w", err)
+	}
+	return result.Tools, nil
+}
+
+// CallTool executes a tool on the server
+func (t *StdioTransport) CallTool(ctx context.Context, name string, arguments map[string]interface{}) (*ToolResponse, error) {
+	params := map[string]interface{}{
+		"name":      name,
+		"arguments": arguments,
+	}
+
+	var result ToolResponse
+	if err := t.call(ctx, "tools/call", params, &result); err != nil {
+		// Servers report unknown tools with an invalid params error
+		if msg := strings.ToLower(err.Error()); strings.Contains(msg, "unknown tool") || strings.Contains(msg, "not found") {
+			return nil, fmt.Errorf("tool '%s' not found", name)
+		}
+		return nil, fmt.Errorf("failed to call tool: %w", err)
+	}
+	return &result, nil
+}
+
+// ListResources returns all resources exposed by the server
+func (t *StdioTransport) ListResources(ctx context.Context) ([]Resource, error) {
+	var result ResourcesListResponse
+	if err := t.call(ctx, "resources/list", map[string]interface{}{}, &result); err != nil {
+		return nil, fmt.Errorf("failed to list resources: %w", err)
+	}
+	return result.Resources, nil
+}
+
+// ReadResource reads the contents of a resource on the server
+func (t *StdioTransport) ReadResource(ctx context.Context, uri string) ([]ResourceContent, error) {
+	var result ResourceReadResponse
+	if err := t.call(ctx, "resources/read", map[string]interface{}{"uri": uri}, &result); err != nil {
+		return nil, fmt.Errorf("failed to read resource %s: %w", uri, err)
+	}
+	return result.Contents, nil
+}
+
+// ListPrompts returns all prompts exposed by the server
+func (t *StdioTransport) ListPrompts(ctx context.Context) ([]Prompt, error) {
+	var result PromptsListResponse
+	if err := t.call(ctx, "prompts/list", map[string]interface{}{}, &result); err != nil {
+		return nil, fmt.Errorf("failed to list prompts: %w", err)
+	}
+	return result.Prompts, nil
+}
+
+// GetPrompt renders a prompt on the server
+func (t *StdioTransport) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*PromptResult, error) {
+	params := map[string]interface{}{
+		"name":      name,
+		"arguments": arguments,
+	}
+
+	var result PromptResult
+	if err := t.call(ctx, "prompts/get", params, &result); err != nil {
+		return nil, fmt.Errorf("failed to get prompt %s: %w", name, err)
+	}
+	return &result, nil
+}
+
+// Capabilities returns the capabilities the server advertised when it was
+// initialized, or nil before initialization
+func (t *StdioTransport) Capabilities() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.capabilities
+}
+
+// Close closes the server's stdin and waits briefly for it to exit before
+// killing it
+func (t *StdioTransport) Close() error {
+	t.mu.Lock()
+	cmd, stdin, exited := t.cmd, t.stdin, t.exited
+	t.stdin = nil
+	t.mu.Unlock()
+	if cmd == nil || stdin == nil {
+		return nil
+	}
+
+	stdin.Close()
+	select {
+	case <-exited:
+		return nil
+	case <-time.After(stdioCloseTimeout):
+	}
+
+	if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("failed to stop %s: %w", t.command, err)
+	}
+	<-exited
+	return nil
+}